@@ -0,0 +1,244 @@
+// Package grpcapi implements the yanzi.v1.IntentService gRPC service (see
+// proto/yanzi/v1/intent.proto) over a store. Register it with
+//
+//	yanziv1.RegisterIntentServiceServer(grpcServer, grpcapi.New(s))
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/chuxorg/chux-yanzi-core --go-grpc_out=../.. --go-grpc_opt=module=github.com/chuxorg/chux-yanzi-core yanzi/v1/intent.proto
+
+import (
+	"context"
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/chuxorg/chux-yanzi-core/api/grpc/yanziv1"
+	"github.com/chuxorg/chux-yanzi-core/api/internal/apistore"
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// Server implements yanziv1.IntentServiceServer for one store.
+type Server struct {
+	yanziv1.UnimplementedIntentServiceServer
+	store *store.Store
+}
+
+var _ yanziv1.IntentServiceServer = (*Server)(nil)
+
+// New returns a Server backed by s.
+func New(s *store.Store) *Server {
+	return &Server{store: s}
+}
+
+// Idempotency metadata for Create, mirroring the HTTP headers.
+const (
+	// MetadataIdempotencyKey makes retries of an append return the original
+	// record; see store.Store.AppendIntentWithKey.
+	MetadataIdempotencyKey = "idempotency-key"
+	// MetadataIdempotentReplayed is sent as a response header with the value
+	// "true" when a retried key is replayed.
+	MetadataIdempotentReplayed = "idempotent-replayed"
+)
+
+// Create stores an intent. An intent without a hash is appended to its chain,
+// and with idempotency-key metadata retries return the intent first appended;
+// one with a hash must verify and is stored as is, idempotently.
+func (s *Server) Create(ctx context.Context, req *yanziv1.CreateRequest) (*yanziv1.CreateResponse, error) {
+	if req.GetIntent() == nil {
+		return nil, status.Error(codes.InvalidArgument, "intent is required")
+	}
+	record := FromProto(req.GetIntent())
+
+	if record.Hash == "" {
+		if err := apistore.ValidateNew(record); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		var stored model.IntentRecord
+		var err error
+		if keys := metadata.ValueFromIncomingContext(ctx, MetadataIdempotencyKey); len(keys) > 0 && keys[0] != "" {
+			var replayed bool
+			stored, replayed, err = s.store.AppendIntentWithKey(ctx, keys[0], record)
+			if replayed {
+				_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataIdempotentReplayed, "true"))
+			}
+		} else {
+			stored, err = s.store.AppendIntent(ctx, record)
+		}
+		if err != nil {
+			return nil, storeError(err)
+		}
+		return &yanziv1.CreateResponse{Intent: ToProto(stored)}, nil
+	}
+
+	if err := record.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := hash.VerifyHash(record); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.store.CreateIntentIdempotent(ctx, record); err != nil {
+		return nil, storeError(err)
+	}
+	stored, err := s.store.GetIntent(ctx, record.ID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &yanziv1.CreateResponse{Intent: ToProto(stored)}, nil
+}
+
+// Get returns the intent with the requested ID.
+func (s *Server) Get(ctx context.Context, req *yanziv1.GetRequest) (*yanziv1.GetResponse, error) {
+	record, err := s.store.GetIntent(ctx, req.GetId())
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &yanziv1.GetResponse{Intent: ToProto(record)}, nil
+}
+
+// List streams intents oldest first, a page at a time.
+func (s *Server) List(req *yanziv1.ListRequest, stream yanziv1.IntentService_ListServer) error {
+	if req.GetLimit() < 0 {
+		return status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+	ctx := stream.Context()
+	remaining := int(req.GetLimit())
+	opts := store.PageOptions{Author: req.GetAuthor(), Cursor: req.GetCursor()}
+	for first := true; ; first = false {
+		page, err := s.store.ListIntentsPage(ctx, opts)
+		if err != nil {
+			if first && opts.Cursor != "" {
+				// Cursor errors are the only ones the caller can cause.
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			return storeError(err)
+		}
+		for _, record := range page.Intents {
+			if err := stream.Send(&yanziv1.ListResponse{Intent: ToProto(record), Cursor: store.PageCursor(record)}); err != nil {
+				return err
+			}
+			if remaining--; remaining == 0 {
+				return nil
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// VerifyChain verifies the chain named in the request or ending at its head.
+func (s *Server) VerifyChain(ctx context.Context, req *yanziv1.VerifyChainRequest) (*yanziv1.VerifyChainResponse, error) {
+	head := req.GetHead()
+	if name := req.GetChain(); name != "" {
+		if head != "" {
+			return nil, status.Error(codes.InvalidArgument, "chain and head are mutually exclusive")
+		}
+		var err error
+		if head, err = s.store.ChainHead(ctx, name); err != nil {
+			return nil, storeError(err)
+		}
+	}
+	if head == "" {
+		return nil, status.Error(codes.InvalidArgument, "chain or head is required")
+	}
+
+	report, err := chain.VerifyChainUntil(ctx, s.store, head, req.GetUntil())
+	if err != nil {
+		return nil, storeError(err)
+	}
+	resp := &yanziv1.VerifyChainResponse{
+		Head:    report.Head,
+		Valid:   report.Valid(),
+		Length:  int32(report.Length),
+		Genesis: report.Genesis,
+	}
+	for _, issue := range report.Issues {
+		resp.Issues = append(resp.Issues, &yanziv1.Issue{Kind: string(issue.Kind), Hash: issue.Hash, Id: issue.ID, Detail: issue.Detail})
+	}
+	return resp, nil
+}
+
+// storeError maps store errors to status codes as the HTTP API does.
+func storeError(err error) error {
+	switch apistore.Classify(err) {
+	case apistore.KindInvalid, apistore.KindRejected:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case apistore.KindNotFound:
+		return status.Error(codes.NotFound, "not found")
+	case apistore.KindDuplicate:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case apistore.KindReadOnly, apistore.KindChainBroken:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case apistore.KindForbidden:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case apistore.KindRateLimited:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.FromContextError(err).Err()
+	}
+}
+
+// ToProto converts a record to its wire form.
+func ToProto(record model.IntentRecord) *yanziv1.Intent {
+	intent := &yanziv1.Intent{
+		Id:           record.ID,
+		CreatedAt:    record.CreatedAt,
+		Author:       record.Author,
+		SourceType:   record.SourceType,
+		Title:        record.Title,
+		Prompt:       record.Prompt,
+		Response:     record.Response,
+		PrevHash:     record.PrevHash,
+		Hash:         record.Hash,
+		Tags:         record.Tags,
+		ThreadId:     record.ThreadID,
+		ParentId:     record.ParentID,
+		HashVersion:  int32(record.HashVersion),
+		Signature:    record.Signature,
+		PublicKey:    record.PublicKey,
+		TombstonedAt: record.TombstonedAt,
+	}
+	if !model.IsEmptyMeta(record.Meta) {
+		intent.Meta = string(record.Meta)
+	}
+	for _, a := range record.Attachments {
+		intent.Attachments = append(intent.Attachments, &yanziv1.Attachment{Digest: a.Digest, Name: a.Name, MediaType: a.MediaType, Size: a.Size})
+	}
+	return intent
+}
+
+// FromProto converts a wire intent to a record.
+func FromProto(intent *yanziv1.Intent) model.IntentRecord {
+	record := model.IntentRecord{
+		ID:           intent.GetId(),
+		CreatedAt:    intent.GetCreatedAt(),
+		Author:       intent.GetAuthor(),
+		SourceType:   intent.GetSourceType(),
+		Title:        intent.GetTitle(),
+		Prompt:       intent.GetPrompt(),
+		Response:     intent.GetResponse(),
+		PrevHash:     intent.GetPrevHash(),
+		Hash:         intent.GetHash(),
+		Tags:         intent.GetTags(),
+		ThreadID:     intent.GetThreadId(),
+		ParentID:     intent.GetParentId(),
+		HashVersion:  int(intent.GetHashVersion()),
+		Signature:    intent.GetSignature(),
+		PublicKey:    intent.GetPublicKey(),
+		TombstonedAt: intent.GetTombstonedAt(),
+	}
+	if intent.GetMeta() != "" {
+		record.Meta = json.RawMessage(intent.GetMeta())
+	}
+	for _, a := range intent.GetAttachments() {
+		record.Attachments = append(record.Attachments, model.Attachment{Digest: a.GetDigest(), Name: a.GetName(), MediaType: a.GetMediaType(), Size: a.GetSize()})
+	}
+	return record
+}
@@ -0,0 +1,193 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/chuxorg/chux-yanzi-core/api/grpc/yanziv1"
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func newTestClient(t *testing.T) yanziv1.IntentServiceClient {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	yanziv1.RegisterIntentServiceServer(srv, New(s))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return yanziv1.NewIntentServiceClient(conn)
+}
+
+func create(t *testing.T, client yanziv1.IntentServiceClient, author, prompt string) *yanziv1.Intent {
+	t.Helper()
+	resp, err := client.Create(context.Background(), &yanziv1.CreateRequest{Intent: &yanziv1.Intent{
+		Author: author, SourceType: "grpc", Prompt: prompt, Response: "r", Meta: `{"k":"v"}`,
+	}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return resp.GetIntent()
+}
+
+func TestCreateGetAndVerify(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	first := create(t, client, "alice", "p1")
+	second := create(t, client, "alice", "p2")
+	if second.GetPrevHash() != first.GetHash() || second.GetMeta() != `{"k":"v"}` {
+		t.Fatalf("expected a linked record with meta, got %+v", second)
+	}
+
+	got, err := client.Get(ctx, &yanziv1.GetRequest{Id: second.GetId()})
+	if err != nil || got.GetIntent().GetHash() != second.GetHash() {
+		t.Fatalf("expected the stored record, got %+v, %v", got, err)
+	}
+	if _, err := client.Get(ctx, &yanziv1.GetRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+
+	report, err := client.VerifyChain(ctx, &yanziv1.VerifyChainRequest{Chain: "alice"})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !report.GetValid() || report.GetLength() != 2 || report.GetHead() != second.GetHash() {
+		t.Fatalf("expected a valid 2-record chain, got %+v", report)
+	}
+	if _, err := client.VerifyChain(ctx, &yanziv1.VerifyChainRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestCreateHashedIntent(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	record := model.IntentRecord{
+		ID: "client-1", CreatedAt: "2026-02-09T10:00:00Z", Author: "alice", SourceType: "grpc", Prompt: "p", Response: "r",
+	}
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	record.Hash = sum
+
+	for range 2 {
+		if _, err := client.Create(ctx, &yanziv1.CreateRequest{Intent: ToProto(record)}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	record.Response = "other"
+	if _, err := client.Create(ctx, &yanziv1.CreateRequest{Intent: ToProto(record)}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a mismatched hash, got %v", err)
+	}
+	if record.Hash, err = hash.HashIntent(record); err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if _, err := client.Create(ctx, &yanziv1.CreateRequest{Intent: ToProto(record)}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists for a colliding ID, got %v", err)
+	}
+}
+
+func TestListStreams(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	for i, author := range []string{"alice", "bob", "alice", "alice"} {
+		create(t, client, author, string(rune('a'+i)))
+	}
+
+	collect := func(req *yanziv1.ListRequest) []*yanziv1.ListResponse {
+		t.Helper()
+		stream, err := client.List(ctx, req)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		var msgs []*yanziv1.ListResponse
+		for {
+			msg, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return msgs
+			}
+			if err != nil {
+				t.Fatalf("recv: %v", err)
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+
+	all := collect(&yanziv1.ListRequest{})
+	if len(all) != 4 {
+		t.Fatalf("expected 4 intents, got %d", len(all))
+	}
+	alice := collect(&yanziv1.ListRequest{Author: "alice", Limit: 2})
+	if len(alice) != 2 || alice[0].GetIntent().GetAuthor() != "alice" {
+		t.Fatalf("expected 2 of alice's intents, got %+v", alice)
+	}
+	rest := collect(&yanziv1.ListRequest{Author: "alice", Cursor: alice[1].GetCursor()})
+	if len(rest) != 1 || rest[0].GetIntent().GetId() != all[3].GetIntent().GetId() {
+		t.Fatalf("expected the cursor to resume after the second intent, got %+v", rest)
+	}
+
+	stream, err := client.List(ctx, &yanziv1.ListRequest{Cursor: "bogus"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a bad cursor, got %v", err)
+	}
+}
+
+func TestCreateWithIdempotencyKey(t *testing.T) {
+	client := newTestClient(t)
+	req := &yanziv1.CreateRequest{Intent: &yanziv1.Intent{Author: "alice", SourceType: "grpc", Prompt: "p", Response: "r"}}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataIdempotencyKey, "req-1")
+
+	first, err := client.Create(ctx, req)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	var header metadata.MD
+	again, err := client.Create(ctx, req, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if again.GetIntent().GetId() != first.GetIntent().GetId() {
+		t.Fatalf("expected the original intent %s, got %s", first.GetIntent().GetId(), again.GetIntent().GetId())
+	}
+	if got := header.Get(MetadataIdempotentReplayed); len(got) != 1 || got[0] != "true" {
+		t.Fatalf("expected the replayed header, got %v", header)
+	}
+
+	req.Intent.Prompt = "different"
+	if _, err := client.Create(ctx, req); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a reused key, got %v", err)
+	}
+}
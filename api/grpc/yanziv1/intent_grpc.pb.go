@@ -0,0 +1,259 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: yanzi/v1/intent.proto
+
+package yanziv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	IntentService_Create_FullMethodName      = "/yanzi.v1.IntentService/Create"
+	IntentService_Get_FullMethodName         = "/yanzi.v1.IntentService/Get"
+	IntentService_List_FullMethodName        = "/yanzi.v1.IntentService/List"
+	IntentService_VerifyChain_FullMethodName = "/yanzi.v1.IntentService/VerifyChain"
+)
+
+// IntentServiceClient is the client API for IntentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// IntentService records and reads intents. Error codes follow the HTTP API:
+// NOT_FOUND for missing records, ALREADY_EXISTS for an ID stored with a
+// different hash, and INVALID_ARGUMENT for malformed requests.
+type IntentServiceClient interface {
+	// Create stores an intent. An intent without a hash is appended to its
+	// chain, which fills id, created_at, prev_hash, and hash; an intent with a
+	// hash must verify and is stored as is, and re-sending it is a no-op.
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error)
+	// Get returns the intent with the given ID.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// List streams intents oldest first.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListResponse], error)
+	// VerifyChain verifies a chain by name or from a head hash.
+	VerifyChain(ctx context.Context, in *VerifyChainRequest, opts ...grpc.CallOption) (*VerifyChainResponse, error)
+}
+
+type intentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIntentServiceClient(cc grpc.ClientConnInterface) IntentServiceClient {
+	return &intentServiceClient{cc}
+}
+
+func (c *intentServiceClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateResponse)
+	err := c.cc.Invoke(ctx, IntentService_Create_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *intentServiceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, IntentService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *intentServiceClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IntentService_ServiceDesc.Streams[0], IntentService_List_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListRequest, ListResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IntentService_ListClient = grpc.ServerStreamingClient[ListResponse]
+
+func (c *intentServiceClient) VerifyChain(ctx context.Context, in *VerifyChainRequest, opts ...grpc.CallOption) (*VerifyChainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyChainResponse)
+	err := c.cc.Invoke(ctx, IntentService_VerifyChain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IntentServiceServer is the server API for IntentService service.
+// All implementations must embed UnimplementedIntentServiceServer
+// for forward compatibility.
+//
+// IntentService records and reads intents. Error codes follow the HTTP API:
+// NOT_FOUND for missing records, ALREADY_EXISTS for an ID stored with a
+// different hash, and INVALID_ARGUMENT for malformed requests.
+type IntentServiceServer interface {
+	// Create stores an intent. An intent without a hash is appended to its
+	// chain, which fills id, created_at, prev_hash, and hash; an intent with a
+	// hash must verify and is stored as is, and re-sending it is a no-op.
+	Create(context.Context, *CreateRequest) (*CreateResponse, error)
+	// Get returns the intent with the given ID.
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// List streams intents oldest first.
+	List(*ListRequest, grpc.ServerStreamingServer[ListResponse]) error
+	// VerifyChain verifies a chain by name or from a head hash.
+	VerifyChain(context.Context, *VerifyChainRequest) (*VerifyChainResponse, error)
+	mustEmbedUnimplementedIntentServiceServer()
+}
+
+// UnimplementedIntentServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIntentServiceServer struct{}
+
+func (UnimplementedIntentServiceServer) Create(context.Context, *CreateRequest) (*CreateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (UnimplementedIntentServiceServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedIntentServiceServer) List(*ListRequest, grpc.ServerStreamingServer[ListResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedIntentServiceServer) VerifyChain(context.Context, *VerifyChainRequest) (*VerifyChainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyChain not implemented")
+}
+func (UnimplementedIntentServiceServer) mustEmbedUnimplementedIntentServiceServer() {}
+func (UnimplementedIntentServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeIntentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IntentServiceServer will
+// result in compilation errors.
+type UnsafeIntentServiceServer interface {
+	mustEmbedUnimplementedIntentServiceServer()
+}
+
+func RegisterIntentServiceServer(s grpc.ServiceRegistrar, srv IntentServiceServer) {
+	// If the following call pancis, it indicates UnimplementedIntentServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IntentService_ServiceDesc, srv)
+}
+
+func _IntentService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntentServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IntentService_Create_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntentServiceServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IntentService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntentServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IntentService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntentServiceServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IntentService_List_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IntentServiceServer).List(m, &grpc.GenericServerStream[ListRequest, ListResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IntentService_ListServer = grpc.ServerStreamingServer[ListResponse]
+
+func _IntentService_VerifyChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyChainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IntentServiceServer).VerifyChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IntentService_VerifyChain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IntentServiceServer).VerifyChain(ctx, req.(*VerifyChainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IntentService_ServiceDesc is the grpc.ServiceDesc for IntentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IntentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "yanzi.v1.IntentService",
+	HandlerType: (*IntentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _IntentService_Create_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _IntentService_Get_Handler,
+		},
+		{
+			MethodName: "VerifyChain",
+			Handler:    _IntentService_VerifyChain_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "List",
+			Handler:       _IntentService_List_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "yanzi/v1/intent.proto",
+}
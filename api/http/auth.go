@@ -0,0 +1,392 @@
+package httpapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for crypto.Hash.New
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope is a permission granted to a caller. Routes require at most one.
+type Scope string
+
+const (
+	// ScopeRead allows fetching and listing intents.
+	ScopeRead Scope = "read"
+	// ScopeWrite allows creating intents.
+	ScopeWrite Scope = "write"
+	// ScopeVerify allows verifying chains.
+	ScopeVerify Scope = "verify"
+)
+
+// HeaderAPIKey carries a static API key. Keys are also accepted as bearer
+// tokens.
+const HeaderAPIKey = "X-API-Key"
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request
+	// carries no credentials it recognizes, so the next one is tried.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned for credentials that are malformed,
+	// unknown, expired, or fail verification.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	// Subject identifies the caller: the API key's name or the token's sub.
+	Subject string
+	Scopes  []Scope
+}
+
+// Has reports whether p was granted scope. Scopes do not imply one another.
+func (p Principal) Has(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// PrincipalFromContext returns the caller authenticated for the request whose
+// context is ctx. It reports false when the server has no authenticators.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Authenticator identifies the caller of a request.
+type Authenticator interface {
+	// Authenticate returns the caller, ErrNoCredentials if the request has
+	// no credentials of this kind, or an error wrapping
+	// ErrInvalidCredentials.
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// WithAuthenticators requires every route except the health checks and the
+// OpenAPI document to be called with credentials accepted by one of auths,
+// granting the route's scope. They are tried in order. Without
+// authenticators the API is open, which is only suitable behind a proxy that
+// authenticates.
+func WithAuthenticators(auths ...Authenticator) Option {
+	return func(s *Server) {
+		s.authenticators = append(s.authenticators, auths...)
+	}
+}
+
+// requireScope wraps next so it runs only for callers granted scope. Routes
+// with no scope, and servers with no authenticators, are not checked.
+func (s *Server) requireScope(scope Scope, next http.HandlerFunc) http.HandlerFunc {
+	if scope == "" || len(s.authenticators) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="yanzi"`)
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+		if !p.Has(scope) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("%s scope required", scope))
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	}
+}
+
+func (s *Server) authenticate(r *http.Request) (Principal, error) {
+	for _, a := range s.authenticators {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return Principal{}, errors.New("credentials required")
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// APIKey is a static key and the principal it authenticates.
+type APIKey struct {
+	Key     string
+	Subject string
+	Scopes  []Scope
+}
+
+// APIKeys authenticates static keys sent in the X-API-Key header or as bearer
+// tokens. Keys are held as SHA-256 digests and looked up by digest, so
+// lookups do not leak key prefixes through timing.
+type APIKeys struct {
+	keys map[[sha256.Size]byte]Principal
+}
+
+var _ Authenticator = (*APIKeys)(nil)
+
+// NewAPIKeys returns an authenticator for keys. Empty keys are ignored.
+func NewAPIKeys(keys ...APIKey) *APIKeys {
+	a := &APIKeys{keys: make(map[[sha256.Size]byte]Principal, len(keys))}
+	for _, k := range keys {
+		if k.Key == "" {
+			continue
+		}
+		a.keys[sha256.Sum256([]byte(k.Key))] = Principal{Subject: k.Subject, Scopes: slices.Clone(k.Scopes)}
+	}
+	return a
+}
+
+// Authenticate looks up the request's key. An unknown bearer token is left to
+// the next authenticator, since it may be a JWT; an unknown X-API-Key is
+// rejected.
+func (a *APIKeys) Authenticate(r *http.Request) (Principal, error) {
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		if p, ok := a.keys[sha256.Sum256([]byte(key))]; ok {
+			return p, nil
+		}
+		return Principal{}, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+	if token, ok := bearerToken(r); ok {
+		if p, ok := a.keys[sha256.Sum256([]byte(token))]; ok {
+			return p, nil
+		}
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+// JWTConfig configures JWT bearer token validation.
+type JWTConfig struct {
+	// Keys maps a key ID (the token header's kid) to its verification key;
+	// the "" entry verifies tokens without a kid. A key is a []byte HMAC
+	// secret (HS256, HS384, HS512), an *rsa.PublicKey (RS256, RS384, RS512),
+	// an *ecdsa.PublicKey (ES256 on P-256, ES384 on P-384, ES512 on P-521),
+	// or an ed25519.PublicKey (EdDSA). A token's alg must match its key's
+	// type and, for ECDSA, its curve.
+	Keys map[string]any
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// JWT authenticates signed JSON Web Tokens sent as bearer tokens. Tokens must
+// carry exp. The principal's Subject is the sub claim and its scopes come
+// from the space-separated scope claim or the scp array.
+type JWT struct {
+	cfg JWTConfig
+	now func() time.Time
+}
+
+var _ Authenticator = (*JWT)(nil)
+
+// NewJWT returns a JWT authenticator, rejecting unsupported key types.
+func NewJWT(cfg JWTConfig) (*JWT, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("jwt: at least one key is required")
+	}
+	for kid, key := range cfg.Keys {
+		switch key := key.(type) {
+		case []byte:
+			if len(key) == 0 {
+				return nil, fmt.Errorf("jwt: key %q: empty secret", kid)
+			}
+		case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		default:
+			return nil, fmt.Errorf("jwt: key %q: unsupported type %T", kid, key)
+		}
+	}
+	return &JWT{cfg: cfg, now: time.Now}, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scp       json.RawMessage `json:"scp"`
+}
+
+// Authenticate validates the request's bearer token. Bearer tokens that are
+// not shaped like a JWT are left to the next authenticator.
+func (j *JWT) Authenticate(r *http.Request) (Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return Principal{}, ErrNoCredentials
+	}
+	p, err := j.parse(token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return p, nil
+}
+
+func (j *JWT) parse(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("header: %w", err)
+	}
+	key, ok := j.cfg.Keys[header.Kid]
+	if !ok {
+		return Principal{}, fmt.Errorf("unknown key %q", header.Kid)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("signature: %w", err)
+	}
+	if err := verifyJWS(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return Principal{}, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("claims: %w", err)
+	}
+	now := j.now()
+	if claims.ExpiresAt == nil {
+		return Principal{}, errors.New("token has no exp")
+	}
+	if now.After(numericDate(*claims.ExpiresAt).Add(j.cfg.Leeway)) {
+		return Principal{}, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(j.cfg.Leeway).Before(numericDate(*claims.NotBefore)) {
+		return Principal{}, errors.New("token not yet valid")
+	}
+	if j.cfg.Issuer != "" && claims.Issuer != j.cfg.Issuer {
+		return Principal{}, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if j.cfg.Audience != "" && !slices.Contains(stringOrList(claims.Audience), j.cfg.Audience) {
+		return Principal{}, errors.New("token is not for this audience")
+	}
+
+	p := Principal{Subject: claims.Subject}
+	for _, s := range append(strings.Fields(claims.Scope), stringOrList(claims.Scp)...) {
+		p.Scopes = append(p.Scopes, Scope(s))
+	}
+	return p, nil
+}
+
+func decodeSegment(seg string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func numericDate(v float64) time.Time {
+	return time.Unix(0, int64(v*float64(time.Second)))
+}
+
+// stringOrList decodes a claim that is either a string or an array of them.
+func stringOrList(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return []string{one}
+	}
+	var many []string
+	_ = json.Unmarshal(raw, &many)
+	return many
+}
+
+// jwsHashes maps each supported alg to its key family and digest, and for
+// ECDSA the curve the alg is defined over.
+var jwsHashes = map[string]struct {
+	family string
+	hash   crypto.Hash
+	curve  elliptic.Curve
+}{
+	"HS256": {"HS", crypto.SHA256, nil}, "HS384": {"HS", crypto.SHA384, nil}, "HS512": {"HS", crypto.SHA512, nil},
+	"RS256": {"RS", crypto.SHA256, nil}, "RS384": {"RS", crypto.SHA384, nil}, "RS512": {"RS", crypto.SHA512, nil},
+	"ES256": {"ES", crypto.SHA256, elliptic.P256()}, "ES384": {"ES", crypto.SHA384, elliptic.P384()}, "ES512": {"ES", crypto.SHA512, elliptic.P521()},
+	"EdDSA": {"EdDSA", 0, nil},
+}
+
+// verifyJWS checks sig over signingInput with key under alg, refusing
+// algorithms that do not match the key's type or, for ECDSA, its curve.
+func verifyJWS(alg string, key any, signingInput string, sig []byte) error {
+	spec, ok := jwsHashes[alg]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if spec.family != keyFamily(key) {
+		return fmt.Errorf("algorithm %q does not match the key", alg)
+	}
+	if key, ok := key.(*ecdsa.PublicKey); ok && key.Curve.Params().Name != spec.curve.Params().Name {
+		return fmt.Errorf("algorithm %q does not match the key's curve %s", alg, key.Curve.Params().Name)
+	}
+	var digest []byte
+	if spec.hash != 0 {
+		h := spec.hash.New()
+		h.Write([]byte(signingInput))
+		digest = h.Sum(nil)
+	}
+
+	var valid bool
+	switch key := key.(type) {
+	case []byte:
+		m := hmac.New(spec.hash.New, key)
+		m.Write([]byte(signingInput))
+		valid = hmac.Equal(m.Sum(nil), sig)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, spec.hash, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) == 2*size {
+			r := new(big.Int).SetBytes(sig[:size])
+			s := new(big.Int).SetBytes(sig[size:])
+			valid = ecdsa.Verify(key, digest, r, s)
+		}
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, []byte(signingInput), sig)
+	}
+	if !valid {
+		return errors.New("bad signature")
+	}
+	return nil
+}
+
+func keyFamily(key any) string {
+	switch key.(type) {
+	case []byte:
+		return "HS"
+	case *rsa.PublicKey:
+		return "RS"
+	case *ecdsa.PublicKey:
+		return "ES"
+	case ed25519.PublicKey:
+		return "EdDSA"
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func newAuthServer(t *testing.T, auths ...Authenticator) *httptest.Server {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	srv := httptest.NewServer(New(s, WithAuthenticators(auths...)))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// send issues a request with the given header pairs and returns the status.
+func send(t *testing.T, method, url string, body any, headers ...string) *http.Response {
+	t.Helper()
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// signJWT returns a compact JWS over claims, signed by sign under alg.
+func signJWT(t *testing.T, alg, kid string, claims map[string]any, sign func([]byte) []byte) string {
+	t.Helper()
+	header := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	var parts []string
+	for _, v := range []any{header, claims} {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		parts = append(parts, base64.RawURLEncoding.EncodeToString(raw))
+	}
+	input := strings.Join(parts, ".")
+	return input + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(input)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(input []byte) []byte {
+		m := hmac.New(sha256.New, secret)
+		m.Write(input)
+		return m.Sum(nil)
+	}
+}
+
+// es256 signs with key over a SHA-256 digest, encoding r||s at the curve's
+// size, whatever the curve.
+func es256(t *testing.T, key *ecdsa.PrivateKey) func([]byte) []byte {
+	return func(input []byte) []byte {
+		digest := sha256.Sum256(input)
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	srv := newAuthServer(t, NewAPIKeys(
+		APIKey{Key: "reader-key", Subject: "dashboard", Scopes: []Scope{ScopeRead}},
+		APIKey{Key: "writer-key", Subject: "agent", Scopes: []Scope{ScopeWrite}},
+	))
+	input := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+
+	resp := send(t, "POST", srv.URL+"/intents", input)
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected 401 with a challenge, got %d", resp.StatusCode)
+	}
+	if resp := send(t, "POST", srv.URL+"/intents", input, HeaderAPIKey, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unknown key, got %d", resp.StatusCode)
+	}
+	if resp := send(t, "POST", srv.URL+"/intents", input, HeaderAPIKey, "reader-key"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without write scope, got %d", resp.StatusCode)
+	}
+	if resp := send(t, "POST", srv.URL+"/intents", input, HeaderAPIKey, "writer-key"); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+	if resp := send(t, "GET", srv.URL+"/intents", nil, "Authorization", "Bearer reader-key"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for a bearer key, got %d", resp.StatusCode)
+	}
+	if resp := send(t, "GET", srv.URL+"/intents", nil, HeaderAPIKey, "writer-key"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 since write does not imply read, got %d", resp.StatusCode)
+	}
+	for _, path := range []string{"/healthz", "/openapi.json"} {
+		if resp := send(t, "GET", srv.URL+path, nil); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected %s to stay open, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestIdempotencyKeysScopedPerPrincipal(t *testing.T) {
+	srv := newAuthServer(t, NewAPIKeys(
+		APIKey{Key: "first-key", Subject: "first", Scopes: []Scope{ScopeWrite}},
+		APIKey{Key: "second-key", Subject: "second", Scopes: []Scope{ScopeWrite}},
+	))
+	input := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+
+	for _, tc := range []struct {
+		key      string
+		replayed string
+	}{
+		{"first-key", ""},
+		{"second-key", ""},
+		{"first-key", "true"},
+	} {
+		resp := send(t, "POST", srv.URL+"/intents", input, HeaderAPIKey, tc.key, HeaderIdempotencyKey, "req")
+		if resp.StatusCode != http.StatusCreated || resp.Header.Get(HeaderIdempotentReplayed) != tc.replayed {
+			t.Fatalf("%s: expected 201 with replayed %q, got %d %q",
+				tc.key, tc.replayed, resp.StatusCode, resp.Header.Get(HeaderIdempotentReplayed))
+		}
+	}
+}
+
+func TestJWT(t *testing.T) {
+	secret := []byte("shared-secret")
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	p521, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	jwt, err := NewJWT(JWTConfig{
+		Keys:     map[string]any{"": secret, "ed": pub, "p256": &p256.PublicKey, "p521": &p521.PublicKey},
+		Issuer:   "https://idp.example",
+		Audience: "yanzi",
+	})
+	if err != nil {
+		t.Fatalf("new jwt: %v", err)
+	}
+	// API keys come first, so bearer JWTs must fall through them.
+	srv := newAuthServer(t, NewAPIKeys(APIKey{Key: "k", Scopes: []Scope{ScopeRead}}), jwt)
+
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{
+			"sub": "ci", "iss": "https://idp.example", "aud": []string{"other", "yanzi"},
+			"exp": time.Now().Add(time.Minute).Unix(), "scope": "read verify",
+		}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+	edSign := func(input []byte) []byte { return ed25519.Sign(priv, input) }
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"hmac", signJWT(t, "HS256", "", claims(nil), hs256(secret)), http.StatusOK},
+		{"eddsa with scp", signJWT(t, "EdDSA", "ed", claims(map[string]any{"scope": "", "scp": []string{"read"}}), edSign), http.StatusOK},
+		{"missing scope", signJWT(t, "HS256", "", claims(map[string]any{"scope": "verify"}), hs256(secret)), http.StatusForbidden},
+		{"expired", signJWT(t, "HS256", "", claims(map[string]any{"exp": time.Now().Add(-time.Minute).Unix()}), hs256(secret)), http.StatusUnauthorized},
+		{"no exp", signJWT(t, "HS256", "", claims(map[string]any{"exp": nil}), hs256(secret)), http.StatusUnauthorized},
+		{"not yet valid", signJWT(t, "HS256", "", claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()}), hs256(secret)), http.StatusUnauthorized},
+		{"wrong audience", signJWT(t, "HS256", "", claims(map[string]any{"aud": "other"}), hs256(secret)), http.StatusUnauthorized},
+		{"wrong issuer", signJWT(t, "HS256", "", claims(map[string]any{"iss": "https://evil.example"}), hs256(secret)), http.StatusUnauthorized},
+		{"bad signature", signJWT(t, "HS256", "", claims(nil), hs256([]byte("guess"))), http.StatusUnauthorized},
+		{"unknown kid", signJWT(t, "HS256", "nope", claims(nil), hs256(secret)), http.StatusUnauthorized},
+		// An HMAC token keyed with the public key bytes must not verify
+		// against the Ed25519 key.
+		{"alg confusion", signJWT(t, "HS256", "ed", claims(nil), hs256(pub)), http.StatusUnauthorized},
+		{"alg none", signJWT(t, "none", "", claims(nil), func([]byte) []byte { return nil }), http.StatusUnauthorized},
+		{"ecdsa", signJWT(t, "ES256", "p256", claims(nil), es256(t, p256)), http.StatusOK},
+		// ES256 is defined over P-256 only.
+		{"ecdsa wrong curve", signJWT(t, "ES256", "p521", claims(nil), es256(t, p521)), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := send(t, "GET", srv.URL+"/intents", nil, "Authorization", "Bearer "+tt.token)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNewJWTRejectsBadKeys(t *testing.T) {
+	for _, keys := range []map[string]any{nil, {"": []byte{}}, {"": "a string"}} {
+		if _, err := NewJWT(JWTConfig{Keys: keys}); err == nil {
+			t.Errorf("expected an error for keys %v", keys)
+		}
+	}
+}
+
+func TestPrincipalInContext(t *testing.T) {
+	keys := NewAPIKeys(APIKey{Key: "k", Subject: "agent", Scopes: []Scope{ScopeRead}})
+	srv := &Server{authenticators: []Authenticator{keys}}
+	var got Principal
+	h := srv.requireScope(ScopeRead, func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+	})
+	req := httptest.NewRequest("GET", "/intents", nil)
+	req.Header.Set(HeaderAPIKey, "k")
+	h(httptest.NewRecorder(), req)
+	if got.Subject != "agent" || !got.Has(ScopeRead) {
+		t.Fatalf("expected the agent principal, got %+v", got)
+	}
+}
+
+func TestOpenAPIDocumentsSecurity(t *testing.T) {
+	doc := (&Server{authenticators: []Authenticator{NewAPIKeys()}}).OpenAPI()
+	schemes := doc["components"].(map[string]any)["securitySchemes"].(map[string]any)
+	if schemes["apiKey"] == nil || schemes["bearerAuth"] == nil {
+		t.Fatalf("expected security schemes, got %v", schemes)
+	}
+	paths := doc["paths"].(map[string]any)
+	create := paths["/intents"].(map[string]any)["post"].(map[string]any)
+	if create["security"] == nil || create["responses"].(map[string]any)["401"] == nil {
+		t.Fatalf("expected createIntent to require credentials, got %v", create)
+	}
+	if health := paths["/healthz"].(map[string]any)["get"].(map[string]any); health["security"] != nil {
+		t.Fatalf("expected healthz to stay open, got %v", health["security"])
+	}
+	if _, ok := (&Server{}).OpenAPI()["components"].(map[string]any)["securitySchemes"]; ok {
+		t.Fatal("expected no security schemes without authenticators")
+	}
+}
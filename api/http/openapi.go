@@ -0,0 +1,196 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIVersion is the OpenAPI version of the generated document.
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPI returns the OpenAPI document describing the server's routes. It is
+// generated from the route table and the Go types the handlers encode.
+func (s *Server) OpenAPI() map[string]any {
+	schemas := make(map[string]any)
+	paths := make(map[string]any)
+	for _, rt := range s.routes() {
+		item, _ := paths[rt.path].(map[string]any)
+		if item == nil {
+			item = make(map[string]any)
+			paths[rt.path] = item
+		}
+		doc := operationDoc(rt.op, schemas)
+		if rt.scope != "" && len(s.authenticators) > 0 {
+			securityDoc(doc, rt.scope, schemas)
+		}
+		item[strings.ToLower(rt.method)] = doc
+	}
+	components := map[string]any{"schemas": schemas}
+	if len(s.authenticators) > 0 {
+		components["securitySchemes"] = map[string]any{
+			"apiKey":     map[string]any{"type": "apiKey", "in": "header", "name": HeaderAPIKey},
+			"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "description": "An API key or a JWT."},
+		}
+	}
+	return map[string]any{
+		"openapi": OpenAPIVersion,
+		"info": map[string]any{
+			"title":   "Yanzi intent API",
+			"version": "1",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+func (s *Server) serveOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	s.openAPIOnce.Do(func() {
+		s.openAPIJSON, s.openAPIErr = json.Marshal(s.OpenAPI())
+	})
+	if s.openAPIErr != nil {
+		writeError(w, http.StatusInternalServerError, s.openAPIErr)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(s.openAPIJSON)
+}
+
+func operationDoc(op operation, schemas map[string]any) map[string]any {
+	doc := map[string]any{"operationId": op.id, "summary": op.summary}
+	if op.description != "" {
+		doc["description"] = op.description
+	}
+	if len(op.params) > 0 {
+		params := make([]any, 0, len(op.params))
+		for _, p := range op.params {
+			pd := map[string]any{"name": p.name, "in": p.in, "schema": map[string]any{"type": p.typ}}
+			if p.required {
+				pd["required"] = true
+			}
+			if p.description != "" {
+				pd["description"] = p.description
+			}
+			params = append(params, pd)
+		}
+		doc["parameters"] = params
+	}
+	if op.body != nil {
+		var content map[string]any
+		if op.bodySchema == "" {
+			content = jsonContent(op.body, schemas)
+		} else {
+			variant := structSchema(reflect.TypeOf(op.body), schemas)
+			variant["required"] = op.bodyRequired
+			schemas[op.bodySchema] = variant
+			content = map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/" + op.bodySchema}}}
+		}
+		doc["requestBody"] = map[string]any{"required": true, "content": content}
+	}
+	responses := make(map[string]any, len(op.responses))
+	for _, r := range op.responses {
+		rd := map[string]any{"description": r.description}
+		if r.body != nil {
+			rd["content"] = jsonContent(r.body, schemas)
+		}
+		responses[strconv.Itoa(r.status)] = rd
+	}
+	doc["responses"] = responses
+	return doc
+}
+
+// securityDoc marks an operation as requiring scope and documents the
+// authentication failures.
+func securityDoc(doc map[string]any, scope Scope, schemas map[string]any) {
+	doc["security"] = []any{map[string]any{"apiKey": []any{}}, map[string]any{"bearerAuth": []any{}}}
+	description, _ := doc["description"].(string)
+	doc["description"] = strings.TrimSpace(description + " Requires the " + string(scope) + " scope.")
+	responses := doc["responses"].(map[string]any)
+	responses[strconv.Itoa(http.StatusUnauthorized)] = map[string]any{
+		"description": "Missing or invalid credentials.",
+		"content":     jsonContent(errorResponse{}, schemas),
+	}
+	if _, ok := responses[strconv.Itoa(http.StatusForbidden)]; !ok {
+		responses[strconv.Itoa(http.StatusForbidden)] = map[string]any{
+			"description": "The caller lacks the " + string(scope) + " scope.",
+			"content":     jsonContent(errorResponse{}, schemas),
+		}
+	}
+}
+
+func jsonContent(body any, schemas map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schemaFor(reflect.TypeOf(body), schemas)}}
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// schemaFor returns the schema of t as encoding/json would encode it. Named
+// structs are added to schemas and referenced.
+func schemaFor(t reflect.Type, schemas map[string]any) map[string]any {
+	if t == rawMessageType {
+		return map[string]any{"type": "object", "additionalProperties": true}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem(), schemas)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = nil // reserve the name for recursive types
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// schemaName exports the Go type name: listResponse becomes ListResponse.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = schemaFor(f.Type, schemas)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
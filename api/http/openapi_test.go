@@ -0,0 +1,91 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func fetchOpenAPI(t *testing.T) map[string]any {
+	t.Helper()
+	_, srv := newTestServer(t)
+	resp, err := http.Get(srv.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("get openapi: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var doc map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	doc := fetchOpenAPI(t)
+	if doc["openapi"] != OpenAPIVersion {
+		t.Fatalf("expected openapi %s, got %v", OpenAPIVersion, doc["openapi"])
+	}
+	paths := doc["paths"].(map[string]any)
+	for _, rt := range (&Server{}).routes() {
+		item, ok := paths[rt.path].(map[string]any)
+		if !ok || item[strings.ToLower(rt.method)] == nil {
+			t.Errorf("missing %s %s", rt.method, rt.path)
+		}
+	}
+}
+
+func TestOpenAPIIntentSchema(t *testing.T) {
+	doc := fetchOpenAPI(t)
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	record := schemas["IntentRecord"].(map[string]any)
+	properties := record["properties"].(map[string]any)
+	rt := reflect.TypeOf(model.IntentRecord{})
+	for i := 0; i < rt.NumField(); i++ {
+		name, _, _ := strings.Cut(rt.Field(i).Tag.Get("json"), ",")
+		if properties[name] == nil {
+			t.Errorf("IntentRecord schema is missing %s", name)
+		}
+	}
+	required := record["required"].([]any)
+	if !slices.Contains(required, any("hash")) || slices.Contains(required, any("title")) {
+		t.Errorf("unexpected required properties %v", required)
+	}
+	input := schemas["IntentInput"].(map[string]any)
+	if slices.Contains(input["required"].([]any), any("hash")) {
+		t.Errorf("expected hash to be optional on input, got %v", input["required"])
+	}
+	if _, ok := schemas["ErrorResponse"]; !ok {
+		t.Error("expected the error model in the schemas")
+	}
+
+	// Every reference resolves.
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				if schemas[strings.TrimPrefix(ref, "#/components/schemas/")] == nil {
+					t.Errorf("dangling reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
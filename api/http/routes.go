@@ -0,0 +1,191 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// route is one API endpoint. The OpenAPI document is generated from the same
+// table the mux is built from, so the two cannot drift apart.
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	// scope is required of callers when the server authenticates; routes
+	// without one are open.
+	scope Scope
+	op    operation
+}
+
+// operation describes a route for the OpenAPI document. Request and response
+// bodies are given as zero values of the Go types the handler encodes.
+type operation struct {
+	id          string
+	summary     string
+	description string
+	params      []param
+	body        any
+	// bodySchema, if set, names a variant of body's schema whose required
+	// properties are bodyRequired, for requests that omit server-filled fields.
+	bodySchema   string
+	bodyRequired []string
+	responses    []response
+}
+
+type param struct {
+	name        string
+	in          string // "path", "query", or "header"
+	typ         string // OpenAPI primitive type
+	required    bool
+	description string
+}
+
+type response struct {
+	status      int
+	description string
+	body        any
+}
+
+// errorResponses are the error statuses shared by every route.
+var errorResponses = []response{
+	{http.StatusInternalServerError, "Store failure.", errorResponse{}},
+	{http.StatusServiceUnavailable, "Request cancelled or timed out.", errorResponse{}},
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{
+			method: http.MethodPost, path: "/intents", handler: s.createIntent, scope: ScopeWrite,
+			op: operation{
+				id:      "createIntent",
+				summary: "Create an intent",
+				description: "An intent without a hash is appended to its author's chain, which fills id, " +
+					"created_at, prev_hash, and hash. An intent with a hash must verify and is stored as is; " +
+					"re-posting it is a no-op.",
+				params: []param{
+					{name: HeaderIdempotencyKey, in: "header", typ: "string",
+						description: "Makes retries of an append return the record first stored, for 24 hours by default."},
+				},
+				body:         model.IntentRecord{},
+				bodySchema:   "IntentInput",
+				bodyRequired: []string{"author", "source_type", "prompt", "response"},
+				responses: append([]response{
+					{http.StatusCreated, "The stored intent.", model.IntentRecord{}},
+					{http.StatusBadRequest, "Malformed or invalid intent.", errorResponse{}},
+					{http.StatusConflict, "The ID is stored with a different hash.", errorResponse{}},
+					{http.StatusUnprocessableEntity, "The idempotency key was used for a different intent, or created_at is too far behind the chain head.", errorResponse{}},
+					{http.StatusForbidden, "The store is read-only.", errorResponse{}},
+					{http.StatusTooManyRequests, "A write rate limit was exceeded; see Retry-After.", errorResponse{}},
+				}, errorResponses...),
+			},
+		},
+		{
+			method: http.MethodGet, path: "/intents/{id}", handler: s.getIntent, scope: ScopeRead,
+			op: operation{
+				id:      "getIntent",
+				summary: "Get an intent by ID",
+				params:  []param{{name: "id", in: "path", typ: "string", required: true}},
+				responses: append([]response{
+					{http.StatusOK, "The intent.", model.IntentRecord{}},
+					{http.StatusNotFound, "No intent has this ID.", errorResponse{}},
+				}, errorResponses...),
+			},
+		},
+		{
+			method: http.MethodGet, path: "/intents/{id}/duplicates", handler: s.nearDuplicates, scope: ScopeRead,
+			op: operation{
+				id:      "nearDuplicates",
+				summary: "List near-duplicates of an intent",
+				description: "Stored intents whose prompt and response are near-duplicates of this intent's, " +
+					"nearest first. Only intents fingerprinted by the store's duplicate detection are candidates.",
+				params: []param{
+					{name: "id", in: "path", typ: "string", required: true},
+					{name: "limit", in: "query", typ: "integer", description: "Maximum candidates; the server clamps it."},
+				},
+				responses: append([]response{
+					{http.StatusOK, "The candidates.", duplicatesResponse{}},
+					{http.StatusBadRequest, "Invalid limit.", errorResponse{}},
+					{http.StatusNotFound, "No intent has this ID.", errorResponse{}},
+				}, errorResponses...),
+			},
+		},
+		{
+			method: http.MethodGet, path: "/intents", handler: s.listIntents, scope: ScopeRead,
+			op: operation{
+				id:      "listIntents",
+				summary: "List intents oldest first, one page at a time",
+				params: []param{
+					{name: "author", in: "query", typ: "string", description: "Only this author's intents."},
+					{name: "cursor", in: "query", typ: "string", description: "next_cursor of the previous page."},
+					{name: "limit", in: "query", typ: "integer", description: "Page size; the server clamps it."},
+				},
+				responses: append([]response{
+					{http.StatusOK, "A page of intents.", listResponse{}},
+					{http.StatusBadRequest, "Invalid cursor or limit.", errorResponse{}},
+				}, errorResponses...),
+			},
+		},
+		{
+			method: http.MethodGet, path: "/chain/verify", handler: s.verifyChain, scope: ScopeVerify,
+			op: operation{
+				id:          "verifyChain",
+				summary:     "Verify a chain",
+				description: "Verifies without changing the store; POST records the run.",
+				params: []param{
+					{name: "chain", in: "query", typ: "string", description: "Chain name; by default an author."},
+					{name: "head", in: "query", typ: "string", description: "Head hash; exclusive with chain."},
+					{name: "until", in: "query", typ: "string", description: "Trusted hash to stop at."},
+				},
+				responses: append([]response{
+					{http.StatusOK, "The verification report.", verifyResponse{}},
+					{http.StatusBadRequest, "Neither or both of chain and head were given.", errorResponse{}},
+					{http.StatusNotFound, "The chain is not tracked.", errorResponse{}},
+				}, errorResponses...),
+			},
+		},
+		{
+			method: http.MethodPost, path: "/chain/verify", handler: s.verifyChain, scope: ScopeVerify,
+			op: operation{
+				id:          "recordChainVerification",
+				summary:     "Verify a chain and record the run",
+				description: "Verifies as GET does and records the run, which the health report shows as last_verified_at.",
+				params: []param{
+					{name: "chain", in: "query", typ: "string", description: "Chain name; by default an author."},
+					{name: "head", in: "query", typ: "string", description: "Head hash; exclusive with chain."},
+					{name: "until", in: "query", typ: "string", description: "Trusted hash to stop at."},
+				},
+				responses: append([]response{
+					{http.StatusOK, "The verification report.", verifyResponse{}},
+					{http.StatusBadRequest, "Neither or both of chain and head were given.", errorResponse{}},
+					{http.StatusNotFound, "The chain is not tracked.", errorResponse{}},
+				}, errorResponses...),
+			},
+		},
+		{
+			method: http.MethodGet, path: "/healthz", handler: s.healthz,
+			op: operation{
+				id:          "healthz",
+				summary:     "Check liveness",
+				description: "Succeeds while the database is reachable.",
+				responses: []response{
+					{http.StatusOK, "The health report.", store.Health{}},
+					{http.StatusServiceUnavailable, "The database is unreachable.", store.Health{}},
+				},
+			},
+		},
+		{
+			method: http.MethodGet, path: "/readyz", handler: s.readyz,
+			op: operation{
+				id:          "readyz",
+				summary:     "Check readiness",
+				description: "Succeeds once the database is reachable and has no pending migrations.",
+				responses: []response{
+					{http.StatusOK, "The health report.", store.Health{}},
+					{http.StatusServiceUnavailable, "The store is not ready.", store.Health{}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,381 @@
+// Package httpapi exposes a store over a JSON REST API. Server is an
+// http.Handler, so it can be mounted on any mux or wrapped in middleware:
+//
+//	POST /intents               create an intent (201)
+//	GET  /intents/{id}          fetch an intent
+//	GET  /intents?author=&cursor=&limit=
+//	                            list intents oldest first, one page at a time
+//	GET  /chain/verify?chain=   verify a chain by name, or ?head=<hash>
+//	POST /chain/verify?chain=   the same, recording the run for /healthz
+//	GET  /healthz               the store's health report; 503 if unreachable
+//	GET  /readyz                the same; 503 until migrated
+//	GET  /openapi.json          the OpenAPI 3 description of the above
+//
+// Errors are returned as {"error": "..."} with a matching status code.
+//
+// With WithAuthenticators, callers present a static API key (X-API-Key or a
+// bearer token; see NewAPIKeys) or a signed JWT (see NewJWT), and each route
+// requires a scope: read for GET /intents, write for POST /intents, and
+// verify for both /chain/verify methods. Missing or invalid credentials are 401 and a
+// missing scope 403. The health checks and OpenAPI document stay open.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/chuxorg/chux-yanzi-core/api/internal/apistore"
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// DefaultMaxBodyBytes bounds request bodies unless overridden with
+// WithMaxBodyBytes.
+const DefaultMaxBodyBytes = 8 << 20
+
+// Idempotency headers for POST /intents.
+const (
+	// HeaderIdempotencyKey makes retries of an append return the original
+	// record; see store.Store.AppendIntentWithKey. Keys are scoped to the
+	// authenticated principal as well as the record's author.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set to "true" on a response replayed for a
+	// retried key.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithMaxBodyBytes sets the largest request body the server reads; values <= 0
+// keep the default.
+func WithMaxBodyBytes(n int64) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxBodyBytes = n
+		}
+	}
+}
+
+// Server serves the REST API for one store.
+type Server struct {
+	store        *store.Store
+	mux          *http.ServeMux
+	maxBodyBytes int64
+	// authenticators, if any, guard every route that requires a scope.
+	authenticators []Authenticator
+
+	// The OpenAPI document is encoded once; routes never change after New.
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+	openAPIErr  error
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// New returns a Server backed by s.
+func New(s *store.Store, opts ...Option) *Server {
+	srv := &Server{store: s, mux: http.NewServeMux(), maxBodyBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(srv)
+	}
+	for _, rt := range srv.routes() {
+		srv.mux.HandleFunc(rt.method+" "+rt.path, srv.requireScope(rt.scope, rt.handler))
+	}
+	srv.mux.HandleFunc("GET /openapi.json", srv.serveOpenAPI)
+	return srv
+}
+
+// ServeHTTP routes r to the API handlers.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// idempotencyKey scopes an idempotency key to the principal authenticated for
+// the request, if any. The subject is length-prefixed so no two principals
+// can produce the same scoped key.
+func idempotencyKey(r *http.Request, key string) string {
+	p, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		return key
+	}
+	return fmt.Sprintf("%d:%s:%s", len(p.Subject), p.Subject, key)
+}
+
+// createIntent stores the posted record. A record without a hash is appended
+// to its chain, which fills ID, CreatedAt, PrevHash, and Hash; with an
+// Idempotency-Key header, retries return the record first appended. A record
+// with a hash (for example one signed by the client) must verify and is
+// stored as is, and re-posting it is a no-op. Records may not set
+// tombstoned_at; only the server tombstones intents.
+func (s *Server) createIntent(w http.ResponseWriter, r *http.Request) {
+	var record model.IntentRecord
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&record); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode intent: %w", err))
+		return
+	}
+	if record.TombstonedAt != "" {
+		writeError(w, http.StatusBadRequest, errors.New("tombstoned_at is assigned by the server"))
+		return
+	}
+
+	if record.Hash == "" {
+		if err := apistore.ValidateNew(record); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		var stored model.IntentRecord
+		var err error
+		if key := r.Header.Get(HeaderIdempotencyKey); key != "" {
+			var replayed bool
+			stored, replayed, err = s.store.AppendIntentWithKey(r.Context(), idempotencyKey(r, key), record)
+			if replayed {
+				w.Header().Set(HeaderIdempotentReplayed, "true")
+			}
+		} else {
+			stored, err = s.store.AppendIntent(r.Context(), record)
+		}
+		if err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, stored)
+		return
+	}
+
+	if err := record.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := hash.VerifyHash(record); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.store.CreateIntentIdempotent(r.Context(), record); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	stored, err := s.store.GetIntent(r.Context(), record.ID)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, stored)
+}
+
+func (s *Server) getIntent(w http.ResponseWriter, r *http.Request) {
+	record, err := s.store.GetIntent(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// duplicatesResponse is the body of GET /intents/{id}/duplicates.
+type duplicatesResponse struct {
+	Candidates []duplicateCandidate `json:"candidates"`
+}
+
+type duplicateCandidate struct {
+	Intent model.IntentRecord `json:"intent"`
+	// Distance is the number of fingerprint bits in which the intents differ.
+	Distance int `json:"distance"`
+}
+
+func (s *Server) nearDuplicates(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a non-negative integer"))
+			return
+		}
+	}
+	candidates, err := s.store.NearDuplicates(r.Context(), r.PathValue("id"), limit)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	resp := duplicatesResponse{Candidates: make([]duplicateCandidate, 0, len(candidates))}
+	for _, c := range candidates {
+		resp.Candidates = append(resp.Candidates, duplicateCandidate{Intent: c.Intent, Distance: c.Distance})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// listResponse is the body of GET /intents.
+type listResponse struct {
+	Intents []model.IntentRecord `json:"intents"`
+	// NextCursor is passed as ?cursor= to fetch the next page; it is omitted
+	// on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) listIntents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := store.PageOptions{Author: q.Get("author"), Cursor: q.Get("cursor")}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a non-negative integer"))
+			return
+		}
+		opts.Limit = limit
+	}
+	page, err := s.store.ListIntentsPage(r.Context(), opts)
+	if err != nil {
+		if opts.Cursor != "" {
+			// Cursor errors are the only ones the caller can cause.
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeStoreError(w, err)
+		return
+	}
+	resp := listResponse{Intents: page.Intents, NextCursor: page.NextCursor}
+	if resp.Intents == nil {
+		resp.Intents = []model.IntentRecord{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// verifyResponse is the body of GET and POST /chain/verify.
+type verifyResponse struct {
+	Head    string        `json:"head"`
+	Valid   bool          `json:"valid"`
+	Length  int           `json:"length"`
+	Genesis string        `json:"genesis,omitempty"`
+	Issues  []verifyIssue `json:"issues"`
+}
+
+type verifyIssue struct {
+	Kind   chain.IssueKind `json:"kind"`
+	Hash   string          `json:"hash"`
+	ID     string          `json:"id,omitempty"`
+	Detail string          `json:"detail"`
+}
+
+// verifyChain verifies the chain named by ?chain= (see store.WithChainKey) or
+// ending at ?head=. An optional ?until= hash stops at a trusted record. Only a
+// POST records the run with store.Store.RecordVerification, so GET stays free
+// of side effects.
+func (s *Server) verifyChain(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	head := q.Get("head")
+	if name := q.Get("chain"); name != "" {
+		if head != "" {
+			writeError(w, http.StatusBadRequest, errors.New("chain and head are mutually exclusive"))
+			return
+		}
+		var err error
+		if head, err = s.store.ChainHead(r.Context(), name); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+	}
+	if head == "" {
+		writeError(w, http.StatusBadRequest, errors.New("chain or head is required"))
+		return
+	}
+
+	report, err := chain.VerifyChainUntil(r.Context(), s.store, head, q.Get("until"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if r.Method == http.MethodPost {
+		// A read-only store still verifies; it just cannot remember doing so.
+		err := s.store.RecordVerification(r.Context(), report.Head, report.Length, len(report.Issues))
+		if err != nil && !errors.Is(err, store.ErrReadOnly) {
+			writeStoreError(w, err)
+			return
+		}
+	}
+	resp := verifyResponse{
+		Head:    report.Head,
+		Valid:   report.Valid(),
+		Length:  report.Length,
+		Genesis: report.Genesis,
+		Issues:  make([]verifyIssue, 0, len(report.Issues)),
+	}
+	for _, issue := range report.Issues {
+		resp.Issues = append(resp.Issues, verifyIssue{Kind: issue.Kind, Hash: issue.Hash, ID: issue.ID, Detail: issue.Detail})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// healthz reports the store's health, failing only if the database is
+// unreachable.
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	h := s.store.Health(r.Context())
+	status := http.StatusOK
+	if !h.Reachable {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, h)
+}
+
+// readyz reports the store's health, failing until it is ready to serve.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	h := s.store.Health(r.Context())
+	status := http.StatusOK
+	if !h.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, h)
+}
+
+// writeStoreError maps store errors to status codes: invalid records and hash
+// mismatches are 400, missing records 404, ID collisions 409, writes to a
+// read-only store and actions the store's Authorizer denies 403, reused
+// idempotency keys, broken chains, and timestamps too far behind the chain
+// head 422, rate-limited writes 429 with Retry-After, cancelled requests 503,
+// and anything else 500.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch apistore.Classify(err) {
+	case apistore.KindInvalid:
+		writeError(w, http.StatusBadRequest, err)
+	case apistore.KindNotFound:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	case apistore.KindDuplicate:
+		writeError(w, http.StatusConflict, err)
+	case apistore.KindReadOnly, apistore.KindForbidden:
+		writeError(w, http.StatusForbidden, err)
+	case apistore.KindRejected, apistore.KindChainBroken:
+		writeError(w, http.StatusUnprocessableEntity, err)
+	case apistore.KindRateLimited:
+		var limited *store.RateLimitedError
+		if errors.As(err, &limited) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		}
+		writeError(w, http.StatusTooManyRequests, err)
+	case apistore.KindCanceled:
+		writeError(w, http.StatusServiceUnavailable, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,361 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func newTestServer(t *testing.T) (*store.Store, *httptest.Server) {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	srv := httptest.NewServer(New(s))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// do sends a request and decodes the JSON response into out, returning the status.
+func do(t *testing.T, method, url string, body any, out any) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected JSON, got %q", ct)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestCreateAndGetIntent(t *testing.T) {
+	_, srv := newTestServer(t)
+
+	var first, second model.IntentRecord
+	input := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p1", Response: "r1"}
+	if status := do(t, "POST", srv.URL+"/intents", input, &first); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if first.ID == "" || first.Hash == "" || first.PrevHash != "" {
+		t.Fatalf("expected a completed genesis record, got %+v", first)
+	}
+	input.Prompt = "p2"
+	if status := do(t, "POST", srv.URL+"/intents", input, &second); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if second.PrevHash != first.Hash {
+		t.Fatalf("expected the second record to link to the first")
+	}
+
+	var got model.IntentRecord
+	if status := do(t, "GET", srv.URL+"/intents/"+second.ID, nil, &got); status != http.StatusOK || got.Hash != second.Hash {
+		t.Fatalf("expected the stored record, got %d %+v", status, got)
+	}
+	var errResp errorResponse
+	if status := do(t, "GET", srv.URL+"/intents/missing", nil, &errResp); status != http.StatusNotFound || errResp.Error == "" {
+		t.Fatalf("expected 404 with an error, got %d %+v", status, errResp)
+	}
+}
+
+func TestCreateHashedIntent(t *testing.T) {
+	_, srv := newTestServer(t)
+	record := model.IntentRecord{
+		ID:         "client-1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "api",
+		Prompt:     "p",
+		Response:   "r",
+	}
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	record.Hash = sum
+
+	for range 2 {
+		if status := do(t, "POST", srv.URL+"/intents", record, nil); status != http.StatusCreated {
+			t.Fatalf("expected 201 for a replayed hashed record, got %d", status)
+		}
+	}
+
+	tampered := record
+	tampered.Response = "edited"
+	if status := do(t, "POST", srv.URL+"/intents", tampered, nil); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a mismatched hash, got %d", status)
+	}
+
+	conflict := record
+	conflict.Response = "other"
+	if conflict.Hash, err = hash.HashIntent(conflict); err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if status := do(t, "POST", srv.URL+"/intents", conflict, nil); status != http.StatusConflict {
+		t.Fatalf("expected 409 for a colliding ID, got %d", status)
+	}
+}
+
+func TestCreateIntentRejectsBadInput(t *testing.T) {
+	_, srv := newTestServer(t)
+	cases := map[string]any{
+		"missing prompt": model.IntentRecord{Author: "alice", SourceType: "api", Response: "r"},
+		"prev_hash":      model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r", PrevHash: "abc"},
+		"unknown field":  map[string]string{"author": "alice", "bogus": "x"},
+		"tombstoned_at":  model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r", TombstonedAt: "2026-02-09T10:00:00Z"},
+	}
+	for name, body := range cases {
+		if status := do(t, "POST", srv.URL+"/intents", body, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, status)
+		}
+	}
+}
+
+func TestListIntents(t *testing.T) {
+	_, srv := newTestServer(t)
+	for _, author := range []string{"alice", "bob", "alice", "alice"} {
+		input := model.IntentRecord{Author: author, SourceType: "api", Prompt: "p", Response: "r"}
+		if status := do(t, "POST", srv.URL+"/intents", input, nil); status != http.StatusCreated {
+			t.Fatalf("expected 201, got %d", status)
+		}
+	}
+
+	var ids []string
+	url := srv.URL + "/intents?author=alice&limit=2"
+	for {
+		var page listResponse
+		if status := do(t, "GET", url, nil, &page); status != http.StatusOK {
+			t.Fatalf("expected 200, got %d", status)
+		}
+		for _, record := range page.Intents {
+			if record.Author != "alice" {
+				t.Fatalf("expected only alice, got %s", record.Author)
+			}
+			ids = append(ids, record.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		url = srv.URL + "/intents?author=alice&limit=2&cursor=" + page.NextCursor
+	}
+	if len(ids) != 3 {
+		t.Fatalf("expected 3 intents, got %v", ids)
+	}
+
+	if status := do(t, "GET", srv.URL+"/intents?cursor=bogus", nil, nil); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad cursor, got %d", status)
+	}
+	if status := do(t, "GET", srv.URL+"/intents?limit=x", nil, nil); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad limit, got %d", status)
+	}
+}
+
+func TestNearDuplicates(t *testing.T) {
+	s, srv := newTestServer(t)
+	var first model.IntentRecord
+	for i, author := range []string{"alice", "bob"} {
+		input := model.IntentRecord{Author: author, SourceType: "api", Prompt: "how do I rotate the signing keys", Response: "use yanzi keys rotate"}
+		var stored model.IntentRecord
+		if status := do(t, "POST", srv.URL+"/intents", input, &stored); status != http.StatusCreated {
+			t.Fatalf("expected 201, got %d", status)
+		}
+		if i == 0 {
+			first = stored
+		}
+	}
+	if _, err := s.FingerprintMissing(context.Background(), 0); err != nil {
+		t.Fatalf("fingerprint intents: %v", err)
+	}
+
+	var resp duplicatesResponse
+	if status := do(t, "GET", srv.URL+"/intents/"+first.ID+"/duplicates", nil, &resp); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if len(resp.Candidates) != 1 || resp.Candidates[0].Intent.Author != "bob" || resp.Candidates[0].Distance != 0 {
+		t.Fatalf("expected bob's intent as the only candidate, got %+v", resp.Candidates)
+	}
+	if status := do(t, "GET", srv.URL+"/intents/missing/duplicates", nil, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing intent, got %d", status)
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	_, srv := newTestServer(t)
+	var head model.IntentRecord
+	for _, prompt := range []string{"p1", "p2", "p3"} {
+		input := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: prompt, Response: "r"}
+		if status := do(t, "POST", srv.URL+"/intents", input, &head); status != http.StatusCreated {
+			t.Fatalf("expected 201, got %d", status)
+		}
+	}
+
+	var resp verifyResponse
+	if status := do(t, "GET", srv.URL+"/chain/verify?chain=alice", nil, &resp); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if !resp.Valid || resp.Length != 3 || resp.Head != head.Hash {
+		t.Fatalf("expected a valid 3-record chain, got %+v", resp)
+	}
+
+	if status := do(t, "GET", srv.URL+"/chain/verify?chain=nobody", nil, nil); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown chain, got %d", status)
+	}
+	if status := do(t, "GET", srv.URL+"/chain/verify", nil, nil); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 without chain or head, got %d", status)
+	}
+	if status := do(t, "GET", srv.URL+"/chain/verify?head=missing", nil, &resp); status != http.StatusOK || resp.Valid {
+		t.Fatalf("expected an invalid report for a missing head, got %d %+v", status, resp)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	s, srv := newTestServer(t)
+	var h store.Health
+	if status := do(t, "GET", srv.URL+"/readyz", nil, &h); status != http.StatusOK || !h.Reachable || h.LastVerifiedAt != "" {
+		t.Fatalf("expected a ready store never verified, got %d %+v", status, h)
+	}
+
+	input := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+	if status := do(t, "POST", srv.URL+"/intents", input, nil); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	if status := do(t, "GET", srv.URL+"/chain/verify?chain=alice", nil, nil); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if status := do(t, "GET", srv.URL+"/healthz", nil, &h); status != http.StatusOK || h.LastVerifiedAt != "" {
+		t.Fatalf("expected GET not to record the verification, got %d %+v", status, h)
+	}
+	if status := do(t, "POST", srv.URL+"/chain/verify?chain=alice", nil, nil); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if status := do(t, "GET", srv.URL+"/healthz", nil, &h); status != http.StatusOK || h.LastVerifiedAt == "" {
+		t.Fatalf("expected POST to record the verification, got %d %+v", status, h)
+	}
+
+	_ = s.Close()
+	if status := do(t, "GET", srv.URL+"/healthz", nil, &h); status != http.StatusServiceUnavailable || h.Reachable {
+		t.Fatalf("expected 503 from a closed store, got %d %+v", status, h)
+	}
+}
+
+func TestReadOnlyStoreRejectsCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yanzi.db")
+	rw, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	if err := rw.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	rw.Close()
+	s, err := store.OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("open read-only: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	srv := httptest.NewServer(New(s))
+	t.Cleanup(srv.Close)
+
+	input := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+	if status := do(t, "POST", srv.URL+"/intents", input, nil); status != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", status)
+	}
+	if status := do(t, "GET", srv.URL+"/readyz", nil, nil); status != http.StatusOK {
+		t.Fatalf("expected a read-only store to be ready, got %d", status)
+	}
+}
+
+func TestRateLimitedCreate(t *testing.T) {
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"), store.WithRateLimits(store.RateLimits{
+		Global: store.RateLimit{Rate: 0.1, Burst: 1},
+	}))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	srv := httptest.NewServer(New(s))
+	t.Cleanup(srv.Close)
+
+	input := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+	if status := do(t, "POST", srv.URL+"/intents", input, nil); status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", status)
+	}
+	resp := send(t, "POST", srv.URL+"/intents", input)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "10" {
+		t.Fatalf("expected 429 with Retry-After 10, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestCreateIntentWithIdempotencyKey(t *testing.T) {
+	_, srv := newTestServer(t)
+	post := func(input model.IntentRecord) (*http.Response, model.IntentRecord) {
+		t.Helper()
+		raw, err := json.Marshal(input)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		req, err := http.NewRequest("POST", srv.URL+"/intents", bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("new request: %v", err)
+		}
+		req.Header.Set(HeaderIdempotencyKey, "req-1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		defer resp.Body.Close()
+		var stored model.IntentRecord
+		_ = json.NewDecoder(resp.Body).Decode(&stored)
+		return resp, stored
+	}
+
+	input := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+	resp, first := post(input)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get(HeaderIdempotentReplayed) != "" {
+		t.Fatalf("expected a fresh 201, got %d %v", resp.StatusCode, resp.Header)
+	}
+	resp, again := post(input)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get(HeaderIdempotentReplayed) != "true" || again.ID != first.ID {
+		t.Fatalf("expected a replayed 201 for %s, got %d %s", first.ID, resp.StatusCode, again.ID)
+	}
+	input.Prompt = "different"
+	if resp, _ := post(input); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a reused key, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,82 @@
+// Package apistore holds what the HTTP and gRPC servers share in front of a
+// store: validating records the store will complete, and classifying store
+// errors so each server can map them to its own status codes.
+package apistore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// ValidateNew validates a record that AppendIntent will complete, standing in
+// for the fields the store fills.
+func ValidateNew(record model.IntentRecord) error {
+	if record.PrevHash != "" {
+		return errors.New("prev_hash is assigned by the server when hash is omitted")
+	}
+	if record.ID == "" {
+		record.ID = "pending"
+	}
+	if record.CreatedAt == "" {
+		record.CreatedAt = "1970-01-01T00:00:00Z"
+	}
+	record.Hash = "pending"
+	return record.Validate()
+}
+
+// Kind classifies a store error.
+type Kind int
+
+const (
+	// KindInternal is any error not classified below.
+	KindInternal Kind = iota
+	// KindInvalid is a record the store rejected: store.ErrInvalidRecord or
+	// store.ErrHashMismatch.
+	KindInvalid
+	// KindNotFound is store.ErrNotFound.
+	KindNotFound
+	// KindDuplicate is store.ErrDuplicateID.
+	KindDuplicate
+	// KindReadOnly is store.ErrReadOnly.
+	KindReadOnly
+	// KindForbidden is store.ErrForbidden.
+	KindForbidden
+	// KindChainBroken is store.ErrChainBroken.
+	KindChainBroken
+	// KindRejected is a well-formed request the store refused:
+	// store.ErrIdempotencyKeyReused or store.ErrClockSkew.
+	KindRejected
+	// KindRateLimited is store.ErrRateLimited.
+	KindRateLimited
+	// KindCanceled is a cancelled or expired context.
+	KindCanceled
+)
+
+// Classify returns the Kind of err.
+func Classify(err error) Kind {
+	switch {
+	case errors.Is(err, store.ErrInvalidRecord), errors.Is(err, store.ErrHashMismatch):
+		return KindInvalid
+	case errors.Is(err, store.ErrNotFound):
+		return KindNotFound
+	case errors.Is(err, store.ErrDuplicateID):
+		return KindDuplicate
+	case errors.Is(err, store.ErrReadOnly):
+		return KindReadOnly
+	case errors.Is(err, store.ErrForbidden):
+		return KindForbidden
+	case errors.Is(err, store.ErrChainBroken):
+		return KindChainBroken
+	case errors.Is(err, store.ErrIdempotencyKeyReused), errors.Is(err, store.ErrClockSkew):
+		return KindRejected
+	case errors.Is(err, store.ErrRateLimited):
+		return KindRateLimited
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return KindCanceled
+	default:
+		return KindInternal
+	}
+}
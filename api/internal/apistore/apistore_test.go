@@ -0,0 +1,38 @@
+package apistore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func TestClassify(t *testing.T) {
+	cases := map[error]Kind{
+		&model.InvalidRecordError{Err: errors.New("bad")}:   KindInvalid,
+		&store.NotFoundError{Kind: "intent", Key: "x"}:      KindNotFound,
+		fmt.Errorf("wrapped: %w", store.ErrReadOnly):        KindReadOnly,
+		fmt.Errorf("wrapped: %w", store.ErrClockSkew):       KindRejected,
+		fmt.Errorf("wrapped: %w", context.DeadlineExceeded): KindCanceled,
+		errors.New("disk on fire"):                          KindInternal,
+	}
+	for err, want := range cases {
+		if got := Classify(err); got != want {
+			t.Errorf("%v: expected kind %d, got %d", err, want, got)
+		}
+	}
+}
+
+func TestValidateNewRejectsPrevHash(t *testing.T) {
+	record := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+	if err := ValidateNew(record); err != nil {
+		t.Fatalf("expected a record the store will complete to validate: %v", err)
+	}
+	record.PrevHash = "abc"
+	if err := ValidateNew(record); err == nil {
+		t.Fatal("expected prev_hash to be rejected")
+	}
+}
@@ -0,0 +1,229 @@
+// Package mcpapi exposes a store to AI assistants as a Model Context Protocol
+// server, so a model can look up and cite recorded intents and record new
+// ones. It speaks JSON-RPC 2.0 over the MCP stdio transport (one message per
+// line) and offers three tools:
+//
+//	search_intents  find intents by text, author, source type, or time range
+//	get_intent      fetch one intent by ID or hash
+//	record_intent   append an intent to its author's chain
+//
+// Run it with `yanzi mcp`, or call Serve with any reader and writer.
+package mcpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// ProtocolVersion is the newest MCP revision the server implements. Clients
+// asking for an older supported revision get that one.
+const ProtocolVersion = "2025-06-18"
+
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// DefaultMaxMessageBytes bounds each incoming message unless overridden with
+// WithMaxMessageBytes.
+const DefaultMaxMessageBytes = 8 << 20
+
+// DefaultSourceType is recorded for intents whose record_intent call names no
+// source type.
+const DefaultSourceType = "mcp"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithDefaultAuthor sets the author recorded when record_intent names none.
+// Without it, record_intent requires an author.
+func WithDefaultAuthor(author string) Option {
+	return func(s *Server) {
+		s.defaultAuthor = author
+	}
+}
+
+// WithServerVersion sets the version reported to clients in serverInfo.
+func WithServerVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// WithMaxMessageBytes sets the largest message Serve reads; values <= 0 keep
+// the default.
+func WithMaxMessageBytes(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.maxMessageBytes = n
+		}
+	}
+}
+
+// Server serves MCP requests for one store.
+type Server struct {
+	store           *store.Store
+	defaultAuthor   string
+	version         string
+	maxMessageBytes int
+	tools           []tool
+}
+
+// New returns a Server backed by s.
+func New(s *store.Store, opts ...Option) *Server {
+	srv := &Server{store: s, version: "dev", maxMessageBytes: DefaultMaxMessageBytes}
+	for _, opt := range opts {
+		opt(srv)
+	}
+	srv.tools = srv.toolset()
+	return srv
+}
+
+// request is an incoming JSON-RPC request or notification; notifications have
+// no ID.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// Serve reads messages from r and writes responses to w, one per line, until r
+// is exhausted or ctx is cancelled. Requests are handled in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), s.maxMessageBytes)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		resp, ok := s.handle(ctx, line)
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return fmt.Errorf("write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read message: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message. It reports false for notifications, which get
+// no response.
+func (s *Server) handle(ctx context.Context, line []byte) (response, bool) {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return errorReply(json.RawMessage("null"), &rpcError{Code: codeParseError, Message: err.Error()}), true
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		id := req.ID
+		if id == nil {
+			id = json.RawMessage("null")
+		}
+		return errorReply(id, &rpcError{Code: codeInvalidRequest, Message: "not a JSON-RPC 2.0 request"}), true
+	}
+	if req.ID == nil {
+		// Notifications (notifications/initialized, notifications/cancelled)
+		// need no action.
+		return response{}, false
+	}
+
+	result, err := s.dispatch(ctx, req)
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return errorReply(req.ID, rerr), true
+	}
+	return response{JSONRPC: "2.0", ID: req.ID, Result: result}, true
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return s.listTools(), nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      serverInfo     `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+type serverInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func (s *Server) initialize(raw json.RawMessage) (any, error) {
+	var params initializeParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, err
+		}
+	}
+	version := ProtocolVersion
+	if slices.Contains(supportedVersions, params.ProtocolVersion) {
+		version = params.ProtocolVersion
+	}
+	return initializeResult{
+		ProtocolVersion: version,
+		Capabilities:    map[string]any{"tools": map[string]any{}},
+		ServerInfo:      serverInfo{Name: "yanzi", Version: s.version},
+		Instructions: "Yanzi is a hash-chained ledger of prompts and responses. Use search_intents and " +
+			"get_intent to find and cite recorded intents, and record_intent to add one.",
+	}, nil
+}
+
+func errorReply(id json.RawMessage, err *rpcError) response {
+	return response{JSONRPC: "2.0", ID: id, Error: err}
+}
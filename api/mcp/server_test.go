@@ -0,0 +1,163 @@
+package mcpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func newTestServer(t *testing.T, opts ...Option) (*store.Store, *Server) {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s, New(s, opts...)
+}
+
+type testResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// session sends each message as one line and returns the responses in order.
+func session(t *testing.T, srv *Server, messages ...string) []testResponse {
+	t.Helper()
+	var out strings.Builder
+	if err := srv.Serve(context.Background(), strings.NewReader(strings.Join(messages, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	var responses []testResponse
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var resp testResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response %q: %v", scanner.Text(), err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func decodeResult(t *testing.T, resp testResponse, v any) {
+	t.Helper()
+	if resp.Error != nil {
+		t.Fatalf("request %d failed: %v", resp.ID, resp.Error)
+	}
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		t.Fatalf("decode result %d: %v", resp.ID, err)
+	}
+}
+
+func TestInitializeAndListTools(t *testing.T) {
+	_, srv := newTestServer(t)
+	responses := session(t, srv,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+		`not json`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 responses (none for the notification), got %d", len(responses))
+	}
+
+	var init initializeResult
+	decodeResult(t, responses[0], &init)
+	if init.ProtocolVersion != "2025-03-26" || init.ServerInfo.Name != "yanzi" {
+		t.Fatalf("unexpected initialize result: %+v", init)
+	}
+
+	var tools listToolsResult
+	decodeResult(t, responses[1], &tools)
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "search_intents,get_intent,record_intent" {
+		t.Fatalf("unexpected tools: %v", names)
+	}
+
+	if responses[2].Error == nil || responses[2].Error.Code != codeMethodNotFound {
+		t.Fatalf("expected method not found, got %+v", responses[2])
+	}
+	if responses[3].Error == nil || responses[3].Error.Code != codeParseError {
+		t.Fatalf("expected parse error, got %+v", responses[3])
+	}
+}
+
+func TestRecordSearchAndGetIntent(t *testing.T) {
+	s, srv := newTestServer(t, WithDefaultAuthor("assistant"))
+	responses := session(t, srv,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"record_intent","arguments":{"prompt":"How do we rotate keys?","response":"Use yanzi keys rotate.","meta":{"model":"m1"}}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search_intents","arguments":{"text":"ROTATE"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"search_intents","arguments":{"text":"nothing like this"}}}`,
+	)
+
+	var recorded callResult
+	var stored model.IntentRecord
+	decodeResult(t, responses[0], &struct {
+		*callResult
+		StructuredContent *model.IntentRecord `json:"structuredContent"`
+	}{&recorded, &stored})
+	if recorded.IsError || stored.Author != "assistant" || stored.SourceType != DefaultSourceType || stored.Hash == "" {
+		t.Fatalf("unexpected record_intent result: %+v %+v", recorded, stored)
+	}
+	if _, err := s.GetIntent(context.Background(), stored.ID); err != nil {
+		t.Fatalf("expected the intent in the store: %v", err)
+	}
+
+	var found struct {
+		StructuredContent intentsResult `json:"structuredContent"`
+	}
+	decodeResult(t, responses[1], &found)
+	if len(found.StructuredContent.Intents) != 1 || found.StructuredContent.Intents[0].ID != stored.ID {
+		t.Fatalf("expected the recorded intent, got %+v", found.StructuredContent)
+	}
+	decodeResult(t, responses[2], &found)
+	if found.StructuredContent.Intents == nil || len(found.StructuredContent.Intents) != 0 {
+		t.Fatalf("expected an empty list, got %+v", found.StructuredContent)
+	}
+
+	get := session(t, srv,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"get_intent","arguments":{"hash":"`+stored.Hash+`"}}}`,
+	)
+	var got struct {
+		StructuredContent model.IntentRecord `json:"structuredContent"`
+	}
+	decodeResult(t, get[0], &got)
+	if got.StructuredContent.ID != stored.ID || got.StructuredContent.Prompt != "How do we rotate keys?" {
+		t.Fatalf("unexpected get_intent result: %+v", got.StructuredContent)
+	}
+}
+
+func TestToolErrors(t *testing.T) {
+	_, srv := newTestServer(t)
+	responses := session(t, srv,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_intent","arguments":{"id":"missing"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"record_intent","arguments":{"prompt":"p","response":"r"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"search_intents","arguments":{"bogus":true}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"delete_everything"}}`,
+	)
+	for i, want := range []string{"intent not found", "author is required", "unknown field"} {
+		var result callResult
+		decodeResult(t, responses[i], &result)
+		if !result.IsError || len(result.Content) != 1 || !strings.Contains(result.Content[0].Text, want) {
+			t.Fatalf("response %d: expected tool error %q, got %+v", i+1, want, result)
+		}
+	}
+	if responses[3].Error == nil || responses[3].Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params for an unknown tool, got %+v", responses[3])
+	}
+}
@@ -0,0 +1,288 @@
+package mcpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// DefaultSearchLimit is how many intents search_intents returns when the call
+// sets no limit.
+const DefaultSearchLimit = 20
+
+// tool is one MCP tool. call decodes its own arguments; its errors are
+// reported to the model as tool errors rather than protocol errors.
+type tool struct {
+	name        string
+	description string
+	inputSchema map[string]any
+	call        func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+func (s *Server) toolset() []tool {
+	return []tool{
+		{
+			name: "search_intents",
+			description: "Search recorded intents (prompt/response pairs), newest first. Text matches the " +
+				"title, prompt, or response, ignoring case; other filters narrow the results.",
+			inputSchema: objectSchema(map[string]any{
+				"text":         stringProp("Substring to find in the title, prompt, or response."),
+				"author":       stringProp("Only intents by this author."),
+				"source_type":  stringProp("Only intents with this source type."),
+				"title_prefix": stringProp("Only intents whose title starts with this (case-sensitive)."),
+				"since":        stringProp("Only intents created at or after this RFC 3339 time."),
+				"until":        stringProp("Only intents created before this RFC 3339 time."),
+				"limit":        map[string]any{"type": "integer", "minimum": 1, "description": fmt.Sprintf("Maximum results (default %d).", DefaultSearchLimit)},
+			}),
+			call: s.searchIntents,
+		},
+		{
+			name:        "get_intent",
+			description: "Fetch one recorded intent by its ID or by its hash.",
+			inputSchema: objectSchema(map[string]any{
+				"id":   stringProp("The intent ID."),
+				"hash": stringProp("The intent hash, as cited elsewhere."),
+			}),
+			call: s.getIntent,
+		},
+		{
+			name: "record_intent",
+			description: "Record a prompt and its response in the ledger. The intent is linked to the head " +
+				"of its author's chain and hashed; the stored intent, with its ID and hash, is returned.",
+			inputSchema: objectSchema(map[string]any{
+				"prompt":      stringProp("The prompt or request."),
+				"response":    stringProp("The response given."),
+				"title":       stringProp("Optional short title."),
+				"author":      stringProp("Who the intent is recorded for; defaults to the server's configured author."),
+				"source_type": stringProp(fmt.Sprintf("Where the intent came from (default %q).", DefaultSourceType)),
+				"meta":        map[string]any{"type": "object", "description": "Optional JSON metadata."},
+				"tags":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Optional labels."},
+				"thread_id":   stringProp("Optional thread grouping a multi-turn exchange."),
+				"parent_id":   stringProp("Optional ID of the turn this one replies to."),
+			}, "prompt", "response"),
+			call: s.recordIntent,
+		},
+	}
+}
+
+func objectSchema(props map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProp(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+type toolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type listToolsResult struct {
+	Tools []toolInfo `json:"tools"`
+}
+
+func (s *Server) listTools() listToolsResult {
+	result := listToolsResult{Tools: make([]toolInfo, 0, len(s.tools))}
+	for _, t := range s.tools {
+		result.Tools = append(result.Tools, toolInfo{Name: t.name, Description: t.description, InputSchema: t.inputSchema})
+	}
+	return result
+}
+
+type callParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content           []content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// callTool runs a tool. Unknown tools are protocol errors; anything the tool
+// itself reports is returned as an isError result so the model can react.
+func (s *Server) callTool(ctx context.Context, raw json.RawMessage) (any, error) {
+	var params callParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	for _, t := range s.tools {
+		if t.name != params.Name {
+			continue
+		}
+		out, err := t.call(ctx, params.Arguments)
+		if err != nil {
+			return callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		text, err := json.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		return callResult{Content: []content{{Type: "text", Text: string(text)}}, StructuredContent: out}, nil
+	}
+	return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name}
+}
+
+// decodeArgs decodes tool arguments strictly; absent arguments decode as {}.
+func decodeArgs(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+type searchArgs struct {
+	Text        string `json:"text"`
+	Author      string `json:"author"`
+	SourceType  string `json:"source_type"`
+	TitlePrefix string `json:"title_prefix"`
+	Since       string `json:"since"`
+	Until       string `json:"until"`
+	Limit       int    `json:"limit"`
+}
+
+type intentsResult struct {
+	Intents []model.IntentRecord `json:"intents"`
+}
+
+func (s *Server) searchIntents(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args searchArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	q := store.Query{
+		Text:        args.Text,
+		Author:      args.Author,
+		SourceType:  args.SourceType,
+		TitlePrefix: args.TitlePrefix,
+		Limit:       args.Limit,
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	var err error
+	if q.CreatedFrom, err = parseTime("since", args.Since); err != nil {
+		return nil, err
+	}
+	if q.CreatedTo, err = parseTime("until", args.Until); err != nil {
+		return nil, err
+	}
+	intents, err := s.store.QueryIntents(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if intents == nil {
+		intents = []model.IntentRecord{}
+	}
+	return intentsResult{Intents: intents}, nil
+}
+
+func parseTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time: %w", name, err)
+	}
+	return t, nil
+}
+
+type getArgs struct {
+	ID   string `json:"id"`
+	Hash string `json:"hash"`
+}
+
+func (s *Server) getIntent(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args getArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	var (
+		record model.IntentRecord
+		err    error
+	)
+	switch {
+	case (args.ID == "") == (args.Hash == ""):
+		return nil, errors.New("exactly one of id or hash is required")
+	case args.ID != "":
+		record, err = s.store.GetIntent(ctx, args.ID)
+	default:
+		record, err = s.store.GetIntentByHash(ctx, args.Hash)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, errors.New("intent not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+type recordArgs struct {
+	Prompt     string          `json:"prompt"`
+	Response   string          `json:"response"`
+	Title      string          `json:"title"`
+	Author     string          `json:"author"`
+	SourceType string          `json:"source_type"`
+	Meta       json.RawMessage `json:"meta"`
+	Tags       []string        `json:"tags"`
+	ThreadID   string          `json:"thread_id"`
+	ParentID   string          `json:"parent_id"`
+}
+
+func (s *Server) recordIntent(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args recordArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Prompt == "" || args.Response == "" {
+		return nil, errors.New("prompt and response are required")
+	}
+	if args.Author == "" {
+		args.Author = s.defaultAuthor
+	}
+	if args.Author == "" {
+		return nil, errors.New("author is required")
+	}
+	if args.SourceType == "" {
+		args.SourceType = DefaultSourceType
+	}
+	if string(args.Meta) == "null" {
+		args.Meta = nil
+	}
+	return s.store.AppendIntent(ctx, model.IntentRecord{
+		Author:     args.Author,
+		SourceType: args.SourceType,
+		Title:      args.Title,
+		Prompt:     args.Prompt,
+		Response:   args.Response,
+		Meta:       args.Meta,
+		Tags:       args.Tags,
+		ThreadID:   args.ThreadID,
+		ParentID:   args.ParentID,
+	})
+}
@@ -0,0 +1,43 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/internal/synth"
+	"github.com/chuxorg/chux-yanzi-core/store/memstore"
+)
+
+func BenchmarkVerifyChain(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range synth.Sizes() {
+		b.Run(fmt.Sprintf("records=%d", n), func(b *testing.B) {
+			s := memstore.New()
+			g := synth.New(synth.Options{Authors: 1})
+			var head string
+			for i := 0; i < n; i++ {
+				record, err := g.Next()
+				if err != nil {
+					b.Fatalf("generate intent: %v", err)
+				}
+				if err := s.CreateIntent(ctx, record); err != nil {
+					b.Fatalf("create intent: %v", err)
+				}
+				head = record.Hash
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				report, err := VerifyChain(ctx, s, head)
+				if err != nil {
+					b.Fatalf("verify chain: %v", err)
+				}
+				if !report.Valid() || report.Length != n {
+					b.Fatalf("expected a valid chain of %d, got length %d with %d issue(s)", n, report.Length, len(report.Issues))
+				}
+			}
+		})
+	}
+}
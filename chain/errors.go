@@ -0,0 +1,50 @@
+package chain
+
+import (
+	"fmt"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// The model package's error sentinels, re-exported for callers of this package.
+var (
+	// ErrNotFound is what a Reader returns, wrapped or not, for a missing hash.
+	ErrNotFound = model.ErrNotFound
+	// ErrChainBroken matches every *VerifyError.
+	ErrChainBroken = model.ErrChainBroken
+	// ErrHashMismatch matches a *VerifyError whose first issue is an altered payload.
+	ErrHashMismatch = model.ErrHashMismatch
+)
+
+// VerifyError is the error form of a Report with issues; see Report.Err.
+type VerifyError struct {
+	Head   string
+	Issues []Issue
+}
+
+func (e *VerifyError) Error() string {
+	first := e.Issues[0]
+	return fmt.Sprintf("chain %s failed verification with %d issue(s); first %s at %s: %s",
+		e.Head, len(e.Issues), first.Kind, first.Hash, first.Detail)
+}
+
+// Unwrap lets errors.Is match ErrChainBroken, and also ErrHashMismatch or
+// ErrNotFound when the first issue is an altered payload or a missing record.
+func (e *VerifyError) Unwrap() []error {
+	errs := []error{ErrChainBroken}
+	switch e.Issues[0].Kind {
+	case IssueAlteredPayload:
+		errs = append(errs, ErrHashMismatch)
+	case IssueMissingRecord:
+		errs = append(errs, ErrNotFound)
+	}
+	return errs
+}
+
+// Err returns nil for a valid report and a *VerifyError otherwise.
+func (r Report) Err() error {
+	if r.Valid() {
+		return nil
+	}
+	return &VerifyError{Head: r.Head, Issues: r.Issues}
+}
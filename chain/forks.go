@@ -0,0 +1,95 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ForkSource exposes the queries needed for fork detection. store.Store satisfies it.
+type ForkSource interface {
+	ForkPoints(ctx context.Context) ([]string, error)
+	ListChildren(ctx context.Context, prevHash string) ([]model.IntentRecord, error)
+}
+
+// Fork is a record with more than one successor.
+type Fork struct {
+	// Parent is the hash shared as prev_hash by every branch root.
+	Parent   string
+	Branches []Branch
+}
+
+// Branch is one path leaving a fork, from its first record to a head.
+type Branch struct {
+	// Root is the hash of the first record after the fork.
+	Root string
+	// Head is the hash of the newest record on the branch (no successors).
+	Head string
+	// Length counts records from Root to Head inclusive.
+	Length int
+}
+
+// DetectForks reports every fork in the store with its divergent branches. A
+// branch that forks again yields one Branch per reachable head, each sharing
+// the same Root.
+func DetectForks(ctx context.Context, src ForkSource) ([]Fork, error) {
+	parents, err := src.ForkPoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("find fork points: %w", err)
+	}
+
+	forks := make([]Fork, 0, len(parents))
+	for _, parent := range parents {
+		children, err := src.ListChildren(ctx, parent)
+		if err != nil {
+			return nil, fmt.Errorf("list children of %s: %w", parent, err)
+		}
+		fork := Fork{Parent: parent}
+		for _, child := range children {
+			branches, err := walkBranch(ctx, src, child)
+			if err != nil {
+				return nil, err
+			}
+			fork.Branches = append(fork.Branches, branches...)
+		}
+		forks = append(forks, fork)
+	}
+	return forks, nil
+}
+
+// walkBranch follows successors from root to every head reachable from it.
+func walkBranch(ctx context.Context, src ForkSource, root model.IntentRecord) ([]Branch, error) {
+	type step struct {
+		hash   string
+		length int
+	}
+	var branches []Branch
+	seen := map[string]struct{}{root.Hash: {}}
+	stack := []step{{hash: root.Hash, length: 1}}
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		children, err := src.ListChildren(ctx, cur.hash)
+		if err != nil {
+			return nil, fmt.Errorf("list children of %s: %w", cur.hash, err)
+		}
+		next := 0
+		for _, child := range children {
+			if _, ok := seen[child.Hash]; ok {
+				continue
+			}
+			seen[child.Hash] = struct{}{}
+			stack = append(stack, step{hash: child.Hash, length: cur.length + 1})
+			next++
+		}
+		if next == 0 {
+			branches = append(branches, Branch{Root: root.Hash, Head: cur.hash, Length: cur.length})
+		}
+	}
+	return branches, nil
+}
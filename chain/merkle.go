@@ -0,0 +1,141 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+)
+
+// HashLister lists intent hashes in canonical order. store.Store satisfies it.
+type HashLister interface {
+	IntentHashes(ctx context.Context, upTo time.Time) ([]string, error)
+}
+
+// MerkleTree is an RFC 6962 style Merkle tree over intent hashes. Leaves are
+// SHA-256(0x00 || hash) where hash is the intent's hash string, and interior
+// nodes are SHA-256(0x01 || left || right), so leaves and nodes cannot collide.
+type MerkleTree struct {
+	leaves [][]byte
+	index  map[string]int
+}
+
+// MerkleProof proves that an intent hash is a leaf of a tree with a given root.
+type MerkleProof struct {
+	Hash     string
+	Index    int
+	TreeSize int
+	// Path holds hex-encoded sibling hashes from the leaf up to the root.
+	Path []string
+}
+
+// BuildMerkleTree builds a tree over intents created at or before upTo, in
+// canonical (created_at, id) order. A zero upTo includes every intent.
+func BuildMerkleTree(ctx context.Context, src HashLister, upTo time.Time) (*MerkleTree, error) {
+	hashes, err := src.IntentHashes(ctx, upTo)
+	if err != nil {
+		return nil, fmt.Errorf("list intent hashes: %w", err)
+	}
+	return NewMerkleTree(hashes), nil
+}
+
+// NewMerkleTree builds a tree over hashes in the given order.
+func NewMerkleTree(hashes []string) *MerkleTree {
+	t := &MerkleTree{
+		leaves: make([][]byte, len(hashes)),
+		index:  make(map[string]int, len(hashes)),
+	}
+	for i, h := range hashes {
+		t.leaves[i] = hash.MerkleLeaf(h)
+		if _, dup := t.index[h]; !dup {
+			t.index[h] = i
+		}
+	}
+	return t
+}
+
+// MerkleRoot returns the hex root over intents created at or before upTo.
+func MerkleRoot(ctx context.Context, src HashLister, upTo time.Time) (string, error) {
+	tree, err := BuildMerkleTree(ctx, src, upTo)
+	if err != nil {
+		return "", err
+	}
+	return tree.Root(), nil
+}
+
+// Size returns the number of leaves.
+func (t *MerkleTree) Size() int {
+	return len(t.leaves)
+}
+
+// Root returns the hex-encoded tree root; an empty tree hashes to SHA-256("").
+func (t *MerkleTree) Root() string {
+	return hex.EncodeToString(hash.MerkleSubtree(t.leaves))
+}
+
+// MerkleProof returns the inclusion proof for an intent hash.
+func (t *MerkleTree) MerkleProof(h string) (MerkleProof, error) {
+	i, ok := t.index[h]
+	if !ok {
+		return MerkleProof{}, fmt.Errorf("hash %s is not in the tree", h)
+	}
+	path := auditPath(i, t.leaves)
+	proof := MerkleProof{Hash: h, Index: i, TreeSize: len(t.leaves), Path: make([]string, len(path))}
+	for j, node := range path {
+		proof.Path[j] = hex.EncodeToString(node)
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks proof against a hex-encoded root without access to the tree.
+func VerifyMerkleProof(root string, proof MerkleProof) error {
+	want, err := hex.DecodeString(root)
+	if err != nil {
+		return errors.New("root must be hex encoded")
+	}
+	if proof.Index < 0 || proof.Index >= proof.TreeSize {
+		return errors.New("proof index out of range")
+	}
+
+	fn, sn := proof.Index, proof.TreeSize-1
+	r := hash.MerkleLeaf(proof.Hash)
+	for _, encoded := range proof.Path {
+		p, err := hex.DecodeString(encoded)
+		if err != nil {
+			return errors.New("proof path must be hex encoded")
+		}
+		if sn == 0 {
+			return errors.New("proof path too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = hash.MerkleNode(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = hash.MerkleNode(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(r, want) {
+		return errors.New("merkle proof does not match root")
+	}
+	return nil
+}
+
+func auditPath(m int, leaves [][]byte) [][]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := hash.MerkleSplit(len(leaves))
+	if m < k {
+		return append(auditPath(m, leaves[:k]), hash.MerkleSubtree(leaves[k:]))
+	}
+	return append(auditPath(m-k, leaves[k:]), hash.MerkleSubtree(leaves[:k]))
+}
@@ -0,0 +1,44 @@
+package chain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMerkleProofsVerifyForEveryLeaf(t *testing.T) {
+	for size := 1; size <= 9; size++ {
+		hashes := make([]string, size)
+		for i := range hashes {
+			hashes[i] = fmt.Sprintf("hash-%d", i)
+		}
+		tree := NewMerkleTree(hashes)
+		root := tree.Root()
+
+		for _, h := range hashes {
+			proof, err := tree.MerkleProof(h)
+			if err != nil {
+				t.Fatalf("size %d: proof for %s: %v", size, h, err)
+			}
+			if err := VerifyMerkleProof(root, proof); err != nil {
+				t.Fatalf("size %d: verify %s: %v", size, h, err)
+			}
+
+			forged := proof
+			forged.Hash = "forged"
+			if err := VerifyMerkleProof(root, forged); err == nil {
+				t.Fatalf("size %d: expected forged leaf to fail", size)
+			}
+		}
+	}
+}
+
+func TestMerkleRootChangesWithContent(t *testing.T) {
+	a := NewMerkleTree([]string{"a", "b", "c"}).Root()
+	b := NewMerkleTree([]string{"a", "c", "b"}).Root()
+	if a == b {
+		t.Fatalf("expected order to affect the root")
+	}
+	if _, err := NewMerkleTree([]string{"a"}).MerkleProof("z"); err == nil {
+		t.Fatalf("expected proof for unknown hash to fail")
+	}
+}
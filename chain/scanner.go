@@ -0,0 +1,162 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ScanSource lists intents in canonical (created_at, id) order after a
+// position. store.Store satisfies it.
+type ScanSource interface {
+	ListIntentsAfter(ctx context.Context, createdAt, id string, limit int) ([]model.IntentRecord, error)
+}
+
+// Position is the last record a Scanner verified. The zero Position is the
+// start of the store.
+type Position struct {
+	CreatedAt string
+	ID        string
+}
+
+const (
+	// DefaultScanBatchSize is used when ScannerConfig.BatchSize is <= 0.
+	DefaultScanBatchSize = 100
+	// DefaultScanInterval is used when ScannerConfig.Interval is <= 0.
+	DefaultScanInterval = time.Second
+)
+
+// ScannerConfig configures a Scanner.
+type ScannerConfig struct {
+	// BatchSize is the number of records verified per step.
+	BatchSize int
+	// Interval is the pause between steps in Run.
+	Interval time.Duration
+	// Start resumes scanning after a previously saved Position.
+	Start Position
+	// HMACSecret verifies keyed (hmac-sha256) hashes. Without it, records
+	// with keyed hashes are reported as IssueUnverifiable.
+	HMACSecret []byte
+	// OnAnomaly is called for every record whose recomputed hash differs from
+	// its stored hash or cannot be recomputed.
+	OnAnomaly func(Issue)
+	// OnError is called when a step fails; Run retries on the next interval.
+	OnError func(error)
+	// Logger, if set, receives anomalies at warning level, step failures at
+	// error level, and completed passes at info level.
+	Logger *slog.Logger
+}
+
+// Scanner re-verifies stored hashes incrementally so that a full audit is
+// spread over time instead of run at once. After reaching the newest record
+// it starts a new pass from the beginning.
+type Scanner struct {
+	src ScanSource
+	cfg ScannerConfig
+
+	mu     sync.Mutex
+	pos    Position
+	passes int
+}
+
+// NewScanner returns a Scanner over src starting at cfg.Start.
+func NewScanner(src ScanSource, cfg ScannerConfig) *Scanner {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultScanBatchSize
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultScanInterval
+	}
+	return &Scanner{src: src, cfg: cfg, pos: cfg.Start}
+}
+
+// Position returns the last verified record; persist it and pass it back as
+// ScannerConfig.Start to resume after a restart.
+func (s *Scanner) Position() Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pos
+}
+
+// Passes returns how many full passes over the store have completed.
+func (s *Scanner) Passes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.passes
+}
+
+// Step verifies the next batch and returns the anomalies found. When the end
+// of the store is reached the position wraps to the start.
+func (s *Scanner) Step(ctx context.Context) ([]Issue, error) {
+	pos := s.Position()
+	batch, err := s.src.ListIntentsAfter(ctx, pos.CreatedAt, pos.ID, s.cfg.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("list intents after %s: %w", pos.ID, err)
+	}
+
+	var issues []Issue
+	for _, record := range batch {
+		if issue, ok := payloadIssue(record, s.cfg.HMACSecret); ok {
+			issues = append(issues, issue)
+		}
+		pos = Position{CreatedAt: record.CreatedAt, ID: record.ID}
+	}
+
+	// A source may clamp the page, so a short one only ends the pass when
+	// nothing follows it.
+	wrapped := len(batch) == 0
+	if !wrapped && len(batch) < s.cfg.BatchSize {
+		next, err := s.src.ListIntentsAfter(ctx, pos.CreatedAt, pos.ID, 1)
+		if err != nil {
+			return nil, fmt.Errorf("list intents after %s: %w", pos.ID, err)
+		}
+		wrapped = len(next) == 0
+	}
+
+	s.mu.Lock()
+	s.pos = pos
+	if wrapped {
+		s.pos = Position{}
+		s.passes++
+	}
+	passes := s.passes
+	s.mu.Unlock()
+
+	for _, issue := range issues {
+		if l := s.cfg.Logger; l != nil {
+			l.Warn("chain verification anomaly", "kind", string(issue.Kind), "id", issue.ID, "hash", issue.Hash, "detail", issue.Detail)
+		}
+		if s.cfg.OnAnomaly != nil {
+			s.cfg.OnAnomaly(issue)
+		}
+	}
+	if l := s.cfg.Logger; l != nil && wrapped {
+		l.Info("chain verification pass complete", "passes", passes)
+	}
+	return issues, nil
+}
+
+// Run calls Step every Interval until ctx is cancelled, then returns ctx.Err().
+func (s *Scanner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Step(ctx); err != nil && ctx.Err() == nil {
+			if l := s.cfg.Logger; l != nil {
+				l.Error("chain verification step failed", "error", err)
+			}
+			if s.cfg.OnError != nil {
+				s.cfg.OnError(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
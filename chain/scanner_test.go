@@ -0,0 +1,138 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store/memstore"
+)
+
+func TestScannerStepsAndWraps(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, 4)
+
+	tampered := records[1]
+	tampered.ID = "intent-tampered"
+	tampered.Prompt = "rewritten"
+	tampered.Hash = "not-the-real-hash"
+	forged(t, s, tampered)
+
+	var reported []Issue
+	scanner := NewScanner(s, ScannerConfig{
+		BatchSize: 3,
+		OnAnomaly: func(issue Issue) { reported = append(reported, issue) },
+	})
+
+	issues, err := scanner.Step(ctx)
+	if err != nil {
+		t.Fatalf("step: %v", err)
+	}
+	if len(issues) != 1 || issues[0].ID != tampered.ID || issues[0].Kind != IssueAlteredPayload {
+		t.Fatalf("expected tampered record in first batch, got %+v", issues)
+	}
+	// The tampered copy shares records[1]'s created_at and sorts after it by ID.
+	if pos := scanner.Position(); pos.ID != tampered.ID {
+		t.Fatalf("expected position at %s, got %+v", tampered.ID, pos)
+	}
+
+	if _, err := scanner.Step(ctx); err != nil {
+		t.Fatalf("step: %v", err)
+	}
+	if scanner.Passes() != 1 || scanner.Position() != (Position{}) {
+		t.Fatalf("expected a completed pass and wrapped position, got %d %+v", scanner.Passes(), scanner.Position())
+	}
+	if len(reported) != 1 {
+		t.Fatalf("expected one anomaly callback, got %d", len(reported))
+	}
+
+	resumed := NewScanner(s, ScannerConfig{Start: Position{CreatedAt: records[3].CreatedAt, ID: records[3].ID}})
+	if issues, err := resumed.Step(ctx); err != nil || len(issues) != 0 || resumed.Passes() != 1 {
+		t.Fatalf("expected clean resumed pass, got %+v %v", issues, err)
+	}
+}
+
+// clampedSource returns at most max records per page, as a store configured
+// with a maximum list limit does.
+type clampedSource struct {
+	ScanSource
+	max int
+}
+
+func (c clampedSource) ListIntentsAfter(ctx context.Context, createdAt, id string, limit int) ([]model.IntentRecord, error) {
+	return c.ScanSource.ListIntentsAfter(ctx, createdAt, id, min(limit, c.max))
+}
+
+func TestScannerDoesNotWrapOnClampedPages(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, 5)
+
+	scanner := NewScanner(clampedSource{ScanSource: s, max: 2}, ScannerConfig{BatchSize: 10})
+	for _, want := range []string{records[1].ID, records[3].ID, ""} {
+		if _, err := scanner.Step(ctx); err != nil {
+			t.Fatalf("step: %v", err)
+		}
+		if pos := scanner.Position(); pos.ID != want {
+			t.Fatalf("expected position %q, got %+v", want, pos)
+		}
+	}
+	if scanner.Passes() != 1 {
+		t.Fatalf("expected one completed pass, got %d", scanner.Passes())
+	}
+}
+
+func TestScannerRunStopsOnCancel(t *testing.T) {
+	s := memstore.New()
+	buildChain(t, s, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scanner := NewScanner(s, ScannerConfig{
+		BatchSize: 1,
+		Interval:  time.Millisecond,
+		OnError:   func(err error) { t.Errorf("unexpected error: %v", err) },
+	})
+	done := make(chan error, 1)
+	go func() { done <- scanner.Run(ctx) }()
+
+	deadline := time.After(2 * time.Second)
+	for scanner.Passes() < 2 {
+		select {
+		case <-deadline:
+			t.Fatalf("scanner made no progress")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestScannerLogsAnomaliesAndPasses(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, 2)
+	tampered := records[0]
+	tampered.ID = "intent-tampered"
+	tampered.Hash = "not-the-real-hash"
+	forged(t, s, tampered)
+
+	var buf bytes.Buffer
+	scanner := NewScanner(s, ScannerConfig{BatchSize: 10, Logger: slog.New(slog.NewTextHandler(&buf, nil))})
+	if _, err := scanner.Step(ctx); err != nil {
+		t.Fatalf("step: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `level=WARN msg="chain verification anomaly" kind=altered_payload id=intent-tampered`) {
+		t.Fatalf("expected anomaly log, got:\n%s", out)
+	}
+	if !strings.Contains(out, `msg="chain verification pass complete" passes=1`) {
+		t.Fatalf("expected pass log, got:\n%s", out)
+	}
+}
@@ -0,0 +1,19 @@
+package chain
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/chuxorg/chux-yanzi-core/chain"
+
+// tracer uses the provider of the span in ctx, so verification is traced
+// wherever its caller is, and falls back to the global provider.
+func tracer(ctx context.Context) trace.Tracer {
+	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+		return span.TracerProvider().Tracer(tracerName)
+	}
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
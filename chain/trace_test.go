@@ -0,0 +1,41 @@
+package chain
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/chuxorg/chux-yanzi-core/store/memstore"
+)
+
+func TestVerifyChainSpanUsesCallerProvider(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	s := memstore.New()
+	records := buildChain(t, s, 3)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	if _, err := VerifyChain(ctx, s, records[2].Hash); err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	parent.End()
+
+	for _, span := range rec.Ended() {
+		if span.Name() != "chain.VerifyChain" {
+			continue
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Fatal("verification span is not a child of the caller's span")
+		}
+		for _, kv := range span.Attributes() {
+			if kv.Key == "chain.length" && kv.Value != attribute.IntValue(3) {
+				t.Fatalf("expected chain.length 3, got %v", kv.Value.Emit())
+			}
+		}
+		return
+	}
+	t.Fatal("no chain.VerifyChain span recorded")
+}
@@ -0,0 +1,358 @@
+// Package chain verifies and analyzes prev_hash-linked intent chains.
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Reader resolves intents by hash. store.Store and store/memstore satisfy it.
+// A hash that is not stored must produce an error matching ErrNotFound.
+type Reader interface {
+	GetIntentByHash(ctx context.Context, hash string) (model.IntentRecord, error)
+}
+
+// IssueKind classifies a chain verification failure.
+type IssueKind string
+
+const (
+	// IssueMissingRecord means no intent is stored under a referenced hash.
+	IssueMissingRecord IssueKind = "missing_record"
+	// IssueAlteredPayload means a record's recomputed hash differs from its stored hash.
+	IssueAlteredPayload IssueKind = "altered_payload"
+	// IssueBrokenLink means following prev_hash revisits an earlier record, so the
+	// chain never reaches a genesis record.
+	IssueBrokenLink IssueKind = "broken_link"
+	// IssueTimestampRegression means a record was created after its successor.
+	IssueTimestampRegression IssueKind = "timestamp_regression"
+	// IssueUnverifiable means a record has a keyed (HMAC) hash and no secret
+	// was given to recompute it.
+	IssueUnverifiable IssueKind = "unverifiable"
+)
+
+// Issue is a single verification failure.
+type Issue struct {
+	Kind IssueKind
+	// Hash is the stored (or, for a missing record, referenced) hash.
+	Hash string
+	// ID is empty when the record is missing.
+	ID     string
+	Detail string
+}
+
+// Report is the result of walking a chain from its head toward genesis.
+type Report struct {
+	Head string
+	// Length is the number of records visited.
+	Length int
+	// Genesis is the hash of the last record reached, which has no prev_hash
+	// when the chain is complete.
+	Genesis string
+	Issues  []Issue
+}
+
+// Valid reports whether the chain verified without issues.
+func (r Report) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// AncestorReader is a Reader that can also return a record and up to
+// limit-1 of its ancestors, newest first, in one call. VerifyChain fetches
+// records in batches through it when r implements it; store.Store does.
+type AncestorReader interface {
+	Reader
+	ListAncestors(ctx context.Context, hash string, limit int) ([]model.IntentRecord, error)
+}
+
+// DefaultVerifyBatchSize is the VerifyOptions.BatchSize used when none is set.
+const DefaultVerifyBatchSize = 1000
+
+// VerifyOptions tune VerifyChainWithOptions.
+type VerifyOptions struct {
+	// TrustedHash stops the walk, as for VerifyChainUntil.
+	TrustedHash string
+	// Workers is how many goroutines recompute hashes; values <= 0 select
+	// runtime.GOMAXPROCS(0).
+	Workers int
+	// BatchSize is how many records are fetched ahead of the link checks and
+	// hashed together; values <= 0 select DefaultVerifyBatchSize.
+	BatchSize int
+	// HMACSecret verifies keyed (hmac-sha256) hashes. Without it, records
+	// with keyed hashes are reported as IssueUnverifiable.
+	HMACSecret []byte
+}
+
+// VerifyChain walks prev_hash links from headHash, recomputing each record's hash
+// and checking that timestamps never decrease toward the head. Verification
+// continues past altered payloads and regressions, and stops at a missing record
+// or a cycle. Errors are returned only when the store itself fails; use
+// Report.Err to treat issues as an error.
+func VerifyChain(ctx context.Context, r Reader, headHash string) (Report, error) {
+	return VerifyChainWithOptions(ctx, r, headHash, VerifyOptions{})
+}
+
+// VerifyChainUntil is VerifyChain stopping at trustedHash, typically the head of
+// a trusted checkpoint. The trusted record and its ancestors are not revisited;
+// Genesis is the oldest record verified. An empty trustedHash walks to genesis.
+func VerifyChainUntil(ctx context.Context, r Reader, headHash, trustedHash string) (Report, error) {
+	return VerifyChainWithOptions(ctx, r, headHash, VerifyOptions{TrustedHash: trustedHash})
+}
+
+// VerifyChainWithOptions is VerifyChain with opts. Records are fetched a batch
+// ahead of verification, through AncestorReader when r supports it, and each
+// batch's hashes are recomputed in parallel; links and timestamps are still
+// checked in chain order, so the report is the same as a one-by-one walk.
+func VerifyChainWithOptions(ctx context.Context, r Reader, headHash string, opts VerifyOptions) (report Report, err error) {
+	ctx, span := tracer(ctx).Start(ctx, "chain.VerifyChain", trace.WithAttributes(attribute.String("chain.head", headHash)))
+	defer func() {
+		span.SetAttributes(attribute.Int("chain.length", report.Length), attribute.Int("chain.issues", len(report.Issues)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	report = Report{Head: headHash}
+	if headHash == "" {
+		return report, errors.New("head hash is required")
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultVerifyBatchSize
+	}
+
+	// The fetcher is stopped and waited for before returning, so r is not
+	// read after the call.
+	fetchCtx, cancel := context.WithCancel(ctx)
+	batches := make(chan batch, 1)
+	fetched := make(chan struct{})
+	go func() {
+		defer close(fetched)
+		fetchBatches(fetchCtx, r, headHash, opts.TrustedHash, opts.BatchSize, batches)
+	}()
+	defer func() {
+		cancel()
+		<-fetched
+	}()
+
+	seen := make(map[string]struct{})
+	var successor *model.IntentRecord
+	current := headHash
+	for current != "" && current != opts.TrustedHash {
+		b, ok := <-batches
+		if !ok {
+			// The fetcher only stops early when ctx is done.
+			return report, ctx.Err()
+		}
+		if b.err != nil {
+			return report, b.err
+		}
+
+		// Follow the links through the batch before hashing it, so records
+		// past a cycle or the trusted hash are never checked.
+		var walked []model.IntentRecord
+		cycle := false
+		for _, record := range b.records {
+			if current == "" || current == opts.TrustedHash {
+				break
+			}
+			if _, ok := seen[current]; ok {
+				cycle = true
+				break
+			}
+			if record.Hash != current {
+				return report, fmt.Errorf("load intent %s: reader returned intent %s", current, record.Hash)
+			}
+			seen[current] = struct{}{}
+			walked = append(walked, record)
+			current = record.PrevHash
+		}
+
+		payload := payloadIssues(walked, opts.Workers, opts.HMACSecret)
+		for i := range walked {
+			record := &walked[i]
+			report.Length++
+			report.Genesis = record.Hash
+			if payload[i] != nil {
+				report.Issues = append(report.Issues, *payload[i])
+			}
+			if successor != nil && createdAfter(*record, *successor) {
+				report.Issues = append(report.Issues, Issue{
+					Kind:   IssueTimestampRegression,
+					Hash:   record.Hash,
+					ID:     record.ID,
+					Detail: fmt.Sprintf("created_at %s is after successor %s created_at %s", record.CreatedAt, successor.ID, successor.CreatedAt),
+				})
+			}
+			successor = record
+		}
+
+		switch {
+		case cycle:
+			report.Issues = append(report.Issues, Issue{
+				Kind:   IssueBrokenLink,
+				Hash:   current,
+				Detail: "prev_hash cycle revisits " + current,
+			})
+			return report, nil
+		case b.missing != "" && current == b.missing:
+			detail := "head record not found"
+			if successor != nil {
+				detail = "referenced by prev_hash of " + successor.ID
+			}
+			report.Issues = append(report.Issues, Issue{Kind: IssueMissingRecord, Hash: current, Detail: detail})
+			return report, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// batch is a run of linked records, newest first. missing is set when the
+// record the run would continue with is not stored.
+type batch struct {
+	records []model.IntentRecord
+	missing string
+	err     error
+}
+
+// fetchBatches sends batches of up to size records following prev_hash from
+// head until genesis, the trusted hash, a missing record, an error, or ctx is
+// done, then closes out. The trusted record is never sent. The caller checks
+// the links; a cycle just repeats until it cancels.
+func fetchBatches(ctx context.Context, r Reader, head, trusted string, size int, out chan<- batch) {
+	defer close(out)
+	current := head
+	for current != "" && current != trusted {
+		b := fetchBatch(ctx, r, current, trusted, size)
+		select {
+		case out <- b:
+		case <-ctx.Done():
+			return
+		}
+		if b.err != nil || b.missing != "" {
+			return
+		}
+		current = b.records[len(b.records)-1].PrevHash
+	}
+}
+
+func fetchBatch(ctx context.Context, r Reader, hash, trusted string, size int) batch {
+	if ar, ok := r.(AncestorReader); ok {
+		records, err := ar.ListAncestors(ctx, hash, size)
+		if err != nil {
+			return batch{err: fmt.Errorf("load intent %s: %w", hash, err)}
+		}
+		if len(records) == 0 {
+			return batch{missing: hash}
+		}
+		// The first record is the one asked for, which the caller checks.
+		for i := 1; i < len(records); i++ {
+			if records[i].Hash == trusted {
+				return batch{records: records[:i]}
+			}
+		}
+		return batch{records: records}
+	}
+	var b batch
+	for len(b.records) < size && hash != "" && hash != trusted {
+		record, err := r.GetIntentByHash(ctx, hash)
+		if errors.Is(err, ErrNotFound) {
+			b.missing = hash
+			break
+		}
+		if err != nil {
+			b.err = fmt.Errorf("load intent %s: %w", hash, err)
+			break
+		}
+		b.records = append(b.records, record)
+		hash = record.PrevHash
+	}
+	return b
+}
+
+// payloadIssues runs payloadIssue over records on up to workers goroutines.
+// The result is indexed like records, nil where the payload checks out.
+func payloadIssues(records []model.IntentRecord, workers int, secret []byte) []*Issue {
+	issues := make([]*Issue, len(records))
+	if len(records) == 0 {
+		return issues
+	}
+	chunk := (len(records) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < len(records); lo += chunk {
+		hi := min(lo+chunk, len(records))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := lo; i < hi; i++ {
+				if issue, ok := payloadIssue(records[i], secret); ok {
+					issues[i] = &issue
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return issues
+}
+
+// payloadIssue recomputes record's hash and reports a mismatch. Keyed (HMAC)
+// hashes are recomputed with secret and reported as unverifiable without it.
+// Tombstoned records no longer hold the content, so their hashes are not
+// recomputed; their links still are, and a tombstone that still carries
+// content is reported as altered.
+func payloadIssue(record model.IntentRecord, secret []byte) (Issue, bool) {
+	if record.Tombstoned() {
+		if err := record.CheckTombstone(); err != nil {
+			return Issue{Kind: IssueAlteredPayload, Hash: record.Hash, ID: record.ID, Detail: err.Error()}, true
+		}
+		return Issue{}, false
+	}
+	if hash.IsKeyed(record.Hash) {
+		if secret == nil {
+			return Issue{Kind: IssueUnverifiable, Hash: record.Hash, ID: record.ID, Detail: "keyed hash cannot be verified without the HMAC secret"}, true
+		}
+		if err := hash.VerifyHMACIntent(record, secret); err != nil {
+			return Issue{Kind: IssueAlteredPayload, Hash: record.Hash, ID: record.ID, Detail: err.Error()}, true
+		}
+		return Issue{}, false
+	}
+	recomputed, err := hash.Recompute(record)
+	if err != nil {
+		return Issue{Kind: IssueAlteredPayload, Hash: record.Hash, ID: record.ID, Detail: "rehash failed: " + err.Error()}, true
+	}
+	if recomputed != record.Hash {
+		return Issue{Kind: IssueAlteredPayload, Hash: record.Hash, ID: record.ID, Detail: "recomputed hash " + recomputed}, true
+	}
+	return Issue{}, false
+}
+
+// createdAfter reports whether a was created strictly after b. Unparseable
+// timestamps are left to the payload check.
+func createdAfter(a, b model.IntentRecord) bool {
+	at, err := time.Parse(time.RFC3339Nano, a.CreatedAt)
+	if err != nil {
+		return false
+	}
+	bt, err := time.Parse(time.RFC3339Nano, b.CreatedAt)
+	if err != nil {
+		return false
+	}
+	return at.After(bt)
+}
@@ -0,0 +1,365 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store/memstore"
+)
+
+// buildChain stores n linked intents and returns them oldest first.
+func buildChain(t *testing.T, s *memstore.Store, n int) []model.IntentRecord {
+	t.Helper()
+	records := make([]model.IntentRecord, 0, n)
+	prev := ""
+	for i := 0; i < n; i++ {
+		record := model.IntentRecord{
+			ID:         fmt.Sprintf("intent-%03d", i),
+			CreatedAt:  time.Date(2026, 2, 9, 10, 0, i, 0, time.UTC).Format(time.RFC3339Nano),
+			Author:     "alice",
+			SourceType: "cli",
+			Prompt:     fmt.Sprintf("prompt %d", i),
+			Response:   fmt.Sprintf("response %d", i),
+			PrevHash:   prev,
+		}
+		sum, err := hash.HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash intent %d: %v", i, err)
+		}
+		record.Hash = sum
+		if err := s.CreateIntent(context.Background(), record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+		records = append(records, record)
+		prev = sum
+	}
+	return records
+}
+
+func TestVerifyChainValid(t *testing.T) {
+	s := memstore.New()
+	records := buildChain(t, s, 5)
+
+	report, err := VerifyChain(context.Background(), s, records[4].Hash)
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if !report.Valid() || report.Length != 5 || report.Genesis != records[0].Hash {
+		t.Fatalf("expected valid 5-record chain, got %+v", report)
+	}
+}
+
+func TestVerifyChainUntilStopsAtTrustedHash(t *testing.T) {
+	s := memstore.New()
+	records := buildChain(t, s, 5)
+
+	report, err := VerifyChainUntil(context.Background(), s, records[4].Hash, records[2].Hash)
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if !report.Valid() || report.Length != 2 || report.Genesis != records[3].Hash {
+		t.Fatalf("expected 2 records verified above the trusted hash, got %+v", report)
+	}
+}
+
+// countingReader counts GetIntentByHash lookups by hash.
+type countingReader struct {
+	*memstore.Store
+	mu      sync.Mutex
+	fetched map[string]int
+}
+
+func (c *countingReader) GetIntentByHash(ctx context.Context, hash string) (model.IntentRecord, error) {
+	c.mu.Lock()
+	c.fetched[hash]++
+	c.mu.Unlock()
+	return c.Store.GetIntentByHash(ctx, hash)
+}
+
+func TestVerifyChainDoesNotFetchPastTrustedHash(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, 10)
+
+	for _, batchSize := range []int{1, 3, 100} {
+		one := &countingReader{Store: s, fetched: make(map[string]int)}
+		report, err := VerifyChainWithOptions(ctx, one, records[9].Hash, VerifyOptions{TrustedHash: records[5].Hash, BatchSize: batchSize})
+		if err != nil {
+			t.Fatalf("verify chain: %v", err)
+		}
+		if !report.Valid() || report.Length != 4 {
+			t.Fatalf("batches of %d: expected 4 records verified, got %+v", batchSize, report)
+		}
+		for _, record := range records[:6] {
+			if n := one.fetched[record.Hash]; n != 0 {
+				t.Fatalf("batches of %d: fetched %s at or past the trusted hash", batchSize, record.ID)
+			}
+		}
+	}
+
+	// Records 9-7 and then 6-4 are listed; the second batch reaches the
+	// trusted hash, so no third is requested.
+	batched := &ancestorReader{Store: s}
+	if _, err := VerifyChainWithOptions(ctx, batched, records[9].Hash, VerifyOptions{TrustedHash: records[5].Hash, BatchSize: 3}); err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if batched.calls != 2 {
+		t.Fatalf("expected 2 ListAncestors calls, got %d", batched.calls)
+	}
+}
+
+// ancestorReader adds ListAncestors to a memstore, counting calls.
+type ancestorReader struct {
+	*memstore.Store
+	calls int
+}
+
+func (a *ancestorReader) ListAncestors(ctx context.Context, hash string, limit int) ([]model.IntentRecord, error) {
+	a.calls++
+	var records []model.IntentRecord
+	for hash != "" && len(records) < limit {
+		record, err := a.GetIntentByHash(ctx, hash)
+		if errors.Is(err, ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+		hash = record.PrevHash
+	}
+	return records, nil
+}
+
+func TestVerifyChainWithOptionsMatchesOneByOneWalk(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, 10)
+	altered := records[3]
+	altered.ID, altered.Prompt, altered.Hash = "altered", "tampered", "stale-hash"
+	forged(t, s, altered)
+	early := model.IntentRecord{ID: "early", CreatedAt: "2026-02-09T09:00:00Z", Author: "alice", SourceType: "cli", Prompt: "p", Response: "r", PrevHash: altered.Hash}
+	early.Hash, _ = hash.HashIntent(early)
+	forged(t, s, early)
+	orphan := model.IntentRecord{ID: "orphan", CreatedAt: "2026-02-09T11:00:00Z", Author: "alice", SourceType: "cli", Prompt: "p", Response: "r", PrevHash: "deadbeef"}
+	orphan.Hash, _ = hash.HashIntent(orphan)
+	forged(t, s, orphan)
+	forged(t, s, model.IntentRecord{ID: "a", CreatedAt: "2026-02-09T10:00:00Z", Author: "x", SourceType: "cli", Prompt: "p", Response: "r", PrevHash: "hb", Hash: "ha"})
+	forged(t, s, model.IntentRecord{ID: "b", CreatedAt: "2026-02-09T10:00:00Z", Author: "x", SourceType: "cli", Prompt: "p", Response: "r", PrevHash: "ha", Hash: "hb"})
+
+	cases := []struct {
+		name    string
+		head    string
+		trusted string
+	}{
+		{"valid", records[9].Hash, ""},
+		{"trusted", records[9].Hash, records[2].Hash},
+		{"altered and regressed", early.Hash, ""},
+		{"missing", orphan.Hash, ""},
+		{"missing head", "nope", ""},
+		{"cycle", "ha", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			want, err := VerifyChainWithOptions(ctx, s, tc.head, VerifyOptions{TrustedHash: tc.trusted, Workers: 1, BatchSize: 1})
+			if err != nil {
+				t.Fatalf("verify chain one by one: %v", err)
+			}
+			for _, batchSize := range []int{2, 3, 100} {
+				r := &ancestorReader{Store: s}
+				got, err := VerifyChainWithOptions(ctx, r, tc.head, VerifyOptions{TrustedHash: tc.trusted, Workers: 4, BatchSize: batchSize})
+				if err != nil {
+					t.Fatalf("verify chain in batches of %d: %v", batchSize, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("batches of %d: expected %+v, got %+v", batchSize, want, got)
+				}
+				if r.calls == 0 {
+					t.Fatalf("expected records fetched through ListAncestors")
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyChainKeyedHashes(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	record := model.IntentRecord{
+		ID:         "intent-keyed",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "response",
+	}
+	sum, err := hash.HMACIntent(record, []byte("secret"))
+	if err != nil {
+		t.Fatalf("hmac intent: %v", err)
+	}
+	record.Hash = sum
+	forged(t, s, record)
+
+	report, err := VerifyChain(ctx, s, sum)
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	assertIssue(t, report, IssueUnverifiable, sum)
+
+	report, err = VerifyChainWithOptions(ctx, s, sum, VerifyOptions{HMACSecret: []byte("secret")})
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if !report.Valid() || report.Length != 1 {
+		t.Fatalf("expected keyed record to verify with the secret, got %+v", report)
+	}
+
+	report, err = VerifyChainWithOptions(ctx, s, sum, VerifyOptions{HMACSecret: []byte("other")})
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	assertIssue(t, report, IssueAlteredPayload, sum)
+}
+
+// forged stores a record under the given hash without recomputing it.
+func forged(t *testing.T, s *memstore.Store, record model.IntentRecord) {
+	t.Helper()
+	if err := s.CreateIntent(context.Background(), record); err != nil {
+		t.Fatalf("create forged intent: %v", err)
+	}
+}
+
+func TestVerifyChainReportsIssues(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("missing record", func(t *testing.T) {
+		s := memstore.New()
+		records := buildChain(t, s, 2)
+		head := records[1]
+		head.ID, head.PrevHash = "orphan", "deadbeef"
+		sum, _ := hash.HashIntent(head)
+		head.Hash = sum
+		forged(t, s, head)
+
+		report, err := VerifyChain(ctx, s, head.Hash)
+		if err != nil {
+			t.Fatalf("verify chain: %v", err)
+		}
+		assertIssue(t, report, IssueMissingRecord, "deadbeef")
+	})
+
+	t.Run("altered payload", func(t *testing.T) {
+		s := memstore.New()
+		records := buildChain(t, s, 1)
+		altered := records[0]
+		altered.ID, altered.Prompt, altered.Hash = "altered", "tampered", "stale-hash"
+		forged(t, s, altered)
+
+		report, err := VerifyChain(ctx, s, "stale-hash")
+		if err != nil {
+			t.Fatalf("verify chain: %v", err)
+		}
+		assertIssue(t, report, IssueAlteredPayload, "stale-hash")
+	})
+
+	t.Run("tombstone with content", func(t *testing.T) {
+		s := memstore.New()
+		records := buildChain(t, s, 1)
+		tombstone := records[0]
+		tombstone.ID, tombstone.Hash = "tombstone", "tombstone-hash"
+		tombstone.TombstonedAt = "2026-02-10T10:00:00Z"
+		forged(t, s, tombstone)
+
+		report, err := VerifyChain(ctx, s, "tombstone-hash")
+		if err != nil {
+			t.Fatalf("verify chain: %v", err)
+		}
+		assertIssue(t, report, IssueAlteredPayload, "tombstone-hash")
+	})
+
+	t.Run("broken link", func(t *testing.T) {
+		s := memstore.New()
+		forged(t, s, model.IntentRecord{ID: "a", CreatedAt: "2026-02-09T10:00:00Z", Author: "x", SourceType: "cli", Prompt: "p", Response: "r", PrevHash: "hb", Hash: "ha"})
+		forged(t, s, model.IntentRecord{ID: "b", CreatedAt: "2026-02-09T10:00:00Z", Author: "x", SourceType: "cli", Prompt: "p", Response: "r", PrevHash: "ha", Hash: "hb"})
+
+		report, err := VerifyChain(ctx, s, "ha")
+		if err != nil {
+			t.Fatalf("verify chain: %v", err)
+		}
+		assertIssue(t, report, IssueBrokenLink, "ha")
+	})
+
+	t.Run("timestamp regression", func(t *testing.T) {
+		s := memstore.New()
+		records := buildChain(t, s, 1)
+		head := model.IntentRecord{
+			ID:         "early",
+			CreatedAt:  "2026-02-09T09:00:00Z",
+			Author:     "alice",
+			SourceType: "cli",
+			Prompt:     "p",
+			Response:   "r",
+			PrevHash:   records[0].Hash,
+		}
+		sum, _ := hash.HashIntent(head)
+		head.Hash = sum
+		forged(t, s, head)
+
+		report, err := VerifyChain(ctx, s, head.Hash)
+		if err != nil {
+			t.Fatalf("verify chain: %v", err)
+		}
+		assertIssue(t, report, IssueTimestampRegression, records[0].Hash)
+	})
+}
+
+func assertIssue(t *testing.T, report Report, kind IssueKind, hash string) {
+	t.Helper()
+	for _, issue := range report.Issues {
+		if issue.Kind == kind && issue.Hash == hash {
+			return
+		}
+	}
+	t.Fatalf("expected %s issue for %s, got %+v", kind, hash, report.Issues)
+}
+
+func TestReportErr(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, 1)
+
+	report, err := VerifyChain(ctx, s, records[0].Hash)
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("expected no error for a valid chain, got %v", err)
+	}
+
+	altered := records[0]
+	altered.ID, altered.Prompt, altered.Hash = "altered", "tampered", "stale-hash"
+	forged(t, s, altered)
+	report, err = VerifyChain(ctx, s, "stale-hash")
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	err = report.Err()
+	if !errors.Is(err, ErrChainBroken) || !errors.Is(err, ErrHashMismatch) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a broken chain with a hash mismatch, got %v", err)
+	}
+
+	report, err = VerifyChain(ctx, s, "missing")
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if err := report.Err(); !errors.Is(err, ErrChainBroken) || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a broken chain with a missing record, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// captureTemplate seeds the editor for capture -editor. Lines starting with
+// "#" are dropped; the marker line separates prompt from response.
+const (
+	captureMarker   = "==== response ===="
+	captureTemplate = "# Enter the prompt above the marker and the response below it.\n" +
+		"# Lines starting with '#' are ignored. Leave either part empty to abort.\n\n" +
+		captureMarker + "\n\n"
+)
+
+// runCapture records one prompt/response pair with author and source type
+// from the config. Without -prompt or -editor it reads the prompt from stdin
+// up to a line holding only ".", then the response up to another "." line or
+// EOF, so it works both interactively and with piped input.
+func runCapture(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "capture", "")
+	var record model.IntentRecord
+	var tags stringList
+	useEditor := fs.Bool("editor", false, "compose the prompt and response in $VISUAL or $EDITOR")
+	fs.StringVar(&record.Prompt, "prompt", "", "prompt text; stdin is then read as the response")
+	fs.StringVar(&record.Title, "title", "", "title")
+	fs.StringVar(&record.Author, "author", e.config.author(), "author (default from config, then $USER)")
+	fs.StringVar(&record.SourceType, "source", e.config.sourceType(), "source type")
+	fs.Var(&tags, "tag", "tag (repeatable)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 || (*useEditor && record.Prompt != "") {
+		fs.Usage()
+		return errUsage
+	}
+
+	var err error
+	switch {
+	case *useEditor:
+		record.Prompt, record.Response, err = captureEditor(e)
+	case record.Prompt != "":
+		var data []byte
+		data, err = io.ReadAll(e.stdin)
+		record.Response = string(data)
+	default:
+		record.Prompt, record.Response, err = captureSections(e)
+	}
+	if err != nil {
+		return err
+	}
+	record.Prompt = strings.TrimSpace(record.Prompt)
+	record.Response = strings.TrimSpace(record.Response)
+	if record.Prompt == "" || record.Response == "" {
+		return errors.New("prompt and response are both required; nothing recorded")
+	}
+	record.Tags = tags
+
+	stored, err := e.store.AppendIntent(ctx, record)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "recorded %s (%s)\n", stored.ID, stored.Hash)
+	return nil
+}
+
+// captureSections reads a "."-terminated prompt and response from stdin.
+func captureSections(e *env) (prompt, response string, err error) {
+	in := bufio.NewReader(e.stdin)
+	fmt.Fprintln(e.stderr, `Prompt (end with a line containing only "."):`)
+	prompt, err = readSection(in)
+	if err != nil {
+		return "", "", err
+	}
+	fmt.Fprintln(e.stderr, `Response (end with a line containing only "." or EOF):`)
+	response, err = readSection(in)
+	return prompt, response, err
+}
+
+// readSection reads lines up to a "." line or EOF.
+func readSection(in *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		line, err := in.ReadString('\n')
+		if strings.TrimRight(line, "\r\n") == "." {
+			return b.String(), nil
+		}
+		b.WriteString(line)
+		if errors.Is(err, io.EOF) {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// captureEditor opens the editor on a template and splits the result at the
+// marker.
+func captureEditor(e *env) (prompt, response string, err error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return "", "", errors.New("set $VISUAL or $EDITOR to use -editor")
+	}
+
+	f, err := os.CreateTemp("", "yanzi-capture-*.txt")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(captureTemplate); err != nil {
+		_ = f.Close()
+		return "", "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", "", err
+	}
+
+	// $EDITOR may carry arguments, as in "code --wait".
+	argv := append(strings.Fields(editor), f.Name())
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, e.stderr, e.stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("run editor: %w", err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", "", err
+	}
+
+	var kept []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, line)
+		}
+	}
+	prompt, response, ok := strings.Cut(strings.Join(kept, "\n"), captureMarker)
+	if !ok {
+		return "", "", fmt.Errorf("marker line %q was removed", captureMarker)
+	}
+	return prompt, response, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// lastIntent returns the newest intent in db.
+func lastIntent(t *testing.T, db string) model.IntentRecord {
+	t.Helper()
+	code, out, errOut := yanzi(t, db, "", "list", "-limit", "100")
+	if code != 0 {
+		t.Fatalf("list: exit %d: %s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	var record model.IntentRecord
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &record); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return record
+}
+
+func TestCaptureUsesConfig(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "yanzi.db")
+	cfg := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(cfg, []byte(`{"author":"carol","source_type":"chatgpt"}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("YANZI_CONFIG", cfg)
+
+	code, out, errOut := yanzi(t, db, "What is a Merkle tree?\n.\nA hash tree.\nSecond line.\n", "capture")
+	if code != 0 || !strings.HasPrefix(out, "recorded ") {
+		t.Fatalf("capture: exit %d: %q %q", code, out, errOut)
+	}
+	record := lastIntent(t, db)
+	if record.Author != "carol" || record.SourceType != "chatgpt" {
+		t.Fatalf("expected config defaults, got %s/%s", record.Author, record.SourceType)
+	}
+	if record.Prompt != "What is a Merkle tree?" || record.Response != "A hash tree.\nSecond line." {
+		t.Fatalf("unexpected bodies %q / %q", record.Prompt, record.Response)
+	}
+
+	if code, _, errOut := yanzi(t, db, "piped answer\n", "capture", "-prompt", "question", "-author", "dave"); code != 0 {
+		t.Fatalf("capture -prompt: exit %d: %s", code, errOut)
+	}
+	record = lastIntent(t, db)
+	if record.Author != "dave" || record.Prompt != "question" || record.Response != "piped answer" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+}
+
+func TestCaptureEditor(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "yanzi.db")
+	editor := filepath.Join(dir, "editor.sh")
+	script := "#!/bin/sh\nprintf '# comment\\nedited prompt\\n" + captureMarker + "\\nedited response\\n' > \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0o700); err != nil {
+		t.Fatalf("write editor: %v", err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", editor)
+
+	if code, _, errOut := yanzi(t, db, "", "capture", "-editor", "-author", "alice"); code != 0 {
+		t.Fatalf("capture -editor: exit %d: %s", code, errOut)
+	}
+	record := lastIntent(t, db)
+	if record.Prompt != "edited prompt" || record.Response != "edited response" {
+		t.Fatalf("unexpected bodies %q / %q", record.Prompt, record.Response)
+	}
+}
+
+func TestCaptureRequiresBothParts(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "yanzi.db")
+	if code, _, _ := yanzi(t, db, "only a prompt\n.\n", "capture", "-author", "alice"); code != 1 {
+		t.Fatalf("expected exit 1 without a response, got %d", code)
+	}
+}
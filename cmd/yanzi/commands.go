@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	mcpapi "github.com/chuxorg/chux-yanzi-core/api/mcp"
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/export"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+func runAdd(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "add", "")
+	var record model.IntentRecord
+	var meta, promptFile, responseFile string
+	var tags stringList
+	fs.StringVar(&record.Author, "author", e.config.author(), "author (default from config, then $USER)")
+	fs.StringVar(&record.SourceType, "source", e.config.sourceType(), "source type")
+	fs.StringVar(&record.Title, "title", "", "title")
+	fs.StringVar(&record.Prompt, "prompt", "", "prompt text")
+	fs.StringVar(&record.Response, "response", "", "response text")
+	fs.StringVar(&promptFile, "prompt-file", "", "read the prompt from `file` (- for stdin)")
+	fs.StringVar(&responseFile, "response-file", "", "read the response from `file` (- for stdin)")
+	fs.StringVar(&meta, "meta", "", "meta as a JSON object")
+	fs.StringVar(&record.ThreadID, "thread", "", "thread ID")
+	fs.StringVar(&record.ParentID, "parent", "", "parent intent ID within the thread")
+	fs.Var(&tags, "tag", "tag (repeatable)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if promptFile == "-" && responseFile == "-" {
+		return errors.New("only one of -prompt-file and -response-file can read stdin")
+	}
+
+	var err error
+	if promptFile != "" {
+		if record.Prompt, err = readText(e, promptFile); err != nil {
+			return err
+		}
+	}
+	if responseFile != "" {
+		if record.Response, err = readText(e, responseFile); err != nil {
+			return err
+		}
+	}
+	if meta != "" {
+		record.Meta = json.RawMessage(meta)
+	}
+	record.Tags = tags
+
+	stored, err := e.store.AppendIntent(ctx, record)
+	if err != nil {
+		return err
+	}
+	return printJSON(e.stdout, stored)
+}
+
+func readText(e *env, path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(e.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	return string(data), err
+}
+
+func runGet(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "get", "<id>")
+	byHash := fs.Bool("hash", false, "look the argument up as a hash")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var record model.IntentRecord
+	var err error
+	if *byHash {
+		record, err = e.store.GetIntentByHash(ctx, fs.Arg(0))
+	} else {
+		record, err = e.store.GetIntent(ctx, fs.Arg(0))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	return printJSON(e.stdout, record)
+}
+
+func runList(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "list", "")
+	var opts store.PageOptions
+	fs.StringVar(&opts.Author, "author", "", "only this author's intents")
+	fs.IntVar(&opts.Limit, "limit", 20, "page size")
+	fs.StringVar(&opts.Cursor, "cursor", "", "resume from a cursor printed by a previous list")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	page, err := e.store.ListIntentsPage(ctx, opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(e.stdout)
+	for _, record := range page.Intents {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	if page.NextCursor != "" {
+		fmt.Fprintf(e.stderr, "next cursor: %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+func runVerify(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "verify", "[chain]")
+	head := fs.String("head", "", "verify from this head `hash` instead of a chain name")
+	until := fs.String("until", "", "stop at this trusted `hash`")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	switch {
+	case fs.NArg() == 1 && *head == "":
+		var err error
+		if *head, err = e.store.ChainHead(ctx, fs.Arg(0)); err != nil {
+			return fmt.Errorf("chain %s: %w", fs.Arg(0), err)
+		}
+	case fs.NArg() == 0 && *head != "":
+	default:
+		fs.Usage()
+		return errUsage
+	}
+
+	report, err := chain.VerifyChainUntil(ctx, e.store, *head, *until)
+	if err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		fmt.Fprintf(e.stdout, "%s %s %s: %s\n", issue.Kind, issue.Hash, issue.ID, issue.Detail)
+	}
+	if !report.Valid() {
+		return fmt.Errorf("chain %s does not verify: %d issue(s) in %d record(s)", report.Head, len(report.Issues), report.Length)
+	}
+	fmt.Fprintf(e.stdout, "ok: %d record(s) from %s\n", report.Length, report.Head)
+	return nil
+}
+
+func runExport(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "export", "")
+	format := fs.String("format", "jsonl", "jsonl, csv, parquet, or bundle")
+	out := fs.String("o", "-", "output `file` (- for stdout)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	return writeOutput(e, *out, func(w io.Writer) error {
+		var n int
+		var err error
+		switch *format {
+		case "jsonl":
+			n, err = export.ExportJSONL(ctx, e.store, w, export.ExportOptions{})
+		case "csv":
+			n, err = export.ExportCSV(ctx, e.store, w, export.CSVOptions{})
+		case "parquet":
+			n, err = export.ExportParquet(ctx, e.store, w, export.ExportOptions{})
+		case "bundle":
+			var manifest export.Manifest
+			manifest, err = export.ExportBundle(ctx, e.store, w, export.BundleOptions{})
+			n = manifest.RecordCount
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+		if err == nil {
+			fmt.Fprintf(e.stderr, "exported %d intent(s)\n", n)
+		}
+		return err
+	})
+}
+
+// writeOutput runs write against path, or stdout for "-", and reports close
+// errors so a truncated file is never mistaken for a complete export.
+func writeOutput(e *env, path string, write func(io.Writer) error) error {
+	if path == "-" {
+		return write(e.stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func runImport(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "import", "[file]")
+	format := fs.String("format", "jsonl", "jsonl or bundle")
+	var opts export.ImportOptions
+	fs.BoolVar(&opts.SkipExisting, "skip-existing", false, "skip intents already in the store")
+	fs.BoolVar(&opts.AllowMissingParents, "allow-missing-parents", false, "accept intents whose parent is absent")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	r := e.stdin
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var report export.ImportReport
+	var err error
+	switch *format {
+	case "jsonl":
+		report, err = export.ImportJSONL(ctx, e.store, r, opts)
+	case "bundle":
+		_, report, err = export.ImportBundle(ctx, e.store, r, export.BundleImportOptions{ImportOptions: opts})
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	fmt.Fprintf(e.stdout, "imported %d, skipped %d\n", report.Imported, report.Skipped)
+	return err
+}
+
+func runStats(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "stats", "")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	stats, err := e.store.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	return printJSON(e.stdout, stats)
+}
+
+// runMCP serves MCP on stdin and stdout until the client closes stdin.
+func runMCP(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "mcp", "")
+	author := fs.String("author", e.config.author(), "author recorded when record_intent names none")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	return mcpapi.New(e.store, mcpapi.WithDefaultAuthor(*author)).Serve(ctx, e.stdin, e.stdout)
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// config holds per-user defaults, read from $YANZI_CONFIG or
+// <user config dir>/yanzi/config.json. Flags and environment variables take
+// precedence; a missing file is an empty config.
+type config struct {
+	DB         string `json:"db,omitempty"`
+	Author     string `json:"author,omitempty"`
+	SourceType string `json:"source_type,omitempty"`
+}
+
+func configPath() (string, error) {
+	if path := os.Getenv("YANZI_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "yanzi", "config.json"), nil
+}
+
+func loadConfig() (config, error) {
+	var cfg config
+	path, err := configPath()
+	if err != nil {
+		// No config location (e.g. $HOME unset) means no config.
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// author returns the configured author, falling back to $USER.
+func (c config) author() string {
+	if c.Author != "" {
+		return c.Author
+	}
+	return os.Getenv("USER")
+}
+
+// sourceType returns the configured source type, falling back to "cli".
+func (c config) sourceType() string {
+	if c.SourceType != "" {
+		return c.SourceType
+	}
+	return "cli"
+}
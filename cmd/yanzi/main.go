@@ -0,0 +1,161 @@
+// Command yanzi records, inspects, verifies, and moves intents in a local
+// store without writing a Go program.
+//
+// Usage:
+//
+//	yanzi [-db path] <command> [flags] [args]
+//
+// The store path defaults to $YANZI_DB, then the db set in the config file
+// ($YANZI_CONFIG or <user config dir>/yanzi/config.json, which also sets the
+// default author and source_type), then yanzi.db. Commands that write migrate
+// the schema on open; commands that only read open an existing store
+// read-only and leave its schema alone. Run "yanzi help" for the list of
+// commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// env is what a command runs against.
+type env struct {
+	config config
+	store  *store.Store
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+	// readOnly opens the store with store.OpenReadOnly and skips Migrate.
+	readOnly bool
+}
+
+var commands = map[string]command{
+	"add":     {"append an intent to its author's chain", runAdd, false},
+	"capture": {"record a prompt and response interactively or from a pipe", runCapture, false},
+	"get":     {"print an intent by ID or hash", runGet, true},
+	"list":    {"list intents oldest first", runList, true},
+	"mcp":     {"serve the ledger to AI assistants over MCP (stdio)", runMCP, false},
+	"verify":  {"verify a chain", runVerify, true},
+	"export":  {"export intents as jsonl, csv, parquet, or a bundle", runExport, true},
+	"import":  {"import a jsonl export or a bundle", runImport, false},
+	"stats":   {"print store statistics", runStats, true},
+}
+
+// errUsage reports a usage error already explained by the flag set.
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes one command and returns the exit code: 0 on success, 1 on
+// failure (including a chain that does not verify), and 2 on usage errors.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "yanzi: config: %v\n", err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("yanzi", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	defaultDB := os.Getenv("YANZI_DB")
+	if defaultDB == "" {
+		defaultDB = cfg.DB
+	}
+	if defaultDB == "" {
+		defaultDB = "yanzi.db"
+	}
+	dbPath := fs.String("db", defaultDB, "store `path` (default $YANZI_DB, the config db, or yanzi.db)")
+	fs.Usage = func() { usage(stderr, fs) }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 || fs.Arg(0) == "help" {
+		usage(stderr, fs)
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "yanzi: unknown command %q\n", fs.Arg(0))
+		usage(stderr, fs)
+		return 2
+	}
+
+	open := store.Open
+	if cmd.readOnly {
+		open = store.OpenReadOnly
+	}
+	s, err := open(*dbPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "yanzi: open %s: %v\n", *dbPath, err)
+		return 1
+	}
+	defer s.Close()
+	if !cmd.readOnly {
+		if err := s.Migrate(ctx); err != nil {
+			fmt.Fprintf(stderr, "yanzi: migrate %s: %v\n", *dbPath, err)
+			return 1
+		}
+	}
+
+	e := &env{config: cfg, store: s, stdin: stdin, stdout: stdout, stderr: stderr}
+	if err := cmd.run(ctx, e, fs.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "yanzi %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "usage: yanzi [-db path] <command> [flags] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-9s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w, "\nflags:")
+	fs.PrintDefaults()
+}
+
+// newFlagSet returns a flag set for a subcommand that reports errors to e.
+func newFlagSet(e *env, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet("yanzi "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: yanzi %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args into fs, mapping failures to errUsage.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
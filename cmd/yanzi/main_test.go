@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the user's own config out of the tests.
+	os.Setenv("YANZI_CONFIG", filepath.Join(os.TempDir(), "yanzi-test-missing-config.json"))
+	os.Unsetenv("YANZI_DB")
+	os.Exit(m.Run())
+}
+
+// yanzi runs the CLI against db and returns its exit code and output.
+func yanzi(t *testing.T, db, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append([]string{"-db", db}, args...), strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestAddGetListVerify(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "yanzi.db")
+
+	code, out, errOut := yanzi(t, db, "", "add", "-author", "alice", "-prompt", "p1", "-response", "r1", "-tag", "Demo", "-meta", `{"k":"v"}`)
+	if code != 0 {
+		t.Fatalf("add: exit %d: %s", code, errOut)
+	}
+	var first model.IntentRecord
+	if err := json.Unmarshal([]byte(out), &first); err != nil {
+		t.Fatalf("decode add output: %v", err)
+	}
+	if first.Hash == "" || len(first.Tags) != 1 {
+		t.Fatalf("unexpected record %+v", first)
+	}
+	if code, _, errOut := yanzi(t, db, "response from stdin", "add", "-author", "alice", "-prompt", "p2", "-response-file", "-"); code != 0 {
+		t.Fatalf("add from stdin: exit %d: %s", code, errOut)
+	}
+
+	code, out, _ = yanzi(t, db, "", "get", first.ID)
+	if code != 0 || !strings.Contains(out, first.Hash) {
+		t.Fatalf("get: exit %d: %s", code, out)
+	}
+	if code, _, _ := yanzi(t, db, "", "get", "-hash", first.Hash); code != 0 {
+		t.Fatalf("get by hash: exit %d", code)
+	}
+	if code, _, _ := yanzi(t, db, "", "get", "missing"); code != 1 {
+		t.Fatalf("expected exit 1 for a missing intent, got %d", code)
+	}
+
+	code, out, errOut = yanzi(t, db, "", "list", "-limit", "1")
+	if code != 0 || strings.Count(out, "\n") != 1 || !strings.Contains(errOut, "next cursor:") {
+		t.Fatalf("list: exit %d: %q %q", code, out, errOut)
+	}
+	cursor := strings.TrimSpace(strings.TrimPrefix(errOut, "next cursor:"))
+	code, out, _ = yanzi(t, db, "", "list", "-cursor", cursor)
+	if code != 0 || !strings.Contains(out, "response from stdin") {
+		t.Fatalf("list from cursor: exit %d: %q", code, out)
+	}
+
+	code, out, _ = yanzi(t, db, "", "verify", "alice")
+	if code != 0 || !strings.HasPrefix(out, "ok: 2 record(s)") {
+		t.Fatalf("verify: exit %d: %q", code, out)
+	}
+
+	code, out, _ = yanzi(t, db, "", "stats")
+	if code != 0 || !strings.Contains(out, `"Total": 2`) {
+		t.Fatalf("stats: exit %d: %q", code, out)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src.db"), filepath.Join(dir, "dst.db")
+	for _, prompt := range []string{"p1", "p2", "p3"} {
+		if code, _, errOut := yanzi(t, src, "", "add", "-author", "alice", "-prompt", prompt, "-response", "r"); code != 0 {
+			t.Fatalf("add: exit %d: %s", code, errOut)
+		}
+	}
+
+	for _, format := range []string{"jsonl", "bundle"} {
+		file := filepath.Join(dir, "export."+format)
+		if code, _, errOut := yanzi(t, src, "", "export", "-format", format, "-o", file); code != 0 || !strings.Contains(errOut, "exported 3") {
+			t.Fatalf("export %s: exit %d: %s", format, code, errOut)
+		}
+		code, out, errOut := yanzi(t, dst, "", "import", "-format", format, "-skip-existing", file)
+		if code != 0 {
+			t.Fatalf("import %s: exit %d: %s", format, code, errOut)
+		}
+		want := "imported 3, skipped 0"
+		if format == "bundle" {
+			want = "imported 0, skipped 3"
+		}
+		if strings.TrimSpace(out) != want {
+			t.Fatalf("import %s: expected %q, got %q", format, want, out)
+		}
+	}
+	if code, out, _ := yanzi(t, dst, "", "verify", "alice"); code != 0 {
+		t.Fatalf("verify imported chain: exit %d: %s", code, out)
+	}
+
+	code, out, _ := yanzi(t, src, "", "export", "-format", "csv")
+	if code != 0 || strings.Count(out, "\n") != 4 {
+		t.Fatalf("csv export: exit %d: %q", code, out)
+	}
+}
+
+func TestReadCommandsOpenReadOnly(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "yanzi.db")
+	if code, _, _ := yanzi(t, db, "", "list"); code != 1 {
+		t.Fatalf("expected list of a missing store to fail, got exit %d", code)
+	}
+	if _, err := os.Stat(db); !os.IsNotExist(err) {
+		t.Fatalf("expected list not to create the store, got %v", err)
+	}
+}
+
+func TestMCPRecordsWithDefaultAuthor(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "yanzi.db")
+	stdin := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"record_intent","arguments":{"prompt":"p","response":"r"}}}` + "\n"
+	code, out, errOut := yanzi(t, db, stdin, "mcp", "-author", "assistant")
+	if code != 0 {
+		t.Fatalf("mcp: exit %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, `\"author\":\"assistant\"`) || strings.Contains(out, `"isError"`) {
+		t.Fatalf("unexpected mcp output: %s", out)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "yanzi.db")
+	if code, _, errOut := yanzi(t, db, "", "add", "-author", "alice", "-prompt", "p", "-response", "r"); code != 0 {
+		t.Fatalf("add: exit %d: %s", code, errOut)
+	}
+	for _, args := range [][]string{{}, {"bogus"}, {"get"}, {"verify"}, {"add", "extra"}} {
+		if code, _, _ := yanzi(t, db, "", args...); code != 2 {
+			t.Errorf("%v: expected exit 2, got %d", args, code)
+		}
+	}
+	if code, _, _ := yanzi(t, db, "", "export", "-format", "xml"); code != 1 {
+		t.Errorf("expected exit 1 for an unknown format, got %d", code)
+	}
+}
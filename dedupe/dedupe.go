@@ -0,0 +1,75 @@
+// Package dedupe fingerprints text with SimHash so near-duplicates can be
+// found by comparing 64-bit fingerprints: texts that share most of their
+// wording have fingerprints that differ in only a few bits.
+//
+//	a := dedupe.Fingerprint("How do I rotate the signing keys?")
+//	b := dedupe.Fingerprint("how do i rotate the signing keys")
+//	dedupe.Distance(a, b) // 0
+package dedupe
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// ShingleSize is how many consecutive words make up one feature. Texts
+// shorter than ShingleSize words are fingerprinted as a single feature.
+const ShingleSize = 3
+
+// Fingerprint returns the SimHash of text over its word shingles. Case,
+// punctuation, and whitespace are ignored. Text without words has the
+// fingerprint 0.
+func Fingerprint(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+	var weights [64]int
+	add := func(feature []string) {
+		h := fnv.New64a()
+		for i, word := range feature {
+			if i > 0 {
+				_, _ = h.Write([]byte{' '})
+			}
+			_, _ = h.Write([]byte(word))
+		}
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	if len(words) < ShingleSize {
+		add(words)
+	}
+	for i := 0; i+ShingleSize <= len(words); i++ {
+		add(words[i : i+ShingleSize])
+	}
+
+	var fp uint64
+	for bit, w := range weights {
+		if w > 0 {
+			fp |= 1 << bit
+		}
+	}
+	return fp
+}
+
+// Distance is the number of bits in which a and b differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Bands splits fp into four 16-bit bands. Two fingerprints within distance 3
+// of each other agree on at least one band, so an index over the bands finds
+// every such candidate with exact lookups.
+func Bands(fp uint64) [4]uint16 {
+	return [4]uint16{uint16(fp), uint16(fp >> 16), uint16(fp >> 32), uint16(fp >> 48)}
+}
@@ -0,0 +1,32 @@
+package dedupe
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	base := Fingerprint("Refactor the store package to remove the duplicated error handling in every write path, and add tests for the retry loop.")
+	same := Fingerprint("refactor the store package to remove the duplicated error handling in every write path and add tests for the retry loop")
+	near := Fingerprint("Refactor the store package to remove the duplicated error handling in every write path, and add tests for the backoff loop.")
+	other := Fingerprint("Summarize the release notes for the webhook dispatcher and the CLI flags.")
+
+	if d := Distance(base, same); d != 0 {
+		t.Fatalf("expected case and punctuation to be ignored, got distance %d", d)
+	}
+	if d := Distance(base, near); d == 0 || d >= Distance(base, other) {
+		t.Fatalf("expected a near-duplicate closer than unrelated text, got %d vs %d", d, Distance(base, other))
+	}
+	if Fingerprint(" ... ") != 0 {
+		t.Fatalf("expected text without words to fingerprint to 0")
+	}
+}
+
+func TestBands(t *testing.T) {
+	a := uint64(0x0123_4567_89ab_cdef)
+	b := a ^ (1 | 1<<17 | 1<<40) // one bit in each of three bands
+	if Distance(a, b) != 3 {
+		t.Fatalf("expected distance 3")
+	}
+	ba, bb := Bands(a), Bands(b)
+	if ba[3] != bb[3] || ba[0] == bb[0] {
+		t.Fatalf("expected only the untouched band to match, got %x and %x", ba, bb)
+	}
+}
@@ -0,0 +1,533 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/keys"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Bundle file names.
+const (
+	BundleRecordsFile   = "records.jsonl"
+	BundleManifestFile  = "manifest.json"
+	BundleSignatureFile = "manifest.sig"
+	bundleBlobDir       = "blobs"
+)
+
+// BundleVersion is the manifest format version written by ExportBundle.
+// Version 2 bundles start with the manifest and its signature so both can be
+// verified before any file they describe is extracted.
+const BundleVersion = 2
+
+// Defaults for BundleImportOptions limits.
+const (
+	DefaultBundleMaxFiles    = 100000
+	DefaultBundleMaxFileSize = 1 << 30
+)
+
+// Size limits for the bundle manifest and signature, which are read into
+// memory.
+const (
+	maxBundleManifestSize  = 64 << 20
+	maxBundleSignatureSize = 4 << 10
+)
+
+// bundleSignaturePrefix domain-separates bundle signatures from other uses of
+// the key.
+const bundleSignaturePrefix = "yanzi-bundle-v1:"
+
+// ErrBundleInvalid is returned by ImportBundle when a bundle's manifest,
+// signature, or contents do not verify.
+var ErrBundleInvalid = errors.New("invalid export bundle")
+
+// BlobGetter reads attachment blobs. store.Store satisfies it.
+type BlobGetter interface {
+	GetBlob(ctx context.Context, digest string) ([]byte, error)
+}
+
+// BlobPutter stores attachment blobs. store.Store satisfies it.
+type BlobPutter interface {
+	PutBlob(ctx context.Context, data []byte) (string, error)
+}
+
+// Manifest describes a bundle's contents.
+type Manifest struct {
+	Version     int    `json:"version"`
+	CreatedAt   string `json:"created_at"`
+	RecordCount int    `json:"record_count"`
+	// Heads are the hashes of exported records no other exported record links
+	// to, oldest first.
+	Heads []string `json:"heads"`
+	// MerkleRoot is hash.MerkleRoot over the record hashes in export order;
+	// for an unfiltered export it matches chain.MerkleRoot of the source.
+	MerkleRoot string         `json:"merkle_root"`
+	Files      []ManifestFile `json:"files"`
+}
+
+// ManifestFile is the digest of one bundle file.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+// bundleSignature is the content of BundleSignatureFile.
+type bundleSignature struct {
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// BundleOptions configures ExportBundle.
+type BundleOptions struct {
+	ExportOptions
+	// Signer, when set, adds a detached signature over the manifest.
+	Signer keys.Signer
+}
+
+// ExportBundle writes a gzip-compressed tar bundle to w holding the records of
+// src as JSON Lines, the blobs their attachments reference, a manifest with
+// the digest of every file, and an optional signature over the manifest.
+// Exporting records with attachments requires src to implement BlobGetter.
+func ExportBundle(ctx context.Context, src Source, w io.Writer, opts BundleOptions) (Manifest, error) {
+	manifest := Manifest{Version: BundleVersion, CreatedAt: time.Now().UTC().Format(time.RFC3339Nano)}
+
+	// Files are spooled to dir, named by their index in manifest.Files, so
+	// the manifest can be written ahead of them.
+	dir, err := os.MkdirTemp("", "yanzi-bundle-")
+	if err != nil {
+		return manifest, err
+	}
+	defer os.RemoveAll(dir)
+	records, err := os.Create(filepath.Join(dir, "0"))
+	if err != nil {
+		return manifest, err
+	}
+	defer records.Close()
+
+	var hashes []string
+	linked := make(map[string]struct{})
+	digests := make(map[string]struct{})
+	exportOpts := opts.ExportOptions
+	filter := exportOpts.Filter
+	exportOpts.Filter = func(record model.IntentRecord) bool {
+		if filter != nil && !filter(record) {
+			return false
+		}
+		hashes = append(hashes, record.Hash)
+		if record.PrevHash != "" {
+			linked[record.PrevHash] = struct{}{}
+		}
+		for _, a := range record.Attachments {
+			digests[a.Digest] = struct{}{}
+		}
+		return true
+	}
+	sum := sha256.New()
+	if manifest.RecordCount, err = ExportJSONL(ctx, src, io.MultiWriter(records, sum), exportOpts); err != nil {
+		return manifest, err
+	}
+	for _, h := range hashes {
+		if _, ok := linked[h]; !ok {
+			manifest.Heads = append(manifest.Heads, h)
+		}
+	}
+	manifest.MerkleRoot = hash.MerkleRoot(hashes)
+
+	size, err := records.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return manifest, err
+	}
+	if err := records.Close(); err != nil {
+		return manifest, err
+	}
+	manifest.Files = append(manifest.Files, ManifestFile{Path: BundleRecordsFile, Digest: hash.FormatBlobDigest(sum.Sum(nil)), Size: size})
+
+	if len(digests) > 0 {
+		blobs, ok := src.(BlobGetter)
+		if !ok {
+			return manifest, errors.New("records have attachments but the source cannot read blobs")
+		}
+		ordered := make([]string, 0, len(digests))
+		for digest := range digests {
+			ordered = append(ordered, digest)
+		}
+		sort.Strings(ordered)
+		for _, digest := range ordered {
+			data, err := blobs.GetBlob(ctx, digest)
+			if err != nil {
+				return manifest, fmt.Errorf("read blob %s: %w", digest, err)
+			}
+			spool := filepath.Join(dir, strconv.Itoa(len(manifest.Files)))
+			if err := os.WriteFile(spool, data, 0o600); err != nil {
+				return manifest, err
+			}
+			manifest.Files = append(manifest.Files, ManifestFile{Path: blobPath(digest), Digest: hash.BlobDigest(data), Size: int64(len(data))})
+		}
+	}
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeTarFile(tw, BundleManifestFile, int64(len(encoded)), bytes.NewReader(encoded)); err != nil {
+		return manifest, err
+	}
+	if opts.Signer != nil {
+		sig, err := opts.Signer.Sign(bundleMessage(encoded))
+		if err != nil {
+			return manifest, fmt.Errorf("sign manifest: %w", err)
+		}
+		encodedSig, err := json.Marshal(bundleSignature{
+			PublicKey: base64.StdEncoding.EncodeToString(opts.Signer.Public()),
+			Signature: base64.StdEncoding.EncodeToString(sig),
+		})
+		if err != nil {
+			return manifest, err
+		}
+		if err := writeTarFile(tw, BundleSignatureFile, int64(len(encodedSig)), bytes.NewReader(encodedSig)); err != nil {
+			return manifest, err
+		}
+	}
+	for i, f := range manifest.Files {
+		if err := writeSpooledFile(tw, f, filepath.Join(dir, strconv.Itoa(i))); err != nil {
+			return manifest, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, gz.Close()
+}
+
+// BundleImportOptions configures ImportBundle.
+type BundleImportOptions struct {
+	ImportOptions
+	// TrustedKeys, when non-empty, requires a manifest signature by one of
+	// these keys.
+	TrustedKeys []ed25519.PublicKey
+	// RequireSignature rejects unsigned bundles even without TrustedKeys.
+	RequireSignature bool
+	// MaxFiles caps the number of files a manifest may list; values <= 0
+	// select DefaultBundleMaxFiles.
+	MaxFiles int
+	// MaxFileSize caps the size of each extracted file; values <= 0 select
+	// DefaultBundleMaxFileSize.
+	MaxFileSize int64
+}
+
+// ImportBundle verifies a bundle written by ExportBundle and imports it into
+// dst. The manifest signature (if present or required) is verified before
+// any file is extracted, and extraction stops at the first file that is not
+// listed in the manifest or does not match its listed size and digest.
+// Nothing is written to dst unless every listed file is present and the
+// records match the manifest's count and Merkle root. Blobs are stored before
+// records, which requires dst to implement BlobPutter when the bundle has
+// any; records are then imported as by ImportJSONL. Verification failures
+// wrap ErrBundleInvalid.
+func ImportBundle(ctx context.Context, dst Sink, r io.Reader, opts BundleImportOptions) (Manifest, ImportReport, error) {
+	dir, err := os.MkdirTemp("", "yanzi-bundle-")
+	if err != nil {
+		return Manifest{}, ImportReport{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := extractBundle(r, dir, opts)
+	if err != nil {
+		return manifest, ImportReport{}, err
+	}
+	count, root, err := summarizeRecords(filepath.Join(dir, BundleRecordsFile))
+	if err != nil {
+		return manifest, ImportReport{}, err
+	}
+	if count != manifest.RecordCount || root != manifest.MerkleRoot {
+		return manifest, ImportReport{}, fmt.Errorf("%w: records do not match the manifest count or Merkle root", ErrBundleInvalid)
+	}
+
+	for _, f := range manifest.Files {
+		if f.Path == BundleRecordsFile {
+			continue
+		}
+		blobs, ok := dst.(BlobPutter)
+		if !ok {
+			return manifest, ImportReport{}, errors.New("bundle has blobs but the destination cannot store them")
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return manifest, ImportReport{}, err
+		}
+		if _, err := blobs.PutBlob(ctx, data); err != nil {
+			return manifest, ImportReport{}, fmt.Errorf("store blob %s: %w", f.Digest, err)
+		}
+	}
+
+	records, err := os.Open(filepath.Join(dir, BundleRecordsFile))
+	if err != nil {
+		return manifest, ImportReport{}, err
+	}
+	defer records.Close()
+	report, err := ImportJSONL(ctx, dst, records, opts.ImportOptions)
+	return manifest, report, err
+}
+
+// extractBundle reads the manifest and signature at the start of a bundle,
+// verifies them, and then unpacks the files the manifest lists into dir,
+// checking each against its listed size and digest.
+func extractBundle(r io.Reader, dir string, opts BundleImportOptions) (Manifest, error) {
+	var manifest Manifest
+	maxFiles, maxFileSize := opts.MaxFiles, opts.MaxFileSize
+	if maxFiles <= 0 {
+		maxFiles = DefaultBundleMaxFiles
+	}
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultBundleMaxFileSize
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, fmt.Errorf("%w: %v", ErrBundleInvalid, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := nextBundleEntry(tr)
+	if err != nil {
+		return manifest, err
+	}
+	if hdr == nil || hdr.Name != BundleManifestFile {
+		return manifest, fmt.Errorf("%w: bundle does not start with %s", ErrBundleInvalid, BundleManifestFile)
+	}
+	encoded, err := readBundleEntry(tr, hdr, maxBundleManifestSize)
+	if err != nil {
+		return manifest, err
+	}
+	if err := json.Unmarshal(encoded, &manifest); err != nil {
+		return manifest, fmt.Errorf("%w: decode manifest: %v", ErrBundleInvalid, err)
+	}
+	if manifest.Version != BundleVersion {
+		return manifest, fmt.Errorf("%w: unsupported manifest version %d", ErrBundleInvalid, manifest.Version)
+	}
+
+	var sig []byte
+	if hdr, err = nextBundleEntry(tr); err != nil {
+		return manifest, err
+	}
+	if hdr != nil && hdr.Name == BundleSignatureFile {
+		if sig, err = readBundleEntry(tr, hdr, maxBundleSignatureSize); err != nil {
+			return manifest, err
+		}
+		if hdr, err = nextBundleEntry(tr); err != nil {
+			return manifest, err
+		}
+	}
+	if err := verifyBundleSignature(encoded, sig, opts); err != nil {
+		return manifest, err
+	}
+
+	if len(manifest.Files) > maxFiles {
+		return manifest, fmt.Errorf("%w: manifest lists %d files, more than the limit of %d", ErrBundleInvalid, len(manifest.Files), maxFiles)
+	}
+	pending := make(map[string]ManifestFile, len(manifest.Files))
+	for _, f := range manifest.Files {
+		if f.Path != BundleRecordsFile && f.Path != blobPath(f.Digest) {
+			return manifest, fmt.Errorf("%w: blob %s stored at %s", ErrBundleInvalid, f.Digest, f.Path)
+		}
+		if !filepath.IsLocal(filepath.FromSlash(f.Path)) {
+			return manifest, fmt.Errorf("%w: unexpected path %q", ErrBundleInvalid, f.Path)
+		}
+		if f.Size < 0 || f.Size > maxFileSize {
+			return manifest, fmt.Errorf("%w: %s is larger than the limit of %d bytes", ErrBundleInvalid, f.Path, maxFileSize)
+		}
+		if _, dup := pending[f.Path]; dup {
+			return manifest, fmt.Errorf("%w: manifest lists %s twice", ErrBundleInvalid, f.Path)
+		}
+		pending[f.Path] = f
+	}
+	if _, ok := pending[BundleRecordsFile]; !ok {
+		return manifest, fmt.Errorf("%w: manifest does not list %s", ErrBundleInvalid, BundleRecordsFile)
+	}
+
+	for ; hdr != nil; hdr, err = nextBundleEntry(tr) {
+		f, ok := pending[hdr.Name]
+		if !ok {
+			return manifest, fmt.Errorf("%w: %s is not listed in the manifest or appears twice", ErrBundleInvalid, hdr.Name)
+		}
+		delete(pending, hdr.Name)
+		if hdr.Size != f.Size {
+			return manifest, fmt.Errorf("%w: %s does not match its manifest size", ErrBundleInvalid, f.Path)
+		}
+		if err := extractBundleFile(tr, dir, f); err != nil {
+			return manifest, err
+		}
+	}
+	if err != nil {
+		return manifest, err
+	}
+	for name := range pending {
+		return manifest, fmt.Errorf("%w: missing %s", ErrBundleInvalid, name)
+	}
+	return manifest, nil
+}
+
+// nextBundleEntry returns the next tar entry, or nil at the end of the
+// bundle. Bundles hold only regular files.
+func nextBundleEntry(tr *tar.Reader) (*tar.Header, error) {
+	hdr, err := tr.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundleInvalid, err)
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%w: unexpected entry %q", ErrBundleInvalid, hdr.Name)
+	}
+	return hdr, nil
+}
+
+// readBundleEntry reads the current entry, which may hold at most limit bytes.
+func readBundleEntry(tr *tar.Reader, hdr *tar.Header, limit int64) ([]byte, error) {
+	if hdr.Size > limit {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrBundleInvalid, hdr.Name, limit)
+	}
+	data, err := io.ReadAll(io.LimitReader(tr, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBundleInvalid, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrBundleInvalid, hdr.Name, limit)
+	}
+	return data, nil
+}
+
+// extractBundleFile writes the current entry to f's path under dir, reading
+// no more than f.Size+1 bytes, and checks it against f.
+func extractBundleFile(tr *tar.Reader, dir string, f ManifestFile) error {
+	target := filepath.Join(dir, filepath.FromSlash(f.Path))
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	sum := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, sum), io.LimitReader(tr, f.Size+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBundleInvalid, err)
+	}
+	if size != f.Size || hash.FormatBlobDigest(sum.Sum(nil)) != f.Digest {
+		return fmt.Errorf("%w: %s does not match its manifest digest", ErrBundleInvalid, f.Path)
+	}
+	return nil
+}
+
+// verifyBundleSignature checks sig, the content of BundleSignatureFile or nil
+// when the bundle has none, over the encoded manifest.
+func verifyBundleSignature(manifest, sig []byte, opts BundleImportOptions) error {
+	if sig == nil {
+		if opts.RequireSignature || len(opts.TrustedKeys) > 0 {
+			return fmt.Errorf("%w: bundle is not signed", ErrBundleInvalid)
+		}
+		return nil
+	}
+
+	var decoded bundleSignature
+	if err := json.Unmarshal(sig, &decoded); err != nil {
+		return fmt.Errorf("%w: decode signature: %v", ErrBundleInvalid, err)
+	}
+	pub, err := base64.StdEncoding.DecodeString(decoded.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: signature public key must be a base64 ed25519 key", ErrBundleInvalid)
+	}
+	raw, err := base64.StdEncoding.DecodeString(decoded.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature must be base64", ErrBundleInvalid)
+	}
+	if !ed25519.Verify(pub, bundleMessage(manifest), raw) {
+		return fmt.Errorf("%w: manifest signature does not verify", ErrBundleInvalid)
+	}
+	if len(opts.TrustedKeys) == 0 {
+		return nil
+	}
+	for _, trusted := range opts.TrustedKeys {
+		if trusted.Equal(ed25519.PublicKey(pub)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: manifest signed by an untrusted key", ErrBundleInvalid)
+}
+
+// summarizeRecords counts the records in a JSONL file and computes the Merkle
+// root of their hashes.
+func summarizeRecords(name string) (int, string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	var hashes []string
+	dec := json.NewDecoder(f)
+	for {
+		var record struct {
+			Hash string `json:"hash"`
+		}
+		if err := dec.Decode(&record); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return 0, "", fmt.Errorf("%w: decode records: %v", ErrBundleInvalid, err)
+		}
+		hashes = append(hashes, record.Hash)
+	}
+	return len(hashes), hash.MerkleRoot(hashes), nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: size, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// writeSpooledFile writes the spooled copy of f to tw.
+func writeSpooledFile(tw *tar.Writer, f ManifestFile, spool string) error {
+	file, err := os.Open(spool)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return writeTarFile(tw, f.Path, f.Size, file)
+}
+
+// blobPath is the bundle path of the blob with digest.
+func blobPath(digest string) string {
+	return bundleBlobDir + "/" + strings.ReplaceAll(digest, ":", "/")
+}
+
+func bundleMessage(manifest []byte) []byte {
+	return append([]byte(bundleSignaturePrefix), manifest...)
+}
@@ -0,0 +1,228 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/keys"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func openStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := openStore(t)
+	records := buildChain(t, src, 3)
+
+	data := []byte("evidence.pdf contents")
+	digest, err := src.PutBlob(ctx, data)
+	if err != nil {
+		t.Fatalf("put blob: %v", err)
+	}
+	attached, err := src.AppendIntent(ctx, model.IntentRecord{
+		Author:      "alice",
+		SourceType:  "cli",
+		Prompt:      "see attached",
+		Response:    "ok",
+		Attachments: []model.Attachment{{Digest: digest, Name: "evidence.pdf", Size: int64(len(data))}},
+	})
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := keys.FromPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+
+	var bundle bytes.Buffer
+	manifest, err := ExportBundle(ctx, src, &bundle, BundleOptions{Signer: signer})
+	if err != nil {
+		t.Fatalf("export bundle: %v", err)
+	}
+	if manifest.RecordCount != len(records)+1 || len(manifest.Files) != 2 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	if len(manifest.Heads) != 1 || manifest.Heads[0] != attached.Hash {
+		t.Fatalf("expected the appended record as the only head, got %v", manifest.Heads)
+	}
+	root, err := chain.MerkleRoot(ctx, src, time.Now())
+	if err != nil {
+		t.Fatalf("merkle root: %v", err)
+	}
+	if root != manifest.MerkleRoot {
+		t.Fatalf("expected manifest Merkle root to match the store")
+	}
+
+	dst := openStore(t)
+	_, report, err := ImportBundle(ctx, dst, bytes.NewReader(bundle.Bytes()), BundleImportOptions{
+		TrustedKeys: []ed25519.PublicKey{signer.Public()},
+	})
+	if err != nil {
+		t.Fatalf("import bundle: %v", err)
+	}
+	if report.Imported != manifest.RecordCount {
+		t.Fatalf("expected %d imported, got %+v", manifest.RecordCount, report)
+	}
+	if got, err := dst.GetBlob(ctx, digest); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("expected blob to be imported, got %v", err)
+	}
+}
+
+func TestImportBundleRejectsInvalid(t *testing.T) {
+	ctx := context.Background()
+	src := openStore(t)
+	buildChain(t, src, 2)
+
+	var unsigned bytes.Buffer
+	if _, err := ExportBundle(ctx, src, &unsigned, BundleOptions{}); err != nil {
+		t.Fatalf("export bundle: %v", err)
+	}
+	other, _, _ := ed25519.GenerateKey(nil)
+	if _, _, err := ImportBundle(ctx, openStore(t), bytes.NewReader(unsigned.Bytes()), BundleImportOptions{TrustedKeys: []ed25519.PublicKey{other}}); !errors.Is(err, ErrBundleInvalid) {
+		t.Fatalf("expected unsigned bundle to be rejected, got %v", err)
+	}
+
+	tampered := rewriteBundle(t, unsigned.Bytes(), BundleRecordsFile, func(b []byte) []byte {
+		return bytes.Replace(b, []byte("prompt 0"), []byte("prompt X"), 1)
+	})
+	dst := openStore(t)
+	if _, _, err := ImportBundle(ctx, dst, bytes.NewReader(tampered), BundleImportOptions{}); !errors.Is(err, ErrBundleInvalid) {
+		t.Fatalf("expected tampered records to be rejected, got %v", err)
+	}
+	if got, err := dst.ListIntents(ctx, 0); err != nil || len(got) != 0 {
+		t.Fatalf("expected nothing imported from a rejected bundle, got %d, %v", len(got), err)
+	}
+}
+
+func TestImportBundleLimits(t *testing.T) {
+	ctx := context.Background()
+	src := openStore(t)
+	buildChain(t, src, 2)
+
+	var bundle bytes.Buffer
+	if _, err := ExportBundle(ctx, src, &bundle, BundleOptions{}); err != nil {
+		t.Fatalf("export bundle: %v", err)
+	}
+	if _, _, err := ImportBundle(ctx, openStore(t), bytes.NewReader(bundle.Bytes()), BundleImportOptions{MaxFileSize: 16}); !errors.Is(err, ErrBundleInvalid) {
+		t.Fatalf("expected an oversized file to be rejected, got %v", err)
+	}
+
+	// A bundle whose manifest follows the files it describes is rejected
+	// before anything is extracted.
+	reordered := reorderBundle(t, bundle.Bytes(), BundleManifestFile)
+	if _, _, err := ImportBundle(ctx, openStore(t), bytes.NewReader(reordered), BundleImportOptions{}); !errors.Is(err, ErrBundleInvalid) {
+		t.Fatalf("expected a bundle without a leading manifest to be rejected, got %v", err)
+	}
+}
+
+// reorderBundle returns bundle with the named file moved to the end.
+func reorderBundle(t *testing.T, bundle []byte, name string) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var (
+		out  bytes.Buffer
+		last []byte
+	)
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read bundle: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read entry: %v", err)
+		}
+		if hdr.Name == name {
+			last = content
+			continue
+		}
+		if err := writeTarFile(tw, hdr.Name, int64(len(content)), bytes.NewReader(content)); err != nil {
+			t.Fatalf("write entry: %v", err)
+		}
+	}
+	if err := writeTarFile(tw, name, int64(len(last)), bytes.NewReader(last)); err != nil {
+		t.Fatalf("write entry: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	return out.Bytes()
+}
+
+// rewriteBundle returns bundle with the named file's content passed through edit.
+func rewriteBundle(t *testing.T, bundle []byte, name string, edit func([]byte) []byte) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read bundle: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read entry: %v", err)
+		}
+		if hdr.Name == name {
+			content = edit(content)
+		}
+		if err := writeTarFile(tw, hdr.Name, int64(len(content)), bytes.NewReader(content)); err != nil {
+			t.Fatalf("write entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// csvColumns are the fixed CSV columns, followed by one "meta.<path>" column
+// per flattened meta key.
+var csvColumns = []string{
+	"id", "created_at", "author", "source_type", "title", "prompt", "response",
+	"prev_hash", "hash", "hash_version", "tags", "thread_id", "parent_id",
+	"attachments", "signature", "public_key", "tombstoned_at",
+}
+
+// CSVOptions configures ExportCSV.
+type CSVOptions struct {
+	ExportOptions
+	// MetaKeys lists the flattened meta paths to emit as columns, e.g.
+	// "model" or "usage.input_tokens". When nil, ExportCSV reads the source
+	// twice and emits every path present, sorted.
+	MetaKeys []string
+}
+
+// ExportCSV writes the records of src to w as CSV with a header row, oldest
+// first, and returns the number of records written. Meta objects are
+// flattened into "meta.<path>" columns joining nested keys with dots; arrays
+// are written as JSON. Tags are joined with ";" and attachments are written as
+// JSON.
+func ExportCSV(ctx context.Context, src Source, w io.Writer, opts CSVOptions) (int, error) {
+	metaKeys := opts.MetaKeys
+	if metaKeys == nil {
+		discovered := make(map[string]struct{})
+		if err := eachRecord(ctx, src, opts.ExportOptions, func(record model.IntentRecord) error {
+			flat, err := flattenMeta(record.Meta)
+			if err != nil {
+				return fmt.Errorf("flatten meta of %s: %w", record.ID, err)
+			}
+			for key := range flat {
+				discovered[key] = struct{}{}
+			}
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+		metaKeys = make([]string, 0, len(discovered))
+		for key := range discovered {
+			metaKeys = append(metaKeys, key)
+		}
+		sort.Strings(metaKeys)
+	}
+
+	cw := csv.NewWriter(w)
+	header := append([]string(nil), csvColumns...)
+	for _, key := range metaKeys {
+		header = append(header, "meta."+key)
+	}
+	if err := cw.Write(header); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	err := eachRecord(ctx, src, opts.ExportOptions, func(record model.IntentRecord) error {
+		flat, err := flattenMeta(record.Meta)
+		if err != nil {
+			return fmt.Errorf("flatten meta of %s: %w", record.ID, err)
+		}
+		var attachments string
+		if len(record.Attachments) > 0 {
+			encoded, err := json.Marshal(record.Attachments)
+			if err != nil {
+				return err
+			}
+			attachments = string(encoded)
+		}
+		var hashVersion string
+		if record.HashVersion != 0 {
+			hashVersion = strconv.Itoa(record.HashVersion)
+		}
+		row := []string{
+			record.ID, record.CreatedAt, record.Author, record.SourceType, record.Title,
+			record.Prompt, record.Response, record.PrevHash, record.Hash, hashVersion,
+			strings.Join(record.Tags, ";"), record.ThreadID, record.ParentID,
+			attachments, record.Signature, record.PublicKey, record.TombstonedAt,
+		}
+		for _, key := range metaKeys {
+			row = append(row, flat[key])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	if err != nil {
+		return written, err
+	}
+	cw.Flush()
+	return written, cw.Error()
+}
+
+// flattenMeta maps each scalar or array in raw to its dotted path. Strings are
+// written unquoted, null as empty, and arrays as JSON.
+func flattenMeta(raw json.RawMessage) (map[string]string, error) {
+	flat := make(map[string]string)
+	if model.IsEmptyMeta(raw) {
+		return flat, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	var walk func(prefix string, v any) error
+	walk = func(prefix string, v any) error {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, child := range v {
+				if err := walk(prefix+"."+key, child); err != nil {
+					return err
+				}
+			}
+		case nil:
+			flat[prefix] = ""
+		case string:
+			flat[prefix] = v
+		case json.Number:
+			flat[prefix] = v.String()
+		case bool:
+			flat[prefix] = strconv.FormatBool(v)
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			flat[prefix] = string(encoded)
+		}
+		return nil
+	}
+	for key, v := range obj {
+		if err := walk(key, v); err != nil {
+			return nil, err
+		}
+	}
+	return flat, nil
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store/memstore"
+)
+
+func TestExportCSVFlattensMeta(t *testing.T) {
+	ctx := context.Background()
+	src := memstore.New()
+	for i, meta := range []string{
+		`{"model":"gpt","usage":{"input":3,"cached":null},"stop":["a","b"]}`,
+		`{"model":"claude","flag":true}`,
+	} {
+		record := model.IntentRecord{
+			ID:         fmt.Sprintf("intent-%03d", i),
+			CreatedAt:  time.Date(2026, 2, 9, 10, 0, i, 0, time.UTC).Format(time.RFC3339Nano),
+			Author:     "alice",
+			SourceType: "cli",
+			Prompt:     "p",
+			Response:   "r",
+			Meta:       []byte(meta),
+			Tags:       []string{"review", "bug"},
+			Hash:       fmt.Sprintf("hash-%d", i),
+		}
+		if err := src.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	n, err := ExportCSV(ctx, src, &out, CSVOptions{})
+	if err != nil {
+		t.Fatalf("export csv: %v", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if n != 2 || len(rows) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d rows", len(rows))
+	}
+
+	col := make(map[string]int)
+	for i, name := range rows[0] {
+		col[name] = i
+	}
+	for _, name := range []string{"meta.flag", "meta.model", "meta.stop", "meta.usage.cached", "meta.usage.input"} {
+		if _, ok := col[name]; !ok {
+			t.Fatalf("expected column %s in %v", name, rows[0])
+		}
+	}
+	first := rows[1]
+	if first[col["meta.model"]] != "gpt" || first[col["meta.usage.input"]] != "3" || first[col["meta.stop"]] != `["a","b"]` {
+		t.Fatalf("unexpected flattened meta %v", first)
+	}
+	if first[col["tags"]] != "review;bug" || rows[2][col["meta.flag"]] != "true" {
+		t.Fatalf("unexpected row values %v / %v", first, rows[2])
+	}
+
+	out.Reset()
+	if _, err := ExportCSV(ctx, src, &out, CSVOptions{MetaKeys: []string{"model"}}); err != nil {
+		t.Fatalf("export csv with keys: %v", err)
+	}
+	header, err := csv.NewReader(&out).Read()
+	if err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	if got := header[len(header)-1]; got != "meta.model" || len(header) != len(csvColumns)+1 {
+		t.Fatalf("expected only meta.model, got %v", header)
+	}
+}
@@ -0,0 +1,215 @@
+// Package export moves intent records between stores as JSON Lines.
+//
+// Each line is one record encoded as RFC 8785 canonical JSON, so identical
+// records always export to identical bytes. Plain JSONL exports omit
+// attachment blobs; bundles (ExportBundle) carry them alongside the records, a
+// digest manifest, and an optional signature.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/sign"
+)
+
+// DefaultBatchSize is how many records ExportJSONL reads per query when
+// ExportOptions.BatchSize is zero.
+const DefaultBatchSize = 500
+
+// Source lists records in canonical (created_at, id) order. store.Store and
+// store/memstore satisfy it.
+type Source interface {
+	ListIntentsAfter(ctx context.Context, createdAt, id string, limit int) ([]model.IntentRecord, error)
+}
+
+// Sink receives imported records. store.Store and store/memstore satisfy it.
+// GetIntentByHash must report a missing hash with an error matching
+// model.ErrNotFound.
+type Sink interface {
+	CreateIntent(ctx context.Context, record model.IntentRecord) error
+	GetIntentByHash(ctx context.Context, hash string) (model.IntentRecord, error)
+}
+
+// ExportOptions configures ExportJSONL.
+type ExportOptions struct {
+	// BatchSize bounds each read from the source; zero selects DefaultBatchSize.
+	BatchSize int
+	// Filter, when set, exports only records for which it returns true.
+	Filter func(model.IntentRecord) bool
+}
+
+// ExportJSONL streams every record in src to w, oldest first, and returns the
+// number written.
+func ExportJSONL(ctx context.Context, src Source, w io.Writer, opts ExportOptions) (int, error) {
+	written := 0
+	err := eachRecord(ctx, src, opts, func(record model.IntentRecord) error {
+		line, err := canonicalLine(record)
+		if err != nil {
+			return fmt.Errorf("encode intent %s: %w", record.ID, err)
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	return written, err
+}
+
+// eachRecord calls fn for every record of src accepted by opts.Filter, oldest first.
+func eachRecord(ctx context.Context, src Source, opts ExportOptions, fn func(model.IntentRecord) error) error {
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	var createdAt, id string
+	for {
+		records, err := src.ListIntentsAfter(ctx, createdAt, id, batch)
+		if err != nil {
+			return fmt.Errorf("list intents: %w", err)
+		}
+		// A source may clamp the page or filter it, so only an empty page
+		// marks the end.
+		if len(records) == 0 {
+			return nil
+		}
+		for _, record := range records {
+			if opts.Filter != nil && !opts.Filter(record) {
+				continue
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		last := records[len(records)-1]
+		createdAt, id = last.CreatedAt, last.ID
+	}
+}
+
+// CanonicalJSON encodes record as RFC 8785 canonical JSON, the form used for
+// each exported line and for records sent to webhooks and message buses.
+func CanonicalJSON(record model.IntentRecord) ([]byte, error) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return hash.CanonicalizeJCS(raw)
+}
+
+// canonicalLine encodes record as canonical JSON followed by a newline.
+func canonicalLine(record model.IntentRecord) ([]byte, error) {
+	canonical, err := CanonicalJSON(record)
+	if err != nil {
+		return nil, err
+	}
+	return append(canonical, '\n'), nil
+}
+
+// ImportOptions configures ImportJSONL.
+type ImportOptions struct {
+	// SkipExisting skips records whose hash is already in the sink instead of
+	// failing on them.
+	SkipExisting bool
+	// AllowMissingParents accepts records whose prev_hash is neither earlier
+	// in the input nor in the sink, e.g. when importing part of a chain.
+	AllowMissingParents bool
+	// HMACSecret verifies keyed (hmac-sha256) hashes. Without it, records with
+	// keyed hashes are rejected.
+	HMACSecret []byte
+}
+
+// ImportReport summarizes an ImportJSONL run.
+type ImportReport struct {
+	Imported int
+	Skipped  int
+}
+
+// ImportJSONL reads records written by ExportJSONL and inserts them into dst.
+// Before each insert the record is validated, its hash recomputed, any
+// signature checked, and its prev_hash resolved against earlier records or
+// dst, so input must list parents before children (ExportJSONL's order).
+// Tombstoned records carry no content, so only their links are checked.
+// Import stops at the first invalid record; records before it stay imported.
+func ImportJSONL(ctx context.Context, dst Sink, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	var report ImportReport
+	seen := make(map[string]struct{})
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		var record model.IntentRecord
+		if err := dec.Decode(&record); errors.Is(err, io.EOF) {
+			return report, nil
+		} else if err != nil {
+			return report, fmt.Errorf("record %d: decode: %w", n, err)
+		}
+
+		if err := verifyRecord(record, opts); err != nil {
+			return report, fmt.Errorf("record %d (%s): %w", n, record.ID, err)
+		}
+
+		_, err := dst.GetIntentByHash(ctx, record.Hash)
+		switch {
+		case err == nil && opts.SkipExisting:
+			seen[record.Hash] = struct{}{}
+			report.Skipped++
+			continue
+		case err == nil:
+			return report, fmt.Errorf("record %d (%s): hash %s already exists", n, record.ID, record.Hash)
+		case !errors.Is(err, model.ErrNotFound):
+			return report, fmt.Errorf("record %d (%s): look up hash: %w", n, record.ID, err)
+		}
+
+		if err := checkParent(ctx, dst, record, seen, opts); err != nil {
+			return report, fmt.Errorf("record %d (%s): %w", n, record.ID, err)
+		}
+		if err := dst.CreateIntent(ctx, record); err != nil {
+			return report, fmt.Errorf("record %d (%s): insert: %w", n, record.ID, err)
+		}
+		seen[record.Hash] = struct{}{}
+		report.Imported++
+	}
+}
+
+// verifyRecord checks record's fields, hash, and signature.
+func verifyRecord(record model.IntentRecord, opts ImportOptions) error {
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	if record.Tombstoned() {
+		return nil
+	}
+	if hash.IsKeyed(record.Hash) {
+		if opts.HMACSecret == nil {
+			return errors.New("keyed hash cannot be verified without ImportOptions.HMACSecret")
+		}
+		return hash.VerifyHMACIntent(record, opts.HMACSecret)
+	}
+	if record.Signature != "" {
+		return sign.VerifyIntent(record)
+	}
+	return hash.VerifyHash(record)
+}
+
+// checkParent ensures record's prev_hash names a record already imported or
+// present in dst.
+func checkParent(ctx context.Context, dst Sink, record model.IntentRecord, seen map[string]struct{}, opts ImportOptions) error {
+	if record.PrevHash == "" || opts.AllowMissingParents {
+		return nil
+	}
+	if _, ok := seen[record.PrevHash]; ok {
+		return nil
+	}
+	_, err := dst.GetIntentByHash(ctx, record.PrevHash)
+	if errors.Is(err, model.ErrNotFound) {
+		return fmt.Errorf("%w: prev_hash %s not found", model.ErrChainBroken, record.PrevHash)
+	}
+	return err
+}
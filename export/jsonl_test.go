@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+	"github.com/chuxorg/chux-yanzi-core/store/memstore"
+)
+
+// buildChain stores n linked intents and returns them oldest first.
+func buildChain(t *testing.T, s Sink, n int) []model.IntentRecord {
+	t.Helper()
+	records := make([]model.IntentRecord, 0, n)
+	prev := ""
+	for i := 0; i < n; i++ {
+		record := model.IntentRecord{
+			ID:         fmt.Sprintf("intent-%03d", i),
+			CreatedAt:  time.Date(2026, 2, 9, 10, 0, i, 0, time.UTC).Format(time.RFC3339Nano),
+			Author:     "alice",
+			SourceType: "cli",
+			Prompt:     fmt.Sprintf("prompt %d", i),
+			Response:   fmt.Sprintf("response %d", i),
+			Meta:       []byte(`{"b":1,"a":2}`),
+			PrevHash:   prev,
+		}
+		sum, err := hash.HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash intent %d: %v", i, err)
+		}
+		record.Hash = sum
+		if err := s.CreateIntent(context.Background(), record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+		records = append(records, record)
+		prev = sum
+	}
+	return records
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := memstore.New()
+	records := buildChain(t, src, 7)
+
+	var out bytes.Buffer
+	n, err := ExportJSONL(ctx, src, &out, ExportOptions{BatchSize: 3})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if n != len(records) || strings.Count(out.String(), "\n") != len(records) {
+		t.Fatalf("expected %d lines, got %d", len(records), n)
+	}
+	if first, _, _ := strings.Cut(out.String(), "\n"); !strings.HasPrefix(first, `{"author":"alice","created_at"`) {
+		t.Fatalf("expected canonical key order, got %s", first)
+	}
+
+	var again bytes.Buffer
+	if _, err := ExportJSONL(ctx, src, &again, ExportOptions{}); err != nil {
+		t.Fatalf("export again: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), again.Bytes()) {
+		t.Fatalf("expected deterministic export")
+	}
+
+	dst, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = dst.Close() })
+	if err := dst.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	report, err := ImportJSONL(ctx, dst, bytes.NewReader(out.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Imported != len(records) {
+		t.Fatalf("expected %d imported, got %+v", len(records), report)
+	}
+	got, err := dst.GetIntentByHash(ctx, records[6].Hash)
+	if err != nil || got.PrevHash != records[5].Hash {
+		t.Fatalf("expected linked record, got %+v, %v", got, err)
+	}
+
+	if _, err := ImportJSONL(ctx, dst, bytes.NewReader(out.Bytes()), ImportOptions{}); err == nil {
+		t.Fatalf("expected duplicate import to fail")
+	}
+	report, err = ImportJSONL(ctx, dst, bytes.NewReader(out.Bytes()), ImportOptions{SkipExisting: true})
+	if err != nil || report.Skipped != len(records) {
+		t.Fatalf("expected all records skipped, got %+v, %v", report, err)
+	}
+}
+
+// clampedSource returns at most max records per page, as a store configured
+// with a maximum list limit does.
+type clampedSource struct {
+	Source
+	max int
+}
+
+func (c clampedSource) ListIntentsAfter(ctx context.Context, createdAt, id string, limit int) ([]model.IntentRecord, error) {
+	return c.Source.ListIntentsAfter(ctx, createdAt, id, min(limit, c.max))
+}
+
+func TestExportReadsPastClampedPages(t *testing.T) {
+	ctx := context.Background()
+	src := memstore.New()
+	records := buildChain(t, src, 5)
+
+	var out bytes.Buffer
+	n, err := ExportJSONL(ctx, clampedSource{Source: src, max: 2}, &out, ExportOptions{BatchSize: 10})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if n != len(records) {
+		t.Fatalf("expected %d records exported, got %d", len(records), n)
+	}
+}
+
+func TestImportRejectsTamperedAndUnlinked(t *testing.T) {
+	ctx := context.Background()
+	src := memstore.New()
+	buildChain(t, src, 3)
+	var out bytes.Buffer
+	if _, err := ExportJSONL(ctx, src, &out, ExportOptions{}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	lines := strings.SplitAfter(out.String(), "\n")
+
+	tampered := strings.Replace(out.String(), `"prompt 1"`, `"prompt X"`, 1)
+	dst := memstore.New()
+	report, err := ImportJSONL(ctx, dst, strings.NewReader(tampered), ImportOptions{})
+	if err == nil || !strings.Contains(err.Error(), "record 2") {
+		t.Fatalf("expected tampered record 2 to be rejected, got %v", err)
+	}
+	if report.Imported != 1 {
+		t.Fatalf("expected the record before the failure to be imported, got %+v", report)
+	}
+
+	orphan := lines[2]
+	if _, err := ImportJSONL(ctx, memstore.New(), strings.NewReader(orphan), ImportOptions{}); err == nil || !strings.Contains(err.Error(), "prev_hash") {
+		t.Fatalf("expected missing parent to be rejected, got %v", err)
+	}
+	if _, err := ImportJSONL(ctx, memstore.New(), strings.NewReader(orphan), ImportOptions{AllowMissingParents: true}); err != nil {
+		t.Fatalf("expected AllowMissingParents to accept a partial chain: %v", err)
+	}
+}
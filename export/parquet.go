@@ -0,0 +1,115 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroupSize is how many rows ExportParquet buffers per write.
+const parquetRowGroupSize = 1000
+
+// ParquetRow is the Parquet schema written by ExportParquet. Meta and
+// attachments are JSON-typed columns, which DuckDB, Spark, and BigQuery can
+// query directly.
+type ParquetRow struct {
+	ID         string `parquet:"id"`
+	CreatedAt  int64  `parquet:"created_at,timestamp(microsecond)"`
+	Author     string `parquet:"author,dict"`
+	SourceType string `parquet:"source_type,dict"`
+	Title      string `parquet:"title,optional"`
+	Prompt     string `parquet:"prompt,zstd"`
+	Response   string `parquet:"response,zstd"`
+	Meta       string `parquet:"meta,optional,json"`
+	PrevHash   string `parquet:"prev_hash,optional"`
+	Hash       string `parquet:"hash"`
+	// HashVersion is 0 for records using the original encoding.
+	HashVersion  int32    `parquet:"hash_version"`
+	Tags         []string `parquet:"tags,list"`
+	ThreadID     string   `parquet:"thread_id,optional"`
+	ParentID     string   `parquet:"parent_id,optional"`
+	Attachments  string   `parquet:"attachments,optional,json"`
+	Signature    string   `parquet:"signature,optional"`
+	PublicKey    string   `parquet:"public_key,optional"`
+	TombstonedAt string   `parquet:"tombstoned_at,optional"`
+}
+
+// NewParquetRow converts record to its Parquet row.
+func NewParquetRow(record model.IntentRecord) (ParquetRow, error) {
+	createdAt, err := time.Parse(time.RFC3339Nano, record.CreatedAt)
+	if err != nil {
+		return ParquetRow{}, fmt.Errorf("created_at must be RFC3339: %w", err)
+	}
+	row := ParquetRow{
+		ID:           record.ID,
+		CreatedAt:    createdAt.UnixMicro(),
+		Author:       record.Author,
+		SourceType:   record.SourceType,
+		Title:        record.Title,
+		Prompt:       record.Prompt,
+		Response:     record.Response,
+		PrevHash:     record.PrevHash,
+		Hash:         record.Hash,
+		HashVersion:  int32(record.HashVersion),
+		Tags:         record.Tags,
+		ThreadID:     record.ThreadID,
+		ParentID:     record.ParentID,
+		Signature:    record.Signature,
+		PublicKey:    record.PublicKey,
+		TombstonedAt: record.TombstonedAt,
+	}
+	if !model.IsEmptyMeta(record.Meta) {
+		row.Meta = string(record.Meta)
+	}
+	if len(record.Attachments) > 0 {
+		encoded, err := json.Marshal(record.Attachments)
+		if err != nil {
+			return ParquetRow{}, err
+		}
+		row.Attachments = string(encoded)
+	}
+	return row, nil
+}
+
+// ExportParquet writes the records of src to w as a Parquet file with the
+// ParquetRow schema, oldest first, and returns the number of records written.
+func ExportParquet(ctx context.Context, src Source, w io.Writer, opts ExportOptions) (int, error) {
+	pw := parquet.NewGenericWriter[ParquetRow](w)
+	rows := make([]ParquetRow, 0, parquetRowGroupSize)
+	written := 0
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		if _, err := pw.Write(rows); err != nil {
+			return err
+		}
+		written += len(rows)
+		rows = rows[:0]
+		return nil
+	}
+
+	err := eachRecord(ctx, src, opts, func(record model.IntentRecord) error {
+		row, err := NewParquetRow(record)
+		if err != nil {
+			return fmt.Errorf("convert intent %s: %w", record.ID, err)
+		}
+		rows = append(rows, row)
+		if len(rows) == cap(rows) {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return written, err
+	}
+	return written, pw.Close()
+}
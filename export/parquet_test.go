@@ -0,0 +1,42 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/store/memstore"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestExportParquet(t *testing.T) {
+	ctx := context.Background()
+	src := memstore.New()
+	records := buildChain(t, src, 3)
+
+	var out bytes.Buffer
+	n, err := ExportParquet(ctx, src, &out, ExportOptions{})
+	if err != nil {
+		t.Fatalf("export parquet: %v", err)
+	}
+	if n != len(records) {
+		t.Fatalf("expected %d rows, got %d", len(records), n)
+	}
+
+	rows, err := parquet.Read[ParquetRow](bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("read parquet: %v", err)
+	}
+	if len(rows) != len(records) {
+		t.Fatalf("expected %d rows back, got %d", len(records), len(rows))
+	}
+	got := rows[2]
+	created, _ := time.Parse(time.RFC3339Nano, records[2].CreatedAt)
+	if got.Hash != records[2].Hash || got.PrevHash != records[1].Hash || got.CreatedAt != created.UnixMicro() {
+		t.Fatalf("unexpected row %+v", got)
+	}
+	if got.Meta != string(records[2].Meta) || got.Title != "" {
+		t.Fatalf("unexpected meta or title %+v", got)
+	}
+}
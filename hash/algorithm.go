@@ -0,0 +1,130 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	gohash "hash"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zeebo/blake3"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Algorithm is a named digest usable for intent hashes. Hashes produced with
+// HashIntentWith carry the name as a prefix, e.g. "sha512:<hex>", so verifiers
+// can dispatch by prefix and chains can mix algorithms during a migration.
+type Algorithm struct {
+	Name string
+	New  func() gohash.Hash
+}
+
+const (
+	// SHA256 is the algorithm behind unprefixed hashes from HashIntent.
+	SHA256 = "sha256"
+	// SHA512 is SHA-512 with a full 64-byte digest.
+	SHA512 = "sha512"
+	// BLAKE3 is BLAKE3 with its default 32-byte digest.
+	BLAKE3 = "blake3"
+)
+
+var (
+	algorithmsMu sync.RWMutex
+	algorithms   = map[string]Algorithm{
+		SHA256: {Name: SHA256, New: sha256.New},
+		SHA512: {Name: SHA512, New: sha512.New},
+		BLAKE3: {Name: BLAKE3, New: func() gohash.Hash { return blake3.New() }},
+	}
+)
+
+// RegisterAlgorithm makes an algorithm available to HashIntentWith and
+// Recompute beyond the built-in SHA256, SHA512, and BLAKE3. It panics if the
+// name is empty, contains ':', or is already registered.
+func RegisterAlgorithm(alg Algorithm) {
+	if alg.Name == "" || strings.Contains(alg.Name, ":") || alg.New == nil {
+		panic("hash: invalid algorithm registration")
+	}
+	algorithmsMu.Lock()
+	defer algorithmsMu.Unlock()
+	if _, dup := algorithms[alg.Name]; dup {
+		panic("hash: algorithm " + alg.Name + " already registered")
+	}
+	algorithms[alg.Name] = alg
+}
+
+// LookupAlgorithm returns the registered algorithm with name.
+func LookupAlgorithm(name string) (Algorithm, bool) {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+	alg, ok := algorithms[name]
+	return alg, ok
+}
+
+// Algorithms returns the registered algorithm names in sorted order.
+func Algorithms() []string {
+	algorithmsMu.RLock()
+	defer algorithmsMu.RUnlock()
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HashIntentWith hashes record's preimage with the named algorithm and returns
+// "<name>:<hex>".
+func HashIntentWith(record model.IntentRecord, name string) (string, error) {
+	alg, ok := LookupAlgorithm(name)
+	if !ok {
+		return "", fmt.Errorf("unknown hash algorithm %q", name)
+	}
+	preimage, err := Preimage(record)
+	if err != nil {
+		return "", err
+	}
+	h := alg.New()
+	h.Write(preimage)
+	return name + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SplitHash separates an intent hash into its algorithm name and hex digest.
+// Unprefixed hashes are legacy SHA-256 and report an empty name.
+func SplitHash(h string) (name, digest string) {
+	if i := strings.IndexByte(h, ':'); i >= 0 {
+		return h[:i], h[i+1:]
+	}
+	return "", h
+}
+
+// Recompute hashes record again with the algorithm that produced record.Hash:
+// the prefixed algorithm, or HashIntent when the hash is empty or unprefixed.
+// Keyed hashes need the secret; use VerifyHMACIntent for those.
+func Recompute(record model.IntentRecord) (string, error) {
+	if IsKeyed(record.Hash) {
+		return "", errors.New("keyed hash cannot be recomputed without the secret")
+	}
+	name, _ := SplitHash(record.Hash)
+	if name == "" {
+		return HashIntent(record)
+	}
+	return HashIntentWith(record, name)
+}
+
+// VerifyHash reports whether record.Hash matches its recomputed hash, dispatching
+// on the algorithm prefix. A mismatch is a *model.HashMismatchError.
+func VerifyHash(record model.IntentRecord) error {
+	recomputed, err := Recompute(record)
+	if err != nil {
+		return err
+	}
+	if recomputed != record.Hash {
+		return &model.HashMismatchError{ID: record.ID, Stored: record.Hash, Recomputed: recomputed}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func algorithmTestRecord() model.IntentRecord {
+	return model.IntentRecord{
+		ID:         "intent-1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "response",
+	}
+}
+
+func TestHashIntentWithPrefixesAlgorithm(t *testing.T) {
+	record := algorithmTestRecord()
+	legacy, err := HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+
+	sha256Hash, err := HashIntentWith(record, SHA256)
+	if err != nil {
+		t.Fatalf("hash with sha256: %v", err)
+	}
+	if sha256Hash != "sha256:"+legacy {
+		t.Fatalf("expected prefixed legacy digest, got %s", sha256Hash)
+	}
+
+	sha512Hash, err := HashIntentWith(record, SHA512)
+	if err != nil {
+		t.Fatalf("hash with sha512: %v", err)
+	}
+	name, digest := SplitHash(sha512Hash)
+	if name != SHA512 || len(digest) != 128 {
+		t.Fatalf("unexpected sha512 hash %s", sha512Hash)
+	}
+
+	blake3Hash, err := HashIntentWith(record, BLAKE3)
+	if err != nil {
+		t.Fatalf("hash with blake3: %v", err)
+	}
+	if name, digest := SplitHash(blake3Hash); name != BLAKE3 || len(digest) != 64 {
+		t.Fatalf("unexpected blake3 hash %s", blake3Hash)
+	}
+
+	if _, err := HashIntentWith(record, "md5"); err == nil {
+		t.Fatalf("expected unknown algorithm to fail")
+	}
+}
+
+func TestVerifyHashDispatchesByPrefix(t *testing.T) {
+	for _, alg := range []string{"", SHA256, SHA512, BLAKE3} {
+		record := algorithmTestRecord()
+		var err error
+		if alg == "" {
+			record.Hash, err = HashIntent(record)
+		} else {
+			record.Hash, err = HashIntentWith(record, alg)
+		}
+		if err != nil {
+			t.Fatalf("%q: hash: %v", alg, err)
+		}
+		if err := VerifyHash(record); err != nil {
+			t.Fatalf("%q: verify: %v", alg, err)
+		}
+		record.Response = "altered"
+		if err := VerifyHash(record); err == nil {
+			t.Fatalf("%q: expected altered record to fail", alg)
+		}
+	}
+
+	record := algorithmTestRecord()
+	record.Hash = "unknown:abcd"
+	if err := VerifyHash(record); err == nil {
+		t.Fatalf("expected unknown prefix to fail")
+	}
+}
+
+func TestBLAKE3KnownAnswers(t *testing.T) {
+	alg, ok := LookupAlgorithm(BLAKE3)
+	if !ok {
+		t.Fatalf("expected blake3 to be built in")
+	}
+	for input, want := range map[string]string{
+		"":    "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+		"abc": "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85",
+	} {
+		h := alg.New()
+		h.Write([]byte(input))
+		if got := hex.EncodeToString(h.Sum(nil)); got != want {
+			t.Fatalf("blake3(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	RegisterAlgorithm(Algorithm{Name: "test-sha224", New: sha256.New224})
+	found := false
+	for _, name := range Algorithms() {
+		found = found || name == "test-sha224"
+	}
+	if !found {
+		t.Fatalf("expected registered algorithm to be listed")
+	}
+	sum, err := HashIntentWith(algorithmTestRecord(), "test-sha224")
+	if err != nil || !strings.HasPrefix(sum, "test-sha224:") {
+		t.Fatalf("unexpected hash %s (%v)", sum, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected duplicate registration to panic")
+		}
+	}()
+	RegisterAlgorithm(Algorithm{Name: SHA256, New: sha256.New})
+}
@@ -0,0 +1,41 @@
+package hash_test
+
+import (
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/internal/synth"
+)
+
+// These live in an external test package because synth hashes the records
+// it generates.
+
+func BenchmarkHashIntent(b *testing.B) {
+	records, err := synth.Intents(synth.Small, synth.Options{})
+	if err != nil {
+		b.Fatalf("generate intents: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := hash.HashIntent(records[i%len(records)]); err != nil {
+			b.Fatalf("hash intent: %v", err)
+		}
+	}
+}
+
+func BenchmarkCanonicalizeMeta(b *testing.B) {
+	records, err := synth.Intents(synth.Small, synth.Options{})
+	if err != nil {
+		b.Fatalf("generate intents: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := hash.CanonicalizeMeta(records[i%len(records)].Meta); err != nil {
+			b.Fatalf("canonicalize meta: %v", err)
+		}
+	}
+}
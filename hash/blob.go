@@ -0,0 +1,18 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// BlobDigest returns the content address of data as "sha256:<hex>".
+func BlobDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return FormatBlobDigest(sum[:])
+}
+
+// FormatBlobDigest formats a SHA-256 sum, typically from an incremental
+// sha256.New over streamed content, as a blob digest.
+func FormatBlobDigest(sum []byte) string {
+	return SHA256 + ":" + hex.EncodeToString(sum)
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"sort"
 	"strconv"
@@ -17,10 +18,14 @@ import (
 )
 
 // CanonicalizeMeta re-encodes a JSON object with sorted keys.
+// Input larger or deeper than the configured MetaLimits is rejected.
 func CanonicalizeMeta(raw json.RawMessage) (json.RawMessage, error) {
 	if len(raw) == 0 {
 		return nil, nil
 	}
+	if err := checkMetaLimits(raw, currentMetaLimits()); err != nil {
+		return nil, err
+	}
 
 	value, err := decodeJSON(raw)
 	if err != nil {
@@ -38,11 +43,29 @@ func CanonicalizeMeta(raw json.RawMessage) (json.RawMessage, error) {
 	return json.RawMessage(b.String()), nil
 }
 
+const (
+	// HashVersionLegacy selects the original canonical preimage. Records with
+	// a zero HashVersion use it.
+	HashVersionLegacy = 1
+	// HashVersionJCS selects an RFC 8785 (JCS) preimage that other languages
+	// can reproduce from the spec alone.
+	HashVersionJCS = 2
+	// HashVersionJCSNFC is HashVersionJCS over text fields normalized to
+	// Unicode NFC (see model.NFCHashVersion).
+	HashVersionJCSNFC = model.NFCHashVersion
+	// HashVersionJCSULID is HashVersionJCSNFC for records whose IDs must be
+	// ULIDs (see model.ULIDHashVersion).
+	HashVersionJCSULID = model.ULIDHashVersion
+	// HashVersionJCSEmptyMeta is HashVersionJCSULID with an empty meta object
+	// hashed as absent meta (see model.EmptyMetaHashVersion).
+	HashVersionJCSEmptyMeta = model.EmptyMetaHashVersion
+)
+
 // HashIntent computes a deterministic SHA-256 hash for an IntentRecord.
-// The hash preimage excludes the hash field and uses canonical field order.
+// The hash preimage excludes the hash, signature, and public_key fields; its
+// encoding is chosen by record.HashVersion.
 func HashIntent(record model.IntentRecord) (string, error) {
-	normalized := record.Normalize()
-	preimage, err := canonicalIntentPreimage(normalized)
+	preimage, err := Preimage(record)
 	if err != nil {
 		return "", err
 	}
@@ -51,28 +74,57 @@ func HashIntent(record model.IntentRecord) (string, error) {
 	return hex.EncodeToString(sum[:]), nil
 }
 
-func canonicalIntentPreimage(record model.IntentRecord) ([]byte, error) {
+// Preimage returns the exact bytes HashIntent, HashIntentWith, and HMACIntent
+// digest for record: the normalized record encoded according to its
+// hash_version. Auditors can hash these bytes with any SHA-256 implementation
+// to reproduce an unprefixed hash independently.
+func Preimage(record model.IntentRecord) ([]byte, error) {
+	return intentPreimage(record.Normalize())
+}
+
+// intentPreimage builds the preimage for a normalized record according to its
+// hash version.
+func intentPreimage(record model.IntentRecord) ([]byte, error) {
+	switch record.HashVersion {
+	case 0, HashVersionLegacy:
+		return canonicalIntentPreimage(record)
+	case HashVersionJCS, HashVersionJCSNFC, HashVersionJCSULID, HashVersionJCSEmptyMeta:
+		return jcsIntentPreimage(record)
+	default:
+		return nil, fmt.Errorf("unsupported hash_version %d", record.HashVersion)
+	}
+}
+
+// requireHashFields checks the fields every preimage version requires.
+func requireHashFields(record model.IntentRecord) error {
 	if len(record.ID) == 0 {
-		return nil, errors.New("id is required for hashing")
+		return errors.New("id is required for hashing")
 	}
 	if len(record.CreatedAt) == 0 {
-		return nil, errors.New("created_at is required for hashing")
-	}
-	createdAt, err := normalizeRFC3339(record.CreatedAt)
-	if err != nil {
-		return nil, errors.New("created_at must be RFC3339")
+		return errors.New("created_at is required for hashing")
 	}
 	if len(record.Author) == 0 {
-		return nil, errors.New("author is required for hashing")
+		return errors.New("author is required for hashing")
 	}
 	if len(record.SourceType) == 0 {
-		return nil, errors.New("source_type is required for hashing")
+		return errors.New("source_type is required for hashing")
 	}
 	if len(record.Prompt) == 0 {
-		return nil, errors.New("prompt is required for hashing")
+		return errors.New("prompt is required for hashing")
 	}
 	if len(record.Response) == 0 {
-		return nil, errors.New("response is required for hashing")
+		return errors.New("response is required for hashing")
+	}
+	return nil
+}
+
+func canonicalIntentPreimage(record model.IntentRecord) ([]byte, error) {
+	if err := requireHashFields(record); err != nil {
+		return nil, err
+	}
+	createdAt, err := normalizeRFC3339(record.CreatedAt)
+	if err != nil {
+		return nil, errors.New("created_at must be RFC3339")
 	}
 
 	var meta json.RawMessage
@@ -103,6 +155,26 @@ func canonicalIntentPreimage(record model.IntentRecord) ([]byte, error) {
 	if record.PrevHash != "" {
 		addStringField(&b, &first, "prev_hash", record.PrevHash)
 	}
+	if len(record.Tags) > 0 {
+		tags, err := json.Marshal(record.Tags)
+		if err != nil {
+			return nil, err
+		}
+		addRawField(&b, &first, "tags", tags)
+	}
+	if record.ThreadID != "" {
+		addStringField(&b, &first, "thread_id", record.ThreadID)
+	}
+	if record.ParentID != "" {
+		addStringField(&b, &first, "parent_id", record.ParentID)
+	}
+	if len(record.Attachments) > 0 {
+		attachments, err := json.Marshal(record.Attachments)
+		if err != nil {
+			return nil, err
+		}
+		addRawField(&b, &first, "attachments", attachments)
+	}
 	b.WriteByte('}')
 
 	return []byte(b.String()), nil
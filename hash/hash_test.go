@@ -2,7 +2,10 @@
 package hash
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/chuxorg/chux-yanzi-core/model"
@@ -54,3 +57,175 @@ func TestHashIntentCanonicalization(t *testing.T) {
 		t.Fatalf("expected identical hash for newline variants, got %s and %s", hash1, hash4)
 	}
 }
+
+func TestHashIntentEmptyMetaMatchesAbsent(t *testing.T) {
+	base := model.IntentRecord{
+		ID:          "01HZYFQ7T9ZV54X2G4A8M4J2C1",
+		CreatedAt:   "2026-02-09T10:00:00Z",
+		Author:      "alice",
+		SourceType:  "cli",
+		Prompt:      "prompt",
+		Response:    "response",
+		HashVersion: HashVersionJCSEmptyMeta,
+	}
+
+	absent, err := HashIntent(base)
+	if err != nil {
+		t.Fatalf("hash absent meta: %v", err)
+	}
+
+	empty := base
+	empty.Meta = json.RawMessage(`{ }`)
+	emptyHash, err := HashIntent(empty)
+	if err != nil {
+		t.Fatalf("hash empty meta: %v", err)
+	}
+	if absent != emptyHash {
+		t.Fatalf("expected empty meta to hash like absent meta, got %s and %s", absent, emptyHash)
+	}
+
+	// Older versions keep hashing {} as written, so their hashes stay valid.
+	empty.HashVersion, base.HashVersion = HashVersionJCSULID, HashVersionJCSULID
+	legacyEmpty, err := HashIntent(empty)
+	if err != nil {
+		t.Fatalf("hash empty meta: %v", err)
+	}
+	legacyAbsent, err := HashIntent(base)
+	if err != nil {
+		t.Fatalf("hash absent meta: %v", err)
+	}
+	if legacyEmpty == legacyAbsent {
+		t.Fatalf("expected empty meta to change the hash before hash_version %d", HashVersionJCSEmptyMeta)
+	}
+
+	populated := base
+	populated.Meta = json.RawMessage(`{"env":"prod"}`)
+	populatedHash, err := HashIntent(populated)
+	if err != nil {
+		t.Fatalf("hash populated meta: %v", err)
+	}
+	if populatedHash == absent {
+		t.Fatalf("expected populated meta to change the hash")
+	}
+}
+
+func TestPreimageReproducesHash(t *testing.T) {
+	record := model.IntentRecord{
+		ID:         "01HZYFQ7T9ZV54X2G4A8M4J2C1",
+		CreatedAt:  "2026-02-09T12:00:00+02:00",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "line1\r\nline2",
+		Response:   "resp",
+		Meta:       json.RawMessage(`{"b":2,"a":1}`),
+		PrevHash:   "abc",
+	}
+
+	preimage, err := Preimage(record)
+	if err != nil {
+		t.Fatalf("preimage: %v", err)
+	}
+	want := `{"id":"01HZYFQ7T9ZV54X2G4A8M4J2C1","created_at":"2026-02-09T10:00:00Z","author":"alice","source_type":"cli","prompt":"line1\nline2","response":"resp","meta":{"a":1,"b":2},"prev_hash":"abc"}`
+	if string(preimage) != want {
+		t.Fatalf("expected preimage\n%s\ngot\n%s", want, preimage)
+	}
+
+	sum := sha256.Sum256(preimage)
+	record.Hash = hex.EncodeToString(sum[:])
+	if err := VerifyHash(record); err != nil {
+		t.Fatalf("independently computed hash should verify: %v", err)
+	}
+	record.Prompt = "changed"
+	if err := VerifyHash(record); err == nil {
+		t.Fatalf("expected altered record to fail verification")
+	}
+}
+
+func TestHashIntentTags(t *testing.T) {
+	record := model.IntentRecord{
+		ID:         "01HZYFQ7T9ZV54X2G4A8M4J2C1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "resp",
+	}
+	untagged, err := HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash untagged: %v", err)
+	}
+
+	record.Tags = []string{}
+	if sum, _ := HashIntent(record); sum != untagged {
+		t.Fatalf("expected empty tags to leave the hash unchanged")
+	}
+
+	record.Tags = []string{"Bug", "review"}
+	a, err := HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash tagged: %v", err)
+	}
+	record.Tags = []string{"review", " bug", "BUG"}
+	b, _ := HashIntent(record)
+	if a == untagged || a != b {
+		t.Fatalf("expected tags to be hashed after normalization")
+	}
+
+	preimage, err := Preimage(record)
+	if err != nil {
+		t.Fatalf("preimage: %v", err)
+	}
+	if !strings.HasSuffix(string(preimage), `"response":"resp","tags":["bug","review"]}`) {
+		t.Fatalf("unexpected preimage %s", preimage)
+	}
+}
+
+func TestHashIntentThreadFields(t *testing.T) {
+	record := model.IntentRecord{
+		ID:         "01HZYFQ7T9ZV54X2G4A8M4J2C1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "resp",
+	}
+	base, _ := HashIntent(record)
+	record.ThreadID = "thread-1"
+	threaded, _ := HashIntent(record)
+	record.ParentID = "turn-0"
+	replied, _ := HashIntent(record)
+	if base == threaded || threaded == replied {
+		t.Fatalf("expected thread_id and parent_id to be part of the hash")
+	}
+}
+
+func TestHashIntentAttachments(t *testing.T) {
+	for _, version := range []int{0, HashVersionJCS} {
+		record := model.IntentRecord{
+			ID:          "01HZX0A0000000000000000000",
+			CreatedAt:   "2026-02-09T10:00:00Z",
+			Author:      "alice",
+			SourceType:  "cli",
+			Prompt:      "p",
+			Response:    "r",
+			HashVersion: version,
+		}
+		plain, err := HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash without attachments: %v", err)
+		}
+		record.Attachments = []model.Attachment{{Digest: BlobDigest([]byte("a")), Size: 1}}
+		first, err := HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash with attachments: %v", err)
+		}
+		record.Attachments[0].Digest = BlobDigest([]byte("b"))
+		second, err := HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash with changed attachment: %v", err)
+		}
+		if first == plain || first == second {
+			t.Fatalf("version %d: expected attachment digests to be part of the hash", version)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// HMACSHA256Prefix marks a hash produced by HMACIntent. The prefix records the
+// algorithm in the hash field itself, so keyed and plain hashes can share a
+// chain and a store column.
+const HMACSHA256Prefix = "hmac-sha256:"
+
+// HMACIntent computes a keyed HMAC-SHA256 over the same canonical preimage as
+// HashIntent and returns it with HMACSHA256Prefix. Without the secret a third
+// party cannot produce a valid hash, and so cannot forge chain entries.
+func HMACIntent(record model.IntentRecord, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", errors.New("hmac secret is required")
+	}
+	preimage, err := Preimage(record)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(preimage)
+	return HMACSHA256Prefix + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyHMACIntent checks record.Hash against HMACIntent under secret using a
+// constant-time comparison.
+func VerifyHMACIntent(record model.IntentRecord, secret []byte) error {
+	if !IsKeyed(record.Hash) {
+		return errors.New("hash is not an HMAC")
+	}
+	want, err := HMACIntent(record, secret)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(want), []byte(record.Hash)) {
+		return fmt.Errorf("%w: hmac for intent %s does not match", model.ErrHashMismatch, record.ID)
+	}
+	return nil
+}
+
+// IsKeyed reports whether h was produced by a keyed algorithm and therefore
+// cannot be recomputed without the secret.
+func IsKeyed(h string) bool {
+	return strings.HasPrefix(h, HMACSHA256Prefix)
+}
@@ -0,0 +1,50 @@
+package hash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestHMACIntent(t *testing.T) {
+	record := model.IntentRecord{
+		ID:         "intent-1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "response",
+	}
+	secret := []byte("shared secret")
+
+	sum, err := HMACIntent(record, secret)
+	if err != nil {
+		t.Fatalf("hmac intent: %v", err)
+	}
+	if !strings.HasPrefix(sum, HMACSHA256Prefix) || !IsKeyed(sum) {
+		t.Fatalf("expected %s prefix, got %s", HMACSHA256Prefix, sum)
+	}
+	plain, err := HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	if IsKeyed(plain) || strings.TrimPrefix(sum, HMACSHA256Prefix) == plain {
+		t.Fatalf("expected keyed hash to differ from plain hash")
+	}
+
+	record.Hash = sum
+	if err := VerifyHMACIntent(record, secret); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := VerifyHMACIntent(record, []byte("other secret")); err == nil {
+		t.Fatalf("expected wrong secret to fail")
+	}
+	record.Prompt = "forged"
+	if err := VerifyHMACIntent(record, secret); err == nil {
+		t.Fatalf("expected altered record to fail")
+	}
+	if _, err := HMACIntent(record, nil); err == nil {
+		t.Fatalf("expected empty secret to fail")
+	}
+}
@@ -0,0 +1,227 @@
+package hash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// CanonicalizeJCS re-encodes a JSON value per RFC 8785 (JSON Canonicalization
+// Scheme): object keys sorted by UTF-16 code units, minimal string escaping,
+// and numbers formatted as ECMAScript doubles. Input larger or deeper than the
+// configured MetaLimits is rejected.
+func CanonicalizeJCS(raw json.RawMessage) ([]byte, error) {
+	if err := checkMetaLimits(raw, currentMetaLimits()); err != nil {
+		return nil, err
+	}
+	value, err := decodeJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	if err := writeJCSValue(&b, value); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// jcsIntentPreimage is the HashVersionJCS preimage: the record minus hash,
+// signature, and public_key, as a JCS object. Empty optional fields are
+// omitted and hash_version is included so the version cannot be swapped.
+func jcsIntentPreimage(record model.IntentRecord) ([]byte, error) {
+	if err := requireHashFields(record); err != nil {
+		return nil, err
+	}
+	createdAt, err := normalizeRFC3339(record.CreatedAt)
+	if err != nil {
+		return nil, errors.New("created_at must be RFC3339")
+	}
+
+	obj := map[string]any{
+		"id":           record.ID,
+		"created_at":   createdAt,
+		"author":       record.Author,
+		"source_type":  record.SourceType,
+		"prompt":       record.Prompt,
+		"response":     record.Response,
+		"hash_version": json.Number(strconv.Itoa(record.HashVersion)),
+	}
+	if record.Title != "" {
+		obj["title"] = record.Title
+	}
+	if record.PrevHash != "" {
+		obj["prev_hash"] = record.PrevHash
+	}
+	if len(record.Tags) > 0 {
+		tags := make([]any, len(record.Tags))
+		for i, tag := range record.Tags {
+			tags[i] = tag
+		}
+		obj["tags"] = tags
+	}
+	if record.ThreadID != "" {
+		obj["thread_id"] = record.ThreadID
+	}
+	if record.ParentID != "" {
+		obj["parent_id"] = record.ParentID
+	}
+	if len(record.Attachments) > 0 {
+		attachments := make([]any, len(record.Attachments))
+		for i, a := range record.Attachments {
+			entry := map[string]any{
+				"digest": a.Digest,
+				"size":   json.Number(strconv.FormatInt(a.Size, 10)),
+			}
+			if a.Name != "" {
+				entry["name"] = a.Name
+			}
+			if a.MediaType != "" {
+				entry["media_type"] = a.MediaType
+			}
+			attachments[i] = entry
+		}
+		obj["attachments"] = attachments
+	}
+	if len(record.Meta) > 0 {
+		if err := checkMetaLimits(record.Meta, currentMetaLimits()); err != nil {
+			return nil, err
+		}
+		meta, err := decodeJSON(record.Meta)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := meta.(map[string]any); !ok {
+			return nil, errors.New("meta must be a JSON object")
+		}
+		obj["meta"] = meta
+	}
+
+	var b strings.Builder
+	if err := writeJCSValue(&b, obj); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+func writeJCSValue(b *strings.Builder, value any) error {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case string:
+		writeJCSString(b, v)
+	case json.Number:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil || math.IsInf(f, 0) {
+			return fmt.Errorf("number %s is not representable as an IEEE 754 double", v)
+		}
+		b.WriteString(formatJCSNumber(f))
+	case []any:
+		b.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeJCSValue(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+		b.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJCSString(b, key)
+			b.WriteByte(':')
+			if err := writeJCSValue(b, v[key]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported JSON value %T", value)
+	}
+	return nil
+}
+
+// formatJCSNumber formats f like ECMAScript Number.prototype.toString.
+func formatJCSNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+	if format == 'e' {
+		// Go writes e-07 where ECMAScript writes e-7.
+		if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-3] == '-' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+	}
+	return s
+}
+
+// writeJCSString escapes only quote, backslash, and control characters, using
+// the short forms where JSON defines them and lowercase \u00xx otherwise.
+func writeJCSString(b *strings.Builder, s string) {
+	const hex = "0123456789abcdef"
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				b.WriteString(`\u00`)
+				b.WriteByte(hex[r>>4])
+				b.WriteByte(hex[r&0xf])
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as RFC 8785 requires.
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
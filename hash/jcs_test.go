@@ -0,0 +1,147 @@
+package hash
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestCanonicalizeJCSSpecExamples(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "rfc8785 section 3.2.2",
+			in:   `{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],"string":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/","literals":[null,true,false]}`,
+			want: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			name: "utf-16 key ordering",
+			in:   `{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`,
+			want: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		{
+			name: "no html escaping and negative zero",
+			in:   `{"a":"<&>\u2028","b":-0,"c":1e-7,"d":100}`,
+			want: "{\"a\":\"<&>\u2028\",\"b\":0,\"c\":1e-7,\"d\":100}",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CanonicalizeJCS(json.RawMessage(tc.in))
+			if err != nil {
+				t.Fatalf("canonicalize: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("expected\n%s\ngot\n%s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeJCSRejectsOverflow(t *testing.T) {
+	if _, err := CanonicalizeJCS(json.RawMessage(`[1e400]`)); err == nil {
+		t.Fatalf("expected out-of-range number to fail")
+	}
+}
+
+func TestHashIntentJCSVersion(t *testing.T) {
+	record := model.IntentRecord{
+		ID:         "intent-1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "response",
+		Meta:       json.RawMessage(`{"b":1.50,"a":"x"}`),
+	}
+	legacy, err := HashIntent(record)
+	if err != nil {
+		t.Fatalf("legacy hash: %v", err)
+	}
+
+	record.HashVersion = HashVersionJCS
+	jcs, err := HashIntent(record)
+	if err != nil {
+		t.Fatalf("jcs hash: %v", err)
+	}
+	if jcs == legacy {
+		t.Fatalf("expected JCS and legacy hashes to differ")
+	}
+
+	preimage, err := jcsIntentPreimage(record.Normalize())
+	if err != nil {
+		t.Fatalf("jcs preimage: %v", err)
+	}
+	want := `{"author":"alice","created_at":"2026-02-09T10:00:00Z","hash_version":2,"id":"intent-1","meta":{"a":"x","b":1.5},"prompt":"prompt","response":"response","source_type":"cli"}`
+	if string(preimage) != want {
+		t.Fatalf("expected preimage\n%s\ngot\n%s", want, preimage)
+	}
+
+	record.HashVersion = 99
+	if _, err := HashIntent(record); err == nil {
+		t.Fatalf("expected unsupported hash_version to fail")
+	}
+}
+
+func TestHashIntentNFCVersionUnifiesCompositions(t *testing.T) {
+	composed := model.IntentRecord{
+		ID:          "intent-1",
+		CreatedAt:   "2026-02-09T10:00:00Z",
+		Author:      "alice",
+		SourceType:  "cli",
+		Prompt:      "caf\u00e9",
+		Response:    "response",
+		HashVersion: HashVersionJCSNFC,
+	}
+	decomposed := composed
+	decomposed.Prompt = "cafe\u0301"
+
+	a, err := HashIntent(composed)
+	if err != nil {
+		t.Fatalf("hash composed: %v", err)
+	}
+	b, err := HashIntent(decomposed)
+	if err != nil {
+		t.Fatalf("hash decomposed: %v", err)
+	}
+	if a != b {
+		t.Fatalf("expected NFC hash version to unify compositions")
+	}
+
+	composed.HashVersion, decomposed.HashVersion = HashVersionJCS, HashVersionJCS
+	a, _ = HashIntent(composed)
+	b, _ = HashIntent(decomposed)
+	if a == b {
+		t.Fatalf("expected earlier hash versions to keep distinct hashes")
+	}
+}
+
+func TestHashIntentULIDVersion(t *testing.T) {
+	record := model.IntentRecord{
+		ID:          "01ARYZ6S41TSV4RRFFQ69G5FAV",
+		CreatedAt:   "2026-02-09T10:00:00Z",
+		Author:      "alice",
+		SourceType:  "cli",
+		Prompt:      "prompt",
+		Response:    "response",
+		HashVersion: HashVersionJCSULID,
+	}
+	a, err := HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	// The version is in the preimage, so it cannot be swapped for one that
+	// skips the ID check.
+	record.HashVersion = HashVersionJCSNFC
+	b, err := HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if a == b {
+		t.Fatal("expected hash versions to hash differently")
+	}
+}
@@ -0,0 +1,94 @@
+package hash
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrMetaTooDeep is returned (wrapped with the limit) when meta nests
+	// objects and arrays deeper than MetaLimits.MaxDepth.
+	ErrMetaTooDeep = errors.New("meta exceeds max depth")
+	// ErrMetaTooLarge is returned (wrapped with the limit) when encoded meta is
+	// larger than MetaLimits.MaxBytes.
+	ErrMetaTooLarge = errors.New("meta exceeds max size")
+)
+
+// MetaLimits bounds the meta JSON accepted by CanonicalizeMeta and HashIntent.
+type MetaLimits struct {
+	// MaxDepth is the deepest allowed nesting of objects and arrays; the meta object itself is depth 1.
+	MaxDepth int
+	// MaxBytes is the largest allowed encoded meta size.
+	MaxBytes int
+}
+
+// DefaultMetaLimits are generous bounds that still keep canonicalization finite.
+var DefaultMetaLimits = MetaLimits{
+	MaxDepth: 64,
+	MaxBytes: 1 << 20,
+}
+
+var (
+	metaLimitsMu sync.RWMutex
+	metaLimits   = DefaultMetaLimits
+)
+
+// SetMetaLimits replaces the limits used by CanonicalizeMeta and HashIntent.
+// Non-positive fields fall back to DefaultMetaLimits.
+func SetMetaLimits(limits MetaLimits) {
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultMetaLimits.MaxDepth
+	}
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = DefaultMetaLimits.MaxBytes
+	}
+	metaLimitsMu.Lock()
+	metaLimits = limits
+	metaLimitsMu.Unlock()
+}
+
+func currentMetaLimits() MetaLimits {
+	metaLimitsMu.RLock()
+	defer metaLimitsMu.RUnlock()
+	return metaLimits
+}
+
+// checkMetaLimits rejects raw before it is decoded, so oversized or deeply
+// nested input never reaches the recursive canonical writer. The scan is
+// iterative and meta arrives as encoded JSON, which cannot contain cycles, so
+// no input can exhaust the stack.
+func checkMetaLimits(raw []byte, limits MetaLimits) error {
+	if len(raw) > limits.MaxBytes {
+		return fmt.Errorf("%w %d bytes", ErrMetaTooLarge, limits.MaxBytes)
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for _, c := range raw {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > limits.MaxDepth {
+				return fmt.Errorf("%w %d", ErrMetaTooDeep, limits.MaxDepth)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return nil
+}
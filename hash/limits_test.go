@@ -0,0 +1,61 @@
+package hash
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestCanonicalizeMetaRejectsDeepNesting(t *testing.T) {
+	const levels = 1000
+	raw := strings.Repeat(`{"a":`, levels) + `1` + strings.Repeat(`}`, levels)
+
+	_, err := CanonicalizeMeta(json.RawMessage(raw))
+	if !errors.Is(err, ErrMetaTooDeep) || !strings.Contains(err.Error(), "meta exceeds max depth 64") {
+		t.Fatalf("expected depth error, got %v", err)
+	}
+
+	record := model.IntentRecord{
+		ID:         "01HZYFQ7T9ZV54X2G4A8M4J2C1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "response",
+		Meta:       json.RawMessage(raw),
+	}
+	if _, err := HashIntent(record); !errors.Is(err, ErrMetaTooDeep) {
+		t.Fatalf("expected HashIntent to reject meta that cannot be canonicalized, got %v", err)
+	}
+	record.HashVersion = HashVersionJCS
+	if _, err := HashIntent(record); !errors.Is(err, ErrMetaTooDeep) {
+		t.Fatalf("expected JCS hashing to apply the same limits, got %v", err)
+	}
+}
+
+func TestCanonicalizeMetaRejectsOversizedMeta(t *testing.T) {
+	raw := `{"blob":"` + strings.Repeat("x", DefaultMetaLimits.MaxBytes) + `"}`
+
+	_, err := CanonicalizeMeta(json.RawMessage(raw))
+	if !errors.Is(err, ErrMetaTooLarge) {
+		t.Fatalf("expected size error, got %v", err)
+	}
+}
+
+func TestSetMetaLimits(t *testing.T) {
+	t.Cleanup(func() { SetMetaLimits(DefaultMetaLimits) })
+	SetMetaLimits(MetaLimits{MaxDepth: 2})
+
+	if _, err := CanonicalizeMeta(json.RawMessage(`{"a":{"b":1}}`)); err != nil {
+		t.Fatalf("expected depth 2 to pass: %v", err)
+	}
+	if _, err := CanonicalizeMeta(json.RawMessage(`{"a":{"b":["c"]}}`)); err == nil {
+		t.Fatalf("expected depth 3 to be rejected")
+	}
+	if _, err := CanonicalizeMeta(json.RawMessage(`{"a":"{{{{"}`)); err != nil {
+		t.Fatalf("expected braces inside strings to be ignored: %v", err)
+	}
+}
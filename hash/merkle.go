@@ -0,0 +1,57 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// MerkleLeaf returns the RFC 6962 leaf hash SHA-256(0x00 || hash) of an intent
+// hash string.
+func MerkleLeaf(hash string) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write([]byte(hash))
+	return h.Sum(nil)
+}
+
+// MerkleNode returns the RFC 6962 interior hash SHA-256(0x01 || left || right).
+func MerkleNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// MerkleRoot returns the hex-encoded root over intent hashes in the given
+// order. An empty list hashes to SHA-256("").
+func MerkleRoot(hashes []string) string {
+	leaves := make([][]byte, len(hashes))
+	for i, h := range hashes {
+		leaves[i] = MerkleLeaf(h)
+	}
+	return hex.EncodeToString(MerkleSubtree(leaves))
+}
+
+// MerkleSubtree returns the root over already hashed leaves.
+func MerkleSubtree(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		return leaves[0]
+	}
+	k := MerkleSplit(len(leaves))
+	return MerkleNode(MerkleSubtree(leaves[:k]), MerkleSubtree(leaves[k:]))
+}
+
+// MerkleSplit returns the largest power of two strictly less than n (n > 1),
+// where RFC 6962 splits a tree of n leaves.
+func MerkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
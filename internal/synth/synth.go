@@ -0,0 +1,182 @@
+// Package synth generates realistic, deterministic intent records for
+// benchmarks. The same Options always produce the same records, so results
+// from different runs compare like for like.
+package synth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Dataset sizes the benchmarks run at.
+const (
+	Small  = 10_000
+	Medium = 100_000
+	Large  = 1_000_000
+)
+
+// MaxRecordsEnv names the environment variable that bounds the dataset sizes
+// returned by Sizes. Seeding a store with Large records takes minutes, so by
+// default only Small runs.
+const MaxRecordsEnv = "YANZI_BENCH_MAX_RECORDS"
+
+// Sizes returns the dataset sizes, smallest first, up to the bound set with
+// MaxRecordsEnv (Small when unset or invalid).
+func Sizes() []int {
+	limit := Small
+	if v, err := strconv.Atoi(os.Getenv(MaxRecordsEnv)); err == nil && v > 0 {
+		limit = v
+	}
+	var sizes []int
+	for _, n := range []int{Small, Medium, Large} {
+		if n <= limit {
+			sizes = append(sizes, n)
+		}
+	}
+	return sizes
+}
+
+// Options shape a generated dataset.
+type Options struct {
+	// Seed selects the dataset; the zero seed is as good as any other.
+	Seed uint64
+	// Authors is how many distinct authors records are spread over; values
+	// <= 0 select 20. Each author's records form one prev_hash chain, as
+	// store.ChainByAuthor expects.
+	Authors int
+	// Start is the created_at of the first record; each later record is one
+	// second newer. The zero value selects 2026-01-01 UTC.
+	Start time.Time
+}
+
+// Generator yields hashed records one at a time, so large datasets need not
+// be held in memory.
+type Generator struct {
+	opts  Options
+	rng   *rand.Rand
+	n     int
+	heads map[string]string
+}
+
+// New returns a Generator for opts.
+func New(opts Options) *Generator {
+	if opts.Authors <= 0 {
+		opts.Authors = 20
+	}
+	if opts.Start.IsZero() {
+		opts.Start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &Generator{
+		opts:  opts,
+		rng:   rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15)),
+		heads: make(map[string]string),
+	}
+}
+
+var (
+	sourceTypes = []string{"cli", "api", "mcp", "recorder"}
+	models      = []string{"gpt-4o", "claude-sonnet", "gemini-pro", "llama-3-70b"}
+	envs        = []string{"prod", "staging", "dev"}
+	tagPool     = []string{"refactor", "bugfix", "docs", "tests", "review", "migration", "security", "perf"}
+	subjects    = []string{"the store package", "the HTTP handler", "the migration runner", "chain verification",
+		"the export pipeline", "the webhook dispatcher", "the CLI flags", "the retention policy"}
+	asks = []string{"Explain how %s works and list its failure modes.",
+		"Refactor %s to remove the duplicated error handling.",
+		"Write table-driven tests for %s.",
+		"Why does %s return a stale result under load?",
+		"Summarize the recent changes to %s for the release notes."}
+	sentences = []string{"The change keeps the public API stable.",
+		"Errors are wrapped with the operation name so callers can classify them.",
+		"Each record is hashed before it is written, and the hash links it to its predecessor.",
+		"The query uses the existing index, so its cost does not grow with the table.",
+		"A retry with backoff covers transient lock contention.",
+		"Tests cover the empty, single, and many-record cases.",
+		"The default is conservative and can be raised through an option."}
+)
+
+// Next returns the next record, hashed and linked to the previous record by
+// the same author.
+func (g *Generator) Next() (model.IntentRecord, error) {
+	n := g.n
+	g.n++
+	r := g.rng
+
+	author := fmt.Sprintf("author-%03d", r.IntN(g.opts.Authors))
+	subject := pick(r, subjects)
+	record := model.IntentRecord{
+		ID:         fmt.Sprintf("synth-%08d", n),
+		CreatedAt:  g.opts.Start.Add(time.Duration(n) * time.Second).Format(time.RFC3339Nano),
+		Author:     author,
+		SourceType: pick(r, sourceTypes),
+		Prompt:     fmt.Sprintf(pick(r, asks), subject),
+		Response:   paragraph(r, 2+r.IntN(12)),
+		PrevHash:   g.heads[author],
+	}
+	if r.IntN(3) > 0 {
+		record.Title = "Work on " + subject
+	}
+	if r.IntN(4) == 0 {
+		record.ThreadID = fmt.Sprintf("thread-%05d", r.IntN(g.opts.Authors*50))
+	}
+	for _, tag := range tagPool {
+		if r.IntN(len(tagPool)) == 0 {
+			record.Tags = append(record.Tags, tag)
+		}
+	}
+	meta, err := json.Marshal(map[string]any{
+		"model":       pick(r, models),
+		"env":         pick(r, envs),
+		"temperature": float64(r.IntN(11)) / 10,
+		"tokens":      map[string]int{"prompt": 20 + r.IntN(400), "completion": 50 + r.IntN(2000)},
+	})
+	if err != nil {
+		return model.IntentRecord{}, err
+	}
+	record.Meta = meta
+
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		return model.IntentRecord{}, fmt.Errorf("hash record %d: %w", n, err)
+	}
+	record.Hash = sum
+	g.heads[author] = sum
+	return record, nil
+}
+
+// Intents returns the first n records for opts.
+func Intents(n int, opts Options) ([]model.IntentRecord, error) {
+	g := New(opts)
+	records := make([]model.IntentRecord, n)
+	for i := range records {
+		record, err := g.Next()
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+func pick(r *rand.Rand, from []string) string {
+	return from[r.IntN(len(from))]
+}
+
+// paragraph joins n sentences drawn from the pool.
+func paragraph(r *rand.Rand, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(pick(r, sentences))
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package synth
+
+import (
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+)
+
+func TestIntentsAreDeterministicAndChained(t *testing.T) {
+	first, err := Intents(200, Options{Seed: 7, Authors: 3})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	second, err := Intents(200, Options{Seed: 7, Authors: 3})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	heads := make(map[string]string)
+	for i, record := range first {
+		if record.Hash != second[i].Hash {
+			t.Fatalf("record %d differs between runs with the same seed", i)
+		}
+		if err := record.Validate(); err != nil {
+			t.Fatalf("record %d is invalid: %v", i, err)
+		}
+		if err := hash.VerifyHash(record); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if record.PrevHash != heads[record.Author] {
+			t.Fatalf("record %d does not link to the previous record by %s", i, record.Author)
+		}
+		heads[record.Author] = record.Hash
+	}
+	if len(heads) != 3 {
+		t.Fatalf("expected records from 3 authors, got %d", len(heads))
+	}
+}
+
+func TestSizes(t *testing.T) {
+	t.Setenv(MaxRecordsEnv, "")
+	if got := Sizes(); len(got) != 1 || got[0] != Small {
+		t.Fatalf("expected only the small dataset by default, got %v", got)
+	}
+	t.Setenv(MaxRecordsEnv, "1000000")
+	if got := Sizes(); len(got) != 3 {
+		t.Fatalf("expected every dataset size, got %v", got)
+	}
+}
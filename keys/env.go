@@ -0,0 +1,20 @@
+package keys
+
+import (
+	"fmt"
+	"os"
+)
+
+// FromEnv loads a base64 key (see ParsePrivateKey) from the environment
+// variable name, for keys injected by a secret manager or orchestrator.
+func FromEnv(name string) (Signer, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	priv, err := ParsePrivateKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", name, err)
+	}
+	return FromPrivateKey(priv)
+}
@@ -0,0 +1,119 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// keyFileIterations is the PBKDF2-SHA256 work factor for new key files.
+const keyFileIterations = 600_000
+
+// keyFile is the on-disk format: the Ed25519 seed sealed with AES-256-GCM
+// under a key derived from the passphrase with PBKDF2-SHA256.
+type keyFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+	PublicKey  []byte `json:"public_key"`
+}
+
+// WriteKeyFile encrypts priv with passphrase and writes it to path with 0600
+// permissions. An existing file is not overwritten.
+func WriteKeyFile(path string, priv ed25519.PrivateKey, passphrase string) error {
+	if len(priv) != ed25519.PrivateKeySize {
+		return errors.New("ed25519 private key has the wrong size")
+	}
+	if passphrase == "" {
+		return errors.New("passphrase is required")
+	}
+
+	kf := keyFile{
+		Version:    1,
+		KDF:        "pbkdf2-sha256",
+		Iterations: keyFileIterations,
+		Salt:       make([]byte, 16),
+		PublicKey:  priv.Public().(ed25519.PublicKey),
+	}
+	if _, err := rand.Read(kf.Salt); err != nil {
+		return err
+	}
+	aead, err := keyFileAEAD(passphrase, kf.Salt, kf.Iterations)
+	if err != nil {
+		return err
+	}
+	kf.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(kf.Nonce); err != nil {
+		return err
+	}
+	kf.Ciphertext = aead.Seal(nil, kf.Nonce, priv.Seed(), kf.PublicKey)
+
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// FromFile decrypts the key file at path written by WriteKeyFile.
+func FromFile(path, passphrase string) (Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("parse key file: %w", err)
+	}
+	if kf.Version != 1 || kf.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported key file version %d (%s)", kf.Version, kf.KDF)
+	}
+	aead, err := keyFileAEAD(passphrase, kf.Salt, kf.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(kf.Nonce) != aead.NonceSize() {
+		return nil, errors.New("key file nonce has the wrong size")
+	}
+	seed, err := aead.Open(nil, kf.Nonce, kf.Ciphertext, kf.PublicKey)
+	if err != nil {
+		return nil, errors.New("decrypt key file: wrong passphrase or corrupted file")
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("key file holds a malformed seed")
+	}
+	return FromPrivateKey(ed25519.NewKeyFromSeed(seed))
+}
+
+func keyFileAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 {
+		return nil, errors.New("key file iterations must be positive")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,67 @@
+package keys
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Keyring reads secrets from an OS credential store.
+type Keyring interface {
+	Get(service, account string) (string, error)
+}
+
+// SystemKeyring reads from the macOS keychain via security(1) or from the
+// freedesktop Secret Service via secret-tool(1) on Linux. Other platforms
+// return an error.
+type SystemKeyring struct{}
+
+// Get returns the secret stored for service and account.
+func (SystemKeyring) Get(service, account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", fmt.Errorf("no system keyring on %s", runtime.GOOS)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("keyring lookup %s/%s: %w: %s", service, account, err, msg)
+		}
+		return "", fmt.Errorf("keyring lookup %s/%s: %w", service, account, err)
+	}
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", fmt.Errorf("keyring has no secret for %s/%s", service, account)
+	}
+	return secret, nil
+}
+
+// FromKeyring loads a base64 key (see ParsePrivateKey) stored under service
+// and account. A nil kr uses SystemKeyring.
+func FromKeyring(kr Keyring, service, account string) (Signer, error) {
+	if service == "" || account == "" {
+		return nil, errors.New("keyring service and account are required")
+	}
+	if kr == nil {
+		kr = SystemKeyring{}
+	}
+	secret, err := kr.Get(service, account)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := ParsePrivateKey(secret)
+	if err != nil {
+		return nil, fmt.Errorf("keyring %s/%s: %w", service, account, err)
+	}
+	return FromPrivateKey(priv)
+}
@@ -0,0 +1,60 @@
+// Package keys provides Ed25519 signers backed by key storage that keeps
+// private keys out of application code: encrypted key files, the OS keychain,
+// and environment-injected keys.
+package keys
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Signer signs messages with an Ed25519 key it may never expose.
+type Signer interface {
+	Public() ed25519.PublicKey
+	Sign(message []byte) ([]byte, error)
+}
+
+type keySigner struct {
+	priv ed25519.PrivateKey
+}
+
+// FromPrivateKey wraps an in-memory private key, mainly for tests and for
+// providers that have already loaded the key.
+func FromPrivateKey(priv ed25519.PrivateKey) (Signer, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, errors.New("ed25519 private key has the wrong size")
+	}
+	return keySigner{priv: priv}, nil
+}
+
+func (s keySigner) Public() ed25519.PublicKey {
+	return s.priv.Public().(ed25519.PublicKey)
+}
+
+func (s keySigner) Sign(message []byte) ([]byte, error) {
+	return ed25519.Sign(s.priv, message), nil
+}
+
+// ParsePrivateKey decodes a base64 Ed25519 seed (32 bytes) or full private key
+// (64 bytes), the format used by every provider in this package.
+func ParsePrivateKey(encoded string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.New("private key must be base64")
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, errors.New("private key must be a 32-byte seed or 64-byte ed25519 key")
+	}
+}
+
+// EncodePrivateKey returns the base64 seed of priv, accepted by ParsePrivateKey.
+func EncodePrivateKey(priv ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(priv.Seed())
+}
@@ -0,0 +1,97 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func newKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return priv
+}
+
+func assertSignsWith(t *testing.T, signer Signer, priv ed25519.PrivateKey) {
+	t.Helper()
+	if !signer.Public().Equal(priv.Public()) {
+		t.Fatalf("signer exposes a different public key")
+	}
+	sig, err := signer.Sign([]byte("message"))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if !ed25519.Verify(signer.Public(), []byte("message"), sig) {
+		t.Fatalf("signature does not verify")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	priv := newKey(t)
+	t.Setenv("YANZI_TEST_KEY", EncodePrivateKey(priv))
+
+	signer, err := FromEnv("YANZI_TEST_KEY")
+	if err != nil {
+		t.Fatalf("from env: %v", err)
+	}
+	assertSignsWith(t, signer, priv)
+
+	if _, err := FromEnv("YANZI_TEST_KEY_MISSING"); err == nil {
+		t.Fatalf("expected missing variable to fail")
+	}
+	t.Setenv("YANZI_TEST_KEY", "not base64!")
+	if _, err := FromEnv("YANZI_TEST_KEY"); err == nil {
+		t.Fatalf("expected malformed key to fail")
+	}
+}
+
+func TestKeyFileRoundTrip(t *testing.T) {
+	priv := newKey(t)
+	path := filepath.Join(t.TempDir(), "signing.key")
+
+	if err := WriteKeyFile(path, priv, "correct horse"); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	if err := WriteKeyFile(path, priv, "correct horse"); err == nil {
+		t.Fatalf("expected existing key file not to be overwritten")
+	}
+
+	signer, err := FromFile(path, "correct horse")
+	if err != nil {
+		t.Fatalf("from file: %v", err)
+	}
+	assertSignsWith(t, signer, priv)
+
+	if _, err := FromFile(path, "wrong"); err == nil {
+		t.Fatalf("expected wrong passphrase to fail")
+	}
+}
+
+type fakeKeyring map[string]string
+
+func (f fakeKeyring) Get(service, account string) (string, error) {
+	secret, ok := f[service+"/"+account]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return secret, nil
+}
+
+func TestFromKeyring(t *testing.T) {
+	priv := newKey(t)
+	kr := fakeKeyring{"yanzi/alice": EncodePrivateKey(priv)}
+
+	signer, err := FromKeyring(kr, "yanzi", "alice")
+	if err != nil {
+		t.Fatalf("from keyring: %v", err)
+	}
+	assertSignsWith(t, signer, priv)
+
+	if _, err := FromKeyring(kr, "yanzi", "bob"); err == nil {
+		t.Fatalf("expected missing entry to fail")
+	}
+}
@@ -0,0 +1,198 @@
+// Package metrics exposes store and chain activity as Prometheus metrics.
+//
+// A Collector is both a store.Observer and a prometheus.Collector. Open the
+// store with it as observer, then register it:
+//
+//	m := metrics.New()
+//	s, err := store.Open(path, store.WithObserver(m))
+//	m.SetStore(s)
+//	prometheus.MustRegister(m)
+//
+// Chain verification results are reported with ObserveVerification.
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// DefaultNamespace prefixes every metric name unless replaced with
+// WithNamespace.
+const DefaultNamespace = "yanzi"
+
+// sizeTimeout bounds the database size query made on each scrape.
+const sizeTimeout = 5 * time.Second
+
+// Option configures a Collector.
+type Option func(*config)
+
+type config struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace sets the prefix of every metric name.
+func WithNamespace(ns string) Option {
+	return func(c *config) {
+		c.namespace = ns
+	}
+}
+
+// WithBuckets sets the upper bounds, in seconds, of the duration histograms.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		if len(buckets) > 0 {
+			c.buckets = buckets
+		}
+	}
+}
+
+// Collector records store operations, migrations, and chain verifications.
+type Collector struct {
+	operations        *prometheus.CounterVec
+	operationDuration *prometheus.HistogramVec
+	intents           *prometheus.CounterVec
+	migrations        *prometheus.CounterVec
+	migrationDuration prometheus.Histogram
+	verifications     *prometheus.CounterVec
+	issues            *prometheus.CounterVec
+	dbSize            *prometheus.Desc
+
+	mu    sync.RWMutex
+	store *store.Store
+}
+
+var (
+	_ store.Observer       = (*Collector)(nil)
+	_ prometheus.Collector = (*Collector)(nil)
+)
+
+// New returns a Collector with no store attached; the database size is
+// reported once SetStore is called.
+func New(opts ...Option) *Collector {
+	cfg := config{namespace: DefaultNamespace, buckets: prometheus.DefBuckets}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ns := cfg.namespace
+	return &Collector{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "store", Name: "operations_total",
+			Help: "Store calls by operation and result.",
+		}, []string{"operation", "result"}),
+		operationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: "store", Name: "operation_duration_seconds",
+			Help: "Duration of store calls by operation.", Buckets: cfg.buckets,
+		}, []string{"operation"}),
+		intents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "store", Name: "intents_total",
+			Help: "Intents written or returned by successful store calls.",
+		}, []string{"operation"}),
+		migrations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "store", Name: "migrations_total",
+			Help: "Migrations applied by result.",
+		}, []string{"result"}),
+		migrationDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: ns, Subsystem: "store", Name: "migration_duration_seconds",
+			Help: "Duration of each applied migration.", Buckets: cfg.buckets,
+		}),
+		verifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "chain", Name: "verifications_total",
+			Help: "Chain verifications by result.",
+		}, []string{"result"}),
+		issues: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns, Subsystem: "chain", Name: "verification_issues_total",
+			Help: "Issues found by chain verification, by kind.",
+		}, []string{"kind"}),
+		dbSize: prometheus.NewDesc(
+			prometheus.BuildFQName(ns, "store", "database_size_bytes"),
+			"Size of the database file, free pages included.", nil, nil),
+	}
+}
+
+// SetStore attaches the store whose database size is reported.
+func (c *Collector) SetStore(s *store.Store) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = s
+}
+
+// ObserveOperation implements store.Observer.
+func (c *Collector) ObserveOperation(op store.Operation, n int, d time.Duration, err error) {
+	c.operations.WithLabelValues(string(op), result(err)).Inc()
+	c.operationDuration.WithLabelValues(string(op)).Observe(d.Seconds())
+	if err == nil && n > 0 {
+		c.intents.WithLabelValues(string(op)).Add(float64(n))
+	}
+}
+
+// ObserveMigration implements store.Observer.
+func (c *Collector) ObserveMigration(_ string, d time.Duration, err error) {
+	c.migrations.WithLabelValues(result(err)).Inc()
+	c.migrationDuration.Observe(d.Seconds())
+}
+
+// ObserveVerification records the outcome of a chain verification. A report
+// with issues counts as a failure.
+func (c *Collector) ObserveVerification(r chain.Report) {
+	if r.Valid() {
+		c.verifications.WithLabelValues("valid").Inc()
+		return
+	}
+	c.verifications.WithLabelValues("invalid").Inc()
+	for _, issue := range r.Issues {
+		c.issues.WithLabelValues(string(issue.Kind)).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.operations.Describe(ch)
+	c.operationDuration.Describe(ch)
+	c.intents.Describe(ch)
+	c.migrations.Describe(ch)
+	c.migrationDuration.Describe(ch)
+	c.verifications.Describe(ch)
+	c.issues.Describe(ch)
+	ch <- c.dbSize
+}
+
+// Collect implements prometheus.Collector. The database size is read on each
+// scrape and reported as invalid if the query fails.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.operations.Collect(ch)
+	c.operationDuration.Collect(ch)
+	c.intents.Collect(ch)
+	c.migrations.Collect(ch)
+	c.migrationDuration.Collect(ch)
+	c.verifications.Collect(ch)
+	c.issues.Collect(ch)
+
+	c.mu.RLock()
+	s := c.store
+	c.mu.RUnlock()
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sizeTimeout)
+	defer cancel()
+	size, err := s.DatabaseSize(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.dbSize, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.dbSize, prometheus.GaugeValue, float64(size))
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func openStore(t *testing.T, m *Collector) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"), store.WithObserver(m))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	m.SetStore(s)
+	return s
+}
+
+func TestCollectorCountsStoreOperations(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	s := openStore(t, m)
+
+	if n := testutil.ToFloat64(m.migrations.WithLabelValues("ok")); n == 0 {
+		t.Fatal("expected migrations to be counted")
+	}
+	record, err := s.AppendIntent(ctx, model.IntentRecord{Author: "alice", SourceType: "cli", Prompt: "p", Response: "r"})
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	if _, err := s.GetIntent(ctx, record.ID); err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if _, err := s.GetIntent(ctx, "missing"); err == nil {
+		t.Fatal("expected error for a missing intent")
+	}
+
+	if n := testutil.ToFloat64(m.operations.WithLabelValues("create", "ok")); n != 1 {
+		t.Fatalf("expected 1 create, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.operations.WithLabelValues("read", "ok")); n != 1 {
+		t.Fatalf("expected 1 successful read, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.operations.WithLabelValues("read", "error")); n != 1 {
+		t.Fatalf("expected 1 failed read, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.intents.WithLabelValues("create")); n != 1 {
+		t.Fatalf("expected 1 intent created, got %v", n)
+	}
+}
+
+func TestCollectorReportsDatabaseSize(t *testing.T) {
+	m := New(WithNamespace("test"))
+	openStore(t, m)
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(m); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, f := range families {
+		if !strings.HasPrefix(f.GetName(), "test_") {
+			t.Fatalf("metric %s lacks the namespace", f.GetName())
+		}
+		if f.GetName() == "test_store_database_size_bytes" {
+			if size := f.GetMetric()[0].GetGauge().GetValue(); size <= 0 {
+				t.Fatalf("expected a positive size, got %v", size)
+			}
+			return
+		}
+	}
+	t.Fatal("database size not reported")
+}
+
+func TestObserveVerification(t *testing.T) {
+	m := New()
+	m.ObserveVerification(chain.Report{})
+	m.ObserveVerification(chain.Report{Issues: []chain.Issue{
+		{Kind: chain.IssueAlteredPayload},
+		{Kind: chain.IssueAlteredPayload},
+		{Kind: chain.IssueBrokenLink},
+	}})
+
+	if n := testutil.ToFloat64(m.verifications.WithLabelValues("valid")); n != 1 {
+		t.Fatalf("expected 1 valid verification, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.verifications.WithLabelValues("invalid")); n != 1 {
+		t.Fatalf("expected 1 invalid verification, got %v", n)
+	}
+	if n := testutil.ToFloat64(m.issues.WithLabelValues(string(chain.IssueAlteredPayload))); n != 2 {
+		t.Fatalf("expected 2 altered payloads, got %v", n)
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The store, chain, and hash packages return errors matching these sentinels
+// via errors.Is, so callers can classify failures without inspecting driver
+// errors or message text. The store and chain packages re-export them.
+var (
+	// ErrNotFound means no record exists under the requested key.
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicateID means a record with the same ID is already stored.
+	ErrDuplicateID = errors.New("duplicate intent id")
+	// ErrHashMismatch means a record's hash differs from the one recomputed
+	// from its content.
+	ErrHashMismatch = errors.New("hash mismatch")
+	// ErrChainBroken means a prev_hash chain failed verification.
+	ErrChainBroken = errors.New("chain broken")
+	// ErrInvalidRecord means a record failed validation.
+	ErrInvalidRecord = errors.New("invalid record")
+)
+
+// InvalidRecordError reports why a record failed validation. It matches
+// ErrInvalidRecord and Err via errors.Is.
+type InvalidRecordError struct {
+	Err error
+}
+
+func (e *InvalidRecordError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap lets errors.Is match ErrInvalidRecord and the underlying cause.
+func (e *InvalidRecordError) Unwrap() []error {
+	return []error{ErrInvalidRecord, e.Err}
+}
+
+// invalid wraps err as an *InvalidRecordError, leaving nil and errors that
+// already match ErrInvalidRecord alone.
+func invalid(err error) error {
+	if err == nil || errors.Is(err, ErrInvalidRecord) {
+		return err
+	}
+	return &InvalidRecordError{Err: err}
+}
+
+// HashMismatchError reports a stored hash that differs from the recomputed one.
+type HashMismatchError struct {
+	ID         string
+	Stored     string
+	Recomputed string
+}
+
+func (e *HashMismatchError) Error() string {
+	return fmt.Sprintf("hash mismatch for intent %s: stored %s, recomputed %s", e.ID, e.Stored, e.Recomputed)
+}
+
+// Unwrap lets errors.Is(err, ErrHashMismatch) match.
+func (e *HashMismatchError) Unwrap() error {
+	return ErrHashMismatch
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IDGenerator produces identifiers for new intent records.
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+// IDGeneratorFunc adapts a plain function to the IDGenerator interface.
+type IDGeneratorFunc func() (string, error)
+
+// NewID calls f.
+func (f IDGeneratorFunc) NewID() (string, error) {
+	return f()
+}
+
+var (
+	idGeneratorMu sync.RWMutex
+	idGenerator   IDGenerator = &ULIDGenerator{}
+)
+
+// SetIDGenerator replaces the generator used by NewID. Passing nil restores the ULID default.
+func SetIDGenerator(g IDGenerator) {
+	idGeneratorMu.Lock()
+	defer idGeneratorMu.Unlock()
+	if g == nil {
+		g = &ULIDGenerator{}
+	}
+	idGenerator = g
+}
+
+// NewID returns an identifier from the configured IDGenerator.
+func NewID() (string, error) {
+	idGeneratorMu.RLock()
+	g := idGenerator
+	idGeneratorMu.RUnlock()
+	return g.NewID()
+}
+
+// intentIDs backs NewIntentID.
+var intentIDs = &ULIDGenerator{}
+
+// NewIntentID returns a monotonic ULID from a process-wide generator, ignoring
+// SetIDGenerator. IDs from one process sort in the order they were made, even
+// within a millisecond; use it wherever records must sort by ID. The store
+// assigns every ID it generates with NewIntentID, so they meet the ULID rule
+// of ULIDHashVersion whatever generator NewID is set to.
+func NewIntentID() (string, error) {
+	return intentIDs.NewID()
+}
+
+// crockfordAlphabet is the Crockford base32 alphabet used by ULIDs.
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ErrInvalidULID is returned by ValidateULID and ULIDTime for strings that are
+// not canonical ULIDs.
+var ErrInvalidULID = errors.New("invalid ulid")
+
+// ULIDGenerator produces 26-character ULIDs: a 48-bit millisecond timestamp
+// followed by 80 bits of entropy. The zero value uses time.Now and crypto/rand.
+//
+// IDs are monotonic: one made in the same millisecond as the last, or after
+// the clock stepped back, reuses the last timestamp and increments the last
+// entropy, so each ID sorts after the previous one.
+type ULIDGenerator struct {
+	// Now returns the timestamp component; defaults to time.Now.
+	Now func() time.Time
+	// Entropy supplies the random component; defaults to crypto/rand.Reader.
+	Entropy io.Reader
+
+	mu     sync.Mutex
+	last   [16]byte
+	lastMS int64
+}
+
+// NewID returns a new ULID string.
+func (g *ULIDGenerator) NewID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now
+	if g.Now != nil {
+		now = g.Now
+	}
+	entropy := g.Entropy
+	if entropy == nil {
+		entropy = rand.Reader
+	}
+
+	ms := now().UnixMilli()
+	if ms < 0 || ms >= 1<<48 {
+		return "", errors.New("ulid timestamp out of range")
+	}
+
+	if g.lastMS > 0 && ms <= g.lastMS {
+		id := g.last
+		for i := len(id) - 1; ; i-- {
+			if i < 6 {
+				return "", errors.New("ulid entropy exhausted within one millisecond")
+			}
+			id[i]++
+			if id[i] != 0 {
+				break
+			}
+		}
+		g.last = id
+		return encodeULID(id), nil
+	}
+
+	var id [16]byte
+	for i, v := 5, ms; i >= 0; i-- {
+		id[i] = byte(v)
+		v >>= 8
+	}
+	if _, err := io.ReadFull(entropy, id[6:]); err != nil {
+		return "", err
+	}
+	g.last, g.lastMS = id, ms
+	return encodeULID(id), nil
+}
+
+// ValidateULID checks that id is a canonical ULID: 26 upper-case Crockford
+// base32 characters whose value fits in 128 bits.
+func ValidateULID(id string) error {
+	if len(id) != 26 {
+		return fmt.Errorf("%w %q: want 26 characters, got %d", ErrInvalidULID, id, len(id))
+	}
+	if id[0] > '7' {
+		return fmt.Errorf("%w %q: value overflows 128 bits", ErrInvalidULID, id)
+	}
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(crockfordAlphabet, id[i]) < 0 {
+			return fmt.Errorf("%w %q: character %q at %d is not upper-case Crockford base32", ErrInvalidULID, id, id[i], i)
+		}
+	}
+	return nil
+}
+
+// ULIDTime returns the timestamp encoded in a ULID.
+func ULIDTime(id string) (time.Time, error) {
+	if err := ValidateULID(id); err != nil {
+		return time.Time{}, err
+	}
+	var ms int64
+	for i := 0; i < 10; i++ {
+		ms = ms<<5 | int64(strings.IndexByte(crockfordAlphabet, id[i]))
+	}
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+// encodeULID renders 128 bits as 26 Crockford base32 characters.
+func encodeULID(id [16]byte) string {
+	var out [26]byte
+	// The leading character carries only the top 3 bits; each following
+	// character consumes the next 5 bits of the 128-bit value.
+	bit := -2
+	for i := range out {
+		var v byte
+		for j := 0; j < 5; j++ {
+			v <<= 1
+			if bit >= 0 && id[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+			bit++
+		}
+		out[i] = crockfordAlphabet[v]
+	}
+	return string(out[:])
+}
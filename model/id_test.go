@@ -0,0 +1,126 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetIDGeneratorUsesCustomGenerator(t *testing.T) {
+	t.Cleanup(func() { SetIDGenerator(nil) })
+
+	n := 0
+	SetIDGenerator(IDGeneratorFunc(func() (string, error) {
+		n++
+		return fmt.Sprintf("ext-%d", n), nil
+	}))
+
+	for _, want := range []string{"ext-1", "ext-2"} {
+		got, err := NewID()
+		if err != nil {
+			t.Fatalf("new id: %v", err)
+		}
+		if got != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestULIDGeneratorDeterministic(t *testing.T) {
+	g := &ULIDGenerator{
+		Now:     func() time.Time { return time.UnixMilli(1469918176385) },
+		Entropy: bytes.NewReader(make([]byte, 10)),
+	}
+
+	got, err := g.NewID()
+	if err != nil {
+		t.Fatalf("new id: %v", err)
+	}
+	if want := "01ARYZ6S41" + strings.Repeat("0", 16); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestULIDGeneratorMonotonic(t *testing.T) {
+	now := time.UnixMilli(1469918176385)
+	g := &ULIDGenerator{Now: func() time.Time { return now }}
+
+	var ids []string
+	for i := 0; i < 100; i++ {
+		if i == 50 {
+			// A clock stepping back must not reorder IDs.
+			now = now.Add(-time.Second)
+		}
+		id, err := g.NewID()
+		if err != nil {
+			t.Fatalf("new id: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("expected increasing ids, got %s after %s", ids[i], ids[i-1])
+		}
+	}
+	if got, _ := ULIDTime(ids[99]); !got.Equal(time.UnixMilli(1469918176385)) {
+		t.Fatalf("expected the last timestamp to be reused, got %v", got)
+	}
+
+	now = now.Add(time.Hour)
+	later, err := g.NewID()
+	if err != nil {
+		t.Fatalf("new id: %v", err)
+	}
+	if got, _ := ULIDTime(later); !got.Equal(now) {
+		t.Fatalf("expected a fresh timestamp %v, got %v", now, got)
+	}
+}
+
+func TestULIDGeneratorOverflow(t *testing.T) {
+	g := &ULIDGenerator{
+		Now:     func() time.Time { return time.UnixMilli(1469918176385) },
+		Entropy: bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)),
+	}
+	if _, err := g.NewID(); err != nil {
+		t.Fatalf("new id: %v", err)
+	}
+	if _, err := g.NewID(); err == nil {
+		t.Fatal("expected exhausted entropy to fail")
+	}
+}
+
+func TestNewIntentIDIgnoresCustomGenerator(t *testing.T) {
+	t.Cleanup(func() { SetIDGenerator(nil) })
+	SetIDGenerator(IDGeneratorFunc(func() (string, error) { return "custom", nil }))
+
+	id, err := NewIntentID()
+	if err != nil {
+		t.Fatalf("new intent id: %v", err)
+	}
+	if err := ValidateULID(id); err != nil {
+		t.Fatalf("expected a ULID, got %s: %v", id, err)
+	}
+}
+
+func TestValidateULID(t *testing.T) {
+	for _, id := range []string{"01ARYZ6S41TSV4RRFFQ69G5FAV", "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"} {
+		if err := ValidateULID(id); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+	for _, id := range []string{
+		"",
+		"intent-000001",
+		"01aryz6s41tsv4rrffq69g5fav", // lower case does not sort with upper case
+		"01ARYZ6S41TSV4RRFFQ69G5FAI", // I is not in the alphabet
+		"8ZZZZZZZZZZZZZZZZZZZZZZZZZ", // overflows 128 bits
+		"01ARYZ6S41TSV4RRFFQ69G5FA",
+	} {
+		if err := ValidateULID(id); !errors.Is(err, ErrInvalidULID) {
+			t.Errorf("%q: expected ErrInvalidULID, got %v", id, err)
+		}
+	}
+}
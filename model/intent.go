@@ -2,13 +2,20 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
-// IntentRecord represents the v0 intent schema persisted and shared across services.
+// IntentRecord represents the v1 intent schema persisted and shared across services.
 type IntentRecord struct {
 	ID         string          `json:"id"`
 	CreatedAt  string          `json:"created_at"`
@@ -20,10 +27,86 @@ type IntentRecord struct {
 	Meta       json.RawMessage `json:"meta,omitempty"`
 	PrevHash   string          `json:"prev_hash,omitempty"`
 	Hash       string          `json:"hash"`
+	// Tags are free-form labels; Normalize trims, lowercases, de-duplicates,
+	// and sorts them. Non-empty tags are part of the hash preimage.
+	Tags []string `json:"tags,omitempty"`
+	// ThreadID groups the turns of a multi-turn exchange and ParentID names the
+	// turn this one replies to. Both are independent of the prev_hash chain and,
+	// when set, part of the hash preimage.
+	ThreadID string `json:"thread_id,omitempty"`
+	ParentID string `json:"parent_id,omitempty"`
+	// Attachments reference content-addressed blobs holding large content such
+	// as uploaded files. Their digests, in order, are part of the hash preimage.
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// HashVersion selects the hash preimage encoding (see package hash). Zero
+	// means the original encoding.
+	HashVersion int `json:"hash_version,omitempty"`
+	// Signature is the base64 Ed25519 signature over Hash, made with the key
+	// whose base64 public half is PublicKey. Both are optional and excluded
+	// from the hash preimage; see package sign.
+	Signature string `json:"signature,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+	// TombstonedAt is set when retention removed the record's content (title,
+	// prompt, response, and meta). The hash and chain links are kept, so the
+	// chain still verifies, but the hash can no longer be recomputed. It is
+	// excluded from the hash preimage.
+	TombstonedAt string `json:"tombstoned_at,omitempty"`
+}
+
+// Tombstoned reports whether the record's content was removed by retention.
+func (r IntentRecord) Tombstoned() bool {
+	return r.TombstonedAt != ""
+}
+
+// CheckTombstone reports content left on a tombstoned record. Only the store
+// sets TombstonedAt, clearing the title, prompt, response, meta, and
+// attachments in the same write, so a tombstone that still carries any of
+// them was not made by the store and its content cannot be trusted. Records
+// that are not tombstoned always pass.
+func (r IntentRecord) CheckTombstone() error {
+	if !r.Tombstoned() {
+		return nil
+	}
+	switch {
+	case r.Title != "":
+		return errors.New("tombstoned record still has a title")
+	case r.Prompt != "":
+		return errors.New("tombstoned record still has a prompt")
+	case r.Response != "":
+		return errors.New("tombstoned record still has a response")
+	case !IsEmptyMeta(r.Meta):
+		return errors.New("tombstoned record still has meta")
+	case len(r.Attachments) > 0:
+		return errors.New("tombstoned record still has attachments")
+	}
+	return nil
+}
+
+// Attachment references a blob by digest ("sha256:<hex>", see hash.BlobDigest).
+type Attachment struct {
+	Digest    string `json:"digest"`
+	Name      string `json:"name,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Size      int64  `json:"size"`
 }
 
-// Validate checks required fields for the v0 schema.
+// BlobRefPrefix marks a prompt or response whose body is stored as a blob
+// rather than inline; the digest follows the prefix.
+const BlobRefPrefix = "yanzi-blob:"
+
+// BlobRef returns the inline placeholder for a body stored under digest.
+func BlobRef(digest string) string {
+	return BlobRefPrefix + digest
+}
+
+// Validate checks required fields for the v1 schema. From ULIDHashVersion on,
+// the ID and any parent ID must also be canonical ULIDs. Failures match
+// ErrInvalidRecord.
 func (r IntentRecord) Validate() error {
+	return invalid(r.validate())
+}
+
+func (r IntentRecord) validate() error {
 	if strings.TrimSpace(r.ID) == "" {
 		return errors.New("id is required")
 	}
@@ -39,30 +122,180 @@ func (r IntentRecord) Validate() error {
 	if len(r.SourceType) == 0 {
 		return errors.New("source_type is required")
 	}
-	if len(r.Prompt) == 0 {
+	if err := r.CheckTombstone(); err != nil {
+		return err
+	}
+	if len(r.Prompt) == 0 && !r.Tombstoned() {
 		return errors.New("prompt is required")
 	}
-	if len(r.Response) == 0 {
+	if len(r.Response) == 0 && !r.Tombstoned() {
 		return errors.New("response is required")
 	}
 	if len(r.Hash) == 0 {
 		return errors.New("hash is required")
 	}
+	for i, a := range r.Attachments {
+		if a.Digest == "" {
+			return fmt.Errorf("attachment %d: digest is required", i)
+		}
+		if a.Size < 0 {
+			return fmt.Errorf("attachment %d: size must not be negative", i)
+		}
+	}
+	if r.ParentID != "" && r.ParentID == r.ID {
+		return errors.New("parent_id must not reference the record itself")
+	}
+	if (r.Signature == "") != (r.PublicKey == "") {
+		return errors.New("signature and public_key must be set together")
+	}
+	if r.HashVersion >= ULIDHashVersion {
+		if err := ValidateULID(r.ID); err != nil {
+			return fmt.Errorf("id: %w", err)
+		}
+		if r.ParentID != "" {
+			if err := ValidateULID(r.ParentID); err != nil {
+				return fmt.Errorf("parent_id: %w", err)
+			}
+		}
+	}
+	return r.ValidateMetaSchema()
+}
+
+// ValidateStrict runs Validate and additionally rejects text fields containing
+// control characters other than newline and tab (for example NUL bytes, ANSI
+// escape sequences, or a carriage return that could overwrite a terminal
+// line). A carriage return is allowed only as part of a CRLF line ending.
+// Validate remains the permissive default.
+func (r IntentRecord) ValidateStrict() error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"author", r.Author},
+		{"source_type", r.SourceType},
+		{"title", r.Title},
+		{"prompt", r.Prompt},
+		{"response", r.Response},
+	}
+	for _, field := range fields {
+		if offset, c, ok := findControlChar(field.value); ok {
+			return invalid(fmt.Errorf("%s contains control character %U at byte offset %d", field.name, c, offset))
+		}
+	}
 	return nil
 }
 
+// findControlChar returns the byte offset of the first disallowed control character.
+func findControlChar(value string) (int, rune, bool) {
+	for i, c := range value {
+		if c == '\n' || c == '\t' || c == utf8.RuneError {
+			continue
+		}
+		if c == '\r' && strings.HasPrefix(value[i+1:], "\n") {
+			continue
+		}
+		if unicode.IsControl(c) {
+			return i, c, true
+		}
+	}
+	return 0, 0, false
+}
+
+// NFCHashVersion is the first hash_version whose Normalize also applies Unicode
+// NFC to text fields. Older versions keep their original bytes so existing
+// hashes stay valid.
+const NFCHashVersion = 3
+
+// ULIDHashVersion is the first hash_version whose records must have ULID IDs
+// (see ValidateULID), so they sort by creation time. Its preimage is that of
+// NFCHashVersion; older versions accept any ID.
+const ULIDHashVersion = 4
+
+// EmptyMetaHashVersion is the first hash_version whose Normalize treats an
+// empty meta object ({}) as absent meta. Its preimage is otherwise that of
+// ULIDHashVersion; older versions hash {} as written.
+const EmptyMetaHashVersion = 5
+
 // Normalize returns a copy with normalized fields for deterministic hashing/storage.
+// Meta is replaced by NormalizedMeta. From NFCHashVersion on, author,
+// source_type, title, prompt, and response are also NFC-normalized, so
+// composed and decomposed forms of the same text hash alike.
 func (r IntentRecord) Normalize() IntentRecord {
 	out := r
-	out.Author = normalizeNewlines(r.Author)
-	out.SourceType = normalizeNewlines(r.SourceType)
-	out.Title = normalizeNewlines(r.Title)
-	out.Prompt = normalizeNewlines(r.Prompt)
-	out.Response = normalizeNewlines(r.Response)
+	out.Meta = r.NormalizedMeta()
+	text := normalizeNewlines
+	if r.HashVersion >= NFCHashVersion {
+		text = func(value string) string {
+			return norm.NFC.String(normalizeNewlines(value))
+		}
+	}
+	out.Author = text(r.Author)
+	out.SourceType = text(r.SourceType)
+	out.Title = text(r.Title)
+	out.Prompt = text(r.Prompt)
+	out.Response = text(r.Response)
 	out.PrevHash = normalizeNewlines(r.PrevHash)
+	out.Tags = NormalizeTags(r.Tags, text)
+	return out
+}
+
+// NormalizeTags returns tags trimmed, lowercased, without empties or
+// duplicates, and sorted, applying text normalization first. It returns nil
+// when no tags remain.
+func NormalizeTags(tags []string, text func(string) string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if text != nil {
+			tag = text(tag)
+		}
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if _, dup := seen[tag]; dup {
+			continue
+		}
+		seen[tag] = struct{}{}
+		out = append(out, tag)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	sort.Strings(out)
 	return out
 }
 
+// NormalizedMeta returns r.Meta as it is hashed and stored: nil when it is
+// absent, or from EmptyMetaHashVersion on when it is an empty object, so a
+// producer sending {} records the same intent as one omitting meta.
+func (r IntentRecord) NormalizedMeta() json.RawMessage {
+	if len(r.Meta) == 0 || (r.HashVersion >= EmptyMetaHashVersion && IsEmptyMeta(r.Meta)) {
+		return nil
+	}
+	return r.Meta
+}
+
+// IsEmptyMeta reports whether raw carries no metadata: it is empty or an empty
+// JSON object. Whether hashing treats the two alike depends on the record's
+// hash_version; see NormalizedMeta.
+func IsEmptyMeta(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return true
+	}
+	if trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return false
+	}
+	return len(bytes.TrimSpace(trimmed[1:len(trimmed)-1])) == 0
+}
+
 func normalizeNewlines(value string) string {
 	if value == "" {
 		return value
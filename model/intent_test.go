@@ -0,0 +1,167 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func strictTestRecord() IntentRecord {
+	return IntentRecord{
+		ID:         "01HZYFQ7T9ZV54X2G4A8M4J2C1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "line1\r\n\tline2",
+		Response:   "resp",
+		Hash:       "abc123",
+	}
+}
+
+func TestValidateStrictAllowsWhitespace(t *testing.T) {
+	if err := strictTestRecord().ValidateStrict(); err != nil {
+		t.Fatalf("expected record to pass strict validation: %v", err)
+	}
+}
+
+func TestValidateStrictRejectsNUL(t *testing.T) {
+	record := strictTestRecord()
+	record.Response = "abc\x00def"
+
+	if err := record.Validate(); err != nil {
+		t.Fatalf("expected permissive validation to pass: %v", err)
+	}
+	err := record.ValidateStrict()
+	if err == nil {
+		t.Fatalf("expected NUL byte to be rejected")
+	}
+	if !strings.Contains(err.Error(), "response") || !strings.Contains(err.Error(), "offset 3") {
+		t.Fatalf("expected error naming field and offset, got %v", err)
+	}
+}
+
+func TestValidateStrictRejectsANSIEscape(t *testing.T) {
+	record := strictTestRecord()
+	record.Prompt = "ok \x1b[31mred"
+
+	err := record.ValidateStrict()
+	if err == nil {
+		t.Fatalf("expected escape sequence to be rejected")
+	}
+	if !strings.Contains(err.Error(), "prompt") || !strings.Contains(err.Error(), "offset 3") {
+		t.Fatalf("expected error naming field and offset, got %v", err)
+	}
+}
+
+func TestValidateStrictRejectsBareCarriageReturn(t *testing.T) {
+	record := strictTestRecord()
+	record.Title = "done\rfailed"
+
+	err := record.ValidateStrict()
+	if err == nil {
+		t.Fatalf("expected a bare carriage return to be rejected")
+	}
+	if !strings.Contains(err.Error(), "title") || !strings.Contains(err.Error(), "offset 4") {
+		t.Fatalf("expected error naming field and offset, got %v", err)
+	}
+}
+
+func TestNormalizeStripsEmptyMeta(t *testing.T) {
+	for _, raw := range []string{"", "{}", " { \n } "} {
+		record := strictTestRecord()
+		record.HashVersion = EmptyMetaHashVersion
+		record.Meta = []byte(raw)
+		if got := record.Normalize().Meta; got != nil {
+			t.Fatalf("expected %q to normalize to nil meta, got %q", raw, got)
+		}
+	}
+
+	legacy := strictTestRecord()
+	legacy.Meta = []byte("{}")
+	if got := string(legacy.Normalize().Meta); got != "{}" {
+		t.Fatalf("expected empty meta kept before hash_version %d, got %q", EmptyMetaHashVersion, got)
+	}
+
+	record := strictTestRecord()
+	record.Meta = []byte(`{"a":1}`)
+	if got := string(record.Normalize().Meta); got != `{"a":1}` {
+		t.Fatalf("expected populated meta preserved, got %q", got)
+	}
+}
+
+func TestNormalizeNFCBehindHashVersion(t *testing.T) {
+	cases := []struct {
+		name       string
+		composed   string
+		decomposed string
+	}{
+		{"latin acute", "caf\u00e9", "cafe\u0301"},
+		{"angstrom sign", "\u212Bngstr\u00f6m", "A\u030Angstro\u0308m"},
+		{"hangul", "\ud55c\uad6d", "\u1112\u1161\u11ab\u1100\u116e\u11a8"},
+		{"mixed", "r\u00e9sum\u00e9 and re\u0301sume\u0301", "re\u0301sume\u0301 and r\u00e9sum\u00e9"},
+		{"reordered marks", "\u1ec7", "e\u0302\u0323"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := strictTestRecord()
+			b := strictTestRecord()
+			a.Prompt, a.Title, a.Author = tc.composed, tc.composed, tc.composed
+			b.Prompt, b.Title, b.Author = tc.decomposed, tc.decomposed, tc.decomposed
+
+			if a.Normalize().Prompt == b.Normalize().Prompt && tc.composed != tc.decomposed {
+				t.Fatalf("expected legacy normalization to keep original bytes")
+			}
+
+			a.HashVersion, b.HashVersion = NFCHashVersion, NFCHashVersion
+			na, nb := a.Normalize(), b.Normalize()
+			if na.Prompt != nb.Prompt || na.Title != nb.Title || na.Author != nb.Author {
+				t.Fatalf("expected NFC to unify %q and %q, got %q and %q", tc.composed, tc.decomposed, na.Prompt, nb.Prompt)
+			}
+		})
+	}
+}
+
+func TestNormalizeNFCStillNormalizesNewlines(t *testing.T) {
+	record := strictTestRecord()
+	record.HashVersion = NFCHashVersion
+	record.Response = "cafe\u0301\r\nnext"
+	if got := record.Normalize().Response; got != "caf\u00e9\nnext" {
+		t.Fatalf("unexpected normalized response %q", got)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	record := strictTestRecord()
+	record.Tags = []string{" Review ", "bug", "", "review", "BUG", "alpha"}
+	got := record.Normalize().Tags
+	want := []string{"alpha", "bug", "review"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	record.Tags = []string{" ", ""}
+	if got := record.Normalize().Tags; got != nil {
+		t.Fatalf("expected blank tags to normalize to nil, got %v", got)
+	}
+}
+
+func TestValidateRequiresULIDBehindHashVersion(t *testing.T) {
+	record := strictTestRecord()
+	record.ID = "intent-1"
+	if err := record.Validate(); err != nil {
+		t.Fatalf("expected older hash versions to accept any id, got %v", err)
+	}
+
+	record.HashVersion = ULIDHashVersion
+	if err := record.Validate(); !errors.Is(err, ErrInvalidULID) {
+		t.Fatalf("expected ErrInvalidULID, got %v", err)
+	}
+	record.ID = strictTestRecord().ID
+	if err := record.Validate(); err != nil {
+		t.Fatalf("validate ulid record: %v", err)
+	}
+	record.ParentID = "turn-1"
+	if err := record.Validate(); !errors.Is(err, ErrInvalidULID) {
+		t.Fatalf("expected the parent id to be checked, got %v", err)
+	}
+}
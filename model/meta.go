@@ -0,0 +1,146 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Conventional meta keys understood by the typed accessors on Meta.
+const (
+	MetaKeyModel        = "model"
+	MetaKeyProvider     = "provider"
+	MetaKeyTemperature  = "temperature"
+	MetaKeyInputTokens  = "input_tokens"
+	MetaKeyOutputTokens = "output_tokens"
+	MetaKeyCostUSD      = "cost_usd"
+	MetaKeySessionID    = "session_id"
+	// MetaKeyDuplicateOf holds the ID of an earlier intent this one
+	// near-duplicates; see store.DuplicateDetection.
+	MetaKeyDuplicateOf = "duplicate_of"
+)
+
+// Meta is a decoded meta object. Values stay as raw JSON, so keys without a
+// typed accessor round-trip unchanged through ParseMeta and Raw. Use Meta{} or
+// ParseMeta to obtain a writable value.
+type Meta map[string]json.RawMessage
+
+// ParseMeta decodes raw meta. Absent or empty meta yields an empty Meta.
+func ParseMeta(raw json.RawMessage) (Meta, error) {
+	m := Meta{}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return m, nil
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("meta must be a JSON object: %w", err)
+	}
+	if m == nil {
+		return nil, errors.New("meta must be a JSON object")
+	}
+	return m, nil
+}
+
+// ParsedMeta decodes r.Meta; see ParseMeta.
+func (r IntentRecord) ParsedMeta() (Meta, error) {
+	return ParseMeta(r.Meta)
+}
+
+// Raw encodes m with sorted keys. An empty Meta encodes as nil (absent meta).
+func (m Meta) Raw() (json.RawMessage, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(map[string]json.RawMessage(m))
+}
+
+// Set stores value under key, encoding it as JSON.
+func (m Meta) Set(key string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode meta %s: %w", key, err)
+	}
+	m[key] = encoded
+	return nil
+}
+
+// Delete removes key.
+func (m Meta) Delete(key string) {
+	delete(m, key)
+}
+
+// String returns the string stored under key. ok is false when the key is
+// absent or not a string.
+func (m Meta) String(key string) (value string, ok bool) {
+	return decodeMeta[string](m, key)
+}
+
+// Float returns the number stored under key.
+func (m Meta) Float(key string) (value float64, ok bool) {
+	return decodeMeta[float64](m, key)
+}
+
+// Int returns the integer stored under key; non-integral numbers are not ok.
+func (m Meta) Int(key string) (value int64, ok bool) {
+	return decodeMeta[int64](m, key)
+}
+
+func decodeMeta[T any](m Meta, key string) (T, bool) {
+	var value T
+	raw, present := m[key]
+	if !present {
+		return value, false
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, false
+	}
+	return value, true
+}
+
+// Model returns the model name, e.g. "gpt-4o" or "claude-sonnet".
+func (m Meta) Model() (string, bool) { return m.String(MetaKeyModel) }
+
+// Provider returns the model provider, e.g. "openai" or "anthropic".
+func (m Meta) Provider() (string, bool) { return m.String(MetaKeyProvider) }
+
+// Temperature returns the sampling temperature.
+func (m Meta) Temperature() (float64, bool) { return m.Float(MetaKeyTemperature) }
+
+// InputTokens returns the prompt token count.
+func (m Meta) InputTokens() (int64, bool) { return m.Int(MetaKeyInputTokens) }
+
+// OutputTokens returns the response token count.
+func (m Meta) OutputTokens() (int64, bool) { return m.Int(MetaKeyOutputTokens) }
+
+// CostUSD returns the request cost in US dollars.
+func (m Meta) CostUSD() (float64, bool) { return m.Float(MetaKeyCostUSD) }
+
+// SessionID returns the client session identifier.
+func (m Meta) SessionID() (string, bool) { return m.String(MetaKeySessionID) }
+
+// DuplicateOf returns the ID of the intent this one near-duplicates.
+func (m Meta) DuplicateOf() (string, bool) { return m.String(MetaKeyDuplicateOf) }
+
+// SetModel sets the model name.
+func (m Meta) SetModel(name string) error { return m.Set(MetaKeyModel, name) }
+
+// SetProvider sets the model provider.
+func (m Meta) SetProvider(provider string) error { return m.Set(MetaKeyProvider, provider) }
+
+// SetTemperature sets the sampling temperature.
+func (m Meta) SetTemperature(t float64) error { return m.Set(MetaKeyTemperature, t) }
+
+// SetInputTokens sets the prompt token count.
+func (m Meta) SetInputTokens(n int64) error { return m.Set(MetaKeyInputTokens, n) }
+
+// SetOutputTokens sets the response token count.
+func (m Meta) SetOutputTokens(n int64) error { return m.Set(MetaKeyOutputTokens, n) }
+
+// SetCostUSD sets the request cost in US dollars.
+func (m Meta) SetCostUSD(cost float64) error { return m.Set(MetaKeyCostUSD, cost) }
+
+// SetSessionID sets the client session identifier.
+func (m Meta) SetSessionID(id string) error { return m.Set(MetaKeySessionID, id) }
+
+// SetDuplicateOf sets the ID of the intent this one near-duplicates.
+func (m Meta) SetDuplicateOf(id string) error { return m.Set(MetaKeyDuplicateOf, id) }
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MetaValidator checks the meta of records with a given source_type. A JSON
+// Schema library can be plugged in by wrapping it in MetaValidatorFunc.
+type MetaValidator interface {
+	ValidateMeta(meta json.RawMessage) error
+}
+
+// MetaValidatorFunc adapts a function to MetaValidator.
+type MetaValidatorFunc func(meta json.RawMessage) error
+
+// ValidateMeta calls f(meta).
+func (f MetaValidatorFunc) ValidateMeta(meta json.RawMessage) error {
+	return f(meta)
+}
+
+// MetaSchemaError reports meta rejected by the validator registered for its
+// source_type. It matches ErrInvalidRecord via errors.Is.
+type MetaSchemaError struct {
+	SourceType string
+	Err        error
+}
+
+func (e *MetaSchemaError) Error() string {
+	return fmt.Sprintf("meta does not match schema for source_type %q: %v", e.SourceType, e.Err)
+}
+
+func (e *MetaSchemaError) Unwrap() []error {
+	return []error{ErrInvalidRecord, e.Err}
+}
+
+var (
+	metaSchemasMu sync.RWMutex
+	metaSchemas   = make(map[string]MetaValidator)
+)
+
+// SetMetaSchema registers v for records with sourceType; Validate and the
+// store's insert path then reject meta that v rejects. A nil v removes the
+// registration. Absent meta is checked too, so a schema may require it.
+func SetMetaSchema(sourceType string, v MetaValidator) {
+	metaSchemasMu.Lock()
+	defer metaSchemasMu.Unlock()
+	if v == nil {
+		delete(metaSchemas, sourceType)
+		return
+	}
+	metaSchemas[sourceType] = v
+}
+
+// ValidateMetaSchema checks r.Meta against the schema registered for
+// r.SourceType, if any.
+func (r IntentRecord) ValidateMetaSchema() error {
+	metaSchemasMu.RLock()
+	v, ok := metaSchemas[r.SourceType]
+	metaSchemasMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	meta := r.Meta
+	if IsEmptyMeta(meta) {
+		meta = nil
+	}
+	if err := v.ValidateMeta(meta); err != nil {
+		return &MetaSchemaError{SourceType: r.SourceType, Err: err}
+	}
+	return nil
+}
+
+// StructSchema returns a MetaValidator that requires meta to decode into T
+// without unknown fields. Top-level fields tagged `meta:"required"` must be
+// present and non-zero.
+func StructSchema[T any]() MetaValidator {
+	return MetaValidatorFunc(func(meta json.RawMessage) error {
+		if len(meta) == 0 {
+			meta = json.RawMessage("{}")
+		}
+		var value T
+		dec := json.NewDecoder(bytes.NewReader(meta))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		return checkRequired(reflect.ValueOf(value))
+	})
+}
+
+func checkRequired(v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("meta") != "required" {
+			continue
+		}
+		if v.Field(i).IsZero() {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			return fmt.Errorf("%s is required", name)
+		}
+	}
+	return nil
+}
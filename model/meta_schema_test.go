@@ -0,0 +1,51 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type reviewMeta struct {
+	Ticket   string `json:"ticket" meta:"required"`
+	Priority int    `json:"priority"`
+}
+
+func TestMetaSchemaValidation(t *testing.T) {
+	SetMetaSchema("review", StructSchema[reviewMeta]())
+	t.Cleanup(func() { SetMetaSchema("review", nil) })
+
+	record := strictTestRecord()
+	record.SourceType = "review"
+
+	record.Meta = json.RawMessage(`{"ticket":"YZ-1","priority":2}`)
+	if err := record.Validate(); err != nil {
+		t.Fatalf("expected valid meta, got %v", err)
+	}
+
+	cases := map[string]string{
+		"missing required": `{"priority":2}`,
+		"unknown field":    `{"ticket":"YZ-1","owner":"bob"}`,
+		"wrong type":       `{"ticket":"YZ-1","priority":"high"}`,
+		"absent meta":      ``,
+	}
+	for name, raw := range cases {
+		record.Meta = json.RawMessage(raw)
+		err := record.Validate()
+		var schemaErr *MetaSchemaError
+		if !errors.As(err, &schemaErr) || schemaErr.SourceType != "review" {
+			t.Fatalf("%s: expected MetaSchemaError, got %v", name, err)
+		}
+	}
+
+	record.Meta = json.RawMessage(`{"priority":2}`)
+	if err := record.Validate(); !strings.Contains(err.Error(), "ticket is required") {
+		t.Fatalf("expected required field to be named, got %v", err)
+	}
+
+	record.SourceType = "cli"
+	if err := record.Validate(); err != nil {
+		t.Fatalf("expected other source types to be unconstrained, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestMetaTypedAccessors(t *testing.T) {
+	m, err := ParseMeta(json.RawMessage(`{"model":"claude-sonnet","provider":"anthropic","temperature":0.2,"input_tokens":120,"output_tokens":45,"cost_usd":0.0031,"session_id":"s-1","custom":{"nested":[1,2]}}`))
+	if err != nil {
+		t.Fatalf("parse meta: %v", err)
+	}
+
+	if v, ok := m.Model(); !ok || v != "claude-sonnet" {
+		t.Fatalf("model: %q %v", v, ok)
+	}
+	if v, ok := m.Provider(); !ok || v != "anthropic" {
+		t.Fatalf("provider: %q %v", v, ok)
+	}
+	if v, ok := m.Temperature(); !ok || v != 0.2 {
+		t.Fatalf("temperature: %v %v", v, ok)
+	}
+	if v, ok := m.InputTokens(); !ok || v != 120 {
+		t.Fatalf("input tokens: %v %v", v, ok)
+	}
+	if v, ok := m.OutputTokens(); !ok || v != 45 {
+		t.Fatalf("output tokens: %v %v", v, ok)
+	}
+	if v, ok := m.CostUSD(); !ok || v != 0.0031 {
+		t.Fatalf("cost: %v %v", v, ok)
+	}
+	if v, ok := m.SessionID(); !ok || v != "s-1" {
+		t.Fatalf("session id: %q %v", v, ok)
+	}
+	if _, ok := m.Int(MetaKeyModel); ok {
+		t.Fatalf("expected wrong-typed lookup to report !ok")
+	}
+	if _, ok := m.String("absent"); ok {
+		t.Fatalf("expected absent key to report !ok")
+	}
+}
+
+func TestMetaRoundTripPreservesUnknownKeys(t *testing.T) {
+	m, err := ParseMeta(json.RawMessage(`{"custom":{"b":1,"a":[true,null]},"model":"old"}`))
+	if err != nil {
+		t.Fatalf("parse meta: %v", err)
+	}
+	if err := m.SetModel("new"); err != nil {
+		t.Fatalf("set model: %v", err)
+	}
+	if err := m.SetInputTokens(7); err != nil {
+		t.Fatalf("set tokens: %v", err)
+	}
+	raw, err := m.Raw()
+	if err != nil {
+		t.Fatalf("encode meta: %v", err)
+	}
+	want := `{"custom":{"b":1,"a":[true,null]},"input_tokens":7,"model":"new"}`
+	if string(raw) != want {
+		t.Fatalf("expected %s, got %s", want, raw)
+	}
+
+	if err := m.SetTemperature(math.NaN()); err == nil {
+		t.Fatalf("expected NaN to be rejected")
+	}
+}
+
+func TestParseMetaEmptyAndInvalid(t *testing.T) {
+	m, err := strictTestRecord().ParsedMeta()
+	if err != nil || len(m) != 0 {
+		t.Fatalf("expected empty meta, got %v %v", m, err)
+	}
+	if raw, err := m.Raw(); err != nil || raw != nil {
+		t.Fatalf("expected empty meta to encode as absent, got %s %v", raw, err)
+	}
+	for _, raw := range []string{`[1]`, `null`, `"x"`} {
+		if _, err := ParseMeta(json.RawMessage(raw)); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
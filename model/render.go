@@ -0,0 +1,105 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const renderIndent = "  "
+
+// RenderText formats an intent as labeled plain text for CLI display and diff review.
+// Control characters other than newline and tab are escaped; the record is not modified.
+func RenderText(r IntentRecord) string {
+	var b strings.Builder
+
+	writeRenderField(&b, "ID", r.ID)
+	writeRenderField(&b, "Created At", r.CreatedAt)
+	writeRenderField(&b, "Author", r.Author)
+	writeRenderField(&b, "Source", r.SourceType)
+	writeRenderField(&b, "Title", r.Title)
+	writeRenderField(&b, "Prev Hash", r.PrevHash)
+	writeRenderField(&b, "Hash", r.Hash)
+
+	writeRenderSection(&b, "Prompt", escapeControl(r.Prompt))
+	writeRenderSection(&b, "Response", escapeControl(r.Response))
+	writeRenderSection(&b, "Meta", renderMeta(r.Meta))
+
+	return b.String()
+}
+
+func writeRenderField(b *strings.Builder, label, value string) {
+	if value == "" {
+		value = "(none)"
+	} else {
+		value = escapeControl(strings.ReplaceAll(value, "\n", `\n`))
+	}
+	fmt.Fprintf(b, "%-11s %s\n", label+":", value)
+}
+
+func writeRenderSection(b *strings.Builder, label, body string) {
+	b.WriteString("\n")
+	b.WriteString(label)
+	b.WriteString(":\n")
+	if body == "" {
+		b.WriteString(renderIndent)
+		b.WriteString("(none)\n")
+		return
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if line != "" {
+			b.WriteString(renderIndent)
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// renderMeta indents raw with its keys sorted at every level, the order
+// hash.CanonicalizeMeta hashes them in, so equal meta renders the same however
+// it was written. Control characters in the result are escaped.
+func renderMeta(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil || dec.More() {
+		return escapeControl(string(raw))
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", renderIndent)
+	if err := enc.Encode(value); err != nil {
+		return escapeControl(string(raw))
+	}
+	return escapeControl(strings.TrimSuffix(out.String(), "\n"))
+}
+
+// escapeControl replaces control characters (except newline and tab) and invalid
+// UTF-8 bytes with Go-style escapes so they cannot corrupt a terminal.
+func escapeControl(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, value[i])
+		case r == '\n' || r == '\t':
+			b.WriteRune(r)
+		case unicode.IsControl(r) && r < utf8.RuneSelf:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case unicode.IsControl(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteString(value[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestRenderTextGolden(t *testing.T) {
+	record := IntentRecord{
+		ID:         "01HZYFQ7T9ZV54X2G4A8M4J2C1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "line1\n\tindented\n\x1b[31mred\x1b[0m",
+		Response:   "resp\x00line2",
+		Meta:       json.RawMessage(`{"b":2,"a":{"z":1.50,"nested":true},"note":"csi \u009b31m"}`),
+		Hash:       "abc123",
+	}
+	original := record
+
+	got := RenderText(record)
+	if string(record.Meta) != string(original.Meta) || record.Prompt != original.Prompt {
+		t.Fatalf("RenderText modified the record")
+	}
+
+	golden := filepath.Join("testdata", "render_text.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatalf("write golden: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	if got != string(want) {
+		t.Fatalf("rendered text mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestRenderTextMetaKeyOrder(t *testing.T) {
+	a := IntentRecord{ID: "x", Meta: json.RawMessage(`{"b":2,"a":{"y":[1,{"d":4,"c":3}],"x":"<&>"}}`)}
+	b := IntentRecord{ID: "x", Meta: json.RawMessage(`{"a":{"x":"<&>","y":[1,{"c":3,"d":4}]},"b":2}`)}
+	if got, want := RenderText(a), RenderText(b); got != want {
+		t.Fatalf("equal meta rendered differently\n--- a ---\n%s\n--- b ---\n%s", got, want)
+	}
+}
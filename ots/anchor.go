@@ -0,0 +1,114 @@
+package ots
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// Backend is the store.Anchor backend name for OpenTimestamps anchors.
+const Backend = "opentimestamps"
+
+// upgradeBatch is how many pending anchors UpgradeAnchors loads at once.
+const upgradeBatch = 100
+
+// AnchorCheckpoint stamps cp's Merkle root and stores the pending anchor.
+func AnchorCheckpoint(ctx context.Context, s *store.Store, c *Client, cp store.Checkpoint) (store.Anchor, error) {
+	if cp.MerkleRoot == "" {
+		return store.Anchor{}, errors.New("checkpoint has no merkle root")
+	}
+	t, err := c.Stamp(ctx, Digest(cp.MerkleRoot))
+	if err != nil {
+		return store.Anchor{}, err
+	}
+	proof, err := MarshalFile(t)
+	if err != nil {
+		return store.Anchor{}, err
+	}
+	return s.SaveAnchor(ctx, store.Anchor{
+		MerkleRoot:    cp.MerkleRoot,
+		CheckpointSeq: cp.Seq,
+		Backend:       Backend,
+		Status:        store.AnchorPending,
+		Proof:         proof,
+	})
+}
+
+// UpgradeAnchors upgrades every pending OpenTimestamps anchor, storing
+// progress, and confirms those whose proofs now verify against src. It
+// returns how many were confirmed. Anchors that fail are left pending and
+// their errors joined.
+func UpgradeAnchors(ctx context.Context, s *store.Store, c *Client, src BlockSource) (int, error) {
+	pending, err := s.PendingAnchors(ctx, Backend, upgradeBatch)
+	if err != nil {
+		return 0, err
+	}
+	confirmed := 0
+	var errs []error
+	for _, a := range pending {
+		ok, err := upgradeAnchor(ctx, s, c, src, a)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("anchor %d: %w", a.ID, err))
+		}
+		if ok {
+			confirmed++
+		}
+	}
+	return confirmed, errors.Join(errs...)
+}
+
+func upgradeAnchor(ctx context.Context, s *store.Store, c *Client, src BlockSource, a store.Anchor) (bool, error) {
+	t, err := anchorTimestamp(a)
+	if err != nil {
+		return false, err
+	}
+	changed, upgradeErr := c.Upgrade(ctx, t)
+	if !changed {
+		return false, upgradeErr
+	}
+	if a.Proof, err = MarshalFile(t); err != nil {
+		return false, err
+	}
+	attested, err := Verify(ctx, t, src)
+	switch {
+	case err == nil:
+		a.Status = store.AnchorConfirmed
+		a.BlockHeight = int64(attested.Height)
+		a.AttestedAt = attested.Time.UTC().Format(time.RFC3339Nano)
+	case !errors.Is(err, ErrPending):
+		// Keep the upgraded proof for inspection but leave it pending.
+		upgradeErr = errors.Join(upgradeErr, err)
+	}
+	if err := s.UpdateAnchor(ctx, a); err != nil {
+		return false, err
+	}
+	return a.Status == store.AnchorConfirmed, upgradeErr
+}
+
+// VerifyAnchor checks that a's proof covers its Merkle root and verifies it
+// against src.
+func VerifyAnchor(ctx context.Context, a store.Anchor, src BlockSource) (Attested, error) {
+	t, err := anchorTimestamp(a)
+	if err != nil {
+		return Attested{}, err
+	}
+	return Verify(ctx, t, src)
+}
+
+func anchorTimestamp(a store.Anchor) (*Timestamp, error) {
+	if a.Backend != Backend {
+		return nil, fmt.Errorf("anchor backend is %q, not %q", a.Backend, Backend)
+	}
+	t, err := ParseFile(a.Proof)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(t.Msg, Digest(a.MerkleRoot)) {
+		return nil, fmt.Errorf("%w: proof is not over merkle root %s", ErrInvalidProof, a.MerkleRoot)
+	}
+	return t, nil
+}
@@ -0,0 +1,245 @@
+// Package ots anchors checkpoint Merkle roots in Bitcoin through
+// OpenTimestamps calendars, for deployments that want a public ledger behind
+// their integrity story. It is optional: nothing else in the module uses it.
+//
+// Anchoring is two-step. AnchorCheckpoint submits a root to the calendars and
+// stores the pending proof; hours later, once a calendar has committed to a
+// Bitcoin transaction, UpgradeAnchors completes the proof, checks it against
+// the block header, and marks the anchor confirmed:
+//
+//	client := ots.New()
+//	a, err := ots.AnchorCheckpoint(ctx, s, client, cp)
+//	// later, periodically
+//	n, err := ots.UpgradeAnchors(ctx, s, client, ots.NewEsplora(""))
+//
+// The proof covers the SHA-256 digest of the root string as stored, so
+// MarshalFile produces a .ots file that the reference `ots verify` client
+// accepts for a file containing exactly that string.
+package ots
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultCalendars are the public calendars stamped unless overridden with
+// WithCalendars.
+var DefaultCalendars = []string{
+	"https://a.pool.opentimestamps.org",
+	"https://b.pool.opentimestamps.org",
+	"https://a.pool.eternitywall.com",
+	"https://ots.btc.catallaxy.com",
+}
+
+// DefaultTimeout bounds each calendar request unless the client is replaced
+// with WithHTTPClient.
+const DefaultTimeout = 30 * time.Second
+
+// ErrPending is returned by Verify for proofs that do not yet reach Bitcoin.
+var ErrPending = errors.New("timestamp is pending")
+
+const (
+	acceptHeader     = "application/vnd.opentimestamps.v1"
+	maxResponseBytes = 64 << 10
+	nonceBytes       = 16
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithCalendars sets the calendars to stamp with. Upgrade only contacts
+// calendars in this list, whatever a proof names.
+func WithCalendars(urls ...string) Option {
+	return func(c *Client) {
+		c.calendars = nil
+		for _, u := range urls {
+			c.calendars = append(c.calendars, strings.TrimSuffix(u, "/"))
+		}
+	}
+}
+
+// WithHTTPClient sets the client used to reach calendars.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.http = hc
+		}
+	}
+}
+
+// WithMinCalendars sets how many calendars must accept a stamp; the default
+// is one.
+func WithMinCalendars(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.minCalendars = n
+		}
+	}
+}
+
+// Client talks to OpenTimestamps calendars.
+type Client struct {
+	calendars    []string
+	http         *http.Client
+	minCalendars int
+}
+
+// New returns a Client for DefaultCalendars.
+func New(opts ...Option) *Client {
+	c := &Client{
+		calendars:    slices.Clone(DefaultCalendars),
+		http:         &http.Client{Timeout: DefaultTimeout},
+		minCalendars: 1,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Digest returns the message stamped for root.
+func Digest(root string) []byte {
+	sum := sha256.Sum256([]byte(root))
+	return sum[:]
+}
+
+// Stamp submits digest to the calendars and returns a pending timestamp over
+// it. A random nonce is appended before submission so calendars learn nothing
+// about the digest.
+func (c *Client) Stamp(ctx context.Context, digest []byte) (*Timestamp, error) {
+	if len(c.calendars) == 0 {
+		return nil, errors.New("no calendars configured")
+	}
+	nonce := make([]byte, nonceBytes)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	root := &Timestamp{Msg: bytes.Clone(digest)}
+	nonced, err := root.add(Op{Tag: opAppend, Arg: nonce})
+	if err != nil {
+		return nil, err
+	}
+	commitment, err := nonced.add(Op{Tag: opSHA256})
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	accepted := 0
+	for _, cal := range c.calendars {
+		ts, err := c.submit(ctx, cal, commitment.Msg)
+		if err == nil {
+			err = commitment.Merge(ts)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", cal, err))
+			continue
+		}
+		accepted++
+	}
+	if accepted < c.minCalendars {
+		return nil, fmt.Errorf("stamp accepted by %d of %d calendars, need %d: %w",
+			accepted, len(c.calendars), c.minCalendars, errors.Join(errs...))
+	}
+	return root, nil
+}
+
+func (c *Client) submit(ctx context.Context, calendar string, msg []byte) (*Timestamp, error) {
+	body, ok, err := c.do(ctx, http.MethodPost, calendar+"/digest", msg)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("calendar refused the digest")
+	}
+	return Parse(body, msg)
+}
+
+// Upgrade asks the calendars behind t's pending attestations for completed
+// proofs and merges what they return. It reports whether t changed; calendars
+// that have not yet reached Bitcoin are not errors.
+func (c *Client) Upgrade(ctx context.Context, t *Timestamp) (bool, error) {
+	type pending struct {
+		node     *Timestamp
+		calendar string
+	}
+	var todo []pending
+	var collect func(*Timestamp)
+	collect = func(node *Timestamp) {
+		for _, a := range node.Attestations {
+			if uri, ok := a.Pending(); ok && !node.confirmed() {
+				todo = append(todo, pending{node: node, calendar: strings.TrimSuffix(uri, "/")})
+			}
+		}
+		for _, b := range node.Branches {
+			collect(b.Next)
+		}
+	}
+	collect(t)
+
+	changed := false
+	var errs []error
+	for _, p := range todo {
+		if !slices.Contains(c.calendars, p.calendar) {
+			continue
+		}
+		body, ok, err := c.do(ctx, http.MethodGet, p.calendar+"/timestamp/"+hex.EncodeToString(p.node.Msg), nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.calendar, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		upgraded, err := Parse(body, p.node.Msg)
+		if err == nil {
+			err = p.node.Merge(upgraded)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.calendar, err))
+			continue
+		}
+		changed = true
+	}
+	return changed, errors.Join(errs...)
+}
+
+// do sends a calendar request. It reports false, without error, for 404s,
+// which calendars use for "not yet".
+func (c *Client) do(ctx context.Context, method, url string, body []byte) ([]byte, bool, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", acceptHeader)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("calendar returned %s", resp.Status)
+	}
+	return data, true, nil
+}
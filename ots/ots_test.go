@@ -0,0 +1,327 @@
+package ots
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// testCalendar commits digests behind a pending attestation and, once ready,
+// completes them with a Bitcoin attestation at height.
+type testCalendar struct {
+	t      *testing.T
+	url    string
+	height uint64
+	blocks *testBlocks
+
+	mu    sync.Mutex
+	ready bool
+}
+
+func newTestCalendar(t *testing.T, blocks *testBlocks) *testCalendar {
+	t.Helper()
+	c := &testCalendar{t: t, height: 840000, blocks: blocks}
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+	c.url = srv.URL
+	return c
+}
+
+func (c *testCalendar) setReady() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ready = true
+}
+
+func (c *testCalendar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var ts *Timestamp
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/digest":
+		msg, _ := io.ReadAll(r.Body)
+		ts = &Timestamp{Msg: msg}
+		prepended, _ := ts.add(Op{Tag: opPrepend, Arg: []byte("cal1")})
+		commitment, _ := prepended.add(Op{Tag: opSHA256})
+		commitment.Attestations = []Attestation{pendingAttestation(c.url)}
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/timestamp/"):
+		c.mu.Lock()
+		ready := c.ready
+		c.mu.Unlock()
+		if !ready {
+			http.NotFound(w, r)
+			return
+		}
+		msg, err := hex.DecodeString(strings.TrimPrefix(r.URL.Path, "/timestamp/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ts = &Timestamp{Msg: msg}
+		appended, _ := ts.add(Op{Tag: opAppend, Arg: []byte("sibling")})
+		root, _ := appended.add(Op{Tag: opSHA256})
+		root.Attestations = []Attestation{bitcoinAttestation(c.height)}
+		c.blocks.set(c.height, root.Msg)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	body, err := ts.MarshalBinary()
+	if err != nil {
+		c.t.Errorf("marshal calendar timestamp: %v", err)
+	}
+	w.Write(body)
+}
+
+// testBlocks is a BlockSource of headers the calendar committed to.
+type testBlocks struct {
+	mu      sync.Mutex
+	headers map[uint64]BlockHeader
+}
+
+func (b *testBlocks) set(height uint64, root []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.headers == nil {
+		b.headers = make(map[uint64]BlockHeader)
+	}
+	if _, ok := b.headers[height]; !ok {
+		b.headers[height] = BlockHeader{MerkleRoot: root, Time: time.Date(2024, 4, 20, 0, 9, 27, 0, time.UTC)}
+	}
+}
+
+func (b *testBlocks) BlockHeader(_ context.Context, height uint64) (BlockHeader, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h, ok := b.headers[height]
+	if !ok {
+		return BlockHeader{}, fmt.Errorf("no block at height %d", height)
+	}
+	return h, nil
+}
+
+func openStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func writeCheckpoint(t *testing.T, s *store.Store) store.Checkpoint {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := s.AppendIntent(ctx, model.IntentRecord{Author: "alice", SourceType: "cli", Prompt: "p", Response: "r"}); err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	cp, err := s.WriteCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+	return cp
+}
+
+func TestParseWireFormat(t *testing.T) {
+	uri := "https://cal.test"
+	// append "abc", then a fork: sha256 to a pending attestation, and a
+	// pending attestation on the appended message itself.
+	data := []byte{0xf0, 0x03, 'a', 'b', 'c', 0xff, 0x08}
+	data = append(data, 0x00)
+	data = append(data, pendingTag[:]...)
+	data = append(data, byte(len(uri)+1), byte(len(uri)))
+	data = append(data, uri...)
+	data = append(data, 0x00)
+	data = append(data, pendingTag[:]...)
+	data = append(data, byte(len(uri)+1), byte(len(uri)))
+	data = append(data, uri...)
+
+	ts, err := Parse(data, []byte("m"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	appended := ts.Branches[0].Next
+	if string(appended.Msg) != "mabc" || len(appended.Branches) != 1 || len(appended.Attestations) != 1 {
+		t.Fatalf("unexpected tree %+v", appended)
+	}
+	if got, ok := appended.Branches[0].Next.Attestations[0].Pending(); !ok || got != uri {
+		t.Fatalf("expected pending %q, got %q", uri, got)
+	}
+	// Attestations serialize before branches, so the encoding differs from
+	// the input but decodes to the same tree.
+	again, err := ts.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	reparsed, err := Parse(again, []byte("m"))
+	if err != nil || !reflect.DeepEqual(reparsed, ts) {
+		t.Fatalf("round trip mismatch: %v", err)
+	}
+}
+
+func TestParseRejectsTruncatedAndTrailing(t *testing.T) {
+	good := []byte{0x08, 0x00}
+	good = append(good, bitcoinTag[:]...)
+	good = append(good, 0x01, 0x05)
+	if _, err := Parse(good, []byte("m")); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, err := Parse(good[:len(good)-1], []byte("m")); err == nil {
+		t.Fatal("expected error for truncated proof")
+	}
+	if _, err := Parse(append(bytes.Clone(good), 0x00), []byte("m")); err == nil {
+		t.Fatal("expected error for trailing data")
+	}
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	ts := &Timestamp{Msg: Digest("root")}
+	next, _ := ts.add(Op{Tag: opSHA256})
+	next.Attestations = []Attestation{bitcoinAttestation(1)}
+	data, err := MarshalFile(ts)
+	if err != nil {
+		t.Fatalf("marshal file: %v", err)
+	}
+	parsed, err := ParseFile(data)
+	if err != nil || !reflect.DeepEqual(parsed, ts) {
+		t.Fatalf("round trip mismatch: %v", err)
+	}
+	if _, err := ParseFile(data[1:]); err == nil {
+		t.Fatal("expected error without magic")
+	}
+}
+
+func TestAnchorCheckpointLifecycle(t *testing.T) {
+	ctx := context.Background()
+	blocks := &testBlocks{}
+	cal := newTestCalendar(t, blocks)
+	client := New(WithCalendars(cal.url))
+	s := openStore(t)
+	cp := writeCheckpoint(t, s)
+
+	a, err := AnchorCheckpoint(ctx, s, client, cp)
+	if err != nil {
+		t.Fatalf("anchor: %v", err)
+	}
+	if a.Status != store.AnchorPending || a.CheckpointSeq != cp.Seq || a.MerkleRoot != cp.MerkleRoot {
+		t.Fatalf("unexpected anchor %+v", a)
+	}
+	if _, err := VerifyAnchor(ctx, a, blocks); !errors.Is(err, ErrPending) {
+		t.Fatalf("expected ErrPending, got %v", err)
+	}
+
+	// Not ready: nothing changes and nothing fails.
+	if n, err := UpgradeAnchors(ctx, s, client, blocks); err != nil || n != 0 {
+		t.Fatalf("expected no confirmations, got %d (%v)", n, err)
+	}
+
+	cal.setReady()
+	if n, err := UpgradeAnchors(ctx, s, client, blocks); err != nil || n != 1 {
+		t.Fatalf("expected one confirmation, got %d (%v)", n, err)
+	}
+	anchors, err := s.Anchors(ctx, cp.MerkleRoot)
+	if err != nil || len(anchors) != 1 {
+		t.Fatalf("expected one anchor, got %d (%v)", len(anchors), err)
+	}
+	got := anchors[0]
+	if got.Status != store.AnchorConfirmed || got.BlockHeight != 840000 || got.AttestedAt != "2024-04-20T00:09:27Z" {
+		t.Fatalf("unexpected confirmed anchor %+v", got)
+	}
+	attested, err := VerifyAnchor(ctx, got, blocks)
+	if err != nil || attested.Height != 840000 {
+		t.Fatalf("verify: %+v (%v)", attested, err)
+	}
+	if pending, _ := s.PendingAnchors(ctx, Backend, 10); len(pending) != 0 {
+		t.Fatalf("expected no pending anchors, got %d", len(pending))
+	}
+}
+
+func TestVerifyRejectsMismatchedBlock(t *testing.T) {
+	ctx := context.Background()
+	blocks := &testBlocks{}
+	cal := newTestCalendar(t, blocks)
+	client := New(WithCalendars(cal.url))
+	ts, err := client.Stamp(ctx, Digest("root"))
+	if err != nil {
+		t.Fatalf("stamp: %v", err)
+	}
+	cal.setReady()
+	if changed, err := client.Upgrade(ctx, ts); err != nil || !changed {
+		t.Fatalf("upgrade: %v (changed %v)", err, changed)
+	}
+	blocks.headers[cal.height] = BlockHeader{MerkleRoot: make([]byte, 32)}
+	if _, err := Verify(ctx, ts, blocks); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("expected ErrInvalidProof, got %v", err)
+	}
+}
+
+func TestUpgradeOnlyContactsConfiguredCalendars(t *testing.T) {
+	ctx := context.Background()
+	cal := newTestCalendar(t, &testBlocks{})
+	ts, err := New(WithCalendars(cal.url)).Stamp(ctx, Digest("root"))
+	if err != nil {
+		t.Fatalf("stamp: %v", err)
+	}
+	cal.setReady()
+	changed, err := New(WithCalendars("https://other.test")).Upgrade(ctx, ts)
+	if err != nil || changed {
+		t.Fatalf("expected no upgrade, got changed=%v (%v)", changed, err)
+	}
+}
+
+func TestStampRequiresMinCalendars(t *testing.T) {
+	cal := newTestCalendar(t, &testBlocks{})
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(down.Close)
+
+	client := New(WithCalendars(cal.url, down.URL), WithMinCalendars(2))
+	if _, err := client.Stamp(context.Background(), Digest("root")); err == nil {
+		t.Fatal("expected error when too few calendars accept")
+	}
+	if _, err := New(WithCalendars(cal.url, down.URL)).Stamp(context.Background(), Digest("root")); err != nil {
+		t.Fatalf("expected one calendar to suffice, got %v", err)
+	}
+}
+
+func TestEsploraBlockHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/block-height/5":
+			io.WriteString(w, "00aa\n")
+		case "/block/00aa":
+			io.WriteString(w, `{"merkle_root":"0102","timestamp":1700000000}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	header, err := NewEsplora(srv.URL).BlockHeader(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("block header: %v", err)
+	}
+	if !bytes.Equal(header.MerkleRoot, []byte{0x02, 0x01}) || header.Time.Unix() != 1700000000 {
+		t.Fatalf("unexpected header %+v", header)
+	}
+	if _, err := NewEsplora(srv.URL).BlockHeader(context.Background(), 6); err == nil {
+		t.Fatal("expected error for missing block")
+	}
+}
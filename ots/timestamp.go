@@ -0,0 +1,402 @@
+package ots
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Operation tags.
+const (
+	opSHA1    = 0x02
+	opSHA256  = 0x08
+	opAppend  = 0xf0
+	opPrepend = 0xf1
+	opReverse = 0xf2
+	opHexlify = 0xf3
+)
+
+const (
+	tagAttestation = 0x00
+	tagFork        = 0xff
+)
+
+// Attestation tags.
+var (
+	pendingTag = [8]byte{0x83, 0xdf, 0xe3, 0x0d, 0x2e, 0xf9, 0x0c, 0x8e}
+	bitcoinTag = [8]byte{0x05, 0x88, 0x96, 0x0d, 0x73, 0xd7, 0x19, 0x01}
+)
+
+// Limits from the reference implementation; proofs beyond them are rejected
+// rather than parsed.
+const (
+	maxDepth        = 256
+	maxMessageBytes = 4096
+	maxArgBytes     = 4096
+	maxPayloadBytes = 8192
+	maxURIBytes     = 1000
+)
+
+// Op is one operation on a message.
+type Op struct {
+	Tag byte
+	// Arg is the operand of append and prepend.
+	Arg []byte
+}
+
+// Apply returns the result of op on msg.
+func (op Op) Apply(msg []byte) ([]byte, error) {
+	var out []byte
+	switch op.Tag {
+	case opSHA256:
+		sum := sha256.Sum256(msg)
+		out = sum[:]
+	case opSHA1:
+		sum := sha1.Sum(msg)
+		out = sum[:]
+	case opAppend:
+		out = append(bytes.Clone(msg), op.Arg...)
+	case opPrepend:
+		out = append(bytes.Clone(op.Arg), msg...)
+	case opReverse:
+		out = bytes.Clone(msg)
+		slices.Reverse(out)
+	case opHexlify:
+		out = []byte(hex.EncodeToString(msg))
+	default:
+		return nil, fmt.Errorf("unsupported operation 0x%02x", op.Tag)
+	}
+	if len(out) > maxMessageBytes {
+		return nil, errors.New("operation result too long")
+	}
+	return out, nil
+}
+
+func (op Op) equal(other Op) bool {
+	return op.Tag == other.Tag && bytes.Equal(op.Arg, other.Arg)
+}
+
+// Attestation is a claim that a message existed at some time. Only pending
+// (calendar) and Bitcoin attestations are interpreted; others are kept so
+// proofs round-trip.
+type Attestation struct {
+	Tag     [8]byte
+	Payload []byte
+}
+
+// Pending reports the calendar URI of a pending attestation.
+func (a Attestation) Pending() (string, bool) {
+	if a.Tag != pendingTag {
+		return "", false
+	}
+	r := bufio.NewReader(bytes.NewReader(a.Payload))
+	uri, err := readVarBytes(r, maxURIBytes)
+	if err != nil {
+		return "", false
+	}
+	return string(uri), true
+}
+
+// Bitcoin reports the block height of a Bitcoin attestation.
+func (a Attestation) Bitcoin() (uint64, bool) {
+	if a.Tag != bitcoinTag {
+		return 0, false
+	}
+	height, err := readVarUint(bufio.NewReader(bytes.NewReader(a.Payload)))
+	if err != nil {
+		return 0, false
+	}
+	return height, true
+}
+
+func pendingAttestation(uri string) Attestation {
+	var b bytes.Buffer
+	writeVarBytes(&b, []byte(uri))
+	return Attestation{Tag: pendingTag, Payload: b.Bytes()}
+}
+
+func bitcoinAttestation(height uint64) Attestation {
+	var b bytes.Buffer
+	writeVarUint(&b, height)
+	return Attestation{Tag: bitcoinTag, Payload: b.Bytes()}
+}
+
+// Branch is an operation and the timestamp of its result.
+type Branch struct {
+	Op   Op
+	Next *Timestamp
+}
+
+// Timestamp is a tree of operations from Msg to attested messages.
+type Timestamp struct {
+	Msg          []byte
+	Attestations []Attestation
+	Branches     []Branch
+}
+
+// add returns the timestamp of op's result, adding the branch if absent.
+func (t *Timestamp) add(op Op) (*Timestamp, error) {
+	for _, b := range t.Branches {
+		if b.Op.equal(op) {
+			return b.Next, nil
+		}
+	}
+	msg, err := op.Apply(t.Msg)
+	if err != nil {
+		return nil, err
+	}
+	next := &Timestamp{Msg: msg}
+	t.Branches = append(t.Branches, Branch{Op: op, Next: next})
+	return next, nil
+}
+
+// Merge adds other's attestations and branches to t. Both must be over the
+// same message.
+func (t *Timestamp) Merge(other *Timestamp) error {
+	if !bytes.Equal(t.Msg, other.Msg) {
+		return errors.New("cannot merge timestamps over different messages")
+	}
+	for _, a := range other.Attestations {
+		if !slices.ContainsFunc(t.Attestations, func(b Attestation) bool {
+			return a.Tag == b.Tag && bytes.Equal(a.Payload, b.Payload)
+		}) {
+			t.Attestations = append(t.Attestations, a)
+		}
+	}
+	for _, b := range other.Branches {
+		next, err := t.add(b.Op)
+		if err != nil {
+			return err
+		}
+		if err := next.Merge(b.Next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk calls fn with every message that carries an attestation.
+func (t *Timestamp) Walk(fn func(msg []byte, a Attestation)) {
+	for _, a := range t.Attestations {
+		fn(t.Msg, a)
+	}
+	for _, b := range t.Branches {
+		b.Next.Walk(fn)
+	}
+}
+
+// confirmed reports whether any path from t reaches a Bitcoin attestation.
+func (t *Timestamp) confirmed() bool {
+	found := false
+	t.Walk(func(_ []byte, a Attestation) {
+		if _, ok := a.Bitcoin(); ok {
+			found = true
+		}
+	})
+	return found
+}
+
+// MarshalBinary serializes t without a header, as calendars send it.
+func (t *Timestamp) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.write(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (t *Timestamp) write(b *bytes.Buffer) error {
+	n := len(t.Attestations) + len(t.Branches)
+	if n == 0 {
+		return errors.New("timestamp has no attestations or operations")
+	}
+	i := 0
+	fork := func() {
+		if i < n-1 {
+			b.WriteByte(tagFork)
+		}
+		i++
+	}
+	for _, a := range t.Attestations {
+		fork()
+		b.WriteByte(tagAttestation)
+		b.Write(a.Tag[:])
+		writeVarBytes(b, a.Payload)
+	}
+	for _, br := range t.Branches {
+		fork()
+		b.WriteByte(br.Op.Tag)
+		if br.Op.Tag == opAppend || br.Op.Tag == opPrepend {
+			writeVarBytes(b, br.Op.Arg)
+		}
+		if err := br.Next.write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse decodes a headerless timestamp over msg, as calendars return it.
+func Parse(data, msg []byte) (*Timestamp, error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	t, err := readTimestamp(r, bytes.Clone(msg), 0)
+	if err != nil {
+		return nil, fmt.Errorf("decode timestamp: %w", err)
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		return nil, errors.New("decode timestamp: trailing data")
+	}
+	return t, nil
+}
+
+func readTimestamp(r *bufio.Reader, msg []byte, depth int) (*Timestamp, error) {
+	if depth > maxDepth {
+		return nil, errors.New("timestamp nested too deeply")
+	}
+	t := &Timestamp{Msg: msg}
+	for {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		fork := tag == tagFork
+		if fork {
+			if tag, err = r.ReadByte(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+		if err := t.readItem(r, tag, depth); err != nil {
+			return nil, err
+		}
+		if !fork {
+			return t, nil
+		}
+	}
+}
+
+func (t *Timestamp) readItem(r *bufio.Reader, tag byte, depth int) error {
+	if tag == tagAttestation {
+		var a Attestation
+		if _, err := io.ReadFull(r, a.Tag[:]); err != nil {
+			return unexpectedEOF(err)
+		}
+		payload, err := readVarBytes(r, maxPayloadBytes)
+		if err != nil {
+			return err
+		}
+		a.Payload = payload
+		t.Attestations = append(t.Attestations, a)
+		return nil
+	}
+	op := Op{Tag: tag}
+	if tag == opAppend || tag == opPrepend {
+		arg, err := readVarBytes(r, maxArgBytes)
+		if err != nil {
+			return err
+		}
+		op.Arg = arg
+	}
+	msg, err := op.Apply(t.Msg)
+	if err != nil {
+		return err
+	}
+	next, err := readTimestamp(r, msg, depth+1)
+	if err != nil {
+		return err
+	}
+	t.Branches = append(t.Branches, Branch{Op: op, Next: next})
+	return nil
+}
+
+// fileMagic opens a detached timestamp (.ots) file.
+var fileMagic = []byte("\x00OpenTimestamps\x00\x00Proof\x00\xbf\x89\xe2\xe8\x84\xe8\x92\x94")
+
+const fileVersion = 1
+
+// MarshalFile serializes t as a detached .ots file for a file whose SHA-256
+// digest is t.Msg, readable by the reference `ots` client.
+func MarshalFile(t *Timestamp) ([]byte, error) {
+	if len(t.Msg) != sha256.Size {
+		return nil, errors.New("detached timestamps must be over a SHA-256 digest")
+	}
+	var b bytes.Buffer
+	b.Write(fileMagic)
+	writeVarUint(&b, fileVersion)
+	b.WriteByte(opSHA256)
+	b.Write(t.Msg)
+	if err := t.write(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ParseFile decodes a detached .ots file over a SHA-256 file digest.
+func ParseFile(data []byte) (*Timestamp, error) {
+	rest, ok := bytes.CutPrefix(data, fileMagic)
+	if !ok {
+		return nil, errors.New("not an OpenTimestamps proof")
+	}
+	r := bufio.NewReader(bytes.NewReader(rest))
+	version, err := readVarUint(r)
+	if err != nil {
+		return nil, err
+	}
+	if version != fileVersion {
+		return nil, fmt.Errorf("unsupported proof version %d", version)
+	}
+	if op, err := r.ReadByte(); err != nil || op != opSHA256 {
+		return nil, errors.New("only SHA-256 file digests are supported")
+	}
+	digest := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r, digest); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	tail, _ := io.ReadAll(r)
+	return Parse(tail, digest)
+}
+
+func writeVarUint(b *bytes.Buffer, v uint64) {
+	b.Write(binary.AppendUvarint(nil, v))
+}
+
+func readVarUint(r io.ByteReader) (uint64, error) {
+	v, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return v, nil
+}
+
+func writeVarBytes(b *bytes.Buffer, p []byte) {
+	writeVarUint(b, uint64(len(p)))
+	b.Write(p)
+}
+
+func readVarBytes(r *bufio.Reader, max int) ([]byte, error) {
+	n, err := readVarUint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(max) {
+		return nil, fmt.Errorf("field of %d bytes exceeds %d", n, max)
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(r, p); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return p, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package ots
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEsploraURL is the Esplora API used by NewEsplora("").
+const DefaultEsploraURL = "https://blockstream.info/api"
+
+// ErrInvalidProof is wrapped by Verify errors that mean the proof does not
+// match the Bitcoin chain.
+var ErrInvalidProof = errors.New("invalid timestamp proof")
+
+// BlockHeader is what verification needs from a Bitcoin block header.
+type BlockHeader struct {
+	// MerkleRoot is in internal byte order, as hashed in the header, not the
+	// reversed order block explorers display.
+	MerkleRoot []byte
+	Time       time.Time
+}
+
+// BlockSource looks up Bitcoin block headers by height. Back it with a
+// trusted node or explorer: verification is only as good as its answers.
+type BlockSource interface {
+	BlockHeader(ctx context.Context, height uint64) (BlockHeader, error)
+}
+
+// Attested is a verified Bitcoin attestation.
+type Attested struct {
+	Height uint64
+	Time   time.Time
+}
+
+// Verify checks every Bitcoin attestation in t against src and returns the
+// earliest, which bounds when t.Msg existed. It returns ErrPending if t has
+// no Bitcoin attestation yet.
+func Verify(ctx context.Context, t *Timestamp, src BlockSource) (Attested, error) {
+	type claim struct {
+		msg    []byte
+		height uint64
+	}
+	var claims []claim
+	t.Walk(func(msg []byte, a Attestation) {
+		if height, ok := a.Bitcoin(); ok {
+			claims = append(claims, claim{msg: msg, height: height})
+		}
+	})
+	if len(claims) == 0 {
+		return Attested{}, ErrPending
+	}
+
+	var earliest Attested
+	for _, c := range claims {
+		header, err := src.BlockHeader(ctx, c.height)
+		if err != nil {
+			return Attested{}, fmt.Errorf("block %d: %w", c.height, err)
+		}
+		if !bytes.Equal(c.msg, header.MerkleRoot) {
+			return Attested{}, fmt.Errorf("%w: block %d merkle root does not match", ErrInvalidProof, c.height)
+		}
+		if earliest.Time.IsZero() || header.Time.Before(earliest.Time) {
+			earliest = Attested{Height: c.height, Time: header.Time}
+		}
+	}
+	return earliest, nil
+}
+
+// Esplora is a BlockSource backed by an Esplora HTTP API, such as
+// blockstream.info or a self-hosted instance.
+type Esplora struct {
+	base string
+	http *http.Client
+}
+
+// NewEsplora returns an Esplora source for the API at base, or
+// DefaultEsploraURL if base is empty.
+func NewEsplora(base string) *Esplora {
+	if base == "" {
+		base = DefaultEsploraURL
+	}
+	return &Esplora{base: strings.TrimSuffix(base, "/"), http: &http.Client{Timeout: DefaultTimeout}}
+}
+
+// BlockHeader implements BlockSource.
+func (e *Esplora) BlockHeader(ctx context.Context, height uint64) (BlockHeader, error) {
+	hash, err := e.get(ctx, "/block-height/"+strconv.FormatUint(height, 10))
+	if err != nil {
+		return BlockHeader{}, err
+	}
+	body, err := e.get(ctx, "/block/"+strings.TrimSpace(string(hash)))
+	if err != nil {
+		return BlockHeader{}, err
+	}
+	var block struct {
+		MerkleRoot string `json:"merkle_root"`
+		Timestamp  int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &block); err != nil {
+		return BlockHeader{}, fmt.Errorf("decode block: %w", err)
+	}
+	root, err := hex.DecodeString(block.MerkleRoot)
+	if err != nil {
+		return BlockHeader{}, fmt.Errorf("decode merkle root: %w", err)
+	}
+	slices.Reverse(root)
+	return BlockHeader{MerkleRoot: root, Time: time.Unix(block.Timestamp, 0).UTC()}, nil
+}
+
+func (e *Esplora) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("esplora returned %s for %s", resp.Status, path)
+	}
+	return body, nil
+}
@@ -0,0 +1,44 @@
+// Package kafkapub publishes intents to Kafka.
+package kafkapub
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/chuxorg/chux-yanzi-core/publish"
+)
+
+// Writer is the part of *kafka.Writer the publisher uses. The writer must not
+// set a Topic of its own, since each message names its topic.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Publisher writes each message to the Kafka topic it names, keyed by author
+// so a chain stays in one partition. The message ID is sent as the
+// Yanzi-Hash header; Kafka itself does not deduplicate.
+type Publisher struct {
+	w Writer
+}
+
+var _ publish.Publisher = (*Publisher)(nil)
+
+// New returns a Publisher backed by w.
+func New(w Writer) *Publisher {
+	return &Publisher{w: w}
+}
+
+// Publish implements publish.Publisher.
+func (p *Publisher) Publish(ctx context.Context, msg publish.Message) error {
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers = append(headers, kafka.Header{Key: h.Key, Value: []byte(h.Value)})
+	}
+	return p.w.WriteMessages(ctx, kafka.Message{
+		Topic:   msg.Topic,
+		Key:     []byte(msg.Key),
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
@@ -0,0 +1,42 @@
+package kafkapub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/chuxorg/chux-yanzi-core/publish"
+)
+
+type fakeWriter struct {
+	msgs []kafka.Message
+}
+
+func (f *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	f.msgs = append(f.msgs, msgs...)
+	return nil
+}
+
+func TestPublishWritesKeyedMessage(t *testing.T) {
+	w := &fakeWriter{}
+	msg := publish.Message{
+		Topic:   "yanzi.intents",
+		Key:     "alice",
+		Value:   []byte(`{"id":"x"}`),
+		Headers: []publish.Header{{Key: publish.HeaderHash, Value: "sha256:abc"}},
+	}
+	if err := New(w).Publish(context.Background(), msg); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(w.msgs) != 1 {
+		t.Fatalf("expected one message, got %d", len(w.msgs))
+	}
+	got := w.msgs[0]
+	if got.Topic != "yanzi.intents" || string(got.Key) != "alice" || string(got.Value) != `{"id":"x"}` {
+		t.Fatalf("unexpected message: %+v", got)
+	}
+	if len(got.Headers) != 1 || got.Headers[0].Key != publish.HeaderHash || string(got.Headers[0].Value) != "sha256:abc" {
+		t.Fatalf("unexpected headers: %+v", got.Headers)
+	}
+}
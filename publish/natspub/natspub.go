@@ -0,0 +1,45 @@
+// Package natspub publishes intents to NATS JetStream.
+package natspub
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/chuxorg/chux-yanzi-core/publish"
+)
+
+// JetStream is the part of jetstream.JetStream the publisher uses.
+type JetStream interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// Publisher publishes each message to the subject named by its topic and
+// waits for the stream's acknowledgement. The message ID is sent as
+// Nats-Msg-Id, so streams with a duplicate window drop redeliveries.
+type Publisher struct {
+	js JetStream
+}
+
+var _ publish.Publisher = (*Publisher)(nil)
+
+// New returns a Publisher backed by js.
+func New(js JetStream) *Publisher {
+	return &Publisher{js: js}
+}
+
+// Publish implements publish.Publisher.
+func (p *Publisher) Publish(ctx context.Context, msg publish.Message) error {
+	m := nats.NewMsg(msg.Topic)
+	m.Data = msg.Value
+	for _, h := range msg.Headers {
+		m.Header.Set(h.Key, h.Value)
+	}
+	var opts []jetstream.PublishOpt
+	if msg.ID != "" {
+		opts = append(opts, jetstream.WithMsgID(msg.ID))
+	}
+	_, err := p.js.PublishMsg(ctx, m, opts...)
+	return err
+}
@@ -0,0 +1,43 @@
+package natspub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/chuxorg/chux-yanzi-core/publish"
+)
+
+type fakeJetStream struct {
+	msg  *nats.Msg
+	opts int
+}
+
+func (f *fakeJetStream) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	f.msg, f.opts = msg, len(opts)
+	return &jetstream.PubAck{Stream: "YANZI", Sequence: 1}, nil
+}
+
+func TestPublishSetsSubjectHeadersAndMsgID(t *testing.T) {
+	js := &fakeJetStream{}
+	msg := publish.Message{
+		Topic:   "yanzi.intents",
+		ID:      "sha256:abc",
+		Value:   []byte(`{"id":"x"}`),
+		Headers: []publish.Header{{Key: publish.HeaderIntentID, Value: "x"}},
+	}
+	if err := New(js).Publish(context.Background(), msg); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if js.msg.Subject != "yanzi.intents" || string(js.msg.Data) != `{"id":"x"}` {
+		t.Fatalf("unexpected message: %+v", js.msg)
+	}
+	if got := js.msg.Header.Get(publish.HeaderIntentID); got != "x" {
+		t.Fatalf("expected intent header, got %q", got)
+	}
+	if js.opts != 1 {
+		t.Fatalf("expected the message ID option, got %d options", js.opts)
+	}
+}
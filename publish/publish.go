@@ -0,0 +1,108 @@
+// Package publish streams new intents to a message bus such as NATS
+// JetStream (publish/natspub) or Kafka (publish/kafkapub).
+//
+// Bus clients sit behind the small Publisher interface, and Handler adapts a
+// Publisher to store.Relay, which reads the store's changelog outbox and
+// checkpoints after each publish:
+//
+//	routes := publish.Routes{Default: "yanzi.intents", BySourceType: map[string]string{"ide": "yanzi.ide"}}
+//	err := s.Relay(ctx, "kafka", publish.Handler(kafkapub.New(w), routes), store.RelayOptions{})
+//
+// Delivery is at least once; each message carries the intent hash as its ID
+// so brokers and consumers can drop duplicates.
+package publish
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chuxorg/chux-yanzi-core/export"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// Header names set on every message.
+const (
+	HeaderIntentID   = "Yanzi-Intent-Id"
+	HeaderHash       = "Yanzi-Hash"
+	HeaderSourceType = "Yanzi-Source-Type"
+)
+
+// Header is one message header.
+type Header struct {
+	Key   string
+	Value string
+}
+
+// Message is one intent bound for a topic (a subject, for NATS).
+type Message struct {
+	Topic string
+	// ID identifies the message for broker-side deduplication.
+	ID string
+	// Key partitions the message; it is the intent's author, so each chain
+	// stays in order on partitioned buses.
+	Key     string
+	Value   []byte
+	Headers []Header
+}
+
+// Publisher sends messages to a bus. Publish returns once the bus has
+// accepted the message.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Routes picks the topic for each intent by source type.
+type Routes struct {
+	// Default receives source types without an entry in BySourceType; empty
+	// drops them.
+	Default      string
+	BySourceType map[string]string
+}
+
+// Topic returns the topic for sourceType, or "" if it is not published.
+func (r Routes) Topic(sourceType string) string {
+	if topic, ok := r.BySourceType[sourceType]; ok {
+		return topic
+	}
+	return r.Default
+}
+
+// NewMessage encodes record as canonical JSON for topic.
+func NewMessage(topic string, record model.IntentRecord) (Message, error) {
+	value, err := export.CanonicalJSON(record)
+	if err != nil {
+		return Message{}, fmt.Errorf("encode intent %s: %w", record.ID, err)
+	}
+	return Message{
+		Topic: topic,
+		ID:    record.Hash,
+		Key:   record.Author,
+		Value: value,
+		Headers: []Header{
+			{Key: HeaderIntentID, Value: record.ID},
+			{Key: HeaderHash, Value: record.Hash},
+			{Key: HeaderSourceType, Value: record.SourceType},
+		},
+	}, nil
+}
+
+// Handler returns a store.PublishFunc that publishes created intents to their
+// routed topic. Other events, intents deleted before they were relayed, and
+// source types without a topic are skipped.
+func Handler(p Publisher, routes Routes) store.PublishFunc {
+	return func(ctx context.Context, event store.IntentEvent) error {
+		if event.Kind != store.EventCreated || event.Intent.ID == "" {
+			return nil
+		}
+		topic := routes.Topic(event.Intent.SourceType)
+		if topic == "" {
+			return nil
+		}
+		msg, err := NewMessage(topic, event.Intent)
+		if err != nil {
+			return err
+		}
+		return p.Publish(ctx, msg)
+	}
+}
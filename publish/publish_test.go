@@ -0,0 +1,61 @@
+package publish
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/export"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+type recorder struct {
+	msgs []Message
+}
+
+func (r *recorder) Publish(ctx context.Context, msg Message) error {
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func TestHandlerRoutesBySourceType(t *testing.T) {
+	ctx := context.Background()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var stored []model.IntentRecord
+	for _, sourceType := range []string{"cli", "ide", "batch"} {
+		record, err := s.AppendIntent(ctx, model.IntentRecord{Author: "alice", SourceType: sourceType, Prompt: "p", Response: "r"})
+		if err != nil {
+			t.Fatalf("append intent: %v", err)
+		}
+		stored = append(stored, record)
+	}
+
+	rec := &recorder{}
+	routes := Routes{Default: "yanzi.intents", BySourceType: map[string]string{"ide": "yanzi.ide", "batch": ""}}
+	if _, err := s.RelayOnce(ctx, "bus", Handler(rec, routes)); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+
+	if len(rec.msgs) != 2 {
+		t.Fatalf("expected two messages, got %+v", rec.msgs)
+	}
+	if rec.msgs[0].Topic != "yanzi.intents" || rec.msgs[1].Topic != "yanzi.ide" {
+		t.Fatalf("unexpected topics: %s, %s", rec.msgs[0].Topic, rec.msgs[1].Topic)
+	}
+	want, err := export.CanonicalJSON(stored[0])
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if msg := rec.msgs[0]; string(msg.Value) != string(want) || msg.ID != stored[0].Hash || msg.Key != "alice" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
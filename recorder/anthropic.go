@@ -0,0 +1,147 @@
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// anthropic parses the Messages API (POST /v1/messages).
+type anthropic struct{}
+
+func (anthropic) name() string { return "anthropic" }
+
+type anthropicRequest struct {
+	Model    string          `json:"model"`
+	Stream   bool            `json:"stream"`
+	System   json.RawMessage `json:"system"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+func (anthropic) parseRequest(_ string, body []byte) (string, string, bool, error) {
+	var req anthropicRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", "", false, err
+	}
+	if len(req.Messages) == 0 {
+		return "", "", false, errors.New("request has no messages")
+	}
+	turns := make([][2]string, 0, len(req.Messages)+1)
+	if system := contentText(req.System); system != "" {
+		turns = append(turns, [2]string{"system", system})
+	}
+	for _, m := range req.Messages {
+		turns = append(turns, [2]string{m.Role, contentText(m.Content)})
+	}
+	return transcript(turns), req.Model, req.Stream, nil
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicMessage struct {
+	ID         string          `json:"id"`
+	Model      string          `json:"model"`
+	Content    json.RawMessage `json:"content"`
+	StopReason string          `json:"stop_reason"`
+	Usage      anthropicUsage  `json:"usage"`
+}
+
+// anthropicBlock is a content block; tool_use blocks carry the call.
+type anthropicBlock struct {
+	Type  string          `json:"type"`
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+func (anthropic) parseResponse(body []byte) (exchange, error) {
+	var msg anthropicMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return exchange{}, err
+	}
+	ex := exchange{
+		ID:           msg.ID,
+		Model:        msg.Model,
+		Text:         contentText(msg.Content),
+		StopReason:   msg.StopReason,
+		InputTokens:  msg.Usage.InputTokens,
+		OutputTokens: msg.Usage.OutputTokens,
+	}
+	var blocks []anthropicBlock
+	if json.Unmarshal(msg.Content, &blocks) == nil {
+		for _, block := range blocks {
+			if block.Type == "tool_use" {
+				ex.ToolCalls = append(ex.ToolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+			}
+		}
+	}
+	return ex, nil
+}
+
+type anthropicEvent struct {
+	Type         string           `json:"type"`
+	Index        int              `json:"index"`
+	Message      anthropicMessage `json:"message"`
+	ContentBlock anthropicBlock   `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+// parseStream assembles message_start, text and tool input deltas, and the
+// final message_delta, which carries the stop reason and output token count.
+func (anthropic) parseStream(body []byte) (exchange, error) {
+	var ex exchange
+	var text strings.Builder
+	calls := map[int]*ToolCall{}
+	var order []int
+	err := eachEvent(body, func(data []byte) error {
+		var event anthropicEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		switch event.Type {
+		case "message_start":
+			ex.ID, ex.Model = event.Message.ID, event.Message.Model
+			ex.InputTokens = event.Message.Usage.InputTokens
+			ex.OutputTokens = event.Message.Usage.OutputTokens
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" {
+				calls[event.Index] = &ToolCall{ID: event.ContentBlock.ID, Name: event.ContentBlock.Name}
+				order = append(order, event.Index)
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				text.WriteString(event.Delta.Text)
+			case "input_json_delta":
+				if call, ok := calls[event.Index]; ok {
+					call.Arguments += event.Delta.PartialJSON
+				}
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				ex.StopReason = event.Delta.StopReason
+			}
+			if event.Usage.OutputTokens > 0 {
+				ex.OutputTokens = event.Usage.OutputTokens
+			}
+		}
+		return nil
+	})
+	ex.Text = text.String()
+	for _, i := range order {
+		ex.ToolCalls = append(ex.ToolCalls, *calls[i])
+	}
+	return ex, err
+}
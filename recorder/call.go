@@ -0,0 +1,99 @@
+package recorder
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Call is one LLM exchange. Transport builds calls from HTTP traffic; other
+// integrations, such as recorder/langchainrec, build them from framework
+// callbacks, so every source records the same shape.
+type Call struct {
+	Provider   string
+	Model      string
+	ResponseID string
+	// Prompt is the request as a role-labelled transcript; Response is the
+	// generated text.
+	Prompt     string
+	Response   string
+	StopReason string
+	ToolCalls  []ToolCall
+	Stream     bool
+	// Incomplete marks a stream the caller stopped reading early.
+	Incomplete   bool
+	Latency      time.Duration
+	InputTokens  int
+	OutputTokens int
+}
+
+// ToolCall is a tool or function call requested by the model, as recorded in
+// meta under "tool_calls".
+type ToolCall struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// callMeta is the meta recorded with each call.
+type callMeta struct {
+	Provider   string     `json:"provider"`
+	Model      string     `json:"model,omitempty"`
+	ResponseID string     `json:"response_id,omitempty"`
+	StopReason string     `json:"stop_reason,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	Stream     bool       `json:"stream,omitempty"`
+	Incomplete bool       `json:"incomplete,omitempty"`
+	LatencyMS  int64      `json:"latency_ms"`
+	Usage      usage      `json:"usage"`
+}
+
+type usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// NewIntent returns the record for call, ready for AppendIntent. An empty
+// sourceType defaults to the provider. A turn that only calls tools has no
+// text, so its response is the calls rendered as "name(arguments)" lines.
+func NewIntent(call Call, author, sourceType string) (model.IntentRecord, error) {
+	meta, err := json.Marshal(callMeta{
+		Provider:   call.Provider,
+		Model:      call.Model,
+		ResponseID: call.ResponseID,
+		StopReason: call.StopReason,
+		ToolCalls:  call.ToolCalls,
+		Stream:     call.Stream,
+		Incomplete: call.Incomplete,
+		LatencyMS:  call.Latency.Milliseconds(),
+		Usage: usage{
+			InputTokens:  call.InputTokens,
+			OutputTokens: call.OutputTokens,
+			TotalTokens:  call.InputTokens + call.OutputTokens,
+		},
+	})
+	if err != nil {
+		return model.IntentRecord{}, err
+	}
+	if sourceType == "" {
+		sourceType = call.Provider
+	}
+	response := call.Response
+	if response == "" {
+		lines := make([]string, 0, len(call.ToolCalls))
+		for _, tc := range call.ToolCalls {
+			lines = append(lines, tc.Name+"("+tc.Arguments+")")
+		}
+		response = strings.Join(lines, "\n")
+	}
+	return model.IntentRecord{
+		Author:     author,
+		SourceType: sourceType,
+		Prompt:     call.Prompt,
+		Response:   response,
+		Meta:       meta,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+)
+
+// gemini parses the Gemini API's generateContent and streamGenerateContent
+// methods (POST .../models/{model}:generateContent), on both the Gemini
+// Developer API and Vertex AI.
+type gemini struct{}
+
+func (gemini) name() string { return "gemini" }
+
+type geminiPart struct {
+	Text         string `json:"text"`
+	FunctionCall *struct {
+		ID   string          `json:"id"`
+		Name string          `json:"name"`
+		Args json.RawMessage `json:"args"`
+	} `json:"functionCall"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role"`
+	Parts []geminiPart `json:"parts"`
+}
+
+// text joins the content's text parts.
+func (c geminiContent) text() string {
+	var b strings.Builder
+	for _, part := range c.Parts {
+		b.WriteString(part.Text)
+	}
+	return b.String()
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent `json:"contents"`
+	SystemInstruction *geminiContent  `json:"systemInstruction"`
+	// The REST API also accepts the snake_case spelling.
+	SystemInstructionSnake *geminiContent `json:"system_instruction"`
+}
+
+func (gemini) parseRequest(urlPath string, body []byte) (string, string, bool, error) {
+	var req geminiRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", "", false, err
+	}
+	if len(req.Contents) == 0 {
+		return "", "", false, errors.New("request has no contents")
+	}
+	var turns [][2]string
+	system := req.SystemInstruction
+	if system == nil {
+		system = req.SystemInstructionSnake
+	}
+	if system != nil {
+		if text := system.text(); text != "" {
+			turns = append(turns, [2]string{"system", text})
+		}
+	}
+	for _, c := range req.Contents {
+		role := c.Role
+		switch role {
+		case "", "user":
+			role = "user"
+		case "model":
+			role = "assistant"
+		}
+		turns = append(turns, [2]string{role, c.text()})
+	}
+	model, method, _ := strings.Cut(path.Base(urlPath), ":")
+	return transcript(turns), model, method == "streamGenerateContent", nil
+}
+
+type geminiResponse struct {
+	ResponseID   string `json:"responseId"`
+	ModelVersion string `json:"modelVersion"`
+	Candidates   []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// add merges one response, or one streamed chunk of it, into ex.
+func (r geminiResponse) add(ex *exchange) {
+	if ex.ID == "" {
+		ex.ID, ex.Model = r.ResponseID, r.ModelVersion
+	}
+	if len(r.Candidates) > 0 {
+		candidate := r.Candidates[0]
+		ex.Text += candidate.Content.text()
+		for _, part := range candidate.Content.Parts {
+			if fc := part.FunctionCall; fc != nil {
+				ex.ToolCalls = append(ex.ToolCalls, ToolCall{ID: fc.ID, Name: fc.Name, Arguments: string(fc.Args)})
+			}
+		}
+		if candidate.FinishReason != "" {
+			ex.StopReason = candidate.FinishReason
+		}
+	}
+	if r.UsageMetadata != nil {
+		ex.InputTokens, ex.OutputTokens = r.UsageMetadata.PromptTokenCount, r.UsageMetadata.CandidatesTokenCount
+	}
+}
+
+func (gemini) parseResponse(body []byte) (exchange, error) {
+	var resp geminiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return exchange{}, err
+	}
+	var ex exchange
+	resp.add(&ex)
+	return ex, nil
+}
+
+// parseStream accepts both stream encodings: server-sent events (?alt=sse, as
+// the SDKs request) and the default JSON array of responses.
+func (gemini) parseStream(body []byte) (exchange, error) {
+	var ex exchange
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var chunks []geminiResponse
+		if err := json.Unmarshal(trimmed, &chunks); err != nil {
+			return exchange{}, err
+		}
+		for _, chunk := range chunks {
+			chunk.add(&ex)
+		}
+		return ex, nil
+	}
+	err := eachEvent(body, func(data []byte) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return err
+		}
+		chunk.add(&ex)
+		return nil
+	})
+	return ex, err
+}
@@ -0,0 +1,235 @@
+// Package langchainrec records langchaingo LLM calls as intents. Handler
+// implements callbacks.Handler; attach it to a model and every GenerateContent
+// call is recorded, tool calls included:
+//
+//	llm, err := openai.New(openai.WithCallback(langchainrec.New(s, langchainrec.WithModel("gpt-4o"))))
+//
+// Records have the same prompt, response, and meta shape as those made by
+// recorder.Transport. Callbacks do not report the model name, so set it with
+// WithModel when it matters.
+package langchainrec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/chuxorg/chux-yanzi-core/recorder"
+)
+
+// Provider is recorded as the provider of calls without WithProvider.
+const Provider = "langchaingo"
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithAuthor sets the author of recorded intents; the default is "llm".
+func WithAuthor(author string) Option {
+	return func(h *Handler) {
+		h.author = author
+	}
+}
+
+// WithSourceType sets the source type of recorded intents; the default is the
+// provider.
+func WithSourceType(sourceType string) Option {
+	return func(h *Handler) {
+		h.sourceType = sourceType
+	}
+}
+
+// WithProvider names the backing provider (for example "openai") in meta.
+func WithProvider(provider string) Option {
+	return func(h *Handler) {
+		h.provider = provider
+	}
+}
+
+// WithModel names the model in meta.
+func WithModel(model string) Option {
+	return func(h *Handler) {
+		h.model = model
+	}
+}
+
+// WithErrorHandler sets a callback for recording failures, which are
+// otherwise dropped.
+func WithErrorHandler(fn func(error)) Option {
+	return func(h *Handler) {
+		h.onError = fn
+	}
+}
+
+// Handler records each GenerateContent call. Calls are matched to their
+// results by context, so concurrent calls are recorded correctly as long as
+// each uses its own context; calls sharing one are matched in order.
+type Handler struct {
+	callbacks.SimpleHandler
+
+	store      recorder.Appender
+	author     string
+	sourceType string
+	provider   string
+	model      string
+	onError    func(error)
+	now        func() time.Time
+
+	mu      sync.Mutex
+	pending map[context.Context][]pendingCall
+}
+
+var _ callbacks.Handler = (*Handler)(nil)
+
+type pendingCall struct {
+	prompt string
+	start  time.Time
+}
+
+// New returns a Handler that records into s.
+func New(s recorder.Appender, opts ...Option) *Handler {
+	h := &Handler{
+		store:    s,
+		author:   "llm",
+		provider: Provider,
+		now:      time.Now,
+		pending:  make(map[context.Context][]pendingCall),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// HandleLLMGenerateContentStart remembers the messages of a call.
+func (h *Handler) HandleLLMGenerateContentStart(ctx context.Context, ms []llms.MessageContent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pending[ctx] = append(h.pending[ctx], pendingCall{prompt: transcript(ms), start: h.now()})
+}
+
+// HandleLLMGenerateContentEnd records the call with its first choice.
+func (h *Handler) HandleLLMGenerateContentEnd(ctx context.Context, res *llms.ContentResponse) {
+	call, ok := h.pop(ctx)
+	if !ok || res == nil || len(res.Choices) == 0 {
+		return
+	}
+	choice := res.Choices[0]
+	rec := recorder.Call{
+		Provider:   h.provider,
+		Model:      h.model,
+		Prompt:     call.prompt,
+		Response:   choice.Content,
+		StopReason: choice.StopReason,
+		Latency:    h.now().Sub(call.start),
+	}
+	for _, tc := range choice.ToolCalls {
+		if tc.FunctionCall != nil {
+			rec.ToolCalls = append(rec.ToolCalls, recorder.ToolCall{ID: tc.ID, Name: tc.FunctionCall.Name, Arguments: tc.FunctionCall.Arguments})
+		}
+	}
+	if len(rec.ToolCalls) == 0 && choice.FuncCall != nil {
+		rec.ToolCalls = []recorder.ToolCall{{Name: choice.FuncCall.Name, Arguments: choice.FuncCall.Arguments}}
+	}
+	rec.InputTokens, rec.OutputTokens = tokenUsage(choice.GenerationInfo)
+
+	record, err := recorder.NewIntent(rec, h.author, h.sourceType)
+	if err == nil {
+		_, err = h.store.AppendIntent(context.WithoutCancel(ctx), record)
+	}
+	if err != nil && h.onError != nil {
+		h.onError(fmt.Errorf("record langchaingo call: %w", err))
+	}
+}
+
+// HandleLLMError drops the failed call.
+func (h *Handler) HandleLLMError(ctx context.Context, _ error) {
+	h.pop(ctx)
+}
+
+func (h *Handler) pop(ctx context.Context) (pendingCall, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	queue := h.pending[ctx]
+	if len(queue) == 0 {
+		return pendingCall{}, false
+	}
+	call := queue[0]
+	if len(queue) == 1 {
+		delete(h.pending, ctx)
+	} else {
+		h.pending[ctx] = queue[1:]
+	}
+	return call, true
+}
+
+// roles maps langchaingo message types to the role names used by the
+// provider APIs, so transcripts read the same whichever way they were made.
+var roles = map[llms.ChatMessageType]string{
+	llms.ChatMessageTypeHuman:  "user",
+	llms.ChatMessageTypeAI:     "assistant",
+	llms.ChatMessageTypeSystem: "system",
+}
+
+// transcript renders messages as "role: text" blocks separated by blank
+// lines. Tool results are included; images and binary parts are not.
+func transcript(ms []llms.MessageContent) string {
+	var b strings.Builder
+	for _, m := range ms {
+		var texts []string
+		for _, part := range m.Parts {
+			switch p := part.(type) {
+			case llms.TextContent:
+				texts = append(texts, p.Text)
+			case llms.ToolCallResponse:
+				texts = append(texts, p.Content)
+			}
+		}
+		role, ok := roles[m.Role]
+		if !ok {
+			role = string(m.Role)
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(role)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(texts, "\n"))
+	}
+	return b.String()
+}
+
+// tokenUsage reads token counts from GenerationInfo, whose keys differ by
+// provider.
+func tokenUsage(info map[string]any) (input, output int) {
+	for _, keys := range [][2]string{
+		{"PromptTokens", "CompletionTokens"},
+		{"InputTokens", "OutputTokens"},
+		{"input_tokens", "output_tokens"},
+	} {
+		in, inOK := asInt(info[keys[0]])
+		out, outOK := asInt(info[keys[1]])
+		if inOK || outOK {
+			return in, out
+		}
+	}
+	return 0, 0
+}
+
+func asInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
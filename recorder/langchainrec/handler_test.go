@@ -0,0 +1,95 @@
+package langchainrec
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func openTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func TestHandlerRecordsGenerateContent(t *testing.T) {
+	s := openTestStore(t)
+	h := New(s, WithAuthor("agent"), WithProvider("openai"), WithModel("gpt-test"),
+		WithErrorHandler(func(err error) { t.Errorf("record: %v", err) }))
+
+	ctx := context.Background()
+	h.HandleLLMGenerateContentStart(ctx, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "Be brief."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris?"),
+	})
+	h.HandleLLMGenerateContentEnd(ctx, &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		StopReason:     "tool_calls",
+		GenerationInfo: map[string]any{"PromptTokens": 11, "CompletionTokens": 7},
+		ToolCalls: []llms.ToolCall{{
+			ID:           "call_1",
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: "weather", Arguments: `{"city":"Paris"}`},
+		}},
+	}}})
+
+	intents, err := s.ListIntents(ctx, 10)
+	if err != nil || len(intents) != 1 {
+		t.Fatalf("expected one intent, got %d (%v)", len(intents), err)
+	}
+	got := intents[0]
+	if got.Author != "agent" || got.SourceType != "openai" || got.Prompt != "system: Be brief.\n\nuser: Weather in Paris?" {
+		t.Fatalf("unexpected record: %+v", got)
+	}
+	if got.Response != `weather({"city":"Paris"})` {
+		t.Fatalf("expected the tool call as the response, got %q", got.Response)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(got.Meta, &meta); err != nil {
+		t.Fatalf("decode meta: %v", err)
+	}
+	if meta["model"] != "gpt-test" || meta["stop_reason"] != "tool_calls" || !strings.Contains(string(got.Meta), `"tool_calls":[{"id":"call_1","name":"weather"`) {
+		t.Fatalf("unexpected meta: %s", got.Meta)
+	}
+	if usage := meta["usage"].(map[string]any); usage["input_tokens"] != float64(11) || usage["total_tokens"] != float64(18) {
+		t.Fatalf("unexpected usage: %v", usage)
+	}
+}
+
+func TestHandlerMatchesCallsByContext(t *testing.T) {
+	s := openTestStore(t)
+	h := New(s)
+
+	type key struct{}
+	first := context.WithValue(context.Background(), key{}, 1)
+	second := context.WithValue(context.Background(), key{}, 2)
+	h.HandleLLMGenerateContentStart(first, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "first")})
+	h.HandleLLMGenerateContentStart(second, []llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "second")})
+	h.HandleLLMError(first, context.DeadlineExceeded)
+	h.HandleLLMGenerateContentEnd(second, &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "answer"}}})
+	// An end without a start is ignored.
+	h.HandleLLMGenerateContentEnd(first, &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "stray"}}})
+
+	intents, err := s.ListIntents(context.Background(), 10)
+	if err != nil || len(intents) != 1 {
+		t.Fatalf("expected one intent, got %d (%v)", len(intents), err)
+	}
+	if intents[0].Prompt != "user: second" || intents[0].Response != "answer" || intents[0].SourceType != Provider {
+		t.Fatalf("unexpected record: %+v", intents[0])
+	}
+	if len(h.pending) != 0 {
+		t.Fatalf("expected no pending calls, got %v", h.pending)
+	}
+}
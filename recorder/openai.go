@@ -0,0 +1,158 @@
+package recorder
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// openAI parses the Chat Completions API (POST .../chat/completions), which
+// OpenAI-compatible servers also implement.
+type openAI struct{}
+
+func (openAI) name() string { return "openai" }
+
+type openAIRequest struct {
+	Model    string `json:"model"`
+	Stream   bool   `json:"stream"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+func (openAI) parseRequest(_ string, body []byte) (string, string, bool, error) {
+	var req openAIRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", "", false, err
+	}
+	if len(req.Messages) == 0 {
+		return "", "", false, errors.New("request has no messages")
+	}
+	turns := make([][2]string, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		turns = append(turns, [2]string{m.Role, contentText(m.Content)})
+	}
+	return transcript(turns), req.Model, req.Stream, nil
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIToolCall struct {
+	// Index orders streamed fragments; a call's fragments share it.
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIMessage struct {
+	Content   string           `json:"content"`
+	ToolCalls []openAIToolCall `json:"tool_calls"`
+}
+
+type openAIResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		Delta        openAIMessage `json:"delta"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// parseResponse records the first choice; requests for several (n > 1) keep
+// only it.
+func (openAI) parseResponse(body []byte) (exchange, error) {
+	var resp openAIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return exchange{}, err
+	}
+	ex := exchange{ID: resp.ID, Model: resp.Model}
+	if len(resp.Choices) > 0 {
+		ex.Text = resp.Choices[0].Message.Content
+		ex.StopReason = resp.Choices[0].FinishReason
+		for _, call := range resp.Choices[0].Message.ToolCalls {
+			ex.ToolCalls = append(ex.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+		}
+	}
+	if resp.Usage != nil {
+		ex.InputTokens, ex.OutputTokens = resp.Usage.PromptTokens, resp.Usage.CompletionTokens
+	}
+	return ex, nil
+}
+
+// parseStream joins the first choice's deltas, including tool call fragments.
+// Usage is only streamed when the request sets stream_options.include_usage.
+func (openAI) parseStream(body []byte) (exchange, error) {
+	var ex exchange
+	var text strings.Builder
+	calls := map[int]*ToolCall{}
+	var order []int
+	err := eachEvent(body, func(data []byte) error {
+		var chunk openAIResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return err
+		}
+		if ex.ID == "" {
+			ex.ID, ex.Model = chunk.ID, chunk.Model
+		}
+		if len(chunk.Choices) > 0 {
+			text.WriteString(chunk.Choices[0].Delta.Content)
+			for _, frag := range chunk.Choices[0].Delta.ToolCalls {
+				call, ok := calls[frag.Index]
+				if !ok {
+					call = &ToolCall{}
+					calls[frag.Index] = call
+					order = append(order, frag.Index)
+				}
+				if frag.ID != "" {
+					call.ID = frag.ID
+				}
+				call.Name += frag.Function.Name
+				call.Arguments += frag.Function.Arguments
+			}
+			if reason := chunk.Choices[0].FinishReason; reason != "" {
+				ex.StopReason = reason
+			}
+		}
+		if chunk.Usage != nil {
+			ex.InputTokens, ex.OutputTokens = chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens
+		}
+		return nil
+	})
+	ex.Text = text.String()
+	for _, i := range order {
+		ex.ToolCalls = append(ex.ToolCalls, *calls[i])
+	}
+	return ex, err
+}
+
+// contentText flattens message content, which is either a string or an array
+// of parts, to its text parts joined by newlines.
+func contentText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &parts) != nil {
+		return ""
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,326 @@
+// Package recorder records LLM API calls as intents without touching call
+// sites. Transport is an http.RoundTripper that recognises OpenAI chat
+// completion, Anthropic message, and Gemini generateContent requests (the
+// last made by google.golang.org/genai among others), lets them through
+// unchanged, and
+// appends an intent for each successful exchange once the caller has read the
+// response, streamed or not:
+//
+//	client := recorder.NewClient(s, recorder.WithAuthor("support-bot"))
+//	// openai.NewClient(option.WithHTTPClient(client))
+//	// anthropic.NewClient(option.WithHTTPClient(client))
+//	// genai.NewClient(ctx, &genai.ClientConfig{HTTPClient: client})
+//
+// The prompt is the request's messages (and system prompt) as a role-labelled
+// transcript, the response is the generated text, and meta records the
+// provider, model, response ID, stop reason, tool calls, token usage, and
+// latency.
+// Recording never fails a call; errors go to the WithErrorHandler callback.
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// DefaultMaxCaptureBytes bounds how much of a response body is buffered for
+// recording unless overridden with WithMaxCaptureBytes. Larger responses pass
+// through unrecorded.
+const DefaultMaxCaptureBytes = 16 << 20
+
+// Appender stores recorded intents. *store.Store satisfies it.
+type Appender interface {
+	AppendIntent(ctx context.Context, record model.IntentRecord) (model.IntentRecord, error)
+}
+
+// Option configures a Transport.
+type Option func(*Transport)
+
+// WithBase sets the RoundTripper that sends requests; the default is
+// http.DefaultTransport.
+func WithBase(rt http.RoundTripper) Option {
+	return func(t *Transport) {
+		if rt != nil {
+			t.base = rt
+		}
+	}
+}
+
+// WithAuthor sets the author of recorded intents, and so the chain they join.
+// The default is "llm".
+func WithAuthor(author string) Option {
+	return func(t *Transport) {
+		t.author = author
+	}
+}
+
+// WithSourceType sets the source type of recorded intents; the default is the
+// provider name ("openai", "anthropic", or "gemini").
+func WithSourceType(sourceType string) Option {
+	return func(t *Transport) {
+		t.sourceType = sourceType
+	}
+}
+
+// WithErrorHandler sets a callback for recording failures, which are
+// otherwise dropped.
+func WithErrorHandler(fn func(error)) Option {
+	return func(t *Transport) {
+		t.onError = fn
+	}
+}
+
+// WithMaxCaptureBytes sets the largest response body recorded; values <= 0
+// keep the default.
+func WithMaxCaptureBytes(n int64) Option {
+	return func(t *Transport) {
+		if n > 0 {
+			t.maxCapture = n
+		}
+	}
+}
+
+// Transport records LLM calls made through it.
+type Transport struct {
+	store      Appender
+	base       http.RoundTripper
+	author     string
+	sourceType string
+	onError    func(error)
+	maxCapture int64
+	now        func() time.Time
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport returns a Transport that records into s.
+func NewTransport(s Appender, opts ...Option) *Transport {
+	t := &Transport{
+		store:      s,
+		base:       http.DefaultTransport,
+		author:     "llm",
+		maxCapture: DefaultMaxCaptureBytes,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// NewClient returns an http.Client whose transport records into s, for SDKs
+// that accept a custom client.
+func NewClient(s Appender, opts ...Option) *http.Client {
+	return &http.Client{Transport: NewTransport(s, opts...)}
+}
+
+// provider extracts an exchange from one API's request and response bodies.
+type provider interface {
+	name() string
+	// parseRequest returns the prompt transcript, the requested model, and
+	// whether the response is streamed.
+	parseRequest(path string, body []byte) (prompt, model string, stream bool, err error)
+	// parseResponse decodes a complete JSON response.
+	parseResponse(body []byte) (exchange, error)
+	// parseStream decodes a server-sent event stream.
+	parseStream(body []byte) (exchange, error)
+}
+
+// exchange is what a provider's response contributes to the record.
+type exchange struct {
+	ID           string
+	Model        string
+	Text         string
+	StopReason   string
+	ToolCalls    []ToolCall
+	InputTokens  int
+	OutputTokens int
+}
+
+// providerFor picks the provider from the request path.
+func providerFor(r *http.Request) provider {
+	if r.Method != http.MethodPost {
+		return nil
+	}
+	switch path := strings.TrimSuffix(r.URL.Path, "/"); {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return openAI{}
+	case strings.HasSuffix(path, "/v1/messages"):
+		return anthropic{}
+	case strings.HasSuffix(path, ":generateContent"), strings.HasSuffix(path, ":streamGenerateContent"):
+		return gemini{}
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := providerFor(req)
+	if p == nil || req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	reqBody, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// RoundTrippers must not modify the caller's request.
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(reqBody))
+	out.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(reqBody)), nil }
+
+	start := t.now()
+	resp, err := t.base.RoundTrip(out)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, err
+	}
+	resp.Body = &capture{
+		ReadCloser: resp.Body,
+		limit:      t.maxCapture,
+		done: func(body []byte, eof, overflow bool) {
+			if overflow {
+				t.fail(fmt.Errorf("%s response not recorded: body over %d bytes", p.name(), t.maxCapture))
+				return
+			}
+			t.record(context.WithoutCancel(req.Context()), p, req.URL.Path, reqBody, body, eof, start)
+		},
+	}
+	return resp, nil
+}
+
+// record parses the exchange and appends it. A body closed before EOF is
+// still recorded if it parses: JSON decoders may stop reading at the end of
+// the object, and a stream the caller abandoned is recorded as far as it was
+// read, marked incomplete.
+func (t *Transport) record(ctx context.Context, p provider, path string, reqBody, respBody []byte, eof bool, start time.Time) {
+	prompt, requested, stream, err := p.parseRequest(path, reqBody)
+	if err != nil {
+		t.fail(fmt.Errorf("parse %s request: %w", p.name(), err))
+		return
+	}
+	var ex exchange
+	if stream {
+		ex, err = p.parseStream(respBody)
+	} else {
+		ex, err = p.parseResponse(respBody)
+	}
+	if err != nil {
+		t.fail(fmt.Errorf("parse %s response: %w", p.name(), err))
+		return
+	}
+	if ex.Model == "" {
+		ex.Model = requested
+	}
+
+	call := Call{
+		Provider:     p.name(),
+		Model:        ex.Model,
+		ResponseID:   ex.ID,
+		Prompt:       prompt,
+		Response:     ex.Text,
+		StopReason:   ex.StopReason,
+		ToolCalls:    ex.ToolCalls,
+		Stream:       stream,
+		Incomplete:   stream && !eof,
+		Latency:      t.now().Sub(start),
+		InputTokens:  ex.InputTokens,
+		OutputTokens: ex.OutputTokens,
+	}
+	record, err := NewIntent(call, t.author, t.sourceType)
+	if err == nil {
+		_, err = t.store.AppendIntent(ctx, record)
+	}
+	if err != nil {
+		t.fail(fmt.Errorf("record %s call: %w", p.name(), err))
+	}
+}
+
+func (t *Transport) fail(err error) {
+	if t.onError != nil {
+		t.onError(err)
+	}
+}
+
+// capture buffers a response body as the caller reads it and calls done once,
+// at EOF or Close, reporting whether EOF was reached and whether the body
+// exceeded limit.
+type capture struct {
+	io.ReadCloser
+	limit    int64
+	buf      bytes.Buffer
+	overflow bool
+	eof      bool
+	once     sync.Once
+	done     func(body []byte, eof, overflow bool)
+}
+
+func (c *capture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if n > 0 && !c.overflow {
+		if int64(c.buf.Len()+n) > c.limit {
+			c.overflow = true
+			c.buf = bytes.Buffer{}
+		} else {
+			c.buf.Write(p[:n])
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		c.eof = true
+		c.finish()
+	}
+	return n, err
+}
+
+func (c *capture) Close() error {
+	err := c.ReadCloser.Close()
+	c.finish()
+	return err
+}
+
+func (c *capture) finish() {
+	c.once.Do(func() { c.done(c.buf.Bytes(), c.eof, c.overflow) })
+}
+
+// transcript renders messages as "role: text" blocks separated by blank lines.
+func transcript(turns [][2]string) string {
+	var b strings.Builder
+	for _, turn := range turns {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(turn[0])
+		b.WriteString(": ")
+		b.WriteString(turn[1])
+	}
+	return b.String()
+}
+
+// eachEvent calls fn with the data of every server-sent event in body,
+// stopping at the first error.
+func eachEvent(body []byte, fn func(data []byte) error) error {
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || string(data) == "[DONE]" {
+			continue
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func openTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+// fakeLLM answers both APIs, streaming when the request asks for it.
+func fakeLLM(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case r.URL.Path == "/v1/chat/completions" && !req.Stream:
+			fmt.Fprint(w, `{"id":"chatcmpl-1","model":"gpt-test","choices":[{"message":{"role":"assistant","content":"Paris."},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":2,"total_tokens":14}}`)
+		case r.URL.Path == "/v1/chat/completions":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"id\":\"chatcmpl-2\",\"model\":\"gpt-test\",\"choices\":[{\"delta\":{\"content\":\"Par\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"id\":\"chatcmpl-2\",\"model\":\"gpt-test\",\"choices\":[{\"delta\":{\"content\":\"is.\"},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: {\"id\":\"chatcmpl-2\",\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":2}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		case r.URL.Path == "/v1/messages" && !req.Stream:
+			fmt.Fprint(w, `{"id":"msg_1","type":"message","model":"claude-test","content":[{"type":"text","text":"Paris."}],"stop_reason":"end_turn","usage":{"input_tokens":20,"output_tokens":3}}`)
+		case r.URL.Path == "/v1/messages":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_2\",\"model\":\"claude-test\",\"usage\":{\"input_tokens\":20,\"output_tokens\":1}}}\n\n")
+			fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Par\"}}\n\n")
+			fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"is.\"}}\n\n")
+			fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":3}}\n\n")
+			fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+		case r.URL.Path == "/v1beta/models/gemini-test:generateContent":
+			fmt.Fprint(w, `{"responseId":"g-1","modelVersion":"gemini-test-001","candidates":[{"content":{"role":"model","parts":[{"text":"Paris."}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":9,"candidatesTokenCount":2,"totalTokenCount":11}}`)
+		case r.URL.Path == "/v1beta/models/gemini-test:streamGenerateContent":
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"responseId\":\"g-2\",\"modelVersion\":\"gemini-test-001\",\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Par\"}]}}]}\r\n\r\n")
+			fmt.Fprint(w, "data: {\"responseId\":\"g-2\",\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"is.\"}]},\"finishReason\":\"STOP\"}],\"usageMetadata\":{\"promptTokenCount\":9,\"candidatesTokenCount\":2}}\r\n\r\n")
+		default:
+			http.Error(w, `{"error":"nope"}`, http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, client *http.Client, url, body string) string {
+	t.Helper()
+	resp, err := client.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(data)
+}
+
+func TestTransportRecordsCalls(t *testing.T) {
+	srv := fakeLLM(t)
+	cases := []struct {
+		name, path, body, prompt, provider, model, id string
+		stream                                        bool
+		input, output                                 int
+	}{
+		{
+			name: "openai", path: "/v1/chat/completions",
+			body:   `{"model":"gpt-test","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":[{"type":"text","text":"Capital of France?"}]}]}`,
+			prompt: "system: Be brief.\n\nuser: Capital of France?", provider: "openai", model: "gpt-test", id: "chatcmpl-1", input: 12, output: 2,
+		},
+		{
+			name: "openai stream", path: "/v1/chat/completions",
+			body:   `{"model":"gpt-test","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Capital of France?"}]}`,
+			prompt: "user: Capital of France?", provider: "openai", model: "gpt-test", id: "chatcmpl-2", stream: true, input: 12, output: 2,
+		},
+		{
+			name: "anthropic", path: "/v1/messages",
+			body:   `{"model":"claude-test","max_tokens":64,"system":"Be brief.","messages":[{"role":"user","content":"Capital of France?"}]}`,
+			prompt: "system: Be brief.\n\nuser: Capital of France?", provider: "anthropic", model: "claude-test", id: "msg_1", input: 20, output: 3,
+		},
+		{
+			name: "gemini", path: "/v1beta/models/gemini-test:generateContent",
+			body:   `{"systemInstruction":{"parts":[{"text":"Be brief."}]},"contents":[{"role":"user","parts":[{"text":"Capital of France?"}]}]}`,
+			prompt: "system: Be brief.\n\nuser: Capital of France?", provider: "gemini", model: "gemini-test-001", id: "g-1", input: 9, output: 2,
+		},
+		{
+			name: "gemini stream", path: "/v1beta/models/gemini-test:streamGenerateContent?alt=sse",
+			body:   `{"contents":[{"parts":[{"text":"Capital of France?"}]}]}`,
+			prompt: "user: Capital of France?", provider: "gemini", model: "gemini-test-001", id: "g-2", stream: true, input: 9, output: 2,
+		},
+		{
+			name: "anthropic stream", path: "/v1/messages",
+			body:   `{"model":"claude-test","max_tokens":64,"stream":true,"messages":[{"role":"user","content":[{"type":"text","text":"Capital of France?"}]}]}`,
+			prompt: "user: Capital of France?", provider: "anthropic", model: "claude-test", id: "msg_2", stream: true, input: 20, output: 3,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := openTestStore(t)
+			client := NewClient(s, WithAuthor("bot"), WithErrorHandler(func(err error) { t.Errorf("record: %v", err) }))
+			body := post(t, client, srv.URL+tc.path, tc.body)
+			if !strings.Contains(body, "Par") {
+				t.Fatalf("caller did not get the response: %s", body)
+			}
+
+			intents, err := s.ListIntents(context.Background(), 10)
+			if err != nil {
+				t.Fatalf("list intents: %v", err)
+			}
+			if len(intents) != 1 {
+				t.Fatalf("expected one recorded intent, got %d", len(intents))
+			}
+			got := intents[0]
+			if got.Author != "bot" || got.SourceType != tc.provider || got.Prompt != tc.prompt || got.Response != "Paris." {
+				t.Fatalf("unexpected record: %+v", got)
+			}
+			var meta callMeta
+			if err := json.Unmarshal(got.Meta, &meta); err != nil {
+				t.Fatalf("decode meta: %v", err)
+			}
+			want := callMeta{Provider: tc.provider, Model: tc.model, ResponseID: tc.id, Stream: tc.stream,
+				Usage: usage{InputTokens: tc.input, OutputTokens: tc.output, TotalTokens: tc.input + tc.output}}
+			meta.LatencyMS, meta.StopReason = 0, ""
+			if !reflect.DeepEqual(meta, want) {
+				t.Fatalf("expected meta %+v, got %+v", want, meta)
+			}
+		})
+	}
+}
+
+func TestTransportSkipsOtherRequestsAndErrors(t *testing.T) {
+	srv := fakeLLM(t)
+	s := openTestStore(t)
+	var failures []error
+	client := NewClient(s, WithErrorHandler(func(err error) { failures = append(failures, err) }))
+
+	post(t, client, srv.URL+"/v1/embeddings", `{"input":"x"}`)
+	// A matching path that fails is passed through unrecorded.
+	post(t, client, srv.URL+"/v2/chat/completions", `{"model":"m","messages":[{"role":"user","content":"hi"}]}`)
+	resp, err := client.Get(srv.URL + "/v1/messages")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+
+	intents, err := s.ListIntents(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	if len(intents) != 0 || len(failures) != 0 {
+		t.Fatalf("expected nothing recorded, got %d intents and errors %v", len(intents), failures)
+	}
+}
+
+func TestTransportReportsOversizedResponses(t *testing.T) {
+	srv := fakeLLM(t)
+	s := openTestStore(t)
+	var failures []error
+	client := NewClient(s, WithMaxCaptureBytes(16), WithErrorHandler(func(err error) { failures = append(failures, err) }))
+	post(t, client, srv.URL+"/v1/messages", `{"model":"claude-test","messages":[{"role":"user","content":"hi"}]}`)
+
+	if len(failures) != 1 || !strings.Contains(failures[0].Error(), "over 16 bytes") {
+		t.Fatalf("expected an oversize error, got %v", failures)
+	}
+}
+
+func TestTransportRecordsToolCalls(t *testing.T) {
+	responses := map[string]string{
+		"/v1/chat/completions": "data: {\"id\":\"c\",\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"function\":{\"name\":\"weather\",\"arguments\":\"{\\\"city\\\":\"}}]}}]}\n\n" +
+			"data: {\"id\":\"c\",\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"Paris\\\"}\"}}]},\"finish_reason\":\"tool_calls\"}]}\n\n" +
+			"data: [DONE]\n\n",
+		"/v1/messages": "data: {\"type\":\"message_start\",\"message\":{\"id\":\"m\",\"usage\":{\"input_tokens\":5}}}\n\n" +
+			"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"call_1\",\"name\":\"weather\",\"input\":{}}}\n\n" +
+			"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"city\\\":\"}}\n\n" +
+			"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"\\\"Paris\\\"}\"}}\n\n" +
+			"data: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"},\"usage\":{\"output_tokens\":7}}\n\n",
+		"/v1beta/models/gemini-test:generateContent": `{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"call_1","name":"weather","args":{"city":"Paris"}}}]},"finishReason":"STOP"}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, responses[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	requests := map[string]string{
+		"/v1/chat/completions": `{"model":"m","stream":true,"messages":[{"role":"user","content":"Weather in Paris?"}]}`,
+		"/v1/messages":         `{"model":"m","stream":true,"messages":[{"role":"user","content":"Weather in Paris?"}]}`,
+		"/v1beta/models/gemini-test:generateContent": `{"contents":[{"parts":[{"text":"Weather in Paris?"}]}]}`,
+	}
+	for path, body := range requests {
+		s := openTestStore(t)
+		client := NewClient(s, WithErrorHandler(func(err error) { t.Errorf("%s: record: %v", path, err) }))
+		post(t, client, srv.URL+path, body)
+
+		intents, err := s.ListIntents(context.Background(), 10)
+		if err != nil || len(intents) != 1 {
+			t.Fatalf("%s: expected one intent, got %d (%v)", path, len(intents), err)
+		}
+		var meta callMeta
+		if err := json.Unmarshal(intents[0].Meta, &meta); err != nil {
+			t.Fatalf("%s: decode meta: %v", path, err)
+		}
+		want := []ToolCall{{ID: "call_1", Name: "weather", Arguments: `{"city":"Paris"}`}}
+		if !reflect.DeepEqual(meta.ToolCalls, want) {
+			t.Fatalf("%s: expected tool calls %+v, got %+v", path, want, meta.ToolCalls)
+		}
+		if got := intents[0].Response; got != `weather({"city":"Paris"})` {
+			t.Fatalf("%s: expected the call as the response, got %q", path, got)
+		}
+	}
+}
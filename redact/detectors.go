@@ -0,0 +1,72 @@
+package redact
+
+import "regexp"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// apiKeyPattern matches widely used credential formats: OpenAI and
+	// Anthropic ("sk-"), AWS access key IDs, GitHub tokens, Slack tokens, and
+	// Google API keys.
+	apiKeyPattern = regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_\-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9\-]{10,}|AIza[0-9A-Za-z_\-]{35})`)
+
+	cardCandidate = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+)
+
+// Default returns the built-in detectors: API keys, then emails, then
+// payment cards.
+func Default() []Detector {
+	return []Detector{APIKey(), Email(), CreditCard()}
+}
+
+// Email detects email addresses.
+func Email() Detector {
+	return Regexp("email", emailPattern)
+}
+
+// APIKey detects common API key and access token formats.
+func APIKey() Detector {
+	return Regexp("api_key", apiKeyPattern)
+}
+
+// CreditCard detects 13 to 19 digit card numbers, optionally grouped with
+// spaces or dashes, that pass the Luhn check.
+func CreditCard() Detector {
+	return creditCardDetector{}
+}
+
+type creditCardDetector struct{}
+
+func (creditCardDetector) Kind() string { return "credit_card" }
+
+func (creditCardDetector) Find(text string) [][2]int {
+	var spans [][2]int
+	for _, loc := range cardCandidate.FindAllStringIndex(text, -1) {
+		if luhnValid(text[loc[0]:loc[1]]) {
+			spans = append(spans, [2]int{loc[0], loc[1]})
+		}
+	}
+	return spans
+}
+
+// luhnValid applies the Luhn checksum to the digits of s.
+func luhnValid(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,191 @@
+// Package redact detects and masks sensitive text such as email addresses,
+// API keys, and payment card numbers in intent records before they are stored.
+//
+// Masking rewrites the prompt and response, so it must happen before the
+// record is hashed or signed; store.WithRedactor applies a Redactor on insert.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// MetaKey is the meta key holding the redaction manifest.
+const MetaKey = "redactions"
+
+// Detector finds spans of one kind of sensitive text.
+type Detector interface {
+	// Kind names what the detector finds, e.g. "email". It appears in masks
+	// and in the manifest.
+	Kind() string
+	// Find returns the [start, end) byte offsets of each match in text.
+	Find(text string) [][2]int
+}
+
+// Redaction records how many spans of one kind were masked in one field. The
+// masked text itself is never recorded.
+type Redaction struct {
+	Field string `json:"field"`
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// Redactor masks the spans found by its detectors.
+type Redactor struct {
+	detectors []Detector
+}
+
+// New returns a Redactor using detectors, or Default() when none are given.
+// When spans overlap, the earlier detector wins.
+func New(detectors ...Detector) *Redactor {
+	if len(detectors) == 0 {
+		detectors = Default()
+	}
+	return &Redactor{detectors: detectors}
+}
+
+// Mask returns text with every detected span replaced by "[REDACTED:<kind>]",
+// and the number of spans masked per kind.
+func (r *Redactor) Mask(text string) (string, map[string]int) {
+	type span struct {
+		start, end int
+		kind       string
+		order      int
+	}
+	var spans []span
+	for i, d := range r.detectors {
+		for _, loc := range d.Find(text) {
+			spans = append(spans, span{start: loc[0], end: loc[1], kind: d.Kind(), order: i})
+		}
+	}
+	if len(spans) == 0 {
+		return text, nil
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].order != spans[j].order {
+			return spans[i].order < spans[j].order
+		}
+		return spans[i].start < spans[j].start
+	})
+
+	// Keep spans in detector priority order, dropping any that overlap one
+	// already kept, then apply them left to right.
+	var kept []span
+	for _, s := range spans {
+		overlaps := false
+		for _, k := range kept {
+			if s.start < k.end && k.start < s.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, s)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].start < kept[j].start })
+
+	var b strings.Builder
+	counts := make(map[string]int)
+	last := 0
+	for _, s := range kept {
+		b.WriteString(text[last:s.start])
+		b.WriteString("[REDACTED:" + s.kind + "]")
+		last = s.end
+		counts[s.kind]++
+	}
+	b.WriteString(text[last:])
+	return b.String(), counts
+}
+
+// Redact masks record's title, prompt, and response and appends the resulting
+// Redactions to the manifest under MetaKey. changed reports whether anything
+// was masked; the hash and signature are left for the caller to recompute.
+func (r *Redactor) Redact(record model.IntentRecord) (model.IntentRecord, bool, error) {
+	var redactions []Redaction
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"title", &record.Title},
+		{"prompt", &record.Prompt},
+		{"response", &record.Response},
+	}
+	for _, f := range fields {
+		masked, counts := r.Mask(*f.value)
+		if len(counts) == 0 {
+			continue
+		}
+		*f.value = masked
+		kinds := make([]string, 0, len(counts))
+		for kind := range counts {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			redactions = append(redactions, Redaction{Field: f.name, Kind: kind, Count: counts[kind]})
+		}
+	}
+	if len(redactions) == 0 {
+		return record, false, nil
+	}
+
+	meta, err := record.ParsedMeta()
+	if err != nil {
+		return record, false, err
+	}
+	existing, err := Manifest(record)
+	if err != nil {
+		return record, false, err
+	}
+	if err := meta.Set(MetaKey, append(existing, redactions...)); err != nil {
+		return record, false, err
+	}
+	if record.Meta, err = meta.Raw(); err != nil {
+		return record, false, err
+	}
+	return record, true, nil
+}
+
+// Manifest returns the redactions recorded in record's meta.
+func Manifest(record model.IntentRecord) ([]Redaction, error) {
+	meta, err := record.ParsedMeta()
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := meta[MetaKey]
+	if !ok {
+		return nil, nil
+	}
+	var redactions []Redaction
+	if err := json.Unmarshal(raw, &redactions); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", MetaKey, err)
+	}
+	return redactions, nil
+}
+
+// regexpDetector finds matches of a regular expression.
+type regexpDetector struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// Regexp returns a Detector reporting every match of re as kind.
+func Regexp(kind string, re *regexp.Regexp) Detector {
+	return regexpDetector{kind: kind, re: re}
+}
+
+func (d regexpDetector) Kind() string { return d.kind }
+
+func (d regexpDetector) Find(text string) [][2]int {
+	var spans [][2]int
+	for _, loc := range d.re.FindAllStringIndex(text, -1) {
+		spans = append(spans, [2]int{loc[0], loc[1]})
+	}
+	return spans
+}
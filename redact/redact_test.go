@@ -0,0 +1,69 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestMaskDefaultDetectors(t *testing.T) {
+	r := New()
+	text := "mail bob@example.com, key sk-abcdefghijklmnopqrstuvwx, card 4111 1111 1111 1111, order 1234567890123"
+	masked, counts := r.Mask(text)
+
+	for _, secret := range []string{"bob@example.com", "sk-abcdef", "4111 1111"} {
+		if strings.Contains(masked, secret) {
+			t.Fatalf("expected %q to be masked in %q", secret, masked)
+		}
+	}
+	if !strings.Contains(masked, "order 1234567890123") {
+		t.Fatalf("expected non-Luhn digits to be kept, got %q", masked)
+	}
+	if counts["email"] != 1 || counts["api_key"] != 1 || counts["credit_card"] != 1 {
+		t.Fatalf("unexpected counts %v", counts)
+	}
+	if again, counts := r.Mask(masked); again != masked || len(counts) != 0 {
+		t.Fatalf("expected masking to be idempotent, got %q %v", again, counts)
+	}
+}
+
+func TestMaskOverlapPrefersEarlierDetector(t *testing.T) {
+	r := New(Email(), APIKey())
+	masked, counts := r.Mask("sk-aaaaaaaaaaaaaaaaaaaaaaaa@example.com")
+	if masked != "[REDACTED:email]" || counts["api_key"] != 0 {
+		t.Fatalf("expected the email detector to win, got %q %v", masked, counts)
+	}
+}
+
+func TestRedactWritesManifest(t *testing.T) {
+	record := model.IntentRecord{
+		Prompt:   "contact alice@example.com or carol@example.com",
+		Response: "ok",
+		Meta:     json.RawMessage(`{"model":"gpt"}`),
+	}
+	redacted, changed, err := New().Redact(record)
+	if err != nil {
+		t.Fatalf("redact: %v", err)
+	}
+	if !changed || redacted.Prompt != "contact [REDACTED:email] or [REDACTED:email]" {
+		t.Fatalf("unexpected redaction %q", redacted.Prompt)
+	}
+	manifest, err := Manifest(redacted)
+	if err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if len(manifest) != 1 || manifest[0] != (Redaction{Field: "prompt", Kind: "email", Count: 2}) {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	meta, _ := redacted.ParsedMeta()
+	if m, _ := meta.Model(); m != "gpt" {
+		t.Fatalf("expected existing meta to be kept, got %s", redacted.Meta)
+	}
+
+	clean := model.IntentRecord{Prompt: "nothing here", Response: "ok"}
+	if _, changed, err := New().Redact(clean); err != nil || changed {
+		t.Fatalf("expected clean record to be unchanged, got %v %v", changed, err)
+	}
+}
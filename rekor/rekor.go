@@ -0,0 +1,292 @@
+// Package rekor publishes intent hashes and checkpoint Merkle roots to a
+// Sigstore Rekor transparency log and verifies the stored inclusion proofs
+// offline, against nothing but the log's public key.
+//
+// Each subject is uploaded as a hashedrekord entry: the SHA-256 digest of the
+// subject string, signed with the caller's ECDSA or RSA key. The log's
+// response (the entry, its signed entry timestamp, and its inclusion proof
+// with a signed checkpoint) is stored as-is:
+//
+//	client := rekor.New("")
+//	e, err := rekor.PublishCheckpoint(ctx, s, client, signer, cp)
+//	// later, offline
+//	err = rekor.VerifyStored(e, logKey)
+package rekor
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// DefaultURL is the public Sigstore Rekor instance.
+const DefaultURL = "https://rekor.sigstore.dev"
+
+// DefaultTimeout bounds each request unless the client is replaced with
+// WithHTTPClient.
+const DefaultTimeout = 30 * time.Second
+
+const (
+	entriesPath      = "/api/v1/log/entries"
+	maxResponseBytes = 1 << 20
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the client used to reach the log.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.http = hc
+		}
+	}
+}
+
+// WithPublicKey makes Upload verify each entry against the log's key before
+// returning it, so only entries with valid proofs are stored.
+func WithPublicKey(pub crypto.PublicKey) Option {
+	return func(c *Client) {
+		c.logKey = pub
+	}
+}
+
+// Client uploads entries to one Rekor log.
+type Client struct {
+	url    string
+	http   *http.Client
+	logKey crypto.PublicKey
+}
+
+// New returns a Client for the log at url, or DefaultURL if url is empty.
+func New(url string, opts ...Option) *Client {
+	if url == "" {
+		url = DefaultURL
+	}
+	c := &Client{url: strings.TrimSuffix(url, "/"), http: &http.Client{Timeout: DefaultTimeout}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// URL returns the log's URL.
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Entry is a log entry as Rekor returns it.
+type Entry struct {
+	UUID string `json:"uuid"`
+	// Body is the base64 canonical entry the log hashed as a leaf.
+	Body           string       `json:"body"`
+	IntegratedTime int64        `json:"integratedTime"`
+	LogID          string       `json:"logID"`
+	LogIndex       int64        `json:"logIndex"`
+	Verification   Verification `json:"verification"`
+}
+
+// Verification holds the log's promises about an entry.
+type Verification struct {
+	InclusionProof *InclusionProof `json:"inclusionProof,omitempty"`
+	// SignedEntryTimestamp is the log's base64 signature over the entry.
+	SignedEntryTimestamp string `json:"signedEntryTimestamp"`
+}
+
+// InclusionProof proves an entry is a leaf of the tree a checkpoint signs.
+type InclusionProof struct {
+	// Checkpoint is the signed note committing to TreeSize and RootHash.
+	Checkpoint string   `json:"checkpoint"`
+	Hashes     []string `json:"hashes"`
+	LogIndex   int64    `json:"logIndex"`
+	RootHash   string   `json:"rootHash"`
+	TreeSize   int64    `json:"treeSize"`
+}
+
+// Digest returns the digest published for subject.
+func Digest(subject string) []byte {
+	sum := sha256.Sum256([]byte(subject))
+	return sum[:]
+}
+
+// hashedRekord is a proposed or canonical hashedrekord v0.0.1 entry.
+type hashedRekord struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Spec       hashedRekordSpec `json:"spec"`
+}
+
+type hashedRekordSpec struct {
+	Data struct {
+		Hash struct {
+			Algorithm string `json:"algorithm"`
+			Value     string `json:"value"`
+		} `json:"hash"`
+	} `json:"data"`
+	Signature struct {
+		Content   string `json:"content"`
+		PublicKey struct {
+			Content string `json:"content"`
+		} `json:"publicKey"`
+	} `json:"signature"`
+}
+
+// Upload signs digest with signer and adds it to the log. Uploading a digest
+// the log already holds returns the existing entry.
+func (c *Client) Upload(ctx context.Context, digest []byte, signer crypto.Signer) (Entry, error) {
+	if len(digest) != sha256.Size {
+		return Entry{}, errors.New("digest must be SHA-256")
+	}
+	switch signer.Public().(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey:
+	default:
+		return Entry{}, fmt.Errorf("hashedrekord entries need an ECDSA or RSA key, not %T", signer.Public())
+	}
+	sig, err := signer.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return Entry{}, fmt.Errorf("sign digest: %w", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return Entry{}, err
+	}
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	proposed := hashedRekord{APIVersion: "0.0.1", Kind: "hashedrekord"}
+	proposed.Spec.Data.Hash.Algorithm = "sha256"
+	proposed.Spec.Data.Hash.Value = hex.EncodeToString(digest)
+	proposed.Spec.Signature.Content = base64.StdEncoding.EncodeToString(sig)
+	proposed.Spec.Signature.PublicKey.Content = base64.StdEncoding.EncodeToString(pub)
+	body, err := json.Marshal(proposed)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+entriesPath, bytes.NewReader(body))
+	if err != nil {
+		return Entry{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Entry{}, fmt.Errorf("upload entry: %w", err)
+	}
+	defer resp.Body.Close()
+	var e Entry
+	switch loc := resp.Header.Get("Location"); {
+	case resp.StatusCode == http.StatusCreated:
+		e, err = decodeEntry(resp.Body)
+	case resp.StatusCode == http.StatusConflict && loc != "":
+		// The log already has this entry; fetch it.
+		e, err = c.get(ctx, loc)
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return Entry{}, fmt.Errorf("rekor returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	if c.logKey != nil {
+		if err := Verify(e, digest, c.logKey); err != nil {
+			return Entry{}, err
+		}
+	}
+	return e, nil
+}
+
+// get fetches an entry named by a Location header, which must be on this
+// log.
+func (c *Client) get(ctx context.Context, loc string) (Entry, error) {
+	if strings.HasPrefix(loc, "/") {
+		loc = c.url + loc
+	}
+	if !strings.HasPrefix(loc, c.url+"/") {
+		return Entry{}, fmt.Errorf("existing entry is on another host: %s", loc)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return Entry{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Entry{}, fmt.Errorf("fetch entry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Entry{}, fmt.Errorf("rekor returned %s", resp.Status)
+	}
+	return decodeEntry(resp.Body)
+}
+
+// decodeEntry decodes Rekor's {uuid: entry} response.
+func decodeEntry(r io.Reader) (Entry, error) {
+	var entries map[string]Entry
+	if err := json.NewDecoder(io.LimitReader(r, maxResponseBytes)).Decode(&entries); err != nil {
+		return Entry{}, fmt.Errorf("decode entry: %w", err)
+	}
+	if len(entries) != 1 {
+		return Entry{}, fmt.Errorf("decode entry: %d entries, want 1", len(entries))
+	}
+	var e Entry
+	for uuid, entry := range entries {
+		e = entry
+		e.UUID = uuid
+	}
+	return e, nil
+}
+
+// PublishIntent uploads the hash of the intent with id and stores the entry.
+func PublishIntent(ctx context.Context, s *store.Store, c *Client, signer crypto.Signer, id string) (store.TransparencyEntry, error) {
+	record, err := s.GetIntent(ctx, id)
+	if err != nil {
+		return store.TransparencyEntry{}, err
+	}
+	return publish(ctx, s, c, signer, record.Hash, 0)
+}
+
+// PublishCheckpoint uploads cp's Merkle root and stores the entry.
+func PublishCheckpoint(ctx context.Context, s *store.Store, c *Client, signer crypto.Signer, cp store.Checkpoint) (store.TransparencyEntry, error) {
+	if cp.MerkleRoot == "" {
+		return store.TransparencyEntry{}, errors.New("checkpoint has no merkle root")
+	}
+	return publish(ctx, s, c, signer, cp.MerkleRoot, cp.Seq)
+}
+
+func publish(ctx context.Context, s *store.Store, c *Client, signer crypto.Signer, subject string, seq int64) (store.TransparencyEntry, error) {
+	e, err := c.Upload(ctx, Digest(subject), signer)
+	if err != nil {
+		return store.TransparencyEntry{}, err
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return store.TransparencyEntry{}, err
+	}
+	return s.SaveTransparencyEntry(ctx, store.TransparencyEntry{
+		Subject:       subject,
+		CheckpointSeq: seq,
+		LogURL:        c.url,
+		UUID:          e.UUID,
+		LogIndex:      e.LogIndex,
+		IntegratedAt:  time.Unix(e.IntegratedTime, 0).UTC().Format(time.RFC3339Nano),
+		Entry:         raw,
+	})
+}
@@ -0,0 +1,301 @@
+package rekor
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// testLog is a minimal Rekor: it appends hashedrekord bodies to an RFC 6962
+// tree and answers with a signed entry timestamp and inclusion proof.
+type testLog struct {
+	t   *testing.T
+	key *ecdsa.PrivateKey
+	url string
+
+	mu     sync.Mutex
+	bodies []string
+	uuids  map[string]int
+}
+
+func newTestLog(t *testing.T) *testLog {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate log key: %v", err)
+	}
+	l := &testLog{t: t, key: key, uuids: make(map[string]int)}
+	// Earlier entries by others, so the proof is not trivial.
+	for i := range 5 {
+		l.bodies = append(l.bodies, fmt.Sprintf(`{"kind":"other","n":%d}`, i))
+	}
+	srv := httptest.NewServer(l)
+	t.Cleanup(srv.Close)
+	l.url = srv.URL
+	return l
+}
+
+func (l *testLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == entriesPath:
+		var proposed hashedRekord
+		if err := json.NewDecoder(r.Body).Decode(&proposed); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, _ := json.Marshal(proposed)
+		uuid := leafUUID(body)
+		if _, ok := l.uuids[uuid]; ok {
+			w.Header().Set("Location", entriesPath+"/"+uuid)
+			http.Error(w, "entry already exists", http.StatusConflict)
+			return
+		}
+		l.uuids[uuid] = len(l.bodies)
+		l.bodies = append(l.bodies, string(body))
+		w.WriteHeader(http.StatusCreated)
+		l.writeEntry(w, uuid)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, entriesPath+"/"):
+		uuid := strings.TrimPrefix(r.URL.Path, entriesPath+"/")
+		if _, ok := l.uuids[uuid]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		l.writeEntry(w, uuid)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func leafUUID(body []byte) string {
+	sum := sha256.Sum256(append([]byte{0}, body...))
+	return hex.EncodeToString(sum[:])
+}
+
+func (l *testLog) writeEntry(w http.ResponseWriter, uuid string) {
+	index := l.uuids[uuid]
+	body := l.bodies[index]
+	tree := chain.NewMerkleTree(l.bodies)
+	proof, err := tree.MerkleProof(body)
+	if err != nil {
+		l.t.Errorf("proof: %v", err)
+	}
+	logID := l.logID()
+	e := Entry{
+		Body:           base64.StdEncoding.EncodeToString([]byte(body)),
+		IntegratedTime: 1700000000 + int64(index),
+		LogID:          logID,
+		LogIndex:       int64(index) + 1000,
+	}
+	set, _ := json.Marshal(struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+	}{e.Body, e.IntegratedTime, e.LogID, e.LogIndex})
+	e.Verification.SignedEntryTimestamp = base64.StdEncoding.EncodeToString(l.sign(set))
+
+	root, _ := hex.DecodeString(tree.Root())
+	note := fmt.Sprintf("rekor.test - 42\n%d\n%s\nTimestamp: 1\n", tree.Size(), base64.StdEncoding.EncodeToString(root))
+	id, _ := hex.DecodeString(logID)
+	sig := append(id[:4:4], l.sign([]byte(note))...)
+	e.Verification.InclusionProof = &InclusionProof{
+		Checkpoint: note + "\n— rekor.test " + base64.StdEncoding.EncodeToString(sig) + "\n",
+		Hashes:     proof.Path,
+		LogIndex:   int64(index),
+		RootHash:   tree.Root(),
+		TreeSize:   int64(tree.Size()),
+	}
+	json.NewEncoder(w).Encode(map[string]Entry{uuid: e})
+}
+
+func (l *testLog) sign(msg []byte) []byte {
+	sum := sha256.Sum256(msg)
+	sig, err := ecdsa.SignASN1(rand.Reader, l.key, sum[:])
+	if err != nil {
+		l.t.Fatalf("sign: %v", err)
+	}
+	return sig
+}
+
+func (l *testLog) logID() string {
+	id, _ := keyID(&l.key.PublicKey)
+	return hex.EncodeToString(id)
+}
+
+func newSigner(t *testing.T) crypto.Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func openStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func TestPublishCheckpointVerifiesOffline(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLog(t)
+	s := openStore(t)
+	if _, err := s.AppendIntent(ctx, model.IntentRecord{Author: "alice", SourceType: "cli", Prompt: "p", Response: "r"}); err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	cp, err := s.WriteCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+
+	client := New(l.url, WithPublicKey(&l.key.PublicKey))
+	e, err := PublishCheckpoint(ctx, s, client, newSigner(t), cp)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if e.Subject != cp.MerkleRoot || e.CheckpointSeq != cp.Seq || e.LogIndex != 1005 || e.IntegratedAt != time.Unix(1700000005, 0).UTC().Format(time.RFC3339Nano) {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	stored, err := s.TransparencyEntries(ctx, cp.MerkleRoot)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("expected one stored entry, got %d (%v)", len(stored), err)
+	}
+	if err := VerifyStored(stored[0], &l.key.PublicKey); err != nil {
+		t.Fatalf("verify stored: %v", err)
+	}
+
+	other := newTestLog(t)
+	if err := VerifyStored(stored[0], &other.key.PublicKey); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("expected ErrInvalidEntry for another log's key, got %v", err)
+	}
+	stored[0].Subject = "not-the-root"
+	if err := VerifyStored(stored[0], &l.key.PublicKey); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("expected ErrInvalidEntry for another subject, got %v", err)
+	}
+}
+
+func TestPublishIntentTwiceReturnsExistingEntry(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLog(t)
+	s := openStore(t)
+	record, err := s.AppendIntent(ctx, model.IntentRecord{Author: "alice", SourceType: "cli", Prompt: "p", Response: "r"})
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	client := New(l.url, WithPublicKey(&l.key.PublicKey))
+	signer, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	first, err := PublishIntent(ctx, s, client, signer, record.ID)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	// RSA PKCS #1 v1.5 signatures are deterministic, so the second upload
+	// proposes the same body and takes the conflict path.
+	again, err := PublishIntent(ctx, s, client, signer, record.ID)
+	if err != nil {
+		t.Fatalf("publish again: %v", err)
+	}
+	if again.ID != first.ID || again.UUID != first.UUID {
+		t.Fatalf("expected the existing entry %+v, got %+v", first, again)
+	}
+	if entries, _ := s.TransparencyEntries(ctx, record.Hash); len(entries) != 1 {
+		t.Fatalf("expected one stored entry, got %d", len(entries))
+	}
+	if _, err := client.get(ctx, "https://elsewhere.test"+entriesPath+"/"+first.UUID); err == nil {
+		t.Fatal("expected error for a Location on another host")
+	}
+}
+
+func TestVerifyRejectsTamperedProof(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLog(t)
+	digest := Digest("subject")
+	e, err := New(l.url).Upload(ctx, digest, newSigner(t))
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := Verify(e, digest, &l.key.PublicKey); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	tampered := e
+	proof := *e.Verification.InclusionProof
+	proof.Hashes = append([]string{strings.Repeat("00", 32)}, proof.Hashes[1:]...)
+	tampered.Verification.InclusionProof = &proof
+	if err := Verify(tampered, digest, &l.key.PublicKey); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("expected ErrInvalidEntry for a bad path, got %v", err)
+	}
+
+	tampered = e
+	tampered.IntegratedTime++
+	if err := Verify(tampered, digest, &l.key.PublicKey); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("expected ErrInvalidEntry for a bad timestamp, got %v", err)
+	}
+
+	tampered = e
+	proof = *e.Verification.InclusionProof
+	proof.Checkpoint = strings.Replace(proof.Checkpoint, "Timestamp: 1", "Timestamp: 2", 1)
+	tampered.Verification.InclusionProof = &proof
+	if err := Verify(tampered, digest, &l.key.PublicKey); !errors.Is(err, ErrInvalidEntry) {
+		t.Fatalf("expected ErrInvalidEntry for a bad checkpoint, got %v", err)
+	}
+}
+
+func TestUploadRejectsEd25519(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := New("http://unused.test").Upload(context.Background(), Digest("x"), priv); err == nil {
+		t.Fatal("expected error for an Ed25519 signer")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key := newSigner(t)
+	der, _ := x509.MarshalPKIXPublicKey(key.Public())
+	pub, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !key.Public().(*ecdsa.PublicKey).Equal(pub) {
+		t.Fatal("parsed key differs")
+	}
+	if _, err := ParsePublicKey([]byte("nope")); err == nil {
+		t.Fatal("expected error for non-PEM input")
+	}
+}
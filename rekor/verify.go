@@ -0,0 +1,214 @@
+package rekor
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// ErrInvalidEntry is wrapped by Verify errors that mean the entry does not
+// prove the digest was logged.
+var ErrInvalidEntry = errors.New("invalid transparency log entry")
+
+// ParsePublicKey parses a log's PEM-encoded public key, as served at
+// /api/v1/log/publicKey. Pin it rather than fetching it at verification time.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block in public key")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// Verify checks offline that e logs digest: the body names the digest and is
+// signed by the key it carries, the log signed the entry timestamp, and the
+// inclusion proof leads to a root in a checkpoint the log signed.
+func Verify(e Entry, digest []byte, logKey crypto.PublicKey) error {
+	body, err := base64.StdEncoding.DecodeString(e.Body)
+	if err != nil {
+		return fmt.Errorf("%w: body is not base64", ErrInvalidEntry)
+	}
+	if err := verifyBody(body, digest); err != nil {
+		return err
+	}
+	logID, err := keyID(logKey)
+	if err != nil {
+		return err
+	}
+	if e.LogID != hex.EncodeToString(logID) {
+		return fmt.Errorf("%w: entry is from log %s, not this key's", ErrInvalidEntry, e.LogID)
+	}
+	if err := verifySET(e, logKey); err != nil {
+		return err
+	}
+
+	p := e.Verification.InclusionProof
+	if p == nil {
+		return fmt.Errorf("%w: no inclusion proof", ErrInvalidEntry)
+	}
+	if err := chain.VerifyMerkleProof(p.RootHash, chain.MerkleProof{
+		Hash:     string(body),
+		Index:    int(p.LogIndex),
+		TreeSize: int(p.TreeSize),
+		Path:     p.Hashes,
+	}); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
+	return verifyCheckpoint(p, logKey, logID[:4])
+}
+
+// VerifyStored checks a stored entry against its subject with Verify.
+func VerifyStored(e store.TransparencyEntry, logKey crypto.PublicKey) error {
+	var entry Entry
+	if err := json.Unmarshal(e.Entry, &entry); err != nil {
+		return fmt.Errorf("decode stored entry: %w", err)
+	}
+	return Verify(entry, Digest(e.Subject), logKey)
+}
+
+// verifyBody checks that a hashedrekord body names digest and that its
+// signature over digest verifies with its public key.
+func verifyBody(body, digest []byte) error {
+	var rec hashedRekord
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return fmt.Errorf("%w: body: %v", ErrInvalidEntry, err)
+	}
+	if rec.Kind != "hashedrekord" {
+		return fmt.Errorf("%w: entry kind is %q", ErrInvalidEntry, rec.Kind)
+	}
+	if rec.Spec.Data.Hash.Algorithm != "sha256" || rec.Spec.Data.Hash.Value != hex.EncodeToString(digest) {
+		return fmt.Errorf("%w: entry does not log digest %x", ErrInvalidEntry, digest)
+	}
+	pemKey, err := base64.StdEncoding.DecodeString(rec.Spec.Signature.PublicKey.Content)
+	if err != nil {
+		return fmt.Errorf("%w: public key is not base64", ErrInvalidEntry)
+	}
+	pub, err := ParsePublicKey(pemKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(rec.Spec.Signature.Content)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not base64", ErrInvalidEntry)
+	}
+	if !verifyDigest(pub, digest, sig) {
+		return fmt.Errorf("%w: entry signature does not verify", ErrInvalidEntry)
+	}
+	return nil
+}
+
+// verifySET checks the log's signature over the canonical JSON of the entry.
+func verifySET(e Entry, logKey crypto.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(e.Verification.SignedEntryTimestamp)
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("%w: missing signed entry timestamp", ErrInvalidEntry)
+	}
+	// Fields in key order, as canonical JSON requires.
+	payload, err := json.Marshal(struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+	}{e.Body, e.IntegratedTime, e.LogID, e.LogIndex})
+	if err != nil {
+		return err
+	}
+	if !verifyMessage(logKey, payload, sig) {
+		return fmt.Errorf("%w: signed entry timestamp does not verify", ErrInvalidEntry)
+	}
+	return nil
+}
+
+// verifyCheckpoint checks that the proof's checkpoint is a note signed by the
+// log committing to the proof's tree size and root.
+//
+//	<origin>
+//	<tree size>
+//	<base64 root hash>
+//	[other lines]
+//
+//	— <name> <base64(key hint || signature)>
+func verifyCheckpoint(p *InclusionProof, logKey crypto.PublicKey, hint []byte) error {
+	text, sigs, ok := strings.Cut(p.Checkpoint, "\n\n")
+	if !ok {
+		return fmt.Errorf("%w: checkpoint is not a signed note", ErrInvalidEntry)
+	}
+	text += "\n"
+	lines := strings.Split(text, "\n")
+	if len(lines) < 4 {
+		return fmt.Errorf("%w: checkpoint is too short", ErrInvalidEntry)
+	}
+	size, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil || size != p.TreeSize {
+		return fmt.Errorf("%w: checkpoint tree size does not match the proof", ErrInvalidEntry)
+	}
+	root, err := hex.DecodeString(p.RootHash)
+	if err != nil || lines[2] != base64.StdEncoding.EncodeToString(root) {
+		return fmt.Errorf("%w: checkpoint root does not match the proof", ErrInvalidEntry)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(sigs, "\n"), "\n") {
+		rest, ok := strings.CutPrefix(line, "— ")
+		if !ok {
+			continue
+		}
+		_, encoded, ok := strings.Cut(rest, " ")
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) <= len(hint) || !bytes.Equal(raw[:len(hint)], hint) {
+			continue
+		}
+		if verifyMessage(logKey, []byte(text), raw[len(hint):]) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: checkpoint is not signed by the log", ErrInvalidEntry)
+}
+
+// keyID is the log ID of a key: the SHA-256 of its PKIX encoding.
+func keyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("log key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return sum[:], nil
+}
+
+// verifyMessage checks sig over msg, hashing with SHA-256 where the key type
+// needs a digest.
+func verifyMessage(pub crypto.PublicKey, msg, sig []byte) bool {
+	if k, ok := pub.(ed25519.PublicKey); ok {
+		return ed25519.Verify(k, msg, sig)
+	}
+	sum := sha256.Sum256(msg)
+	return verifyDigest(pub, sum[:], sig)
+}
+
+// verifyDigest checks sig over a SHA-256 digest.
+func verifyDigest(pub crypto.PublicKey, digest, sig []byte) bool {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest, sig)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, sig) == nil
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+// Package replicate copies a store to object storage and restores it.
+//
+// A Replicator takes a consistent snapshot of the database (see
+// store.Store.Snapshot) every interval, gzips it, and uploads it to a Target
+// alongside a JSON manifest recording its SHA-256 and the chain heads it
+// contains:
+//
+//	<prefix>snapshot-20240102T150405.000000000Z.db.gz
+//	<prefix>snapshot-20240102T150405.000000000Z.json
+//
+// Old snapshots are pruned according to a Retention policy. Restore downloads
+// a snapshot, checks its digest, and re-verifies every chain before handing
+// the file back, so a corrupted or tampered backup is never mistaken for a
+// good one. The manifest is authenticated only when the replicator and
+// Restore share a key (WithManifestKey and RequireManifestKey); otherwise
+// someone able to write to the target can replace a snapshot and its
+// manifest together.
+//
+// Snapshots bound data loss to the interval. For continuous WAL-level
+// streaming, run Litestream (https://litestream.io) against the database
+// file; the store's WAL mode and checkpointing are compatible with it, and
+// Restore can still be used to verify what it recovers by pointing a Dir
+// target at a snapshot directory.
+//
+// Targets are provided for S3-compatible object storage (NewS3) and for
+// local directories (Dir).
+package replicate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// DefaultInterval is the pause between snapshots unless overridden with
+// WithInterval.
+const DefaultInterval = time.Hour
+
+// Object key layout.
+const (
+	snapshotPrefix   = "snapshot-"
+	snapshotSuffix   = ".db.gz"
+	manifestSuffix   = ".json"
+	snapshotTimeForm = "20060102T150405.000000000Z"
+)
+
+// Retention decides which snapshots Prune keeps. A snapshot is kept if it is
+// one of the newest Keep or younger than MaxAge; zero values disable each
+// rule, and the newest snapshot is always kept.
+type Retention struct {
+	Keep   int
+	MaxAge time.Duration
+}
+
+// Manifest describes one uploaded snapshot.
+type Manifest struct {
+	// Name identifies the snapshot, e.g. "snapshot-20240102T150405.000000000Z".
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// SHA256 and Size describe the uncompressed database file.
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	// Heads maps each chain to its head hash at snapshot time.
+	Heads map[string]string `json:"heads"`
+	// MAC is the hex HMAC-SHA256 of the manifest with MAC empty, under the
+	// key set with WithManifestKey; it is empty when no key is set.
+	MAC string `json:"mac,omitempty"`
+}
+
+// sum returns the HMAC-SHA256 of m with MAC cleared under key.
+func (m Manifest) sum(key []byte) ([]byte, error) {
+	m.MAC = ""
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return mac.Sum(nil), nil
+}
+
+// Option configures a Replicator.
+type Option func(*Replicator)
+
+// WithPrefix places every object under prefix, e.g. "yanzi/prod/".
+func WithPrefix(prefix string) Option {
+	return func(r *Replicator) { r.prefix = prefix }
+}
+
+// WithInterval sets the pause between snapshots in Run; values <= 0 keep the
+// default.
+func WithInterval(d time.Duration) Option {
+	return func(r *Replicator) {
+		if d > 0 {
+			r.interval = d
+		}
+	}
+}
+
+// WithRetention prunes old snapshots after each upload in Run.
+func WithRetention(policy Retention) Option {
+	return func(r *Replicator) { r.retention = policy }
+}
+
+// WithManifestKey authenticates each uploaded manifest with an HMAC-SHA256
+// under key, so Restore with RequireManifestKey can tell a manifest written
+// by the replicator from one edited in the bucket. Without it manifests
+// carry no MAC, and anyone who can write to the target can replace a
+// snapshot together with its manifest.
+func WithManifestKey(key []byte) Option {
+	return func(r *Replicator) { r.manifestKey = bytes.Clone(key) }
+}
+
+// WithLogger logs uploads, pruning, and failures to l.
+func WithLogger(l *slog.Logger) Option {
+	return func(r *Replicator) {
+		if l != nil {
+			r.logger = l
+		}
+	}
+}
+
+// Replicator uploads snapshots of one store to a Target.
+type Replicator struct {
+	store       *store.Store
+	target      Target
+	prefix      string
+	interval    time.Duration
+	retention   Retention
+	manifestKey []byte
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+// New returns a Replicator copying s to t.
+func New(s *store.Store, t Target, opts ...Option) *Replicator {
+	r := &Replicator{
+		store:    s,
+		target:   t,
+		interval: DefaultInterval,
+		logger:   slog.New(slog.DiscardHandler),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run takes a snapshot immediately and then every interval, pruning after
+// each, until ctx is cancelled. Failures are logged and retried at the next
+// tick; Run returns nil once ctx is done.
+func (r *Replicator) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.SnapshotOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			r.logger.Error("snapshot replication failed", "err", err)
+		} else if _, err := r.Prune(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("snapshot pruning failed", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// SnapshotOnce uploads one snapshot and its manifest. The database is
+// uploaded first, so a manifest never names a missing snapshot.
+func (r *Replicator) SnapshotOnce(ctx context.Context) (Manifest, error) {
+	dir, err := os.MkdirTemp("", "yanzi-snapshot-")
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	created := r.now().UTC()
+	m := Manifest{Name: snapshotPrefix + created.Format(snapshotTimeForm), CreatedAt: created}
+	// Heads are read before the copy; appends in between only extend the
+	// chains, so every recorded head is still present in the snapshot.
+	if m.Heads, err = r.store.ChainHeads(ctx); err != nil {
+		return Manifest{}, fmt.Errorf("read chain heads: %w", err)
+	}
+	dbPath := filepath.Join(dir, "snapshot.db")
+	if err := r.store.Snapshot(ctx, dbPath); err != nil {
+		return Manifest{}, err
+	}
+
+	gzPath := dbPath + ".gz"
+	if m.SHA256, m.Size, err = compressFile(dbPath, gzPath); err != nil {
+		return Manifest{}, fmt.Errorf("compress snapshot: %w", err)
+	}
+	gz, err := os.Open(gzPath)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer gz.Close()
+	info, err := gz.Stat()
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := r.target.Put(ctx, r.prefix+m.Name+snapshotSuffix, gz, info.Size()); err != nil {
+		return Manifest{}, fmt.Errorf("upload snapshot: %w", err)
+	}
+
+	if len(r.manifestKey) > 0 {
+		sum, err := m.sum(r.manifestKey)
+		if err != nil {
+			return Manifest{}, err
+		}
+		m.MAC = hex.EncodeToString(sum)
+	}
+	body, err := json.Marshal(m)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := r.target.Put(ctx, r.prefix+m.Name+manifestSuffix, bytes.NewReader(body), int64(len(body))); err != nil {
+		return Manifest{}, fmt.Errorf("upload manifest: %w", err)
+	}
+	r.logger.Info("snapshot replicated", "name", m.Name, "bytes", m.Size, "compressed_bytes", info.Size())
+	return m, nil
+}
+
+// compressFile gzips src into dst, returning the digest and size of src.
+func compressFile(src, dst string) (string, int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", 0, err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", 0, err
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	zw := gzip.NewWriter(out)
+	n, err := io.Copy(io.MultiWriter(zw, h), in)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := out.Close(); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// Snapshots lists the snapshots under the replicator's prefix, oldest first.
+func (r *Replicator) Snapshots(ctx context.Context) ([]string, error) {
+	return listSnapshots(ctx, r.target, r.prefix)
+}
+
+// listSnapshots returns the names of snapshots whose manifest is present,
+// oldest first.
+func listSnapshots(ctx context.Context, t Target, prefix string) ([]string, error) {
+	objects, err := t.List(ctx, prefix+snapshotPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, obj := range objects {
+		name, ok := strings.CutSuffix(strings.TrimPrefix(obj.Key, prefix), manifestSuffix)
+		if ok {
+			names = append(names, name)
+		}
+	}
+	// Keys embed a fixed-width UTC timestamp, so key order is time order.
+	return names, nil
+}
+
+// snapshotTime parses the creation time embedded in a snapshot name.
+func snapshotTime(name string) (time.Time, bool) {
+	t, err := time.Parse(snapshotTimeForm, strings.TrimPrefix(name, snapshotPrefix))
+	return t, err == nil
+}
+
+// Prune deletes the snapshots the retention policy no longer keeps and
+// returns their names. Without a policy it deletes nothing.
+func (r *Replicator) Prune(ctx context.Context) ([]string, error) {
+	if r.retention.Keep <= 0 && r.retention.MaxAge <= 0 {
+		return nil, nil
+	}
+	names, err := r.Snapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := r.now()
+	var pruned []string
+	for i, name := range names {
+		newest := len(names) - i
+		if newest == 1 {
+			break
+		}
+		if r.retention.Keep > 0 && newest <= r.retention.Keep {
+			continue
+		}
+		if created, ok := snapshotTime(name); r.retention.MaxAge > 0 && ok && now.Sub(created) < r.retention.MaxAge {
+			continue
+		}
+		// The manifest goes first so a partly deleted snapshot is never listed.
+		for _, suffix := range []string{manifestSuffix, snapshotSuffix} {
+			if err := r.target.Delete(ctx, r.prefix+name+suffix); err != nil {
+				return pruned, fmt.Errorf("delete %s: %w", name, err)
+			}
+		}
+		pruned = append(pruned, name)
+	}
+	if len(pruned) > 0 {
+		r.logger.Info("snapshots pruned", "count", len(pruned))
+	}
+	return pruned, nil
+}
@@ -0,0 +1,245 @@
+package replicate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func openTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func appendIntent(t *testing.T, s *store.Store, prompt string) model.IntentRecord {
+	t.Helper()
+	record, err := s.AppendIntent(context.Background(), model.IntentRecord{Author: "alice", SourceType: "cli", Prompt: prompt, Response: "r"})
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	return record
+}
+
+// fakeClock returns a clock advancing one minute per call.
+func fakeClock() func() time.Time {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	target := Dir(t.TempDir())
+	r := New(s, target, WithPrefix("prod/"))
+	r.now = fakeClock()
+
+	appendIntent(t, s, "one")
+	first, err := r.SnapshotOnce(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	last := appendIntent(t, s, "two")
+	second, err := r.SnapshotOnce(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if second.Heads["alice"] != last.Hash {
+		t.Fatalf("expected manifest head %s, got %v", last.Hash, second.Heads)
+	}
+
+	names, err := r.Snapshots(ctx)
+	if err != nil {
+		t.Fatalf("list snapshots: %v", err)
+	}
+	if len(names) != 2 || names[0] != first.Name || names[1] != second.Name {
+		t.Fatalf("expected [%s %s], got %v", first.Name, second.Name, names)
+	}
+
+	path := filepath.Join(t.TempDir(), "restored.db")
+	report, err := Restore(ctx, target, "prod/", "", path)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if report.Manifest.Name != second.Name || !report.Valid() {
+		t.Fatalf("expected a valid restore of %s, got %+v", second.Name, report)
+	}
+	if got := report.Chains["alice"]; got.Head != last.Hash || got.Length != 2 {
+		t.Fatalf("expected chain of 2 ending at %s, got %+v", last.Hash, got)
+	}
+
+	restored, err := store.OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("open restored: %v", err)
+	}
+	defer restored.Close()
+	if _, err := restored.GetIntent(ctx, last.ID); err != nil {
+		t.Fatalf("get restored intent: %v", err)
+	}
+
+	if _, err := Restore(ctx, target, "prod/", first.Name, path); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected restore over an existing file to fail, got %v", err)
+	}
+}
+
+func TestRestoreRejectsTamperedSnapshot(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	dir := t.TempDir()
+	r := New(s, Dir(dir))
+	appendIntent(t, s, "one")
+	m, err := r.SnapshotOnce(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("not a database"))
+	zw.Close()
+	if err := os.WriteFile(filepath.Join(dir, m.Name+snapshotSuffix), buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "restored.db")
+	if _, err := Restore(ctx, Dir(dir), "", m.Name, path); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected ErrInvalidSnapshot, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the rejected file to be removed, got %v", err)
+	}
+}
+
+func TestRestoreRejectsMissingHead(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	dir := t.TempDir()
+	r := New(s, Dir(dir))
+	appendIntent(t, s, "one")
+	m, err := r.SnapshotOnce(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	// A manifest claiming a head the snapshot lacks means records were lost.
+	manifest := filepath.Join(dir, m.Name+manifestSuffix)
+	body, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	body = bytes.Replace(body, []byte(m.Heads["alice"]), []byte(strings.Repeat("0", 64)), 1)
+	if err := os.WriteFile(manifest, body, 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "restored.db")
+	if _, err := Restore(ctx, Dir(dir), "", "", path); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected ErrInvalidSnapshot, got %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	appendIntent(t, s, "one")
+	target := Dir(t.TempDir())
+	r := New(s, target, WithRetention(Retention{Keep: 2, MaxAge: 90 * time.Second}))
+	clock := fakeClock()
+	r.now = clock
+
+	var names []string
+	for i := 0; i < 4; i++ {
+		m, err := r.SnapshotOnce(ctx)
+		if err != nil {
+			t.Fatalf("snapshot: %v", err)
+		}
+		names = append(names, m.Name)
+	}
+	// At prune time the snapshots are 4, 3, 2, and 1 minutes old: the newest
+	// two are kept by count and none is young enough to be kept by age.
+	pruned, err := r.Prune(ctx)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if len(pruned) != 2 || pruned[0] != names[0] || pruned[1] != names[1] {
+		t.Fatalf("expected %v pruned, got %v", names[:2], pruned)
+	}
+	objects, err := target.List(ctx, "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 4 {
+		t.Fatalf("expected 2 snapshots and 2 manifests left, got %v", objects)
+	}
+
+	r.retention = Retention{}
+	if pruned, err := r.Prune(ctx); err != nil || len(pruned) != 0 {
+		t.Fatalf("expected no pruning without a policy, got %v, %v", pruned, err)
+	}
+}
+
+func TestRestoreRequiresManifestKey(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	dir := t.TempDir()
+	key := []byte("manifest-key")
+	appendIntent(t, s, "one")
+	m, err := New(s, Dir(dir), WithManifestKey(key)).SnapshotOnce(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if m.MAC == "" {
+		t.Fatal("expected the manifest to carry a MAC")
+	}
+	if _, err := Restore(ctx, Dir(dir), "", m.Name, filepath.Join(t.TempDir(), "restored.db"), RequireManifestKey(key)); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := Restore(ctx, Dir(dir), "", m.Name, filepath.Join(t.TempDir(), "restored.db"), RequireManifestKey([]byte("other"))); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected ErrInvalidSnapshot under the wrong key, got %v", err)
+	}
+
+	// An edited manifest no longer matches its MAC.
+	manifest := filepath.Join(dir, m.Name+manifestSuffix)
+	body, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	body = bytes.Replace(body, []byte(m.SHA256), []byte(strings.Repeat("0", 64)), 1)
+	if err := os.WriteFile(manifest, body, 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "restored.db")
+	if _, err := Restore(ctx, Dir(dir), "", m.Name, path, RequireManifestKey(key)); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected ErrInvalidSnapshot for an edited manifest, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing written, got %v", err)
+	}
+
+	unsigned, err := New(s, Dir(dir)).SnapshotOnce(ctx)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if _, err := Restore(ctx, Dir(dir), "", unsigned.Name, path, RequireManifestKey(key)); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("expected ErrInvalidSnapshot for a manifest without a MAC, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package replicate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// ErrInvalidSnapshot is returned by Restore when a downloaded snapshot does
+// not match its manifest or one of its chains fails verification.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// RestoreReport describes a restored snapshot.
+type RestoreReport struct {
+	Manifest Manifest
+	// Chains holds the verification report of every chain in the restored
+	// database, keyed by chain name.
+	Chains map[string]chain.Report
+}
+
+// Valid reports whether every chain verified without issues.
+func (r RestoreReport) Valid() bool {
+	for _, report := range r.Chains {
+		if !report.Valid() {
+			return false
+		}
+	}
+	return true
+}
+
+// RestoreOption configures Restore.
+type RestoreOption func(*restoreConfig)
+
+type restoreConfig struct {
+	manifestKey []byte
+}
+
+// RequireManifestKey makes Restore reject a manifest whose MAC is missing or
+// does not match key, the key the snapshots were uploaded with (see
+// WithManifestKey).
+func RequireManifestKey(key []byte) RestoreOption {
+	return func(c *restoreConfig) { c.manifestKey = bytes.Clone(key) }
+}
+
+// Restore downloads the snapshot called name from the objects under prefix
+// in t and writes the database to path, which must not exist. An empty name
+// restores the newest snapshot.
+//
+// With RequireManifestKey the manifest's MAC is checked first. The file is
+// decompressed no further than the manifest's size and checked against its
+// SHA-256, every chain head the manifest recorded must be present, and every
+// chain in the restored database is verified with chain.VerifyChain. If any
+// check fails, path is removed and the error wraps ErrInvalidSnapshot; the
+// report is still returned so the caller can see what was wrong.
+func Restore(ctx context.Context, t Target, prefix, name, path string, opts ...RestoreOption) (RestoreReport, error) {
+	var cfg restoreConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if name == "" {
+		names, err := listSnapshots(ctx, t, prefix)
+		if err != nil {
+			return RestoreReport{}, fmt.Errorf("list snapshots: %w", err)
+		}
+		if len(names) == 0 {
+			return RestoreReport{}, ErrNotFound
+		}
+		name = names[len(names)-1]
+	}
+	m, err := readManifest(ctx, t, prefix+name+manifestSuffix)
+	if err != nil {
+		return RestoreReport{}, err
+	}
+	report := RestoreReport{Manifest: m}
+	if err := checkManifest(m, name, cfg.manifestKey); err != nil {
+		return report, err
+	}
+
+	if err := download(ctx, t, prefix+name+snapshotSuffix, path, m); err != nil {
+		return report, err
+	}
+	report.Chains, err = verifyRestored(ctx, path, m)
+	if err == nil && !report.Valid() {
+		err = fmt.Errorf("%w: chain verification failed", ErrInvalidSnapshot)
+	}
+	if err != nil {
+		os.Remove(path)
+		return report, err
+	}
+	return report, nil
+}
+
+func readManifest(ctx context.Context, t Target, key string) (Manifest, error) {
+	rc, err := t.Get(ctx, key)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("read manifest: %w", err)
+	}
+	defer rc.Close()
+	var m Manifest
+	if err := json.NewDecoder(io.LimitReader(rc, 16<<20)).Decode(&m); err != nil {
+		return Manifest{}, fmt.Errorf("%w: decode manifest: %v", ErrInvalidSnapshot, err)
+	}
+	return m, nil
+}
+
+// checkManifest authenticates m when key is set and checks that it describes
+// the snapshot called name.
+func checkManifest(m Manifest, name string, key []byte) error {
+	if len(key) > 0 {
+		mac, err := hex.DecodeString(m.MAC)
+		if err != nil || m.MAC == "" {
+			return fmt.Errorf("%w: manifest has no valid MAC", ErrInvalidSnapshot)
+		}
+		want, err := m.sum(key)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(mac, want) {
+			return fmt.Errorf("%w: manifest MAC mismatch", ErrInvalidSnapshot)
+		}
+	}
+	if m.Name != name {
+		return fmt.Errorf("%w: manifest describes %q, not %q", ErrInvalidSnapshot, m.Name, name)
+	}
+	return nil
+}
+
+// download decompresses the object at key into path, checking it against m.
+// At most one byte more than m.Size is written, so a snapshot that inflates
+// past its manifest fails without filling the disk. A partial or mismatched
+// file is removed.
+func download(ctx context.Context, t Target, key, path string, m Manifest) (err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	rc, err := t.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("download snapshot: %w", err)
+	}
+	defer rc.Close()
+	zr, err := gzip.NewReader(rc)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(zr, m.Size+1))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); n != m.Size || sum != m.SHA256 {
+		return fmt.Errorf("%w: got %d bytes with sha256 %s, manifest has %d bytes with sha256 %s",
+			ErrInvalidSnapshot, n, sum, m.Size, m.SHA256)
+	}
+	return f.Sync()
+}
+
+// verifyRestored opens the restored database read-only, checks that the
+// manifest's heads are present, and verifies every chain.
+func verifyRestored(ctx context.Context, path string, m Manifest) (map[string]chain.Report, error) {
+	s, err := store.OpenReadOnly(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: open: %v", ErrInvalidSnapshot, err)
+	}
+	defer s.Close()
+
+	names := make([]string, 0, len(m.Heads))
+	for name := range m.Heads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		_, err := s.GetIntentByHash(ctx, m.Heads[name])
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("%w: chain %q head %s is missing", ErrInvalidSnapshot, name, m.Heads[name])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	heads, err := s.ChainHeads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read chain heads: %w", err)
+	}
+	reports := make(map[string]chain.Report, len(heads))
+	for name, head := range heads {
+		report, err := chain.VerifyChain(ctx, s, head)
+		if err != nil {
+			return reports, fmt.Errorf("verify chain %q: %w", name, err)
+		}
+		reports[name] = report
+	}
+	return reports, nil
+}
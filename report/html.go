@@ -0,0 +1,55 @@
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("html").Funcs(template.FuncMap{
+	"short": shortHash,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; }
+article { border: 1px solid #ccc; border-radius: 4px; padding: 1rem; margin: 1rem 0; }
+article.invalid { border-color: #c00; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 0.25rem 1rem; }
+dt { font-weight: bold; }
+pre { background: #f6f6f6; padding: 0.75rem; white-space: pre-wrap; }
+.ok { color: #080; }
+.bad { color: #c00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Head <code>{{.Head}}</code> &middot; {{len .Entries}} records &middot;
+{{if .Verification.Valid}}<span class="ok">verification passed</span>{{else}}<span class="bad">{{len .Verification.Issues}} verification issue(s)</span>{{end}}</p>
+{{range .Entries}}{{$e := .}}{{with .Record}}
+<article id="{{.Hash}}"{{if not $e.Verified}} class="invalid"{{end}}>
+<h2>{{if .Title}}{{.Title}}{{else}}Intent {{.ID}}{{end}}</h2>
+<dl>
+<dt>Author</dt><dd>{{.Author}}</dd>
+<dt>Source</dt><dd>{{.SourceType}}</dd>
+<dt>Created</dt><dd><time>{{.CreatedAt}}</time></dd>
+<dt>Hash</dt><dd><code title="{{.Hash}}">{{short .Hash}}</code></dd>
+<dt>Previous</dt><dd>{{if .PrevHash}}<a href="#{{.PrevHash}}"><code title="{{.PrevHash}}">{{short .PrevHash}}</code></a>{{else}}genesis{{end}}</dd>
+<dt>Status</dt><dd>{{if $e.Verified}}<span class="ok">verified</span>{{else}}{{range $e.Issues}}<span class="bad">{{.Kind}}: {{.Detail}}</span> {{end}}{{end}}</dd>
+</dl>
+{{if .Tombstoned}}<p><em>Content removed on {{.TombstonedAt}}.</em></p>
+{{else}}<h3>Prompt</h3>
+<pre>{{.Prompt}}</pre>
+<h3>Response</h3>
+<pre>{{.Response}}</pre>
+{{end}}</article>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// WriteHTML renders the segment as a standalone HTML page. Record content is
+// escaped, so prompts and responses cannot inject markup.
+func (s Segment) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, s)
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"io"
+	"strings"
+	"text/template"
+)
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"short": shortHash,
+	"fence": fence,
+}).Parse(`# {{.Title}}
+
+- Head: ` + "`{{.Head}}`" + `
+- Records: {{len .Entries}}
+- Verification: {{if .Verification.Valid}}passed{{else}}**{{len .Verification.Issues}} issue(s)**{{end}}
+{{range $i, $e := .Entries}}{{with $e.Record}}
+## {{if .Title}}{{.Title}}{{else}}Intent {{.ID}}{{end}}
+
+| | |
+|---|---|
+| Author | {{.Author}} |
+| Source | {{.SourceType}} |
+| Created | {{.CreatedAt}} |
+| Hash | ` + "`{{short .Hash}}`" + ` |
+| Previous | {{if .PrevHash}}` + "`{{short .PrevHash}}`" + `{{else}}genesis{{end}} |
+| Status | {{if $e.Verified}}verified{{else}}{{range $e.Issues}}**{{.Kind}}**: {{.Detail}} {{end}}{{end}} |
+{{if .Tombstoned}}
+_Content removed on {{.TombstonedAt}}._
+{{else}}
+**Prompt**
+
+{{fence .Prompt}}
+
+**Response**
+
+{{fence .Response}}
+{{end}}{{end}}{{end}}`))
+
+// WriteMarkdown renders the segment as Markdown.
+func (s Segment) WriteMarkdown(w io.Writer) error {
+	return markdownTemplate.Execute(w, s)
+}
+
+// fence wraps text in a code fence longer than any backtick run inside it.
+func fence(text string) string {
+	longest, run := 0, 0
+	for _, c := range text {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	marker := strings.Repeat("`", max(3, longest+1))
+	return marker + "\n" + text + "\n" + marker
+}
@@ -0,0 +1,133 @@
+// Package report renders a segment of an intent chain as a human-readable
+// Markdown or HTML document: prompts and responses in chronological order with
+// authors, timestamps, hash links, and verification status.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// DefaultLimit bounds a segment when Options.Limit is zero.
+const DefaultLimit = 100
+
+// Options selects the chain segment to report on.
+type Options struct {
+	// Title heads the report; empty selects "Intent history".
+	Title string
+	// Until stops the walk before the record with this hash, as
+	// chain.VerifyChainUntil does.
+	Until string
+	// Limit bounds the number of records; zero selects DefaultLimit.
+	Limit int
+}
+
+// Segment is a verified chain segment, oldest record first.
+type Segment struct {
+	Title   string
+	Head    string
+	Entries []Entry
+	// Verification covers exactly the records in Entries.
+	Verification chain.Report
+}
+
+// Entry is one record in a Segment.
+type Entry struct {
+	Record model.IntentRecord
+	Issues []chain.Issue
+}
+
+// Verified reports whether the record has no verification issues.
+func (e Entry) Verified() bool {
+	return len(e.Issues) == 0
+}
+
+// Load walks up to opts.Limit records back from headHash and verifies them.
+func Load(ctx context.Context, r chain.Reader, headHash string, opts Options) (Segment, error) {
+	if headHash == "" {
+		return Segment{}, errors.New("head hash is required")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	seg := Segment{Title: opts.Title, Head: headHash}
+	if seg.Title == "" {
+		seg.Title = "Intent history"
+	}
+
+	var records []model.IntentRecord
+	seen := make(map[string]struct{})
+	current := headHash
+	for ; current != "" && current != opts.Until && len(records) < limit; current = records[len(records)-1].PrevHash {
+		if _, ok := seen[current]; ok {
+			break
+		}
+		seen[current] = struct{}{}
+		record, err := r.GetIntentByHash(ctx, current)
+		if errors.Is(err, chain.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return Segment{}, fmt.Errorf("load intent %s: %w", current, err)
+		}
+		records = append(records, record)
+	}
+
+	// A segment cut short by the limit is verified only up to its oldest
+	// record; otherwise verification reports what ended the walk.
+	until := opts.Until
+	if len(records) == limit {
+		until = current
+	}
+	verification, err := chain.VerifyChainUntil(ctx, r, headHash, until)
+	if err != nil {
+		return Segment{}, fmt.Errorf("verify chain: %w", err)
+	}
+	seg.Verification = verification
+
+	issues := make(map[string][]chain.Issue)
+	for _, issue := range verification.Issues {
+		issues[issue.Hash] = append(issues[issue.Hash], issue)
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		seg.Entries = append(seg.Entries, Entry{Record: records[i], Issues: issues[records[i].Hash]})
+	}
+	return seg, nil
+}
+
+// Markdown writes the segment ending at headHash to w as Markdown.
+func Markdown(ctx context.Context, r chain.Reader, headHash string, w io.Writer, opts Options) error {
+	seg, err := Load(ctx, r, headHash, opts)
+	if err != nil {
+		return err
+	}
+	return seg.WriteMarkdown(w)
+}
+
+// HTML writes the segment ending at headHash to w as a standalone HTML page.
+func HTML(ctx context.Context, r chain.Reader, headHash string, w io.Writer, opts Options) error {
+	seg, err := Load(ctx, r, headHash, opts)
+	if err != nil {
+		return err
+	}
+	return seg.WriteHTML(w)
+}
+
+// shortHash abbreviates a hash for display, keeping any algorithm prefix.
+func shortHash(h string) string {
+	prefix := ""
+	if i := strings.LastIndexByte(h, ':'); i >= 0 {
+		prefix, h = h[:i+1], h[i+1:]
+	}
+	if len(h) > 12 {
+		h = h[:12]
+	}
+	return prefix + h
+}
@@ -0,0 +1,169 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store/memstore"
+)
+
+// buildChain stores linked intents with the given prompts and returns them
+// oldest first.
+func buildChain(t *testing.T, s *memstore.Store, prompts ...string) []model.IntentRecord {
+	t.Helper()
+	records := make([]model.IntentRecord, 0, len(prompts))
+	prev := ""
+	for i, prompt := range prompts {
+		record := model.IntentRecord{
+			ID:         fmt.Sprintf("intent-%03d", i),
+			CreatedAt:  time.Date(2026, 2, 9, 10, 0, i, 0, time.UTC).Format(time.RFC3339Nano),
+			Author:     "alice",
+			SourceType: "cli",
+			Prompt:     prompt,
+			Response:   fmt.Sprintf("response %d", i),
+			PrevHash:   prev,
+		}
+		sum, err := hash.HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash intent %d: %v", i, err)
+		}
+		record.Hash = sum
+		if err := s.CreateIntent(context.Background(), record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+		records = append(records, record)
+		prev = sum
+	}
+	return records
+}
+
+func TestLoadSegment(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, "a", "b", "c", "d", "e")
+	head := records[4].Hash
+
+	seg, err := Load(ctx, s, head, Options{Limit: 3})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(seg.Entries) != 3 || seg.Entries[0].Record.ID != records[2].ID || seg.Entries[2].Record.ID != records[4].ID {
+		t.Fatalf("expected records 2..4 oldest first, got %+v", seg.Entries)
+	}
+	if !seg.Verification.Valid() || seg.Verification.Length != 3 {
+		t.Fatalf("expected the 3-record segment to verify, got %+v", seg.Verification)
+	}
+
+	seg, err = Load(ctx, s, head, Options{Until: records[1].Hash})
+	if err != nil {
+		t.Fatalf("load until: %v", err)
+	}
+	if len(seg.Entries) != 3 || seg.Entries[0].Record.ID != records[2].ID {
+		t.Fatalf("expected the walk to stop before record 1, got %d entries", len(seg.Entries))
+	}
+}
+
+func TestLoadReportsIssues(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, "a", "b")
+
+	// A record whose stored hash no longer matches its payload.
+	tampered := model.IntentRecord{
+		ID:         "intent-tampered",
+		CreatedAt:  time.Date(2026, 2, 9, 11, 0, 0, 0, time.UTC).Format(time.RFC3339Nano),
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "original",
+		Response:   "response",
+		PrevHash:   records[1].Hash,
+	}
+	sum, err := hash.HashIntent(tampered)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	tampered.Hash = sum
+	tampered.Prompt = "edited"
+	if err := s.CreateIntent(ctx, tampered); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	seg, err := Load(ctx, s, tampered.Hash, Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if seg.Verification.Valid() {
+		t.Fatal("expected verification issues")
+	}
+	last := seg.Entries[len(seg.Entries)-1]
+	if last.Verified() || last.Issues[0].Kind != chain.IssueAlteredPayload {
+		t.Fatalf("expected the tampered entry to carry an altered payload issue, got %+v", last.Issues)
+	}
+	if !seg.Entries[0].Verified() {
+		t.Fatalf("expected untouched entries to verify, got %+v", seg.Entries[0].Issues)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, "first prompt", "code:\n```go\nx := 1\n```")
+
+	var out bytes.Buffer
+	if err := Markdown(ctx, s, records[1].Hash, &out, Options{Title: "Design review"}); err != nil {
+		t.Fatalf("markdown: %v", err)
+	}
+	doc := out.String()
+	for _, want := range []string{
+		"# Design review",
+		"Verification: passed",
+		"| Author | alice |",
+		"`" + shortHash(records[0].Hash) + "`",
+		"| Previous | genesis |",
+		"````\ncode:\n```go",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("expected %q in report:\n%s", want, doc)
+		}
+	}
+	if strings.Index(doc, "first prompt") > strings.Index(doc, "code:") {
+		t.Error("expected entries in chronological order")
+	}
+}
+
+func TestHTMLEscapesContent(t *testing.T) {
+	ctx := context.Background()
+	s := memstore.New()
+	records := buildChain(t, s, "plain", "<script>alert(1)</script>")
+
+	var out bytes.Buffer
+	if err := HTML(ctx, s, records[1].Hash, &out, Options{}); err != nil {
+		t.Fatalf("html: %v", err)
+	}
+	doc := out.String()
+	if strings.Contains(doc, "<script>") {
+		t.Fatal("expected prompt markup to be escaped")
+	}
+	if !strings.Contains(doc, `href="#`+records[0].Hash+`"`) {
+		t.Error("expected the previous-hash link to target the parent entry")
+	}
+	if !strings.Contains(doc, "verification passed") {
+		t.Error("expected verification status")
+	}
+}
+
+func TestFence(t *testing.T) {
+	if got := fence("x"); got != "```\nx\n```" {
+		t.Fatalf("unexpected fence %q", got)
+	}
+	if got := fence("a ```` b"); !strings.HasPrefix(got, "`````\n") {
+		t.Fatalf("expected a 5-backtick fence, got %q", got)
+	}
+}
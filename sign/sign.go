@@ -0,0 +1,82 @@
+// Package sign attaches and checks Ed25519 signatures on intent records.
+//
+// A signature covers the record's hash, which in turn covers every other field,
+// so it binds the author to the full record. The hash preimage excludes the
+// signature and public_key fields, so signing does not change the hash.
+package sign
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/keys"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ErrInvalidSignature is returned by VerifyIntent when the signature does not
+// match the record's hash and public key.
+var ErrInvalidSignature = errors.New("invalid intent signature")
+
+// signaturePrefix domain-separates intent signatures from other uses of the key.
+const signaturePrefix = "yanzi-intent-v1:"
+
+// SignIntent returns record with Hash recomputed (see hash.Recompute) and
+// Signature and PublicKey set.
+func SignIntent(record model.IntentRecord, privKey ed25519.PrivateKey) (model.IntentRecord, error) {
+	signer, err := keys.FromPrivateKey(privKey)
+	if err != nil {
+		return model.IntentRecord{}, err
+	}
+	return SignIntentWith(record, signer)
+}
+
+// SignIntentWith is SignIntent for a key held by a keys.Signer.
+func SignIntentWith(record model.IntentRecord, signer keys.Signer) (model.IntentRecord, error) {
+	sum, err := hash.Recompute(record)
+	if err != nil {
+		return model.IntentRecord{}, err
+	}
+	sig, err := signer.Sign(message(sum))
+	if err != nil {
+		return model.IntentRecord{}, fmt.Errorf("sign intent: %w", err)
+	}
+	record.Hash = sum
+	record.Signature = base64.StdEncoding.EncodeToString(sig)
+	record.PublicKey = base64.StdEncoding.EncodeToString(signer.Public())
+	return record, nil
+}
+
+// VerifyIntent recomputes record's hash and checks its signature against the
+// embedded public key. Callers must separately decide whether that key is
+// trusted for record.Author.
+func VerifyIntent(record model.IntentRecord) error {
+	if record.Signature == "" || record.PublicKey == "" {
+		return errors.New("intent is not signed")
+	}
+	sum, err := hash.Recompute(record)
+	if err != nil {
+		return err
+	}
+	if sum != record.Hash {
+		return &model.HashMismatchError{ID: record.ID, Stored: record.Hash, Recomputed: sum}
+	}
+	pub, err := base64.StdEncoding.DecodeString(record.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("public_key must be a base64 ed25519 public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(record.Signature)
+	if err != nil {
+		return errors.New("signature must be base64")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), message(sum), sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func message(sum string) []byte {
+	return []byte(signaturePrefix + sum)
+}
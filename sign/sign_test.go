@@ -0,0 +1,63 @@
+package sign
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func testRecord() model.IntentRecord {
+	return model.IntentRecord{
+		ID:         "intent-1",
+		CreatedAt:  "2026-02-09T10:00:00Z",
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "response",
+	}
+}
+
+func TestSignAndVerifyIntent(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signed, err := SignIntent(testRecord(), priv)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := signed.Validate(); err != nil {
+		t.Fatalf("signed record invalid: %v", err)
+	}
+	if err := VerifyIntent(signed); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	tampered := signed
+	tampered.Response = "changed"
+	if err := VerifyIntent(tampered); err == nil {
+		t.Fatalf("expected tampered payload to fail")
+	}
+
+	_, other, _ := ed25519.GenerateKey(nil)
+	resigned, err := SignIntent(testRecord(), other)
+	if err != nil {
+		t.Fatalf("sign with other key: %v", err)
+	}
+	if resigned.Hash != signed.Hash {
+		t.Fatalf("signing must not change the hash")
+	}
+	swapped := signed
+	swapped.PublicKey = resigned.PublicKey
+	if err := VerifyIntent(swapped); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for swapped key, got %v", err)
+	}
+}
+
+func TestVerifyIntentRequiresSignature(t *testing.T) {
+	if err := VerifyIntent(testRecord()); err == nil {
+		t.Fatalf("expected unsigned record to fail")
+	}
+}
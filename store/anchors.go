@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// AnchorStatus is the state of an Anchor.
+type AnchorStatus string
+
+const (
+	// AnchorPending means the backend has accepted the commitment but the
+	// proof does not yet reach the public ledger.
+	AnchorPending   AnchorStatus = "pending"
+	AnchorConfirmed AnchorStatus = "confirmed"
+)
+
+// Anchor is a proof that a checkpoint's Merkle root was committed to a
+// public ledger by an anchoring backend.
+type Anchor struct {
+	ID            int64
+	MerkleRoot    string
+	CheckpointSeq int64
+	// Backend names the anchoring service, for example "opentimestamps".
+	Backend string
+	Status  AnchorStatus
+	// Proof is the backend's serialized proof.
+	Proof []byte
+	// BlockHeight and AttestedAt are set once the anchor is confirmed.
+	BlockHeight int64
+	AttestedAt  string
+	CreatedAt   string
+	UpdatedAt   string
+}
+
+const anchorColumns = `id, merkle_root, checkpoint_seq, backend, status, proof, block_height, attested_at, created_at, updated_at`
+
+// SaveAnchor stores a and returns it with ID, CreatedAt, and UpdatedAt set.
+func (s *Store) SaveAnchor(ctx context.Context, a Anchor) (_ Anchor, err error) {
+	ctx, span := s.startSpan(ctx, "SaveAnchor")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("SaveAnchor"); err != nil {
+		return Anchor{}, err
+	}
+	if s.db == nil {
+		return Anchor{}, errors.New("store not initialized")
+	}
+	if a.MerkleRoot == "" || a.Backend == "" || len(a.Proof) == 0 {
+		return Anchor{}, errors.New("anchor requires a merkle root, a backend, and a proof")
+	}
+	if a.Status == "" {
+		a.Status = AnchorPending
+	}
+	a.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	a.UpdatedAt = a.CreatedAt
+	res, err := s.db.ExecContext(ctx, `INSERT INTO anchors (merkle_root, checkpoint_seq, backend, status, proof,
+		block_height, attested_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.MerkleRoot, a.CheckpointSeq, a.Backend, a.Status, a.Proof, a.BlockHeight, a.AttestedAt, a.CreatedAt, a.UpdatedAt)
+	if err != nil {
+		return Anchor{}, err
+	}
+	if a.ID, err = res.LastInsertId(); err != nil {
+		return Anchor{}, err
+	}
+	return a, nil
+}
+
+// UpdateAnchor stores a's Status, Proof, BlockHeight, and AttestedAt. It
+// returns a *NotFoundError if the anchor does not exist.
+func (s *Store) UpdateAnchor(ctx context.Context, a Anchor) (err error) {
+	ctx, span := s.startSpan(ctx, "UpdateAnchor")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("UpdateAnchor"); err != nil {
+		return err
+	}
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE anchors SET status = ?, proof = ?, block_height = ?, attested_at = ?,
+		updated_at = ? WHERE id = ?`,
+		a.Status, a.Proof, a.BlockHeight, a.AttestedAt, time.Now().UTC().Format(time.RFC3339Nano), a.ID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &NotFoundError{Kind: "anchor", Key: strconv.FormatInt(a.ID, 10)}
+	}
+	return nil
+}
+
+// PendingAnchors returns backend's pending anchors, oldest first.
+func (s *Store) PendingAnchors(ctx context.Context, backend string, limit int) (_ []Anchor, err error) {
+	ctx, span := s.startSpan(ctx, "PendingAnchors")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+anchorColumns+` FROM anchors
+		WHERE backend = ? AND status = ? ORDER BY id ASC LIMIT ?`, backend, AnchorPending, s.clampLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	return collectAnchors(rows)
+}
+
+// Anchors returns every anchor of merkleRoot, oldest first.
+func (s *Store) Anchors(ctx context.Context, merkleRoot string) (_ []Anchor, err error) {
+	ctx, span := s.startSpan(ctx, "Anchors")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+anchorColumns+` FROM anchors
+		WHERE merkle_root = ? ORDER BY id ASC`, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	return collectAnchors(rows)
+}
+
+func collectAnchors(rows *sql.Rows) ([]Anchor, error) {
+	defer rows.Close()
+	var anchors []Anchor
+	for rows.Next() {
+		var a Anchor
+		if err := rows.Scan(&a.ID, &a.MerkleRoot, &a.CheckpointSeq, &a.Backend, &a.Status, &a.Proof,
+			&a.BlockHeight, &a.AttestedAt, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		anchors = append(anchors, a)
+	}
+	return anchors, rows.Err()
+}
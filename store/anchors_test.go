@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestAnchorsLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	if _, err := s.SaveAnchor(ctx, Anchor{MerkleRoot: "root", Backend: "test"}); err == nil {
+		t.Fatal("expected error for an anchor without a proof")
+	}
+	a, err := s.SaveAnchor(ctx, Anchor{MerkleRoot: "root", CheckpointSeq: 3, Backend: "test", Proof: []byte{1}})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if a.ID == 0 || a.Status != AnchorPending || a.CreatedAt == "" {
+		t.Fatalf("unexpected anchor %+v", a)
+	}
+	if _, err := s.SaveAnchor(ctx, Anchor{MerkleRoot: "root", Backend: "other", Proof: []byte{2}}); err != nil {
+		t.Fatalf("save other backend: %v", err)
+	}
+
+	pending, err := s.PendingAnchors(ctx, "test", 10)
+	if err != nil || len(pending) != 1 || pending[0].ID != a.ID {
+		t.Fatalf("expected anchor %d pending, got %+v (%v)", a.ID, pending, err)
+	}
+
+	a.Status = AnchorConfirmed
+	a.Proof = []byte{1, 2}
+	a.BlockHeight = 840000
+	a.AttestedAt = "2024-04-20T00:09:27Z"
+	if err := s.UpdateAnchor(ctx, a); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if pending, _ := s.PendingAnchors(ctx, "test", 10); len(pending) != 0 {
+		t.Fatalf("expected no pending anchors, got %d", len(pending))
+	}
+	anchors, err := s.Anchors(ctx, "root")
+	if err != nil || len(anchors) != 2 {
+		t.Fatalf("expected two anchors, got %d (%v)", len(anchors), err)
+	}
+	got := anchors[0]
+	if got.Status != AnchorConfirmed || got.BlockHeight != 840000 || len(got.Proof) != 2 || got.CheckpointSeq != 3 {
+		t.Fatalf("unexpected updated anchor %+v", got)
+	}
+
+	if err := s.UpdateAnchor(ctx, Anchor{ID: 999, Status: AnchorPending, Proof: []byte{1}}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ErrChainHeadMoved is returned by AppendIntent when other writers kept
+// advancing the chain head across every retry.
+var ErrChainHeadMoved = errors.New("chain head moved during append")
+
+// appendAttempts bounds AppendIntent retries when the head moves underneath it.
+const appendAttempts = 3
+
+// AppendIntent links record to the current head of its chain, computes its hash,
+// and inserts it, all in one transaction, returning the stored record. A missing
+// ID is filled from model.NewIntentID and a missing CreatedAt from the store's
+// clock (see WithClock and WithServerTimestamps); any caller-supplied PrevHash
+// or Hash is replaced, the hash computed as configured with WithHashing. A
+// configured redactor (see WithRedactor) runs before hashing,
+// WithMonotonicTimestamps bounds how far CreatedAt may trail the chain head,
+// and DuplicateDetection.MarkMeta marks near-duplicates in meta before
+// hashing.
+//
+// The head only advances if it still matches the PrevHash that was read, so a
+// concurrent writer (including another process) causes a retry rather than a fork.
+func (s *Store) AppendIntent(ctx context.Context, record model.IntentRecord) (_ model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "AppendIntent")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpCreate, start, 1, err) }(time.Now())
+	return s.appendIntent(ctx, "AppendIntent", record, nil)
+}
+
+// appendIntent implements AppendIntent for op. A non-nil afterInsert runs in
+// the append transaction once the record is stored.
+func (s *Store) appendIntent(ctx context.Context, op string, record model.IntentRecord, afterInsert func(tx *sql.Tx, stored model.IntentRecord) error) (_ model.IntentRecord, err error) {
+	if err := s.writable(op); err != nil {
+		return model.IntentRecord{}, err
+	}
+	if record.Tombstoned() {
+		return model.IntentRecord{}, errTombstonedInput
+	}
+	if err := s.authorize(ctx, ActionCreate, record); err != nil {
+		return model.IntentRecord{}, err
+	}
+	if err := s.limiter.admitRecords(record); err != nil {
+		return model.IntentRecord{}, err
+	}
+	defer func() { s.limiter.refundInvalid(err, record) }()
+	if s.opts.chainKey(record) == "" {
+		return model.IntentRecord{}, &model.InvalidRecordError{Err: errors.New("record does not belong to a chain")}
+	}
+	if record.ID == "" {
+		id, err := model.NewIntentID()
+		if err != nil {
+			return model.IntentRecord{}, fmt.Errorf("generate id: %w", err)
+		}
+		record.ID = id
+	}
+	record, err = s.redact(record, false)
+	if err != nil {
+		return model.IntentRecord{}, err
+	}
+	if err := record.ValidateMetaSchema(); err != nil {
+		return model.IntentRecord{}, err
+	}
+
+	s.appendMu.Lock()
+	defer s.appendMu.Unlock()
+
+	// Stamp under the lock so server-assigned times follow chain order.
+	record = s.stampCreatedAt(record)
+
+	for attempt := 0; attempt < appendAttempts; attempt++ {
+		var stored model.IntentRecord
+		err := s.withRetryTx(ctx, op, func(tx *sql.Tx) error {
+			if err := s.checkSkewTx(ctx, tx, record); err != nil {
+				return err
+			}
+			marked, err := s.markDuplicateTx(ctx, tx, record)
+			if err != nil {
+				return err
+			}
+			linked, err := s.linkToHead(ctx, tx, marked)
+			if err != nil {
+				return err
+			}
+			if s.opts.validation.Fields {
+				if err := linked.Validate(); err != nil {
+					return err
+				}
+			}
+			if err := checkAttachmentsTx(ctx, tx, linked); err != nil {
+				return err
+			}
+			stmt, err := s.txStmt(ctx, tx, insertIntentSQL)
+			if err != nil {
+				return err
+			}
+			args, err := intentArgs(linked, s.codec)
+			if err != nil {
+				return err
+			}
+			if _, err := stmt.ExecContext(ctx, args...); err != nil {
+				return duplicateTx(ctx, tx, linked, err)
+			}
+			advanced, err := s.advanceChainHead(ctx, tx, linked)
+			if err != nil {
+				return err
+			}
+			if !advanced {
+				return ErrChainHeadMoved
+			}
+			if err := s.insertTagsTx(ctx, tx, linked); err != nil {
+				return err
+			}
+			if err := s.insertPromotedMetaTx(ctx, tx, linked); err != nil {
+				return err
+			}
+			if err := s.insertFingerprintTx(ctx, tx, linked); err != nil {
+				return err
+			}
+			if afterInsert != nil {
+				if err := afterInsert(tx, linked); err != nil {
+					return err
+				}
+			}
+			stored = linked
+			return s.checkpointDueTx(ctx, tx)
+		})
+		if errors.Is(err, ErrChainHeadMoved) {
+			s.logger().Debug("chain head moved, retrying append", "id", record.ID, "attempt", attempt+1)
+			continue
+		}
+		if err != nil {
+			return model.IntentRecord{}, err
+		}
+		return stored, nil
+	}
+	s.logger().Warn("append gave up after chain head kept moving", "id", record.ID, "attempts", appendAttempts)
+	return model.IntentRecord{}, ErrChainHeadMoved
+}
+
+// linkToHead sets PrevHash to the current chain head read within tx and rehashes.
+func (s *Store) linkToHead(ctx context.Context, tx *sql.Tx, record model.IntentRecord) (model.IntentRecord, error) {
+	stmt, err := s.txStmt(ctx, tx, selectChainHeadSQL)
+	if err != nil {
+		return record, err
+	}
+	var head string
+	err = stmt.QueryRowContext(ctx, s.opts.chainKey(record)).Scan(&head)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return record, fmt.Errorf("read chain head: %w", err)
+	}
+	record.PrevHash = head
+
+	if record, err = s.hashIntent(ctx, record); err != nil {
+		return record, fmt.Errorf("hash intent: %w", err)
+	}
+	return record, nil
+}
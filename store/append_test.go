@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestAppendIntentLinksChain(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	var last model.IntentRecord
+	for i := 0; i < 3; i++ {
+		record := testIntent(t, i)
+		record.Hash = ""
+		appended, err := s.AppendIntent(ctx, record)
+		if err != nil {
+			t.Fatalf("append intent %d: %v", i, err)
+		}
+		if appended.PrevHash != last.Hash {
+			t.Fatalf("intent %d: expected prev_hash %q, got %q", i, last.Hash, appended.PrevHash)
+		}
+		last = appended
+	}
+
+	report, err := chain.VerifyChain(ctx, s, last.Hash)
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if !report.Valid() || report.Length != 3 {
+		t.Fatalf("expected valid 3-record chain, got %+v", report)
+	}
+}
+
+func TestAppendIntentAssignsULIDs(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	t.Cleanup(func() { model.SetIDGenerator(nil) })
+	model.SetIDGenerator(model.IDGeneratorFunc(func() (string, error) { return "custom", nil }))
+
+	record := testIntent(t, 0)
+	record.ID, record.Hash = "", ""
+	appended, err := s.AppendIntent(ctx, record)
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	if err := model.ValidateULID(appended.ID); err != nil {
+		t.Fatalf("expected a ULID id, got %s: %v", appended.ID, err)
+	}
+}
+
+func TestAppendIntentConcurrent(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := s.AppendIntent(ctx, model.IntentRecord{
+				Author:     "alice",
+				SourceType: "cli",
+				Prompt:     fmt.Sprintf("prompt %d", i),
+				Response:   "response",
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("append intent: %v", err)
+		}
+	}
+
+	head, err := s.ChainHead(ctx, "alice")
+	if err != nil {
+		t.Fatalf("chain head: %v", err)
+	}
+	report, err := chain.VerifyChain(ctx, s, head)
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if !report.Valid() || report.Length != n {
+		t.Fatalf("expected a single valid chain of %d, got %+v", n, report)
+	}
+}
+
+func TestListAncestors(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	var chained []model.IntentRecord
+	for i := 0; i < 5; i++ {
+		record := testIntent(t, i)
+		record.Hash = ""
+		appended, err := s.AppendIntent(ctx, record)
+		if err != nil {
+			t.Fatalf("append intent %d: %v", i, err)
+		}
+		chained = append(chained, appended)
+	}
+
+	got, err := s.ListAncestors(ctx, chained[4].Hash, 3)
+	if err != nil {
+		t.Fatalf("list ancestors: %v", err)
+	}
+	if len(got) != 3 || got[0].Hash != chained[4].Hash || got[2].Hash != chained[2].Hash {
+		t.Fatalf("expected the head and two ancestors newest first, got %d record(s)", len(got))
+	}
+	got, err = s.ListAncestors(ctx, chained[1].Hash, 10)
+	if err != nil {
+		t.Fatalf("list ancestors: %v", err)
+	}
+	if len(got) != 2 || got[1].Hash != chained[0].Hash {
+		t.Fatalf("expected the walk to stop at genesis, got %d record(s)", len(got))
+	}
+	if got, err := s.ListAncestors(ctx, "missing", 10); err != nil || len(got) != 0 {
+		t.Fatalf("expected no records for a missing hash, got %d, %v", len(got), err)
+	}
+
+	var _ chain.AncestorReader = s
+	report, err := chain.VerifyChainWithOptions(ctx, s, chained[4].Hash, chain.VerifyOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if !report.Valid() || report.Length != 5 || report.Genesis != chained[0].Hash {
+		t.Fatalf("expected valid 5-record chain, got %+v", report)
+	}
+}
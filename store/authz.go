@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Action is an operation an Authorizer is asked to allow.
+type Action string
+
+const (
+	// ActionCreate is checked with each record before it is stored.
+	ActionCreate Action = "create"
+	// ActionGet is checked with a record fetched by ID or hash.
+	ActionGet Action = "get"
+	// ActionList is checked with each record a list or query would return.
+	ActionList Action = "list"
+	// ActionTombstone is checked with each record before its content is
+	// removed by TombstoneIntent or EraseAuthor.
+	ActionTombstone Action = "tombstone"
+)
+
+// ErrForbidden is matched by errors returned when the Authorizer denies an
+// action.
+var ErrForbidden = errors.New("forbidden")
+
+// Authorizer decides whether the caller behind ctx may perform action on
+// record. The store knows nothing of callers; an Authorizer typically reads
+// an identity that a wrapper, such as an HTTP middleware, put in ctx.
+type Authorizer interface {
+	// Allow returns nil to allow the action; any error denies it.
+	Allow(ctx context.Context, action Action, record model.IntentRecord) error
+}
+
+// AuthorizerFunc adapts a function to Authorizer.
+type AuthorizerFunc func(ctx context.Context, action Action, record model.IntentRecord) error
+
+// Allow calls f.
+func (f AuthorizerFunc) Allow(ctx context.Context, action Action, record model.IntentRecord) error {
+	return f(ctx, action, record)
+}
+
+// WithAuthorizer consults a on every create, get, list, and tombstone.
+// Creates, gets, and tombstones fail with an error wrapping ErrForbidden and
+// the Authorizer's error; lists and queries omit denied records, so a page
+// may hold fewer than its limit. Change feeds, chain traversal by
+// GetIntentsByHashes and ListChildren, and ApplyRetention are not checked.
+func WithAuthorizer(a Authorizer) Option {
+	return func(o *options) {
+		o.authorizer = a
+	}
+}
+
+// authorize asks the configured Authorizer to allow action on record.
+func (s *Store) authorize(ctx context.Context, action Action, record model.IntentRecord) error {
+	if s.opts.authorizer == nil {
+		return nil
+	}
+	if err := s.opts.authorizer.Allow(ctx, action, record); err != nil {
+		return fmt.Errorf("%w: %s intent %s: %w", ErrForbidden, action, record.ID, err)
+	}
+	return nil
+}
+
+// allowed returns the records the configured Authorizer allows for action.
+func (s *Store) allowed(ctx context.Context, action Action, records []model.IntentRecord) []model.IntentRecord {
+	if s.opts.authorizer == nil {
+		return records
+	}
+	kept := records[:0]
+	for _, record := range records {
+		if s.opts.authorizer.Allow(ctx, action, record) == nil {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// TombstoneIntent removes the content of the intent with id as ApplyRetention
+// does, keeping its hash and links so its chain still verifies. Tombstoning
+// an already tombstoned intent is a no-op; a missing intent is a *NotFoundError.
+func (s *Store) TombstoneIntent(ctx context.Context, id string) (err error) {
+	ctx, span := s.startSpan(ctx, "TombstoneIntent")
+	defer func() { err = span.end(err) }()
+	defer s.cache.invalidate(id)
+	if err := s.writable("TombstoneIntent"); err != nil {
+		return err
+	}
+	return s.withRetryTx(ctx, "TombstoneIntent", func(tx *sql.Tx) error {
+		record, err := s.scanIntent(tx.QueryRowContext(ctx, selectIntentByIDSQL, id))
+		if err != nil {
+			return notFound(err, "intent", id)
+		}
+		if record.Tombstoned() {
+			return nil
+		}
+		if err := s.authorize(ctx, ActionTombstone, record); err != nil {
+			return err
+		}
+		return tombstoneTx(ctx, tx, record, s.now().Format(time.RFC3339Nano))
+	})
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+type callerKey struct{}
+
+func asCaller(name string) context.Context {
+	return context.WithValue(context.Background(), callerKey{}, name)
+}
+
+// ownerOnly lets callers create and see only their own intents, and tombstone
+// only what they authored.
+var ownerOnly = AuthorizerFunc(func(ctx context.Context, action Action, record model.IntentRecord) error {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	if caller != record.Author {
+		return errors.New(caller + " is not the author")
+	}
+	return nil
+})
+
+func openAuthorizedStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithAuthorizer(ownerOnly))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func TestAuthorizerCreateAndRead(t *testing.T) {
+	s := openAuthorizedStore(t)
+	alice, bob := asCaller("alice"), asCaller("bob")
+
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(bob, record); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden creating as bob, got %v", err)
+	}
+	if err := s.CreateIntentIdempotent(bob, record); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden from the idempotent create, got %v", err)
+	}
+	if _, err := s.AppendIntent(bob, model.IntentRecord{Author: "alice", SourceType: "cli", Prompt: "p", Response: "r"}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden appending as bob, got %v", err)
+	}
+	if err := s.CreateIntent(alice, record); err != nil {
+		t.Fatalf("create as alice: %v", err)
+	}
+	bobs := model.IntentRecord{Author: "bob", SourceType: "cli", Prompt: "p", Response: "r"}
+	if _, err := s.AppendIntent(bob, bobs); err != nil {
+		t.Fatalf("append as bob: %v", err)
+	}
+
+	if _, err := s.GetIntent(bob, record.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden reading as bob, got %v", err)
+	}
+	if _, err := s.GetIntentByHash(alice, record.Hash); err != nil {
+		t.Fatalf("get by hash as alice: %v", err)
+	}
+
+	listed, err := s.ListIntents(bob, 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(listed) != 1 || listed[0].Author != "bob" {
+		t.Fatalf("expected only bob's intent, got %+v", listed)
+	}
+	page, err := s.ListIntentsPage(alice, PageOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("list page: %v", err)
+	}
+	// Alice's intent sorts first; bob's is filtered from the second page
+	// without ending the listing early.
+	if len(page.Intents) != 1 || page.Intents[0].ID != record.ID || page.NextCursor == "" {
+		t.Fatalf("expected alice's intent and a cursor, got %+v", page)
+	}
+	queried, err := s.QueryIntents(alice, Query{Text: "p"})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(queried) != 1 || queried[0].ID != record.ID {
+		t.Fatalf("expected only alice's intent, got %+v", queried)
+	}
+}
+
+func TestTombstoneIntent(t *testing.T) {
+	s := openAuthorizedStore(t)
+	alice := asCaller("alice")
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(alice, record); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	if err := s.TombstoneIntent(asCaller("bob"), record.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden tombstoning as bob, got %v", err)
+	}
+	if _, err := s.EraseAuthor(asCaller("bob"), "alice", nil); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden erasing as bob, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.TombstoneIntent(alice, record.ID); err != nil {
+			t.Fatalf("tombstone %d: %v", i, err)
+		}
+	}
+	got, err := s.GetIntent(alice, record.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !got.Tombstoned() || got.Prompt != "" || got.Hash != record.Hash {
+		t.Fatalf("expected a tombstone keeping the hash, got %+v", got)
+	}
+	if err := s.TombstoneIntent(alice, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Backend is the storage contract for intent records. Store is the SQLite
+// implementation; alternative backends register themselves with RegisterBackend.
+// Implementations report a missing record with an error matching ErrNotFound
+// and an ID that is already stored with one matching ErrDuplicateID.
+type Backend interface {
+	CreateIntent(ctx context.Context, record model.IntentRecord) error
+	GetIntent(ctx context.Context, id string) (model.IntentRecord, error)
+	GetIntentByHash(ctx context.Context, hash string) (model.IntentRecord, error)
+	ListIntents(ctx context.Context, limit int) ([]model.IntentRecord, error)
+	Migrate(ctx context.Context) error
+	Close() error
+}
+
+// BackendOpener opens a backend from a backend-specific data source name.
+type BackendOpener func(dsn string) (Backend, error)
+
+var _ Backend = (*Store)(nil)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]BackendOpener)
+)
+
+func init() {
+	RegisterBackend("sqlite", func(dsn string) (Backend, error) {
+		return Open(dsn)
+	})
+}
+
+// RegisterBackend makes a backend available by name to OpenBackend.
+// It panics if open is nil or name is already registered.
+func RegisterBackend(name string, open BackendOpener) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if open == nil {
+		panic("store: RegisterBackend opener is nil")
+	}
+	if _, dup := backends[name]; dup {
+		panic("store: RegisterBackend called twice for backend " + name)
+	}
+	backends[name] = open
+}
+
+// OpenBackend opens the named backend with dsn.
+func OpenBackend(name, dsn string) (Backend, error) {
+	backendsMu.RLock()
+	open, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown store backend %q", name)
+	}
+	return open(dsn)
+}
+
+// Backends returns the sorted names of registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenBackendSQLite(t *testing.T) {
+	ctx := context.Background()
+	backend, err := OpenBackend("sqlite", filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open backend: %v", err)
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+
+	if err := backend.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	record := testIntent(t, 1)
+	if err := backend.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	got, err := backend.GetIntentByHash(ctx, record.Hash)
+	if err != nil {
+		t.Fatalf("get intent by hash: %v", err)
+	}
+	if got.ID != record.ID {
+		t.Fatalf("expected %s, got %s", record.ID, got.ID)
+	}
+}
+
+func TestOpenBackendUnknown(t *testing.T) {
+	if _, err := OpenBackend("nope", "dsn"); err == nil {
+		t.Fatalf("expected error for unknown backend")
+	}
+}
+
+func TestRegisterBackendDuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected duplicate registration to panic")
+		}
+	}()
+	RegisterBackend("sqlite", func(string) (Backend, error) { return nil, nil })
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestCreateIntentsInsertsBatch(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	records := make([]model.IntentRecord, 50)
+	for i := range records {
+		records[i] = testIntent(t, i)
+	}
+	if err := s.CreateIntents(ctx, records); err != nil {
+		t.Fatalf("create intents: %v", err)
+	}
+
+	intents, err := s.ListIntents(ctx, 100)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	if len(intents) != len(records) {
+		t.Fatalf("expected %d intents, got %d", len(records), len(intents))
+	}
+}
+
+func TestCreateIntentsIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	records := []model.IntentRecord{testIntent(t, 1), testIntent(t, 2), testIntent(t, 1)}
+	err := s.CreateIntents(ctx, records)
+	if err == nil || !strings.Contains(err.Error(), "create intent 2") {
+		t.Fatalf("expected duplicate at index 2 to fail, got %v", err)
+	}
+
+	intents, err := s.ListIntents(ctx, 0)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	if len(intents) != 0 {
+		t.Fatalf("expected failed batch to insert nothing, got %d", len(intents))
+	}
+}
+
+func BenchmarkCreateIntentsBatch(b *testing.B) {
+	ctx := context.Background()
+	s := openTestStore(b)
+	records := benchmarkIntents(b, b.N)
+
+	b.ResetTimer()
+	if err := s.CreateIntents(ctx, records); err != nil {
+		b.Fatalf("create intents: %v", err)
+	}
+}
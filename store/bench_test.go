@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/internal/synth"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// seedBatch is how many records seedStore writes per transaction.
+const seedBatch = 1000
+
+// seedStore opens a store holding n synthetic records and returns it with
+// the generator, positioned to continue the same chains.
+func seedStore(b *testing.B, n int) (*Store, *synth.Generator) {
+	b.Helper()
+	ctx := context.Background()
+	s, err := Open(filepath.Join(b.TempDir(), "yanzi.db"), WithSynchronous(SynchronousNormal))
+	if err != nil {
+		b.Fatalf("open store: %v", err)
+	}
+	b.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		b.Fatalf("migrate: %v", err)
+	}
+
+	g := synth.New(synth.Options{})
+	for seeded := 0; seeded < n; seeded += seedBatch {
+		batch := nextIntents(b, g, min(seedBatch, n-seeded))
+		if err := s.CreateIntents(ctx, batch); err != nil {
+			b.Fatalf("seed intents: %v", err)
+		}
+	}
+	return s, g
+}
+
+func nextIntents(b *testing.B, g *synth.Generator, n int) []model.IntentRecord {
+	b.Helper()
+	records := make([]model.IntentRecord, n)
+	for i := range records {
+		record, err := g.Next()
+		if err != nil {
+			b.Fatalf("generate intent: %v", err)
+		}
+		records[i] = record
+	}
+	return records
+}
+
+// benchmarkSizes runs fn as a sub-benchmark over a store seeded with each of
+// synth.Sizes.
+func benchmarkSizes(b *testing.B, fn func(b *testing.B, s *Store, g *synth.Generator)) {
+	for _, n := range synth.Sizes() {
+		b.Run(fmt.Sprintf("records=%d", n), func(b *testing.B) {
+			s, g := seedStore(b, n)
+			b.ReportAllocs()
+			fn(b, s, g)
+		})
+	}
+}
+
+func BenchmarkCreateIntent(b *testing.B) {
+	ctx := context.Background()
+	benchmarkSizes(b, func(b *testing.B, s *Store, g *synth.Generator) {
+		records := nextIntents(b, g, b.N)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := s.CreateIntent(ctx, records[i]); err != nil {
+				b.Fatalf("create intent: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkCreateIntents(b *testing.B) {
+	const batchSize = 100
+	ctx := context.Background()
+	benchmarkSizes(b, func(b *testing.B, s *Store, g *synth.Generator) {
+		records := nextIntents(b, g, b.N*batchSize)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := s.CreateIntents(ctx, records[i*batchSize:(i+1)*batchSize]); err != nil {
+				b.Fatalf("create intents: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkListIntentsPage(b *testing.B) {
+	ctx := context.Background()
+	benchmarkSizes(b, func(b *testing.B, s *Store, _ *synth.Generator) {
+		var cursor string
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			page, err := s.ListIntentsPage(ctx, PageOptions{Limit: 100, Cursor: cursor})
+			if err != nil {
+				b.Fatalf("list intents page: %v", err)
+			}
+			cursor = page.NextCursor
+		}
+	})
+}
+
+func BenchmarkVerifyChain(b *testing.B) {
+	ctx := context.Background()
+	benchmarkSizes(b, func(b *testing.B, s *Store, _ *synth.Generator) {
+		head, err := s.ChainHead(ctx, "author-000")
+		if err != nil {
+			b.Fatalf("chain head: %v", err)
+		}
+		var verified int
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			report, err := chain.VerifyChain(ctx, s, head)
+			if err != nil {
+				b.Fatalf("verify chain: %v", err)
+			}
+			if !report.Valid() {
+				b.Fatalf("expected a valid chain, got %d issue(s)", len(report.Issues))
+			}
+			verified += report.Length
+		}
+		b.ReportMetric(float64(verified)/b.Elapsed().Seconds(), "records/s")
+	})
+}
@@ -0,0 +1,268 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	gohash "hash"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// blobChunkSize is the size of each row PutBlobReader writes to blob_chunks.
+const blobChunkSize = 1 << 20
+
+// PutBlob stores data under its content address and returns the digest.
+// Storing the same content twice is a no-op.
+func (s *Store) PutBlob(ctx context.Context, data []byte) (_ string, err error) {
+	ctx, span := s.startSpan(ctx, "PutBlob")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("PutBlob"); err != nil {
+		return "", err
+	}
+	if s.db == nil {
+		return "", errors.New("store not initialized")
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	digest := hash.BlobDigest(data)
+	sealed, err := s.codec.sealBlob(digest, data)
+	if err != nil {
+		return "", err
+	}
+	err = s.retryBusy(ctx, "PutBlob", func() error {
+		_, err := s.db.ExecContext(ctx, `INSERT INTO blobs (digest, size, data, created_at, encoding) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (digest) DO NOTHING`,
+			digest, len(data), sealed, time.Now().UTC().Format(time.RFC3339Nano), s.codec.blobEncoding())
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// PutBlobReader stores the content of r in fixed-size chunks, hashing it as
+// it is read so the content is never fully buffered, and returns its digest
+// and size.
+func (s *Store) PutBlobReader(ctx context.Context, r io.Reader) (_ string, _ int64, err error) {
+	ctx, span := s.startSpan(ctx, "PutBlobReader")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("PutBlobReader"); err != nil {
+		return "", 0, err
+	}
+	var digest string
+	var size int64
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		digest, size, err = s.putBlobStreamTx(ctx, tx, r)
+		return err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return digest, size, nil
+}
+
+// putBlobStreamTx writes r's chunks under a pending key, then files them
+// under the content digest once it is known. Content already stored is
+// discarded.
+func (s *Store) putBlobStreamTx(ctx context.Context, tx *sql.Tx, r io.Reader) (string, int64, error) {
+	id, err := model.NewIntentID()
+	if err != nil {
+		return "", 0, fmt.Errorf("generate pending blob key: %w", err)
+	}
+	pending := "pending:" + id
+
+	h := sha256.New()
+	buf := make([]byte, blobChunkSize)
+	var size int64
+	var chunks int
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			h.Write(buf[:n])
+			chunk, err := s.codec.sealBlob(strconv.Itoa(chunks), buf[:n])
+			if err != nil {
+				return "", 0, err
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO blob_chunks (digest, seq, data) VALUES (?, ?, ?)`, pending, chunks, chunk); err != nil {
+				return "", 0, fmt.Errorf("write blob chunk %d: %w", chunks, err)
+			}
+			size += int64(n)
+			chunks++
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return "", 0, fmt.Errorf("read blob: %w", readErr)
+		}
+	}
+	digest := hash.FormatBlobDigest(h.Sum(nil))
+
+	var exists int
+	err = tx.QueryRowContext(ctx, `SELECT 1 FROM blobs WHERE digest = ?`, digest).Scan(&exists)
+	switch {
+	case err == nil:
+		if _, err := tx.ExecContext(ctx, `DELETE FROM blob_chunks WHERE digest = ?`, pending); err != nil {
+			return "", 0, err
+		}
+		return digest, size, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return "", 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE blob_chunks SET digest = ? WHERE digest = ?`, digest, pending); err != nil {
+		return "", 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO blobs (digest, size, data, created_at, chunks, encoding) VALUES (?, ?, ?, ?, ?, ?)`,
+		digest, size, []byte{}, time.Now().UTC().Format(time.RFC3339Nano), chunks, s.codec.blobEncoding()); err != nil {
+		return "", 0, err
+	}
+	return digest, size, nil
+}
+
+// GetBlob returns the content stored under digest, or a *NotFoundError. The
+// content is re-hashed on read, so a tampered blob is reported as an error.
+func (s *Store) GetBlob(ctx context.Context, digest string) (_ []byte, err error) {
+	ctx, span := s.startSpan(ctx, "GetBlob")
+	defer func() { err = span.end(err) }()
+	rc, err := s.OpenBlob(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// OpenBlob streams the content stored under digest, or returns a *NotFoundError.
+// Chunked blobs are read one chunk at a time. The content is re-hashed as it
+// is read and a mismatch is returned in place of io.EOF.
+func (s *Store) OpenBlob(ctx context.Context, digest string) (_ io.ReadCloser, err error) {
+	ctx, span := s.startStreamSpan(ctx, "OpenBlob")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return s.openBlob(ctx, s.rdb, digest)
+}
+
+// rowQuerier is a *sql.DB or *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// openBlob implements OpenBlob, reading through q.
+func (s *Store) openBlob(ctx context.Context, q rowQuerier, digest string) (io.ReadCloser, error) {
+	var size int64
+	var chunks int
+	var data []byte
+	var encoding sql.NullString
+	err := q.QueryRowContext(ctx, `SELECT size, chunks, data, encoding FROM blobs WHERE digest = ?`, digest).Scan(&size, &chunks, &data, &encoding)
+	if err != nil {
+		return nil, notFound(err, "blob", digest)
+	}
+	var src io.Reader
+	if chunks > 0 {
+		src = &chunkReader{ctx: ctx, q: q, codec: s.codec, encoding: encoding.String, digest: digest, chunks: chunks}
+	} else {
+		if data, err = s.codec.openBlob(encoding.String, digest, data); err != nil {
+			return nil, fmt.Errorf("read blob %s: %w", digest, err)
+		}
+		src = bytes.NewReader(data)
+	}
+	return &verifyingReader{src: src, digest: digest, size: size, h: sha256.New()}, nil
+}
+
+// chunkReader reads a chunked blob's rows in sequence order, decoding each
+// with codec.
+type chunkReader struct {
+	ctx      context.Context
+	q        rowQuerier
+	codec    bodyCodec
+	encoding string
+	digest   string
+	chunks   int
+	next     int
+	buf      []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.next >= r.chunks {
+			return 0, io.EOF
+		}
+		err := r.q.QueryRowContext(r.ctx, `SELECT data FROM blob_chunks WHERE digest = ? AND seq = ?`, r.digest, r.next).Scan(&r.buf)
+		if err != nil {
+			return 0, fmt.Errorf("read blob %s chunk %d: %w", r.digest, r.next, err)
+		}
+		if r.buf, err = r.codec.openBlob(r.encoding, strconv.Itoa(r.next), r.buf); err != nil {
+			return 0, fmt.Errorf("read blob %s chunk %d: %w", r.digest, r.next, err)
+		}
+		r.next++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// verifyingReader hashes content as it passes through and checks it against
+// the expected digest and size at EOF.
+type verifyingReader struct {
+	src    io.Reader
+	digest string
+	size   int64
+	read   int64
+	h      gohash.Hash
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+	r.h.Write(p[:n])
+	r.read += int64(n)
+	if errors.Is(err, io.EOF) {
+		if got := hash.FormatBlobDigest(r.h.Sum(nil)); got != r.digest || r.read != r.size {
+			return n, fmt.Errorf("blob %s is corrupted: content hashes to %s", r.digest, got)
+		}
+	}
+	return n, err
+}
+
+func (r *verifyingReader) Close() error {
+	return nil
+}
+
+// checkAttachmentsTx ensures every attachment of record references a stored
+// blob of the declared size.
+func checkAttachmentsTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	if record.Tombstoned() {
+		// Retention removes a tombstone's attachments along with its blobs.
+		if len(record.Attachments) > 0 {
+			return &model.InvalidRecordError{Err: errors.New("tombstoned record still has attachments")}
+		}
+		return nil
+	}
+	for _, a := range record.Attachments {
+		var size int64
+		err := tx.QueryRowContext(ctx, `SELECT size FROM blobs WHERE digest = ?`, a.Digest).Scan(&size)
+		if errors.Is(err, sql.ErrNoRows) {
+			return &model.InvalidRecordError{Err: fmt.Errorf("attachment %s: blob not stored", a.Digest)}
+		}
+		if err != nil {
+			return err
+		}
+		if size != a.Size {
+			return &model.InvalidRecordError{Err: fmt.Errorf("attachment %s: size %d does not match stored blob size %d", a.Digest, a.Size, size)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestPutAndGetBlob(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	data := []byte("attachment body")
+	digest, err := s.PutBlob(ctx, data)
+	if err != nil {
+		t.Fatalf("put blob: %v", err)
+	}
+	if digest != hash.BlobDigest(data) {
+		t.Fatalf("expected digest %s, got %s", hash.BlobDigest(data), digest)
+	}
+	if again, err := s.PutBlob(ctx, data); err != nil || again != digest {
+		t.Fatalf("expected idempotent put, got %s, %v", again, err)
+	}
+
+	got, err := s.GetBlob(ctx, digest)
+	if err != nil {
+		t.Fatalf("get blob: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Fatalf("expected %q, got %q", data, got)
+	}
+
+	if _, err := s.GetBlob(ctx, hash.BlobDigest([]byte("missing"))); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE blobs SET data = ? WHERE digest = ?`, []byte("tampered"), digest); err != nil {
+		t.Fatalf("tamper blob: %v", err)
+	}
+	if _, err := s.GetBlob(ctx, digest); err == nil {
+		t.Fatalf("expected tampered blob to be rejected")
+	}
+}
+
+func TestIntentAttachments(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	data := []byte("%PDF-1.7")
+	digest, err := s.PutBlob(ctx, data)
+	if err != nil {
+		t.Fatalf("put blob: %v", err)
+	}
+
+	attach := func(n int, a model.Attachment) model.IntentRecord {
+		record := testIntent(t, n)
+		record.Attachments = []model.Attachment{a}
+		sum, err := hash.HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash intent: %v", err)
+		}
+		record.Hash = sum
+		return record
+	}
+
+	record := attach(1, model.Attachment{Digest: digest, Name: "spec.pdf", MediaType: "application/pdf", Size: int64(len(data))})
+	if record.Hash == testIntent(t, 1).Hash {
+		t.Fatalf("expected attachments to change the hash")
+	}
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0] != record.Attachments[0] {
+		t.Fatalf("expected attachments to round-trip, got %+v", got.Attachments)
+	}
+
+	missing := attach(2, model.Attachment{Digest: hash.BlobDigest([]byte("absent")), Size: 6})
+	if err := s.CreateIntent(ctx, missing); err == nil {
+		t.Fatalf("expected unknown blob to be rejected")
+	}
+	wrongSize := attach(3, model.Attachment{Digest: digest, Size: 1})
+	if err := s.CreateIntent(ctx, wrongSize); err == nil {
+		t.Fatalf("expected size mismatch to be rejected")
+	}
+}
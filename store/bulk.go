@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// maxSQLiteVars is the conservative per-statement bound on bound parameters
+// (SQLITE_MAX_VARIABLE_NUMBER in builds before 3.32).
+const maxSQLiteVars = 999
+
+// GetIntentsByHashes resolves many hashes with chunked IN queries. The result is
+// keyed by hash; hashes without a matching intent are omitted.
+func (s *Store) GetIntentsByHashes(ctx context.Context, hashes []string) (_ map[string]model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "GetIntentsByHashes")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	unique := make([]any, 0, len(hashes))
+	seen := make(map[string]struct{}, len(hashes))
+	for _, h := range hashes {
+		if _, ok := seen[h]; ok {
+			continue
+		}
+		seen[h] = struct{}{}
+		unique = append(unique, h)
+	}
+
+	found := make(map[string]model.IntentRecord, len(unique))
+	for start := 0; start < len(unique); start += maxSQLiteVars {
+		end := min(start+maxSQLiteVars, len(unique))
+		chunk := unique[start:end]
+
+		query := `SELECT ` + intentColumns + ` FROM intents WHERE hash IN (` + placeholders(len(chunk)) + `)`
+		rows, err := s.rdb.QueryContext(ctx, query, chunk...)
+		if err != nil {
+			return nil, err
+		}
+		intents, err := s.collectIntents(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range intents {
+			found[record.Hash] = record
+		}
+	}
+	return found, nil
+}
+
+// placeholders returns n comma-separated "?" markers.
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?,", n-1) + "?"
+}
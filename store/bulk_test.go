@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGetIntentsByHashesMixed(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	present := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, present); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	found, err := s.GetIntentsByHashes(ctx, []string{present.Hash, "missing", present.Hash})
+	if err != nil {
+		t.Fatalf("get intents by hashes: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(found))
+	}
+	if found[present.Hash].ID != present.ID {
+		t.Fatalf("expected %s, got %+v", present.ID, found[present.Hash])
+	}
+	if _, ok := found["missing"]; ok {
+		t.Fatalf("expected missing hash to be omitted")
+	}
+}
+
+func TestGetIntentsByHashesChunks(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	const n = maxSQLiteVars + 25
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		record := testIntent(t, i)
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+		hashes = append(hashes, record.Hash)
+	}
+
+	found, err := s.GetIntentsByHashes(ctx, hashes)
+	if err != nil {
+		t.Fatalf("get intents by hashes: %v", err)
+	}
+	if len(found) != n {
+		t.Fatalf("expected %d matches, got %d", n, len(found))
+	}
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"container/list"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// WithIntentCache keeps up to size recently read intents in memory, in front
+// of GetIntent and GetIntentByHash, so repeated lookups such as those made by
+// chain verification skip the database. Misses are not cached. Tombstoning,
+// rehashing, meta canonicalization, merges, and rollbacks through this Store
+// invalidate the affected entries; changes made by other processes writing
+// the same file are not seen until an entry is evicted, so use it only where
+// this Store is the only writer. Values <= 0 disable the cache.
+func WithIntentCache(size int) Option {
+	return func(o *options) {
+		o.cacheSize = size
+	}
+}
+
+// intentCache is a size-bounded LRU of intents by ID, with a hash index.
+// Entries are clones, so callers cannot alter cached records.
+type intentCache struct {
+	mu     sync.Mutex
+	size   int
+	order  *list.List // of model.IntentRecord, most recently used first
+	byID   map[string]*list.Element
+	byHash map[string]*list.Element
+	// gen counts invalidations. A record read from the database is only
+	// cached if no invalidation ran since the read began, so a read racing
+	// a tombstone cannot cache the old content.
+	gen uint64
+}
+
+func newIntentCache(size int) *intentCache {
+	if size <= 0 {
+		return nil
+	}
+	return &intentCache{
+		size:   size,
+		order:  list.New(),
+		byID:   make(map[string]*list.Element),
+		byHash: make(map[string]*list.Element),
+	}
+}
+
+// generation returns the value to pass to add for a read starting now.
+func (c *intentCache) generation() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+func (c *intentCache) getByID(id string) (model.IntentRecord, bool) {
+	if c == nil {
+		return model.IntentRecord{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hit(c.byID[id])
+}
+
+func (c *intentCache) getByHash(hash string) (model.IntentRecord, bool) {
+	if c == nil {
+		return model.IntentRecord{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hit(c.byHash[hash])
+}
+
+func (c *intentCache) hit(e *list.Element) (model.IntentRecord, bool) {
+	if e == nil {
+		return model.IntentRecord{}, false
+	}
+	c.order.MoveToFront(e)
+	return cloneIntent(e.Value.(model.IntentRecord)), true
+}
+
+// add caches record, read from the database since generation returned gen.
+func (c *intentCache) add(gen uint64, record model.IntentRecord) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if e, ok := c.byID[record.ID]; ok {
+		c.remove(e)
+	}
+	e := c.order.PushFront(cloneIntent(record))
+	c.byID[record.ID] = e
+	c.byHash[record.Hash] = e
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidate drops the intents with ids, or every intent when ids is empty.
+func (c *intentCache) invalidate(ids ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	if len(ids) == 0 {
+		c.order.Init()
+		clear(c.byID)
+		clear(c.byHash)
+		return
+	}
+	for _, id := range ids {
+		if e, ok := c.byID[id]; ok {
+			c.remove(e)
+		}
+	}
+}
+
+func (c *intentCache) remove(e *list.Element) {
+	record := c.order.Remove(e).(model.IntentRecord)
+	delete(c.byID, record.ID)
+	if c.byHash[record.Hash] == e {
+		delete(c.byHash, record.Hash)
+	}
+}
+
+// cloneIntent copies record's slices so the copy shares no memory with it.
+func cloneIntent(record model.IntentRecord) model.IntentRecord {
+	record.Meta = json.RawMessage(slices.Clone([]byte(record.Meta)))
+	record.Tags = slices.Clone(record.Tags)
+	record.Attachments = slices.Clone(record.Attachments)
+	return record
+}
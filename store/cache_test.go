@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestIntentCache(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithIntentCache(2))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	record := testIntent(t, 1)
+	record.Tags = []string{"cached"}
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	got.Tags[0] = "changed"
+
+	// A change behind the store's back shows the second read is cached.
+	if _, err := s.db.ExecContext(ctx, `UPDATE intents SET prompt = 'edited' WHERE id = ?`, record.ID); err != nil {
+		t.Fatalf("edit intent: %v", err)
+	}
+	byHash, err := s.GetIntentByHash(ctx, record.Hash)
+	if err != nil {
+		t.Fatalf("get intent by hash: %v", err)
+	}
+	if byHash.Prompt != record.Prompt || byHash.Tags[0] != "cached" {
+		t.Fatalf("expected the unaltered cached record, got prompt %q tags %v", byHash.Prompt, byHash.Tags)
+	}
+
+	if err := s.TombstoneIntent(ctx, record.ID); err != nil {
+		t.Fatalf("tombstone intent: %v", err)
+	}
+	got, err = s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if !got.Tombstoned() {
+		t.Fatalf("expected tombstoning to invalidate the cached record")
+	}
+
+	for n := 2; n <= 4; n++ {
+		r := testIntent(t, n)
+		if err := s.CreateIntent(ctx, r); err != nil {
+			t.Fatalf("create intent: %v", err)
+		}
+		if _, err := s.GetIntent(ctx, r.ID); err != nil {
+			t.Fatalf("get intent: %v", err)
+		}
+	}
+	if n := s.cache.order.Len(); n != 2 || len(s.cache.byID) != 2 || len(s.cache.byHash) != 2 {
+		t.Fatalf("expected the cache bounded to 2 entries, got %d", n)
+	}
+}
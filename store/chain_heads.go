@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ChainKeyFunc names the chain a record belongs to. Returning "" leaves the
+// record out of head tracking.
+type ChainKeyFunc func(record model.IntentRecord) string
+
+// ChainByAuthor is the default ChainKeyFunc: one chain per author.
+func ChainByAuthor(record model.IntentRecord) string {
+	return record.Author
+}
+
+// WithChainKey overrides how records map to chains in chain_heads.
+func WithChainKey(fn ChainKeyFunc) Option {
+	return func(o *options) {
+		if fn != nil {
+			o.chainKey = fn
+		}
+	}
+}
+
+// advanceChainHeadSQL creates a head for a new chain, or moves an existing head
+// only when the record links to it, so forks never displace the current head.
+const advanceChainHeadSQL = `INSERT INTO chain_heads (chain, head_hash, updated_at) VALUES (?, ?, ?)
+	ON CONFLICT (chain) DO UPDATE SET head_hash = excluded.head_hash, updated_at = excluded.updated_at
+	WHERE chain_heads.head_hash = ?`
+
+// selectChainHeadSQL reads the head hash of a chain.
+const selectChainHeadSQL = `SELECT head_hash FROM chain_heads WHERE chain = ?`
+
+// advanceChainHead updates the head of record's chain within tx and reports
+// whether the head now points at record.
+func (s *Store) advanceChainHead(ctx context.Context, tx *sql.Tx, record model.IntentRecord) (bool, error) {
+	chain := s.opts.chainKey(record)
+	if chain == "" {
+		return false, nil
+	}
+	stmt, err := s.txStmt(ctx, tx, advanceChainHeadSQL)
+	if err != nil {
+		return false, err
+	}
+	res, err := stmt.ExecContext(ctx, chain, record.Hash, s.now().Format(time.RFC3339Nano), record.PrevHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// ChainHead returns the current head hash of chain, or a *NotFoundError if the
+// chain has no records.
+func (s *Store) ChainHead(ctx context.Context, chain string) (_ string, err error) {
+	ctx, span := s.startSpan(ctx, "ChainHead")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return "", errors.New("store not initialized")
+	}
+	stmt, err := s.preparedRead(ctx, selectChainHeadSQL)
+	if err != nil {
+		return "", err
+	}
+	var head string
+	err = stmt.QueryRowContext(ctx, chain).Scan(&head)
+	return head, notFound(err, "chain", chain)
+}
+
+// ChainHeads returns the head hash of every tracked chain.
+func (s *Store) ChainHeads(ctx context.Context) (_ map[string]string, err error) {
+	ctx, span := s.startSpan(ctx, "ChainHeads")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return chainHeads(ctx, s.db)
+}
+
+func chainHeads(ctx context.Context, q queryer) (map[string]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT chain, head_hash FROM chain_heads`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	heads := make(map[string]string)
+	for rows.Next() {
+		var chain, head string
+		if err := rows.Scan(&chain, &head); err != nil {
+			return nil, err
+		}
+		heads[chain] = head
+	}
+	return heads, rows.Err()
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// linkedIntent returns testIntent(n) for author linked to prev and rehashed.
+func linkedIntent(t *testing.T, n int, author, prev string) model.IntentRecord {
+	t.Helper()
+	record := testIntent(t, n)
+	record.Author = author
+	record.PrevHash = prev
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	record.Hash = sum
+	return record
+}
+
+func TestChainHeadAdvancesOnCreate(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	if _, err := s.ChainHead(ctx, "alice"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected no head before first intent, got %v", err)
+	}
+
+	first := linkedIntent(t, 1, "alice", "")
+	second := linkedIntent(t, 2, "alice", first.Hash)
+	fork := linkedIntent(t, 3, "alice", first.Hash)
+	other := linkedIntent(t, 4, "bob", "")
+	for _, record := range []model.IntentRecord{first, second, fork, other} {
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create %s: %v", record.ID, err)
+		}
+	}
+
+	head, err := s.ChainHead(ctx, "alice")
+	if err != nil {
+		t.Fatalf("chain head: %v", err)
+	}
+	if head != second.Hash {
+		t.Fatalf("expected head %s (fork must not displace it), got %s", second.Hash, head)
+	}
+
+	heads, err := s.ChainHeads(ctx)
+	if err != nil {
+		t.Fatalf("chain heads: %v", err)
+	}
+	if len(heads) != 2 || heads["bob"] != other.Hash {
+		t.Fatalf("unexpected heads: %v", heads)
+	}
+}
+
+func TestChainHeadsBackfilledByMigration(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	for tableExists(t, s, "chain_heads") {
+		if err := s.Rollback(ctx, 1); err != nil {
+			t.Fatalf("rollback chain_heads: %v", err)
+		}
+	}
+
+	first := linkedIntent(t, 1, "alice", "")
+	second := linkedIntent(t, 2, "alice", first.Hash)
+	for _, record := range []model.IntentRecord{first, second} {
+		// Use the columns that exist before chain_heads; later migrations add more.
+		if _, err := s.db.ExecContext(ctx, `INSERT INTO intents (id, created_at, author, source_type, prompt, response, prev_hash, hash)
+			VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)`,
+			record.ID, record.CreatedAt, record.Author, record.SourceType, record.Prompt, record.Response, record.PrevHash, record.Hash); err != nil {
+			t.Fatalf("insert %s: %v", record.ID, err)
+		}
+	}
+
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	head, err := s.ChainHead(ctx, "alice")
+	if err != nil {
+		t.Fatalf("chain head: %v", err)
+	}
+	if head != second.Hash {
+		t.Fatalf("expected backfilled head %s, got %s", second.Hash, head)
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// ChangeOp identifies the kind of change recorded in the intent changelog.
+type ChangeOp string
+
+const (
+	ChangeInsert ChangeOp = "insert"
+	ChangeUpdate ChangeOp = "update"
+	ChangeDelete ChangeOp = "delete"
+)
+
+// ChangeEvent is one row of the intent_changelog table, written by triggers on intents.
+type ChangeEvent struct {
+	Seq       int64
+	IntentID  string
+	Op        ChangeOp
+	ChangedAt string
+	// Tombstoned is set on the update that tombstoned the intent.
+	Tombstoned bool
+}
+
+const readChangelogSQL = `SELECT seq, intent_id, op, changed_at, tombstoned FROM intent_changelog WHERE seq > ? ORDER BY seq ASC LIMIT ?`
+
+// ReadChangelog returns changelog events with a sequence greater than sinceSeq,
+// in sequence order. Consumers persist the last Seq they processed and pass it
+// back to resume; limit follows the same default and clamping as ListIntents.
+func (s *Store) ReadChangelog(ctx context.Context, sinceSeq int64, limit int) (_ []ChangeEvent, err error) {
+	ctx, span := s.startSpan(ctx, "ReadChangelog")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	limit = s.clampLimit(limit)
+
+	stmt, err := s.preparedRead(ctx, readChangelogSQL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, sinceSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []ChangeEvent
+	for rows.Next() {
+		var event ChangeEvent
+		if err := rows.Scan(&event.Seq, &event.IntentID, &event.Op, &event.ChangedAt, &event.Tombstoned); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
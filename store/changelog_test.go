@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReadChangelogRecordsOperationsInOrder(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	first := testIntent(t, 1)
+	second := testIntent(t, 2)
+	if err := s.CreateIntent(ctx, first); err != nil {
+		t.Fatalf("create first: %v", err)
+	}
+	if err := s.CreateIntent(ctx, second); err != nil {
+		t.Fatalf("create second: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE intents SET title = 'renamed' WHERE id = ?`, first.ID); err != nil {
+		t.Fatalf("update first: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM intents WHERE id = ?`, second.ID); err != nil {
+		t.Fatalf("delete second: %v", err)
+	}
+
+	events, err := s.ReadChangelog(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("read changelog: %v", err)
+	}
+	want := []ChangeEvent{
+		{IntentID: first.ID, Op: ChangeInsert},
+		{IntentID: second.ID, Op: ChangeInsert},
+		{IntentID: first.ID, Op: ChangeUpdate},
+		{IntentID: second.ID, Op: ChangeDelete},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, event := range events {
+		if event.IntentID != want[i].IntentID || event.Op != want[i].Op {
+			t.Fatalf("event %d: expected %s %s, got %s %s", i, want[i].Op, want[i].IntentID, event.Op, event.IntentID)
+		}
+		if i > 0 && event.Seq <= events[i-1].Seq {
+			t.Fatalf("expected increasing seq, got %d after %d", event.Seq, events[i-1].Seq)
+		}
+	}
+
+	rest, err := s.ReadChangelog(ctx, events[1].Seq, 10)
+	if err != nil {
+		t.Fatalf("resume changelog: %v", err)
+	}
+	if len(rest) != 2 || rest[0].Seq != events[2].Seq {
+		t.Fatalf("expected to resume after seq %d, got %+v", events[1].Seq, rest)
+	}
+}
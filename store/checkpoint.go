@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+)
+
+// Checkpoint commits to the state of the store at a point in time: the number
+// of intents, the newest chain head (the newest intent no other intent links
+// to), and the Merkle root over every intent hash in canonical (created_at,
+// id) order. Verifiers that trust a checkpoint can stop walking a chain once
+// they reach HeadHash.
+type Checkpoint struct {
+	Seq        int64
+	Count      int
+	HeadHash   string
+	MerkleRoot string
+	CreatedAt  string
+	// Signature is nil unless a CheckpointSigner was configured.
+	Signature []byte
+}
+
+// Payload returns the bytes a CheckpointSigner signs.
+func (c Checkpoint) Payload() []byte {
+	return []byte("yanzi-checkpoint\n" +
+		strconv.Itoa(c.Count) + "\n" +
+		c.HeadHash + "\n" +
+		c.MerkleRoot + "\n" +
+		c.CreatedAt)
+}
+
+// CheckpointSigner signs a checkpoint payload.
+type CheckpointSigner func(payload []byte) ([]byte, error)
+
+// WithCheckpointSigner signs every checkpoint the store writes.
+func WithCheckpointSigner(sign CheckpointSigner) Option {
+	return func(o *options) {
+		o.checkpointSigner = sign
+	}
+}
+
+// WithCheckpointInterval writes a checkpoint in the same transaction as the
+// write that brings the intent count to a multiple of n. The count is kept
+// by a trigger, so writes between checkpoints only read it; the write that
+// crosses the interval also rebuilds the Merkle root over every intent.
+// Values <= 0 disable automatic checkpoints; WriteCheckpoint still works on
+// demand.
+func WithCheckpointInterval(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.checkpointInterval = n
+		}
+	}
+}
+
+const checkpointColumns = `seq, intent_count, head_hash, merkle_root, created_at, signature`
+
+// WriteCheckpoint records a checkpoint over every stored intent. It fails on
+// an empty store.
+func (s *Store) WriteCheckpoint(ctx context.Context) (_ Checkpoint, err error) {
+	ctx, span := s.startSpan(ctx, "WriteCheckpoint")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("WriteCheckpoint"); err != nil {
+		return Checkpoint{}, err
+	}
+	var cp Checkpoint
+	err = s.withRetryTx(ctx, "WriteCheckpoint", func(tx *sql.Tx) error {
+		var err error
+		cp, err = s.writeCheckpointTx(ctx, tx)
+		return err
+	})
+	return cp, err
+}
+
+// LatestCheckpoint returns the most recent checkpoint, or a *NotFoundError if
+// none has been written.
+func (s *Store) LatestCheckpoint(ctx context.Context) (_ Checkpoint, err error) {
+	ctx, span := s.startSpan(ctx, "LatestCheckpoint")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return Checkpoint{}, errors.New("store not initialized")
+	}
+	row := s.rdb.QueryRowContext(ctx, `SELECT `+checkpointColumns+` FROM checkpoints ORDER BY seq DESC LIMIT 1`)
+	cp, err := scanCheckpoint(row)
+	return cp, notFound(err, "checkpoint", "")
+}
+
+// ListCheckpoints returns checkpoints oldest first.
+func (s *Store) ListCheckpoints(ctx context.Context) (_ []Checkpoint, err error) {
+	ctx, span := s.startSpan(ctx, "ListCheckpoints")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+checkpointColumns+` FROM checkpoints ORDER BY seq ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []Checkpoint
+	for rows.Next() {
+		cp, err := scanCheckpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, rows.Err()
+}
+
+// Queries checkpointDueTx runs after every insert once an interval is set.
+const (
+	countIntentsSQL        = `SELECT n FROM intent_count WHERE id = 1`
+	lastCheckpointCountSQL = `SELECT COALESCE(MAX(intent_count), 0) FROM checkpoints`
+)
+
+// checkpointDueTx writes a checkpoint within tx when the configured interval
+// has been crossed since the last one.
+func (s *Store) checkpointDueTx(ctx context.Context, tx *sql.Tx) error {
+	every := s.opts.checkpointInterval
+	if every <= 0 {
+		return nil
+	}
+	countStmt, err := s.txStmt(ctx, tx, countIntentsSQL)
+	if err != nil {
+		return err
+	}
+	lastStmt, err := s.txStmt(ctx, tx, lastCheckpointCountSQL)
+	if err != nil {
+		return err
+	}
+	var count, last int
+	if err := countStmt.QueryRowContext(ctx).Scan(&count); err != nil {
+		return err
+	}
+	if err := lastStmt.QueryRowContext(ctx).Scan(&last); err != nil {
+		return err
+	}
+	if count/every <= last/every {
+		return nil
+	}
+	_, err = s.writeCheckpointTx(ctx, tx)
+	return err
+}
+
+// selectNewestHeadSQL finds the newest intent no other intent links to.
+const selectNewestHeadSQL = `SELECT i.hash FROM intents i
+	WHERE NOT EXISTS (SELECT 1 FROM intents n WHERE n.prev_hash = i.hash)
+	ORDER BY i.created_at DESC, i.id DESC LIMIT 1`
+
+func (s *Store) writeCheckpointTx(ctx context.Context, tx *sql.Tx) (Checkpoint, error) {
+	hashes, err := intentHashes(ctx, tx, time.Time{})
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("list intent hashes: %w", err)
+	}
+	if len(hashes) == 0 {
+		return Checkpoint{}, errors.New("no intents to checkpoint")
+	}
+	var head string
+	if err := tx.QueryRowContext(ctx, selectNewestHeadSQL).Scan(&head); err != nil {
+		return Checkpoint{}, fmt.Errorf("find chain head: %w", err)
+	}
+
+	cp := Checkpoint{
+		Count:      len(hashes),
+		HeadHash:   head,
+		MerkleRoot: hash.MerkleRoot(hashes),
+		CreatedAt:  s.now().Format(time.RFC3339Nano),
+	}
+	if sign := s.opts.checkpointSigner; sign != nil {
+		sig, err := sign(cp.Payload())
+		if err != nil {
+			return Checkpoint{}, fmt.Errorf("sign checkpoint: %w", err)
+		}
+		cp.Signature = sig
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO checkpoints (intent_count, head_hash, merkle_root, created_at, signature) VALUES (?, ?, ?, ?, ?)`,
+		cp.Count, cp.HeadHash, cp.MerkleRoot, cp.CreatedAt, cp.Signature)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if cp.Seq, err = res.LastInsertId(); err != nil {
+		return Checkpoint{}, err
+	}
+	return cp, nil
+}
+
+func scanCheckpoint(row rowScanner) (Checkpoint, error) {
+	var cp Checkpoint
+	err := row.Scan(&cp.Seq, &cp.Count, &cp.HeadHash, &cp.MerkleRoot, &cp.CreatedAt, &cp.Signature)
+	return cp, err
+}
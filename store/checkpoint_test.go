@@ -0,0 +1,122 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestWriteCheckpointOnDemand(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	if _, err := s.LatestCheckpoint(ctx); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected no checkpoint, got %v", err)
+	}
+	if _, err := s.WriteCheckpoint(ctx); err == nil {
+		t.Fatalf("expected checkpoint of empty store to fail")
+	}
+
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		record := testIntent(t, i)
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+		hashes = append(hashes, record.Hash)
+	}
+
+	cp, err := s.WriteCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+	if cp.Count != 3 || cp.HeadHash != hashes[2] || cp.MerkleRoot != hash.MerkleRoot(hashes) || cp.Signature != nil {
+		t.Fatalf("unexpected checkpoint: %+v", cp)
+	}
+
+	latest, err := s.LatestCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("latest checkpoint: %v", err)
+	}
+	if latest.Seq != cp.Seq || latest.MerkleRoot != cp.MerkleRoot {
+		t.Fatalf("expected latest %+v, got %+v", cp, latest)
+	}
+}
+
+func TestCheckpointIntervalSignsAutomatically(t *testing.T) {
+	ctx := context.Background()
+	dbPath := t.TempDir() + "/checkpoints.db"
+	sign := func(payload []byte) ([]byte, error) {
+		return append([]byte("sig:"), payload...), nil
+	}
+	s, err := Open(dbPath, WithCheckpointInterval(2), WithCheckpointSigner(sign))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := s.CreateIntent(ctx, testIntent(t, i)); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+	batch := []model.IntentRecord{testIntent(t, 3), testIntent(t, 4)}
+	if err := s.CreateIntents(ctx, batch); err != nil {
+		t.Fatalf("create intents: %v", err)
+	}
+	if _, err := s.AppendIntent(ctx, model.IntentRecord{
+		CreatedAt:  time.Date(2026, 2, 9, 11, 0, 0, 0, time.UTC).Format(time.RFC3339Nano),
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "p",
+		Response:   "r",
+	}); err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+
+	checkpoints, err := s.ListCheckpoints(ctx)
+	if err != nil {
+		t.Fatalf("list checkpoints: %v", err)
+	}
+	var counts []int
+	for _, cp := range checkpoints {
+		counts = append(counts, cp.Count)
+		if !bytes.Equal(cp.Signature, append([]byte("sig:"), cp.Payload()...)) {
+			t.Fatalf("checkpoint %d has unexpected signature %q", cp.Seq, cp.Signature)
+		}
+	}
+	if len(counts) != 3 || counts[0] != 2 || counts[1] != 5 || counts[2] != 6 {
+		t.Fatalf("expected checkpoints at 2, 5 and 6 intents, got %v", counts)
+	}
+}
+
+func TestCheckpointHeadIsChainHead(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	// The child is stamped before its parent, so the newest row is not the
+	// head of the chain.
+	parent := linkedIntent(t, 5, "alice", "")
+	child := linkedIntent(t, 1, "alice", parent.Hash)
+	for _, record := range []model.IntentRecord{parent, child} {
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent: %v", err)
+		}
+	}
+	cp, err := s.WriteCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("write checkpoint: %v", err)
+	}
+	if cp.Count != 2 || cp.HeadHash != child.Hash {
+		t.Fatalf("expected head %s, got %+v", child.Hash, cp)
+	}
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Clock supplies the current time to the store.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a plain function to the Clock interface.
+type ClockFunc func() time.Time
+
+// Now calls f.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock reads the system time; it is the default Clock.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// ErrClockSkew is returned by AppendIntent and the create methods when a
+// record's CreatedAt is older than the head of its chain by more than the
+// skew allowed with WithMonotonicTimestamps.
+var ErrClockSkew = errors.New("created_at is older than the chain head")
+
+// WithClock sets the clock used for the timestamps the store assigns: a
+// missing CreatedAt on append, tombstones, retention, meta revisions,
+// checkpoints, chain head updates, idempotency key expiry, and rate limiting.
+// A nil clock keeps SystemClock. Tests can pass a fixed clock to freeze time.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		if c != nil {
+			o.clock = c
+		}
+	}
+}
+
+// WithServerTimestamps makes AppendIntent and CreateIntentStream always set
+// CreatedAt from the store's clock, replacing any value the caller supplied,
+// so clients cannot backdate records.
+func WithServerTimestamps() Option {
+	return func(o *options) {
+		o.serverTimestamps = true
+	}
+}
+
+// WithMonotonicTimestamps makes AppendIntent and the create methods reject,
+// with ErrClockSkew, a record whose CreatedAt is earlier than the CreatedAt of
+// its chain head by more than skew. Records that belong to no chain are not
+// checked. A skew of 0 requires timestamps never to go backwards;
+// negative values are treated as 0.
+func WithMonotonicTimestamps(skew time.Duration) Option {
+	return func(o *options) {
+		o.monotonic = true
+		o.maxClockSkew = max(skew, 0)
+	}
+}
+
+// now returns the current UTC time from the configured clock.
+func (s *Store) now() time.Time {
+	return s.opts.clock.Now().UTC()
+}
+
+// stampCreatedAt fills record's CreatedAt from the clock when it is missing,
+// or always when server timestamps are enabled.
+func (s *Store) stampCreatedAt(record model.IntentRecord) model.IntentRecord {
+	if record.CreatedAt == "" || s.opts.serverTimestamps {
+		record.CreatedAt = s.now().Format(time.RFC3339Nano)
+	}
+	return record
+}
+
+// selectChainHeadTimeSQL reads the created_at of a chain's head record.
+const selectChainHeadTimeSQL = `SELECT i.created_at FROM chain_heads h JOIN intents i ON i.hash = h.head_hash WHERE h.chain = ?`
+
+// checkSkewTx enforces WithMonotonicTimestamps for record against the head of
+// its chain read within tx. An empty chain accepts any timestamp.
+func (s *Store) checkSkewTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	key := s.opts.chainKey(record)
+	if !s.opts.monotonic || key == "" {
+		return nil
+	}
+	stmt, err := s.txStmt(ctx, tx, selectChainHeadTimeSQL)
+	if err != nil {
+		return err
+	}
+	var head string
+	err = stmt.QueryRowContext(ctx, key).Scan(&head)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read chain head time: %w", err)
+	}
+	created, err := time.Parse(time.RFC3339Nano, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("parse created_at: %w", err)
+	}
+	headAt, err := time.Parse(time.RFC3339Nano, head)
+	if err != nil {
+		return fmt.Errorf("parse chain head created_at: %w", err)
+	}
+	if behind := headAt.Sub(created); behind > s.opts.maxClockSkew {
+		return fmt.Errorf("%w: %s is %s before the head at %s (allowed skew %s)", ErrClockSkew, record.CreatedAt, behind, head, s.opts.maxClockSkew)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func openClockStore(t *testing.T, opts ...Option) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), opts...)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func TestClockAssignsTimestamps(t *testing.T) {
+	ctx := context.Background()
+	frozen := time.Date(2026, 3, 4, 5, 6, 7, 8, time.UTC)
+	s := openClockStore(t, WithClock(ClockFunc(func() time.Time { return frozen })), WithCheckpointInterval(1))
+	record := model.IntentRecord{Author: "alice", SourceType: "cli", Prompt: "p", Response: "r"}
+
+	stored, err := s.AppendIntent(ctx, record)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	want := frozen.Format(time.RFC3339Nano)
+	if stored.CreatedAt != want {
+		t.Fatalf("expected created_at %s, got %s", want, stored.CreatedAt)
+	}
+	cp, err := s.LatestCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("latest checkpoint: %v", err)
+	}
+	if cp.CreatedAt != want {
+		t.Fatalf("expected checkpoint at %s, got %s", want, cp.CreatedAt)
+	}
+
+	record.CreatedAt = "2020-01-01T00:00:00Z"
+	stored, err = s.AppendIntent(ctx, record)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if stored.CreatedAt != record.CreatedAt {
+		t.Fatalf("expected the caller's created_at to be kept, got %s", stored.CreatedAt)
+	}
+
+	if err := s.TombstoneIntent(ctx, stored.ID); err != nil {
+		t.Fatalf("tombstone: %v", err)
+	}
+	tombstoned, err := s.GetIntent(ctx, stored.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if tombstoned.TombstonedAt != want {
+		t.Fatalf("expected tombstoned_at %s, got %s", want, tombstoned.TombstonedAt)
+	}
+}
+
+func TestServerTimestamps(t *testing.T) {
+	ctx := context.Background()
+	frozen := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	s := openClockStore(t, WithClock(ClockFunc(func() time.Time { return frozen })), WithServerTimestamps())
+
+	stored, err := s.AppendIntent(ctx, model.IntentRecord{
+		Author: "alice", SourceType: "cli", Prompt: "p", Response: "r", CreatedAt: "2020-01-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if want := frozen.Format(time.RFC3339Nano); stored.CreatedAt != want {
+		t.Fatalf("expected the server time %s, got %s", want, stored.CreatedAt)
+	}
+}
+
+func TestMonotonicTimestamps(t *testing.T) {
+	ctx := context.Background()
+	s := openClockStore(t, WithMonotonicTimestamps(time.Minute))
+	at := func(author, createdAt string) error {
+		_, err := s.AppendIntent(ctx, model.IntentRecord{Author: author, SourceType: "cli", Prompt: "p", Response: "r", CreatedAt: createdAt})
+		return err
+	}
+
+	if err := at("alice", "2026-01-01T12:00:00Z"); err != nil {
+		t.Fatalf("append head: %v", err)
+	}
+	if err := at("alice", "2026-01-01T11:59:30Z"); err != nil {
+		t.Fatalf("expected a record within the skew to be accepted, got %v", err)
+	}
+	if err := at("alice", "2026-01-01T11:58:00Z"); !errors.Is(err, ErrClockSkew) {
+		t.Fatalf("expected ErrClockSkew, got %v", err)
+	}
+	if err := at("bob", "2020-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("expected bob's chain to be checked separately, got %v", err)
+	}
+
+	backdated := model.IntentRecord{ID: "backdated", Author: "alice", SourceType: "cli", Prompt: "p", Response: "r", CreatedAt: "2026-01-01T11:00:00Z"}
+	sum, err := hash.HashIntent(backdated)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	backdated.Hash = sum
+	if err := s.CreateIntent(ctx, backdated); !errors.Is(err, ErrClockSkew) {
+		t.Fatalf("expected CreateIntent to enforce the skew, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// bodyCodec encodes the prompt, response, and meta columns at rest. The steps
+// applied to a row are recorded in body_encoding joined by "+", in the order
+// they were applied: "zstd", "aes-256-gcm", or "zstd+aes-256-gcm". Compression
+// covers only prompt and response; encryption covers all three.
+type bodyCodec struct {
+	compression Compression
+	aead        cipher.AEAD
+}
+
+// newBodyCodec builds the codec selected by the store options.
+func newBodyCodec(o options) (bodyCodec, error) {
+	codec := bodyCodec{compression: o.compression}
+	if o.encryptionKey != nil {
+		aead, err := newAEAD(o.encryptionKey)
+		if err != nil {
+			return bodyCodec{}, err
+		}
+		codec.aead = aead
+	}
+	return codec, nil
+}
+
+// encodedColumns holds the at-rest values of the encoded columns.
+type encodedColumns struct {
+	prompt, response, meta any
+	// encoding is the body_encoding value, or nil for a plain row.
+	encoding any
+}
+
+// encode returns the column values for record.
+func (c bodyCodec) encode(record model.IntentRecord) (encodedColumns, error) {
+	meta := record.NormalizedMeta()
+	prompt, response, compressed, err := compressBodies(c.compression, []byte(record.Prompt), []byte(record.Response))
+	if err != nil {
+		return encodedColumns{}, err
+	}
+
+	var steps []string
+	if compressed {
+		steps = append(steps, string(CompressionZstd))
+	}
+	if c.aead != nil {
+		if prompt, err = sealColumn(c.aead, record.ID, "prompt", prompt); err != nil {
+			return encodedColumns{}, err
+		}
+		if response, err = sealColumn(c.aead, record.ID, "response", response); err != nil {
+			return encodedColumns{}, err
+		}
+		if meta != nil {
+			if meta, err = sealColumn(c.aead, record.ID, "meta", meta); err != nil {
+				return encodedColumns{}, err
+			}
+		}
+		steps = append(steps, encryptionAESGCM)
+	}
+
+	if len(steps) == 0 {
+		cols := encodedColumns{prompt: record.Prompt, response: record.Response}
+		if meta != nil {
+			cols.meta = string(meta)
+		}
+		return cols, nil
+	}
+	cols := encodedColumns{prompt: prompt, response: response, encoding: strings.Join(steps, "+")}
+	if meta != nil {
+		cols.meta = meta
+	}
+	return cols, nil
+}
+
+// decodeBody reverses encode for the prompt or response column.
+func (c bodyCodec) decodeBody(encoding, id, column string, raw []byte) (string, error) {
+	compressed, encrypted, err := parseBodyEncoding(encoding)
+	if err != nil {
+		return "", err
+	}
+	if encrypted {
+		if raw, err = c.open(id, column, raw); err != nil {
+			return "", err
+		}
+	}
+	if compressed {
+		if raw, err = decompressBody(raw); err != nil {
+			return "", err
+		}
+	}
+	return string(raw), nil
+}
+
+// encodeMeta encodes a replacement meta value for a row stored with encoding.
+func (c bodyCodec) encodeMeta(encoding, id string, meta []byte) (any, error) {
+	_, encrypted, err := parseBodyEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return string(meta), nil
+	}
+	if c.aead == nil {
+		return nil, errors.New("row is encrypted; open the store WithEncryptionKey")
+	}
+	return sealColumn(c.aead, id, "meta", meta)
+}
+
+// decodeMeta reverses encode for the meta column.
+func (c bodyCodec) decodeMeta(encoding, id string, raw []byte) ([]byte, error) {
+	_, encrypted, err := parseBodyEncoding(encoding)
+	if err != nil || !encrypted || raw == nil {
+		return raw, err
+	}
+	return c.open(id, "meta", raw)
+}
+
+func (c bodyCodec) open(id, column string, sealed []byte) ([]byte, error) {
+	if c.aead == nil {
+		return nil, errors.New("row is encrypted; open the store WithEncryptionKey")
+	}
+	plain, err := openColumn(c.aead, id, column, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return plain, nil
+}
+
+// blobEncoding is the blobs.encoding value for content sealed by sealBlob, or
+// nil for plaintext.
+func (c bodyCodec) blobEncoding() any {
+	if c.aead == nil {
+		return nil
+	}
+	return encryptionAESGCM
+}
+
+// sealBlob encrypts blob content bound to label (see blobAAD) when the store
+// has an encryption key.
+func (c bodyCodec) sealBlob(label string, data []byte) ([]byte, error) {
+	if c.aead == nil {
+		return data, nil
+	}
+	return seal(c.aead, blobAAD(label), data)
+}
+
+// openBlob reverses sealBlob for content stored with encoding.
+func (c bodyCodec) openBlob(encoding, label string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "":
+		return data, nil
+	case encryptionAESGCM:
+	default:
+		return nil, fmt.Errorf("unsupported blob encoding %q", encoding)
+	}
+	if c.aead == nil {
+		return nil, errors.New("blob is encrypted; open the store WithEncryptionKey")
+	}
+	plain, err := open(c.aead, blobAAD(label), data)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return plain, nil
+}
+
+// parseBodyEncoding reports which steps a body_encoding value records.
+func parseBodyEncoding(encoding string) (compressed, encrypted bool, err error) {
+	if encoding == "" {
+		return false, false, nil
+	}
+	for _, step := range strings.Split(encoding, "+") {
+		switch step {
+		case string(CompressionZstd):
+			compressed = true
+		case encryptionAESGCM:
+			encrypted = true
+		default:
+			return false, false, fmt.Errorf("unsupported body encoding %q", encoding)
+		}
+	}
+	return compressed, encrypted, nil
+}
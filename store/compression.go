@@ -0,0 +1,67 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression names an at-rest encoding for the prompt and response columns.
+type Compression string
+
+const (
+	// CompressionNone stores bodies as plain text.
+	CompressionNone Compression = ""
+	// CompressionZstd stores bodies zstd-compressed when that makes them smaller.
+	CompressionZstd Compression = "zstd"
+)
+
+// compressionMinSize is the smallest body worth attempting to compress.
+const compressionMinSize = 256
+
+// WithCompression compresses the prompt and response of newly inserted intents.
+// Each row records its encoding in body_encoding, so reads are transparent and
+// stores may mix compressed and plain rows; hashes cover the decoded text and
+// are unaffected. Bodies shorter than a few hundred bytes, or that would not
+// shrink, are stored plain.
+func WithCompression(c Compression) Option {
+	return func(o *options) {
+		o.compression = c
+	}
+}
+
+var (
+	zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) { return zstd.NewWriter(nil) })
+	zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) { return zstd.NewReader(nil) })
+)
+
+// compressBodies compresses prompt and response under c. ok is false when
+// they should be stored uncompressed.
+func compressBodies(c Compression, prompt, response []byte) (p, r []byte, ok bool, err error) {
+	if c != CompressionNone && c != CompressionZstd {
+		return nil, nil, false, fmt.Errorf("unsupported compression %q", c)
+	}
+	if c == CompressionNone || len(prompt)+len(response) < compressionMinSize {
+		return prompt, response, false, nil
+	}
+	enc, err := zstdEncoder()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	p = enc.EncodeAll(prompt, nil)
+	r = enc.EncodeAll(response, nil)
+	if len(p)+len(r) >= len(prompt)+len(response) {
+		return prompt, response, false, nil
+	}
+	return p, r, true, nil
+}
+
+// decompressBody reverses compressBodies for one zstd column.
+func decompressBody(raw []byte) ([]byte, error) {
+	dec, err := zstdDecoder()
+	if err != nil {
+		return nil, err
+	}
+	return dec.DecodeAll(raw, nil)
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "yanzi.db")
+	s, err := Open(dbPath, WithCompression(CompressionZstd))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	large := testIntent(t, 1)
+	large.Response = strings.Repeat("the model said the same thing again. ", 200)
+	sum, err := hash.HashIntent(large)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	large.Hash = sum
+	small := testIntent(t, 2)
+	if err := s.CreateIntents(ctx, []model.IntentRecord{large, small}); err != nil {
+		t.Fatalf("create intents: %v", err)
+	}
+
+	var encoding sql.NullString
+	var stored int
+	if err := s.db.QueryRowContext(ctx, `SELECT body_encoding, length(CAST(response AS BLOB)) FROM intents WHERE id = ?`, large.ID).Scan(&encoding, &stored); err != nil {
+		t.Fatalf("read row: %v", err)
+	}
+	if encoding.String != string(CompressionZstd) || stored >= len(large.Response) {
+		t.Fatalf("expected compressed response, got encoding %q and %d bytes", encoding.String, stored)
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT body_encoding FROM intents WHERE id = ?`, small.ID).Scan(&encoding); err != nil {
+		t.Fatalf("read row: %v", err)
+	}
+	if encoding.Valid {
+		t.Fatalf("expected short bodies to be stored plain, got %q", encoding.String)
+	}
+
+	got, err := s.GetIntent(ctx, large.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if got.Response != large.Response || got.Prompt != large.Prompt {
+		t.Fatalf("expected bodies to decode transparently")
+	}
+	if err := hash.VerifyHash(got); err != nil {
+		t.Fatalf("verify hash: %v", err)
+	}
+
+	plain, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	t.Cleanup(func() { _ = plain.Close() })
+	if got, err := plain.GetIntent(ctx, large.ID); err != nil || got.Response != large.Response {
+		t.Fatalf("expected a store without compression to read compressed rows, got %v", err)
+	}
+}
+
+func TestCompressionUnsupported(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithCompression("lz4"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := s.CreateIntent(context.Background(), testIntent(t, 1)); err == nil {
+		t.Fatalf("expected unsupported compression to be rejected")
+	}
+}
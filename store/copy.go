@@ -0,0 +1,311 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// CopySource pages through intents oldest first, as Store.ListIntentsAfter does.
+type CopySource interface {
+	ListIntentsAfter(ctx context.Context, createdAt, id string, limit int) ([]model.IntentRecord, error)
+}
+
+// CopyTarget receives copied intents.
+type CopyTarget interface {
+	CreateIntent(ctx context.Context, record model.IntentRecord) error
+	GetIntentByHash(ctx context.Context, hash string) (model.IntentRecord, error)
+}
+
+// blobOpener and blobWriter let Copy carry attachment blobs between stores
+// that hold them.
+type blobOpener interface {
+	OpenBlob(ctx context.Context, digest string) (io.ReadCloser, error)
+}
+
+type blobWriter interface {
+	PutBlobReader(ctx context.Context, r io.Reader) (string, int64, error)
+}
+
+// CopyOptions selects and configures the intents Copy transfers.
+type CopyOptions struct {
+	// From and To bound created_at to [From, To); zero values are open.
+	From, To time.Time
+	// Author, if set, copies only that author's intents.
+	Author string
+	// BatchSize is the page size read from the source; <= 0 selects
+	// DefaultListLimit.
+	BatchSize int
+	// AllowMissingParents copies records whose prev_hash is in neither the
+	// copy nor the target, e.g. the first record of a time range that starts
+	// mid-chain. Without it Copy fails on them.
+	AllowMissingParents bool
+	// HMACSecret verifies keyed (hmac-sha256) hashes. Without it, records with
+	// keyed hashes are rejected.
+	HMACSecret []byte
+}
+
+// CopyReport summarizes a Copy run.
+type CopyReport struct {
+	Copied int
+	// Skipped counts records whose hash was already in the target.
+	Skipped int
+	// Orphans lists the IDs copied under AllowMissingParents whose parent is
+	// absent from the target.
+	Orphans []string
+}
+
+// Copy transfers intents from src to dst in chain order, verifying each
+// record's hash before writing it, so prev_hash links are never written ahead
+// of their parents. Records already in dst are skipped, which makes an
+// interrupted copy safe to rerun. Attachment blobs are copied along when both
+// sides store blobs. Copy stops at the first invalid record; records before it
+// stay copied.
+func Copy(ctx context.Context, src CopySource, dst CopyTarget, opts CopyOptions) (CopyReport, error) {
+	var report CopyReport
+	if src == nil || dst == nil {
+		return report, errors.New("store not initialized")
+	}
+	c := &copier{src: src, dst: dst, opts: opts, report: &report, copied: make(map[string]struct{})}
+
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = DefaultListLimit
+	}
+	var afterCreatedAt, afterID string
+	for {
+		records, err := src.ListIntentsAfter(ctx, afterCreatedAt, afterID, batch)
+		if err != nil {
+			return report, err
+		}
+		// The source may clamp the page or filter it, so only an empty page
+		// marks the end.
+		if len(records) == 0 {
+			break
+		}
+		for _, record := range records {
+			afterCreatedAt, afterID = record.CreatedAt, record.ID
+			ok, err := c.selected(record)
+			if err != nil {
+				return report, err
+			}
+			if !ok {
+				continue
+			}
+			if err := c.offer(ctx, record); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, c.flush(ctx, "")
+}
+
+// copier holds the state of one Copy run. Records sharing a created_at may be
+// listed child first, so a record whose parent has not been copied waits in
+// pending until the source moves past its timestamp.
+type copier struct {
+	src     CopySource
+	dst     CopyTarget
+	opts    CopyOptions
+	report  *CopyReport
+	copied  map[string]struct{}
+	pending []model.IntentRecord
+}
+
+func (c *copier) selected(record model.IntentRecord) (bool, error) {
+	if c.opts.Author != "" && record.Author != c.opts.Author {
+		return false, nil
+	}
+	if c.opts.From.IsZero() && c.opts.To.IsZero() {
+		return true, nil
+	}
+	created, err := time.Parse(time.RFC3339Nano, record.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("intent %s: parse created_at: %w", record.ID, err)
+	}
+	if !c.opts.From.IsZero() && created.Before(c.opts.From) {
+		return false, nil
+	}
+	if !c.opts.To.IsZero() && !created.Before(c.opts.To) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// offer copies record once its parent is available, releasing any pending
+// records that were waiting on it.
+func (c *copier) offer(ctx context.Context, record model.IntentRecord) error {
+	if err := c.flush(ctx, record.CreatedAt); err != nil {
+		return err
+	}
+	ready, err := c.parentReady(ctx, record)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		c.pending = append(c.pending, record)
+		return nil
+	}
+	return c.copy(ctx, record)
+}
+
+// flush resolves pending records older than createdAt, or all of them when
+// createdAt is empty: their parents can no longer appear in the source. A
+// record whose parent is itself pending stays queued until that parent is
+// copied.
+func (c *copier) flush(ctx context.Context, createdAt string) error {
+	waiting := make(map[string]struct{}, len(c.pending))
+	for _, record := range c.pending {
+		waiting[record.Hash] = struct{}{}
+	}
+	var kept, orphans []model.IntentRecord
+	for _, record := range c.pending {
+		_, parentPending := waiting[record.PrevHash]
+		if parentPending || (createdAt != "" && record.CreatedAt == createdAt) {
+			kept = append(kept, record)
+			continue
+		}
+		orphans = append(orphans, record)
+	}
+	c.pending = kept
+	for _, record := range orphans {
+		if !c.opts.AllowMissingParents {
+			return fmt.Errorf("%w: intent %s: prev_hash %s not found", ErrChainBroken, record.ID, record.PrevHash)
+		}
+		c.report.Orphans = append(c.report.Orphans, record.ID)
+		if err := c.copy(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *copier) parentReady(ctx context.Context, record model.IntentRecord) (bool, error) {
+	if record.PrevHash == "" {
+		return true, nil
+	}
+	if _, ok := c.copied[record.PrevHash]; ok {
+		return true, nil
+	}
+	_, err := c.dst.GetIntentByHash(ctx, record.PrevHash)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *copier) copy(ctx context.Context, record model.IntentRecord) error {
+	_, err := c.dst.GetIntentByHash(ctx, record.Hash)
+	switch {
+	case err == nil:
+		c.report.Skipped++
+	case !errors.Is(err, ErrNotFound):
+		return err
+	default:
+		if err := verifyCopied(record, c.opts.HMACSecret); err != nil {
+			return fmt.Errorf("intent %s: %w", record.ID, err)
+		}
+		if err := c.copyBlobs(ctx, record); err != nil {
+			return fmt.Errorf("intent %s: %w", record.ID, err)
+		}
+		create := c.dst.CreateIntent
+		if t, ok := c.dst.(*Store); ok && record.Tombstoned() {
+			create = t.copyTombstone
+		}
+		if err := create(ctx, record); err != nil {
+			return fmt.Errorf("intent %s: %w", record.ID, err)
+		}
+		c.report.Copied++
+	}
+	c.copied[record.Hash] = struct{}{}
+	return c.release(ctx, record.Hash)
+}
+
+// release copies pending records waiting on parentHash.
+func (c *copier) release(ctx context.Context, parentHash string) error {
+	for {
+		i := slices.IndexFunc(c.pending, func(r model.IntentRecord) bool { return r.PrevHash == parentHash })
+		if i < 0 {
+			return nil
+		}
+		record := c.pending[i]
+		c.pending = slices.Delete(c.pending, i, i+1)
+		if err := c.copy(ctx, record); err != nil {
+			return err
+		}
+	}
+}
+
+// copyBlobs writes record's attachment blobs to dst when both sides store
+// blobs. Tombstoned records may reference blobs that were already removed.
+func (c *copier) copyBlobs(ctx context.Context, record model.IntentRecord) error {
+	if record.Tombstoned() || len(record.Attachments) == 0 {
+		return nil
+	}
+	from, ok := c.src.(blobOpener)
+	if !ok {
+		return nil
+	}
+	to, ok := c.dst.(blobWriter)
+	if !ok {
+		return nil
+	}
+	for _, a := range record.Attachments {
+		if err := copyBlob(ctx, from, to, a.Digest); err != nil {
+			return fmt.Errorf("copy blob %s: %w", a.Digest, err)
+		}
+	}
+	return nil
+}
+
+func copyBlob(ctx context.Context, from blobOpener, to blobWriter, digest string) error {
+	r, err := from.OpenBlob(ctx, digest)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, _, err = to.PutBlobReader(ctx, r)
+	return err
+}
+
+// copyTombstone stores a tombstone copied from another store. CreateIntent
+// rejects tombstones, which only the store itself may make.
+func (s *Store) copyTombstone(ctx context.Context, record model.IntentRecord) error {
+	if err := s.writable("Copy"); err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, ActionCreate, record); err != nil {
+		return err
+	}
+	return s.withRetryTx(ctx, "Copy", func(tx *sql.Tx) error {
+		if err := s.insertTombstoneTx(ctx, tx, record); err != nil {
+			return err
+		}
+		return s.checkpointDueTx(ctx, tx)
+	})
+}
+
+// verifyCopied checks record's hash. Tombstoned records carry no content, so
+// only their links are checked.
+func verifyCopied(record model.IntentRecord, secret []byte) error {
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	if record.Tombstoned() {
+		return nil
+	}
+	if hash.IsKeyed(record.Hash) {
+		if secret == nil {
+			return errors.New("keyed hash cannot be verified without CopyOptions.HMACSecret")
+		}
+		return hash.VerifyHMACIntent(record, secret)
+	}
+	return hash.VerifyHash(record)
+}
@@ -0,0 +1,190 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// seedChain stores a linked chain of n intents for author and returns it
+// oldest first.
+func seedChain(t *testing.T, s *Store, author string, n int) []model.IntentRecord {
+	t.Helper()
+	records := make([]model.IntentRecord, 0, n)
+	prev := ""
+	for i := 1; i <= n; i++ {
+		record := linkedIntent(t, i, author, prev)
+		record.ID = author + "-" + record.ID
+		sum, err := hash.HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash intent: %v", err)
+		}
+		record.Hash = sum
+		if err := s.CreateIntent(context.Background(), record); err != nil {
+			t.Fatalf("create intent: %v", err)
+		}
+		records = append(records, record)
+		prev = record.Hash
+	}
+	return records
+}
+
+func TestCopyPreservesChains(t *testing.T) {
+	ctx := context.Background()
+	src, dst := openTestStore(t), openTestStore(t)
+	alice := seedChain(t, src, "alice", 5)
+	seedChain(t, src, "bob", 3)
+
+	report, err := Copy(ctx, src, dst, CopyOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if report.Copied != 8 || report.Skipped != 0 || len(report.Orphans) != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	head, err := dst.ChainHead(ctx, "alice")
+	if err != nil || head != alice[4].Hash {
+		t.Fatalf("expected alice's head to be copied, got %q, %v", head, err)
+	}
+	if diff, err := Reconcile(ctx, src, dst); err != nil || !diff.Empty() {
+		t.Fatalf("expected identical stores, got %+v, %v", diff, err)
+	}
+
+	report, err = Copy(ctx, src, dst, CopyOptions{})
+	if err != nil {
+		t.Fatalf("rerun copy: %v", err)
+	}
+	if report.Copied != 0 || report.Skipped != 8 {
+		t.Fatalf("expected rerun to skip every record, got %+v", report)
+	}
+}
+
+func TestCopyReadsPastClampedPages(t *testing.T) {
+	ctx := context.Background()
+	src, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithMaxListLimit(2))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = src.Close() })
+	if err := src.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	seedChain(t, src, "alice", 5)
+
+	dst := openTestStore(t)
+	report, err := Copy(ctx, src, dst, CopyOptions{BatchSize: 10})
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if report.Copied != 5 {
+		t.Fatalf("expected every record copied through clamped pages, got %+v", report)
+	}
+}
+
+func TestCopyFilters(t *testing.T) {
+	ctx := context.Background()
+	src := openTestStore(t)
+	alice := seedChain(t, src, "alice", 5)
+	seedChain(t, src, "bob", 3)
+
+	dst := openTestStore(t)
+	report, err := Copy(ctx, src, dst, CopyOptions{Author: "bob"})
+	if err != nil {
+		t.Fatalf("copy bob: %v", err)
+	}
+	if report.Copied != 3 {
+		t.Fatalf("expected bob's 3 intents, got %+v", report)
+	}
+
+	from, err := time.Parse(time.RFC3339Nano, alice[2].CreatedAt)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	opts := CopyOptions{Author: "alice", From: from}
+	dst = openTestStore(t)
+	if _, err := Copy(ctx, src, dst, opts); err == nil || !strings.Contains(err.Error(), "prev_hash") {
+		t.Fatalf("expected a missing parent error, got %v", err)
+	}
+
+	opts.AllowMissingParents = true
+	opts.To = from.Add(2 * time.Second)
+	report, err = Copy(ctx, src, dst, opts)
+	if err != nil {
+		t.Fatalf("copy range: %v", err)
+	}
+	if report.Copied != 2 || len(report.Orphans) != 1 || report.Orphans[0] != alice[2].ID {
+		t.Fatalf("expected alice 3..4 with 3 orphaned, got %+v", report)
+	}
+}
+
+func TestCopyOrdersTiedTimestamps(t *testing.T) {
+	ctx := context.Background()
+	src, dst := openTestStore(t), openTestStore(t)
+	parent := linkedIntent(t, 1, "alice", "")
+	// Same created_at as its parent and an ID that sorts first, so the source
+	// lists the child before the parent.
+	child := linkedIntent(t, 1, "alice", parent.Hash)
+	child.ID = "intent-0"
+	sum, err := hash.HashIntent(child)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	child.Hash = sum
+	for _, record := range []model.IntentRecord{parent, child} {
+		if err := src.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	report, err := Copy(ctx, src, dst, CopyOptions{})
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if report.Copied != 2 || len(report.Orphans) != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if head, err := dst.ChainHead(ctx, "alice"); err != nil || head != child.Hash {
+		t.Fatalf("expected the child to be the head, got %q, %v", head, err)
+	}
+}
+
+func TestCopyRejectsTamperedRecords(t *testing.T) {
+	ctx := context.Background()
+	src, dst := openTestStore(t), openTestStore(t)
+	records := seedChain(t, src, "alice", 3)
+	if _, err := src.db.ExecContext(ctx, `UPDATE intents SET prompt = 'edited' WHERE id = ?`, records[1].ID); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+
+	report, err := Copy(ctx, src, dst, CopyOptions{})
+	if err == nil || !strings.Contains(err.Error(), records[1].ID) {
+		t.Fatalf("expected the tampered record to be rejected, got %v", err)
+	}
+	if report.Copied != 1 {
+		t.Fatalf("expected records before the tampered one to be copied, got %+v", report)
+	}
+}
+
+func TestCopyAttachments(t *testing.T) {
+	ctx := context.Background()
+	src, dst := openTestStore(t), openTestStore(t)
+	record, err := src.CreateIntentStream(ctx, model.IntentRecord{Author: "alice", SourceType: "cli"},
+		strings.NewReader("streamed prompt"), strings.NewReader("streamed response"))
+	if err != nil {
+		t.Fatalf("create stream: %v", err)
+	}
+
+	if _, err := Copy(ctx, src, dst, CopyOptions{}); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	for _, a := range record.Attachments {
+		if _, err := dst.GetBlob(ctx, a.Digest); err != nil {
+			t.Fatalf("expected blob %s in target: %v", a.Digest, err)
+		}
+	}
+}
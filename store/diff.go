@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"sort"
+)
+
+// DiffReport is a ReconcileReport extended with chain head differences.
+type DiffReport struct {
+	ReconcileReport
+	// Heads lists chains whose head differs, sorted by chain name.
+	Heads []HeadDivergence
+}
+
+// HeadDivergence is a chain whose head differs between two stores. A chain
+// tracked by only one store has an empty head on the other side.
+type HeadDivergence struct {
+	Chain string
+	HeadA string
+	HeadB string
+}
+
+// Empty reports whether the stores hold identical intents and chain heads.
+func (r DiffReport) Empty() bool {
+	return r.ReconcileReport.Empty() && len(r.Heads) == 0
+}
+
+// Diff compares two stores: intents present in only one of them, shared IDs
+// with differing hashes (as Reconcile reports), and chain heads that differ.
+// An empty report shows the stores captured identical history.
+func Diff(ctx context.Context, a, b *Store) (DiffReport, error) {
+	var report DiffReport
+	reconciled, err := Reconcile(ctx, a, b)
+	if err != nil {
+		return report, err
+	}
+	report.ReconcileReport = reconciled
+
+	headsA, err := a.ChainHeads(ctx)
+	if err != nil {
+		return report, err
+	}
+	headsB, err := b.ChainHeads(ctx)
+	if err != nil {
+		return report, err
+	}
+	for chain, headA := range headsA {
+		if headB := headsB[chain]; headB != headA {
+			report.Heads = append(report.Heads, HeadDivergence{Chain: chain, HeadA: headA, HeadB: headB})
+		}
+	}
+	for chain, headB := range headsB {
+		if _, ok := headsA[chain]; !ok {
+			report.Heads = append(report.Heads, HeadDivergence{Chain: chain, HeadB: headB})
+		}
+	}
+	sort.Slice(report.Heads, func(i, j int) bool { return report.Heads[i].Chain < report.Heads[j].Chain })
+	return report, nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiffIdenticalStores(t *testing.T) {
+	ctx := context.Background()
+	a, b := openTestStore(t), openTestStore(t)
+	seedStores(t, 4, a, b)
+
+	report, err := Diff(ctx, a, b)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !report.Empty() {
+		t.Fatalf("expected empty report, got %+v", report)
+	}
+}
+
+func TestDiffReportsHeads(t *testing.T) {
+	ctx := context.Background()
+	a, b := openTestStore(t), openTestStore(t)
+	first := linkedIntent(t, 1, "alice", "")
+	second := linkedIntent(t, 2, "alice", first.Hash)
+	bob := linkedIntent(t, 3, "bob", "")
+	for _, s := range []*Store{a, b} {
+		if err := s.CreateIntent(ctx, first); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if err := a.CreateIntent(ctx, second); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := b.CreateIntent(ctx, bob); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	report, err := Diff(ctx, a, b)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if len(report.OnlyInA) != 1 || report.OnlyInA[0] != second.ID || len(report.OnlyInB) != 1 || report.OnlyInB[0] != bob.ID {
+		t.Fatalf("unexpected intent differences %+v", report.ReconcileReport)
+	}
+	want := []HeadDivergence{
+		{Chain: "alice", HeadA: second.Hash, HeadB: first.Hash},
+		{Chain: "bob", HeadB: bob.Hash},
+	}
+	if len(report.Heads) != len(want) || report.Heads[0] != want[0] || report.Heads[1] != want[1] {
+		t.Fatalf("expected heads %+v, got %+v", want, report.Heads)
+	}
+}
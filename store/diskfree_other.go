@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package store
+
+import "errors"
+
+// freeDiskBytes is not supported on this platform.
+func freeDiskBytes(string) (int64, error) {
+	return 0, errors.New("free disk space is not supported on this platform")
+}
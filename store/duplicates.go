@@ -0,0 +1,300 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/chuxorg/chux-yanzi-core/dedupe"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// DefaultDuplicateDistance is the DuplicateDetection.MaxDistance used when
+// none is set.
+const DefaultDuplicateDistance = 3
+
+// DuplicateDetection configures near-duplicate detection. Each intent's
+// prompt and response are fingerprinted with dedupe.Fingerprint as it is
+// written, and intents whose fingerprints are within MaxDistance bits of each
+// other are near-duplicates.
+type DuplicateDetection struct {
+	// MaxDistance is the largest fingerprint distance that counts as a
+	// near-duplicate; values <= 0 select DefaultDuplicateDistance. Candidates
+	// are looked up by dedupe.Bands, which finds every intent within distance
+	// 3; larger distances are matched when a band happens to agree.
+	MaxDistance int
+	// MarkMeta sets model.MetaKeyDuplicateOf in the meta of an intent added
+	// with AppendIntent to the ID of the nearest earlier intent the Authorizer
+	// allows the writer to list, before it is hashed. Records passed to
+	// CreateIntent are already hashed and are never changed.
+	MarkMeta bool
+}
+
+// WithDuplicateDetection fingerprints intents as they are written so
+// NearDuplicates can find them. Run FingerprintMissing to cover intents
+// written before it was enabled.
+func WithDuplicateDetection(d DuplicateDetection) Option {
+	return func(o *options) {
+		if d.MaxDistance <= 0 {
+			d.MaxDistance = DefaultDuplicateDistance
+		}
+		o.duplicates = &d
+	}
+}
+
+// DuplicateCandidate is a stored intent that near-duplicates another.
+type DuplicateCandidate struct {
+	Intent model.IntentRecord
+	// Distance is the number of fingerprint bits in which the two differ;
+	// 0 means the texts are the same up to case and punctuation.
+	Distance int
+}
+
+const (
+	insertFingerprintSQL = `INSERT OR REPLACE INTO intent_fingerprints (intent_id, simhash, band0, band1, band2, band3)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	selectFingerprintCandidatesSQL = `SELECT f.intent_id, f.simhash, i.created_at FROM intent_fingerprints f
+		JOIN intents i ON i.id = f.intent_id
+		WHERE (f.band0 = ? OR f.band1 = ? OR f.band2 = ? OR f.band3 = ?) AND i.tombstoned_at IS NULL`
+)
+
+// NearDuplicates returns up to limit stored intents that near-duplicate the
+// intent with id, nearest first and then oldest first. Only fingerprinted
+// intents are candidates, and results the Authorizer does not allow for
+// ActionList are dropped. A tombstoned intent has no near-duplicates. Limits
+// follow ListIntents.
+func (s *Store) NearDuplicates(ctx context.Context, id string, limit int) (_ []DuplicateCandidate, err error) {
+	ctx, span := s.startSpan(ctx, "NearDuplicates")
+	defer func() { err = span.end(err) }()
+	record, err := s.getIntent(ctx, selectIntentByIDSQL, id, s.cache.getByID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Tombstoned() {
+		return nil, nil
+	}
+	return s.nearDuplicates(ctx, record, limit)
+}
+
+// FindNearDuplicates is NearDuplicates for a record that need not be stored,
+// such as one about to be created. Only its ID, prompt, and response are
+// used; an intent stored under its ID is not returned.
+func (s *Store) FindNearDuplicates(ctx context.Context, record model.IntentRecord, limit int) (_ []DuplicateCandidate, err error) {
+	ctx, span := s.startSpan(ctx, "FindNearDuplicates")
+	defer func() { err = span.end(err) }()
+	return s.nearDuplicates(ctx, record, limit)
+}
+
+func (s *Store) nearDuplicates(ctx context.Context, record model.IntentRecord, limit int) ([]DuplicateCandidate, error) {
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	limit = s.clampLimit(limit)
+	stmt, err := s.preparedRead(ctx, selectFingerprintCandidatesSQL)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := s.fingerprintMatches(ctx, stmt, record)
+	if err != nil {
+		return nil, err
+	}
+
+	load, err := s.preparedRead(ctx, selectIntentByIDSQL)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []DuplicateCandidate
+	for _, m := range matches {
+		if len(candidates) == limit {
+			break
+		}
+		intent, err := s.scanIntent(load.QueryRowContext(ctx, m.id))
+		if errors.Is(err, sql.ErrNoRows) {
+			continue // removed since the fingerprints were read
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load intent %s: %w", m.id, err)
+		}
+		if len(s.allowed(ctx, ActionList, []model.IntentRecord{intent})) == 0 {
+			continue
+		}
+		candidates = append(candidates, DuplicateCandidate{Intent: intent, Distance: m.distance})
+	}
+	return candidates, nil
+}
+
+// fingerprintMatch is a fingerprinted intent within the configured distance.
+type fingerprintMatch struct {
+	id        string
+	createdAt string
+	distance  int
+}
+
+// fingerprintMatches runs stmt, a prepared selectFingerprintCandidatesSQL,
+// for record and returns the matches other than record itself, nearest
+// first and then oldest first.
+func (s *Store) fingerprintMatches(ctx context.Context, stmt *sql.Stmt, record model.IntentRecord) ([]fingerprintMatch, error) {
+	maxDistance := DefaultDuplicateDistance
+	if d := s.opts.duplicates; d != nil {
+		maxDistance = d.MaxDistance
+	}
+	fp := dedupe.Fingerprint(duplicateText(record))
+	bands := dedupe.Bands(fp)
+	rows, err := stmt.QueryContext(ctx, bands[0], bands[1], bands[2], bands[3])
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []fingerprintMatch
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var (
+			m       fingerprintMatch
+			simhash int64
+		)
+		if err := rows.Scan(&m.id, &simhash, &m.createdAt); err != nil {
+			return nil, err
+		}
+		if m.id == record.ID {
+			continue
+		}
+		if m.distance = dedupe.Distance(fp, uint64(simhash)); m.distance <= maxDistance {
+			matches = append(matches, m)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if a.createdAt != b.createdAt {
+			return a.createdAt < b.createdAt
+		}
+		return a.id < b.id
+	})
+	return matches, nil
+}
+
+// FingerprintMissing fingerprints up to limit intents, oldest first, that
+// have no fingerprint yet, and returns how many it fingerprinted. Tombstoned
+// intents are skipped. Limits follow ListIntents.
+func (s *Store) FingerprintMissing(ctx context.Context, limit int) (n int, err error) {
+	ctx, span := s.startSpan(ctx, "FingerprintMissing")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("FingerprintMissing"); err != nil {
+		return 0, err
+	}
+	if s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+intentColumns+` FROM intents
+		WHERE tombstoned_at IS NULL AND id NOT IN (SELECT intent_id FROM intent_fingerprints)
+		ORDER BY created_at ASC, id ASC LIMIT ?`, s.clampLimit(limit))
+	if err != nil {
+		return 0, err
+	}
+	records, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return 0, err
+	}
+	err = s.withRetryTx(ctx, "FingerprintMissing", func(tx *sql.Tx) error {
+		stmt, err := s.txStmt(ctx, tx, insertFingerprintSQL)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if _, err := stmt.ExecContext(ctx, fingerprintArgs(record)...); err != nil {
+				return fmt.Errorf("fingerprint intent %s: %w", record.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// insertFingerprintTx fingerprints record within tx when duplicate detection
+// is enabled.
+func (s *Store) insertFingerprintTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	if s.opts.duplicates == nil || record.Tombstoned() {
+		return nil
+	}
+	stmt, err := s.txStmt(ctx, tx, insertFingerprintSQL)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.ExecContext(ctx, fingerprintArgs(record)...)
+	return err
+}
+
+// markDuplicateTx sets model.MetaKeyDuplicateOf in record's meta to the
+// nearest earlier intent, read within tx, when MarkMeta is enabled and the
+// key is not already set. Candidates the Authorizer does not allow for
+// ActionList are skipped, so the meta never names an intent the writer could
+// not find with NearDuplicates.
+func (s *Store) markDuplicateTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) (model.IntentRecord, error) {
+	if d := s.opts.duplicates; d == nil || !d.MarkMeta {
+		return record, nil
+	}
+	meta, err := record.ParsedMeta()
+	if err != nil {
+		return record, &model.InvalidRecordError{Err: err}
+	}
+	if _, ok := meta[model.MetaKeyDuplicateOf]; ok {
+		return record, nil
+	}
+	stmt, err := s.txStmt(ctx, tx, selectFingerprintCandidatesSQL)
+	if err != nil {
+		return record, err
+	}
+	matches, err := s.fingerprintMatches(ctx, stmt, record)
+	if err != nil || len(matches) == 0 {
+		return record, err
+	}
+	load, err := s.txStmt(ctx, tx, selectIntentByIDSQL)
+	if err != nil {
+		return record, err
+	}
+	var nearest string
+	for _, m := range matches {
+		intent, err := s.scanIntent(load.QueryRowContext(ctx, m.id))
+		if err != nil {
+			return record, fmt.Errorf("load intent %s: %w", m.id, err)
+		}
+		if len(s.allowed(ctx, ActionList, []model.IntentRecord{intent})) > 0 {
+			nearest = intent.ID
+			break
+		}
+	}
+	if nearest == "" {
+		return record, nil
+	}
+	if err := meta.SetDuplicateOf(nearest); err != nil {
+		return record, err
+	}
+	if record.Meta, err = meta.Raw(); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+// duplicateText is the text of record that is fingerprinted.
+func duplicateText(record model.IntentRecord) string {
+	return record.Prompt + "\n" + record.Response
+}
+
+func fingerprintArgs(record model.IntentRecord) []any {
+	fp := dedupe.Fingerprint(duplicateText(record))
+	bands := dedupe.Bands(fp)
+	return []any{record.ID, int64(fp), bands[0], bands[1], bands[2], bands[3]}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestNearDuplicates(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithDuplicateDetection(DuplicateDetection{MarkMeta: true}))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	const response = "Errors are wrapped with the operation name so callers can classify them, and each write path now shares one helper."
+	appendPrompt := func(n int, prompt string) model.IntentRecord {
+		t.Helper()
+		record := testIntent(t, n)
+		record.Prompt, record.Response, record.Hash = prompt, response, ""
+		stored, err := s.AppendIntent(ctx, record)
+		if err != nil {
+			t.Fatalf("append intent %d: %v", n, err)
+		}
+		return stored
+	}
+	original := appendPrompt(1, "Refactor the store package to remove the duplicated error handling.")
+	unrelated := appendPrompt(2, "Summarize the release notes for the webhook dispatcher and the CLI flags, in three bullet points each.")
+	repeat := appendPrompt(3, "refactor the store package to remove the duplicated error handling")
+
+	if meta, _ := original.ParsedMeta(); len(meta) != 0 {
+		t.Fatalf("expected the first intent unmarked, got %s", original.Meta)
+	}
+	meta, err := repeat.ParsedMeta()
+	if err != nil {
+		t.Fatalf("parse meta: %v", err)
+	}
+	if of, _ := meta.DuplicateOf(); of != original.ID {
+		t.Fatalf("expected the repeat marked as a duplicate of %s, got meta %s", original.ID, repeat.Meta)
+	}
+
+	candidates, err := s.NearDuplicates(ctx, original.ID, 10)
+	if err != nil {
+		t.Fatalf("near duplicates: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Intent.ID != repeat.ID || candidates[0].Distance != 0 {
+		t.Fatalf("expected only the repeat as a candidate, got %+v", candidates)
+	}
+	found, err := s.FindNearDuplicates(ctx, model.IntentRecord{Prompt: unrelated.Prompt, Response: response}, 10)
+	if err != nil {
+		t.Fatalf("find near duplicates: %v", err)
+	}
+	if len(found) != 1 || found[0].Intent.ID != unrelated.ID {
+		t.Fatalf("expected the unrelated intent found by its own text, got %+v", found)
+	}
+
+	if err := s.TombstoneIntent(ctx, repeat.ID); err != nil {
+		t.Fatalf("tombstone intent: %v", err)
+	}
+	if candidates, err := s.NearDuplicates(ctx, original.ID, 10); err != nil || len(candidates) != 0 {
+		t.Fatalf("expected no candidates once the repeat is tombstoned, got %+v, %v", candidates, err)
+	}
+}
+
+func TestMarkDuplicateSkipsUnlistableIntents(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"),
+		WithDuplicateDetection(DuplicateDetection{MarkMeta: true}), WithAuthorizer(ownerOnly))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	appendAs := func(author string) model.IntentRecord {
+		t.Helper()
+		record := model.IntentRecord{Author: author, SourceType: "cli", Prompt: "the same question", Response: "the same answer"}
+		stored, err := s.AppendIntent(asCaller(author), record)
+		if err != nil {
+			t.Fatalf("append intent as %s: %v", author, err)
+		}
+		return stored
+	}
+	original := appendAs("alice")
+	// Bob cannot list alice's intent, so his repeat must not name it.
+	if meta, _ := appendAs("bob").ParsedMeta(); len(meta) != 0 {
+		t.Fatalf("expected bob's repeat unmarked, got %v", meta)
+	}
+	meta, err := appendAs("alice").ParsedMeta()
+	if err != nil {
+		t.Fatalf("parse meta: %v", err)
+	}
+	if of, _ := meta.DuplicateOf(); of != original.ID {
+		t.Fatalf("expected alice's repeat marked as a duplicate of %s, got %v", original.ID, meta)
+	}
+}
+
+func TestFingerprintMissing(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	for n := 1; n <= 2; n++ {
+		record := testIntent(t, n)
+		record.Prompt, record.Response, record.Hash = "the same question", "the same answer", ""
+		if _, err := s.AppendIntent(ctx, record); err != nil {
+			t.Fatalf("append intent %d: %v", n, err)
+		}
+	}
+	if candidates, err := s.NearDuplicates(ctx, "intent-000001", 10); err != nil || len(candidates) != 0 {
+		t.Fatalf("expected no candidates before fingerprinting, got %+v, %v", candidates, err)
+	}
+	if n, err := s.FingerprintMissing(ctx, 0); err != nil || n != 2 {
+		t.Fatalf("expected 2 intents fingerprinted, got %d, %v", n, err)
+	}
+	if n, err := s.FingerprintMissing(ctx, 0); err != nil || n != 0 {
+		t.Fatalf("expected nothing left to fingerprint, got %d, %v", n, err)
+	}
+	candidates, err := s.NearDuplicates(ctx, "intent-000001", 10)
+	if err != nil {
+		t.Fatalf("near duplicates: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Intent.ID != "intent-000002" {
+		t.Fatalf("expected the other intent as a candidate, got %+v", candidates)
+	}
+}
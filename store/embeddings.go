@@ -0,0 +1,324 @@
+package store
+
+import (
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Embedder turns text into a vector for similarity search. Implementations
+// typically call an embedding model over the network.
+type Embedder interface {
+	// Model names the embedding model. Vectors are only ever compared with
+	// vectors from the same model.
+	Model() string
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// WithEmbedder sets the Embedder used by EmbedIntent, EmbedMissing, and text
+// queries to SearchSimilar. Intents are not embedded as they are written;
+// run EmbedMissing to catch up. An intent's prompt is what gets embedded.
+func WithEmbedder(e Embedder) Option {
+	return func(o *options) {
+		o.embedder = e
+	}
+}
+
+// Embedding is the vector stored for an intent under one model. Vectors carry
+// the meaning of the text they were made from, so they are removed when the
+// intent is tombstoned and are not encrypted with WithEncryptionKey.
+type Embedding struct {
+	IntentID  string
+	Model     string
+	Vector    []float32
+	CreatedAt string
+}
+
+// SimilarQuery is the input to SearchSimilar: either a Vector, compared with
+// embeddings stored under Model, or Text, embedded with the configured
+// Embedder and compared with embeddings from its model.
+type SimilarQuery struct {
+	Text   string
+	Vector []float32
+	// Model defaults to the configured Embedder's model.
+	Model string
+}
+
+// SimilarIntent is a SearchSimilar result.
+type SimilarIntent struct {
+	Intent model.IntentRecord
+	// Score is the cosine similarity to the query, from -1 to 1.
+	Score float64
+}
+
+// PutEmbedding stores e, replacing any vector already stored for the same
+// intent and model.
+func (s *Store) PutEmbedding(ctx context.Context, e Embedding) (err error) {
+	ctx, span := s.startSpan(ctx, "PutEmbedding")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("PutEmbedding"); err != nil {
+		return err
+	}
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	if e.IntentID == "" || len(e.Vector) == 0 {
+		return errors.New("embedding requires an intent id and a vector")
+	}
+	norm := vectorNorm(e.Vector)
+	if norm == 0 || math.IsNaN(norm) || math.IsInf(norm, 0) {
+		return errors.New("embedding vector must be finite and non-zero")
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT INTO embeddings (intent_id, model, dims, vector, norm, created_at)
+		SELECT id, ?, ?, ?, ?, ? FROM intents WHERE id = ?
+		ON CONFLICT (intent_id, model) DO UPDATE SET dims = excluded.dims, vector = excluded.vector,
+			norm = excluded.norm, created_at = excluded.created_at`,
+		e.Model, len(e.Vector), encodeVector(e.Vector), norm, s.now().Format(time.RFC3339Nano), e.IntentID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &NotFoundError{Kind: "intent", Key: e.IntentID}
+	}
+	return nil
+}
+
+// GetEmbedding returns the vector stored for the intent with id under
+// modelName, or a *NotFoundError if there is none.
+func (s *Store) GetEmbedding(ctx context.Context, id, modelName string) (_ Embedding, err error) {
+	ctx, span := s.startSpan(ctx, "GetEmbedding")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return Embedding{}, errors.New("store not initialized")
+	}
+	e := Embedding{IntentID: id, Model: modelName}
+	var blob []byte
+	err = s.rdb.QueryRowContext(ctx, `SELECT vector, created_at FROM embeddings WHERE intent_id = ? AND model = ?`,
+		id, modelName).Scan(&blob, &e.CreatedAt)
+	if err != nil {
+		return Embedding{}, notFound(err, "embedding", id)
+	}
+	e.Vector = decodeVector(blob)
+	return e, nil
+}
+
+// EmbedIntent embeds the prompt of the intent with id using the configured
+// Embedder and stores the vector. Tombstoned intents cannot be embedded.
+func (s *Store) EmbedIntent(ctx context.Context, id string) (_ Embedding, err error) {
+	ctx, span := s.startSpan(ctx, "EmbedIntent")
+	defer func() { err = span.end(err) }()
+	if s.opts.embedder == nil {
+		return Embedding{}, errors.New("no embedder configured")
+	}
+	record, err := s.getIntent(ctx, selectIntentByIDSQL, id, s.cache.getByID)
+	if err != nil {
+		return Embedding{}, err
+	}
+	if record.Tombstoned() {
+		return Embedding{}, fmt.Errorf("intent %s is tombstoned", id)
+	}
+	return s.embed(ctx, record)
+}
+
+// EmbedMissing embeds up to limit intents, oldest first, that have no vector
+// from the configured Embedder's model, and returns how many it embedded.
+// Tombstoned intents are skipped. Limits follow ListIntents.
+func (s *Store) EmbedMissing(ctx context.Context, limit int) (n int, err error) {
+	ctx, span := s.startSpan(ctx, "EmbedMissing")
+	defer func() { err = span.end(err) }()
+	if s.opts.embedder == nil {
+		return 0, errors.New("no embedder configured")
+	}
+	if s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+intentColumns+` FROM intents
+		WHERE tombstoned_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM embeddings e WHERE e.intent_id = intents.id AND e.model = ?)
+		ORDER BY created_at ASC, id ASC LIMIT ?`, s.opts.embedder.Model(), s.clampLimit(limit))
+	if err != nil {
+		return 0, err
+	}
+	records, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return 0, err
+	}
+	for _, record := range records {
+		if _, err := s.embed(ctx, record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (s *Store) embed(ctx context.Context, record model.IntentRecord) (Embedding, error) {
+	vector, err := s.opts.embedder.Embed(ctx, record.Prompt)
+	if err != nil {
+		return Embedding{}, fmt.Errorf("embed intent %s: %w", record.ID, err)
+	}
+	e := Embedding{IntentID: record.ID, Model: s.opts.embedder.Model(), Vector: vector}
+	if err := s.PutEmbedding(ctx, e); err != nil {
+		return Embedding{}, fmt.Errorf("store embedding of %s: %w", record.ID, err)
+	}
+	return e, nil
+}
+
+// SearchSimilar returns up to k intents whose stored vectors are most similar
+// to q, most similar first. Only vectors with the query's model and dimension
+// are compared, tombstoned intents are skipped, and results the Authorizer
+// does not allow for ActionList are dropped rather than replaced. Limits
+// follow ListIntents.
+//
+// The search compares the query with every candidate vector, which suits
+// stores of up to a few hundred thousand embeddings.
+func (s *Store) SearchSimilar(ctx context.Context, q SimilarQuery, k int) (results []SimilarIntent, err error) {
+	ctx, span := s.startSpan(ctx, "SearchSimilar")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpQuery, start, len(results), err) }(time.Now())
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	k = s.clampLimit(k)
+
+	vector, modelName := q.Vector, q.Model
+	if modelName == "" && s.opts.embedder != nil {
+		modelName = s.opts.embedder.Model()
+	}
+	if len(vector) == 0 {
+		if q.Text == "" {
+			return nil, errors.New("similarity query requires text or a vector")
+		}
+		if s.opts.embedder == nil {
+			return nil, errors.New("no embedder configured")
+		}
+		if vector, err = s.opts.embedder.Embed(ctx, q.Text); err != nil {
+			return nil, fmt.Errorf("embed query: %w", err)
+		}
+	}
+	qnorm := vectorNorm(vector)
+	if qnorm == 0 || math.IsNaN(qnorm) || math.IsInf(qnorm, 0) {
+		return nil, errors.New("query vector must be finite and non-zero")
+	}
+
+	rows, err := s.rdb.QueryContext(ctx, `SELECT e.intent_id, e.vector, e.norm FROM embeddings e
+		JOIN intents i ON i.id = e.intent_id
+		WHERE e.model = ? AND e.dims = ? AND i.tombstoned_at IS NULL`, modelName, len(vector))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	top := make(similarHeap, 0, k)
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var (
+			id   string
+			blob []byte
+			norm float64
+		)
+		if err := rows.Scan(&id, &blob, &norm); err != nil {
+			return nil, err
+		}
+		score := dotEncoded(vector, blob) / (qnorm * norm)
+		if len(top) < k {
+			heap.Push(&top, similar{id: id, score: score})
+		} else if score > top[0].score {
+			top[0] = similar{id: id, score: score}
+			heap.Fix(&top, 0)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].score != top[j].score {
+			return top[i].score > top[j].score
+		}
+		return top[i].id < top[j].id
+	})
+	stmt, err := s.preparedRead(ctx, selectIntentByIDSQL)
+	if err != nil {
+		return nil, err
+	}
+	for _, hit := range top {
+		record, err := s.scanIntent(stmt.QueryRowContext(ctx, hit.id))
+		if err != nil {
+			return nil, fmt.Errorf("load intent %s: %w", hit.id, err)
+		}
+		if len(s.allowed(ctx, ActionList, []model.IntentRecord{record})) == 0 {
+			continue
+		}
+		results = append(results, SimilarIntent{Intent: record, Score: hit.score})
+	}
+	return results, nil
+}
+
+// similar is a SearchSimilar candidate.
+type similar struct {
+	id    string
+	score float64
+}
+
+// similarHeap is a min-heap on score, holding the best candidates so far.
+type similarHeap []similar
+
+func (h similarHeap) Len() int           { return len(h) }
+func (h similarHeap) Less(i, j int) bool { return h[i].score < h[j].score }
+func (h similarHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *similarHeap) Push(x any)        { *h = append(*h, x.(similar)) }
+func (h *similarHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// encodeVector stores v as little-endian float32s.
+func encodeVector(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+func decodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+// dotEncoded is the dot product of v with the encoded vector b, decoded in
+// place to avoid an allocation per candidate.
+func dotEncoded(v []float32, b []byte) float64 {
+	var dot float64
+	for i := 0; i < len(v) && 4*i+4 <= len(b); i++ {
+		dot += float64(v[i]) * float64(math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:])))
+	}
+	return dot
+}
+
+func vectorNorm(v []float32) float64 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	return math.Sqrt(sum)
+}
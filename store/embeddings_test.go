@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// wordEmbedder embeds text as counts of its words hashed into 32 buckets, so
+// texts sharing words are similar.
+type wordEmbedder struct{}
+
+func (wordEmbedder) Model() string { return "words-32" }
+
+func (wordEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	v := make([]float32, 32)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(word))
+		v[h.Sum32()%32]++
+	}
+	return v, nil
+}
+
+func TestSearchSimilar(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithEmbedder(wordEmbedder{}))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	prompts := []string{
+		"how do I rotate the signing keys",
+		"explain the retention policy for old intents",
+		"rotate signing keys every month",
+	}
+	for i, prompt := range prompts {
+		record := testIntent(t, i)
+		record.Prompt = prompt
+		record.Hash = ""
+		if _, err := s.AppendIntent(ctx, record); err != nil {
+			t.Fatalf("append intent %d: %v", i, err)
+		}
+	}
+	n, err := s.EmbedMissing(ctx, 0)
+	if err != nil || n != len(prompts) {
+		t.Fatalf("expected %d intents embedded, got %d, %v", len(prompts), n, err)
+	}
+	if n, err := s.EmbedMissing(ctx, 0); err != nil || n != 0 {
+		t.Fatalf("expected nothing left to embed, got %d, %v", n, err)
+	}
+
+	results, err := s.SearchSimilar(ctx, SimilarQuery{Text: "rotate the signing keys"}, 2)
+	if err != nil {
+		t.Fatalf("search similar: %v", err)
+	}
+	if len(results) != 2 || results[0].Intent.ID != "intent-000000" || results[1].Intent.ID != "intent-000002" {
+		t.Fatalf("expected the two key rotation intents, got %+v", results)
+	}
+	if results[0].Score < results[1].Score || results[0].Score > 1.0000001 {
+		t.Fatalf("expected descending cosine scores, got %v then %v", results[0].Score, results[1].Score)
+	}
+
+	stored, err := s.GetEmbedding(ctx, "intent-000001", "words-32")
+	if err != nil {
+		t.Fatalf("get embedding: %v", err)
+	}
+	byVector, err := s.SearchSimilar(ctx, SimilarQuery{Vector: stored.Vector}, 1)
+	if err != nil {
+		t.Fatalf("search similar by vector: %v", err)
+	}
+	if len(byVector) != 1 || byVector[0].Intent.ID != "intent-000001" {
+		t.Fatalf("expected the intent the vector came from, got %+v", byVector)
+	}
+
+	if err := s.TombstoneIntent(ctx, "intent-000000"); err != nil {
+		t.Fatalf("tombstone intent: %v", err)
+	}
+	if _, err := s.GetEmbedding(ctx, "intent-000000", "words-32"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected tombstoning to remove the embedding, got %v", err)
+	}
+	if err := s.PutEmbedding(ctx, Embedding{IntentID: "missing", Model: "words-32", Vector: []float32{1}}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a missing intent to be not found, got %v", err)
+	}
+}
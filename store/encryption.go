@@ -0,0 +1,85 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// EncryptionKeySize is the length of the AES-256 key taken by WithEncryptionKey.
+const EncryptionKeySize = 32
+
+// encryptionAESGCM marks columns sealed with AES-256-GCM in body_encoding.
+const encryptionAESGCM = "aes-256-gcm"
+
+// WithEncryptionKey encrypts the prompt, response, and meta of newly inserted
+// intents, and the content of newly stored blobs (including the bodies written
+// by CreateIntentStream), with AES-256-GCM under key, which must be
+// EncryptionKeySize bytes; Open fails otherwise. Hashes and blob digests are
+// computed over the plaintext, so chains and signatures verify as before. Each
+// value is bound to its intent ID and column, so ciphertexts cannot be swapped
+// between rows undetected; blob content is re-hashed on read.
+//
+// Reading an encrypted row or blob requires the same key. Other columns,
+// including title, tags, promoted meta values, meta revisions, and blob
+// digests, stay in plaintext, so anyone who can read the database can tell
+// whether it stores a blob with content they already know. ListIntentsByMeta
+// and Query cannot match meta inside encrypted rows. Rows and blobs written
+// before the key was set stay in plaintext.
+func WithEncryptionKey(key []byte) Option {
+	return func(o *options) {
+		o.encryptionKey = append([]byte(nil), key...)
+	}
+}
+
+// newAEAD builds the AES-256-GCM cipher for key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != EncryptionKeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", EncryptionKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealColumn encrypts plain as nonce||ciphertext bound to the intent and column.
+func sealColumn(aead cipher.AEAD, id, column string, plain []byte) ([]byte, error) {
+	return seal(aead, columnAAD(id, column), plain)
+}
+
+// openColumn reverses sealColumn.
+func openColumn(aead cipher.AEAD, id, column string, sealed []byte) ([]byte, error) {
+	return open(aead, columnAAD(id, column), sealed)
+}
+
+func columnAAD(id, column string) []byte {
+	return []byte("yanzi-intent:" + id + ":" + column)
+}
+
+// blobAAD binds blob content to label: the digest for inline data, or the
+// sequence number for a chunk, whose digest is not known while it is written.
+func blobAAD(label string) []byte {
+	return []byte("yanzi-blob:" + label)
+}
+
+// seal encrypts plain as nonce||ciphertext bound to aad.
+func seal(aead cipher.AEAD, aad, plain []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+// open reverses seal.
+func open(aead cipher.AEAD, aad, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+)
+
+func TestEncryptionAtRest(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "yanzi.db")
+	unkeyed, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = unkeyed.Close() })
+	if err := unkeyed.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	plain := testIntent(t, 1)
+	if err := unkeyed.CreateIntent(ctx, plain); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	key := bytes.Repeat([]byte{7}, EncryptionKeySize)
+	s, err := Open(dbPath, WithEncryptionKey(key), WithCompression(CompressionZstd))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	record := testIntent(t, 2)
+	record.Response = strings.Repeat("secret model output ", 50)
+	record.Meta = json.RawMessage(`{"model":"gpt"}`)
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	record.Hash = sum
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	var encoding string
+	var response, meta []byte
+	if err := s.db.QueryRowContext(ctx, `SELECT body_encoding, response, meta FROM intents WHERE id = ?`, record.ID).Scan(&encoding, &response, &meta); err != nil {
+		t.Fatalf("read row: %v", err)
+	}
+	if encoding != "zstd+aes-256-gcm" {
+		t.Fatalf("expected compressed and encrypted row, got %q", encoding)
+	}
+	if bytes.Contains(response, []byte("secret")) || bytes.Contains(meta, []byte("gpt")) {
+		t.Fatalf("expected ciphertext at rest")
+	}
+
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if got.Response != record.Response || string(got.Meta) != string(record.Meta) {
+		t.Fatalf("expected transparent decryption, got %q / %s", got.Response, got.Meta)
+	}
+	if err := hash.VerifyHash(got); err != nil {
+		t.Fatalf("verify hash: %v", err)
+	}
+
+	// JSON1 filters skip encrypted meta instead of failing on it.
+	if _, err := s.ListIntentsByMeta(ctx, map[string]string{"model": "gpt"}, 0); err != nil {
+		t.Fatalf("list by meta: %v", err)
+	}
+
+	if _, err := s.GetIntent(ctx, plain.ID); err != nil {
+		t.Fatalf("expected rows written before encryption to stay readable: %v", err)
+	}
+	if _, err := unkeyed.GetIntent(ctx, record.ID); err == nil {
+		t.Fatalf("expected encrypted row to require the key")
+	}
+
+	// Ciphertexts are bound to their row and column.
+	if _, err := s.db.ExecContext(ctx, `UPDATE intents SET prompt = response WHERE id = ?`, record.ID); err != nil {
+		t.Fatalf("swap columns: %v", err)
+	}
+	if _, err := s.GetIntent(ctx, record.ID); err == nil {
+		t.Fatalf("expected swapped ciphertext to fail decryption")
+	}
+}
+
+func TestEncryptedBlobs(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "yanzi.db")
+	key := bytes.Repeat([]byte{7}, EncryptionKeySize)
+	s, err := Open(dbPath, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	inline, err := s.PutBlob(ctx, []byte("secret inline blob"))
+	if err != nil {
+		t.Fatalf("put blob: %v", err)
+	}
+	chunked := bytes.Repeat([]byte("secret chunk "), blobChunkSize/8)
+	streamed, _, err := s.PutBlobReader(ctx, bytes.NewReader(chunked))
+	if err != nil {
+		t.Fatalf("put blob reader: %v", err)
+	}
+
+	var leaked int
+	if err := s.db.QueryRowContext(ctx, `SELECT
+		(SELECT COUNT(*) FROM blobs WHERE CAST(data AS TEXT) LIKE '%secret%') +
+		(SELECT COUNT(*) FROM blob_chunks WHERE CAST(data AS TEXT) LIKE '%secret%')`).Scan(&leaked); err != nil {
+		t.Fatalf("scan blobs: %v", err)
+	}
+	if leaked != 0 {
+		t.Fatalf("expected blob content to be encrypted at rest, found %d plaintext rows", leaked)
+	}
+
+	if got, err := s.GetBlob(ctx, inline); err != nil || string(got) != "secret inline blob" {
+		t.Fatalf("expected inline blob to decrypt, got %q, %v", got, err)
+	}
+	if got, err := s.GetBlob(ctx, streamed); err != nil || !bytes.Equal(got, chunked) {
+		t.Fatalf("expected chunked blob to decrypt, got %d bytes, %v", len(got), err)
+	}
+
+	unkeyed, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = unkeyed.Close() })
+	if _, err := unkeyed.GetBlob(ctx, inline); err == nil {
+		t.Fatalf("expected reading an encrypted blob without the key to fail")
+	}
+}
+
+func TestEncryptionKeySize(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithEncryptionKey([]byte("short"))); err == nil {
+		t.Fatalf("expected short key to be rejected")
+	}
+}
+
+func TestCanonicalizeEncryptedMeta(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithEncryptionKey(bytes.Repeat([]byte{1}, EncryptionKeySize)))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	record := testIntent(t, 1)
+	record.Meta = json.RawMessage(`{"b":1, "a":2}`)
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	record.Hash = sum
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	if _, err := s.CanonicalizeStoredMeta(ctx, false); err != nil {
+		t.Fatalf("canonicalize: %v", err)
+	}
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if string(got.Meta) != `{"a":2,"b":1}` {
+		t.Fatalf("expected canonical meta, got %s", got.Meta)
+	}
+	if ids, err := s.FindNonCanonicalMeta(ctx); err != nil || len(ids) != 0 {
+		t.Fatalf("expected no non-canonical meta, got %v, %v", ids, err)
+	}
+}
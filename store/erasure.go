@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ErasureReceipt records one EraseAuthor call.
+type ErasureReceipt struct {
+	ID        string
+	Author    string
+	ErasedAt  string
+	IntentIDs []string
+	// ExportDigest is the digest ("sha256:<hex>") of the JSONL export written
+	// for the erasure, so the export handed to the data subject can be matched
+	// to the receipt.
+	ExportDigest string
+}
+
+// EraseAuthor tombstones every live intent by author, as ApplyRetention does,
+// and stores an ErasureReceipt. Before tombstoning, each removed record is
+// written to export as one JSON object per line, oldest first, with the
+// content of its attachments inlined under "blobs" (keyed by digest) since
+// their blobs are removed too; a nil export discards it but the digest is
+// still recorded. The erased intents' author is replaced with "erased:" and
+// the receipt ID, and their hashes and chain links are kept so the chain stays
+// verifiable; the receipt keeps the author so the erasure can be audited.
+// Everything happens in one transaction, so a failed export leaves the store
+// unchanged.
+func (s *Store) EraseAuthor(ctx context.Context, author string, export io.Writer) (_ ErasureReceipt, err error) {
+	ctx, span := s.startSpan(ctx, "EraseAuthor")
+	defer func() { err = span.end(err) }()
+	defer s.cache.invalidate()
+	if err := s.writable("EraseAuthor"); err != nil {
+		return ErasureReceipt{}, err
+	}
+	if author == "" {
+		return ErasureReceipt{}, errors.New("author is required")
+	}
+	if export == nil {
+		export = io.Discard
+	}
+	id, err := model.NewIntentID()
+	if err != nil {
+		return ErasureReceipt{}, fmt.Errorf("generate receipt id: %w", err)
+	}
+	receipt := ErasureReceipt{ID: id, Author: author}
+
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT `+intentColumns+` FROM intents
+			WHERE author = ? AND tombstoned_at IS NULL ORDER BY created_at ASC, id ASC`, author)
+		if err != nil {
+			return err
+		}
+		records, err := s.collectIntents(ctx, rows)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := s.authorize(ctx, ActionTombstone, record); err != nil {
+				return err
+			}
+		}
+
+		digest := sha256.New()
+		enc := json.NewEncoder(io.MultiWriter(export, digest))
+		for _, record := range records {
+			line, err := s.erasedIntent(ctx, tx, record)
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(line); err != nil {
+				return fmt.Errorf("export intent %s: %w", record.ID, err)
+			}
+		}
+
+		receipt.ErasedAt = s.now().Format(time.RFC3339Nano)
+		receipt.IntentIDs = make([]string, 0, len(records))
+		for _, record := range records {
+			record.Author = "erased:" + receipt.ID
+			if err := tombstoneTx(ctx, tx, record, receipt.ErasedAt); err != nil {
+				return fmt.Errorf("tombstone intent %s: %w", record.ID, err)
+			}
+			receipt.IntentIDs = append(receipt.IntentIDs, record.ID)
+		}
+		receipt.ExportDigest = hash.FormatBlobDigest(digest.Sum(nil))
+
+		ids, err := json.Marshal(receipt.IntentIDs)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO erasure_receipts (id, author, erased_at, intent_ids, export_digest) VALUES (?, ?, ?, ?, ?)`,
+			receipt.ID, receipt.Author, receipt.ErasedAt, string(ids), receipt.ExportDigest)
+		return err
+	})
+	if err != nil {
+		return ErasureReceipt{}, err
+	}
+	return receipt, nil
+}
+
+// erasedIntent is an EraseAuthor export line.
+type erasedIntent struct {
+	model.IntentRecord
+	// Blobs holds the content of the record's attachments by digest.
+	Blobs map[string][]byte `json:"blobs,omitempty"`
+}
+
+// erasedIntent reads the attachments of record within tx for its export
+// line. Blobs already removed are left out.
+func (s *Store) erasedIntent(ctx context.Context, tx *sql.Tx, record model.IntentRecord) (erasedIntent, error) {
+	line := erasedIntent{IntentRecord: record}
+	for _, a := range record.Attachments {
+		rc, err := s.openBlob(ctx, tx, a.Digest)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return line, fmt.Errorf("export attachment %s of %s: %w", a.Digest, record.ID, err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return line, fmt.Errorf("export attachment %s of %s: %w", a.Digest, record.ID, err)
+		}
+		if line.Blobs == nil {
+			line.Blobs = make(map[string][]byte)
+		}
+		line.Blobs[a.Digest] = data
+	}
+	return line, nil
+}
+
+// ErasureReceipts returns the receipts recorded for author, oldest first.
+func (s *Store) ErasureReceipts(ctx context.Context, author string) (_ []ErasureReceipt, err error) {
+	ctx, span := s.startSpan(ctx, "ErasureReceipts")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT id, author, erased_at, intent_ids, export_digest
+		FROM erasure_receipts WHERE author = ? ORDER BY erased_at ASC, id ASC`, author)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []ErasureReceipt
+	for rows.Next() {
+		var receipt ErasureReceipt
+		var ids string
+		if err := rows.Scan(&receipt.ID, &receipt.Author, &receipt.ErasedAt, &ids, &receipt.ExportDigest); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(ids), &receipt.IntentIDs); err != nil {
+			return nil, fmt.Errorf("decode receipt %s: %w", receipt.ID, err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, rows.Err()
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestEraseAuthor(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	var alice []model.IntentRecord
+	for i, author := range []string{"alice", "bob", "alice"} {
+		record, err := s.AppendIntent(ctx, model.IntentRecord{
+			Author:     author,
+			SourceType: "cli",
+			CreatedAt:  testIntent(t, i).CreatedAt,
+			Prompt:     "my address is 1 Main St",
+			Response:   "noted",
+		})
+		if err != nil {
+			t.Fatalf("append intent: %v", err)
+		}
+		if author == "alice" {
+			alice = append(alice, record)
+		}
+	}
+
+	var export bytes.Buffer
+	receipt, err := s.EraseAuthor(ctx, "alice", &export)
+	if err != nil {
+		t.Fatalf("erase author: %v", err)
+	}
+	if len(receipt.IntentIDs) != 2 || receipt.IntentIDs[0] != alice[0].ID {
+		t.Fatalf("unexpected receipt %+v", receipt)
+	}
+	if receipt.ExportDigest != hash.BlobDigest(export.Bytes()) {
+		t.Fatalf("expected receipt to carry the export digest")
+	}
+
+	scanner := bufio.NewScanner(&export)
+	var exported []model.IntentRecord
+	for scanner.Scan() {
+		var record model.IntentRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decode export line: %v", err)
+		}
+		exported = append(exported, record)
+	}
+	if len(exported) != 2 || exported[1].Prompt != alice[1].Prompt {
+		t.Fatalf("expected exported content, got %+v", exported)
+	}
+
+	for _, record := range alice {
+		got, err := s.GetIntent(ctx, record.ID)
+		if err != nil {
+			t.Fatalf("get intent: %v", err)
+		}
+		if !got.Tombstoned() || got.Prompt != "" {
+			t.Fatalf("expected %s to be tombstoned", record.ID)
+		}
+	}
+	report, err := chain.VerifyChain(ctx, s, alice[1].Hash)
+	if err != nil || !report.Valid() {
+		t.Fatalf("expected erased chain to verify, got %+v, %v", report, err)
+	}
+
+	receipts, err := s.ErasureReceipts(ctx, "alice")
+	if err != nil {
+		t.Fatalf("erasure receipts: %v", err)
+	}
+	if len(receipts) != 1 || receipts[0].ID != receipt.ID || len(receipts[0].IntentIDs) != 2 {
+		t.Fatalf("unexpected stored receipts %+v", receipts)
+	}
+
+	again, err := s.EraseAuthor(ctx, "alice", nil)
+	if err != nil {
+		t.Fatalf("erase again: %v", err)
+	}
+	if len(again.IntentIDs) != 0 {
+		t.Fatalf("expected nothing left to erase, got %v", again.IntentIDs)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestEraseAuthorExportFailureLeavesStoreUnchanged(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	if _, err := s.EraseAuthor(ctx, record.Author, failingWriter{}); err == nil {
+		t.Fatalf("expected export failure")
+	}
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if got.Tombstoned() {
+		t.Fatalf("expected intent to be untouched after a failed export")
+	}
+}
+
+func TestEraseAuthorInlinesBlobsAndClearsAuthor(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	content := []byte("scanned passport")
+	digest, err := s.PutBlob(ctx, content)
+	if err != nil {
+		t.Fatalf("put blob: %v", err)
+	}
+	record, err := s.AppendIntent(ctx, model.IntentRecord{
+		Author:      "alice",
+		SourceType:  "cli",
+		Prompt:      "see attached",
+		Response:    "noted",
+		Attachments: []model.Attachment{{Digest: digest, Name: "passport", Size: int64(len(content))}},
+	})
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+
+	var export bytes.Buffer
+	receipt, err := s.EraseAuthor(ctx, "alice", &export)
+	if err != nil {
+		t.Fatalf("erase author: %v", err)
+	}
+	var line struct {
+		ID    string            `json:"id"`
+		Blobs map[string][]byte `json:"blobs"`
+	}
+	if err := json.Unmarshal(export.Bytes(), &line); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if line.ID != record.ID || !bytes.Equal(line.Blobs[digest], content) {
+		t.Fatalf("expected the attachment content in the export, got %+v", line)
+	}
+	if _, err := s.GetBlob(ctx, digest); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the blob to be removed, got %v", err)
+	}
+
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if got.Author != "erased:"+receipt.ID {
+		t.Fatalf("expected the author to be cleared, got %q", got.Author)
+	}
+	if receipts, err := s.ErasureReceipts(ctx, "alice"); err != nil || len(receipts) != 1 {
+		t.Fatalf("expected the receipt to stay findable by author, got %+v, %v", receipts, err)
+	}
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Errors returned by the store match one of these via errors.Is; they are the
+// model package's sentinels, so chain and hash errors classify the same way.
+var (
+	// ErrNotFound matches every *NotFoundError.
+	ErrNotFound = model.ErrNotFound
+	// ErrDuplicateID matches every *DuplicateIDError and *ConflictError.
+	ErrDuplicateID = model.ErrDuplicateID
+	// ErrHashMismatch matches a record whose hash does not match its content.
+	ErrHashMismatch = model.ErrHashMismatch
+	// ErrChainBroken matches a chain that failed verification.
+	ErrChainBroken = model.ErrChainBroken
+	// ErrInvalidRecord matches a record rejected by validation, including a
+	// meta schema or a chain key.
+	ErrInvalidRecord = model.ErrInvalidRecord
+)
+
+// NotFoundError reports a lookup that matched nothing. It matches ErrNotFound
+// and, for callers written before it existed, sql.ErrNoRows.
+type NotFoundError struct {
+	// Kind names what was looked up, such as "intent" or "blob".
+	Kind string
+	// Key is the ID, hash, digest, or name looked up; it may be empty.
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Key == "" {
+		return e.Kind + " not found"
+	}
+	return fmt.Sprintf("%s %s not found", e.Kind, e.Key)
+}
+
+// Unwrap lets errors.Is match ErrNotFound and sql.ErrNoRows.
+func (e *NotFoundError) Unwrap() []error {
+	return []error{ErrNotFound, sql.ErrNoRows}
+}
+
+// DuplicateIDError reports an insert whose ID is already stored.
+type DuplicateIDError struct {
+	ID           string
+	Hash         string
+	ExistingHash string
+}
+
+func (e *DuplicateIDError) Error() string {
+	return fmt.Sprintf("intent %s already exists", e.ID)
+}
+
+// Unwrap lets errors.Is(err, ErrDuplicateID) match.
+func (e *DuplicateIDError) Unwrap() error {
+	return ErrDuplicateID
+}
+
+// notFound converts sql.ErrNoRows from looking up kind by key into a
+// *NotFoundError; other errors pass through.
+func notFound(err error, kind, key string) error {
+	if errors.Is(err, sql.ErrNoRows) && !errors.Is(err, ErrNotFound) {
+		return &NotFoundError{Kind: kind, Key: key}
+	}
+	return err
+}
+
+// duplicateTx converts a uniqueness violation from inserting record into a
+// *DuplicateIDError when record's ID is already stored; other errors pass
+// through. The lookup runs in tx, which a failed insert leaves usable.
+func duplicateTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord, err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+	if code := sqliteErr.Code(); code != sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY && code != sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return err
+	}
+	var existing string
+	if tx.QueryRowContext(ctx, `SELECT hash FROM intents WHERE id = ?`, record.ID).Scan(&existing) != nil {
+		return err
+	}
+	return &DuplicateIDError{ID: record.ID, Hash: record.Hash, ExistingHash: existing}
+}
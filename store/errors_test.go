@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestStoreErrorTaxonomy(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	_, err := s.GetIntent(ctx, "missing")
+	var nf *NotFoundError
+	if !errors.As(err, &nf) || nf.Kind != "intent" || nf.Key != "missing" {
+		t.Fatalf("expected NotFoundError for intent missing, got %v", err)
+	}
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected ErrNotFound and sql.ErrNoRows, got %v", err)
+	}
+	if _, err := s.ChainHead(ctx, "nobody"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for a missing chain, got %v", err)
+	}
+
+	duplicate := testIntent(t, 2)
+	duplicate.ID = record.ID
+	err = s.CreateIntent(ctx, duplicate)
+	var dup *DuplicateIDError
+	if !errors.As(err, &dup) || dup.ExistingHash != record.Hash {
+		t.Fatalf("expected DuplicateIDError with existing hash %s, got %v", record.Hash, err)
+	}
+
+	if !errors.Is(&model.HashMismatchError{ID: "x"}, ErrHashMismatch) {
+		t.Fatal("expected HashMismatchError to match ErrHashMismatch")
+	}
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ForkPoints returns the hashes referenced as prev_hash by more than one intent,
+// ordered by hash.
+func (s *Store) ForkPoints(ctx context.Context) (_ []string, err error) {
+	ctx, span := s.startSpan(ctx, "ForkPoints")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT prev_hash FROM intents
+		WHERE prev_hash IS NOT NULL AND prev_hash != ''
+		GROUP BY prev_hash HAVING COUNT(1) > 1 ORDER BY prev_hash`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
+
+// ListChildren returns the intents whose prev_hash is prevHash, oldest first.
+func (s *Store) ListChildren(ctx context.Context, prevHash string) (_ []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "ListChildren")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+intentColumns+` FROM intents WHERE prev_hash = ? ORDER BY created_at ASC, id ASC`, prevHash)
+	if err != nil {
+		return nil, err
+	}
+	return s.collectIntents(ctx, rows)
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestDetectForks(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	genesis := linkedIntent(t, 0, "alice", "")
+	main1 := linkedIntent(t, 1, "alice", genesis.Hash)
+	main2 := linkedIntent(t, 2, "alice", main1.Hash)
+	rewrite := linkedIntent(t, 3, "alice", genesis.Hash)
+	for _, record := range []model.IntentRecord{genesis, main1, main2, rewrite} {
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create %s: %v", record.ID, err)
+		}
+	}
+
+	forks, err := chain.DetectForks(ctx, s)
+	if err != nil {
+		t.Fatalf("detect forks: %v", err)
+	}
+	if len(forks) != 1 || forks[0].Parent != genesis.Hash {
+		t.Fatalf("expected one fork at genesis, got %+v", forks)
+	}
+	want := []chain.Branch{
+		{Root: main1.Hash, Head: main2.Hash, Length: 2},
+		{Root: rewrite.Hash, Head: rewrite.Hash, Length: 1},
+	}
+	if len(forks[0].Branches) != len(want) {
+		t.Fatalf("expected %d branches, got %+v", len(want), forks[0].Branches)
+	}
+	for i, branch := range forks[0].Branches {
+		if branch != want[i] {
+			t.Fatalf("branch %d: expected %+v, got %+v", i, want[i], branch)
+		}
+	}
+}
+
+func TestMerkleRootUpTo(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	var hashes []string
+	for i := 0; i < 4; i++ {
+		record := testIntent(t, i)
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+		hashes = append(hashes, record.Hash)
+	}
+
+	upTo := time.Date(2026, 2, 9, 10, 0, 2, 0, time.UTC)
+	root, err := chain.MerkleRoot(ctx, s, upTo)
+	if err != nil {
+		t.Fatalf("merkle root: %v", err)
+	}
+	if want := chain.NewMerkleTree(hashes[:3]).Root(); root != want {
+		t.Fatalf("expected root over first three intents %s, got %s", want, root)
+	}
+
+	tree, err := chain.BuildMerkleTree(ctx, s, time.Time{})
+	if err != nil {
+		t.Fatalf("build tree: %v", err)
+	}
+	proof, err := tree.MerkleProof(hashes[1])
+	if err != nil {
+		t.Fatalf("merkle proof: %v", err)
+	}
+	if err := chain.VerifyMerkleProof(tree.Root(), proof); err != nil {
+		t.Fatalf("verify proof: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// IntentHashes returns the hashes of intents created at or before upTo in
+// canonical (created_at, id) order. A zero upTo includes every intent.
+func (s *Store) IntentHashes(ctx context.Context, upTo time.Time) (_ []string, err error) {
+	ctx, span := s.startSpan(ctx, "IntentHashes")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return intentHashes(ctx, s.db, upTo)
+}
+
+func intentHashes(ctx context.Context, q queryer, upTo time.Time) ([]string, error) {
+	query := `SELECT hash FROM intents`
+	var args []any
+	if !upTo.IsZero() {
+		query += ` WHERE julianday(created_at) <= julianday(?)`
+		args = append(args, upTo.UTC().Format(time.RFC3339Nano))
+	}
+	query += ` ORDER BY created_at ASC, id ASC`
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes, rows.Err()
+}
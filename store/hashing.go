@@ -0,0 +1,22 @@
+package store
+
+// Hashing selects how AppendIntent and CreateIntentStream hash the records
+// they hash themselves. The zero value hashes with hash.HashIntent under each
+// record's own HashVersion.
+type Hashing struct {
+	// Version is the HashVersion given to records that have none.
+	Version int
+	// Algorithm names a registered hash algorithm (see
+	// hash.RegisterAlgorithm); empty selects hash.HashIntent.
+	Algorithm string
+	// HMACSecret, when set, produces keyed hashes with hash.HMACIntent in
+	// place of Algorithm. Pair it with Validation.HMACSecret.
+	HMACSecret []byte
+}
+
+// WithHashing sets how the store hashes the records it hashes itself.
+func WithHashing(h Hashing) Option {
+	return func(o *options) {
+		o.hashing = h
+	}
+}
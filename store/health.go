@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Health is a point-in-time readiness report, encoded as JSON for /healthz
+// style endpoints.
+type Health struct {
+	// Reachable is false when the database could not be queried; Error says why.
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+	// PendingMigrations lists migrations Migrate would still apply.
+	PendingMigrations []string `json:"pending_migrations"`
+	// WALBytes is the size of the write-ahead log file.
+	WALBytes int64 `json:"wal_bytes"`
+	// FreeDiskBytes is the space available to the process on the database's
+	// filesystem, or -1 where it cannot be determined.
+	FreeDiskBytes int64 `json:"free_disk_bytes"`
+	// LastVerifiedAt is when a chain last verified without issues, as
+	// recorded by RecordVerification; empty if never.
+	LastVerifiedAt string `json:"last_verified_at,omitempty"`
+	CheckedAt      string `json:"checked_at"`
+}
+
+// Ready reports whether the store can serve requests: the database is
+// reachable and fully migrated.
+func (h Health) Ready() bool {
+	return h.Reachable && h.Error == "" && len(h.PendingMigrations) == 0
+}
+
+// Health checks the database and reports on it. Failures are reported in the
+// result rather than returned, so it is always safe to encode.
+func (s *Store) Health(ctx context.Context) Health {
+	ctx, span := s.startSpan(ctx, "Health")
+	defer span.end(nil)
+	h := Health{
+		PendingMigrations: []string{},
+		FreeDiskBytes:     -1,
+		CheckedAt:         time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := s.checkHealth(ctx, &h); err != nil {
+		h.Error = err.Error()
+		span.SetStatus(codes.Error, h.Error)
+	}
+	return h
+}
+
+func (s *Store) checkHealth(ctx context.Context, h *Health) error {
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	if err := s.db.PingContext(ctx); err != nil {
+		return err
+	}
+	h.Reachable = true
+
+	pending, err := s.pendingMigrations(ctx, MigrateOptions{})
+	if err != nil {
+		return err
+	}
+	for _, m := range pending {
+		h.PendingMigrations = append(h.PendingMigrations, m.Version)
+	}
+
+	file, err := s.databaseFile(ctx)
+	if err != nil {
+		return err
+	}
+	if file != "" {
+		if fi, err := os.Stat(file + "-wal"); err == nil {
+			h.WALBytes = fi.Size()
+		}
+		if free, err := freeDiskBytes(file); err == nil {
+			h.FreeDiskBytes = free
+		}
+	}
+
+	if len(pending) == 0 {
+		var last sql.NullString
+		if err := s.rdb.QueryRowContext(ctx, `SELECT MAX(verified_at) FROM verification_runs WHERE issues = 0`).Scan(&last); err != nil {
+			return err
+		}
+		h.LastVerifiedAt = last.String
+	}
+	return nil
+}
+
+// databaseFile returns the path of the main database file, or "" for an
+// in-memory database.
+func (s *Store) databaseFile(ctx context.Context) (string, error) {
+	var seq int
+	var name, file string
+	if err := s.rdb.QueryRowContext(ctx, `PRAGMA database_list`).Scan(&seq, &name, &file); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// RecordVerification records that the chain with head was verified, visiting
+// length records and finding issues problems. Runs without issues are what
+// Health reports as LastVerifiedAt.
+func (s *Store) RecordVerification(ctx context.Context, head string, length, issues int) (err error) {
+	ctx, span := s.startSpan(ctx, "RecordVerification")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("RecordVerification"); err != nil {
+		return err
+	}
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	if head == "" {
+		return errors.New("head hash is required")
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO verification_runs (head, length, issues, verified_at) VALUES (?, ?, ?, ?)`,
+		head, length, issues, time.Now().UTC().Format(time.RFC3339Nano))
+	return err
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthReportsPendingMigrations(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	h := s.Health(ctx)
+	if !h.Reachable || h.Ready() || len(h.PendingMigrations) == 0 || h.PendingMigrations[0] != "0001_create_intents.sql" {
+		t.Fatalf("expected an unmigrated store to be reachable but not ready, got %+v", h)
+	}
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	h = s.Health(ctx)
+	if !h.Ready() || h.Error != "" {
+		t.Fatalf("expected a ready store, got %+v", h)
+	}
+	if h.WALBytes <= 0 {
+		t.Fatalf("expected a write-ahead log after migrating, got %d bytes", h.WALBytes)
+	}
+	if h.FreeDiskBytes == 0 {
+		t.Fatal("expected free disk space to be reported")
+	}
+}
+
+func TestRecordVerificationSetsLastVerifiedAt(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	if err := s.RecordVerification(ctx, "head-1", 3, 1); err != nil {
+		t.Fatalf("record failed verification: %v", err)
+	}
+	if h := s.Health(ctx); h.LastVerifiedAt != "" {
+		t.Fatalf("a run with issues must not count as verified, got %q", h.LastVerifiedAt)
+	}
+	if err := s.RecordVerification(ctx, "head-1", 3, 0); err != nil {
+		t.Fatalf("record verification: %v", err)
+	}
+	if h := s.Health(ctx); h.LastVerifiedAt == "" {
+		t.Fatal("expected LastVerifiedAt after a clean run")
+	}
+	if err := s.RecordVerification(ctx, "", 0, 0); err == nil {
+		t.Fatal("expected error without a head")
+	}
+}
+
+func TestHealthUninitialized(t *testing.T) {
+	h := (&Store{}).Health(context.Background())
+	if h.Reachable || h.Error == "" || h.Ready() {
+		t.Fatalf("expected an unreachable report, got %+v", h)
+	}
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// DefaultIdempotencyTTL is how long an idempotency key is remembered unless
+// overridden with WithIdempotencyTTL.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// ErrIdempotencyKeyReused is returned by AppendIntentWithKey when the key was
+// already used for a different record.
+var ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+
+// errIdempotencyKeyTaken aborts an append whose key was stored by a
+// concurrent writer after it was looked up.
+var errIdempotencyKeyTaken = errors.New("idempotency key taken")
+
+// WithIdempotencyTTL sets how long AppendIntentWithKey remembers a key; values
+// <= 0 keep the default.
+func WithIdempotencyTTL(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.idempotencyTTL = d
+		}
+	}
+}
+
+// AppendIntentWithKey appends record as AppendIntent does and remembers key,
+// so a retry of the same request within the TTL (see WithIdempotencyTTL)
+// returns the originally stored record with replayed set instead of
+// appending a duplicate under a new ID. Keys are scoped to record.Author, so
+// two authors may use the same key independently. Requests are the same when
+// the records passed in are equal; reusing a live key for a different record
+// returns ErrIdempotencyKeyReused. The key is stored in the append
+// transaction, so concurrent retries store the record once.
+//
+// A replay returns the record as it was stored, even if a later Merge
+// replaced it or it has since been removed. Once the intent is tombstoned,
+// the stored copy is discarded and the tombstoned record is returned.
+func (s *Store) AppendIntentWithKey(ctx context.Context, key string, record model.IntentRecord) (stored model.IntentRecord, replayed bool, err error) {
+	ctx, span := s.startSpan(ctx, "AppendIntentWithKey")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpCreate, start, 1, err) }(time.Now())
+	if err := s.writable("AppendIntentWithKey"); err != nil {
+		return model.IntentRecord{}, false, err
+	}
+	if key == "" {
+		return model.IntentRecord{}, false, errors.New("idempotency key is required")
+	}
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return model.IntentRecord{}, false, fmt.Errorf("fingerprint request: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	for attempt := 0; attempt < 2; attempt++ {
+		stored, found, err := s.replayIdempotent(ctx, record.Author, key, fingerprint)
+		if err != nil || found {
+			return stored, found, err
+		}
+
+		stored, err = s.appendIntent(ctx, "AppendIntentWithKey", record, func(tx *sql.Tx, stored model.IntentRecord) error {
+			result, err := json.Marshal(stored)
+			if err != nil {
+				return fmt.Errorf("encode idempotent result: %w", err)
+			}
+			if result, err = s.codec.sealBlob(idempotencyLabel(stored.ID), result); err != nil {
+				return fmt.Errorf("encode idempotent result: %w", err)
+			}
+			now := s.now()
+			res, err := tx.ExecContext(ctx, `INSERT INTO idempotency_keys (author, key, fingerprint, intent_id, result, result_encoding, created_at, expires_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (author, key) DO UPDATE SET fingerprint = excluded.fingerprint, intent_id = excluded.intent_id,
+					result = excluded.result, result_encoding = excluded.result_encoding,
+					created_at = excluded.created_at, expires_at = excluded.expires_at
+				WHERE julianday(idempotency_keys.expires_at) <= julianday(excluded.created_at)`,
+				stored.Author, key, fingerprint, stored.ID, result, s.codec.blobEncoding(),
+				now.Format(time.RFC3339Nano), now.Add(s.opts.idempotencyTTL).Format(time.RFC3339Nano))
+			if err != nil {
+				return fmt.Errorf("store idempotency key: %w", err)
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return errIdempotencyKeyTaken
+			}
+			return nil
+		})
+		if errors.Is(err, errIdempotencyKeyTaken) {
+			continue
+		}
+		return stored, false, err
+	}
+	return model.IntentRecord{}, false, errors.New("idempotency key changed hands during append")
+}
+
+// replayIdempotent returns the record stored under author's live key, if
+// any: the copy kept when the key was stored, or the intent as it is now when
+// that copy was discarded on tombstoning.
+func (s *Store) replayIdempotent(ctx context.Context, author, key, fingerprint string) (model.IntentRecord, bool, error) {
+	var (
+		stored, id string
+		result     []byte
+		encoding   sql.NullString
+	)
+	err := s.rdb.QueryRowContext(ctx, `SELECT fingerprint, intent_id, result, result_encoding FROM idempotency_keys
+		WHERE author = ? AND key = ? AND julianday(expires_at) > julianday(?)`,
+		author, key, s.now().Format(time.RFC3339Nano)).Scan(&stored, &id, &result, &encoding)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.IntentRecord{}, false, nil
+	}
+	if err != nil {
+		return model.IntentRecord{}, false, fmt.Errorf("read idempotency key: %w", err)
+	}
+	if stored != fingerprint {
+		return model.IntentRecord{}, false, ErrIdempotencyKeyReused
+	}
+	if result == nil {
+		record, err := s.GetIntent(ctx, id)
+		if err != nil {
+			return model.IntentRecord{}, false, err
+		}
+		return record, true, nil
+	}
+	plain, err := s.codec.openBlob(encoding.String, idempotencyLabel(id), result)
+	if err != nil {
+		return model.IntentRecord{}, false, fmt.Errorf("read idempotent result: %w", err)
+	}
+	var record model.IntentRecord
+	if err := json.Unmarshal(plain, &record); err != nil {
+		return model.IntentRecord{}, false, fmt.Errorf("read idempotent result: %w", err)
+	}
+	if err := s.authorize(ctx, ActionGet, record); err != nil {
+		return model.IntentRecord{}, false, err
+	}
+	return record, true, nil
+}
+
+// idempotencyLabel binds a stored result to its intent when it is encrypted.
+func idempotencyLabel(id string) string {
+	return "idempotency:" + id
+}
+
+// PurgeIdempotencyKeys deletes expired idempotency keys and returns how many
+// were removed. Expired keys are ignored and overwritten anyway; purging only
+// reclaims space.
+func (s *Store) PurgeIdempotencyKeys(ctx context.Context) (_ int64, err error) {
+	ctx, span := s.startSpan(ctx, "PurgeIdempotencyKeys")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("PurgeIdempotencyKeys"); err != nil {
+		return 0, err
+	}
+	if s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE julianday(expires_at) <= julianday(?)`, s.now().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestAppendIntentWithKey(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+
+	first, replayed, err := s.AppendIntentWithKey(ctx, "req-1", record)
+	if err != nil || replayed {
+		t.Fatalf("first append: replayed=%v err=%v", replayed, err)
+	}
+	again, replayed, err := s.AppendIntentWithKey(ctx, "req-1", record)
+	if err != nil || !replayed {
+		t.Fatalf("retry: replayed=%v err=%v", replayed, err)
+	}
+	if again.ID != first.ID || again.Hash != first.Hash {
+		t.Fatalf("expected the original record %s, got %s", first.ID, again.ID)
+	}
+
+	changed := record
+	changed.Prompt = "different"
+	if _, _, err := s.AppendIntentWithKey(ctx, "req-1", changed); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+	if _, replayed, err := s.AppendIntentWithKey(ctx, "req-2", record); err != nil || replayed {
+		t.Fatalf("expected a new key to append, got replayed=%v err=%v", replayed, err)
+	}
+
+	listed, err := s.ListIntents(ctx, 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(listed) != 2 {
+		t.Fatalf("expected 2 intents, got %d", len(listed))
+	}
+}
+
+func TestAppendIntentWithKeyConcurrentRetries(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+
+	var wg sync.WaitGroup
+	ids := make([]string, 8)
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stored, _, err := s.AppendIntentWithKey(ctx, "req", record)
+			if err != nil {
+				t.Errorf("append: %v", err)
+				return
+			}
+			ids[i] = stored.ID
+		}()
+	}
+	wg.Wait()
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("expected every retry to return %s, got %v", ids[0], ids)
+		}
+	}
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithIdempotencyTTL(time.Millisecond))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	record := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+
+	first, _, err := s.AppendIntentWithKey(ctx, "req", record)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	second, replayed, err := s.AppendIntentWithKey(ctx, "req", record)
+	if err != nil || replayed || second.ID == first.ID {
+		t.Fatalf("expected an expired key to append anew, got replayed=%v err=%v", replayed, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	n, err := s.PurgeIdempotencyKeys(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 key purged, got %d, %v", n, err)
+	}
+}
+
+func TestIdempotencyKeysScopedPerAuthor(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	alice := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+	bob := model.IntentRecord{Author: "bob", SourceType: "api", Prompt: "other", Response: "r"}
+
+	first, _, err := s.AppendIntentWithKey(ctx, "req", alice)
+	if err != nil {
+		t.Fatalf("append alice: %v", err)
+	}
+	second, replayed, err := s.AppendIntentWithKey(ctx, "req", bob)
+	if err != nil || replayed || second.ID == first.ID {
+		t.Fatalf("expected bob's key to be independent, got replayed=%v err=%v", replayed, err)
+	}
+	again, replayed, err := s.AppendIntentWithKey(ctx, "req", alice)
+	if err != nil || !replayed || again.ID != first.ID {
+		t.Fatalf("expected alice's retry to replay %s, got %s replayed=%v err=%v", first.ID, again.ID, replayed, err)
+	}
+}
+
+func TestIdempotencyKeyReplaysStoredResult(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := model.IntentRecord{Author: "alice", SourceType: "api", Prompt: "p", Response: "r"}
+
+	first, _, err := s.AppendIntentWithKey(ctx, "removed", record)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM intents WHERE id = ?`, first.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	again, replayed, err := s.AppendIntentWithKey(ctx, "removed", record)
+	if err != nil || !replayed {
+		t.Fatalf("expected a replay of the removed intent, got replayed=%v err=%v", replayed, err)
+	}
+	if again.ID != first.ID || again.Hash != first.Hash || again.Prompt != "p" {
+		t.Fatalf("expected the stored record %+v, got %+v", first, again)
+	}
+
+	other := record
+	other.Prompt = "q"
+	tombstoned, _, err := s.AppendIntentWithKey(ctx, "tombstoned", other)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := s.TombstoneIntent(ctx, tombstoned.ID); err != nil {
+		t.Fatalf("tombstone: %v", err)
+	}
+	again, replayed, err = s.AppendIntentWithKey(ctx, "tombstoned", other)
+	if err != nil || !replayed {
+		t.Fatalf("expected a replay of the tombstoned intent, got replayed=%v err=%v", replayed, err)
+	}
+	if !again.Tombstoned() || again.Prompt != "" {
+		t.Fatalf("expected the tombstoned record, got %+v", again)
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ErrConflict matches any ConflictError via errors.Is.
+var ErrConflict = errors.New("intent conflict")
+
+// ConflictError reports an insert whose ID is already stored with a different
+// hash. It matches both ErrConflict and ErrDuplicateID.
+type ConflictError struct {
+	ID           string
+	Hash         string
+	ExistingHash string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("intent %s already exists with hash %s (got %s)", e.ID, e.ExistingHash, e.Hash)
+}
+
+// Unwrap lets errors.Is match ErrConflict and ErrDuplicateID.
+func (e *ConflictError) Unwrap() []error {
+	return []error{ErrConflict, ErrDuplicateID}
+}
+
+// CreateIntentIdempotent inserts record unless an intent with the same ID and
+// hash already exists, in which case it is a no-op. If the ID exists with a
+// different hash a *ConflictError is returned, so retried ingestion can tell a
+// replay from a genuine collision.
+func (s *Store) CreateIntentIdempotent(ctx context.Context, record model.IntentRecord) (err error) {
+	ctx, span := s.startSpan(ctx, "CreateIntentIdempotent")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("CreateIntentIdempotent"); err != nil {
+		return err
+	}
+	err = s.CreateIntent(ctx, record)
+	var dup *DuplicateIDError
+	if !errors.As(err, &dup) {
+		return err
+	}
+	if dup.ExistingHash == record.Hash {
+		return nil
+	}
+	return &ConflictError{ID: record.ID, Hash: record.Hash, ExistingHash: dup.ExistingHash}
+}
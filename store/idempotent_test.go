@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCreateIntentIdempotent(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := testIntent(t, 1)
+
+	if err := s.CreateIntentIdempotent(ctx, record); err != nil {
+		t.Fatalf("first create: %v", err)
+	}
+	if err := s.CreateIntentIdempotent(ctx, record); err != nil {
+		t.Fatalf("expected identical retry to be a no-op: %v", err)
+	}
+
+	changed := record
+	changed.Hash = "different"
+	err := s.CreateIntentIdempotent(ctx, changed)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.ExistingHash != record.Hash {
+		t.Fatalf("expected ConflictError with existing hash %s, got %v", record.Hash, err)
+	}
+
+	intents, err := s.ListIntents(ctx, 0)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	if len(intents) != 1 {
+		t.Fatalf("expected one stored intent, got %d", len(intents))
+	}
+}
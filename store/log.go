@@ -0,0 +1,37 @@
+package store
+
+import (
+	"log/slog"
+	"time"
+)
+
+// DefaultSlowThreshold is the duration above which a store call is logged as
+// slow unless overridden with WithSlowThreshold.
+const DefaultSlowThreshold = 500 * time.Millisecond
+
+// WithLogger logs migrations, slow calls, and retries to l. Without it the
+// store logs nothing.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithSlowThreshold sets the duration above which a create, read, or query
+// call is logged at warning level; values <= 0 keep the default.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.slowThreshold = d
+		}
+	}
+}
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func (s *Store) logger() *slog.Logger {
+	if s.opts.logger == nil {
+		return discardLogger
+	}
+	return s.opts.logger
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerRecordsMigrationsAndSlowCalls(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithLogger(logger), WithSlowThreshold(time.Nanosecond))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if !strings.Contains(buf.String(), `msg="applied migration" version=0001_create_intents.sql`) {
+		t.Fatalf("expected migration log, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if _, err := s.ListIntents(ctx, 10); err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	if !strings.Contains(buf.String(), `level=WARN msg="slow store operation" operation=read`) {
+		t.Fatalf("expected slow call log, got:\n%s", buf.String())
+	}
+}
+
+func TestStoreIsSilentByDefault(t *testing.T) {
+	s := openTestStore(t)
+	if s.logger().Enabled(context.Background(), slog.LevelError) {
+		t.Fatal("expected the default logger to discard everything")
+	}
+}
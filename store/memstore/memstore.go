@@ -0,0 +1,189 @@
+// Package memstore provides an in-memory store.Backend for tests and ephemeral use.
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func init() {
+	store.RegisterBackend("memory", func(string) (store.Backend, error) {
+		return New(), nil
+	})
+}
+
+// Store keeps intents in maps guarded by a mutex. Errors match the store
+// package's taxonomy: lookups of missing records return a
+// *store.NotFoundError and duplicate IDs a *store.DuplicateIDError, as the
+// SQLite store does.
+type Store struct {
+	mu     sync.RWMutex
+	byID   map[string]model.IntentRecord
+	byHash map[string]string
+	closed bool
+}
+
+var _ store.Backend = (*Store)(nil)
+
+// New returns an empty in-memory store.
+func New() *Store {
+	return &Store{
+		byID:   make(map[string]model.IntentRecord),
+		byHash: make(map[string]string),
+	}
+}
+
+// Migrate is a no-op; the in-memory store has no schema.
+func (s *Store) Migrate(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Close releases the stored records; later calls fail.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byID = nil
+	s.byHash = nil
+	s.closed = true
+	return nil
+}
+
+func (s *Store) CreateIntent(ctx context.Context, record model.IntentRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("store is closed")
+	}
+	if existing, ok := s.byID[record.ID]; ok {
+		return &store.DuplicateIDError{ID: record.ID, Hash: record.Hash, ExistingHash: existing.Hash}
+	}
+	if _, ok := s.byHash[record.Hash]; ok {
+		return fmt.Errorf("intent with hash %s already exists", record.Hash)
+	}
+
+	s.byID[record.ID] = clone(record)
+	s.byHash[record.Hash] = record.ID
+	return nil
+}
+
+func (s *Store) GetIntent(ctx context.Context, id string) (model.IntentRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return model.IntentRecord{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.byID[id]
+	if !ok {
+		return model.IntentRecord{}, &store.NotFoundError{Kind: "intent", Key: id}
+	}
+	return clone(record), nil
+}
+
+// GetIntentByHash loads an intent by its hash for chain traversal.
+func (s *Store) GetIntentByHash(ctx context.Context, hash string) (model.IntentRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return model.IntentRecord{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.byHash[hash]
+	if !ok {
+		return model.IntentRecord{}, &store.NotFoundError{Kind: "intent", Key: hash}
+	}
+	return clone(s.byID[id]), nil
+}
+
+// ListIntents returns the newest intents first, breaking created_at ties by
+// descending ID. Limits follow store.DefaultListLimit and store.DefaultMaxListLimit.
+func (s *Store) ListIntents(ctx context.Context, limit int) ([]model.IntentRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = store.DefaultListLimit
+	}
+	if limit > store.DefaultMaxListLimit {
+		limit = store.DefaultMaxListLimit
+	}
+
+	s.mu.RLock()
+	intents := make([]model.IntentRecord, 0, len(s.byID))
+	for _, record := range s.byID {
+		intents = append(intents, record)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(intents, func(i, j int) bool {
+		if intents[i].CreatedAt != intents[j].CreatedAt {
+			return intents[i].CreatedAt > intents[j].CreatedAt
+		}
+		return intents[i].ID > intents[j].ID
+	})
+	if len(intents) > limit {
+		intents = intents[:limit]
+	}
+	for i := range intents {
+		intents[i] = clone(intents[i])
+	}
+	if len(intents) == 0 {
+		return nil, nil
+	}
+	return intents, nil
+}
+
+// ListIntentsAfter returns intents after the (createdAt, id) position, oldest
+// first, matching the SQLite store.
+func (s *Store) ListIntentsAfter(ctx context.Context, createdAt, id string, limit int) ([]model.IntentRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = store.DefaultListLimit
+	}
+	if limit > store.DefaultMaxListLimit {
+		limit = store.DefaultMaxListLimit
+	}
+
+	s.mu.RLock()
+	var intents []model.IntentRecord
+	for _, record := range s.byID {
+		if record.CreatedAt > createdAt || (record.CreatedAt == createdAt && record.ID > id) {
+			intents = append(intents, record)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(intents, func(i, j int) bool {
+		if intents[i].CreatedAt != intents[j].CreatedAt {
+			return intents[i].CreatedAt < intents[j].CreatedAt
+		}
+		return intents[i].ID < intents[j].ID
+	})
+	if len(intents) > limit {
+		intents = intents[:limit]
+	}
+	for i := range intents {
+		intents[i] = clone(intents[i])
+	}
+	return intents, nil
+}
+
+// clone copies the record so callers cannot mutate stored meta, and applies
+// the same empty-meta rule as the SQLite store.
+func clone(record model.IntentRecord) model.IntentRecord {
+	record.Meta = bytes.Clone(record.NormalizedMeta())
+	record.Tags = slices.Clone(record.Tags)
+	record.Attachments = slices.Clone(record.Attachments)
+	return record
+}
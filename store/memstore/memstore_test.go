@@ -0,0 +1,117 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func testIntent(n int) model.IntentRecord {
+	return model.IntentRecord{
+		ID:         fmt.Sprintf("intent-%06d", n),
+		CreatedAt:  fmt.Sprintf("2026-02-09T10:00:%02dZ", n),
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     "prompt",
+		Response:   "response",
+		Hash:       fmt.Sprintf("hash-%d", n),
+	}
+}
+
+func TestMemstoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	backend, err := store.OpenBackend("memory", "")
+	if err != nil {
+		t.Fatalf("open backend: %v", err)
+	}
+	defer backend.Close()
+
+	record := testIntent(1)
+	if err := backend.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	if err := backend.CreateIntent(ctx, record); err == nil {
+		t.Fatalf("expected duplicate id to fail")
+	}
+
+	got, err := backend.GetIntentByHash(ctx, record.Hash)
+	if err != nil {
+		t.Fatalf("get intent by hash: %v", err)
+	}
+	if got.ID != record.ID {
+		t.Fatalf("expected %s, got %s", record.ID, got.ID)
+	}
+	if _, err := backend.GetIntent(ctx, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestMemstoreListOrdering(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	for _, n := range []int{2, 0, 3, 1} {
+		if err := s.CreateIntent(ctx, testIntent(n)); err != nil {
+			t.Fatalf("create intent %d: %v", n, err)
+		}
+	}
+
+	intents, err := s.ListIntents(ctx, 3)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	want := []string{"intent-000003", "intent-000002", "intent-000001"}
+	if len(intents) != len(want) {
+		t.Fatalf("expected %d intents, got %d", len(want), len(intents))
+	}
+	for i, id := range want {
+		if intents[i].ID != id {
+			t.Fatalf("position %d: expected %s, got %s", i, id, intents[i].ID)
+		}
+	}
+}
+
+func TestMemstoreEmptyMetaFollowsHashVersion(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	for _, tc := range []struct {
+		version  int
+		wantMeta string
+	}{
+		{hash.HashVersionJCS, "{}"},
+		{hash.HashVersionJCSEmptyMeta, ""},
+	} {
+		record := testIntent(tc.version)
+		id, err := model.NewIntentID()
+		if err != nil {
+			t.Fatalf("new id: %v", err)
+		}
+		record.ID, record.HashVersion = id, tc.version
+		record.Meta = json.RawMessage("{}")
+		sum, err := hash.HashIntent(record)
+		if err != nil {
+			t.Fatalf("v%d: hash intent: %v", tc.version, err)
+		}
+		record.Hash = sum
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("v%d: create intent: %v", tc.version, err)
+		}
+		got, err := s.GetIntent(ctx, record.ID)
+		if err != nil {
+			t.Fatalf("v%d: get intent: %v", tc.version, err)
+		}
+		if string(got.Meta) != tc.wantMeta {
+			t.Fatalf("v%d: expected meta %q, got %q", tc.version, tc.wantMeta, got.Meta)
+		}
+		if err := hash.VerifyHash(got); err != nil {
+			t.Fatalf("v%d: verify stored record: %v", tc.version, err)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// MergePolicy decides how Merge resolves an ID stored in both stores with
+// different hashes.
+type MergePolicy int
+
+const (
+	// MergeFailOnConflict aborts the merge with a *ConflictError.
+	MergeFailOnConflict MergePolicy = iota
+	// MergePreferEarliest keeps the version with the earlier created_at; the
+	// target's version wins ties.
+	MergePreferEarliest
+)
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	Policy MergePolicy
+	// HMACSecret verifies keyed (hmac-sha256) hashes in the source. Without
+	// it, source records with keyed hashes are rejected.
+	HMACSecret []byte
+}
+
+// MergeConflict is an ID that both stores held with different hashes.
+type MergeConflict struct {
+	ID            string `json:"id"`
+	KeptHash      string `json:"kept_hash"`
+	DiscardedHash string `json:"discarded_hash"`
+}
+
+// MergeRecord records one Merge call, including the chain heads of both stores
+// as they were before the merge.
+type MergeRecord struct {
+	ID          string
+	MergedAt    string
+	TargetHeads map[string]string
+	SourceHeads map[string]string
+	// Inserted counts source records added to the target, including
+	// conflict winners that replaced the target's version.
+	Inserted  int
+	Conflicts []MergeConflict
+}
+
+// Merge adds every intent of src to s, parents first, verifying each source
+// hash, and stores a MergeRecord. Records already in s are skipped. An ID
+// stored in both with different hashes is resolved by opts.Policy; when the
+// source version wins, the target's version is replaced, and target records
+// linking to the discarded hash keep their prev_hash. Chains that diverged
+// while the stores were apart become forks under their existing heads.
+// Everything happens in one transaction on s, so a failed merge leaves it
+// unchanged.
+func (s *Store) Merge(ctx context.Context, src *Store, opts MergeOptions) (_ MergeRecord, err error) {
+	ctx, span := s.startSpan(ctx, "Merge")
+	defer func() { err = span.end(err) }()
+	defer s.cache.invalidate()
+	if err := s.writable("Merge"); err != nil {
+		return MergeRecord{}, err
+	}
+	if s.db == nil || src == nil || src.db == nil {
+		return MergeRecord{}, errors.New("store not initialized")
+	}
+	if src == s {
+		return MergeRecord{}, errors.New("cannot merge a store into itself")
+	}
+	id, err := model.NewIntentID()
+	if err != nil {
+		return MergeRecord{}, fmt.Errorf("generate merge id: %w", err)
+	}
+	merge := MergeRecord{ID: id}
+
+	if merge.SourceHeads, err = src.ChainHeads(ctx); err != nil {
+		return MergeRecord{}, fmt.Errorf("load source heads: %w", err)
+	}
+	rows, err := src.rdb.QueryContext(ctx, `SELECT `+intentColumns+` FROM intents ORDER BY created_at ASC, id ASC`)
+	if err != nil {
+		return MergeRecord{}, err
+	}
+	records, err := src.collectIntents(ctx, rows)
+	if err != nil {
+		return MergeRecord{}, err
+	}
+	if records, err = parentsFirst(records); err != nil {
+		return MergeRecord{}, err
+	}
+
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		if merge.TargetHeads, err = chainHeads(ctx, tx); err != nil {
+			return fmt.Errorf("load target heads: %w", err)
+		}
+		for _, record := range records {
+			inserted, err := s.mergeIntentTx(ctx, tx, src, record, opts, &merge)
+			if err != nil {
+				return fmt.Errorf("merge intent %s: %w", record.ID, err)
+			}
+			if inserted {
+				merge.Inserted++
+			}
+		}
+
+		merge.MergedAt = time.Now().UTC().Format(time.RFC3339Nano)
+		targetHeads, err := json.Marshal(merge.TargetHeads)
+		if err != nil {
+			return err
+		}
+		sourceHeads, err := json.Marshal(merge.SourceHeads)
+		if err != nil {
+			return err
+		}
+		conflicts, err := json.Marshal(merge.Conflicts)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO merges (id, merged_at, target_heads, source_heads, inserted, conflicts) VALUES (?, ?, ?, ?, ?, ?)`,
+			merge.ID, merge.MergedAt, string(targetHeads), string(sourceHeads), merge.Inserted, string(conflicts))
+		return err
+	})
+	if err != nil {
+		return MergeRecord{}, err
+	}
+	return merge, nil
+}
+
+// mergeIntentTx adds one source record to the target within tx and reports
+// whether it was inserted.
+func (s *Store) mergeIntentTx(ctx context.Context, tx *sql.Tx, src *Store, record model.IntentRecord, opts MergeOptions, merge *MergeRecord) (bool, error) {
+	existing, err := s.scanIntent(tx.QueryRowContext(ctx, selectIntentByIDSQL, record.ID))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, err
+	case existing.Hash == record.Hash:
+		return false, nil
+	default:
+		conflict := &ConflictError{ID: record.ID, Hash: record.Hash, ExistingHash: existing.Hash}
+		if opts.Policy != MergePreferEarliest {
+			return false, conflict
+		}
+		sourceFirst, err := createdBefore(record, existing)
+		if err != nil {
+			return false, err
+		}
+		if !sourceFirst {
+			merge.Conflicts = append(merge.Conflicts, MergeConflict{ID: record.ID, KeptHash: existing.Hash, DiscardedHash: record.Hash})
+			return false, nil
+		}
+		merge.Conflicts = append(merge.Conflicts, MergeConflict{ID: record.ID, KeptHash: record.Hash, DiscardedHash: existing.Hash})
+		if err := deleteIntentTx(ctx, tx, existing); err != nil {
+			return false, err
+		}
+	}
+
+	if err := verifyCopied(record, opts.HMACSecret); err != nil {
+		return false, err
+	}
+	if !record.Tombstoned() {
+		for _, a := range record.Attachments {
+			if err := s.mergeBlobTx(ctx, tx, src, a.Digest); err != nil {
+				return false, fmt.Errorf("copy blob %s: %w", a.Digest, err)
+			}
+		}
+	}
+	insert := s.insertIntentTx
+	if record.Tombstoned() {
+		insert = s.insertTombstoneTx
+	}
+	if err := insert(ctx, tx, record); err != nil {
+		return false, err
+	}
+	if existing.Hash != "" {
+		if _, err := tx.ExecContext(ctx, `UPDATE chain_heads SET head_hash = ? WHERE head_hash = ?`, record.Hash, existing.Hash); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// createdBefore reports whether a was created strictly before b.
+func createdBefore(a, b model.IntentRecord) (bool, error) {
+	at, err := time.Parse(time.RFC3339Nano, a.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("parse created_at of %s: %w", a.ID, err)
+	}
+	bt, err := time.Parse(time.RFC3339Nano, b.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("parse created_at of %s: %w", b.ID, err)
+	}
+	return at.Before(bt), nil
+}
+
+// deleteIntentTx removes record and the rows derived from it within tx.
+// Tags are removed by their foreign key.
+func deleteIntentTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM intent_revisions WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM intent_promoted_meta WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM embeddings WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM intent_fingerprints WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM intents WHERE id = ?`, record.ID)
+	return err
+}
+
+// mergeBlobTx copies blob digest from src into the target within tx unless it
+// is already stored.
+func (s *Store) mergeBlobTx(ctx context.Context, tx *sql.Tx, src *Store, digest string) error {
+	var exists int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM blobs WHERE digest = ?`, digest).Scan(&exists)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	r, err := src.OpenBlob(ctx, digest)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, _, err = s.putBlobStreamTx(ctx, tx, r)
+	return err
+}
+
+// Merges returns the recorded merges, oldest first.
+func (s *Store) Merges(ctx context.Context) (_ []MergeRecord, err error) {
+	ctx, span := s.startSpan(ctx, "Merges")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT id, merged_at, target_heads, source_heads, inserted, conflicts
+		FROM merges ORDER BY merged_at ASC, id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var merges []MergeRecord
+	for rows.Next() {
+		var merge MergeRecord
+		var targetHeads, sourceHeads, conflicts string
+		if err := rows.Scan(&merge.ID, &merge.MergedAt, &targetHeads, &sourceHeads, &merge.Inserted, &conflicts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(targetHeads), &merge.TargetHeads); err != nil {
+			return nil, fmt.Errorf("decode merge %s: %w", merge.ID, err)
+		}
+		if err := json.Unmarshal([]byte(sourceHeads), &merge.SourceHeads); err != nil {
+			return nil, fmt.Errorf("decode merge %s: %w", merge.ID, err)
+		}
+		if err := json.Unmarshal([]byte(conflicts), &merge.Conflicts); err != nil {
+			return nil, fmt.Errorf("decode merge %s: %w", merge.ID, err)
+		}
+		merges = append(merges, merge)
+	}
+	return merges, rows.Err()
+}
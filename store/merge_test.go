@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestMergeOfflineCapture(t *testing.T) {
+	ctx := context.Background()
+	central, laptop := openTestStore(t), openTestStore(t)
+	first := linkedIntent(t, 1, "alice", "")
+	for _, s := range []*Store{central, laptop} {
+		if err := s.CreateIntent(ctx, first); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	offline := linkedIntent(t, 2, "alice", first.Hash)
+	if err := laptop.CreateIntent(ctx, offline); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	bob := linkedIntent(t, 3, "bob", "")
+	if err := central.CreateIntent(ctx, bob); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	merge, err := central.Merge(ctx, laptop, MergeOptions{})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if merge.Inserted != 1 || len(merge.Conflicts) != 0 {
+		t.Fatalf("unexpected merge %+v", merge)
+	}
+	if merge.TargetHeads["alice"] != first.Hash || merge.TargetHeads["bob"] != bob.Hash || merge.SourceHeads["alice"] != offline.Hash {
+		t.Fatalf("expected both original heads, got target %v source %v", merge.TargetHeads, merge.SourceHeads)
+	}
+	if head, err := central.ChainHead(ctx, "alice"); err != nil || head != offline.Hash {
+		t.Fatalf("expected alice's head to advance, got %q, %v", head, err)
+	}
+
+	merges, err := central.Merges(ctx)
+	if err != nil {
+		t.Fatalf("merges: %v", err)
+	}
+	if len(merges) != 1 || merges[0].ID != merge.ID || merges[0].SourceHeads["alice"] != offline.Hash {
+		t.Fatalf("expected the stored merge record, got %+v", merges)
+	}
+
+	again, err := central.Merge(ctx, laptop, MergeOptions{})
+	if err != nil || again.Inserted != 0 {
+		t.Fatalf("expected a repeated merge to insert nothing, got %+v, %v", again, err)
+	}
+}
+
+// divergent returns testIntent(n) for author with its created_at shifted by
+// offset and its prompt replaced, rehashed.
+func divergent(t *testing.T, n int, author string, offset time.Duration, prompt string) model.IntentRecord {
+	t.Helper()
+	record := testIntent(t, n)
+	record.Author = author
+	created, err := time.Parse(time.RFC3339Nano, record.CreatedAt)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	record.CreatedAt = created.Add(offset).Format(time.RFC3339Nano)
+	record.Prompt = prompt
+	if record.Hash, err = hash.HashIntent(record); err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	return record
+}
+
+func TestMergeConflicts(t *testing.T) {
+	ctx := context.Background()
+	target, src := openTestStore(t), openTestStore(t)
+	olderTarget := divergent(t, 1, "bob", 0, "target")
+	newerSource := divergent(t, 1, "bob", time.Second, "source")
+	newerTarget := divergent(t, 5, "alice", time.Second, "target")
+	olderSource := divergent(t, 5, "alice", 0, "source")
+	for _, record := range []model.IntentRecord{olderTarget, newerTarget} {
+		if err := target.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	for _, record := range []model.IntentRecord{newerSource, olderSource} {
+		if err := src.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	_, err := target.Merge(ctx, src, MergeOptions{})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	if merges, err := target.Merges(ctx); err != nil || len(merges) != 0 {
+		t.Fatalf("expected a failed merge to record nothing, got %+v, %v", merges, err)
+	}
+
+	merge, err := target.Merge(ctx, src, MergeOptions{Policy: MergePreferEarliest})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	want := []MergeConflict{
+		{ID: olderTarget.ID, KeptHash: olderTarget.Hash, DiscardedHash: newerSource.Hash},
+		{ID: olderSource.ID, KeptHash: olderSource.Hash, DiscardedHash: newerTarget.Hash},
+	}
+	if merge.Inserted != 1 || len(merge.Conflicts) != 2 || merge.Conflicts[0] != want[0] || merge.Conflicts[1] != want[1] {
+		t.Fatalf("expected conflicts %+v, got %+v", want, merge)
+	}
+	got, err := target.GetIntent(ctx, olderSource.ID)
+	if err != nil || got.Hash != olderSource.Hash {
+		t.Fatalf("expected the earlier source version to replace the target's, got %q, %v", got.Hash, err)
+	}
+	if head, err := target.ChainHead(ctx, "alice"); err != nil || head != olderSource.Hash {
+		t.Fatalf("expected the head to follow the replacement, got %q, %v", head, err)
+	}
+}
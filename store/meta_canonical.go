@@ -0,0 +1,136 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+)
+
+// MetaRewrite describes a stored meta value that is (or was) rewritten to canonical form.
+type MetaRewrite struct {
+	ID      string
+	OldHash string
+	NewHash string
+}
+
+// HashChanged reports whether rewriting the meta changed the record hash. Records
+// whose prev_hash points at OldHash are no longer linked once this is true.
+func (r MetaRewrite) HashChanged() bool {
+	return r.OldHash != r.NewHash
+}
+
+// FindNonCanonicalMeta returns the IDs of intents whose stored meta differs
+// byte-for-byte from hash.CanonicalizeMeta of the same value, ordered by ID.
+func (s *Store) FindNonCanonicalMeta(ctx context.Context) (_ []string, err error) {
+	ctx, span := s.startSpan(ctx, "FindNonCanonicalMeta")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	rows, err := s.rdb.QueryContext(ctx, `SELECT id, meta, body_encoding FROM intents WHERE meta IS NOT NULL AND meta != '' ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		var raw []byte
+		var encoding sql.NullString
+		if err := rows.Scan(&id, &raw, &encoding); err != nil {
+			return nil, err
+		}
+		meta, err := s.codec.decodeMeta(encoding.String, id, raw)
+		if err != nil {
+			return nil, fmt.Errorf("decode meta for %s: %w", id, err)
+		}
+		canonical, err := hash.CanonicalizeMeta(meta)
+		if err != nil {
+			return nil, fmt.Errorf("canonicalize meta for %s: %w", id, err)
+		}
+		if !bytes.Equal(canonical, meta) {
+			ids = append(ids, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CanonicalizeStoredMeta rewrites non-canonical meta in place, recomputing the
+// hash of each rewritten record. Because the hash preimage already uses canonical
+// meta the hash normally stays the same; when it does not (the stored hash was
+// computed over non-canonical meta), any record linking to the old hash is broken,
+// which callers can detect with MetaRewrite.HashChanged. With dryRun the planned
+// rewrites are returned without modifying the database.
+func (s *Store) CanonicalizeStoredMeta(ctx context.Context, dryRun bool) (_ []MetaRewrite, err error) {
+	ctx, span := s.startSpan(ctx, "CanonicalizeStoredMeta")
+	defer func() { err = span.end(err) }()
+	defer s.cache.invalidate()
+	if !dryRun {
+		if err := s.writable("CanonicalizeStoredMeta"); err != nil {
+			return nil, err
+		}
+	}
+	ids, err := s.FindNonCanonicalMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rewrites := make([]MetaRewrite, 0, len(ids))
+	for _, id := range ids {
+		record, err := s.scanIntent(tx.QueryRowContext(ctx, selectIntentByIDSQL, id))
+		if err != nil {
+			return nil, fmt.Errorf("load intent %s: %w", id, err)
+		}
+		canonical, err := hash.CanonicalizeMeta(record.Meta)
+		if err != nil {
+			return nil, fmt.Errorf("canonicalize meta for %s: %w", id, err)
+		}
+		record.Meta = canonical
+		newHash, err := hash.Recompute(record)
+		if err != nil {
+			return nil, fmt.Errorf("hash intent %s: %w", id, err)
+		}
+		rewrites = append(rewrites, MetaRewrite{ID: id, OldHash: record.Hash, NewHash: newHash})
+
+		if dryRun {
+			continue
+		}
+		var encoding sql.NullString
+		if err := tx.QueryRowContext(ctx, `SELECT body_encoding FROM intents WHERE id = ?`, id).Scan(&encoding); err != nil {
+			return nil, fmt.Errorf("load encoding for %s: %w", id, err)
+		}
+		stored, err := s.codec.encodeMeta(encoding.String, id, canonical)
+		if err != nil {
+			return nil, fmt.Errorf("encode meta for %s: %w", id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE intents SET meta = ?, hash = ? WHERE id = ?`, stored, newHash, id); err != nil {
+			return nil, fmt.Errorf("rewrite meta for %s: %w", id, err)
+		}
+	}
+
+	if dryRun {
+		return rewrites, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return rewrites, nil
+}
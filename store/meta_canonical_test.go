@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+)
+
+func TestCanonicalizeStoredMeta(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	legacy := testIntent(t, 1)
+	legacy.Meta = json.RawMessage(`{"b":2, "a":1}`)
+	sum, err := hash.HashIntent(legacy)
+	if err != nil {
+		t.Fatalf("hash legacy intent: %v", err)
+	}
+	legacy.Hash = sum
+	if err := s.CreateIntent(ctx, legacy); err != nil {
+		t.Fatalf("create legacy intent: %v", err)
+	}
+
+	canonical := testIntent(t, 2)
+	canonical.Meta = json.RawMessage(`{"a":1}`)
+	if err := s.CreateIntent(ctx, canonical); err != nil {
+		t.Fatalf("create canonical intent: %v", err)
+	}
+
+	ids, err := s.FindNonCanonicalMeta(ctx)
+	if err != nil {
+		t.Fatalf("find non-canonical meta: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != legacy.ID {
+		t.Fatalf("expected [%s], got %v", legacy.ID, ids)
+	}
+
+	planned, err := s.CanonicalizeStoredMeta(ctx, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(planned) != 1 || planned[0].HashChanged() {
+		t.Fatalf("expected one rewrite without hash change, got %+v", planned)
+	}
+	if ids, _ := s.FindNonCanonicalMeta(ctx); len(ids) != 1 {
+		t.Fatalf("dry run modified the store: %v", ids)
+	}
+
+	if _, err := s.CanonicalizeStoredMeta(ctx, false); err != nil {
+		t.Fatalf("canonicalize stored meta: %v", err)
+	}
+	if ids, err := s.FindNonCanonicalMeta(ctx); err != nil || len(ids) != 0 {
+		t.Fatalf("expected no non-canonical meta, got %v (%v)", ids, err)
+	}
+
+	fixed, err := s.GetIntent(ctx, legacy.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if string(fixed.Meta) != `{"a":1,"b":2}` {
+		t.Fatalf("expected canonical meta, got %s", fixed.Meta)
+	}
+	if fixed.Hash != legacy.Hash {
+		t.Fatalf("expected hash unchanged, got %s want %s", fixed.Hash, legacy.Hash)
+	}
+}
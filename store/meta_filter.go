@@ -1,8 +1,12 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/chuxorg/chux-yanzi-core/model"
 )
@@ -56,3 +60,75 @@ func matchesMetaFilters(raw json.RawMessage, filters map[string]string) (bool, e
 
 	return true, nil
 }
+
+// ListIntentsByMeta returns intents whose meta matches all filters (AND semantics),
+// newest first, evaluating the filters in SQLite with JSON1 instead of loading rows.
+// As with FilterIntentsByMeta, only string meta values can match. Limits follow
+// the same default and clamping as ListIntents.
+func (s *Store) ListIntentsByMeta(ctx context.Context, filters map[string]string, limit int) (_ []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "ListIntentsByMeta")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	limit = s.clampLimit(limit)
+
+	where, args, err := metaFilterClause(filters)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + intentColumns + ` FROM intents`
+	if where != "" {
+		query += ` WHERE ` + where
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.rdb.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	intents, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	return s.allowed(ctx, ActionList, intents), nil
+}
+
+// metaFilterClause compiles filters into a parameterized JSON1 condition with keys
+// in sorted order so equal filters always produce the same SQL.
+func metaFilterClause(filters map[string]string) (string, []any, error) {
+	if len(filters) == 0 {
+		return "", nil, nil
+	}
+
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	conditions := make([]string, 0, len(keys))
+	args := make([]any, 0, len(keys)*3)
+	for _, key := range keys {
+		path, err := metaPath(key)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, metaEqSQL)
+		args = append(args, path, path, filters[key])
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// metaEqSQL matches a string meta value. Encrypted meta is stored as a BLOB,
+// which JSON1 rejects, so the CASE keeps those rows from being evaluated.
+const metaEqSQL = `(CASE WHEN typeof(meta) = 'text' THEN json_type(meta, ?) = 'text' AND json_extract(meta, ?) = ? END)`
+
+// metaPath returns the JSON1 path selecting a top-level meta key.
+func metaPath(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `"\`) {
+		return "", fmt.Errorf("invalid meta filter key %q", key)
+	}
+	return `$."` + key + `"`, nil
+}
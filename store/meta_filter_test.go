@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestListIntentsByMetaMatchesFilterIntentsByMeta(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	metas := []string{
+		`{"env":"prod","team":"core"}`,
+		`{"env":"prod","team":"web"}`,
+		`{"env":"dev","team":"core"}`,
+		`{"env":1,"team":"core"}`,
+		`{"env.region":"prod"}`,
+		``,
+	}
+	for i, meta := range metas {
+		record := testIntent(t, i)
+		record.Meta = json.RawMessage(meta)
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	all, err := s.ListIntents(ctx, 0)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+
+	cases := []map[string]string{
+		{"env": "prod"},
+		{"env": "prod", "team": "core"},
+		{"env": "1"},
+		{"env.region": "prod"},
+		{"missing": "x"},
+		{},
+	}
+	for _, filters := range cases {
+		got, err := s.ListIntentsByMeta(ctx, filters, 0)
+		if err != nil {
+			t.Fatalf("list by meta %v: %v", filters, err)
+		}
+		want, err := FilterIntentsByMeta(all, filters)
+		if err != nil {
+			t.Fatalf("filter intents %v: %v", filters, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("filters %v: expected %d intents, got %d", filters, len(want), len(got))
+		}
+		for i := range got {
+			if got[i].ID != want[i].ID {
+				t.Fatalf("filters %v position %d: expected %s, got %s", filters, i, want[i].ID, got[i].ID)
+			}
+		}
+	}
+}
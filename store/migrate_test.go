@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openUnmigratedStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestMigrateAllowEmpty(t *testing.T) {
+	ctx := context.Background()
+	s := openUnmigratedStore(t)
+	dir := t.TempDir()
+
+	if err := s.MigrateWithOptions(ctx, MigrateOptions{FS: os.DirFS(dir)}); err == nil || !strings.Contains(err.Error(), "no migration files found") {
+		t.Fatalf("expected empty directory error, got %v", err)
+	}
+	if err := s.MigrateWithOptions(ctx, MigrateOptions{FS: os.DirFS(dir), AllowEmpty: true}); err != nil {
+		t.Fatalf("expected allowed empty directory to succeed: %v", err)
+	}
+}
+
+func TestMigrateMissingDir(t *testing.T) {
+	ctx := context.Background()
+	s := openUnmigratedStore(t)
+	dir := filepath.Join(t.TempDir(), "missing")
+
+	if err := s.MigrateWithOptions(ctx, MigrateOptions{FS: os.DirFS(dir)}); err == nil || !strings.Contains(err.Error(), "list migrations") {
+		t.Fatalf("expected missing directory error, got %v", err)
+	}
+	if err := s.MigrateWithOptions(ctx, MigrateOptions{FS: os.DirFS(dir), AllowEmpty: true}); err != nil {
+		t.Fatalf("expected allowed missing directory to succeed: %v", err)
+	}
+}
+
+func TestMigrateRejectsMalformedFilename(t *testing.T) {
+	ctx := context.Background()
+	s := openUnmigratedStore(t)
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "init.sql"), []byte(`CREATE TABLE t (id TEXT);`), 0o644); err != nil {
+		t.Fatalf("write migration: %v", err)
+	}
+
+	err := s.MigrateWithOptions(ctx, MigrateOptions{FS: os.DirFS(dir)})
+	if err == nil || !strings.Contains(err.Error(), `invalid migration filename "init.sql"`) {
+		t.Fatalf("expected malformed filename error, got %v", err)
+	}
+}
+
+func TestMigrateUsesEmbeddedSchemaOutsideWorkingDir(t *testing.T) {
+	ctx := context.Background()
+	s := openUnmigratedStore(t)
+	t.Chdir(t.TempDir())
+
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate from embedded schema: %v", err)
+	}
+	if err := s.CreateIntent(ctx, testIntent(t, 1)); err != nil {
+		t.Fatalf("create intent after migrate: %v", err)
+	}
+}
+
+func TestWithMigrationsFSOverridesEmbeddedSchema(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "0001_custom.sql"), []byte(`CREATE TABLE custom (id TEXT);`), 0o644); err != nil {
+		t.Fatalf("write migration: %v", err)
+	}
+
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithMigrationsFS(os.DirFS(dir)))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO custom (id) VALUES ('x')`); err != nil {
+		t.Fatalf("expected override migration applied: %v", err)
+	}
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM sqlite_master WHERE name = 'intents'`).Scan(&count); err != nil {
+		t.Fatalf("query schema: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected embedded schema not applied when overridden")
+	}
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+// PendingMigration is a migration file that has not been applied yet.
+type PendingMigration struct {
+	Version  string
+	Checksum string // hex SHA-256 of the file contents
+
+	contents []byte
+}
+
+// MigrationPlan returns the migrations Migrate would apply, in order, with checksums.
+func (s *Store) MigrationPlan(ctx context.Context) (_ []PendingMigration, err error) {
+	ctx, span := s.startSpan(ctx, "MigrationPlan")
+	defer func() { err = span.end(err) }()
+	return s.MigrationPlanWithOptions(ctx, MigrateOptions{})
+}
+
+// MigrationPlanWithOptions is MigrationPlan for an explicit migration source.
+func (s *Store) MigrationPlanWithOptions(ctx context.Context, opts MigrateOptions) (_ []PendingMigration, err error) {
+	ctx, span := s.startSpan(ctx, "MigrationPlanWithOptions")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	return s.pendingMigrations(ctx, opts)
+}
+
+// pendingMigrations lists unapplied migrations from the source described by opts.
+// It does not create schema_migrations, so planning never modifies the database.
+func (s *Store) pendingMigrations(ctx context.Context, opts MigrateOptions) ([]PendingMigration, error) {
+	fsys, dir := s.resolveMigrations(opts)
+
+	paths, err := listMigrationFiles(fsys, dir)
+	if err != nil {
+		if opts.AllowEmpty && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(paths) == 0 {
+		if opts.AllowEmpty {
+			return nil, nil
+		}
+		return nil, errors.New("no migration files found")
+	}
+
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	pending := make([]PendingMigration, 0, len(paths))
+	for _, file := range paths {
+		version := path.Base(file)
+		if _, ok := applied[version]; ok {
+			continue
+		}
+		contents, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", version, err)
+		}
+		sum := sha256.Sum256(contents)
+		pending = append(pending, PendingMigration{
+			Version:  version,
+			Checksum: hex.EncodeToString(sum[:]),
+			contents: contents,
+		})
+	}
+	return pending, nil
+}
+
+// appliedMigrations returns the recorded migration versions, or an empty set
+// when schema_migrations has not been created yet.
+func (s *Store) appliedMigrations(ctx context.Context) (map[string]struct{}, error) {
+	var exists int
+	if err := s.rdb.QueryRowContext(ctx, `SELECT COUNT(1) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check schema_migrations: %w", err)
+	}
+	applied := make(map[string]struct{})
+	if exists == 0 {
+		return applied, nil
+	}
+
+	rows, err := s.rdb.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = struct{}{}
+	}
+	return applied, rows.Err()
+}
+
+// dryRunMigrations executes pending migrations in one transaction and rolls it
+// back, so later migrations are validated against the effects of earlier ones.
+func (s *Store) dryRunMigrations(ctx context.Context, pending []PendingMigration) error {
+	if len(pending) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin dry run: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, migration := range pending {
+		if _, err := tx.ExecContext(ctx, string(migration.contents)); err != nil {
+			return fmt.Errorf("dry run migration %s: %w", migration.Version, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrationPlanListsPendingWithChecksums(t *testing.T) {
+	ctx := context.Background()
+	s := openUnmigratedStore(t)
+
+	plan, err := s.MigrationPlan(ctx)
+	if err != nil {
+		t.Fatalf("migration plan: %v", err)
+	}
+	if len(plan) == 0 || plan[0].Version != "0001_create_intents.sql" {
+		t.Fatalf("expected plan to start with 0001_create_intents.sql, got %+v", plan)
+	}
+	for _, migration := range plan {
+		if len(migration.Checksum) != 64 {
+			t.Fatalf("expected sha256 checksum for %s, got %q", migration.Version, migration.Checksum)
+		}
+	}
+	if tableExists(t, s, "schema_migrations") {
+		t.Fatalf("expected planning not to create schema_migrations")
+	}
+
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	plan, err = s.MigrationPlan(ctx)
+	if err != nil {
+		t.Fatalf("migration plan after migrate: %v", err)
+	}
+	if len(plan) != 0 {
+		t.Fatalf("expected no pending migrations, got %+v", plan)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	ctx := context.Background()
+	s := openUnmigratedStore(t)
+
+	if err := s.MigrateWithOptions(ctx, MigrateOptions{DryRun: true}); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if tableExists(t, s, "intents") || tableExists(t, s, "schema_migrations") {
+		t.Fatalf("expected dry run to leave schema untouched")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "0001_broken.sql"), []byte(`CREATE TABLE broken (`), 0o644); err != nil {
+		t.Fatalf("write migration: %v", err)
+	}
+	err := s.MigrateWithOptions(ctx, MigrateOptions{FS: os.DirFS(dir), DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "dry run migration 0001_broken.sql") {
+		t.Fatalf("expected dry run to report invalid SQL, got %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_intents_prev_hash;
+DROP INDEX IF EXISTS idx_intents_created_at;
+DROP TABLE IF EXISTS intents;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_intents_changelog_delete;
+DROP TRIGGER IF EXISTS trg_intents_changelog_update;
+DROP TRIGGER IF EXISTS trg_intents_changelog_insert;
+DROP TABLE IF EXISTS intent_changelog;
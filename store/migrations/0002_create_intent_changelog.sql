@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS intent_changelog (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	intent_id TEXT NOT NULL,
+	op TEXT NOT NULL CHECK (op IN ('insert', 'update', 'delete')),
+	changed_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_intents_changelog_insert
+AFTER INSERT ON intents
+BEGIN
+	INSERT INTO intent_changelog (intent_id, op) VALUES (NEW.id, 'insert');
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_intents_changelog_update
+AFTER UPDATE ON intents
+BEGIN
+	INSERT INTO intent_changelog (intent_id, op) VALUES (NEW.id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_intents_changelog_delete
+AFTER DELETE ON intents
+BEGIN
+	INSERT INTO intent_changelog (intent_id, op) VALUES (OLD.id, 'delete');
+END;
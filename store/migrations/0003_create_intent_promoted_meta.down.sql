@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_intent_promoted_meta_lookup;
+DROP TABLE IF EXISTS intent_promoted_meta;
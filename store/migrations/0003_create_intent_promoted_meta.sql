@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS intent_promoted_meta (
+	intent_id TEXT NOT NULL REFERENCES intents (id) ON DELETE CASCADE,
+	key TEXT NOT NULL,
+	value TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (intent_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_intent_promoted_meta_lookup ON intent_promoted_meta (key, value, created_at, intent_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_intents_created_at;
+CREATE INDEX IF NOT EXISTS idx_intents_created_at_id ON intents (created_at, id);
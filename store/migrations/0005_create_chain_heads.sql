@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS chain_heads (
+	chain TEXT PRIMARY KEY,
+	head_hash TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+-- Backfill each author's head: the newest intent that no other intent links to.
+INSERT OR IGNORE INTO chain_heads (chain, head_hash, updated_at)
+SELECT author, hash, created_at FROM (
+	SELECT i.author, i.hash, i.created_at,
+		ROW_NUMBER() OVER (PARTITION BY i.author ORDER BY i.created_at DESC, i.id DESC) AS rn
+	FROM intents i
+	WHERE NOT EXISTS (SELECT 1 FROM intents n WHERE n.prev_hash = i.hash)
+)
+WHERE rn = 1;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS checkpoints (
+	seq INTEGER PRIMARY KEY AUTOINCREMENT,
+	intent_count INTEGER NOT NULL,
+	head_hash TEXT NOT NULL,
+	merkle_root TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	signature BLOB
+);
@@ -0,0 +1,2 @@
+ALTER TABLE intents DROP COLUMN public_key;
+ALTER TABLE intents DROP COLUMN signature;
@@ -0,0 +1,2 @@
+ALTER TABLE intents ADD COLUMN signature TEXT;
+ALTER TABLE intents ADD COLUMN public_key TEXT;
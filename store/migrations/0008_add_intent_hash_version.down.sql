@@ -0,0 +1 @@
+ALTER TABLE intents DROP COLUMN hash_version;
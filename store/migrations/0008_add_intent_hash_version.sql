@@ -0,0 +1 @@
+ALTER TABLE intents ADD COLUMN hash_version INTEGER;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_hash_migrations_new_hash;
+DROP TABLE IF EXISTS hash_migrations;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS hash_migrations (
+	old_hash TEXT PRIMARY KEY,
+	new_hash TEXT NOT NULL,
+	intent_id TEXT NOT NULL,
+	from_version INTEGER NOT NULL,
+	to_version INTEGER NOT NULL,
+	migrated_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_hash_migrations_new_hash ON hash_migrations (new_hash);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_intent_tags_lookup;
+DROP TABLE IF EXISTS intent_tags;
+ALTER TABLE intents DROP COLUMN tags;
@@ -0,0 +1,10 @@
+ALTER TABLE intents ADD COLUMN tags TEXT;
+
+CREATE TABLE IF NOT EXISTS intent_tags (
+	intent_id TEXT NOT NULL REFERENCES intents (id) ON DELETE CASCADE,
+	tag TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (intent_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_intent_tags_lookup ON intent_tags (tag, created_at, intent_id);
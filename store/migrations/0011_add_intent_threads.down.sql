@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_intents_thread;
+ALTER TABLE intents DROP COLUMN parent_id;
+ALTER TABLE intents DROP COLUMN thread_id;
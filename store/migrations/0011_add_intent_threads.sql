@@ -0,0 +1,4 @@
+ALTER TABLE intents ADD COLUMN thread_id TEXT;
+ALTER TABLE intents ADD COLUMN parent_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_intents_thread ON intents (thread_id, created_at, id);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS intent_revisions (
+	intent_id TEXT NOT NULL REFERENCES intents (id) ON DELETE CASCADE,
+	revision INTEGER NOT NULL,
+	meta TEXT,
+	created_at TEXT NOT NULL,
+	prev_hash TEXT NOT NULL,
+	hash TEXT NOT NULL,
+	PRIMARY KEY (intent_id, revision)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE intents DROP COLUMN attachments;
+DROP TABLE IF EXISTS blobs;
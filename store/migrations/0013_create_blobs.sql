@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS blobs (
+	digest TEXT PRIMARY KEY,
+	size INTEGER NOT NULL,
+	data BLOB NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+ALTER TABLE intents ADD COLUMN attachments TEXT;
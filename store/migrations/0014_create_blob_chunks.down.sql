@@ -0,0 +1,3 @@
+DELETE FROM blobs WHERE chunks > 0;
+ALTER TABLE blobs DROP COLUMN chunks;
+DROP TABLE IF EXISTS blob_chunks;
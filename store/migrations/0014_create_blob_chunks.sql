@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS blob_chunks (
+	digest TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	data BLOB NOT NULL,
+	PRIMARY KEY (digest, seq)
+);
+
+ALTER TABLE blobs ADD COLUMN chunks INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE intents DROP COLUMN body_encoding;
@@ -0,0 +1 @@
+ALTER TABLE intents ADD COLUMN body_encoding TEXT;
@@ -0,0 +1 @@
+ALTER TABLE intents DROP COLUMN tombstoned_at;
@@ -0,0 +1 @@
+ALTER TABLE intents ADD COLUMN tombstoned_at TEXT;
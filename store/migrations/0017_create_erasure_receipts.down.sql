@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_erasure_receipts_author;
+DROP TABLE IF EXISTS erasure_receipts;
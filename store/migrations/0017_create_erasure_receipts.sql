@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS erasure_receipts (
+	id TEXT PRIMARY KEY,
+	author TEXT NOT NULL,
+	erased_at TEXT NOT NULL,
+	intent_ids TEXT NOT NULL,
+	export_digest TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_erasure_receipts_author ON erasure_receipts (author, erased_at);
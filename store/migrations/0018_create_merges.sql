@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS merges (
+	id TEXT PRIMARY KEY,
+	merged_at TEXT NOT NULL,
+	target_heads TEXT NOT NULL,
+	source_heads TEXT NOT NULL,
+	inserted INTEGER NOT NULL,
+	conflicts TEXT NOT NULL
+);
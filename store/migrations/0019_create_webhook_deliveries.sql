@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	intent_id TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	last_status_code INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	delivered_at TEXT,
+	UNIQUE (url, intent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_intent ON webhook_deliveries (intent_id);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS outbox_checkpoints (
+	consumer TEXT PRIMARY KEY,
+	seq INTEGER NOT NULL,
+	updated_at TEXT NOT NULL
+);
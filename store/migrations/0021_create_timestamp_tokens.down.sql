@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_timestamp_tokens_chain;
+DROP INDEX IF EXISTS idx_timestamp_tokens_hash;
+DROP TABLE IF EXISTS timestamp_tokens;
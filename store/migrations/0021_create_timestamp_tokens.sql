@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS timestamp_tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	hash TEXT NOT NULL,
+	chain TEXT NOT NULL DEFAULT '',
+	authority TEXT NOT NULL,
+	token BLOB NOT NULL,
+	gen_time TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_timestamp_tokens_hash ON timestamp_tokens (hash, id);
+CREATE INDEX IF NOT EXISTS idx_timestamp_tokens_chain ON timestamp_tokens (chain, id) WHERE chain <> '';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_anchors_merkle_root;
+DROP INDEX IF EXISTS idx_anchors_pending;
+DROP TABLE IF EXISTS anchors;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS anchors (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	merkle_root TEXT NOT NULL,
+	checkpoint_seq INTEGER NOT NULL DEFAULT 0,
+	backend TEXT NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('pending', 'confirmed')),
+	proof BLOB NOT NULL,
+	block_height INTEGER NOT NULL DEFAULT 0,
+	attested_at TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_anchors_pending ON anchors (backend, id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_anchors_merkle_root ON anchors (merkle_root, id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transparency_entries_subject;
+DROP TABLE IF EXISTS transparency_entries;
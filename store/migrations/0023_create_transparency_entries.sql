@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS transparency_entries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	subject TEXT NOT NULL,
+	checkpoint_seq INTEGER NOT NULL DEFAULT 0,
+	log_url TEXT NOT NULL,
+	uuid TEXT NOT NULL,
+	log_index INTEGER NOT NULL,
+	integrated_at TEXT NOT NULL,
+	entry BLOB NOT NULL,
+	created_at TEXT NOT NULL,
+	UNIQUE (log_url, uuid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transparency_entries_subject ON transparency_entries (subject, id);
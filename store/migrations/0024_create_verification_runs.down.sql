@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_verification_runs_valid;
+DROP TABLE IF EXISTS verification_runs;
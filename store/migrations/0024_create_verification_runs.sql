@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS verification_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	head TEXT NOT NULL,
+	length INTEGER NOT NULL,
+	issues INTEGER NOT NULL,
+	verified_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_verification_runs_valid ON verification_runs (verified_at) WHERE issues = 0;
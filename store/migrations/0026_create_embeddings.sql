@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS embeddings (
+	intent_id TEXT NOT NULL REFERENCES intents (id) ON DELETE CASCADE,
+	model TEXT NOT NULL,
+	dims INTEGER NOT NULL,
+	vector BLOB NOT NULL,
+	norm REAL NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (intent_id, model)
+);
+
+CREATE INDEX IF NOT EXISTS idx_embeddings_model ON embeddings (model, dims);
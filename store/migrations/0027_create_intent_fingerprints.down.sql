@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_intent_fingerprints_band3;
+DROP INDEX IF EXISTS idx_intent_fingerprints_band2;
+DROP INDEX IF EXISTS idx_intent_fingerprints_band1;
+DROP INDEX IF EXISTS idx_intent_fingerprints_band0;
+DROP TABLE IF EXISTS intent_fingerprints;
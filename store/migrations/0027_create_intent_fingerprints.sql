@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS intent_fingerprints (
+	intent_id TEXT PRIMARY KEY REFERENCES intents (id) ON DELETE CASCADE,
+	simhash INTEGER NOT NULL,
+	band0 INTEGER NOT NULL,
+	band1 INTEGER NOT NULL,
+	band2 INTEGER NOT NULL,
+	band3 INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_intent_fingerprints_band0 ON intent_fingerprints (band0);
+CREATE INDEX IF NOT EXISTS idx_intent_fingerprints_band1 ON intent_fingerprints (band1);
+CREATE INDEX IF NOT EXISTS idx_intent_fingerprints_band2 ON intent_fingerprints (band2);
+CREATE INDEX IF NOT EXISTS idx_intent_fingerprints_band3 ON intent_fingerprints (band3);
@@ -0,0 +1,3 @@
+-- The cleared attachment lists referenced blobs that were already removed,
+-- so there is nothing to restore.
+SELECT 1;
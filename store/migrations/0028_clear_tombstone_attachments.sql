@@ -0,0 +1,3 @@
+-- Tombstones no longer keep their attachment list; its blobs were already
+-- removed, and verification now reports a tombstone that carries content.
+UPDATE intents SET attachments = NULL WHERE tombstoned_at IS NOT NULL AND attachments IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE blobs ADD COLUMN encoding TEXT;
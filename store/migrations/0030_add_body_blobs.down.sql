@@ -0,0 +1,2 @@
+ALTER TABLE intents DROP COLUMN response_blob;
+ALTER TABLE intents DROP COLUMN prompt_blob;
@@ -0,0 +1,14 @@
+ALTER TABLE intents ADD COLUMN prompt_blob TEXT;
+ALTER TABLE intents ADD COLUMN response_blob TEXT;
+
+-- Plain rows written by CreateIntentStream reference their bodies by
+-- placeholder and a same-named attachment. Encoded rows cannot be read here
+-- and keep returning the placeholder.
+UPDATE intents SET prompt_blob = substr(prompt, 12)
+	WHERE body_encoding IS NULL AND prompt LIKE 'yanzi-blob:%' AND EXISTS (
+		SELECT 1 FROM json_each(intents.attachments) a
+		WHERE json_extract(a.value, '$.name') = 'prompt' AND json_extract(a.value, '$.digest') = substr(intents.prompt, 12));
+UPDATE intents SET response_blob = substr(response, 12)
+	WHERE body_encoding IS NULL AND response LIKE 'yanzi-blob:%' AND EXISTS (
+		SELECT 1 FROM json_each(intents.attachments) a
+		WHERE json_extract(a.value, '$.name') = 'response' AND json_extract(a.value, '$.digest') = substr(intents.response, 12));
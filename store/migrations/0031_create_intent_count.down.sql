@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_intents_count_delete;
+DROP TRIGGER IF EXISTS trg_intents_count_insert;
+DROP TABLE IF EXISTS intent_count;
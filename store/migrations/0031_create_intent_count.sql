@@ -0,0 +1,20 @@
+-- A running count of intents, so checkpointing need not count every row on
+-- each write.
+CREATE TABLE IF NOT EXISTS intent_count (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	n INTEGER NOT NULL
+);
+
+INSERT OR REPLACE INTO intent_count (id, n) SELECT 1, COUNT(*) FROM intents;
+
+CREATE TRIGGER IF NOT EXISTS trg_intents_count_insert
+AFTER INSERT ON intents
+BEGIN
+	UPDATE intent_count SET n = n + 1 WHERE id = 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trg_intents_count_delete
+AFTER DELETE ON intents
+BEGIN
+	UPDATE intent_count SET n = n - 1 WHERE id = 1;
+END;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS trg_intents_changelog_update;
+
+CREATE TRIGGER IF NOT EXISTS trg_intents_changelog_update
+AFTER UPDATE ON intents
+BEGIN
+	INSERT INTO intent_changelog (intent_id, op) VALUES (NEW.id, 'update');
+END;
+
+ALTER TABLE intent_changelog DROP COLUMN tombstoned;
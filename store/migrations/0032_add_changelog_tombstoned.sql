@@ -0,0 +1,12 @@
+-- Mark the update that tombstones an intent, so later updates to a tombstoned
+-- intent (a rehash, say) are not reported as tombstoning it again.
+ALTER TABLE intent_changelog ADD COLUMN tombstoned INTEGER NOT NULL DEFAULT 0;
+
+DROP TRIGGER IF EXISTS trg_intents_changelog_update;
+
+CREATE TRIGGER IF NOT EXISTS trg_intents_changelog_update
+AFTER UPDATE ON intents
+BEGIN
+	INSERT INTO intent_changelog (intent_id, op, tombstoned)
+	VALUES (NEW.id, 'update', OLD.tombstoned_at IS NULL AND NEW.tombstoned_at IS NOT NULL);
+END;
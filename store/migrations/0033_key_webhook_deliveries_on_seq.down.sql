@@ -0,0 +1,22 @@
+CREATE TABLE webhook_deliveries_old (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	intent_id TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	last_status_code INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	delivered_at TEXT,
+	UNIQUE (url, intent_id)
+);
+
+INSERT OR IGNORE INTO webhook_deliveries_old SELECT * FROM webhook_deliveries ORDER BY id;
+
+DROP TABLE webhook_deliveries;
+ALTER TABLE webhook_deliveries_old RENAME TO webhook_deliveries;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_intent ON webhook_deliveries (intent_id);
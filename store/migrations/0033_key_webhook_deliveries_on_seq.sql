@@ -0,0 +1,25 @@
+-- Key deliveries on the change they deliver rather than the intent, so one
+-- intent can be delivered again for a later change. SQLite cannot drop a
+-- table constraint, so the table is rebuilt.
+CREATE TABLE webhook_deliveries_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	intent_id TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	last_status_code INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	delivered_at TEXT,
+	UNIQUE (url, seq)
+);
+
+INSERT OR IGNORE INTO webhook_deliveries_new SELECT * FROM webhook_deliveries ORDER BY id;
+
+DROP TABLE webhook_deliveries;
+ALTER TABLE webhook_deliveries_new RENAME TO webhook_deliveries;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_intent ON webhook_deliveries (intent_id);
@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys_old (
+	key TEXT PRIMARY KEY,
+	fingerprint TEXT NOT NULL,
+	intent_id TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	expires_at TEXT NOT NULL
+);
+
+INSERT OR IGNORE INTO idempotency_keys_old SELECT key, fingerprint, intent_id, created_at, expires_at FROM idempotency_keys;
+
+DROP TABLE idempotency_keys;
+ALTER TABLE idempotency_keys_old RENAME TO idempotency_keys;
@@ -0,0 +1,25 @@
+-- Scope idempotency keys to the author of the record they were used for, so
+-- one client's key cannot collide with another's, and keep the stored record
+-- so a replay can return it after the intent is merged away or removed.
+-- Existing keys take their intent's author. SQLite cannot change a primary
+-- key, so the table is rebuilt.
+CREATE TABLE idempotency_keys_new (
+	author TEXT NOT NULL DEFAULT '',
+	key TEXT NOT NULL,
+	fingerprint TEXT NOT NULL,
+	intent_id TEXT NOT NULL,
+	result BLOB,
+	result_encoding TEXT,
+	created_at TEXT NOT NULL,
+	expires_at TEXT NOT NULL,
+	PRIMARY KEY (author, key)
+);
+
+INSERT OR IGNORE INTO idempotency_keys_new (author, key, fingerprint, intent_id, created_at, expires_at)
+	SELECT COALESCE(i.author, ''), k.key, k.fingerprint, k.intent_id, k.created_at, k.expires_at
+	FROM idempotency_keys k LEFT JOIN intents i ON i.id = k.intent_id;
+
+DROP TABLE idempotency_keys;
+ALTER TABLE idempotency_keys_new RENAME TO idempotency_keys;
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_intent ON idempotency_keys (intent_id);
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Operation names a kind of store call reported to an Observer.
+type Operation string
+
+const (
+	// OpCreate covers CreateIntent, CreateIntents, and AppendIntent.
+	OpCreate Operation = "create"
+	// OpRead covers GetIntent, GetIntentByHash, ListIntents,
+	// ListIntentsPage, and ListAncestors.
+	OpRead Operation = "read"
+	// OpQuery covers QueryIntents and SearchSimilar.
+	OpQuery Operation = "query"
+)
+
+// Observer is told about store calls as they complete, for metrics and
+// tracing. Methods are called synchronously on the calling goroutine, so they
+// must be quick and safe for concurrent use.
+type Observer interface {
+	// ObserveOperation reports a call of kind op that took d. n is the number
+	// of intents written or returned, and is meaningless when err is set.
+	ObserveOperation(op Operation, n int, d time.Duration, err error)
+	// ObserveMigration reports one migration applied by Migrate.
+	ObserveMigration(version string, d time.Duration, err error)
+}
+
+// WithObserver reports store calls to o.
+func WithObserver(o Observer) Option {
+	return func(opts *options) {
+		opts.observer = o
+	}
+}
+
+// observe reports a call that started at start to the Observer, if set, and
+// logs it if it was slow.
+func (s *Store) observe(op Operation, start time.Time, n int, err error) {
+	d := time.Since(start)
+	if o := s.opts.observer; o != nil {
+		o.ObserveOperation(op, n, d, err)
+	}
+	if d >= s.opts.slowThreshold {
+		s.logger().Warn("slow store operation", "operation", string(op), "duration", d, "intents", n)
+	}
+}
+
+// DatabaseSize returns the size of the database in bytes, free pages
+// included.
+func (s *Store) DatabaseSize(ctx context.Context) (_ int64, err error) {
+	ctx, span := s.startSpan(ctx, "DatabaseSize")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	var pages, pageSize int64
+	if err := s.rdb.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := s.rdb.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pages * pageSize, nil
+}
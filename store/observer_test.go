@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+type recordingObserver struct {
+	mu         sync.Mutex
+	ops        []Operation
+	counts     []int
+	errs       []error
+	migrations []string
+}
+
+func (o *recordingObserver) ObserveOperation(op Operation, n int, _ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ops = append(o.ops, op)
+	o.counts = append(o.counts, n)
+	o.errs = append(o.errs, err)
+}
+
+func (o *recordingObserver) ObserveMigration(version string, _ time.Duration, _ error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.migrations = append(o.migrations, version)
+}
+
+func TestObserverSeesOperations(t *testing.T) {
+	ctx := context.Background()
+	obs := &recordingObserver{}
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithObserver(obs))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(obs.migrations) == 0 || obs.migrations[0] != "0001_create_intents.sql" {
+		t.Fatalf("expected migrations from 0001_create_intents.sql, got %v", obs.migrations)
+	}
+
+	if err := s.CreateIntents(ctx, []model.IntentRecord{testIntent(t, 1), testIntent(t, 2)}); err != nil {
+		t.Fatalf("create intents: %v", err)
+	}
+	if _, err := s.GetIntent(ctx, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+	if _, err := s.ListIntents(ctx, 10); err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+
+	want := []Operation{OpCreate, OpRead, OpRead}
+	if len(obs.ops) != len(want) {
+		t.Fatalf("expected operations %v, got %v", want, obs.ops)
+	}
+	for i := range want {
+		if obs.ops[i] != want[i] {
+			t.Fatalf("expected operations %v, got %v", want, obs.ops)
+		}
+	}
+	if obs.counts[0] != 2 || obs.errs[1] == nil || obs.counts[2] != 2 {
+		t.Fatalf("unexpected counts %v or errors %v", obs.counts, obs.errs)
+	}
+
+	size, err := s.DatabaseSize(ctx)
+	if err != nil || size <= 0 {
+		t.Fatalf("expected a positive database size, got %d (%v)", size, err)
+	}
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultListLimit is applied when a listing method is called with limit <= 0.
+	DefaultListLimit = 100
+	// DefaultMaxListLimit caps listing methods unless overridden with WithMaxListLimit.
+	DefaultMaxListLimit = 1000
+)
+
+// Option configures a Store at Open time.
+type Option func(*options)
+
+type options struct {
+	maxListLimit     int
+	promotedMetaKeys map[string]struct{}
+	migrationsFS     fs.FS
+	chainKey         ChainKeyFunc
+	compression      Compression
+	encryptionKey    []byte
+	redactor         RedactFunc
+	authorizer       Authorizer
+	validation       Validation
+	hashing          Hashing
+	rateLimits       RateLimits
+	idempotencyTTL   time.Duration
+	retry            Retry
+	cacheSize        int
+	embedder         Embedder
+	duplicates       *DuplicateDetection
+	retention        Retention
+	watchInterval    time.Duration
+	observer         Observer
+	tracerProvider   trace.TracerProvider
+	logger           *slog.Logger
+	slowThreshold    time.Duration
+	timeouts         Timeouts
+	busyTimeout      time.Duration
+	maxOpenConns     int
+	synchronous      Synchronous
+	cacheSizeKiB     int
+	readOnly         bool
+
+	clock            Clock
+	serverTimestamps bool
+	monotonic        bool
+	maxClockSkew     time.Duration
+
+	checkpointInterval int
+	checkpointSigner   CheckpointSigner
+}
+
+func defaultOptions() options {
+	return options{
+		maxListLimit:   DefaultMaxListLimit,
+		chainKey:       ChainByAuthor,
+		slowThreshold:  DefaultSlowThreshold,
+		busyTimeout:    DefaultBusyTimeout,
+		idempotencyTTL: DefaultIdempotencyTTL,
+		clock:          SystemClock,
+	}
+}
+
+// WithMaxListLimit sets the largest number of rows a single listing call may return.
+// Requested limits above max are clamped to max; values <= 0 keep the default.
+func WithMaxListLimit(max int) Option {
+	return func(o *options) {
+		if max > 0 {
+			o.maxListLimit = max
+		}
+	}
+}
+
+// WithMigrationsFS makes Migrate read migration files from the root of fsys
+// instead of the schema embedded in this package. The override replaces the
+// embedded set entirely.
+func WithMigrationsFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.migrationsFS = fsys
+	}
+}
+
+// clampLimit resolves a caller-supplied limit: limit <= 0 selects DefaultListLimit,
+// and any limit above the configured maximum is reduced to that maximum.
+func (s *Store) clampLimit(limit int) int {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if max := s.opts.maxListLimit; max > 0 && limit > max {
+		limit = max
+	}
+	return limit
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestListIntentsClampsLimit(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithMaxListLimit(3))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := s.CreateIntent(ctx, testIntent(t, i)); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	intents, err := s.ListIntents(ctx, 10_000_000)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	if len(intents) != 3 {
+		t.Fatalf("expected limit clamped to 3, got %d", len(intents))
+	}
+}
+
+func TestClampLimitDefaults(t *testing.T) {
+	s := &Store{opts: defaultOptions()}
+
+	if got := s.clampLimit(0); got != DefaultListLimit {
+		t.Fatalf("expected default limit %d for zero, got %d", DefaultListLimit, got)
+	}
+	if got := s.clampLimit(-5); got != DefaultListLimit {
+		t.Fatalf("expected default limit %d for negative, got %d", DefaultListLimit, got)
+	}
+	if got := s.clampLimit(DefaultMaxListLimit + 1); got != DefaultMaxListLimit {
+		t.Fatalf("expected max limit %d, got %d", DefaultMaxListLimit, got)
+	}
+	if got := s.clampLimit(42); got != 42 {
+		t.Fatalf("expected limit 42 unchanged, got %d", got)
+	}
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The intent changelog is the outbox: its triggers write an entry in the same
+// transaction as every insert, update, and delete, so an event exists exactly
+// when its change committed. A relay reads entries after a consumer's
+// checkpoint, publishes them, and only then advances the checkpoint, which
+// gives at-least-once delivery across restarts. Publishers should treat
+// IntentEvent.Seq as an idempotency key.
+
+// Relay retry delays unless overridden in RelayOptions.
+const (
+	DefaultRelayBackoff    = time.Second
+	DefaultRelayMaxBackoff = time.Minute
+)
+
+// PublishFunc publishes one event. Returning an error stops the pass without
+// advancing the checkpoint, so the event is published again.
+type PublishFunc func(ctx context.Context, event IntentEvent) error
+
+// RelayOptions configures Relay.
+type RelayOptions struct {
+	// Backoff is the delay after the first failed pass, doubled after each
+	// consecutive failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// OnError, when set, is called with every failed pass before the delay.
+	OnError func(error)
+}
+
+// OutboxCheckpoint returns the last sequence consumer acknowledged, or zero if
+// it has none.
+func (s *Store) OutboxCheckpoint(ctx context.Context, consumer string) (_ int64, err error) {
+	ctx, span := s.startSpan(ctx, "OutboxCheckpoint")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	var seq int64
+	err = s.rdb.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM outbox_checkpoints WHERE consumer = ?`, consumer).Scan(&seq)
+	return seq, err
+}
+
+// SaveOutboxCheckpoint records seq as the last sequence consumer handled.
+// Saving a lower sequence rewinds the consumer so events are replayed.
+func (s *Store) SaveOutboxCheckpoint(ctx context.Context, consumer string, seq int64) (err error) {
+	ctx, span := s.startSpan(ctx, "SaveOutboxCheckpoint")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("SaveOutboxCheckpoint"); err != nil {
+		return err
+	}
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	if consumer == "" {
+		return errors.New("consumer is required")
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO outbox_checkpoints (consumer, seq, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (consumer) DO UPDATE SET seq = excluded.seq, updated_at = excluded.updated_at`,
+		consumer, seq, time.Now().UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// RelayOnce publishes every event after consumer's checkpoint, advancing the
+// checkpoint after each one, and returns how many it published. It stops at
+// the first publish error, leaving that event to be published again.
+func (s *Store) RelayOnce(ctx context.Context, consumer string, publish PublishFunc) (_ int, err error) {
+	ctx, span := s.startSpan(ctx, "RelayOnce")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("RelayOnce"); err != nil {
+		return 0, err
+	}
+	seq, err := s.OutboxCheckpoint(ctx, consumer)
+	if err != nil {
+		return 0, fmt.Errorf("read checkpoint: %w", err)
+	}
+	published := 0
+	for {
+		changes, err := s.ReadChangelog(ctx, seq, watchBatchSize)
+		if err != nil {
+			return published, fmt.Errorf("read changelog: %w", err)
+		}
+		for _, change := range changes {
+			event, err := s.intentEvent(ctx, change)
+			if err != nil {
+				return published, fmt.Errorf("resolve change %d: %w", change.Seq, err)
+			}
+			if err := publish(ctx, event); err != nil {
+				return published, fmt.Errorf("publish change %d: %w", change.Seq, err)
+			}
+			if err := s.SaveOutboxCheckpoint(ctx, consumer, change.Seq); err != nil {
+				return published, fmt.Errorf("save checkpoint: %w", err)
+			}
+			seq = change.Seq
+			published++
+		}
+		if len(changes) < watchBatchSize {
+			return published, nil
+		}
+	}
+}
+
+// Relay runs RelayOnce for consumer every WithWatchInterval until ctx is
+// cancelled, backing off after failed passes. It returns nil on cancellation.
+func (s *Store) Relay(ctx context.Context, consumer string, publish PublishFunc, opts RelayOptions) (err error) {
+	ctx, span := s.startSpan(ctx, "Relay")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("Relay"); err != nil {
+		return err
+	}
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	if consumer == "" {
+		return errors.New("consumer is required")
+	}
+	interval := s.opts.watchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	backoff := opts.Backoff
+	if backoff <= 0 {
+		backoff = DefaultRelayBackoff
+	}
+	maxBackoff := opts.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRelayMaxBackoff
+	}
+
+	delay := time.Duration(0)
+	for {
+		if _, err := s.RelayOnce(ctx, consumer, publish); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if opts.OnError != nil {
+				opts.OnError(err)
+			}
+			if delay == 0 {
+				delay = backoff
+			} else {
+				delay = min(delay*2, maxBackoff)
+			}
+			s.logger().Warn("outbox relay failed, backing off", "consumer", consumer, "retry_in", delay, "error", err)
+		} else {
+			delay = 0
+		}
+
+		wait := interval
+		if delay > 0 {
+			wait = delay
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
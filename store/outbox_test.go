@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRelayOnceRedeliversAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	for i := 0; i < 3; i++ {
+		if err := s.CreateIntent(ctx, testIntent(t, i)); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	var published []string
+	failOn := testIntent(t, 1).ID
+	publish := func(ctx context.Context, event IntentEvent) error {
+		if event.IntentID == failOn {
+			return errors.New("broker unavailable")
+		}
+		published = append(published, event.IntentID)
+		return nil
+	}
+
+	n, err := s.RelayOnce(ctx, "bus", publish)
+	if err == nil || n != 1 {
+		t.Fatalf("expected the pass to stop after one event, got %d, %v", n, err)
+	}
+	if seq, err := s.OutboxCheckpoint(ctx, "bus"); err != nil || seq != 1 {
+		t.Fatalf("expected checkpoint 1, got %d, %v", seq, err)
+	}
+
+	failOn = ""
+	n, err = s.RelayOnce(ctx, "bus", publish)
+	if err != nil || n != 2 {
+		t.Fatalf("expected the remaining two events, got %d, %v", n, err)
+	}
+	want := []string{testIntent(t, 0).ID, testIntent(t, 1).ID, testIntent(t, 2).ID}
+	if len(published) != len(want) {
+		t.Fatalf("expected %v, got %v", want, published)
+	}
+	for i := range want {
+		if published[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, published)
+		}
+	}
+
+	// Other consumers keep their own position.
+	if seq, err := s.OutboxCheckpoint(ctx, "siem"); err != nil || seq != 0 {
+		t.Fatalf("expected no checkpoint for a new consumer, got %d, %v", seq, err)
+	}
+}
+
+func TestRelayResumesFromCheckpointAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "yanzi.db")
+	open := func() *Store {
+		s, err := Open(path, WithWatchInterval(5*time.Millisecond))
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if err := s.Migrate(ctx); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		return s
+	}
+
+	s := open()
+	if err := s.CreateIntent(ctx, testIntent(t, 0)); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	if _, err := s.RelayOnce(ctx, "bus", func(context.Context, IntentEvent) error { return nil }); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if err := s.CreateIntent(ctx, testIntent(t, 1)); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	_ = s.Close()
+
+	s = open()
+	defer s.Close()
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var got []string
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Relay(runCtx, "bus", func(ctx context.Context, event IntentEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, event.IntentID)
+			cancel()
+			return nil
+		}, RelayOptions{})
+	}()
+	if err := <-done; err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 || got[0] != testIntent(t, 1).ID {
+		t.Fatalf("expected only the intent created after the checkpoint, got %v", got)
+	}
+}
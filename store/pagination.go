@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// PageOptions selects a page of intents for ListIntentsPage.
+type PageOptions struct {
+	// Limit follows the same default and clamping as ListIntents.
+	Limit int
+	// Cursor is the NextCursor of the previous page; empty starts from the beginning.
+	// A cursor is only valid with the Sort it was issued for.
+	Cursor string
+	// Sort defaults to created_at ascending.
+	Sort Sort
+	// Author, if set, restricts the page to that author's intents.
+	Author string
+}
+
+// Page is one page of intents plus the cursor for the next page.
+type Page struct {
+	Intents []model.IntentRecord
+	// NextCursor is empty when there are no further intents.
+	NextCursor string
+}
+
+// pageCursor is the decoded form of an opaque page cursor. Field and Direction
+// are omitted for the default created_at ascending sort.
+type pageCursor struct {
+	Field     SortField     `json:"f,omitempty"`
+	Direction SortDirection `json:"d,omitempty"`
+	Value     string        `json:"c"`
+	ID        string        `json:"i"`
+}
+
+// ListIntentsPage returns intents in (sort field, id) order using keyset
+// pagination, so deep pages cost the same as the first.
+func (s *Store) ListIntentsPage(ctx context.Context, opts PageOptions) (page Page, err error) {
+	ctx, span := s.startSpan(ctx, "ListIntentsPage")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpRead, start, len(page.Intents), err) }(time.Now())
+	order, err := opts.Sort.resolve(SortAsc)
+	if err != nil {
+		return Page{}, err
+	}
+	cursor, err := decodePageCursor(opts.Cursor)
+	if err != nil {
+		return Page{}, err
+	}
+	limit := s.clampLimit(opts.Limit)
+
+	var where []string
+	args := []any{}
+	if opts.Author != "" {
+		where = append(where, `author = ?`)
+		args = append(args, opts.Author)
+	}
+	if opts.Cursor != "" {
+		if cursorSort(cursor) != order {
+			return Page{}, errors.New("page cursor does not match sort order")
+		}
+		where = append(where, order.after())
+		args = append(args, cursor.Value, cursor.Value, cursor.ID)
+	}
+	query := `SELECT ` + intentColumns + ` FROM intents`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY ` + order.orderBy() + ` LIMIT ?`
+	args = append(args, limit+1)
+
+	stmt, err := s.preparedRead(ctx, query)
+	if err != nil {
+		return Page{}, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return Page{}, err
+	}
+	intents, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page = Page{Intents: intents}
+	if len(intents) > limit {
+		page.Intents = intents[:limit]
+		last := page.Intents[limit-1]
+		page.NextCursor = encodePageCursor(order, last)
+	}
+	// The cursor is taken before filtering so denied records are skipped
+	// rather than ending the listing.
+	page.Intents = s.allowed(ctx, ActionList, page.Intents)
+	return page, nil
+}
+
+// PageCursor returns the cursor that resumes a default-sorted (created_at
+// ascending) ListIntentsPage after record.
+func PageCursor(record model.IntentRecord) string {
+	return encodePageCursor(Sort{Field: SortCreatedAt, Direction: SortAsc}, record)
+}
+
+func encodePageCursor(order Sort, last model.IntentRecord) string {
+	cursor := pageCursor{Value: order.value(last), ID: last.ID}
+	if order != (Sort{Field: SortCreatedAt, Direction: SortAsc}) {
+		cursor.Field = order.Field
+		cursor.Direction = order.Direction
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodePageCursor(value string) (pageCursor, error) {
+	var cursor pageCursor
+	if value == "" {
+		return cursor, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, errors.New("invalid page cursor")
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+		return cursor, errors.New("invalid page cursor")
+	}
+	return cursor, nil
+}
+
+// cursorSort returns the sort a cursor was issued for.
+func cursorSort(cursor pageCursor) Sort {
+	if cursor.Field == "" {
+		return Sort{Field: SortCreatedAt, Direction: SortAsc}
+	}
+	return Sort{Field: cursor.Field, Direction: cursor.Direction}
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListIntentsPageWalksAllIntents(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	const n = 7
+	for i := 0; i < n; i++ {
+		if err := s.CreateIntent(ctx, testIntent(t, i)); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	var seen []string
+	opts := PageOptions{Limit: 3}
+	for pages := 0; ; pages++ {
+		if pages > n {
+			t.Fatalf("pagination did not terminate")
+		}
+		page, err := s.ListIntentsPage(ctx, opts)
+		if err != nil {
+			t.Fatalf("list page: %v", err)
+		}
+		for _, intent := range page.Intents {
+			seen = append(seen, intent.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	if len(seen) != n {
+		t.Fatalf("expected %d intents, got %d: %v", n, len(seen), seen)
+	}
+	for i, id := range seen {
+		if want := testIntent(t, i).ID; id != want {
+			t.Fatalf("position %d: expected %s, got %s", i, want, id)
+		}
+	}
+}
+
+func TestListIntentsPageRejectsInvalidCursor(t *testing.T) {
+	s := openTestStore(t)
+	if _, err := s.ListIntentsPage(context.Background(), PageOptions{Cursor: "not a cursor"}); err == nil {
+		t.Fatalf("expected invalid cursor error")
+	}
+}
+
+func TestListIntentsPageSortsByAuthorWithStableIDs(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	authors := []string{"carol", "alice", "bob", "alice", "carol"}
+	for i, author := range authors {
+		record := testIntent(t, i)
+		record.Author = author
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	order := Sort{Field: SortAuthor, Direction: SortDesc}
+	var seen []int
+	opts := PageOptions{Limit: 2, Sort: order}
+	for {
+		page, err := s.ListIntentsPage(ctx, opts)
+		if err != nil {
+			t.Fatalf("list page: %v", err)
+		}
+		for _, intent := range page.Intents {
+			for i := range authors {
+				if testIntent(t, i).ID == intent.ID {
+					seen = append(seen, i)
+				}
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	want := []int{4, 0, 2, 3, 1}
+	if len(seen) != len(want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, seen)
+		}
+	}
+
+	first, err := s.ListIntentsPage(ctx, PageOptions{Limit: 2, Sort: order})
+	if err != nil {
+		t.Fatalf("list page: %v", err)
+	}
+	if _, err := s.ListIntentsPage(ctx, PageOptions{Limit: 2, Cursor: first.NextCursor}); err == nil {
+		t.Fatalf("expected cursor reuse with a different sort to fail")
+	}
+}
+
+func TestListIntentsPageByAuthor(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	for i := 0; i < 6; i++ {
+		author := "alice"
+		if i%2 == 1 {
+			author = "bob"
+		}
+		if err := s.CreateIntent(ctx, linkedIntent(t, i, author, "")); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	page, err := s.ListIntentsPage(ctx, PageOptions{Limit: 2, Author: "bob"})
+	if err != nil {
+		t.Fatalf("list page: %v", err)
+	}
+	if len(page.Intents) != 2 || page.NextCursor == "" {
+		t.Fatalf("expected a full first page, got %+v", page)
+	}
+	page, err = s.ListIntentsPage(ctx, PageOptions{Limit: 2, Author: "bob", Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("list page: %v", err)
+	}
+	if len(page.Intents) != 1 || page.Intents[0].Author != "bob" || page.NextCursor != "" {
+		t.Fatalf("expected bob's last intent, got %+v", page)
+	}
+}
+
+func TestPageCursorResumesAfterRecord(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	for i := 0; i < 4; i++ {
+		if err := s.CreateIntent(ctx, testIntent(t, i)); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	page, err := s.ListIntentsPage(ctx, PageOptions{Cursor: PageCursor(testIntent(t, 1))})
+	if err != nil {
+		t.Fatalf("list page: %v", err)
+	}
+	if len(page.Intents) != 2 || page.Intents[0].ID != testIntent(t, 2).ID {
+		t.Fatalf("expected intents 2 and 3, got %+v", page.Intents)
+	}
+}
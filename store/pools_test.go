@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadsDoNotBlockWrites(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	for n := 1; n <= 3; n++ {
+		if err := s.CreateIntent(ctx, testIntent(t, n)); err != nil {
+			t.Fatalf("create intent: %v", err)
+		}
+	}
+
+	// Hold a read open mid-iteration, as a long listing would.
+	rows, err := s.rdb.QueryContext(ctx, `SELECT id FROM intents`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("expected a row")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for n := 4; n < 12; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.AppendIntent(ctx, testIntent(t, n)); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("append during open read: %v", err)
+	}
+}
+
+func TestReadPoolIsQueryOnly(t *testing.T) {
+	s := openTestStore(t)
+	if s.rdb == s.db {
+		t.Fatal("expected separate reader and writer pools for a file database")
+	}
+	if _, err := s.rdb.ExecContext(context.Background(), `DELETE FROM intents`); err == nil {
+		t.Fatal("expected a write on the read pool to fail")
+	}
+}
+
+func TestInMemoryStoreSharesOnePool(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if s.rdb != s.db {
+		t.Fatal("expected an in-memory store to use one pool")
+	}
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := s.CreateIntent(ctx, testIntent(t, 1)); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	if _, err := s.GetIntent(ctx, testIntent(t, 1).ID); err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBusyTimeout is how long a connection waits on a locked database
+// unless overridden with WithBusyTimeout.
+const DefaultBusyTimeout = 5 * time.Second
+
+// Synchronous is an SQLite synchronous setting.
+type Synchronous string
+
+const (
+	SynchronousOff    Synchronous = "OFF"
+	SynchronousNormal Synchronous = "NORMAL"
+	SynchronousFull   Synchronous = "FULL"
+	SynchronousExtra  Synchronous = "EXTRA"
+)
+
+// WithBusyTimeout sets how long a connection waits on a locked database before
+// failing with SQLITE_BUSY; values < 0 keep the default.
+func WithBusyTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d >= 0 {
+			o.busyTimeout = d
+		}
+	}
+}
+
+// WithMaxOpenConns limits the pool of read connections; values <= 0 leave it
+// unlimited. Writes always go through a single connection.
+func WithMaxOpenConns(n int) Option {
+	return func(o *options) {
+		o.maxOpenConns = n
+	}
+}
+
+// WithSynchronous sets how often SQLite syncs to disk. In WAL mode
+// SynchronousNormal is durable against application crashes but may lose the
+// latest commits on power loss, in exchange for much faster writes. Without
+// it SQLite's default, FULL, is used.
+func WithSynchronous(mode Synchronous) Option {
+	return func(o *options) {
+		o.synchronous = mode
+	}
+}
+
+// WithCacheSize sets each connection's page cache to kib kibibytes; values
+// <= 0 keep SQLite's default of about 2 MiB.
+func WithCacheSize(kib int) Option {
+	return func(o *options) {
+		o.cacheSizeKiB = kib
+	}
+}
+
+// WithReadOnly opens the database read-only: writes fail and Open neither
+// creates the file nor changes its journal mode.
+func WithReadOnly() Option {
+	return func(o *options) {
+		o.readOnly = true
+	}
+}
+
+// dsn returns the data source name for path, carrying the connection pragmas
+// so that every pooled connection applies them, not just the first.
+func (o options) dsn(path string) (string, error) {
+	q := url.Values{}
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", o.busyTimeout.Milliseconds()))
+	q.Add("_pragma", "foreign_keys(1)")
+	switch o.synchronous {
+	case "":
+	case SynchronousOff, SynchronousNormal, SynchronousFull, SynchronousExtra:
+		q.Add("_pragma", "synchronous("+string(o.synchronous)+")")
+	default:
+		return "", fmt.Errorf("unknown synchronous mode %q", o.synchronous)
+	}
+	if o.cacheSizeKiB > 0 {
+		q.Add("_pragma", fmt.Sprintf("cache_size(-%d)", o.cacheSizeKiB))
+	}
+
+	if o.readOnly {
+		// mode=ro is only honoured in a file: URI.
+		q.Set("mode", "ro")
+		if rest, ok := strings.CutPrefix(path, "file:"); ok {
+			return "file:" + appendQuery(rest, q), nil
+		}
+		return "file:" + (&url.URL{Path: path}).EscapedPath() + "?" + q.Encode(), nil
+	}
+	return appendQuery(path, q), nil
+}
+
+// appendQuery adds q to a path that may already carry query parameters.
+func appendQuery(path string, q url.Values) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + q.Encode()
+	}
+	return path + "?" + q.Encode()
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPragmasApplyToEveryConnection(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"),
+		WithBusyTimeout(2*time.Second), WithSynchronous(SynchronousNormal), WithCacheSize(8192), WithMaxOpenConns(3))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	// Hold the writer and every reader open at once so each is checked.
+	for i := range 4 {
+		pool := s.rdb
+		if i == 0 {
+			pool = s.db
+		}
+		conn, err := pool.Conn(ctx)
+		if err != nil {
+			t.Fatalf("conn %d: %v", i, err)
+		}
+		defer conn.Close()
+		var busy, sync, cache, fk int
+		for pragma, dst := range map[string]*int{"busy_timeout": &busy, "synchronous": &sync, "cache_size": &cache, "foreign_keys": &fk} {
+			if err := conn.QueryRowContext(ctx, "PRAGMA "+pragma).Scan(dst); err != nil {
+				t.Fatalf("conn %d: read %s: %v", i, pragma, err)
+			}
+		}
+		// synchronous NORMAL is 1.
+		if busy != 2000 || sync != 1 || cache != -8192 || fk != 1 {
+			t.Fatalf("conn %d: busy_timeout=%d synchronous=%d cache_size=%d foreign_keys=%d", i, busy, sync, cache, fk)
+		}
+	}
+	if got := s.rdb.Stats().MaxOpenConnections; got != 3 {
+		t.Fatalf("expected 3 max open read connections, got %d", got)
+	}
+	if got := s.db.Stats().MaxOpenConnections; got != 1 {
+		t.Fatalf("expected a single writer connection, got %d", got)
+	}
+}
+
+func TestWithReadOnlyRejectsWrites(t *testing.T) {
+	ctx := context.Background()
+	// The space must survive escaping into a file: URI.
+	path := filepath.Join(t.TempDir(), "yanzi ledger.db")
+	rw, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := rw.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := rw.CreateIntent(ctx, testIntent(t, 1)); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	rw.Close()
+
+	ro, err := Open(path, WithReadOnly())
+	if err != nil {
+		t.Fatalf("open read-only: %v", err)
+	}
+	t.Cleanup(func() { ro.Close() })
+	if _, err := ro.GetIntent(ctx, testIntent(t, 1).ID); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if err := ro.CreateIntent(ctx, testIntent(t, 2)); err == nil {
+		t.Fatal("expected a write to a read-only store to fail")
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.db"), WithReadOnly()); err == nil {
+		t.Fatal("expected read-only open of a missing file to fail")
+	}
+}
+
+func TestOpenRejectsUnknownSynchronous(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithSynchronous("SOMETIMES")); err == nil {
+		t.Fatal("expected error for an unknown synchronous mode")
+	}
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+const insertPromotedMetaSQL = `INSERT INTO intent_promoted_meta (intent_id, key, value, created_at) VALUES (?, ?, ?, ?)`
+
+const listIntentsByPromotedKeySQL = `SELECT ` + intentColumnsQualified + ` FROM intents i
+	JOIN intent_promoted_meta p ON p.intent_id = i.id
+	WHERE p.key = ? AND p.value = ?
+	ORDER BY p.created_at DESC, p.intent_id DESC LIMIT ?`
+
+const intentColumnsQualified = `i.id, i.created_at, i.author, i.source_type, i.title, i.prompt, i.response, i.meta, i.prev_hash, i.hash, i.signature, i.public_key, i.hash_version, i.tags, i.thread_id, i.parent_id, i.attachments, i.body_encoding, i.tombstoned_at`
+
+// WithPromotedMetaKeys declares meta keys whose string values are copied into the
+// indexed intent_promoted_meta table on insert, enabling ListIntentsByPromotedKey.
+// The meta JSON is stored unchanged, so hashes are unaffected. Only intents created
+// while a key is promoted are indexed under it.
+func WithPromotedMetaKeys(keys []string) Option {
+	return func(o *options) {
+		set := make(map[string]struct{}, len(keys))
+		for _, key := range keys {
+			if key != "" {
+				set[key] = struct{}{}
+			}
+		}
+		o.promotedMetaKeys = set
+	}
+}
+
+// ListIntentsByPromotedKey returns intents whose promoted meta key equals value,
+// newest first. The key must have been declared with WithPromotedMetaKeys.
+func (s *Store) ListIntentsByPromotedKey(ctx context.Context, key, value string, limit int) (_ []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "ListIntentsByPromotedKey")
+	defer func() { err = span.end(err) }()
+	if _, ok := s.opts.promotedMetaKeys[key]; !ok {
+		return nil, fmt.Errorf("meta key %q is not promoted", key)
+	}
+	limit = s.clampLimit(limit)
+
+	stmt, err := s.preparedRead(ctx, listIntentsByPromotedKeySQL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, key, value, limit)
+	if err != nil {
+		return nil, err
+	}
+	intents, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	return s.allowed(ctx, ActionList, intents), nil
+}
+
+// promotedMetaValues extracts the promoted keys that carry string values in raw.
+func (s *Store) promotedMetaValues(raw json.RawMessage) (map[string]string, error) {
+	if len(s.opts.promotedMetaKeys) == 0 || model.IsEmptyMeta(raw) {
+		return nil, nil
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("decode meta: %w", err)
+	}
+
+	values := make(map[string]string)
+	for key := range s.opts.promotedMetaKeys {
+		if v, ok := payload[key].(string); ok {
+			values[key] = v
+		}
+	}
+	return values, nil
+}
+
+// insertPromotedMeta writes promoted values for record inside tx in key order.
+// created_at is copied so the lookup index also serves the ORDER BY.
+func insertPromotedMeta(ctx context.Context, stmt *sql.Stmt, record model.IntentRecord, values map[string]string) error {
+	if stmt == nil {
+		return errors.New("promoted meta statement not prepared")
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := stmt.ExecContext(ctx, record.ID, key, values[key], record.CreatedAt); err != nil {
+			return fmt.Errorf("promote meta %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func openPromotedStore(tb testing.TB) *Store {
+	tb.Helper()
+	s, err := Open(filepath.Join(tb.TempDir(), "yanzi.db"), WithPromotedMetaKeys([]string{"env", "team"}))
+	if err != nil {
+		tb.Fatalf("open store: %v", err)
+	}
+	tb.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		tb.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func seedPromotedIntents(tb testing.TB, s *Store, n int) {
+	tb.Helper()
+	for i := 0; i < n; i++ {
+		record := testIntent(tb, i)
+		env := "dev"
+		if i%10 == 0 {
+			env = "prod"
+		}
+		record.Meta = json.RawMessage(fmt.Sprintf(`{"env":%q,"owner":"bob"}`, env))
+		if err := s.CreateIntent(context.Background(), record); err != nil {
+			tb.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+}
+
+func TestListIntentsByPromotedKey(t *testing.T) {
+	ctx := context.Background()
+	s := openPromotedStore(t)
+	seedPromotedIntents(t, s, 30)
+
+	intents, err := s.ListIntentsByPromotedKey(ctx, "env", "prod", 0)
+	if err != nil {
+		t.Fatalf("list by promoted key: %v", err)
+	}
+	if len(intents) != 3 {
+		t.Fatalf("expected 3 prod intents, got %d", len(intents))
+	}
+	for _, intent := range intents {
+		if string(intent.Meta) != `{"env":"prod","owner":"bob"}` {
+			t.Fatalf("expected meta blob preserved, got %s", intent.Meta)
+		}
+	}
+	if intents[0].CreatedAt < intents[1].CreatedAt {
+		t.Fatalf("expected newest first")
+	}
+
+	if _, err := s.ListIntentsByPromotedKey(ctx, "owner", "bob", 0); err == nil {
+		t.Fatalf("expected error for key that is not promoted")
+	}
+}
+
+func BenchmarkListByPromotedKey(b *testing.B) {
+	ctx := context.Background()
+	s := openPromotedStore(b)
+	seedPromotedIntents(b, s, 5000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ListIntentsByPromotedKey(ctx, "env", "prod", 50); err != nil {
+			b.Fatalf("list by promoted key: %v", err)
+		}
+	}
+}
+
+func BenchmarkListByJSONExtract(b *testing.B) {
+	ctx := context.Background()
+	s := openPromotedStore(b)
+	seedPromotedIntents(b, s, 5000)
+
+	query := `SELECT ` + intentColumns + ` FROM intents WHERE json_extract(meta, '$.env') = ? ORDER BY created_at DESC LIMIT ?`
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := s.db.QueryContext(ctx, query, "prod", 50)
+		if err != nil {
+			b.Fatalf("list by json_extract: %v", err)
+		}
+		if _, err := s.collectIntents(ctx, rows); err != nil {
+			b.Fatalf("collect intents: %v", err)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Query describes an intent search. Set fields are combined with AND; zero
+// values are ignored. Meta conditions compose with MetaAnd and MetaOr.
+type Query struct {
+	Author     string
+	SourceType string
+	// CreatedFrom (inclusive) and CreatedTo (exclusive) bound created_at. They are
+	// compared as instants, so stored timestamps with differing offsets or
+	// fractional precision order correctly.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// TitlePrefix matches titles starting with the given string (case-sensitive).
+	TitlePrefix string
+	// Text matches intents whose title, prompt, or response contains it,
+	// ignoring ASCII case. Compressed or encrypted rows are matched after
+	// decoding, so it works with WithCompression and WithEncryptionKey.
+	Text string
+	// HasPrevHash, when set, selects intents with (true) or without (false) a prev_hash.
+	HasPrevHash *bool
+	Meta        MetaCondition
+	// Sort defaults to created_at descending.
+	Sort Sort
+	// Limit follows the same default and clamping as ListIntents.
+	Limit int
+}
+
+// MetaCondition is a composable predicate over top-level string meta values.
+type MetaCondition interface {
+	compileMeta(b *queryBuilder) error
+}
+
+// MetaEq matches intents whose meta key holds the string value.
+func MetaEq(key, value string) MetaCondition {
+	return metaEq{key: key, value: value}
+}
+
+// MetaAnd matches when every condition matches.
+func MetaAnd(conds ...MetaCondition) MetaCondition {
+	return metaGroup{op: " AND ", conds: conds}
+}
+
+// MetaOr matches when any condition matches.
+func MetaOr(conds ...MetaCondition) MetaCondition {
+	return metaGroup{op: " OR ", conds: conds}
+}
+
+type metaEq struct {
+	key   string
+	value string
+}
+
+func (c metaEq) compileMeta(b *queryBuilder) error {
+	path, err := metaPath(c.key)
+	if err != nil {
+		return err
+	}
+	b.sql.WriteString(metaEqSQL)
+	b.args = append(b.args, path, path, c.value)
+	return nil
+}
+
+type metaGroup struct {
+	op    string
+	conds []MetaCondition
+}
+
+func (g metaGroup) compileMeta(b *queryBuilder) error {
+	if len(g.conds) == 0 {
+		return errors.New("meta condition group is empty")
+	}
+	b.sql.WriteByte('(')
+	for i, cond := range g.conds {
+		if cond == nil {
+			return errors.New("meta condition is nil")
+		}
+		if i > 0 {
+			b.sql.WriteString(g.op)
+		}
+		if err := cond.compileMeta(b); err != nil {
+			return err
+		}
+	}
+	b.sql.WriteByte(')')
+	return nil
+}
+
+// queryBuilder accumulates a WHERE clause and its arguments.
+type queryBuilder struct {
+	sql  strings.Builder
+	args []any
+	n    int
+}
+
+func (b *queryBuilder) and(cond string, args ...any) {
+	if b.n > 0 {
+		b.sql.WriteString(" AND ")
+	}
+	b.n++
+	b.sql.WriteString(cond)
+	b.args = append(b.args, args...)
+}
+
+// compile returns the WHERE clause (without the keyword) and its arguments.
+func (q Query) compile() (string, []any, error) {
+	var b queryBuilder
+	if q.Author != "" {
+		b.and(`author = ?`, q.Author)
+	}
+	if q.SourceType != "" {
+		b.and(`source_type = ?`, q.SourceType)
+	}
+	if !q.CreatedFrom.IsZero() {
+		b.and(`julianday(created_at) >= julianday(?)`, q.CreatedFrom.UTC().Format(time.RFC3339Nano))
+	}
+	if !q.CreatedTo.IsZero() {
+		b.and(`julianday(created_at) < julianday(?)`, q.CreatedTo.UTC().Format(time.RFC3339Nano))
+	}
+	if q.TitlePrefix != "" {
+		b.and(`substr(title, 1, length(?)) = ?`, q.TitlePrefix, q.TitlePrefix)
+	}
+	if q.Text != "" {
+		// Encoded bodies cannot be matched in SQL; QueryIntents filters them
+		// after decoding.
+		needle := asciiLower(q.Text)
+		b.and(`(body_encoding IS NOT NULL OR instr(lower(title), ?) > 0 OR instr(lower(prompt), ?) > 0 OR instr(lower(response), ?) > 0)`,
+			needle, needle, needle)
+	}
+	if q.HasPrevHash != nil {
+		if *q.HasPrevHash {
+			b.and(`prev_hash IS NOT NULL AND prev_hash != ''`)
+		} else {
+			b.and(`(prev_hash IS NULL OR prev_hash = '')`)
+		}
+	}
+	if q.Meta != nil {
+		if b.n > 0 {
+			b.sql.WriteString(" AND ")
+		}
+		b.n++
+		if err := q.Meta.compileMeta(&b); err != nil {
+			return "", nil, err
+		}
+	}
+	return b.sql.String(), b.args, nil
+}
+
+// QueryIntents returns intents matching q, ordered by q.Sort (newest first by default).
+func (s *Store) QueryIntents(ctx context.Context, q Query) (records []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "QueryIntents")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpQuery, start, len(records), err) }(time.Now())
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	order, err := q.Sort.resolve(SortDesc)
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := q.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + intentColumns + ` FROM intents`
+	limit := s.clampLimit(q.Limit)
+
+	if q.Text == "" {
+		if where != "" {
+			query += ` WHERE ` + where
+		}
+		query += ` ORDER BY ` + order.orderBy() + ` LIMIT ?`
+		rows, err := s.rdb.QueryContext(ctx, query, append(args, limit)...)
+		if err != nil {
+			return nil, err
+		}
+		intents, err := s.collectIntents(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		return s.allowed(ctx, ActionList, intents), nil
+	}
+
+	// Candidates include every encoded row, so read in batches until limit
+	// intents actually match. Each batch resumes after the last row of the
+	// one before, as ListIntentsPage does, rather than rescanning with OFFSET.
+	needle := asciiLower(q.Text)
+	firstQuery, nextQuery := query, query+` WHERE `+order.after()
+	if where != "" {
+		firstQuery += ` WHERE ` + where
+		nextQuery += ` AND ` + where
+	}
+	tail := ` ORDER BY ` + order.orderBy() + ` LIMIT ?`
+	var (
+		matched []model.IntentRecord
+		last    *model.IntentRecord
+	)
+	for len(matched) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		stmt, batchArgs := firstQuery+tail, append(slices.Clone(args), limit)
+		if last != nil {
+			value := order.value(*last)
+			stmt, batchArgs = nextQuery+tail, append([]any{value, value, last.ID}, append(slices.Clone(args), limit)...)
+		}
+		rows, err := s.rdb.QueryContext(ctx, stmt, batchArgs...)
+		if err != nil {
+			return nil, err
+		}
+		batch, err := s.collectIntents(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range s.allowed(ctx, ActionList, batch) {
+			if matchesText(record, needle) && len(matched) < limit {
+				matched = append(matched, record)
+			}
+		}
+		if len(batch) < limit {
+			break
+		}
+		last = &batch[len(batch)-1]
+	}
+	return matched, nil
+}
+
+// matchesText reports whether record's title, prompt, or response contains
+// needle, which is already lowered with asciiLower.
+func matchesText(record model.IntentRecord, needle string) bool {
+	return strings.Contains(asciiLower(record.Title), needle) ||
+		strings.Contains(asciiLower(record.Prompt), needle) ||
+		strings.Contains(asciiLower(record.Response), needle)
+}
+
+// asciiLower lowers ASCII letters only, matching SQLite's lower().
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, s)
+}
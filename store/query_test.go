@@ -0,0 +1,140 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueryIntents(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	fixtures := []struct {
+		author, source, title, prev, meta string
+	}{
+		{"alice", "cli", "Design: schema", "", `{"env":"prod"}`},
+		{"alice", "api", "design notes", "h0", `{"env":"dev"}`},
+		{"bob", "cli", "Design: chain", "h1", `{"env":"staging","team":"core"}`},
+		{"bob", "cli", "Misc", "h2", `{"env":"prod","team":"web"}`},
+	}
+	for i, f := range fixtures {
+		record := testIntent(t, i)
+		record.Author = f.author
+		record.SourceType = f.source
+		record.Title = f.title
+		record.PrevHash = f.prev
+		record.Meta = json.RawMessage(f.meta)
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	yes, no := true, false
+	base := time.Date(2026, 2, 9, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		q    Query
+		want []int
+	}{
+		{"author", Query{Author: "bob"}, []int{3, 2}},
+		{"source and author", Query{Author: "alice", SourceType: "cli"}, []int{0}},
+		{"created range", Query{CreatedFrom: base.Add(time.Second), CreatedTo: base.Add(3 * time.Second)}, []int{2, 1}},
+		{"title prefix is case-sensitive", Query{TitlePrefix: "Design"}, []int{2, 0}},
+		{"has prev hash", Query{HasPrevHash: &yes, SourceType: "cli"}, []int{3, 2}},
+		{"no prev hash", Query{HasPrevHash: &no}, []int{0}},
+		{"meta or", Query{Meta: MetaOr(MetaEq("env", "dev"), MetaEq("env", "staging"))}, []int{2, 1}},
+		{"text ignores case", Query{Text: "DESIGN"}, []int{2, 1, 0}},
+		{"text in prompt", Query{Text: "Prompt 3"}, []int{3}},
+		{"meta and/or", Query{Meta: MetaAnd(MetaEq("env", "prod"), MetaOr(MetaEq("team", "web"), MetaEq("team", "core")))}, []int{3}},
+	}
+	for _, tc := range cases {
+		got, err := s.QueryIntents(ctx, tc.q)
+		if err != nil {
+			t.Fatalf("%s: query intents: %v", tc.name, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: expected %d intents, got %d", tc.name, len(tc.want), len(got))
+		}
+		for i, n := range tc.want {
+			if want := testIntent(t, n).ID; got[i].ID != want {
+				t.Fatalf("%s position %d: expected %s, got %s", tc.name, i, want, got[i].ID)
+			}
+		}
+	}
+
+	if _, err := s.QueryIntents(ctx, Query{Meta: MetaOr()}); err == nil {
+		t.Fatalf("expected empty meta group to fail")
+	}
+}
+
+func TestQueryIntentsSortAscending(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	for i := 0; i < 3; i++ {
+		if err := s.CreateIntent(ctx, testIntent(t, i)); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	got, err := s.QueryIntents(ctx, Query{Sort: Sort{Direction: SortAsc}})
+	if err != nil {
+		t.Fatalf("query intents: %v", err)
+	}
+	for i, intent := range got {
+		if want := testIntent(t, i).ID; intent.ID != want {
+			t.Fatalf("position %d: expected %s, got %s", i, want, intent.ID)
+		}
+	}
+
+	if _, err := s.QueryIntents(ctx, Query{Sort: Sort{Field: "prompt"}}); err == nil {
+		t.Fatalf("expected unsupported sort field to fail")
+	}
+}
+
+func TestQueryIntentsTextMatchesEncryptedRows(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{7}, EncryptionKeySize)
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := s.CreateIntent(ctx, testIntent(t, i)); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	// The only match is the oldest row, past several full batches.
+	got, err := s.QueryIntents(ctx, Query{Text: "PROMPT 0", Limit: 2})
+	if err != nil {
+		t.Fatalf("query intents: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != testIntent(t, 0).ID {
+		t.Fatalf("expected intent 0, got %+v", got)
+	}
+
+	got, err = s.QueryIntents(ctx, Query{Text: "response", Limit: 2})
+	if err != nil {
+		t.Fatalf("query intents: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != testIntent(t, 4).ID || got[1].ID != testIntent(t, 3).ID {
+		t.Fatalf("expected the two newest intents, got %+v", got)
+	}
+
+	// Every intent has the same author, so batches resume on the ID tiebreak.
+	got, err = s.QueryIntents(ctx, Query{Text: "PROMPT 0", Limit: 2, Sort: Sort{Field: SortAuthor, Direction: SortDesc}})
+	if err != nil {
+		t.Fatalf("query intents: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != testIntent(t, 0).ID {
+		t.Fatalf("expected intent 0 sorted by author, got %+v", got)
+	}
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// ErrRateLimited is matched by errors returned when a write exceeds the
+// configured rate limits.
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimitedError reports a write rejected by a rate limit.
+type RateLimitedError struct {
+	// Author is the author whose limit was exceeded, or empty for the global
+	// limit.
+	Author string
+	// RetryAfter is how long until the write would be admitted, assuming no
+	// other writes in between.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.Author == "" {
+		return fmt.Sprintf("global write rate limit exceeded; retry after %s", e.RetryAfter)
+	}
+	return fmt.Sprintf("write rate limit exceeded for author %q; retry after %s", e.Author, e.RetryAfter)
+}
+
+// Unwrap lets errors.Is(err, ErrRateLimited) match.
+func (e *RateLimitedError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RateLimit is a token bucket: Burst intents may be written at once, and the
+// bucket refills at Rate intents per second. A zero Rate disables the limit;
+// a Burst below 1 is treated as 1.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits bounds how fast intents are written.
+type RateLimits struct {
+	// Global limits writes across all authors.
+	Global RateLimit
+	// PerAuthor limits each author separately.
+	PerAuthor RateLimit
+}
+
+// WithRateLimits rejects writes by CreateIntent, CreateIntents,
+// CreateIntentIdempotent, AppendIntent, and CreateIntentStream that exceed
+// limits with a *RateLimitedError, before they reach the database. A batch
+// counts one token per record and is admitted or rejected as a whole. Tokens
+// taken by a write that fails validation are refunded. Limits
+// apply to this Store only; other processes writing the same file have their
+// own.
+func WithRateLimits(limits RateLimits) Option {
+	return func(o *options) {
+		o.rateLimits = limits
+	}
+}
+
+// maxIdleBuckets bounds how many per-author buckets are kept before full
+// (idle) ones are dropped.
+const maxIdleBuckets = 10000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter holds the token buckets of one store. A nil limiter admits
+// everything.
+type rateLimiter struct {
+	limits RateLimits
+	now    func() time.Time
+
+	mu      sync.Mutex
+	global  bucket
+	authors map[string]*bucket
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	if limits.Global.Rate <= 0 && limits.PerAuthor.Rate <= 0 {
+		return nil
+	}
+	limits.Global.Burst = max(limits.Global.Burst, 1)
+	limits.PerAuthor.Burst = max(limits.PerAuthor.Burst, 1)
+	return &rateLimiter{limits: limits, now: time.Now, authors: make(map[string]*bucket)}
+}
+
+// admitRecords takes one token per record from the global bucket and from
+// each record's author, or none if any bucket is short.
+func (l *rateLimiter) admitRecords(records ...model.IntentRecord) error {
+	if l == nil {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, record := range records {
+		counts[record.Author]++
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if l.limits.Global.Rate > 0 {
+		if wait := l.global.take(l.limits.Global, now, len(records), false); wait > 0 {
+			return &RateLimitedError{RetryAfter: wait}
+		}
+	}
+	if l.limits.PerAuthor.Rate > 0 {
+		if len(l.authors) > maxIdleBuckets {
+			l.dropIdle(now)
+		}
+		for author, n := range counts {
+			b := l.authors[author]
+			if b == nil {
+				b = &bucket{tokens: float64(l.limits.PerAuthor.Burst), last: now}
+				l.authors[author] = b
+			}
+			if wait := b.take(l.limits.PerAuthor, now, n, false); wait > 0 {
+				return &RateLimitedError{Author: author, RetryAfter: wait}
+			}
+		}
+	}
+
+	// Every bucket has room; now take the tokens.
+	if l.limits.Global.Rate > 0 {
+		l.global.take(l.limits.Global, now, len(records), true)
+	}
+	if l.limits.PerAuthor.Rate > 0 {
+		for author, n := range counts {
+			l.authors[author].take(l.limits.PerAuthor, now, n, true)
+		}
+	}
+	return nil
+}
+
+// refundInvalid returns the tokens admitRecords took for records when err
+// shows they were rejected as invalid, so a client fixing a bad request is
+// not also throttled for it.
+func (l *rateLimiter) refundInvalid(err error, records ...model.IntentRecord) {
+	if l == nil || !errors.Is(err, ErrInvalidRecord) {
+		return
+	}
+	counts := make(map[string]int)
+	for _, record := range records {
+		counts[record.Author]++
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limits.Global.Rate > 0 {
+		l.global.refund(l.limits.Global, len(records))
+	}
+	if l.limits.PerAuthor.Rate > 0 {
+		for author, n := range counts {
+			if b := l.authors[author]; b != nil {
+				b.refund(l.limits.PerAuthor, n)
+			}
+		}
+	}
+}
+
+// refund puts n tokens back in b, up to the burst.
+func (b *bucket) refund(limit RateLimit, n int) {
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+float64(n))
+}
+
+// take refills b to now and, if it holds n tokens, removes them when commit
+// is set. It returns how long until n tokens are available, or zero.
+func (b *bucket) take(limit RateLimit, now time.Time, n int, commit bool) time.Duration {
+	if b.last.IsZero() {
+		b.tokens, b.last = float64(limit.Burst), now
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed.Seconds()*limit.Rate)
+		b.last = now
+	}
+	// A batch larger than the bucket is admitted once the bucket is full and
+	// leaves it in debt, so throughput stays bounded by Rate.
+	if need := float64(min(n, limit.Burst)) - b.tokens; need > 0 {
+		return time.Duration(math.Ceil(need / limit.Rate * float64(time.Second)))
+	}
+	if commit {
+		b.tokens -= float64(n)
+	}
+	return 0
+}
+
+// dropIdle forgets per-author buckets that have refilled completely, since a
+// new bucket starts full anyway.
+func (l *rateLimiter) dropIdle(now time.Time) {
+	for author, b := range l.authors {
+		refilled := b.tokens + now.Sub(b.last).Seconds()*l.limits.PerAuthor.Rate
+		if refilled >= float64(l.limits.PerAuthor.Burst) {
+			delete(l.authors, author)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestRateLimitsPerAuthor(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithRateLimits(RateLimits{
+		PerAuthor: RateLimit{Rate: 0.001, Burst: 2},
+	}))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	record := func(author string) model.IntentRecord {
+		return model.IntentRecord{Author: author, SourceType: "cli", Prompt: "p", Response: "r"}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := s.AppendIntent(ctx, record("alice")); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+	_, err = s.AppendIntent(ctx, record("alice"))
+	var limited *RateLimitedError
+	if !errors.As(err, &limited) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected a RateLimitedError, got %v", err)
+	}
+	if limited.Author != "alice" || limited.RetryAfter <= 0 {
+		t.Fatalf("expected alice to retry later, got %+v", limited)
+	}
+	if err := s.CreateIntent(ctx, testIntent(t, 1)); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected CreateIntent to share alice's bucket, got %v", err)
+	}
+	if _, err := s.AppendIntent(ctx, record("bob")); err != nil {
+		t.Fatalf("expected a separate bucket for bob, got %v", err)
+	}
+}
+
+func TestRateLimitsRefundInvalidWrites(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithRateLimits(RateLimits{
+		PerAuthor: RateLimit{Rate: 0.001, Burst: 1},
+	}))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	invalid := testIntent(t, 0)
+	invalid.TombstonedAt = invalid.CreatedAt
+	if err := s.CreateIntent(ctx, invalid); !errors.Is(err, ErrInvalidRecord) {
+		t.Fatalf("expected an invalid record error, got %v", err)
+	}
+	if err := s.CreateIntents(ctx, []model.IntentRecord{invalid}); !errors.Is(err, ErrInvalidRecord) {
+		t.Fatalf("expected an invalid record error, got %v", err)
+	}
+	if err := s.CreateIntent(ctx, testIntent(t, 1)); err != nil {
+		t.Fatalf("expected the rejected writes to be refunded, got %v", err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(RateLimits{
+		Global:    RateLimit{Rate: 10, Burst: 4},
+		PerAuthor: RateLimit{Rate: 1, Burst: 2},
+	})
+	l.now = func() time.Time { return now }
+	alice := model.IntentRecord{Author: "alice"}
+	bob := model.IntentRecord{Author: "bob"}
+
+	if err := l.admitRecords(alice, alice); err != nil {
+		t.Fatalf("admit alice's burst: %v", err)
+	}
+	// Alice is out of tokens, so the batch is rejected without spending
+	// bob's or the global bucket's.
+	var limited *RateLimitedError
+	if err := l.admitRecords(bob, alice); !errors.As(err, &limited) || limited.Author != "alice" || limited.RetryAfter != time.Second {
+		t.Fatalf("expected alice limited for 1s, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := l.admitRecords(bob); err != nil {
+			t.Fatalf("admit bob %d: %v", i, err)
+		}
+	}
+	if err := l.admitRecords(bob); !errors.As(err, &limited) || limited.Author != "" {
+		t.Fatalf("expected the global limit, got %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if err := l.admitRecords(alice); err != nil {
+		t.Fatalf("admit alice after refill: %v", err)
+	}
+
+	// A batch larger than the burst is admitted from a full bucket and leaves
+	// it in debt.
+	now = now.Add(time.Minute)
+	if err := l.admitRecords(bob, bob, bob); err != nil {
+		t.Fatalf("admit oversized batch: %v", err)
+	}
+	if err := l.admitRecords(bob); !errors.As(err, &limited) || limited.Author != "bob" || limited.RetryAfter != 2*time.Second {
+		t.Fatalf("expected bob limited for 2s, got %v", err)
+	}
+}
+
+func TestRateLimiterDropsIdleBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(RateLimits{PerAuthor: RateLimit{Rate: 1, Burst: 1}})
+	l.now = func() time.Time { return now }
+	for i := 0; i <= maxIdleBuckets; i++ {
+		l.authors[string(rune(i))] = &bucket{tokens: 0, last: now}
+	}
+	now = now.Add(time.Second)
+	if err := l.admitRecords(model.IntentRecord{Author: "alice"}); err != nil {
+		t.Fatalf("admit: %v", err)
+	}
+	if len(l.authors) != 1 {
+		t.Fatalf("expected only alice's bucket after dropping idle ones, got %d", len(l.authors))
+	}
+	if newRateLimiter(RateLimits{}) != nil {
+		t.Fatal("expected no limiter without limits")
+	}
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrReadOnly matches any ReadOnlyError via errors.Is.
+var ErrReadOnly = errors.New("store is read-only")
+
+// ReadOnlyError reports a mutating call on a store opened read-only.
+type ReadOnlyError struct {
+	Op string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("%s: store is read-only", e.Op)
+}
+
+// Unwrap lets errors.Is(err, ErrReadOnly) match.
+func (e *ReadOnlyError) Unwrap() error {
+	return ErrReadOnly
+}
+
+// OpenReadOnly opens the database at path with SQLite's mode=ro, so neither
+// this process nor a bug in it can modify the ledger. Mutating methods fail
+// with a *ReadOnlyError before touching the database, and Migrate only checks
+// that the schema is current. The file must already exist.
+func OpenReadOnly(path string, opts ...Option) (*Store, error) {
+	return Open(path, append(opts, WithReadOnly())...)
+}
+
+// ReadOnly reports whether the store was opened read-only.
+func (s *Store) ReadOnly() bool {
+	return s.opts.readOnly
+}
+
+// writable returns a *ReadOnlyError for op if the store is read-only.
+func (s *Store) writable(op string) error {
+	if s.opts.readOnly {
+		return &ReadOnlyError{Op: op}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOpenReadOnlyRejectsMutations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "yanzi.db")
+	rw, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := rw.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	stored, err := rw.AppendIntent(ctx, testIntent(t, 1))
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	rw.Close()
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read db: %v", err)
+	}
+
+	s, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("open read-only: %v", err)
+	}
+	if !s.ReadOnly() {
+		t.Fatal("expected ReadOnly to report true")
+	}
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("expected Migrate to accept a current schema, got %v", err)
+	}
+	if _, err := s.GetIntent(ctx, stored.ID); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if _, err := s.Rehash(ctx, RehashOptions{DryRun: true}); err != nil {
+		t.Fatalf("dry-run rehash: %v", err)
+	}
+
+	var roErr *ReadOnlyError
+	if err := s.CreateIntent(ctx, testIntent(t, 2)); !errors.As(err, &roErr) || roErr.Op != "CreateIntent" {
+		t.Fatalf("expected *ReadOnlyError from CreateIntent, got %v", err)
+	}
+	mutations := map[string]error{}
+	_, mutations["AppendIntent"] = s.AppendIntent(ctx, testIntent(t, 3))
+	_, mutations["PutBlob"] = s.PutBlob(ctx, []byte("blob"))
+	_, mutations["WriteCheckpoint"] = s.WriteCheckpoint(ctx)
+	_, mutations["EraseAuthor"] = s.EraseAuthor(ctx, stored.Author, nil)
+	_, mutations["ApplyRetention"] = s.ApplyRetention(ctx)
+	_, mutations["Rehash"] = s.Rehash(ctx, RehashOptions{})
+	mutations["Rollback"] = s.Rollback(ctx, 1)
+	mutations["RecordVerification"] = s.RecordVerification(ctx, stored.Hash, 1, 0)
+	for name, err := range mutations {
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+	s.Close()
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read db: %v", err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatal("database file changed while open read-only")
+	}
+}
+
+func TestOpenReadOnlyMigrateFailsWhenSchemaIsBehind(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "yanzi.db")
+	rw, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	rw.Close()
+
+	s, err := OpenReadOnly(path, WithMigrationsFS(fstest.MapFS{
+		"0001_create_things.sql": {Data: []byte(`CREATE TABLE things (id INTEGER PRIMARY KEY);`)},
+	}))
+	if err != nil {
+		t.Fatalf("open read-only: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(ctx); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly with migrations pending, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ReconcileReport lists the differences between two stores, keyed by intent ID.
+type ReconcileReport struct {
+	OnlyInA   []string
+	OnlyInB   []string
+	Divergent []Divergence
+}
+
+// Divergence is an ID present in both stores with differing hashes.
+type Divergence struct {
+	ID    string
+	HashA string
+	HashB string
+}
+
+// Empty reports whether the stores hold identical (id, hash) sets.
+func (r ReconcileReport) Empty() bool {
+	return len(r.OnlyInA) == 0 && len(r.OnlyInB) == 0 && len(r.Divergent) == 0
+}
+
+// Reconcile compares two stores by intent ID and hash. Both sides are streamed
+// in ID order and merged, so neither store is loaded into memory.
+func Reconcile(ctx context.Context, a, b *Store) (ReconcileReport, error) {
+	var report ReconcileReport
+	if a == nil || a.db == nil || b == nil || b.db == nil {
+		return report, errors.New("store not initialized")
+	}
+
+	left, err := openIDHashCursor(ctx, a)
+	if err != nil {
+		return report, err
+	}
+	defer left.close()
+	right, err := openIDHashCursor(ctx, b)
+	if err != nil {
+		return report, err
+	}
+	defer right.close()
+
+	for left.ok || right.ok {
+		switch {
+		case !right.ok || (left.ok && left.id < right.id):
+			report.OnlyInA = append(report.OnlyInA, left.id)
+			err = left.next()
+		case !left.ok || right.id < left.id:
+			report.OnlyInB = append(report.OnlyInB, right.id)
+			err = right.next()
+		default:
+			if left.hash != right.hash {
+				report.Divergent = append(report.Divergent, Divergence{ID: left.id, HashA: left.hash, HashB: right.hash})
+			}
+			if err = left.next(); err == nil {
+				err = right.next()
+			}
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// idHashCursor walks (id, hash) pairs of a store in ID order.
+type idHashCursor struct {
+	rows *sql.Rows
+	ok   bool
+	id   string
+	hash string
+}
+
+func openIDHashCursor(ctx context.Context, s *Store) (*idHashCursor, error) {
+	rows, err := s.rdb.QueryContext(ctx, `SELECT id, hash FROM intents ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	c := &idHashCursor{rows: rows}
+	if err := c.next(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *idHashCursor) next() error {
+	c.ok = c.rows.Next()
+	if !c.ok {
+		return c.rows.Err()
+	}
+	return c.rows.Scan(&c.id, &c.hash)
+}
+
+func (c *idHashCursor) close() {
+	_ = c.rows.Close()
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func seedStores(t *testing.T, n int, stores ...*Store) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		record := testIntent(t, i)
+		for _, s := range stores {
+			if err := s.CreateIntent(context.Background(), record); err != nil {
+				t.Fatalf("create intent %d: %v", i, err)
+			}
+		}
+	}
+}
+
+func TestReconcileIdenticalStores(t *testing.T) {
+	a, b := openTestStore(t), openTestStore(t)
+	seedStores(t, 5, a, b)
+
+	report, err := Reconcile(context.Background(), a, b)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if !report.Empty() {
+		t.Fatalf("expected empty report, got %+v", report)
+	}
+}
+
+func TestReconcileMissingInB(t *testing.T) {
+	ctx := context.Background()
+	a, b := openTestStore(t), openTestStore(t)
+	seedStores(t, 3, a, b)
+	extra := testIntent(t, 10)
+	if err := a.CreateIntent(ctx, extra); err != nil {
+		t.Fatalf("create extra intent: %v", err)
+	}
+
+	report, err := Reconcile(ctx, a, b)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(report.OnlyInA) != 1 || report.OnlyInA[0] != extra.ID {
+		t.Fatalf("expected only-in-a [%s], got %+v", extra.ID, report)
+	}
+	if len(report.OnlyInB) != 0 || len(report.Divergent) != 0 {
+		t.Fatalf("unexpected differences: %+v", report)
+	}
+}
+
+func TestReconcileDivergentHash(t *testing.T) {
+	ctx := context.Background()
+	a, b := openTestStore(t), openTestStore(t)
+	seedStores(t, 3, a, b)
+
+	record := testIntent(t, 1)
+	if _, err := b.db.ExecContext(ctx, `UPDATE intents SET hash = 'tampered' WHERE id = ?`, record.ID); err != nil {
+		t.Fatalf("tamper hash: %v", err)
+	}
+
+	report, err := Reconcile(ctx, a, b)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	want := Divergence{ID: record.ID, HashA: record.Hash, HashB: "tampered"}
+	if len(report.Divergent) != 1 || report.Divergent[0] != want {
+		t.Fatalf("expected divergence %+v, got %+v", want, report)
+	}
+}
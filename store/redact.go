@@ -0,0 +1,72 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// RedactFunc masks sensitive content in record, reporting whether it changed
+// anything. (*redact.Redactor).Redact satisfies it.
+type RedactFunc func(record model.IntentRecord) (model.IntentRecord, bool, error)
+
+// WithRedactor applies fn to every record before it is inserted.
+// AppendIntent and CreateIntentStream redact before hashing; CreateIntentStream
+// reads both bodies into memory to do so. Records passed to CreateIntent and
+// the other create methods are already hashed, and rehashing them would break
+// links to the hash the caller computed, so one that fn would change is
+// rejected with an error matching ErrInvalidRecord; redact before hashing.
+func WithRedactor(fn RedactFunc) Option {
+	return func(o *options) {
+		o.redactor = fn
+	}
+}
+
+// redact applies the configured redactor to record. A hashed record that the
+// redactor would change is rejected.
+func (s *Store) redact(record model.IntentRecord, hashed bool) (model.IntentRecord, error) {
+	if s.opts.redactor == nil {
+		return record, nil
+	}
+	redacted, changed, err := s.opts.redactor(record)
+	if err != nil {
+		return record, fmt.Errorf("redact intent %s: %w", record.ID, err)
+	}
+	if !changed {
+		return record, nil
+	}
+	if hashed {
+		return record, &model.InvalidRecordError{Err: fmt.Errorf("intent %s has content the redactor would mask; redact before hashing", record.ID)}
+	}
+	if redacted.Signature != "" {
+		return record, errors.New("redaction would invalidate the intent signature; redact before signing")
+	}
+	return redacted, nil
+}
+
+// redactStream applies the configured redactor to record with the prompt and
+// response bodies read into memory, and returns the redacted record with its
+// bodies moved back into readers.
+func (s *Store) redactStream(record model.IntentRecord, prompt, response io.Reader) (model.IntentRecord, io.Reader, io.Reader, error) {
+	if s.opts.redactor == nil {
+		return record, prompt, response, nil
+	}
+	p, err := io.ReadAll(prompt)
+	if err != nil {
+		return record, nil, nil, fmt.Errorf("read prompt: %w", err)
+	}
+	r, err := io.ReadAll(response)
+	if err != nil {
+		return record, nil, nil, fmt.Errorf("read response: %w", err)
+	}
+	record.Prompt, record.Response = string(p), string(r)
+	if record, err = s.redact(record, false); err != nil {
+		return record, nil, nil, err
+	}
+	prompt, response = strings.NewReader(record.Prompt), strings.NewReader(record.Response)
+	record.Prompt, record.Response = "", ""
+	return record, prompt, response, nil
+}
@@ -0,0 +1,272 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/keys"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/sign"
+)
+
+// RehashOptions selects the target of Rehash.
+type RehashOptions struct {
+	// Version is the hash_version to recompute every record under.
+	Version int
+	// Algorithm switches records to a registered hash algorithm. Empty keeps
+	// each record's current algorithm.
+	Algorithm string
+	// DryRun computes the mapping without modifying the database.
+	DryRun bool
+	// Signers re-sign signed records whose hash changes, each record with
+	// the signer whose public key it carries. A signature no signer matches
+	// no longer covers the record; it is cleared and the record listed in
+	// RehashReport.Unsigned.
+	Signers []keys.Signer
+}
+
+// HashMapping records that an intent's hash changed during a rehash.
+type HashMapping struct {
+	IntentID    string
+	OldHash     string
+	NewHash     string
+	FromVersion int
+	ToVersion   int
+	MigratedAt  string
+}
+
+// RehashReport summarizes a Rehash run.
+type RehashReport struct {
+	// Mappings lists every changed hash, parents before children.
+	Mappings []HashMapping
+	// Unchanged counts records whose hash and prev_hash were already current.
+	Unchanged int
+	// Unsigned lists the IDs of records whose signature was cleared because
+	// no signer in RehashOptions.Signers matched it.
+	Unsigned []string
+}
+
+// Rehash recomputes every stored hash under opts.Version in one transaction.
+// Records are processed parents first so that each prev_hash is rewritten to
+// its parent's new hash before the child is hashed, keeping chains linked.
+// Chain heads and meta revision chains follow the new hashes, signatures are
+// renewed as described for RehashOptions.Signers, and each change is recorded
+// in the hash_migrations table so old hashes (in checkpoints, signatures, or
+// external receipts) stay resolvable with LookupHashMigration; a hash that is
+// migrated again resolves to its latest replacement. Records with keyed hashes
+// cannot be recomputed and make Rehash fail.
+func (s *Store) Rehash(ctx context.Context, opts RehashOptions) (_ RehashReport, err error) {
+	ctx, span := s.startSpan(ctx, "Rehash")
+	defer func() { err = span.end(err) }()
+	defer s.cache.invalidate()
+	if !opts.DryRun {
+		if err := s.writable("Rehash"); err != nil {
+			return RehashReport{}, err
+		}
+	}
+	var report RehashReport
+	if s.db == nil {
+		return report, errors.New("store not initialized")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return report, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+intentColumns+` FROM intents ORDER BY created_at ASC, id ASC`)
+	if err != nil {
+		return report, err
+	}
+	records, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return report, err
+	}
+
+	order, err := parentsFirst(records)
+	if err != nil {
+		return report, err
+	}
+
+	now := s.now().Format(time.RFC3339Nano)
+	renamed := make(map[string]string)
+	for _, record := range order {
+		if hash.IsKeyed(record.Hash) {
+			return report, fmt.Errorf("intent %s has a keyed hash and cannot be rehashed", record.ID)
+		}
+		if record.Tombstoned() {
+			return report, fmt.Errorf("intent %s is tombstoned and cannot be rehashed", record.ID)
+		}
+		oldHash, oldPrev, oldVersion := record.Hash, record.PrevHash, record.HashVersion
+		if newPrev, ok := renamed[record.PrevHash]; ok {
+			record.PrevHash = newPrev
+		}
+		record.HashVersion = opts.Version
+
+		var newHash string
+		if opts.Algorithm != "" {
+			newHash, err = hash.HashIntentWith(record, opts.Algorithm)
+		} else {
+			newHash, err = hash.Recompute(record)
+		}
+		if err != nil {
+			return report, fmt.Errorf("rehash intent %s: %w", record.ID, err)
+		}
+		if newHash == oldHash && record.PrevHash == oldPrev && oldVersion == opts.Version {
+			report.Unchanged++
+			continue
+		}
+
+		renamed[oldHash] = newHash
+		record.Hash = newHash
+		if record.Signature != "" {
+			resigned, ok, err := resign(record, opts.Signers)
+			if err != nil {
+				return report, fmt.Errorf("re-sign intent %s: %w", record.ID, err)
+			}
+			if !ok {
+				report.Unsigned = append(report.Unsigned, record.ID)
+			}
+			record = resigned
+		}
+		mapping := HashMapping{
+			IntentID:    record.ID,
+			OldHash:     oldHash,
+			NewHash:     newHash,
+			FromVersion: oldVersion,
+			ToVersion:   opts.Version,
+			MigratedAt:  now,
+		}
+		report.Mappings = append(report.Mappings, mapping)
+		if opts.DryRun {
+			continue
+		}
+
+		var prevHash, version, signature, publicKey any
+		if record.PrevHash != "" {
+			prevHash = record.PrevHash
+		}
+		if opts.Version != 0 {
+			version = opts.Version
+		}
+		if record.Signature != "" {
+			signature, publicKey = record.Signature, record.PublicKey
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE intents SET prev_hash = ?, hash = ?, hash_version = ?, signature = ?, public_key = ? WHERE id = ?`,
+			prevHash, newHash, version, signature, publicKey, record.ID); err != nil {
+			return report, fmt.Errorf("update intent %s: %w", record.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO hash_migrations (old_hash, new_hash, intent_id, from_version, to_version, migrated_at) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (old_hash) DO UPDATE SET new_hash = excluded.new_hash, intent_id = excluded.intent_id,
+				from_version = excluded.from_version, to_version = excluded.to_version, migrated_at = excluded.migrated_at`,
+			mapping.OldHash, mapping.NewHash, mapping.IntentID, mapping.FromVersion, mapping.ToVersion, mapping.MigratedAt); err != nil {
+			return report, fmt.Errorf("record hash migration for %s: %w", record.ID, err)
+		}
+		if err := relinkRevisionsTx(ctx, tx, record.ID, newHash); err != nil {
+			return report, fmt.Errorf("relink revisions of %s: %w", record.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE chain_heads SET head_hash = ? WHERE head_hash = ?`, newHash, oldHash); err != nil {
+			return report, fmt.Errorf("update chain head for %s: %w", record.ID, err)
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	return report, tx.Commit()
+}
+
+// resign signs record, whose Hash is already current, with the signer whose
+// public key it carries. Without one, it returns record with its signature
+// cleared and false.
+func resign(record model.IntentRecord, signers []keys.Signer) (model.IntentRecord, bool, error) {
+	for _, signer := range signers {
+		if base64.StdEncoding.EncodeToString(signer.Public()) != record.PublicKey {
+			continue
+		}
+		signed, err := sign.SignIntentWith(record, signer)
+		return signed, err == nil, err
+	}
+	record.Signature, record.PublicKey = "", ""
+	return record, false, nil
+}
+
+// relinkRevisionsTx rewrites the meta revision chain of intent id to start at
+// intentHash, rehashing each revision, within tx.
+func relinkRevisionsTx(ctx context.Context, tx *sql.Tx, id, intentHash string) error {
+	rows, err := tx.QueryContext(ctx, `SELECT intent_id, revision, meta, created_at, prev_hash, hash
+		FROM intent_revisions WHERE intent_id = ? ORDER BY revision ASC`, id)
+	if err != nil {
+		return err
+	}
+	revisions, err := scanRevisions(rows)
+	if err != nil {
+		return err
+	}
+	prev := intentHash
+	for _, rev := range revisions {
+		rev.PrevHash = prev
+		if rev.Hash, err = revisionHash(rev); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE intent_revisions SET prev_hash = ?, hash = ? WHERE intent_id = ? AND revision = ?`,
+			rev.PrevHash, rev.Hash, rev.IntentID, rev.Revision); err != nil {
+			return err
+		}
+		prev = rev.Hash
+	}
+	return nil
+}
+
+// LookupHashMigration returns the mapping recorded when oldHash was replaced,
+// or a *NotFoundError if it never was.
+func (s *Store) LookupHashMigration(ctx context.Context, oldHash string) (_ HashMapping, err error) {
+	ctx, span := s.startSpan(ctx, "LookupHashMigration")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return HashMapping{}, errors.New("store not initialized")
+	}
+	var m HashMapping
+	err = s.rdb.QueryRowContext(ctx, `SELECT intent_id, old_hash, new_hash, from_version, to_version, migrated_at
+		FROM hash_migrations WHERE old_hash = ?`, oldHash).
+		Scan(&m.IntentID, &m.OldHash, &m.NewHash, &m.FromVersion, &m.ToVersion, &m.MigratedAt)
+	return m, notFound(err, "hash migration", oldHash)
+}
+
+// parentsFirst orders records so that every record follows the record its
+// prev_hash names. Records whose parent is not stored are roots. It fails if
+// prev_hash links form a cycle.
+func parentsFirst(records []model.IntentRecord) ([]model.IntentRecord, error) {
+	stored := make(map[string]struct{}, len(records))
+	for _, record := range records {
+		stored[record.Hash] = struct{}{}
+	}
+	children := make(map[string][]model.IntentRecord)
+	var queue []model.IntentRecord
+	for _, record := range records {
+		if _, ok := stored[record.PrevHash]; ok && record.PrevHash != "" {
+			children[record.PrevHash] = append(children[record.PrevHash], record)
+			continue
+		}
+		queue = append(queue, record)
+	}
+
+	order := make([]model.IntentRecord, 0, len(records))
+	for len(queue) > 0 {
+		record := queue[0]
+		queue = queue[1:]
+		order = append(order, record)
+		queue = append(queue, children[record.Hash]...)
+		delete(children, record.Hash)
+	}
+	if len(order) != len(records) {
+		return nil, fmt.Errorf("%w: prev_hash links form a cycle", ErrChainBroken)
+	}
+	return order, nil
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/keys"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/sign"
+)
+
+func TestRehashKeepsChainsLinked(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	first := linkedIntent(t, 1, "alice", "")
+	second := linkedIntent(t, 2, "alice", first.Hash)
+	third := linkedIntent(t, 3, "alice", second.Hash)
+	for _, record := range []model.IntentRecord{first, second, third} {
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create %s: %v", record.ID, err)
+		}
+	}
+
+	dry, err := s.Rehash(ctx, RehashOptions{Version: hash.HashVersionJCS, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(dry.Mappings) != 3 {
+		t.Fatalf("expected 3 planned mappings, got %+v", dry)
+	}
+	if got, err := s.GetIntent(ctx, first.ID); err != nil || got.Hash != first.Hash {
+		t.Fatalf("dry run must not modify records: %+v %v", got, err)
+	}
+
+	report, err := s.Rehash(ctx, RehashOptions{Version: hash.HashVersionJCS})
+	if err != nil {
+		t.Fatalf("rehash: %v", err)
+	}
+	if len(report.Mappings) != 3 || report.Mappings[0].OldHash != first.Hash {
+		t.Fatalf("expected parents-first mappings, got %+v", report.Mappings)
+	}
+
+	head, err := s.ChainHead(ctx, "alice")
+	if err != nil {
+		t.Fatalf("chain head: %v", err)
+	}
+	if head != report.Mappings[2].NewHash {
+		t.Fatalf("expected chain head to follow rehash, got %s", head)
+	}
+	verified, err := chain.VerifyChain(ctx, s, head)
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if !verified.Valid() || verified.Length != 3 {
+		t.Fatalf("expected valid rehashed chain, got %+v", verified)
+	}
+
+	mapping, err := s.LookupHashMigration(ctx, second.Hash)
+	if err != nil {
+		t.Fatalf("lookup migration: %v", err)
+	}
+	if mapping.IntentID != second.ID || mapping.ToVersion != hash.HashVersionJCS || mapping.FromVersion != 0 {
+		t.Fatalf("unexpected mapping %+v", mapping)
+	}
+	if _, err := s.LookupHashMigration(ctx, "unknown"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	again, err := s.Rehash(ctx, RehashOptions{Version: hash.HashVersionJCS})
+	if err != nil {
+		t.Fatalf("second rehash: %v", err)
+	}
+	if len(again.Mappings) != 0 || again.Unchanged != 3 {
+		t.Fatalf("expected rehash to be idempotent, got %+v", again)
+	}
+}
+
+func TestRehashSwitchesAlgorithm(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	report, err := s.Rehash(ctx, RehashOptions{Version: hash.HashVersionLegacy, Algorithm: hash.SHA512})
+	if err != nil {
+		t.Fatalf("rehash: %v", err)
+	}
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if name, _ := hash.SplitHash(got.Hash); name != hash.SHA512 || got.Hash != report.Mappings[0].NewHash {
+		t.Fatalf("expected sha512 hash, got %s", got.Hash)
+	}
+	if err := hash.VerifyHash(got); err != nil {
+		t.Fatalf("verify rehashed record: %v", err)
+	}
+}
+
+func TestRehashRenewsSignaturesAndRevisions(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := keys.FromPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signed, err := sign.SignIntentWith(testIntent(t, 1), signer)
+	if err != nil {
+		t.Fatalf("sign intent: %v", err)
+	}
+	foreign, err := sign.SignIntent(testIntent(t, 2), otherPriv)
+	if err != nil {
+		t.Fatalf("sign intent: %v", err)
+	}
+	for _, record := range []model.IntentRecord{signed, foreign} {
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create %s: %v", record.ID, err)
+		}
+	}
+	if _, err := s.UpdateIntentMeta(ctx, signed.ID, []byte(`{"fixed":true}`)); err != nil {
+		t.Fatalf("update meta: %v", err)
+	}
+
+	report, err := s.Rehash(ctx, RehashOptions{Version: hash.HashVersionJCS, Signers: []keys.Signer{signer}})
+	if err != nil {
+		t.Fatalf("rehash: %v", err)
+	}
+	if len(report.Unsigned) != 1 || report.Unsigned[0] != foreign.ID {
+		t.Fatalf("expected only the foreign signature to be cleared, got %v", report.Unsigned)
+	}
+	got, err := s.GetIntent(ctx, signed.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if err := sign.VerifyIntent(got); err != nil {
+		t.Fatalf("expected the record to be re-signed: %v", err)
+	}
+	if got, err := s.GetIntent(ctx, foreign.ID); err != nil || got.Signature != "" || got.PublicKey != "" {
+		t.Fatalf("expected the foreign signature to be cleared, got %+v, %v", got, err)
+	}
+	revisions, err := s.GetIntentRevisions(ctx, signed.ID)
+	if err != nil {
+		t.Fatalf("get revisions: %v", err)
+	}
+	if err := VerifyRevisions(got.Hash, revisions); err != nil {
+		t.Fatalf("expected revisions to follow the new hash: %v", err)
+	}
+
+	// Rehashing back and forth migrates the same old hash twice.
+	for _, version := range []int{hash.HashVersionLegacy, hash.HashVersionJCS} {
+		if _, err := s.Rehash(ctx, RehashOptions{Version: version, Signers: []keys.Signer{signer}}); err != nil {
+			t.Fatalf("rehash to version %d: %v", version, err)
+		}
+	}
+	mapping, err := s.LookupHashMigration(ctx, signed.Hash)
+	if err != nil {
+		t.Fatalf("lookup migration: %v", err)
+	}
+	if current, err := s.GetIntent(ctx, signed.ID); err != nil || mapping.NewHash != current.Hash {
+		t.Fatalf("expected the latest mapping, got %+v for %+v, %v", mapping, current, err)
+	}
+}
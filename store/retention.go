@@ -0,0 +1,219 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// retentionBatchSize bounds how many intents ApplyRetention tombstones per
+// transaction.
+const retentionBatchSize = 100
+
+// RetentionRule expires intents by age or count. An intent is expired when any
+// rule matching its source_type says so.
+type RetentionRule struct {
+	// SourceType limits the rule to one source_type; empty matches all.
+	SourceType string
+	// MaxAge expires intents created longer ago than this; zero disables it.
+	MaxAge time.Duration
+	// MaxCount keeps the content of only the newest MaxCount matching intents;
+	// zero disables it.
+	MaxCount int
+}
+
+// ArchiveFunc receives intents, with their content, just before they are
+// tombstoned. Returning an error stops ApplyRetention before that batch is
+// tombstoned.
+type ArchiveFunc func(ctx context.Context, records []model.IntentRecord) error
+
+// Retention is the policy applied by ApplyRetention.
+type Retention struct {
+	Rules []RetentionRule
+	// Archive, when set, is called with each batch before it is tombstoned.
+	Archive ArchiveFunc
+}
+
+// RetentionReport lists the intents tombstoned by ApplyRetention.
+type RetentionReport struct {
+	Tombstoned []string
+}
+
+// WithRetention sets the policy applied by ApplyRetention.
+func WithRetention(policy Retention) Option {
+	return func(o *options) {
+		o.retention = policy
+	}
+}
+
+// ApplyRetention tombstones every live intent expired under the configured
+// retention policy. Tombstoning clears the title, prompt, response, and meta,
+// drops meta revisions and promoted meta, and deletes attachment blobs no live
+// intent still references; the row keeps its ID, timestamps, hash, and
+// prev_hash, so chains and Merkle roots still verify. Intents are processed
+// oldest first in batches, each in its own transaction.
+func (s *Store) ApplyRetention(ctx context.Context) (_ RetentionReport, err error) {
+	ctx, span := s.startSpan(ctx, "ApplyRetention")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("ApplyRetention"); err != nil {
+		return RetentionReport{}, err
+	}
+	var report RetentionReport
+	if s.db == nil {
+		return report, errors.New("store not initialized")
+	}
+	if len(s.opts.retention.Rules) == 0 {
+		return report, errors.New("no retention rules configured")
+	}
+
+	ids, err := s.expiredIntents(ctx, s.now())
+	if err != nil {
+		return report, err
+	}
+	for start := 0; start < len(ids); start += retentionBatchSize {
+		end := min(start+retentionBatchSize, len(ids))
+		if err := s.tombstoneBatch(ctx, ids[start:end]); err != nil {
+			return report, err
+		}
+		report.Tombstoned = append(report.Tombstoned, ids[start:end]...)
+	}
+	return report, nil
+}
+
+// expiredIntents returns the IDs of live intents expired as of now, oldest first.
+func (s *Store) expiredIntents(ctx context.Context, now time.Time) ([]string, error) {
+	type expired struct{ createdAt, id string }
+	found := make(map[string]expired)
+	collect := func(query string, args ...any) error {
+		rows, err := s.rdb.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var e expired
+			if err := rows.Scan(&e.createdAt, &e.id); err != nil {
+				return err
+			}
+			found[e.id] = e
+		}
+		return rows.Err()
+	}
+
+	for _, rule := range s.opts.retention.Rules {
+		if rule.MaxAge < 0 || rule.MaxCount < 0 {
+			return nil, errors.New("retention limits must not be negative")
+		}
+		if rule.MaxAge > 0 {
+			cutoff := now.Add(-rule.MaxAge).Format(time.RFC3339Nano)
+			if err := collect(`SELECT created_at, id FROM intents
+				WHERE tombstoned_at IS NULL AND (? = '' OR source_type = ?) AND julianday(created_at) < julianday(?)`,
+				rule.SourceType, rule.SourceType, cutoff); err != nil {
+				return nil, fmt.Errorf("find intents older than %s: %w", rule.MaxAge, err)
+			}
+		}
+		if rule.MaxCount > 0 {
+			if err := collect(`SELECT created_at, id FROM (
+					SELECT created_at, id, tombstoned_at FROM intents WHERE (? = '' OR source_type = ?)
+					ORDER BY created_at DESC, id DESC LIMIT -1 OFFSET ?
+				) WHERE tombstoned_at IS NULL`,
+				rule.SourceType, rule.SourceType, rule.MaxCount); err != nil {
+				return nil, fmt.Errorf("find intents beyond the newest %d: %w", rule.MaxCount, err)
+			}
+		}
+	}
+
+	ordered := make([]expired, 0, len(found))
+	for _, e := range found {
+		ordered = append(ordered, e)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].createdAt != ordered[j].createdAt {
+			return ordered[i].createdAt < ordered[j].createdAt
+		}
+		return ordered[i].id < ordered[j].id
+	})
+	ids := make([]string, len(ordered))
+	for i, e := range ordered {
+		ids[i] = e.id
+	}
+	return ids, nil
+}
+
+// tombstoneBatch archives and then tombstones ids in one transaction.
+func (s *Store) tombstoneBatch(ctx context.Context, ids []string) error {
+	defer s.cache.invalidate(ids...)
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		records := make([]model.IntentRecord, 0, len(ids))
+		for _, id := range ids {
+			record, err := s.scanIntent(tx.QueryRowContext(ctx, selectIntentByIDSQL, id))
+			if err != nil {
+				return fmt.Errorf("load intent %s: %w", id, err)
+			}
+			records = append(records, record)
+		}
+		if archive := s.opts.retention.Archive; archive != nil {
+			if err := archive(ctx, records); err != nil {
+				return fmt.Errorf("archive intents: %w", err)
+			}
+		}
+
+		now := s.now().Format(time.RFC3339Nano)
+		for _, record := range records {
+			if err := tombstoneTx(ctx, tx, record, now); err != nil {
+				return fmt.Errorf("tombstone intent %s: %w", record.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// tombstoneTx clears record's content (title, prompt, response, meta,
+// attachments, and the copy kept for idempotent replays) within tx and stores
+// record.Author, which EraseAuthor replaces. It is the only writer of
+// tombstoned_at.
+func tombstoneTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord, now string) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE intents SET author = ?, title = NULL, prompt = '', response = '', meta = NULL,
+		attachments = NULL, prompt_blob = NULL, response_blob = NULL, body_encoding = NULL, tombstoned_at = ? WHERE id = ?`,
+		record.Author, now, record.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM intent_revisions WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM intent_promoted_meta WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM embeddings WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM intent_fingerprints WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE idempotency_keys SET result = NULL, result_encoding = NULL WHERE intent_id = ?`, record.ID); err != nil {
+		return err
+	}
+	for _, a := range record.Attachments {
+		var referenced int
+		err := tx.QueryRowContext(ctx, `SELECT 1 FROM intents i, json_each(i.attachments) a
+			WHERE i.tombstoned_at IS NULL AND json_extract(a.value, '$.digest') = ? LIMIT 1`, a.Digest).Scan(&referenced)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM blob_chunks WHERE digest = ?`, a.Digest); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM blobs WHERE digest = ?`, a.Digest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/chain"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestApplyRetention(t *testing.T) {
+	ctx := context.Background()
+	var archived []model.IntentRecord
+	policy := Retention{
+		Rules: []RetentionRule{
+			{MaxAge: 90 * 24 * time.Hour},
+			{SourceType: "chat", MaxCount: 1},
+		},
+		Archive: func(_ context.Context, records []model.IntentRecord) error {
+			archived = append(archived, records...)
+			return nil
+		},
+	}
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithRetention(policy))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	now := time.Now().UTC()
+	ages := []struct {
+		age        time.Duration
+		sourceType string
+	}{
+		{100 * 24 * time.Hour, "cli"},
+		{10 * 24 * time.Hour, "cli"},
+		{5 * 24 * time.Hour, "chat"},
+		{time.Hour, "chat"},
+	}
+	var stored []model.IntentRecord
+	for i, a := range ages {
+		record, err := s.AppendIntent(ctx, model.IntentRecord{
+			ID:         fmt.Sprintf("intent-%06d", i),
+			CreatedAt:  now.Add(-a.age).Format(time.RFC3339Nano),
+			Author:     "alice",
+			SourceType: a.sourceType,
+			Prompt:     fmt.Sprintf("prompt %d", i),
+			Response:   fmt.Sprintf("response %d", i),
+			Meta:       []byte(`{"secret":"x"}`),
+		})
+		if err != nil {
+			t.Fatalf("append intent %d: %v", i, err)
+		}
+		stored = append(stored, record)
+	}
+
+	report, err := s.ApplyRetention(ctx)
+	if err != nil {
+		t.Fatalf("apply retention: %v", err)
+	}
+	if len(report.Tombstoned) != 2 || report.Tombstoned[0] != stored[0].ID || report.Tombstoned[1] != stored[2].ID {
+		t.Fatalf("expected the old cli and older chat intents to expire, got %v", report.Tombstoned)
+	}
+	if len(archived) != 2 || archived[0].Prompt != "prompt 0" {
+		t.Fatalf("expected archived records with content, got %+v", archived)
+	}
+
+	got, err := s.GetIntent(ctx, stored[0].ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if !got.Tombstoned() || got.Prompt != "" || got.Response != "" || got.Meta != nil || got.Hash != stored[0].Hash {
+		t.Fatalf("expected tombstone keeping the hash, got %+v", got)
+	}
+	if err := got.Validate(); err != nil {
+		t.Fatalf("expected tombstone to validate: %v", err)
+	}
+
+	verified, err := chain.VerifyChain(ctx, s, stored[len(stored)-1].Hash)
+	if err != nil {
+		t.Fatalf("verify chain: %v", err)
+	}
+	if !verified.Valid() || verified.Length != len(stored) {
+		t.Fatalf("expected chain to verify across tombstones, got %+v", verified)
+	}
+
+	again, err := s.ApplyRetention(ctx)
+	if err != nil {
+		t.Fatalf("reapply retention: %v", err)
+	}
+	if len(again.Tombstoned) != 0 {
+		t.Fatalf("expected no further expiries, got %v", again.Tombstoned)
+	}
+}
+
+func TestApplyRetentionDeletesOrphanedBlobs(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithRetention(Retention{Rules: []RetentionRule{{MaxCount: 1}}}))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	shared, err := s.PutBlob(ctx, []byte("shared"))
+	if err != nil {
+		t.Fatalf("put blob: %v", err)
+	}
+	only, err := s.PutBlob(ctx, []byte("only"))
+	if err != nil {
+		t.Fatalf("put blob: %v", err)
+	}
+	attachments := [][]model.Attachment{
+		{{Digest: shared, Size: 6}, {Digest: only, Size: 4}},
+		{{Digest: shared, Size: 6}},
+	}
+	for i, a := range attachments {
+		if _, err := s.AppendIntent(ctx, model.IntentRecord{
+			ID:          fmt.Sprintf("intent-%06d", i),
+			CreatedAt:   time.Date(2026, 2, 9, 10, 0, i, 0, time.UTC).Format(time.RFC3339Nano),
+			Author:      "alice",
+			SourceType:  "cli",
+			Prompt:      "p",
+			Response:    "r",
+			Attachments: a,
+		}); err != nil {
+			t.Fatalf("append intent %d: %v", i, err)
+		}
+	}
+
+	if _, err := s.ApplyRetention(ctx); err != nil {
+		t.Fatalf("apply retention: %v", err)
+	}
+	if _, err := s.GetBlob(ctx, shared); err != nil {
+		t.Fatalf("expected blob still referenced by a live intent to remain: %v", err)
+	}
+	if _, err := s.GetBlob(ctx, only); err == nil {
+		t.Fatalf("expected orphaned blob to be deleted")
+	}
+}
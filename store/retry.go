@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Retry controls how writes that fail with SQLITE_BUSY or SQLITE_LOCKED are
+// retried. Each retry waits a random duration between half and all of the
+// current backoff, which starts at InitialBackoff and doubles up to
+// MaxBackoff.
+type Retry struct {
+	// MaxAttempts bounds how many times a write is tried, the first included;
+	// values <= 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry; values <= 0 select
+	// DefaultRetry's.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries; values <= 0 leave it
+	// uncapped.
+	MaxBackoff time.Duration
+	// Budget bounds the total time spent waiting between retries; values
+	// <= 0 leave it unbounded.
+	Budget time.Duration
+}
+
+// DefaultRetry is a reasonable Retry for a database shared with other
+// processes.
+var DefaultRetry = Retry{
+	MaxAttempts:    5,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     500 * time.Millisecond,
+	Budget:         2 * time.Second,
+}
+
+// WithRetry retries CreateIntent, CreateIntents, CreateIntentIdempotent,
+// AppendIntent, TombstoneIntent, UpdateIntentMeta, PutBlob, and
+// WriteCheckpoint when the database is busy or locked, as described by r.
+// Each attempt runs in a fresh transaction, so a failed one leaves nothing
+// behind. The busy timeout still applies within each attempt; retries cover
+// the cases SQLite does not wait on, such as a write transaction whose
+// snapshot went stale. Without it a busy write fails at once.
+func WithRetry(r Retry) Option {
+	return func(o *options) {
+		if r.InitialBackoff <= 0 {
+			r.InitialBackoff = DefaultRetry.InitialBackoff
+		}
+		r.MaxBackoff = max(r.MaxBackoff, 0)
+		r.Budget = max(r.Budget, 0)
+		o.retry = r
+	}
+}
+
+// isBusy reports whether err is SQLITE_BUSY or SQLITE_LOCKED, including
+// their extended codes.
+func isBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
+
+// retryBusy runs fn, retrying it for op under the configured Retry while it
+// fails with a busy or locked database.
+func (s *Store) retryBusy(ctx context.Context, op string, fn func() error) error {
+	r := s.opts.retry
+	backoff := r.InitialBackoff
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.MaxAttempts || !isBusy(err) {
+			return err
+		}
+		wait := backoff/2 + rand.N(backoff/2+1)
+		if r.Budget > 0 && waited+wait > r.Budget {
+			return err
+		}
+		s.logger().Debug("database busy, retrying write", "operation", op, "attempt", attempt, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		waited += wait
+		if backoff *= 2; backoff <= 0 {
+			backoff = math.MaxInt64 // doubled past the largest Duration
+		}
+		if r.MaxBackoff > 0 && backoff > r.MaxBackoff {
+			backoff = r.MaxBackoff
+		}
+	}
+}
+
+// withRetryTx is withTx retried with retryBusy. fn must leave no trace
+// outside tx, since a failed attempt is rolled back and run again.
+func (s *Store) withRetryTx(ctx context.Context, op string, fn func(tx *sql.Tx) error) error {
+	return s.retryBusy(ctx, op, func() error {
+		return s.withTx(ctx, fn)
+	})
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRetryBusyWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "yanzi.db")
+	open := func(opts ...Option) *Store {
+		t.Helper()
+		s, err := Open(path, append([]Option{WithBusyTimeout(0)}, opts...)...)
+		if err != nil {
+			t.Fatalf("open store: %v", err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	}
+	holder := open()
+	if err := holder.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	plain := open()
+	retrying := open(WithRetry(Retry{MaxAttempts: 100, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}))
+
+	tx, err := holder.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM intents`); err != nil {
+		t.Fatalf("take write lock: %v", err)
+	}
+
+	if err := plain.CreateIntent(ctx, testIntent(t, 1)); !isBusy(err) {
+		_ = tx.Rollback()
+		t.Fatalf("expected a busy error without retries, got %v", err)
+	}
+
+	release := time.AfterFunc(50*time.Millisecond, func() { _ = tx.Commit() })
+	defer release.Stop()
+	if err := retrying.CreateIntent(ctx, testIntent(t, 2)); err != nil {
+		t.Fatalf("expected the write to succeed once the lock was released: %v", err)
+	}
+}
+
+func TestWithRetryClampsBackoff(t *testing.T) {
+	var o options
+	WithRetry(Retry{MaxAttempts: 3, InitialBackoff: -time.Second, MaxBackoff: -time.Second, Budget: -time.Second})(&o)
+	if o.retry.InitialBackoff != DefaultRetry.InitialBackoff || o.retry.MaxBackoff != 0 || o.retry.Budget != 0 {
+		t.Fatalf("expected negative durations to be clamped, got %+v", o.retry)
+	}
+}
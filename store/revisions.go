@@ -0,0 +1,219 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// IntentRevision is a correction to an intent's meta. The intent row itself is
+// never modified, so its hash and chain links stay valid; revisions form their
+// own hash chain starting at the intent's hash, which Rehash relinks when that
+// hash changes.
+type IntentRevision struct {
+	IntentID string
+	// Revision numbers start at 1 for the first correction.
+	Revision  int
+	Meta      json.RawMessage
+	CreatedAt string
+	// PrevHash is the previous revision's Hash, or the intent's hash for
+	// revision 1.
+	PrevHash string
+	Hash     string
+}
+
+// revisionHash returns the SHA-256 of the JCS encoding of rev without Hash.
+func revisionHash(rev IntentRevision) (string, error) {
+	obj := map[string]any{
+		"intent_id":  rev.IntentID,
+		"revision":   rev.Revision,
+		"created_at": rev.CreatedAt,
+		"prev_hash":  rev.PrevHash,
+	}
+	if len(rev.Meta) > 0 {
+		obj["meta"] = rev.Meta
+	}
+	encoded, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := hash.CanonicalizeJCS(encoded)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// UpdateIntentMeta records meta as a new revision of intent id and returns it.
+// The meta is canonicalized and checked against the intent's meta schema;
+// empty meta records a revision that clears it. It returns a *NotFoundError
+// if the intent does not exist.
+func (s *Store) UpdateIntentMeta(ctx context.Context, id string, meta json.RawMessage) (_ IntentRevision, err error) {
+	ctx, span := s.startSpan(ctx, "UpdateIntentMeta")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("UpdateIntentMeta"); err != nil {
+		return IntentRevision{}, err
+	}
+	var canonical json.RawMessage
+	if !model.IsEmptyMeta(meta) {
+		var err error
+		if canonical, err = hash.CanonicalizeMeta(meta); err != nil {
+			return IntentRevision{}, err
+		}
+	}
+
+	var rev IntentRevision
+	err = s.withRetryTx(ctx, "UpdateIntentMeta", func(tx *sql.Tx) error {
+		record, err := s.scanIntent(tx.QueryRowContext(ctx, selectIntentByIDSQL, id))
+		if err != nil {
+			return notFound(err, "intent", id)
+		}
+		record.Meta = canonical
+		if err := record.ValidateMetaSchema(); err != nil {
+			return err
+		}
+
+		rev = IntentRevision{IntentID: id, Revision: 1, Meta: canonical, PrevHash: record.Hash}
+		var lastRevision int
+		var lastHash string
+		err = tx.QueryRowContext(ctx, `SELECT revision, hash FROM intent_revisions WHERE intent_id = ? ORDER BY revision DESC LIMIT 1`, id).Scan(&lastRevision, &lastHash)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return err
+		default:
+			rev.Revision = lastRevision + 1
+			rev.PrevHash = lastHash
+		}
+		rev.CreatedAt = s.now().Format(time.RFC3339Nano)
+		if rev.Hash, err = revisionHash(rev); err != nil {
+			return fmt.Errorf("hash revision: %w", err)
+		}
+
+		var metaArg any
+		if len(canonical) > 0 {
+			metaArg = string(canonical)
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO intent_revisions (intent_id, revision, meta, created_at, prev_hash, hash) VALUES (?, ?, ?, ?, ?, ?)`,
+			rev.IntentID, rev.Revision, metaArg, rev.CreatedAt, rev.PrevHash, rev.Hash)
+		return err
+	})
+	if err != nil {
+		return IntentRevision{}, err
+	}
+	return rev, nil
+}
+
+// GetIntentRevisions returns the revisions of intent id, oldest first. An
+// intent without corrections has none.
+func (s *Store) GetIntentRevisions(ctx context.Context, id string) (_ []IntentRevision, err error) {
+	ctx, span := s.startSpan(ctx, "GetIntentRevisions")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT intent_id, revision, meta, created_at, prev_hash, hash
+		FROM intent_revisions WHERE intent_id = ? ORDER BY revision ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanRevisions(rows)
+}
+
+// scanRevisions reads and closes rows of intent_revisions columns.
+func scanRevisions(rows *sql.Rows) ([]IntentRevision, error) {
+	defer rows.Close()
+	var revisions []IntentRevision
+	for rows.Next() {
+		var rev IntentRevision
+		var meta sql.NullString
+		if err := rows.Scan(&rev.IntentID, &rev.Revision, &meta, &rev.CreatedAt, &rev.PrevHash, &rev.Hash); err != nil {
+			return nil, err
+		}
+		if meta.Valid {
+			rev.Meta = json.RawMessage(meta.String)
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// VerifyIntentRevisions checks the revisions of intent id with
+// VerifyRevisions. Rehash relinks revisions to the new hash, but revisions of
+// a store rehashed before it did still start at an old hash; they are
+// accepted when hash_migrations maps that hash to the intent's current one.
+func (s *Store) VerifyIntentRevisions(ctx context.Context, id string) (err error) {
+	ctx, span := s.startSpan(ctx, "VerifyIntentRevisions")
+	defer func() { err = span.end(err) }()
+	record, err := s.GetIntent(ctx, id)
+	if err != nil {
+		return err
+	}
+	revisions, err := s.GetIntentRevisions(ctx, id)
+	if err != nil || len(revisions) == 0 {
+		return err
+	}
+	base := record.Hash
+	if first := revisions[0].PrevHash; first != base {
+		migrated, err := s.migratesTo(ctx, first, base)
+		if err != nil {
+			return err
+		}
+		if migrated {
+			base = first
+		}
+	}
+	return VerifyRevisions(base, revisions)
+}
+
+// migratesTo reports whether following hash_migrations from old reaches
+// current.
+func (s *Store) migratesTo(ctx context.Context, old, current string) (bool, error) {
+	seen := map[string]struct{}{old: {}}
+	for h := old; h != current; {
+		err := s.rdb.QueryRowContext(ctx, `SELECT new_hash FROM hash_migrations WHERE old_hash = ?`, h).Scan(&h)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if _, ok := seen[h]; ok && h != current {
+			return false, nil
+		}
+		seen[h] = struct{}{}
+	}
+	return true, nil
+}
+
+// VerifyRevisions checks that revisions (as returned by GetIntentRevisions)
+// form an unbroken hash chain from intentHash.
+func VerifyRevisions(intentHash string, revisions []IntentRevision) error {
+	prev := intentHash
+	for i, rev := range revisions {
+		if rev.Revision != i+1 {
+			return fmt.Errorf("revision %d out of sequence at position %d", rev.Revision, i)
+		}
+		if rev.PrevHash != prev {
+			return fmt.Errorf("%w: revision %d does not link to its predecessor", ErrChainBroken, rev.Revision)
+		}
+		sum, err := revisionHash(rev)
+		if err != nil {
+			return err
+		}
+		if sum != rev.Hash {
+			return fmt.Errorf("%w: revision %d", ErrHashMismatch, rev.Revision)
+		}
+		prev = rev.Hash
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestUpdateIntentMetaAppendsRevisions(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	first, err := s.UpdateIntentMeta(ctx, record.ID, json.RawMessage(`{"owner":"bob", "reason":"misattributed"}`))
+	if err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if first.Revision != 1 || first.PrevHash != record.Hash || string(first.Meta) != `{"owner":"bob","reason":"misattributed"}` {
+		t.Fatalf("unexpected first revision %+v", first)
+	}
+	second, err := s.UpdateIntentMeta(ctx, record.ID, json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("second update: %v", err)
+	}
+	if second.Revision != 2 || second.PrevHash != first.Hash || second.Meta != nil {
+		t.Fatalf("unexpected second revision %+v", second)
+	}
+
+	stored, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if stored.Hash != record.Hash || string(stored.Meta) != string(record.Meta) {
+		t.Fatalf("expected original record to stay unchanged, got %+v", stored)
+	}
+
+	revisions, err := s.GetIntentRevisions(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get revisions: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %d", len(revisions))
+	}
+	if err := VerifyRevisions(record.Hash, revisions); err != nil {
+		t.Fatalf("verify revisions: %v", err)
+	}
+	revisions[0].Meta = json.RawMessage(`{"owner":"mallory"}`)
+	if err := VerifyRevisions(record.Hash, revisions); err == nil {
+		t.Fatalf("expected altered revision to fail verification")
+	}
+
+	if _, err := s.UpdateIntentMeta(ctx, "missing", json.RawMessage(`{"a":1}`)); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for missing intent, got %v", err)
+	}
+	if _, err := s.UpdateIntentMeta(ctx, record.ID, json.RawMessage(`[1]`)); err == nil {
+		t.Fatalf("expected non-object meta to be rejected")
+	}
+}
+
+func TestVerifyIntentRevisionsFollowsHashMigrations(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	if _, err := s.UpdateIntentMeta(ctx, record.ID, json.RawMessage(`{"owner":"bob"}`)); err != nil {
+		t.Fatalf("update meta: %v", err)
+	}
+	if err := s.VerifyIntentRevisions(ctx, record.ID); err != nil {
+		t.Fatalf("verify revisions: %v", err)
+	}
+
+	// A rehash from before revisions were relinked left them on the old hash.
+	if _, err := s.db.ExecContext(ctx, `UPDATE intents SET hash = 'sha256:new' WHERE id = ?`, record.ID); err != nil {
+		t.Fatalf("rewrite hash: %v", err)
+	}
+	s.cache.invalidate(record.ID)
+	if err := s.VerifyIntentRevisions(ctx, record.ID); !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("expected an unmapped old hash to break the chain, got %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO hash_migrations (old_hash, new_hash, intent_id, from_version, to_version, migrated_at)
+		VALUES (?, 'sha256:new', ?, 0, 2, '2026-01-01T00:00:00Z')`, record.Hash, record.ID); err != nil {
+		t.Fatalf("record migration: %v", err)
+	}
+	if err := s.VerifyIntentRevisions(ctx, record.ID); err != nil {
+		t.Fatalf("expected the migrated hash to resolve: %v", err)
+	}
+}
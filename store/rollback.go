@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Rollback reverts the most recently applied steps migrations using their
+// paired .down.sql files from the embedded schema (or WithMigrationsFS).
+func (s *Store) Rollback(ctx context.Context, steps int) (err error) {
+	ctx, span := s.startSpan(ctx, "Rollback")
+	defer func() { err = span.end(err) }()
+	return s.RollbackWithOptions(ctx, steps, MigrateOptions{})
+}
+
+// RollbackWithOptions reverts the most recently applied steps migrations, newest
+// first, each in its own transaction. Every down file is located before any is
+// run, so a missing rollback script leaves the database untouched.
+func (s *Store) RollbackWithOptions(ctx context.Context, steps int, opts MigrateOptions) (err error) {
+	ctx, span := s.startSpan(ctx, "RollbackWithOptions")
+	defer func() { err = span.end(err) }()
+	defer s.cache.invalidate()
+	if err := s.writable("RollbackWithOptions"); err != nil {
+		return err
+	}
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	if steps <= 0 {
+		return errors.New("rollback steps must be positive")
+	}
+	fsys, dir := s.resolveMigrations(opts)
+
+	if _, err := s.db.ExecContext(ctx, schemaMigrationsTable); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	versions, err := s.latestMigrations(ctx, steps)
+	if err != nil {
+		return err
+	}
+	if len(versions) < steps {
+		return fmt.Errorf("cannot roll back %d migrations: only %d applied", steps, len(versions))
+	}
+
+	scripts := make([][]byte, len(versions))
+	for i, version := range versions {
+		downName := strings.TrimSuffix(version, ".sql") + downMigrationSuffix
+		contents, err := fs.ReadFile(fsys, path.Join(dir, downName))
+		if err != nil {
+			return fmt.Errorf("read down migration for %s: %w", version, err)
+		}
+		scripts[i] = contents
+	}
+
+	for i, version := range versions {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin rollback %s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, string(scripts[i])); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("roll back migration %s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, version); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("unrecord migration %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit rollback %s: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// latestMigrations returns up to n applied migration versions, newest first.
+func (s *Store) latestMigrations(ctx context.Context, n int) ([]string, error) {
+	rows, err := s.rdb.QueryContext(ctx, `SELECT version FROM schema_migrations ORDER BY version DESC LIMIT ?`, n)
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []string
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
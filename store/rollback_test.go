@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tableExists(t *testing.T, s *Store, name string) bool {
+	t.Helper()
+	var count int
+	if err := s.db.QueryRowContext(context.Background(), `SELECT COUNT(1) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count); err != nil {
+		t.Fatalf("query schema: %v", err)
+	}
+	return count > 0
+}
+
+func TestRollbackRevertsLatestMigrations(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	applied, err := s.latestMigrations(ctx, 1000)
+	if err != nil {
+		t.Fatalf("list applied migrations: %v", err)
+	}
+	if err := s.Rollback(ctx, len(applied)-1); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if tableExists(t, s, "intent_changelog") {
+		t.Fatalf("expected migrations after the first rolled back")
+	}
+	if !tableExists(t, s, "intents") {
+		t.Fatalf("expected intents table to remain")
+	}
+	remaining, err := s.latestMigrations(ctx, 1000)
+	if err != nil {
+		t.Fatalf("list remaining migrations: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0] != "0001_create_intents.sql" {
+		t.Fatalf("expected only the first migration recorded, got %v", remaining)
+	}
+
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("re-migrate: %v", err)
+	}
+	if !tableExists(t, s, "intent_changelog") {
+		t.Fatalf("expected changelog restored after re-migrate")
+	}
+}
+
+func TestRollbackMissingDownFileLeavesSchema(t *testing.T) {
+	ctx := context.Background()
+	s := openUnmigratedStore(t)
+	dir := t.TempDir()
+	files := map[string]string{
+		"0001_a.sql":      `CREATE TABLE a (id TEXT);`,
+		"0001_a.down.sql": `DROP TABLE a;`,
+		"0002_b.sql":      `CREATE TABLE b (id TEXT);`,
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	opts := MigrateOptions{FS: os.DirFS(dir)}
+	if err := s.MigrateWithOptions(ctx, opts); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	err := s.RollbackWithOptions(ctx, 2, opts)
+	if err == nil || !strings.Contains(err.Error(), "0002_b.sql") {
+		t.Fatalf("expected missing down migration error, got %v", err)
+	}
+	if !tableExists(t, s, "a") || !tableExists(t, s, "b") {
+		t.Fatalf("expected schema untouched after failed rollback")
+	}
+
+	if err := s.RollbackWithOptions(ctx, 5, opts); err == nil {
+		t.Fatalf("expected error rolling back more steps than applied")
+	}
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// Snapshot writes a consistent, compacted copy of the database to path, which
+// must not already exist. It runs on a read connection, so writers carry on
+// while it copies; the snapshot reflects the moment it started.
+func (s *Store) Snapshot(ctx context.Context, path string) (err error) {
+	ctx, span := s.startSpan(ctx, "Snapshot")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	if path == "" {
+		return errors.New("snapshot path is required")
+	}
+	if s.rdb == s.db {
+		_, err = s.db.ExecContext(ctx, `VACUUM INTO ?`, path)
+		return err
+	}
+
+	// Readers are query_only, which VACUUM INTO counts as writing even though
+	// it only writes the new file. Lift it on one connection for the copy.
+	conn, err := s.rdb.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `PRAGMA query_only=0`); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `VACUUM INTO ?`, path)
+	if _, restoreErr := conn.ExecContext(context.WithoutCancel(ctx), `PRAGMA query_only=1`); restoreErr != nil {
+		// Never hand a writable connection back to the read pool.
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		return errors.Join(err, fmt.Errorf("restore query_only: %w", restoreErr))
+	}
+	if err != nil {
+		return fmt.Errorf("vacuum into %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotCopiesDatabase(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	stored, err := s.AppendIntent(ctx, testIntent(t, 1))
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := s.Snapshot(ctx, path); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if err := s.Snapshot(ctx, path); err == nil {
+		t.Fatal("expected error snapshotting over an existing file")
+	}
+	// The reader used for the copy must be query_only again.
+	for range 3 {
+		if _, err := s.rdb.ExecContext(ctx, `DELETE FROM intents`); err == nil {
+			t.Fatal("expected the read pool to stay query-only")
+		}
+	}
+
+	copied, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	defer copied.Close()
+	got, err := copied.GetIntent(ctx, stored.ID)
+	if err != nil || got.Hash != stored.Hash {
+		t.Fatalf("expected the intent in the snapshot, got %+v (%v)", got, err)
+	}
+	if err := copied.Snapshot(ctx, filepath.Join(t.TempDir(), "again.db")); err != nil {
+		t.Fatalf("snapshot of a read-only store: %v", err)
+	}
+}
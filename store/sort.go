@@ -0,0 +1,84 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// SortField names a column listing APIs can order by.
+type SortField string
+
+const (
+	SortCreatedAt  SortField = "created_at"
+	SortAuthor     SortField = "author"
+	SortSourceType SortField = "source_type"
+)
+
+// SortDirection is ascending or descending; empty selects the API's default.
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// Sort orders listing results. Ties are always broken by ID in the same
+// direction, so ordering is stable across calls and pages. The zero value sorts
+// by created_at in the calling API's default direction.
+type Sort struct {
+	Field     SortField
+	Direction SortDirection
+}
+
+// resolve validates the sort and fills defaults.
+func (o Sort) resolve(defaultDirection SortDirection) (Sort, error) {
+	if o.Field == "" {
+		o.Field = SortCreatedAt
+	}
+	switch o.Field {
+	case SortCreatedAt, SortAuthor, SortSourceType:
+	default:
+		return o, fmt.Errorf("unsupported sort field %q", o.Field)
+	}
+	if o.Direction == "" {
+		o.Direction = defaultDirection
+	}
+	switch o.Direction {
+	case SortAsc, SortDesc:
+	default:
+		return o, fmt.Errorf("unsupported sort direction %q", o.Direction)
+	}
+	return o, nil
+}
+
+// orderBy returns the ORDER BY clause for a resolved sort.
+func (o Sort) orderBy() string {
+	dir := " ASC"
+	if o.Direction == SortDesc {
+		dir = " DESC"
+	}
+	return string(o.Field) + dir + ", id" + dir
+}
+
+// after returns a keyset condition selecting rows past (value, id) in sort order.
+func (o Sort) after() string {
+	cmp := ">"
+	if o.Direction == SortDesc {
+		cmp = "<"
+	}
+	col := string(o.Field)
+	return "(" + col + " " + cmp + " ? OR (" + col + " = ? AND id " + cmp + " ?))"
+}
+
+// value returns the record's value for the sort field.
+func (o Sort) value(record model.IntentRecord) string {
+	switch o.Field {
+	case SortAuthor:
+		return record.Author
+	case SortSourceType:
+		return record.SourceType
+	default:
+		return record.CreatedAt
+	}
+}
@@ -1,15 +1,19 @@
-// Package store provides a SQLite persistence layer.
+// Package store provides intent persistence behind the Backend interface, with a SQLite implementation.
 package store
 
 import (
 	"context"
 	"database/sql"
+	"embed"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"sort"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/chuxorg/chux-yanzi-core/model"
@@ -24,102 +28,250 @@ CREATE TABLE IF NOT EXISTS schema_migrations (
 `
 
 type Store struct {
-	db *sql.DB
+	// db is the single writer connection; every write and transaction runs
+	// on it, so the store's own writers never contend for SQLite's lock.
+	// rdb is a pool of query-only connections for reads, which in WAL mode
+	// proceed alongside the writer. Read-only and in-memory stores use one
+	// pool for both.
+	db   *sql.DB
+	rdb  *sql.DB
+	opts options
+
+	stmtMu      sync.Mutex
+	stmts       map[string]*sql.Stmt
+	readStmts   map[string]*sql.Stmt
+	queuedStmts map[string]struct{}
+	closed      bool
+
+	appendMu sync.Mutex
+	limiter  *rateLimiter
+
+	codec bodyCodec
+	cache *intentCache
 }
 
-func Open(path string) (*Store, error) {
+// Open opens the SQLite database at path and applies the connection pragmas
+// configured by opts to every pooled connection.
+func Open(path string, opts ...Option) (*Store, error) {
 	if strings.TrimSpace(path) == "" {
 		return nil, errors.New("sqlite path is required")
 	}
 
-	db, err := sql.Open("sqlite", path)
+	cfg := defaultOptions()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	codec, err := newBodyCodec(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
-		_ = db.Close()
+	dsn, err := cfg.dsn(path)
+	if err != nil {
 		return nil, err
 	}
-
-	if _, err := db.Exec(`PRAGMA journal_mode=WAL;`); err != nil {
-		_ = db.Close()
+	// A read-only store needs no writer, and separate in-memory connections
+	// would each see their own database, so both use a single pool.
+	memory := inMemory(path)
+	shared := cfg.readOnly || memory
+	maxWriters := 1
+	if cfg.readOnly && !memory {
+		maxWriters = cfg.maxOpenConns
+	}
+	db, err := openPool(dsn, maxWriters)
+	if err != nil {
 		return nil, err
 	}
-	if _, err := db.Exec(`PRAGMA foreign_keys=ON;`); err != nil {
-		_ = db.Close()
+
+	// The journal mode is stored in the database file, so setting it once
+	// covers every connection.
+	if !cfg.readOnly {
+		if _, err := db.Exec(`PRAGMA journal_mode=WAL;`); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	rdb := db
+	if !shared {
+		rdb, err = openPool(appendQuery(dsn, url.Values{"_pragma": {"query_only(1)"}}), cfg.maxOpenConns)
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	limiter := newRateLimiter(cfg.rateLimits)
+	if limiter != nil {
+		limiter.now = cfg.clock.Now
+	}
+	return &Store{db: db, rdb: rdb, opts: cfg, codec: codec, limiter: limiter, cache: newIntentCache(cfg.cacheSize)}, nil
+}
+
+// openPool opens and pings a connection pool for dsn, limited to max
+// connections if max > 0.
+func openPool(dsn string, max int) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
 		return nil, err
 	}
-	if _, err := db.Exec(`PRAGMA busy_timeout=5000;`); err != nil {
+	if max > 0 {
+		db.SetMaxOpenConns(max)
+	}
+	if err := db.Ping(); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
+	return db, nil
+}
 
-	return &Store{db: db}, nil
+// inMemory reports whether path names an in-memory database.
+func inMemory(path string) bool {
+	return path == ":memory:" || strings.HasPrefix(path, "file::memory:") || strings.Contains(path, "mode=memory")
 }
 
 func (s *Store) Close() error {
 	if s.db == nil {
 		return nil
 	}
-	return s.db.Close()
+	stmtErr := s.closeStatements()
+	var readErr error
+	if s.rdb != nil && s.rdb != s.db {
+		readErr = s.rdb.Close()
+	}
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return stmtErr
+}
+
+// embeddedMigrations holds the canonical schema shipped with the package.
+//
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// MigrateOptions controls how Migrate discovers migration files.
+type MigrateOptions struct {
+	// FS holds the migration files. It defaults to the migrations embedded in
+	// this package (or the FS passed to WithMigrationsFS), so Migrate does not
+	// depend on the process working directory.
+	FS fs.FS
+	// Dir is the directory within FS holding migration files. It defaults to
+	// "migrations" for the embedded FS and "." for a caller-supplied FS.
+	Dir string
+	// AllowEmpty makes a missing or empty migration directory a successful no-op
+	// instead of an error.
+	AllowEmpty bool
+	// DryRun validates pending migrations in a rolled-back transaction.
+	DryRun bool
 }
 
+// migrationNamePattern enforces the version_name.sql convention, e.g. 0001_create_intents.sql.
+// A paired rollback uses the same name with a .down.sql suffix.
+var migrationNamePattern = regexp.MustCompile(`^[0-9]+_[A-Za-z0-9_-]+(\.down)?\.sql$`)
+
+const downMigrationSuffix = ".down.sql"
+
+// Migrate applies pending migrations from the embedded schema, or from the FS
+// configured with WithMigrationsFS. It is traced and timed as
+// MigrateWithOptions.
 func (s *Store) Migrate(ctx context.Context) error {
+	return s.MigrateWithOptions(ctx, MigrateOptions{})
+}
+
+// MigrateWithOptions applies pending migrations in filename order, each in its
+// own transaction. With DryRun, pending migrations are executed inside a single
+// transaction that is always rolled back, validating their SQL without changing
+// the schema.
+func (s *Store) MigrateWithOptions(ctx context.Context, opts MigrateOptions) (err error) {
+	ctx, span := s.startSpan(ctx, "MigrateWithOptions")
+	defer func() { err = span.end(err) }()
 	if s.db == nil {
 		return errors.New("store not initialized")
 	}
-	if _, err := s.db.ExecContext(ctx, schemaMigrationsTable); err != nil {
-		return fmt.Errorf("create schema_migrations: %w", err)
-	}
 
-	paths, err := listMigrationFiles()
+	pending, err := s.pendingMigrations(ctx, opts)
 	if err != nil {
 		return err
 	}
-	if len(paths) == 0 {
-		return errors.New("no migration files found")
+	if s.opts.readOnly {
+		// Nothing can be applied; only fail if the schema is behind.
+		if len(pending) > 0 {
+			return fmt.Errorf("%w: %d migrations pending, starting with %s", &ReadOnlyError{Op: "Migrate"}, len(pending), pending[0].Version)
+		}
+		return nil
+	}
+	if opts.DryRun {
+		return s.dryRunMigrations(ctx, pending)
 	}
 
-	sort.Strings(paths)
-	for _, path := range paths {
-		version := filepath.Base(path)
-		applied, err := s.isMigrationApplied(ctx, version)
+	if _, err := s.db.ExecContext(ctx, schemaMigrationsTable); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	for _, migration := range pending {
+		start := time.Now()
+		err := s.applyMigration(ctx, migration)
+		d := time.Since(start)
+		if o := s.opts.observer; o != nil {
+			o.ObserveMigration(migration.Version, d, err)
+		}
 		if err != nil {
+			s.logger().Error("migration failed", "version", migration.Version, "error", err)
 			return err
 		}
-		if applied {
-			continue
-		}
+		s.logger().Info("applied migration", "version", migration.Version, "duration", d)
+	}
 
-		contents, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("read migration %s: %w", version, err)
-		}
+	return nil
+}
 
-		tx, err := s.db.BeginTx(ctx, nil)
-		if err != nil {
-			return fmt.Errorf("begin migration %s: %w", version, err)
-		}
-		if _, err := tx.ExecContext(ctx, string(contents)); err != nil {
-			_ = tx.Rollback()
-			return fmt.Errorf("apply migration %s: %w", version, err)
-		}
-		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
-			_ = tx.Rollback()
-			return fmt.Errorf("record migration %s: %w", version, err)
-		}
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("commit migration %s: %w", version, err)
-		}
-	}
+// applyMigration runs one migration and records it in its own transaction.
+func (s *Store) applyMigration(ctx context.Context, migration PendingMigration) error {
+	version := migration.Version
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin migration %s: %w", version, err)
+	}
+	if _, err := tx.ExecContext(ctx, string(migration.contents)); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("apply migration %s: %w", version, err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("record migration %s: %w", version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration %s: %w", version, err)
+	}
 	return nil
 }
 
-// listMigrationFiles collects migration SQL files from the migrations directory.
-func listMigrationFiles() ([]string, error) {
-	entries, err := os.ReadDir("migrations")
+// resolveMigrations picks the migration FS and directory for opts.
+func (s *Store) resolveMigrations(opts MigrateOptions) (fs.FS, string) {
+	fsys := opts.FS
+	if fsys == nil {
+		fsys = s.opts.migrationsFS
+	}
+	if fsys == nil {
+		fsys = embeddedMigrations
+		if opts.Dir == "" {
+			opts.Dir = "migrations"
+		}
+	}
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	return fsys, opts.Dir
+}
+
+// listMigrationFiles collects up migration SQL files from dir within fsys,
+// rejecting names that do not follow the version_name.sql convention.
+func listMigrationFiles(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
 	if err != nil {
 		return nil, fmt.Errorf("list migrations: %w", err)
 	}
@@ -133,157 +285,458 @@ func listMigrationFiles() ([]string, error) {
 		if !strings.HasSuffix(name, ".sql") {
 			continue
 		}
-		paths = append(paths, filepath.Join("migrations", name))
+		if !migrationNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid migration filename %q: expected <version>_<name>.sql", name)
+		}
+		if strings.HasSuffix(name, downMigrationSuffix) {
+			continue
+		}
+		paths = append(paths, path.Join(dir, name))
 	}
 	return paths, nil
 }
 
-func (s *Store) isMigrationApplied(ctx context.Context, version string) (bool, error) {
-	var count int
-	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM schema_migrations WHERE version = ?`, version).Scan(&count); err != nil {
-		return false, fmt.Errorf("check migration %s: %w", version, err)
+// CreateIntent inserts record, its promoted meta, its chain head update, and any
+// due checkpoint in one transaction.
+func (s *Store) CreateIntent(ctx context.Context, record model.IntentRecord) (err error) {
+	ctx, span := s.startSpan(ctx, "CreateIntent")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpCreate, start, 1, err) }(time.Now())
+	if err := s.writable("CreateIntent"); err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, ActionCreate, record); err != nil {
+		return err
+	}
+	if err := s.limiter.admitRecords(record); err != nil {
+		return err
 	}
-	return count > 0, nil
+	defer func() { s.limiter.refundInvalid(err, record) }()
+	return s.withRetryTx(ctx, "CreateIntent", func(tx *sql.Tx) error {
+		if err := s.insertIntentTx(ctx, tx, record); err != nil {
+			return err
+		}
+		return s.checkpointDueTx(ctx, tx)
+	})
 }
 
-func (s *Store) CreateIntent(ctx context.Context, record model.IntentRecord) error {
+// CreateIntents inserts records in a single transaction using the cached insert
+// statement. Either every record is stored or none are; the error identifies the
+// first record that failed.
+func (s *Store) CreateIntents(ctx context.Context, records []model.IntentRecord) (err error) {
+	ctx, span := s.startSpan(ctx, "CreateIntents")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("CreateIntents"); err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	defer func(start time.Time) { s.observe(OpCreate, start, len(records), err) }(time.Now())
+	for _, record := range records {
+		if err := s.authorize(ctx, ActionCreate, record); err != nil {
+			return err
+		}
+	}
+	if err := s.limiter.admitRecords(records...); err != nil {
+		return err
+	}
+	defer func() { s.limiter.refundInvalid(err, records...) }()
+	return s.withRetryTx(ctx, "CreateIntents", func(tx *sql.Tx) error {
+		for i, record := range records {
+			if err := s.insertIntentTx(ctx, tx, record); err != nil {
+				return fmt.Errorf("create intent %d (%s): %w", i, record.ID, err)
+			}
+		}
+		return s.checkpointDueTx(ctx, tx)
+	})
+}
+
+// errTombstonedInput rejects records that arrive with TombstonedAt set; only
+// tombstoneTx may set it.
+var errTombstonedInput = &model.InvalidRecordError{Err: errors.New("tombstoned_at is set by the store and must be empty")}
+
+// insertIntentTx redacts record and checks it against the configured
+// Validation, its meta schema, its attachments, and WithMonotonicTimestamps,
+// then inserts it with its tags and promoted meta and advances its chain head
+// within tx. Tombstoned records are rejected; only the store makes tombstones.
+func (s *Store) insertIntentTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	if record.Tombstoned() {
+		return errTombstonedInput
+	}
+	record, err := s.redact(record, true)
+	if err != nil {
+		return err
+	}
+	if err := s.validateTx(ctx, tx, record); err != nil {
+		return err
+	}
+	if err := record.ValidateMetaSchema(); err != nil {
+		return err
+	}
+	if err := checkAttachmentsTx(ctx, tx, record); err != nil {
+		return err
+	}
+	if err := s.checkSkewTx(ctx, tx, record); err != nil {
+		return err
+	}
+	return s.writeIntentTx(ctx, tx, record)
+}
+
+// insertTombstoneTx inserts a tombstone copied from another store, as Merge
+// and Copy do, so the chain through it stays intact. The tombstone must carry
+// no content (see model.IntentRecord.CheckTombstone); its hash cannot be
+// recomputed, so only its fields are checked.
+func (s *Store) insertTombstoneTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	return s.writeIntentTx(ctx, tx, record)
+}
+
+// writeIntentTx inserts a checked record with its tags, promoted meta, and
+// fingerprint, and advances its chain head within tx.
+func (s *Store) writeIntentTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	stmt, err := s.txStmt(ctx, tx, insertIntentSQL)
+	if err != nil {
+		return err
+	}
+	args, err := intentArgs(record, s.codec)
+	if err != nil {
+		return err
+	}
+	if _, err := stmt.ExecContext(ctx, args...); err != nil {
+		return duplicateTx(ctx, tx, record, err)
+	}
+	if _, err := s.advanceChainHead(ctx, tx, record); err != nil {
+		return err
+	}
+	if err := s.insertTagsTx(ctx, tx, record); err != nil {
+		return err
+	}
+	if err := s.insertPromotedMetaTx(ctx, tx, record); err != nil {
+		return err
+	}
+	return s.insertFingerprintTx(ctx, tx, record)
+}
+
+// insertPromotedMetaTx stores record's promoted meta values within tx.
+func (s *Store) insertPromotedMetaTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	promoted, err := s.promotedMetaValues(record.Meta)
+	if err != nil {
+		return err
+	}
+	if len(promoted) == 0 {
+		return nil
+	}
+	promoteStmt, err := s.txStmt(ctx, tx, insertPromotedMetaSQL)
+	if err != nil {
+		return err
+	}
+	return insertPromotedMeta(ctx, promoteStmt, record, promoted)
+}
+
+// withTx runs fn in a transaction, committing on success and rolling back on error.
+func (s *Store) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	s.prepareQueued(ctx)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) GetIntent(ctx context.Context, id string) (_ model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "GetIntent")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpRead, start, 1, err) }(time.Now())
+	return s.getIntent(ctx, selectIntentByIDSQL, id, s.cache.getByID)
+}
+
+// GetIntentByHash loads an intent by its hash for chain traversal.
+func (s *Store) GetIntentByHash(ctx context.Context, hash string) (_ model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "GetIntentByHash")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpRead, start, 1, err) }(time.Now())
+	return s.getIntent(ctx, selectIntentByHashSQL, hash, s.cache.getByHash)
+}
+
+// getIntent loads the intent query selects by key, consulting the intent
+// cache through cached first. Cache hits are still authorized.
+func (s *Store) getIntent(ctx context.Context, query, key string, cached func(string) (model.IntentRecord, bool)) (model.IntentRecord, error) {
+	record, ok := cached(key)
+	if !ok {
+		gen := s.cache.generation()
+		stmt, err := s.preparedRead(ctx, query)
+		if err != nil {
+			return model.IntentRecord{}, err
+		}
+		record, err = s.scanIntent(stmt.QueryRowContext(ctx, key))
+		if err != nil {
+			return model.IntentRecord{}, notFound(err, "intent", key)
+		}
+		s.cache.add(gen, record)
+	}
+	if err := s.authorize(ctx, ActionGet, record); err != nil {
+		return model.IntentRecord{}, err
+	}
+	return record, nil
+}
+
+// listAncestorsSQL follows prev_hash from a hash for up to ? records, newest
+// first. A cycle stops at the depth bound rather than looping forever.
+const listAncestorsSQL = `WITH RECURSIVE walk (h, depth) AS (
+		SELECT ?, 0
+		UNION ALL
+		SELECT i.prev_hash, walk.depth + 1 FROM walk JOIN intents i ON i.hash = walk.h
+		WHERE i.prev_hash IS NOT NULL AND i.prev_hash != '' AND walk.depth + 1 < ?
+	)
+	SELECT ` + intentColumns + ` FROM walk JOIN intents ON intents.hash = walk.h ORDER BY walk.depth`
+
+// ListAncestors returns the intent stored under hash followed by its
+// ancestors in prev_hash order, up to limit records in all, so a chain can be
+// walked a batch per query. It stops early at genesis or at a missing record,
+// and returns no records when hash itself is not stored. Limits follow
+// ListIntents. Every record is authorized as by GetIntentByHash.
+func (s *Store) ListAncestors(ctx context.Context, hash string, limit int) (records []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "ListAncestors")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpRead, start, len(records), err) }(time.Now())
+	limit = s.clampLimit(limit)
+
+	stmt, err := s.preparedRead(ctx, listAncestorsSQL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, hash, limit)
+	if err != nil {
+		return nil, err
+	}
+	intents, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range intents {
+		if err := s.authorize(ctx, ActionGet, record); err != nil {
+			return nil, err
+		}
+	}
+	return intents, nil
+}
+
+// ListIntents returns the newest intents first. A limit <= 0 selects
+// DefaultListLimit and larger limits are clamped to the configured maximum
+// (see WithMaxListLimit).
+func (s *Store) ListIntents(ctx context.Context, limit int) (records []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "ListIntents")
+	defer func() { err = span.end(err) }()
+	defer func(start time.Time) { s.observe(OpRead, start, len(records), err) }(time.Now())
+	limit = s.clampLimit(limit)
+
+	stmt, err := s.preparedRead(ctx, listIntentsSQL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	intents, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	return s.allowed(ctx, ActionList, intents), nil
+}
+
+// intentArgs returns the insert arguments for a record, mapping empty optional
+// fields (including an empty meta object) to NULL. Tags are stored normalized
+// and the prompt, response, and meta are encoded with codec.
+func intentArgs(record model.IntentRecord, codec bodyCodec) ([]any, error) {
+	cols, err := codec.encode(record)
+	if err != nil {
+		return nil, err
+	}
 	var title any
 	if record.Title != "" {
 		title = record.Title
 	}
-	var meta any
-	if len(record.Meta) > 0 {
-		meta = string(record.Meta)
-	}
 	var prevHash any
 	if record.PrevHash != "" {
 		prevHash = record.PrevHash
 	}
-
-	_, err := s.db.ExecContext(
-		ctx,
-		`INSERT INTO intents (id, created_at, author, source_type, title, prompt, response, meta, prev_hash, hash)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	var signature, publicKey any
+	if record.Signature != "" {
+		signature = record.Signature
+	}
+	if record.PublicKey != "" {
+		publicKey = record.PublicKey
+	}
+	var hashVersion any
+	if record.HashVersion != 0 {
+		hashVersion = record.HashVersion
+	}
+	var tags any
+	if normalized := record.Normalize().Tags; len(normalized) > 0 {
+		encoded, _ := json.Marshal(normalized)
+		tags = string(encoded)
+	}
+	var threadID, parentID any
+	if record.ThreadID != "" {
+		threadID = record.ThreadID
+	}
+	if record.ParentID != "" {
+		parentID = record.ParentID
+	}
+	var tombstonedAt any
+	if record.TombstonedAt != "" {
+		tombstonedAt = record.TombstonedAt
+	}
+	var attachments any
+	if len(record.Attachments) > 0 {
+		encoded, _ := json.Marshal(record.Attachments)
+		attachments = string(encoded)
+	}
+	return []any{
 		record.ID,
 		record.CreatedAt,
 		record.Author,
 		record.SourceType,
 		title,
-		record.Prompt,
-		record.Response,
-		meta,
+		cols.prompt,
+		cols.response,
+		cols.meta,
 		prevHash,
 		record.Hash,
-	)
-	return err
+		signature,
+		publicKey,
+		hashVersion,
+		tags,
+		threadID,
+		parentID,
+		attachments,
+		cols.encoding,
+		tombstonedAt,
+		bodyBlob(record, "prompt", record.Prompt),
+		bodyBlob(record, "response", record.Response),
+	}, nil
 }
 
-func (s *Store) GetIntent(ctx context.Context, id string) (model.IntentRecord, error) {
-	var record model.IntentRecord
-	var title sql.NullString
-	var meta sql.NullString
-	var prevHash sql.NullString
-	row := s.db.QueryRowContext(ctx, `SELECT id, created_at, author, source_type, title, prompt, response, meta, prev_hash, hash FROM intents WHERE id = ?`, id)
-	if err := row.Scan(
-		&record.ID,
-		&record.CreatedAt,
-		&record.Author,
-		&record.SourceType,
-		&title,
-		&record.Prompt,
-		&record.Response,
-		&meta,
-		&prevHash,
-		&record.Hash,
-	); err != nil {
-		return record, err
+// bodyBlob returns the digest of the blob holding record's body, the prompt
+// or response given by name, or nil when the body is inline. A body is a blob
+// when it is the model.BlobRef placeholder of an attachment with the same
+// name, as CreateIntentStream writes it.
+func bodyBlob(record model.IntentRecord, name, body string) any {
+	for _, a := range record.Attachments {
+		if a.Name == name && body == model.BlobRef(a.Digest) {
+			return a.Digest
+		}
 	}
+	return nil
+}
 
-	if title.Valid {
-		record.Title = title.String
-	}
-	if meta.Valid && meta.String != "" {
-		record.Meta = []byte(meta.String)
-	}
-	if prevHash.Valid {
-		record.PrevHash = prevHash.String
-	}
-	return record, nil
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-// GetIntentByHash loads an intent by its hash for chain traversal.
-func (s *Store) GetIntentByHash(ctx context.Context, hash string) (model.IntentRecord, error) {
+// scanIntent reads a row selected with intentColumns into an IntentRecord.
+func (s *Store) scanIntent(row rowScanner) (model.IntentRecord, error) {
 	var record model.IntentRecord
 	var title sql.NullString
-	var meta sql.NullString
+	var meta []byte
 	var prevHash sql.NullString
-	row := s.db.QueryRowContext(ctx, `SELECT id, created_at, author, source_type, title, prompt, response, meta, prev_hash, hash FROM intents WHERE hash = ?`, hash)
+	var signature sql.NullString
+	var publicKey sql.NullString
+	var hashVersion sql.NullInt64
+	var tags sql.NullString
+	var threadID sql.NullString
+	var parentID sql.NullString
+	var attachments sql.NullString
+	var prompt, response []byte
+	var bodyEncoding sql.NullString
+	var tombstonedAt sql.NullString
 	if err := row.Scan(
 		&record.ID,
 		&record.CreatedAt,
 		&record.Author,
 		&record.SourceType,
 		&title,
-		&record.Prompt,
-		&record.Response,
+		&prompt,
+		&response,
 		&meta,
 		&prevHash,
 		&record.Hash,
+		&signature,
+		&publicKey,
+		&hashVersion,
+		&tags,
+		&threadID,
+		&parentID,
+		&attachments,
+		&bodyEncoding,
+		&tombstonedAt,
 	); err != nil {
 		return record, err
 	}
 
+	var err error
+	if record.Prompt, err = s.codec.decodeBody(bodyEncoding.String, record.ID, "prompt", prompt); err != nil {
+		return record, fmt.Errorf("decode prompt for %s: %w", record.ID, err)
+	}
+	if record.Response, err = s.codec.decodeBody(bodyEncoding.String, record.ID, "response", response); err != nil {
+		return record, fmt.Errorf("decode response for %s: %w", record.ID, err)
+	}
+	if meta, err = s.codec.decodeMeta(bodyEncoding.String, record.ID, meta); err != nil {
+		return record, fmt.Errorf("decode meta for %s: %w", record.ID, err)
+	}
+
 	if title.Valid {
 		record.Title = title.String
 	}
-	if meta.Valid && meta.String != "" {
-		record.Meta = []byte(meta.String)
+	if len(meta) > 0 {
+		record.Meta = meta
 	}
 	if prevHash.Valid {
 		record.PrevHash = prevHash.String
 	}
+	record.Signature = signature.String
+	record.PublicKey = publicKey.String
+	record.HashVersion = int(hashVersion.Int64)
+	record.ThreadID = threadID.String
+	record.ParentID = parentID.String
+	record.TombstonedAt = tombstonedAt.String
+	if attachments.Valid {
+		if err := json.Unmarshal([]byte(attachments.String), &record.Attachments); err != nil {
+			return record, fmt.Errorf("decode attachments for %s: %w", record.ID, err)
+		}
+	}
+	if tags.Valid {
+		if err := json.Unmarshal([]byte(tags.String), &record.Tags); err != nil {
+			return record, fmt.Errorf("decode tags for %s: %w", record.ID, err)
+		}
+	}
 	return record, nil
 }
 
-func (s *Store) ListIntents(ctx context.Context, limit int) ([]model.IntentRecord, error) {
-	if limit <= 0 {
-		limit = 100
-	}
-
-	rows, err := s.db.QueryContext(ctx, `SELECT id, created_at, author, source_type, title, prompt, response, meta, prev_hash, hash FROM intents ORDER BY created_at DESC LIMIT ?`, limit)
-	if err != nil {
-		return nil, err
-	}
+// collectIntents drains rows selected with intentColumns and closes them,
+// stopping with ctx's error once ctx is done.
+func (s *Store) collectIntents(ctx context.Context, rows *sql.Rows) ([]model.IntentRecord, error) {
 	defer rows.Close()
 
 	var intents []model.IntentRecord
 	for rows.Next() {
-		var record model.IntentRecord
-		var title sql.NullString
-		var meta sql.NullString
-		var prevHash sql.NullString
-		if err := rows.Scan(
-			&record.ID,
-			&record.CreatedAt,
-			&record.Author,
-			&record.SourceType,
-			&title,
-			&record.Prompt,
-			&record.Response,
-			&meta,
-			&prevHash,
-			&record.Hash,
-		); err != nil {
+		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
-		if title.Valid {
-			record.Title = title.String
-		}
-		if meta.Valid && meta.String != "" {
-			record.Meta = []byte(meta.String)
-		}
-		if prevHash.Valid {
-			record.PrevHash = prevHash.String
+		record, err := s.scanIntent(rows)
+		if err != nil {
+			return nil, err
 		}
 		intents = append(intents, record)
 	}
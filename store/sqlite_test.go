@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// openTestStore opens a migrated store in a temporary directory.
+func openTestStore(tb testing.TB) *Store {
+	tb.Helper()
+
+	s, err := Open(filepath.Join(tb.TempDir(), "yanzi.db"))
+	if err != nil {
+		tb.Fatalf("open store: %v", err)
+	}
+	tb.Cleanup(func() { _ = s.Close() })
+
+	if err := s.Migrate(context.Background()); err != nil {
+		tb.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+// testIntent builds a hashed record whose created_at advances with n.
+func testIntent(tb testing.TB, n int) model.IntentRecord {
+	tb.Helper()
+
+	record := model.IntentRecord{
+		ID:         fmt.Sprintf("intent-%06d", n),
+		CreatedAt:  time.Date(2026, 2, 9, 10, 0, 0, 0, time.UTC).Add(time.Duration(n) * time.Second).Format(time.RFC3339Nano),
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     fmt.Sprintf("prompt %d", n),
+		Response:   fmt.Sprintf("response %d", n),
+	}
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		tb.Fatalf("hash intent: %v", err)
+	}
+	record.Hash = sum
+	return record
+}
+
+func TestCreateAndGetIntent(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	byID, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if byID.Hash != record.Hash {
+		t.Fatalf("expected hash %s, got %s", record.Hash, byID.Hash)
+	}
+
+	byHash, err := s.GetIntentByHash(ctx, record.Hash)
+	if err != nil {
+		t.Fatalf("get intent by hash: %v", err)
+	}
+	if byHash.ID != record.ID {
+		t.Fatalf("expected id %s, got %s", record.ID, byHash.ID)
+	}
+
+	intents, err := s.ListIntents(ctx, 0)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	if len(intents) != 1 {
+		t.Fatalf("expected 1 intent, got %d", len(intents))
+	}
+}
+
+func TestEmptyMetaStoredAsAbsent(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	cases := []struct {
+		name        string
+		hashVersion int
+		meta        string
+		want        string
+	}{
+		{"absent", model.EmptyMetaHashVersion, "", ""},
+		{"empty", model.EmptyMetaHashVersion, `{}`, ""},
+		{"populated", model.EmptyMetaHashVersion, `{"env":"prod"}`, `{"env":"prod"}`},
+		// Older hash versions hash {} as written, so it is stored as written.
+		{"legacy empty", 0, `{}`, `{}`},
+	}
+	for i, tc := range cases {
+		record := testIntent(t, i)
+		record.HashVersion = tc.hashVersion
+		if tc.hashVersion >= model.ULIDHashVersion {
+			id, err := model.NewIntentID()
+			if err != nil {
+				t.Fatalf("new id: %v", err)
+			}
+			record.ID = id
+		}
+		record.Meta = json.RawMessage(tc.meta)
+		sum, err := hash.HashIntent(record)
+		if err != nil {
+			t.Fatalf("hash %s intent: %v", tc.name, err)
+		}
+		record.Hash = sum
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create %s intent: %v", tc.name, err)
+		}
+
+		got, err := s.GetIntent(ctx, record.ID)
+		if err != nil {
+			t.Fatalf("get %s intent: %v", tc.name, err)
+		}
+		if string(got.Meta) != tc.want {
+			t.Fatalf("%s: expected meta %q, got %q", tc.name, tc.want, got.Meta)
+		}
+		if sum, err := hash.HashIntent(got); err != nil || sum != record.Hash {
+			t.Fatalf("%s: expected the stored record to keep its hash, got %s, %v", tc.name, sum, err)
+		}
+
+		var isNull bool
+		if err := s.db.QueryRowContext(ctx, `SELECT meta IS NULL FROM intents WHERE id = ?`, record.ID).Scan(&isNull); err != nil {
+			t.Fatalf("query %s meta: %v", tc.name, err)
+		}
+		if isNull != (tc.want == "") {
+			t.Fatalf("%s: expected meta NULL=%v, got %v", tc.name, tc.want == "", isNull)
+		}
+	}
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	record := testIntent(t, 1)
+	record.Signature = "c2lnbmF0dXJl"
+	record.PublicKey = "cHVibGljLWtleQ=="
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	unsigned := testIntent(t, 2)
+	if err := s.CreateIntent(ctx, unsigned); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if got.Signature != record.Signature || got.PublicKey != record.PublicKey {
+		t.Fatalf("expected signature to round-trip, got %+v", got)
+	}
+	got, err = s.GetIntent(ctx, unsigned.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if got.Signature != "" || got.PublicKey != "" {
+		t.Fatalf("expected unsigned intent, got %+v", got)
+	}
+}
+
+func TestHashVersionRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	record := testIntent(t, 1)
+	record.HashVersion = hash.HashVersionJCS
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	record.Hash = sum
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	got, err := s.GetIntent(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("get intent: %v", err)
+	}
+	if got.HashVersion != hash.HashVersionJCS {
+		t.Fatalf("expected hash_version %d, got %d", hash.HashVersionJCS, got.HashVersion)
+	}
+	if recomputed, err := hash.HashIntent(got); err != nil || recomputed != record.Hash {
+		t.Fatalf("expected stored record to rehash to %s, got %s (%v)", record.Hash, recomputed, err)
+	}
+}
+
+func TestCreateIntentEnforcesMetaSchema(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	model.SetMetaSchema("cli", model.MetaValidatorFunc(func(meta json.RawMessage) error {
+		if len(meta) == 0 {
+			return errors.New("meta is required")
+		}
+		return nil
+	}))
+	t.Cleanup(func() { model.SetMetaSchema("cli", nil) })
+
+	var schemaErr *model.MetaSchemaError
+	if err := s.CreateIntent(ctx, testIntent(t, 1)); !errors.As(err, &schemaErr) || !errors.Is(err, ErrInvalidRecord) {
+		t.Fatalf("expected MetaSchemaError matching ErrInvalidRecord, got %v", err)
+	}
+	if _, err := s.GetIntent(ctx, testIntent(t, 1).ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected rejected intent not to be stored, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Stats summarizes the intents table.
+type Stats struct {
+	Total        int64
+	ByAuthor     map[string]int64
+	BySourceType map[string]int64
+	// ByDay counts intents per UTC calendar day (YYYY-MM-DD).
+	ByDay map[string]int64
+	// PromptBytes and ResponseBytes are total stored sizes: UTF-8 text, or the
+	// compressed size for rows written with WithCompression.
+	PromptBytes   int64
+	ResponseBytes int64
+}
+
+// Stats computes counts and sizes with aggregate queries, without loading intents.
+func (s *Store) Stats(ctx context.Context) (_ Stats, err error) {
+	ctx, span := s.startSpan(ctx, "Stats")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return Stats{}, errors.New("store not initialized")
+	}
+
+	var stats Stats
+	if err := s.rdb.QueryRowContext(ctx, `SELECT COUNT(1),
+		COALESCE(SUM(length(CAST(prompt AS BLOB))), 0),
+		COALESCE(SUM(length(CAST(response AS BLOB))), 0)
+		FROM intents`).Scan(&stats.Total, &stats.PromptBytes, &stats.ResponseBytes); err != nil {
+		return Stats{}, fmt.Errorf("count intents: %w", err)
+	}
+
+	if stats.ByAuthor, err = s.countBy(ctx, `author`); err != nil {
+		return Stats{}, err
+	}
+	if stats.BySourceType, err = s.countBy(ctx, `source_type`); err != nil {
+		return Stats{}, err
+	}
+	if stats.ByDay, err = s.countBy(ctx, `date(created_at)`); err != nil {
+		return Stats{}, err
+	}
+	return stats, nil
+}
+
+// countBy groups intents by expr, which must be a trusted SQL expression.
+func (s *Store) countBy(ctx context.Context, expr string) (map[string]int64, error) {
+	rows, err := s.rdb.QueryContext(ctx, `SELECT COALESCE(`+expr+`, ''), COUNT(1) FROM intents GROUP BY 1`)
+	if err != nil {
+		return nil, fmt.Errorf("count intents by %s: %w", expr, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var key string
+		var count int64
+		if err := rows.Scan(&key, &count); err != nil {
+			return nil, err
+		}
+		counts[key] = count
+	}
+	return counts, rows.Err()
+}
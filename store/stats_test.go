@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	authors := []string{"alice", "alice", "bob"}
+	for i, author := range authors {
+		record := testIntent(t, i)
+		record.Author = author
+		if i == 2 {
+			record.SourceType = "api"
+			record.CreatedAt = "2026-02-10T01:00:00+02:00"
+			record.Prompt = "héllo"
+		}
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	stats, err := s.Stats(ctx)
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.Total != 3 {
+		t.Fatalf("expected total 3, got %d", stats.Total)
+	}
+	if stats.ByAuthor["alice"] != 2 || stats.ByAuthor["bob"] != 1 {
+		t.Fatalf("unexpected author counts: %v", stats.ByAuthor)
+	}
+	if stats.BySourceType["cli"] != 2 || stats.BySourceType["api"] != 1 {
+		t.Fatalf("unexpected source counts: %v", stats.BySourceType)
+	}
+	if stats.ByDay["2026-02-09"] != 3 {
+		t.Fatalf("expected all intents on 2026-02-09 UTC, got %v", stats.ByDay)
+	}
+	wantPrompt := int64(len("prompt 0") + len("prompt 1") + len("héllo"))
+	if stats.PromptBytes != wantPrompt {
+		t.Fatalf("expected %d prompt bytes, got %d", wantPrompt, stats.PromptBytes)
+	}
+	if stats.ResponseBytes != int64(3*len("response 0")) {
+		t.Fatalf("unexpected response bytes %d", stats.ResponseBytes)
+	}
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const intentColumns = `id, created_at, author, source_type, title, prompt, response, meta, prev_hash, hash, signature, public_key, hash_version, tags, thread_id, parent_id, attachments, body_encoding, tombstoned_at`
+
+const (
+	insertIntentSQL = `INSERT INTO intents (` + intentColumns + `, prompt_blob, response_blob)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	selectIntentByIDSQL   = `SELECT ` + intentColumns + ` FROM intents WHERE id = ?`
+	selectIntentByHashSQL = `SELECT ` + intentColumns + ` FROM intents WHERE hash = ?`
+	listIntentsSQL        = `SELECT ` + intentColumns + ` FROM intents ORDER BY created_at DESC, id DESC LIMIT ?`
+)
+
+// errStoreClosed is returned when a statement is requested after Close.
+var errStoreClosed = errors.New("store is closed")
+
+// prepared returns a cached statement for query on the writer, preparing it
+// on first use. Use it for writes, binding it with tx.StmtContext.
+//
+// A *sql.Stmt belongs to the pool rather than to a single connection:
+// database/sql re-prepares it transparently on whichever connection runs it,
+// so a cached statement is safe to share across goroutines. Statements used
+// inside a transaction must still be bound with tx.StmtContext, and only
+// statements from the writer can be.
+func (s *Store) prepared(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.prepare(ctx, s.db, &s.stmts, query)
+}
+
+// txStmt returns the cached writer statement for query bound to tx.
+//
+// Preparing on the writer needs its only connection, which tx holds, so a
+// statement not yet cached is prepared on tx for this transaction alone and
+// queued; withTx caches queued statements before its next transaction.
+func (s *Store) txStmt(ctx context.Context, tx *sql.Tx, query string) (*sql.Stmt, error) {
+	s.stmtMu.Lock()
+	stmt, ok := s.stmts[query]
+	if !ok && !s.closed {
+		if s.queuedStmts == nil {
+			s.queuedStmts = make(map[string]struct{})
+		}
+		s.queuedStmts[query] = struct{}{}
+	}
+	s.stmtMu.Unlock()
+	if ok {
+		return tx.StmtContext(ctx, stmt), nil
+	}
+	return tx.PrepareContext(ctx, query)
+}
+
+// prepareQueued caches the writer statements txStmt queued. It must not be
+// called inside a transaction. Failures are left queued for the next call.
+func (s *Store) prepareQueued(ctx context.Context) {
+	s.stmtMu.Lock()
+	queued := make([]string, 0, len(s.queuedStmts))
+	for query := range s.queuedStmts {
+		queued = append(queued, query)
+	}
+	s.stmtMu.Unlock()
+	for _, query := range queued {
+		if _, err := s.prepared(ctx, query); err == nil {
+			s.stmtMu.Lock()
+			delete(s.queuedStmts, query)
+			s.stmtMu.Unlock()
+		}
+	}
+}
+
+// preparedRead is prepared for reads, on the read pool.
+func (s *Store) preparedRead(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.prepare(ctx, s.rdb, &s.readStmts, query)
+}
+
+func (s *Store) prepare(ctx context.Context, db *sql.DB, cache *map[string]*sql.Stmt, query string) (*sql.Stmt, error) {
+	if db == nil {
+		return nil, errors.New("store not initialized")
+	}
+
+	s.stmtMu.Lock()
+	defer s.stmtMu.Unlock()
+
+	if s.closed {
+		return nil, errStoreClosed
+	}
+	if stmt, ok := (*cache)[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if *cache == nil {
+		*cache = make(map[string]*sql.Stmt)
+	}
+	(*cache)[query] = stmt
+	return stmt, nil
+}
+
+// closeStatements releases every cached statement and blocks further caching.
+func (s *Store) closeStatements() error {
+	s.stmtMu.Lock()
+	defer s.stmtMu.Unlock()
+
+	var errs []error
+	for _, cache := range []map[string]*sql.Stmt{s.stmts, s.readStmts} {
+		for query, stmt := range cache {
+			if err := stmt.Close(); err != nil {
+				errs = append(errs, err)
+			}
+			delete(cache, query)
+		}
+	}
+	s.closed = true
+	return errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestCloseReleasesStatements(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	// The first insert runs inside a transaction holding the only writer
+	// connection, so its statement is cached before the second.
+	for n := 1; n <= 2; n++ {
+		if err := s.CreateIntent(ctx, testIntent(t, n)); err != nil {
+			t.Fatalf("create intent: %v", err)
+		}
+	}
+	if _, err := s.ListIntents(ctx, 10); err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+
+	s.stmtMu.Lock()
+	stmt := s.stmts[insertIntentSQL]
+	_, listCached := s.readStmts[listIntentsSQL]
+	s.stmtMu.Unlock()
+	if stmt == nil || !listCached {
+		t.Fatalf("expected the insert cached on the writer and the list on the readers, got insert=%v list=%v", stmt != nil, listCached)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if len(s.stmts) != 0 || len(s.readStmts) != 0 {
+		t.Fatalf("expected no cached statements after close, got %d and %d", len(s.stmts), len(s.readStmts))
+	}
+	args, err := intentArgs(testIntent(t, 3), bodyCodec{})
+	if err != nil {
+		t.Fatalf("intent args: %v", err)
+	}
+	if _, err := stmt.ExecContext(ctx, args...); err == nil {
+		t.Fatalf("expected closed statement to fail")
+	}
+	if _, err := s.GetIntent(ctx, "intent-000001"); !errors.Is(err, errStoreClosed) {
+		t.Fatalf("expected errStoreClosed, got %v", err)
+	}
+}
+
+func BenchmarkCreateIntentCached(b *testing.B) {
+	ctx := context.Background()
+	s := openTestStore(b)
+	records := benchmarkIntents(b, b.N)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.CreateIntent(ctx, records[i]); err != nil {
+			b.Fatalf("create intent: %v", err)
+		}
+	}
+}
+
+func BenchmarkCreateIntentUncached(b *testing.B) {
+	ctx := context.Background()
+	s := openTestStore(b)
+	records := benchmarkIntents(b, b.N)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		args, err := intentArgs(records[i], bodyCodec{})
+		if err != nil {
+			b.Fatalf("intent args: %v", err)
+		}
+		if _, err := s.db.ExecContext(ctx, insertIntentSQL, args...); err != nil {
+			b.Fatalf("create intent: %v", err)
+		}
+	}
+}
+
+func benchmarkIntents(b *testing.B, n int) []model.IntentRecord {
+	b.Helper()
+	records := make([]model.IntentRecord, n)
+	for i := range records {
+		records[i] = testIntent(b, i)
+	}
+	return records
+}
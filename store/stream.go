@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// streamBodyMediaType is recorded on the attachments created for streamed bodies.
+const streamBodyMediaType = "text/plain; charset=utf-8"
+
+// CreateIntentStream stores an intent whose prompt and response are read from
+// streams rather than held in memory. Each body is written as a chunked blob
+// while its digest is computed incrementally; the record's Prompt and Response
+// hold model.BlobRef placeholders and its Attachments gain "prompt" and
+// "response" entries, so both digests are covered by the intent hash.
+//
+// The remaining fields come from record. A missing ID or CreatedAt is filled
+// as in AppendIntent, Hash is computed as configured with WithHashing, and the
+// record is checked with model.IntentRecord.Validate; the stored record is
+// returned.
+// Everything happens in one transaction, which holds the write lock while the
+// streams are read. A configured redactor (see WithRedactor) reads both
+// bodies into memory first.
+func (s *Store) CreateIntentStream(ctx context.Context, record model.IntentRecord, prompt, response io.Reader) (_ model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "CreateIntentStream")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("CreateIntentStream"); err != nil {
+		return model.IntentRecord{}, err
+	}
+	if err := s.authorize(ctx, ActionCreate, record); err != nil {
+		return model.IntentRecord{}, err
+	}
+	if err := s.limiter.admitRecords(record); err != nil {
+		return model.IntentRecord{}, err
+	}
+	defer func() { s.limiter.refundInvalid(err, record) }()
+	if record.ID == "" {
+		id, err := model.NewIntentID()
+		if err != nil {
+			return model.IntentRecord{}, fmt.Errorf("generate id: %w", err)
+		}
+		record.ID = id
+	}
+	record = s.stampCreatedAt(record)
+	record, prompt, response, err = s.redactStream(record, prompt, response)
+	if err != nil {
+		return model.IntentRecord{}, err
+	}
+
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		bodies := []struct {
+			name  string
+			r     io.Reader
+			field *string
+		}{
+			{"prompt", prompt, &record.Prompt},
+			{"response", response, &record.Response},
+		}
+		for _, body := range bodies {
+			digest, size, err := s.putBlobStreamTx(ctx, tx, body.r)
+			if err != nil {
+				return fmt.Errorf("store %s: %w", body.name, err)
+			}
+			*body.field = model.BlobRef(digest)
+			record.Attachments = append(record.Attachments, model.Attachment{
+				Digest:    digest,
+				Name:      body.name,
+				MediaType: streamBodyMediaType,
+				Size:      size,
+			})
+		}
+
+		if record, err = s.hashIntent(ctx, record); err != nil {
+			return fmt.Errorf("hash intent: %w", err)
+		}
+		if err := record.Validate(); err != nil {
+			return err
+		}
+		if err := s.insertIntentTx(ctx, tx, record); err != nil {
+			return err
+		}
+		return s.checkpointDueTx(ctx, tx)
+	})
+	if err != nil {
+		return model.IntentRecord{}, err
+	}
+	return record, nil
+}
+
+// OpenIntentPrompt streams the prompt of the intent with id, reading it from
+// its blob when it was stored by CreateIntentStream.
+func (s *Store) OpenIntentPrompt(ctx context.Context, id string) (_ io.ReadCloser, err error) {
+	ctx, span := s.startStreamSpan(ctx, "OpenIntentPrompt")
+	defer func() { err = span.end(err) }()
+	record, err := s.GetIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.openBody(ctx, id, "prompt_blob", record.Prompt)
+}
+
+// OpenIntentResponse streams the response of the intent with id, reading it
+// from its blob when it was stored by CreateIntentStream.
+func (s *Store) OpenIntentResponse(ctx context.Context, id string) (_ io.ReadCloser, err error) {
+	ctx, span := s.startStreamSpan(ctx, "OpenIntentResponse")
+	defer func() { err = span.end(err) }()
+	record, err := s.GetIntent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.openBody(ctx, id, "response_blob", record.Response)
+}
+
+// openBody opens the blob recorded in column (see bodyBlob) for intent id, or
+// body itself when the body is inline.
+func (s *Store) openBody(ctx context.Context, id, column, body string) (io.ReadCloser, error) {
+	var digest sql.NullString
+	err := s.rdb.QueryRowContext(ctx, `SELECT `+column+` FROM intents WHERE id = ?`, id).Scan(&digest)
+	if err != nil {
+		return nil, notFound(err, "intent", id)
+	}
+	if digest.Valid {
+		return s.OpenBlob(ctx, digest.String)
+	}
+	return io.NopCloser(strings.NewReader(body)), nil
+}
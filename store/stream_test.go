@@ -0,0 +1,149 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestPutBlobReaderChunks(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), blobChunkSize/8+3)
+	digest, size, err := s.PutBlobReader(ctx, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("put blob reader: %v", err)
+	}
+	if digest != hash.BlobDigest(data) || size != int64(len(data)) {
+		t.Fatalf("expected %s/%d, got %s/%d", hash.BlobDigest(data), len(data), digest, size)
+	}
+	var chunks int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM blob_chunks WHERE digest = ?`, digest).Scan(&chunks); err != nil {
+		t.Fatalf("count chunks: %v", err)
+	}
+	if chunks != 3 {
+		t.Fatalf("expected 3 chunks, got %d", chunks)
+	}
+
+	if again, _, err := s.PutBlobReader(ctx, bytes.NewReader(data)); err != nil || again != digest {
+		t.Fatalf("expected idempotent put, got %s, %v", again, err)
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM blob_chunks`).Scan(&chunks); err != nil {
+		t.Fatalf("count chunks: %v", err)
+	}
+	if chunks != 3 {
+		t.Fatalf("expected duplicate chunks to be discarded, got %d rows", chunks)
+	}
+
+	got, err := s.GetBlob(ctx, digest)
+	if err != nil {
+		t.Fatalf("get blob: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("expected chunked blob to round-trip")
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE blob_chunks SET data = ? WHERE digest = ? AND seq = 1`, []byte("x"), digest); err != nil {
+		t.Fatalf("tamper chunk: %v", err)
+	}
+	if _, err := s.GetBlob(ctx, digest); err == nil {
+		t.Fatalf("expected tampered chunk to be rejected")
+	}
+}
+
+func TestCreateIntentStream(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	response := strings.Repeat("streamed response ", blobChunkSize/9)
+	stored, err := s.CreateIntentStream(ctx, model.IntentRecord{
+		Author:     "alice",
+		SourceType: "cli",
+	}, strings.NewReader("prompt"), strings.NewReader(response))
+	if err != nil {
+		t.Fatalf("create intent stream: %v", err)
+	}
+	if len(stored.Attachments) != 2 || stored.Attachments[1].Name != "response" || stored.Attachments[1].Size != int64(len(response)) {
+		t.Fatalf("expected prompt and response attachments, got %+v", stored.Attachments)
+	}
+	if stored.Response != model.BlobRef(hash.BlobDigest([]byte(response))) {
+		t.Fatalf("expected response blob reference, got %q", stored.Response)
+	}
+	if err := hash.VerifyHash(stored); err != nil {
+		t.Fatalf("verify hash: %v", err)
+	}
+
+	rc, err := s.OpenIntentResponse(ctx, stored.ID)
+	if err != nil {
+		t.Fatalf("open response: %v", err)
+	}
+	defer rc.Close()
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	if string(got) != response {
+		t.Fatalf("expected streamed response to round-trip")
+	}
+
+	inline := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, inline); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	rc, err = s.OpenIntentPrompt(ctx, inline.ID)
+	if err != nil {
+		t.Fatalf("open inline prompt: %v", err)
+	}
+	defer rc.Close()
+	if got, _ := io.ReadAll(rc); string(got) != inline.Prompt {
+		t.Fatalf("expected inline prompt %q, got %q", inline.Prompt, got)
+	}
+
+	// A prompt that merely looks like a blob reference is inline text.
+	lookalike := testIntent(t, 2)
+	lookalike.Prompt = stored.Response
+	if lookalike.Hash, err = hash.HashIntent(lookalike); err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	if err := s.CreateIntent(ctx, lookalike); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+	rc, err = s.OpenIntentPrompt(ctx, lookalike.ID)
+	if err != nil {
+		t.Fatalf("open lookalike prompt: %v", err)
+	}
+	defer rc.Close()
+	if got, _ := io.ReadAll(rc); string(got) != lookalike.Prompt {
+		t.Fatalf("expected the lookalike prompt as text, got %d bytes", len(got))
+	}
+}
+
+func TestCreateIntentStreamHashing(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("secret")
+	s := openClockStore(t, WithHashing(Hashing{Version: hash.HashVersionJCS, HMACSecret: secret}))
+
+	_, err := s.CreateIntentStream(ctx, model.IntentRecord{Author: "alice", SourceType: "cli", Signature: "c2ln"},
+		strings.NewReader("p"), strings.NewReader("r"))
+	if !errors.Is(err, ErrInvalidRecord) {
+		t.Fatalf("expected a signature without a public key to be rejected, got %v", err)
+	}
+
+	stored, err := s.CreateIntentStream(ctx, model.IntentRecord{Author: "alice", SourceType: "cli"}, strings.NewReader("p"), strings.NewReader("r"))
+	if err != nil {
+		t.Fatalf("create intent stream: %v", err)
+	}
+	if stored.HashVersion != hash.HashVersionJCS || !hash.IsKeyed(stored.Hash) {
+		t.Fatalf("expected a keyed version %d hash, got %d %s", hash.HashVersionJCS, stored.HashVersion, stored.Hash)
+	}
+	if err := hash.VerifyHMACIntent(stored, secret); err != nil {
+		t.Fatalf("verify hmac: %v", err)
+	}
+}
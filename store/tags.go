@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+const insertIntentTagSQL = `INSERT INTO intent_tags (intent_id, tag, created_at) VALUES (?, ?, ?)`
+
+const listIntentsByTagSQL = `SELECT ` + intentColumnsQualified + ` FROM intents i
+	JOIN intent_tags t ON t.intent_id = i.id
+	WHERE t.tag = ?
+	ORDER BY t.created_at DESC, t.intent_id DESC LIMIT ?`
+
+// ListIntentsByTag returns intents carrying tag, newest first. The tag is
+// normalized like model.NormalizeTags before matching.
+func (s *Store) ListIntentsByTag(ctx context.Context, tag string, limit int) (_ []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "ListIntentsByTag")
+	defer func() { err = span.end(err) }()
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return nil, errors.New("tag is required")
+	}
+	limit = s.clampLimit(limit)
+
+	stmt, err := s.preparedRead(ctx, listIntentsByTagSQL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, tag, limit)
+	if err != nil {
+		return nil, err
+	}
+	intents, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	return s.allowed(ctx, ActionList, intents), nil
+}
+
+// insertTagsTx indexes record's normalized tags within tx.
+func (s *Store) insertTagsTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	tags := record.Normalize().Tags
+	if len(tags) == 0 {
+		return nil
+	}
+	stmt, err := s.txStmt(ctx, tx, insertIntentTagSQL)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := stmt.ExecContext(ctx, record.ID, tag, record.CreatedAt); err != nil {
+			return fmt.Errorf("tag %s: %w", tag, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListIntentsByTag(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	tagged := []struct {
+		n    int
+		tags []string
+	}{
+		{1, []string{"Review", "bug"}},
+		{2, []string{"review"}},
+		{3, nil},
+		{4, []string{" REVIEW ", "review"}},
+	}
+	for _, tc := range tagged {
+		record := testIntent(t, tc.n)
+		record.Tags = tc.tags
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create intent %d: %v", tc.n, err)
+		}
+	}
+
+	got, err := s.ListIntentsByTag(ctx, "Review", 0)
+	if err != nil {
+		t.Fatalf("list by tag: %v", err)
+	}
+	if len(got) != 3 || got[0].ID != testIntent(t, 4).ID || got[2].ID != testIntent(t, 1).ID {
+		t.Fatalf("expected three review intents newest first, got %d", len(got))
+	}
+	if tags := got[2].Tags; len(tags) != 2 || tags[0] != "bug" || tags[1] != "review" {
+		t.Fatalf("expected normalized stored tags, got %v", tags)
+	}
+
+	bugs, err := s.ListIntentsByTag(ctx, "bug", 1)
+	if err != nil || len(bugs) != 1 {
+		t.Fatalf("expected one bug intent, got %d (%v)", len(bugs), err)
+	}
+	if _, err := s.ListIntentsByTag(ctx, " ", 0); err == nil {
+		t.Fatalf("expected empty tag to be rejected")
+	}
+}
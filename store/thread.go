@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+const listThreadSQL = `SELECT ` + intentColumns + ` FROM intents WHERE thread_id = ? ORDER BY created_at ASC, id ASC`
+
+// ListThread returns the turns of threadID in conversation order: each turn
+// follows the turn it replies to, and replies to the same turn (branches) are
+// ordered by (created_at, id) with each branch kept contiguous. Turns whose
+// parent is outside the thread are treated as roots.
+func (s *Store) ListThread(ctx context.Context, threadID string) (_ []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "ListThread")
+	defer func() { err = span.end(err) }()
+	if threadID == "" {
+		return nil, errors.New("thread id is required")
+	}
+	stmt, err := s.preparedRead(ctx, listThreadSQL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	turns, err := s.collectIntents(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	return threadOrder(s.allowed(ctx, ActionList, turns)), nil
+}
+
+// threadOrder arranges turns, already sorted by (created_at, id), depth-first
+// along parent_id links.
+func threadOrder(turns []model.IntentRecord) []model.IntentRecord {
+	inThread := make(map[string]struct{}, len(turns))
+	for _, turn := range turns {
+		inThread[turn.ID] = struct{}{}
+	}
+	replies := make(map[string][]model.IntentRecord)
+	var roots []model.IntentRecord
+	for _, turn := range turns {
+		if _, ok := inThread[turn.ParentID]; ok && turn.ParentID != turn.ID {
+			replies[turn.ParentID] = append(replies[turn.ParentID], turn)
+			continue
+		}
+		roots = append(roots, turn)
+	}
+
+	ordered := make([]model.IntentRecord, 0, len(turns))
+	visited := make(map[string]struct{}, len(turns))
+	stack := make([]model.IntentRecord, 0, len(roots))
+	for i := len(roots) - 1; i >= 0; i-- {
+		stack = append(stack, roots[i])
+	}
+	for len(stack) > 0 {
+		turn := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, seen := visited[turn.ID]; seen {
+			continue
+		}
+		visited[turn.ID] = struct{}{}
+		ordered = append(ordered, turn)
+		children := replies[turn.ID]
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+	// Turns caught in a parent_id cycle are unreachable from any root; keep
+	// them rather than silently dropping data.
+	for _, turn := range turns {
+		if _, seen := visited[turn.ID]; !seen {
+			ordered = append(ordered, turn)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// threadTurn returns testIntent(n) as a turn of thread replying to parent.
+func threadTurn(t *testing.T, n int, thread, parent string) model.IntentRecord {
+	t.Helper()
+	record := testIntent(t, n)
+	record.ThreadID = thread
+	record.ParentID = parent
+	sum, err := hash.HashIntent(record)
+	if err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	record.Hash = sum
+	return record
+}
+
+func TestListThreadOrdersTurnsByParent(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	root := threadTurn(t, 1, "t-1", "")
+	// The reply is timestamped before its parent (clock skew) and must still follow it.
+	reply := threadTurn(t, 0, "t-1", root.ID)
+	branchA := threadTurn(t, 3, "t-1", reply.ID)
+	branchB := threadTurn(t, 2, "t-1", root.ID)
+	other := threadTurn(t, 4, "t-2", "")
+	for _, record := range []model.IntentRecord{root, reply, branchA, branchB, other} {
+		if err := s.CreateIntent(ctx, record); err != nil {
+			t.Fatalf("create %s: %v", record.ID, err)
+		}
+	}
+
+	turns, err := s.ListThread(ctx, "t-1")
+	if err != nil {
+		t.Fatalf("list thread: %v", err)
+	}
+	want := []string{root.ID, reply.ID, branchA.ID, branchB.ID}
+	if len(turns) != len(want) {
+		t.Fatalf("expected %d turns, got %d", len(want), len(turns))
+	}
+	for i, id := range want {
+		if turns[i].ID != id {
+			t.Fatalf("turn %d: expected %s, got %s", i, id, turns[i].ID)
+		}
+	}
+	if turns[1].ThreadID != "t-1" || turns[1].ParentID != root.ID {
+		t.Fatalf("expected thread fields to round-trip, got %+v", turns[1])
+	}
+	if err := hash.VerifyHash(turns[1]); err != nil {
+		t.Fatalf("expected stored turn to verify: %v", err)
+	}
+
+	if _, err := s.ListThread(ctx, ""); err == nil {
+		t.Fatalf("expected empty thread id to be rejected")
+	}
+}
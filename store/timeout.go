@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Timeouts bound how long Store methods may run. A timeout only shortens the
+// caller's context; an earlier deadline the caller set still applies.
+type Timeouts struct {
+	// Default applies to every method without an entry in Methods; zero
+	// means no timeout.
+	Default time.Duration
+	// Methods overrides Default for the named methods, such as
+	// "QueryIntents" or "ApplyRetention". A zero or negative entry turns the
+	// timeout off for that method.
+	Methods map[string]time.Duration
+}
+
+// WithTimeouts gives each Store method call a deadline from t. Methods that
+// return a stream (OpenBlob, OpenIntentPrompt, OpenIntentResponse,
+// WatchIntents, Watch, and WatchSince) run under the caller's context alone,
+// since the stream outlives the call. A method that calls another, as
+// EmbedIntent calls PutEmbedding, runs under both timeouts. A method that runs
+// past its deadline returns a *TimeoutError.
+func WithTimeouts(t Timeouts) Option {
+	return func(o *options) {
+		o.timeouts = t
+	}
+}
+
+// timeout returns the timeout configured for method, or 0 for none.
+func (t Timeouts) timeout(method string) time.Duration {
+	if d, ok := t.Methods[method]; ok {
+		return max(d, 0)
+	}
+	return t.Default
+}
+
+// TimeoutError reports a Store method whose context deadline passed before
+// it finished. It matches context.DeadlineExceeded and the error the method
+// failed with.
+type TimeoutError struct {
+	Method string
+	Err    error
+}
+
+func (e *TimeoutError) Error() string {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return fmt.Sprintf("%s: %v", e.Method, e.Err)
+	}
+	return fmt.Sprintf("%s: %v: %v", e.Method, context.DeadlineExceeded, e.Err)
+}
+
+// Unwrap lets errors.Is match context.DeadlineExceeded and Err.
+func (e *TimeoutError) Unwrap() []error {
+	return []error{context.DeadlineExceeded, e.Err}
+}
+
+// withTimeout bounds ctx by the timeout configured for method. The returned
+// cancel func is never nil.
+func (s *Store) withTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	d := s.opts.timeouts.timeout(method)
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// timedOut converts err from method into a *TimeoutError when ctx's deadline
+// has passed, since SQLite reports an interrupted statement with its own
+// error rather than ctx.Err(). Errors already converted by a nested method
+// pass through.
+func timedOut(ctx context.Context, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var te *TimeoutError
+	if errors.As(err, &te) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Method: method, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTimeouts(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithTimeouts(Timeouts{
+		Default: time.Nanosecond,
+		Methods: map[string]time.Duration{"MigrateWithOptions": 0, "CreateIntent": 0, "PutBlob": 0},
+	}))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent without a timeout: %v", err)
+	}
+
+	_, err = s.ListIntents(ctx, 0)
+	var te *TimeoutError
+	if !errors.As(err, &te) || te.Method != "ListIntents" || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a ListIntents TimeoutError, got %v", err)
+	}
+
+	digest, err := s.PutBlob(ctx, []byte("attachment"))
+	if err != nil {
+		t.Fatalf("put blob: %v", err)
+	}
+	rc, err := s.OpenBlob(ctx, digest)
+	if err != nil {
+		t.Fatalf("open blob: %v", err)
+	}
+	defer rc.Close()
+	if data, err := io.ReadAll(rc); err != nil || string(data) != "attachment" {
+		t.Fatalf("expected the blob stream to outlive the call, got %q, %v", data, err)
+	}
+}
+
+func TestCallerDeadline(t *testing.T) {
+	s := openTestStore(t)
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(context.Background(), record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err := s.GetIntent(ctx, record.ID)
+	var te *TimeoutError
+	if !errors.As(err, &te) || te.Method != "GetIntent" || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a GetIntent TimeoutError, got %v", err)
+	}
+}
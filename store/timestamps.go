@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TimestampToken is an RFC 3161 timestamp token over an intent hash or a
+// chain head, as returned by a timestamp authority.
+type TimestampToken struct {
+	ID int64
+	// Hash is the intent hash the token covers.
+	Hash string
+	// Chain is set when the token was requested for the head of a chain.
+	Chain string
+	// Authority is the URL of the timestamp authority that issued the token.
+	Authority string
+	// Token is the DER-encoded TimeStampToken.
+	Token []byte
+	// GenTime is the time the authority asserts, in RFC 3339 form.
+	GenTime   string
+	CreatedAt string
+}
+
+const timestampTokenColumns = `id, hash, chain, authority, token, gen_time, created_at`
+
+// SaveTimestampToken stores t and returns it with ID and CreatedAt set. The
+// token is stored as given; verify it before saving.
+func (s *Store) SaveTimestampToken(ctx context.Context, t TimestampToken) (_ TimestampToken, err error) {
+	ctx, span := s.startSpan(ctx, "SaveTimestampToken")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("SaveTimestampToken"); err != nil {
+		return TimestampToken{}, err
+	}
+	if s.db == nil {
+		return TimestampToken{}, errors.New("store not initialized")
+	}
+	if t.Hash == "" || len(t.Token) == 0 {
+		return TimestampToken{}, errors.New("timestamp token requires a hash and a token")
+	}
+	t.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	res, err := s.db.ExecContext(ctx, `INSERT INTO timestamp_tokens (hash, chain, authority, token, gen_time, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, t.Hash, t.Chain, t.Authority, t.Token, t.GenTime, t.CreatedAt)
+	if err != nil {
+		return TimestampToken{}, err
+	}
+	if t.ID, err = res.LastInsertId(); err != nil {
+		return TimestampToken{}, err
+	}
+	return t, nil
+}
+
+// TimestampTokens returns the tokens covering hash, oldest first.
+func (s *Store) TimestampTokens(ctx context.Context, hash string) (_ []TimestampToken, err error) {
+	ctx, span := s.startSpan(ctx, "TimestampTokens")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+timestampTokenColumns+` FROM timestamp_tokens
+		WHERE hash = ? ORDER BY id ASC`, hash)
+	if err != nil {
+		return nil, err
+	}
+	return collectTimestampTokens(rows)
+}
+
+// LatestChainTimestamp returns the most recent token taken over the head of
+// chain, or a *NotFoundError if there is none.
+func (s *Store) LatestChainTimestamp(ctx context.Context, chain string) (_ TimestampToken, err error) {
+	ctx, span := s.startSpan(ctx, "LatestChainTimestamp")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return TimestampToken{}, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+timestampTokenColumns+` FROM timestamp_tokens
+		WHERE chain = ? AND chain <> '' ORDER BY id DESC LIMIT 1`, chain)
+	if err != nil {
+		return TimestampToken{}, err
+	}
+	tokens, err := collectTimestampTokens(rows)
+	if err != nil {
+		return TimestampToken{}, err
+	}
+	if len(tokens) == 0 {
+		return TimestampToken{}, &NotFoundError{Kind: "chain timestamp", Key: chain}
+	}
+	return tokens[0], nil
+}
+
+func collectTimestampTokens(rows *sql.Rows) ([]TimestampToken, error) {
+	defer rows.Close()
+	var tokens []TimestampToken
+	for rows.Next() {
+		var t TimestampToken
+		if err := rows.Scan(&t.ID, &t.Hash, &t.Chain, &t.Authority, &t.Token, &t.GenTime, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestTimestampTokens(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	if _, err := s.LatestChainTimestamp(ctx, "alice"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+	if _, err := s.SaveTimestampToken(ctx, TimestampToken{Hash: "h1"}); err == nil {
+		t.Fatal("expected error for a token without bytes")
+	}
+
+	first, err := s.SaveTimestampToken(ctx, TimestampToken{Hash: "h1", Authority: "https://tsa.test", Token: []byte{1}, GenTime: "2026-01-01T00:00:00Z"})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	head, err := s.SaveTimestampToken(ctx, TimestampToken{Hash: "h1", Chain: "alice", Authority: "https://tsa.test", Token: []byte{2}, GenTime: "2026-01-02T00:00:00Z"})
+	if err != nil {
+		t.Fatalf("save chain head: %v", err)
+	}
+	if first.ID == 0 || head.ID <= first.ID || head.CreatedAt == "" {
+		t.Fatalf("unexpected saved tokens %+v %+v", first, head)
+	}
+
+	tokens, err := s.TimestampTokens(ctx, "h1")
+	if err != nil {
+		t.Fatalf("tokens: %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].ID != first.ID || tokens[1].Chain != "alice" || tokens[1].Token[0] != 2 {
+		t.Fatalf("unexpected tokens %+v", tokens)
+	}
+	latest, err := s.LatestChainTimestamp(ctx, "alice")
+	if err != nil || latest.ID != head.ID {
+		t.Fatalf("expected latest chain token %d, got %+v (%v)", head.ID, latest, err)
+	}
+}
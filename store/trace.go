@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// tracerName identifies this package's spans.
+const tracerName = "github.com/chuxorg/chux-yanzi-core/store"
+
+// WithTracerProvider records a span for every Store method, and for intent
+// hashing within them, using tp. Without it the global provider from
+// otel.GetTracerProvider is used, which does nothing unless one is set.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+func (s *Store) tracer() trace.Tracer {
+	tp := s.opts.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// opSpan is the span of one Store method call. It also carries the
+// method's timeout, which ending the span releases.
+type opSpan struct {
+	trace.Span
+	ctx    context.Context
+	method string
+	cancel context.CancelFunc
+}
+
+// startSpan starts a span named "Store.<method>" as a child of any span in
+// ctx and applies the method's timeout to the returned context. End it with
+// opSpan.end.
+func (s *Store) startSpan(ctx context.Context, method string) (context.Context, *opSpan) {
+	ctx, span := s.startStreamSpan(ctx, method)
+	ctx, span.cancel = s.withTimeout(ctx, method)
+	span.ctx = ctx
+	return ctx, span
+}
+
+// startStreamSpan is startSpan without a timeout, for methods whose result
+// keeps using ctx after they return.
+func (s *Store) startStreamSpan(ctx context.Context, method string) (context.Context, *opSpan) {
+	ctx, span := s.tracer().Start(ctx, "Store."+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "sqlite")))
+	return ctx, &opSpan{Span: span, ctx: ctx, method: method, cancel: func() {}}
+}
+
+// end records err, if any, on the span, ends it, and releases the method's
+// timeout. It returns err, converted to a *TimeoutError if the deadline
+// passed.
+func (sp *opSpan) end(err error) error {
+	err = timedOut(sp.ctx, sp.method, err)
+	endSpan(sp.Span, err)
+	sp.cancel()
+	return err
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// hashIntent sets record's hash as configured with WithHashing, in its own
+// span.
+func (s *Store) hashIntent(ctx context.Context, record model.IntentRecord) (_ model.IntentRecord, err error) {
+	_, span := s.tracer().Start(ctx, "hash.HashIntent")
+	defer func() { endSpan(span, err) }()
+	h := s.opts.hashing
+	if record.HashVersion == 0 {
+		record.HashVersion = h.Version
+	}
+	var sum string
+	switch {
+	case h.HMACSecret != nil:
+		sum, err = hash.HMACIntent(record, h.HMACSecret)
+	case h.Algorithm != "":
+		sum, err = hash.HashIntentWith(record, h.Algorithm)
+	default:
+		sum, err = hash.HashIntent(record)
+	}
+	if err != nil {
+		return record, err
+	}
+	record.Hash = sum
+	return record, nil
+}
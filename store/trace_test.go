@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracerProviderRecordsSpans(t *testing.T) {
+	ctx := context.Background()
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithTracerProvider(tp))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	parentCtx, parent := tp.Tracer("test").Start(ctx, "request")
+	if _, err := s.AppendIntent(parentCtx, testIntent(t, 1)); err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	if _, err := s.GetIntent(parentCtx, "missing"); err == nil {
+		t.Fatal("expected error for a missing intent")
+	}
+	parent.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range rec.Ended() {
+		spans[span.Name()] = span
+	}
+	for _, name := range []string{"Store.MigrateWithOptions", "Store.AppendIntent", "hash.HashIntent", "Store.GetIntent"} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("no %s span among %d", name, len(spans))
+		}
+	}
+	appendSpan := spans["Store.AppendIntent"]
+	if appendSpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("AppendIntent span is not a child of the incoming span")
+	}
+	if spans["hash.HashIntent"].Parent().SpanID() != appendSpan.SpanContext().SpanID() {
+		t.Fatal("hash span is not a child of the AppendIntent span")
+	}
+	if appendSpan.Status().Code == codes.Error {
+		t.Fatalf("unexpected error status on AppendIntent: %v", appendSpan.Status())
+	}
+	if spans["Store.GetIntent"].Status().Code != codes.Error {
+		t.Fatal("expected error status on the failed GetIntent")
+	}
+}
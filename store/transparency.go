@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TransparencyEntry records that a subject (an intent hash or a checkpoint's
+// Merkle root) was published to a transparency log.
+type TransparencyEntry struct {
+	ID      int64
+	Subject string
+	// CheckpointSeq is set when Subject is a checkpoint's Merkle root.
+	CheckpointSeq int64
+	LogURL        string
+	// UUID and LogIndex identify the entry in the log.
+	UUID         string
+	LogIndex     int64
+	IntegratedAt string
+	// Entry is the log's response, inclusion proof included, as JSON.
+	Entry     []byte
+	CreatedAt string
+}
+
+const transparencyEntryColumns = `id, subject, checkpoint_seq, log_url, uuid, log_index, integrated_at, entry, created_at`
+
+// SaveTransparencyEntry stores e and returns it with ID and CreatedAt set.
+// Saving the same log entry again returns the stored copy.
+func (s *Store) SaveTransparencyEntry(ctx context.Context, e TransparencyEntry) (_ TransparencyEntry, err error) {
+	ctx, span := s.startSpan(ctx, "SaveTransparencyEntry")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("SaveTransparencyEntry"); err != nil {
+		return TransparencyEntry{}, err
+	}
+	if s.db == nil {
+		return TransparencyEntry{}, errors.New("store not initialized")
+	}
+	if e.Subject == "" || e.LogURL == "" || e.UUID == "" || len(e.Entry) == 0 {
+		return TransparencyEntry{}, errors.New("transparency entry requires a subject, log URL, UUID, and entry")
+	}
+	e.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	_, err = s.db.ExecContext(ctx, `INSERT INTO transparency_entries (subject, checkpoint_seq, log_url, uuid, log_index,
+		integrated_at, entry, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (log_url, uuid) DO NOTHING`,
+		e.Subject, e.CheckpointSeq, e.LogURL, e.UUID, e.LogIndex, e.IntegratedAt, e.Entry, e.CreatedAt)
+	if err != nil {
+		return TransparencyEntry{}, err
+	}
+	row := s.rdb.QueryRowContext(ctx, `SELECT `+transparencyEntryColumns+` FROM transparency_entries
+		WHERE log_url = ? AND uuid = ?`, e.LogURL, e.UUID)
+	return scanTransparencyEntry(row)
+}
+
+// TransparencyEntries returns the entries published for subject, oldest
+// first.
+func (s *Store) TransparencyEntries(ctx context.Context, subject string) (_ []TransparencyEntry, err error) {
+	ctx, span := s.startSpan(ctx, "TransparencyEntries")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+transparencyEntryColumns+` FROM transparency_entries
+		WHERE subject = ? ORDER BY id ASC`, subject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []TransparencyEntry
+	for rows.Next() {
+		e, err := scanTransparencyEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func scanTransparencyEntry(row rowScanner) (TransparencyEntry, error) {
+	var e TransparencyEntry
+	err := row.Scan(&e.ID, &e.Subject, &e.CheckpointSeq, &e.LogURL, &e.UUID, &e.LogIndex, &e.IntegratedAt, &e.Entry, &e.CreatedAt)
+	return e, err
+}
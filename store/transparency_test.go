@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTransparencyEntries(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	if _, err := s.SaveTransparencyEntry(ctx, TransparencyEntry{Subject: "root", LogURL: "https://log.test"}); err == nil {
+		t.Fatal("expected error for an entry without a UUID")
+	}
+	e := TransparencyEntry{
+		Subject:       "root",
+		CheckpointSeq: 2,
+		LogURL:        "https://log.test",
+		UUID:          "abc",
+		LogIndex:      7,
+		IntegratedAt:  "2023-11-14T22:13:20Z",
+		Entry:         []byte(`{"uuid":"abc"}`),
+	}
+	first, err := s.SaveTransparencyEntry(ctx, e)
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if first.ID == 0 || first.CreatedAt == "" || first.LogIndex != 7 {
+		t.Fatalf("unexpected entry %+v", first)
+	}
+	again, err := s.SaveTransparencyEntry(ctx, e)
+	if err != nil || again.ID != first.ID || again.CreatedAt != first.CreatedAt {
+		t.Fatalf("expected saving again to return entry %d, got %+v (%v)", first.ID, again, err)
+	}
+
+	entries, err := s.TransparencyEntries(ctx, "root")
+	if err != nil || len(entries) != 1 || string(entries[0].Entry) != `{"uuid":"abc"}` || entries[0].CheckpointSeq != 2 {
+		t.Fatalf("unexpected entries %+v (%v)", entries, err)
+	}
+	if entries, _ := s.TransparencyEntries(ctx, "other"); len(entries) != 0 {
+		t.Fatalf("expected no entries for another subject, got %d", len(entries))
+	}
+}
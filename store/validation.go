@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// Validation selects the checks the store runs on a record before inserting
+// it. The zero value runs none, so records are stored as given.
+type Validation struct {
+	// Fields runs model.IntentRecord.Validate.
+	Fields bool
+	// Hash recomputes the hash with the record's algorithm and rejects a
+	// mismatch. Tombstones carried over by Merge have no content to hash
+	// and are checked with model.IntentRecord.Validate instead.
+	Hash bool
+	// HMACSecret verifies keyed (hmac-sha256) hashes when Hash is set.
+	// Without it, records with keyed hashes are rejected.
+	HMACSecret []byte
+	// PrevHash requires a non-empty PrevHash to name a stored intent,
+	// including one inserted earlier in the same batch.
+	PrevHash bool
+}
+
+// WithValidation makes CreateIntent, CreateIntents, CreateIntentIdempotent,
+// CreateIntentStream, and Merge run v's checks on each record after
+// redaction. Failures match ErrInvalidRecord, ErrHashMismatch, or
+// ErrChainBroken, and nothing from the call is stored. AppendIntent computes
+// the hash and prev_hash itself, so only Fields applies to it.
+func WithValidation(v Validation) Option {
+	return func(o *options) {
+		o.validation = v
+	}
+}
+
+// selectIntentIDByHashSQL resolves a prev_hash for Validation.PrevHash.
+const selectIntentIDByHashSQL = `SELECT id FROM intents WHERE hash = ?`
+
+// validateTx runs the configured checks on record, resolving prev_hash
+// within tx.
+func (s *Store) validateTx(ctx context.Context, tx *sql.Tx, record model.IntentRecord) error {
+	v := s.opts.validation
+	if v.Fields {
+		if err := record.Validate(); err != nil {
+			return err
+		}
+	}
+	if v.Hash {
+		if err := verifyRecordHash(record, v.HMACSecret); err != nil {
+			return err
+		}
+	}
+	if v.PrevHash && record.PrevHash != "" {
+		stmt, err := s.txStmt(ctx, tx, selectIntentIDByHashSQL)
+		if err != nil {
+			return err
+		}
+		var id string
+		err = stmt.QueryRowContext(ctx, record.PrevHash).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: prev_hash %s not found", ErrChainBroken, record.PrevHash)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyRecordHash checks record's hash, using secret for keyed hashes.
+func verifyRecordHash(record model.IntentRecord, secret []byte) error {
+	if hash.IsKeyed(record.Hash) {
+		if secret == nil {
+			return &model.InvalidRecordError{Err: errors.New("keyed hash cannot be verified without Validation.HMACSecret")}
+		}
+		return hash.VerifyHMACIntent(record, secret)
+	}
+	return hash.VerifyHash(record)
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuxorg/chux-yanzi-core/hash"
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+func TestCreateIntentValidation(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithValidation(Validation{Fields: true, Hash: true, PrevHash: true}))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	incomplete := testIntent(t, 1)
+	incomplete.Author = ""
+	if err := s.CreateIntent(ctx, incomplete); !errors.Is(err, ErrInvalidRecord) {
+		t.Fatalf("expected ErrInvalidRecord for a missing author, got %v", err)
+	}
+
+	altered := testIntent(t, 2)
+	altered.Prompt = "altered"
+	if err := s.CreateIntent(ctx, altered); !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("expected ErrHashMismatch for an altered prompt, got %v", err)
+	}
+
+	orphan := testIntent(t, 3)
+	orphan.PrevHash = "sha256:missing"
+	if orphan.Hash, err = hash.HashIntent(orphan); err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	if err := s.CreateIntent(ctx, orphan); !errors.Is(err, ErrChainBroken) {
+		t.Fatalf("expected ErrChainBroken for a dangling prev_hash, got %v", err)
+	}
+
+	parent := testIntent(t, 4)
+	child := testIntent(t, 5)
+	child.PrevHash = parent.Hash
+	if child.Hash, err = hash.HashIntent(child); err != nil {
+		t.Fatalf("hash intent: %v", err)
+	}
+	if err := s.CreateIntents(ctx, []model.IntentRecord{parent, child}); err != nil {
+		t.Fatalf("expected a valid batch to be stored: %v", err)
+	}
+
+	intents, err := s.ListIntents(ctx, 0)
+	if err != nil {
+		t.Fatalf("list intents: %v", err)
+	}
+	if len(intents) != 2 {
+		t.Fatalf("expected only the valid batch to be stored, got %d intents", len(intents))
+	}
+}
+
+func TestCreateIntentWithoutValidation(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+
+	altered := testIntent(t, 1)
+	altered.Prompt = "altered"
+	if err := s.CreateIntent(ctx, altered); err != nil {
+		t.Fatalf("expected the default store to accept records as given: %v", err)
+	}
+
+	tombstoned := testIntent(t, 2)
+	tombstoned.Title, tombstoned.Prompt, tombstoned.Response = "", "", ""
+	tombstoned.TombstonedAt = "2026-02-10T10:00:00Z"
+	if err := s.CreateIntent(ctx, tombstoned); !errors.Is(err, ErrInvalidRecord) {
+		t.Fatalf("expected ErrInvalidRecord for a caller-set tombstone, got %v", err)
+	}
+	if _, err := s.GetIntent(ctx, tombstoned.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the tombstone not to be stored, got %v", err)
+	}
+}
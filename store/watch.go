@@ -0,0 +1,265 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+)
+
+// watchBatchSize bounds how many rows a single watch poll reads at once.
+const watchBatchSize = 100
+
+const watchIntentsSQL = `SELECT ` + intentColumns + ` FROM intents
+	WHERE created_at > ? OR (created_at = ? AND id > ?)
+	ORDER BY created_at ASC, id ASC LIMIT ?`
+
+// watchCursor identifies the last intent delivered by a watcher.
+type watchCursor struct {
+	createdAt string
+	id        string
+}
+
+// WatchIntents streams intents created after the call, oldest first.
+//
+// SQLite has no push notifications, so this polls every pollInterval for rows
+// past a (created_at, id) cursor and drains them in batches; a burst of inserts
+// is delivered on the next poll rather than immediately. Rows inserted with a
+// created_at earlier than the cursor are not observed. Failed polls are retried
+// on the next tick. The channel is closed when ctx is cancelled.
+func (s *Store) WatchIntents(ctx context.Context, pollInterval time.Duration) (_ <-chan model.IntentRecord, err error) {
+	ctx, span := s.startStreamSpan(ctx, "WatchIntents")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	if pollInterval <= 0 {
+		return nil, errors.New("poll interval must be positive")
+	}
+
+	cursor, err := s.latestCursor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan model.IntentRecord, watchBatchSize)
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			for {
+				batch, err := s.intentsAfter(ctx, cursor, watchBatchSize)
+				if err != nil {
+					break
+				}
+				for _, record := range batch {
+					select {
+					case out <- record:
+						cursor = watchCursor{createdAt: record.CreatedAt, id: record.ID}
+					case <-ctx.Done():
+						return
+					}
+				}
+				if len(batch) < watchBatchSize {
+					break
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *Store) latestCursor(ctx context.Context) (watchCursor, error) {
+	var cursor watchCursor
+	err := s.rdb.QueryRowContext(ctx, `SELECT created_at, id FROM intents ORDER BY created_at DESC, id DESC LIMIT 1`).Scan(&cursor.createdAt, &cursor.id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return watchCursor{}, nil
+	}
+	return cursor, err
+}
+
+func (s *Store) intentsAfter(ctx context.Context, cursor watchCursor, limit int) ([]model.IntentRecord, error) {
+	stmt, err := s.preparedRead(ctx, watchIntentsSQL)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, cursor.createdAt, cursor.createdAt, cursor.id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.collectIntents(ctx, rows)
+}
+
+// ListIntentsAfter returns intents after the (createdAt, id) position in
+// canonical ascending order, for callers that walk the whole store
+// incrementally. Empty createdAt and id start from the beginning; limit is
+// clamped like ListIntents.
+func (s *Store) ListIntentsAfter(ctx context.Context, createdAt, id string, limit int) (_ []model.IntentRecord, err error) {
+	ctx, span := s.startSpan(ctx, "ListIntentsAfter")
+	defer func() { err = span.end(err) }()
+	return s.intentsAfter(ctx, watchCursor{createdAt: createdAt, id: id}, s.clampLimit(limit))
+}
+
+// DefaultWatchInterval is how often Watch polls the changelog unless
+// overridden with WithWatchInterval.
+const DefaultWatchInterval = 250 * time.Millisecond
+
+// WithWatchInterval sets how often Watch and WatchSince poll the changelog;
+// values <= 0 keep the default.
+func WithWatchInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.watchInterval = d
+		}
+	}
+}
+
+// EventKind classifies an IntentEvent.
+type EventKind string
+
+const (
+	// EventCreated reports an inserted intent.
+	EventCreated EventKind = "created"
+	// EventTombstoned reports an intent whose content retention or erasure removed.
+	EventTombstoned EventKind = "tombstoned"
+	// EventUpdated reports any other change to a stored intent, such as a rehash.
+	EventUpdated EventKind = "updated"
+	// EventDeleted reports a removed intent, such as one replaced by Merge.
+	EventDeleted EventKind = "deleted"
+)
+
+// IntentEvent is one change delivered by Watch.
+type IntentEvent struct {
+	// Seq is the changelog sequence; pass the last one handled to WatchSince
+	// to resume.
+	Seq      int64
+	Kind     EventKind
+	IntentID string
+	// Intent is the record as stored when the event was delivered; it is
+	// zero for deletions and for intents deleted since the change.
+	Intent    model.IntentRecord
+	ChangedAt string
+}
+
+// Watch delivers events for changes made after the call. See WatchSince.
+func (s *Store) Watch(ctx context.Context) (_ <-chan IntentEvent, err error) {
+	ctx, span := s.startStreamSpan(ctx, "Watch")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	var seq int64
+	if err := s.rdb.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM intent_changelog`).Scan(&seq); err != nil {
+		return nil, err
+	}
+	return s.WatchSince(ctx, seq)
+}
+
+// WatchSince delivers events for changelog entries after sinceSeq, in order.
+// The changelog is written by triggers in the same transaction as each change,
+// so no committed change is missed, and consumers that persist the last Seq
+// they handled can resume after a restart. Like WatchIntents it polls (every
+// WithWatchInterval); failed polls are retried on the next tick. The channel is
+// closed when ctx is cancelled.
+func (s *Store) WatchSince(ctx context.Context, sinceSeq int64) (_ <-chan IntentEvent, err error) {
+	ctx, span := s.startStreamSpan(ctx, "WatchSince")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	interval := s.opts.watchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	out := make(chan IntentEvent, watchBatchSize)
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		seq := sinceSeq
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			var ok bool
+			if seq, ok = s.deliverEvents(ctx, seq, out); !ok {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// deliverEvents sends the events after seq until the changelog is drained or a
+// read fails, and returns the last delivered sequence. It reports false once
+// ctx is done.
+func (s *Store) deliverEvents(ctx context.Context, seq int64, out chan<- IntentEvent) (int64, bool) {
+	for {
+		changes, err := s.ReadChangelog(ctx, seq, watchBatchSize)
+		if err != nil {
+			return seq, ctx.Err() == nil
+		}
+		for _, change := range changes {
+			event, err := s.intentEvent(ctx, change)
+			if err != nil {
+				return seq, ctx.Err() == nil
+			}
+			select {
+			case out <- event:
+				seq = change.Seq
+			case <-ctx.Done():
+				return seq, false
+			}
+		}
+		if len(changes) < watchBatchSize {
+			return seq, true
+		}
+	}
+}
+
+// intentEvent resolves a changelog entry into an event. Only the update that
+// set tombstoned_at is reported as EventTombstoned; later updates to a
+// tombstoned intent are EventUpdated.
+func (s *Store) intentEvent(ctx context.Context, change ChangeEvent) (IntentEvent, error) {
+	event := IntentEvent{Seq: change.Seq, IntentID: change.IntentID, ChangedAt: change.ChangedAt}
+	switch change.Op {
+	case ChangeInsert:
+		event.Kind = EventCreated
+	case ChangeDelete:
+		event.Kind = EventDeleted
+		return event, nil
+	case ChangeUpdate:
+		event.Kind = EventUpdated
+		if change.Tombstoned {
+			event.Kind = EventTombstoned
+		}
+	default:
+		event.Kind = EventUpdated
+	}
+
+	record, err := s.GetIntent(ctx, change.IntentID)
+	if errors.Is(err, ErrNotFound) {
+		return event, nil
+	}
+	if err != nil {
+		return event, err
+	}
+	event.Intent = record
+	return event, nil
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchIntentsDeliversNewIntentsInOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := openTestStore(t)
+
+	if err := s.CreateIntent(ctx, testIntent(t, 0)); err != nil {
+		t.Fatalf("create existing intent: %v", err)
+	}
+
+	events, err := s.WatchIntents(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("watch intents: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := s.CreateIntent(ctx, testIntent(t, i)); err != nil {
+			t.Fatalf("create intent %d: %v", i, err)
+		}
+	}
+
+	for i := 1; i <= 3; i++ {
+		want := testIntent(t, i).ID
+		select {
+		case got := <-events:
+			if got.ID != want {
+				t.Fatalf("expected %s, got %s", want, got.ID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	cancel()
+	select {
+	case record, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected intent after cancel: %s", record.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("watch channel not closed after cancel")
+	}
+}
+
+// nextEvent waits for the next event on events.
+func nextEvent(t *testing.T, events <-chan IntentEvent) IntentEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("watch channel closed")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return IntentEvent{}
+}
+
+func TestWatchDeliversCreateAndTombstone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, err := Open(filepath.Join(t.TempDir(), "yanzi.db"), WithWatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := s.CreateIntent(ctx, testIntent(t, 0)); err != nil {
+		t.Fatalf("create existing intent: %v", err)
+	}
+
+	events, err := s.Watch(ctx)
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	record := testIntent(t, 1)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	created := nextEvent(t, events)
+	if created.Kind != EventCreated || created.IntentID != record.ID || created.Intent.Hash != record.Hash {
+		t.Fatalf("unexpected create event %+v", created)
+	}
+
+	if _, err := s.EraseAuthor(ctx, "alice", nil); err != nil {
+		t.Fatalf("erase: %v", err)
+	}
+	tombstoned := map[string]bool{}
+	for range 2 {
+		event := nextEvent(t, events)
+		if event.Kind != EventTombstoned || !event.Intent.Tombstoned() {
+			t.Fatalf("unexpected tombstone event %+v", event)
+		}
+		tombstoned[event.IntentID] = true
+	}
+	if !tombstoned[testIntent(t, 0).ID] || !tombstoned[record.ID] {
+		t.Fatalf("expected both intents tombstoned, got %v", tombstoned)
+	}
+
+	// A watcher resuming from the create event replays what followed it.
+	resumed, err := s.WatchSince(ctx, created.Seq)
+	if err != nil {
+		t.Fatalf("watch since: %v", err)
+	}
+	if event := nextEvent(t, resumed); event.Kind != EventTombstoned || event.Seq <= created.Seq {
+		t.Fatalf("expected to resume after seq %d, got %+v", created.Seq, event)
+	}
+
+	// Later updates to a tombstoned intent are not tombstones.
+	if _, err := s.db.ExecContext(ctx, `UPDATE intents SET signature = NULL WHERE id = ?`, record.ID); err != nil {
+		t.Fatalf("update tombstoned intent: %v", err)
+	}
+	if event := nextEvent(t, events); event.Kind != EventUpdated || event.IntentID != record.ID {
+		t.Fatalf("expected an update event, got %+v", event)
+	}
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DeliveryStatus is the state of a WebhookDelivery.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed means the delivery ran out of attempts.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery tracks sending one intent to one webhook URL.
+type WebhookDelivery struct {
+	ID       int64
+	URL      string
+	IntentID string
+	// Seq is the changelog sequence of the intent's creation.
+	Seq            int64
+	Status         DeliveryStatus
+	Attempts       int
+	LastError      string
+	LastStatusCode int
+	NextAttemptAt  string
+	CreatedAt      string
+	// DeliveredAt is empty until the delivery succeeds.
+	DeliveredAt string
+}
+
+const webhookDeliveryColumns = `id, url, intent_id, seq, status, attempts, last_error, last_status_code, next_attempt_at, created_at, delivered_at`
+
+const insertWebhookDeliverySQL = `INSERT INTO webhook_deliveries (url, intent_id, seq, status, next_attempt_at, created_at)
+	VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (url, seq) DO NOTHING`
+
+// EnqueueWebhookDeliveries records a pending delivery of intentID to each of
+// urls, due immediately, in one transaction, so LastWebhookSeq never reports
+// seq while some URL is missing it. Deliveries are keyed by URL and seq;
+// enqueueing the same pair again is a no-op.
+func (s *Store) EnqueueWebhookDeliveries(ctx context.Context, urls []string, intentID string, seq int64) (err error) {
+	ctx, span := s.startSpan(ctx, "EnqueueWebhookDeliveries")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("EnqueueWebhookDeliveries"); err != nil {
+		return err
+	}
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	now := s.now().Format(time.RFC3339Nano)
+	return s.withRetryTx(ctx, "EnqueueWebhookDeliveries", func(tx *sql.Tx) error {
+		stmt, err := s.txStmt(ctx, tx, insertWebhookDeliverySQL)
+		if err != nil {
+			return err
+		}
+		for _, url := range urls {
+			if _, err := stmt.ExecContext(ctx, url, intentID, seq, DeliveryPending, now, now); err != nil {
+				return fmt.Errorf("enqueue delivery to %s: %w", url, err)
+			}
+		}
+		return nil
+	})
+}
+
+// DueWebhookDeliveries returns pending deliveries whose next attempt is at or
+// before now, earliest first.
+func (s *Store) DueWebhookDeliveries(ctx context.Context, now time.Time, limit int) (_ []WebhookDelivery, err error) {
+	ctx, span := s.startSpan(ctx, "DueWebhookDeliveries")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE status = ? AND julianday(next_attempt_at) <= julianday(?)
+		ORDER BY next_attempt_at ASC, id ASC LIMIT ?`,
+		DeliveryPending, now.UTC().Format(time.RFC3339Nano), s.clampLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	return collectWebhookDeliveries(rows)
+}
+
+// UpdateWebhookDelivery stores the outcome of an attempt: Status, Attempts,
+// LastError, LastStatusCode, NextAttemptAt, and DeliveredAt. It returns a
+// *NotFoundError if the delivery does not exist.
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, d WebhookDelivery) (err error) {
+	ctx, span := s.startSpan(ctx, "UpdateWebhookDelivery")
+	defer func() { err = span.end(err) }()
+	if err := s.writable("UpdateWebhookDelivery"); err != nil {
+		return err
+	}
+	if s.db == nil {
+		return errors.New("store not initialized")
+	}
+	var deliveredAt any
+	if d.DeliveredAt != "" {
+		deliveredAt = d.DeliveredAt
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE webhook_deliveries SET status = ?, attempts = ?, last_error = ?,
+		last_status_code = ?, next_attempt_at = ?, delivered_at = ? WHERE id = ?`,
+		d.Status, d.Attempts, d.LastError, d.LastStatusCode, d.NextAttemptAt, deliveredAt, d.ID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &NotFoundError{Kind: "webhook delivery", Key: strconv.FormatInt(d.ID, 10)}
+	}
+	return nil
+}
+
+// WebhookDeliveries returns the deliveries of intentID, oldest first.
+func (s *Store) WebhookDeliveries(ctx context.Context, intentID string) (_ []WebhookDelivery, err error) {
+	ctx, span := s.startSpan(ctx, "WebhookDeliveries")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return nil, errors.New("store not initialized")
+	}
+	rows, err := s.rdb.QueryContext(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE intent_id = ? ORDER BY id ASC`, intentID)
+	if err != nil {
+		return nil, err
+	}
+	return collectWebhookDeliveries(rows)
+}
+
+// LastWebhookSeq returns the highest changelog sequence enqueued for delivery,
+// or zero if none was, so a dispatcher can resume where it stopped.
+func (s *Store) LastWebhookSeq(ctx context.Context) (_ int64, err error) {
+	ctx, span := s.startSpan(ctx, "LastWebhookSeq")
+	defer func() { err = span.end(err) }()
+	if s.db == nil {
+		return 0, errors.New("store not initialized")
+	}
+	var seq int64
+	err = s.rdb.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM webhook_deliveries`).Scan(&seq)
+	return seq, err
+}
+
+func collectWebhookDeliveries(rows *sql.Rows) ([]WebhookDelivery, error) {
+	defer rows.Close()
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var deliveredAt sql.NullString
+		if err := rows.Scan(&d.ID, &d.URL, &d.IntentID, &d.Seq, &d.Status, &d.Attempts, &d.LastError,
+			&d.LastStatusCode, &d.NextAttemptAt, &d.CreatedAt, &deliveredAt); err != nil {
+			return nil, err
+		}
+		d.DeliveredAt = deliveredAt.String
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWebhookDeliveriesLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := testIntent(t, 0)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := s.EnqueueWebhookDeliveries(ctx, []string{"https://example.test/hook"}, record.ID, 7); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	due, err := s.DueWebhookDeliveries(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("due: %v", err)
+	}
+	if len(due) != 1 || due[0].Status != DeliveryPending || due[0].Seq != 7 {
+		t.Fatalf("expected one pending delivery, got %+v", due)
+	}
+	if seq, err := s.LastWebhookSeq(ctx); err != nil || seq != 7 {
+		t.Fatalf("expected last seq 7, got %d (%v)", seq, err)
+	}
+
+	retry := due[0]
+	retry.Attempts = 1
+	retry.LastError = "endpoint returned 503"
+	retry.NextAttemptAt = time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	if err := s.UpdateWebhookDelivery(ctx, retry); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if due, err := s.DueWebhookDeliveries(ctx, time.Now(), 10); err != nil || len(due) != 0 {
+		t.Fatalf("expected nothing due before the retry time, got %+v (%v)", due, err)
+	}
+	if due, err := s.DueWebhookDeliveries(ctx, time.Now().Add(2*time.Hour), 10); err != nil || len(due) != 1 {
+		t.Fatalf("expected the retry to come due, got %+v (%v)", due, err)
+	}
+
+	retry.Status = DeliveryDelivered
+	retry.Attempts = 2
+	retry.DeliveredAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.UpdateWebhookDelivery(ctx, retry); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, err := s.WebhookDeliveries(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if len(got) != 1 || got[0].Status != DeliveryDelivered || got[0].DeliveredAt == "" || got[0].LastError != "endpoint returned 503" {
+		t.Fatalf("unexpected delivery: %+v", got)
+	}
+
+	retry.ID = 999
+	if err := s.UpdateWebhookDelivery(ctx, retry); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestWebhookDeliveriesKeyedBySeq(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	record := testIntent(t, 0)
+	if err := s.CreateIntent(ctx, record); err != nil {
+		t.Fatalf("create intent: %v", err)
+	}
+
+	urls := []string{"https://a.example.test/hook", "https://b.example.test/hook"}
+	for _, seq := range []int64{7, 7, 9} {
+		if err := s.EnqueueWebhookDeliveries(ctx, urls, record.ID, seq); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	got, err := s.WebhookDeliveries(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected one delivery per URL and seq, got %+v", got)
+	}
+}
@@ -0,0 +1,214 @@
+// Package tsa obtains and checks RFC 3161 timestamp tokens over intent hashes,
+// giving auditors third-party proof that a record existed at a claimed time.
+//
+// A token covers the SHA-256 digest of a hash string exactly as stored (for
+// example "sha256:9f86…"), so any hash algorithm can be stamped. Stamping a
+// chain head covers every intent before it, since each links to its
+// predecessor:
+//
+//	client := tsa.New("https://freetsa.org/tsr")
+//	tok, err := tsa.StampChainHead(ctx, s, client, "alice")
+//	// later
+//	info, err := tsa.Verify(tok.Token, tok.Hash, roots)
+package tsa
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// DefaultTimeout bounds each request to the authority unless the client is
+// replaced with WithHTTPClient.
+const DefaultTimeout = 30 * time.Second
+
+// maxResponseBytes bounds the response read from an authority.
+const maxResponseBytes = 1 << 20
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the client used to reach the authority.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		if c != nil {
+			cl.http = c
+		}
+	}
+}
+
+// WithPolicy requests tokens under a specific TSA policy.
+func WithPolicy(policy asn1.ObjectIdentifier) Option {
+	return func(cl *Client) {
+		cl.policy = policy
+	}
+}
+
+// Client requests timestamp tokens from one authority.
+type Client struct {
+	url    string
+	http   *http.Client
+	policy asn1.ObjectIdentifier
+}
+
+// New returns a Client for the authority at url.
+func New(url string, opts ...Option) *Client {
+	c := &Client{url: url, http: &http.Client{Timeout: DefaultTimeout}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// URL returns the authority's URL.
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Digest returns the message imprint stamped for hash.
+func Digest(hash string) []byte {
+	sum := sha256.Sum256([]byte(hash))
+	return sum[:]
+}
+
+// Stamp requests a token over hash, verifies that it answers the request, and
+// returns it with the time the authority asserts. It does not check the
+// authority's certificate; use Verify for that.
+func (c *Client) Stamp(ctx context.Context, hash string) ([]byte, time.Time, error) {
+	if hash == "" {
+		return nil, time.Time{}, errors.New("hash is required")
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	digest := Digest(hash)
+	req, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		ReqPolicy: c.policy,
+		Nonce:     nonce,
+		CertReq:   true,
+	})
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("encode timestamp request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(req))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	httpReq.Header.Set("Accept", "application/timestamp-reply")
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("request timestamp: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("read timestamp response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("timestamp authority returned %s", resp.Status)
+	}
+
+	token, err := parseResponse(body)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	info, err := parseToken(token)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if info.Nonce == nil || info.Nonce.Cmp(nonce) != 0 {
+		return nil, time.Time{}, errors.New("timestamp token nonce does not match the request")
+	}
+	if err := checkImprint(info.tst, hash); err != nil {
+		return nil, time.Time{}, err
+	}
+	return token, info.GenTime, nil
+}
+
+// parseResponse checks a TimeStampResp's status and returns its token.
+func parseResponse(der []byte) ([]byte, error) {
+	var resp timeStampResp
+	rest, err := asn1.Unmarshal(der, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("decode timestamp response: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("decode timestamp response: trailing data")
+	}
+	// 0 is granted, 1 granted with modifications.
+	if resp.Status.Status > 1 {
+		msg := strings.Join(resp.Status.StatusString, "; ")
+		if msg == "" {
+			msg = "no reason given"
+		}
+		return nil, fmt.Errorf("timestamp request rejected (status %d): %s", resp.Status.Status, msg)
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, errors.New("timestamp response has no token")
+	}
+	return resp.TimeStampToken.FullBytes, nil
+}
+
+// StampIntent requests a token over the hash of the intent with id and saves
+// it.
+func StampIntent(ctx context.Context, s *store.Store, c *Client, id string) (store.TimestampToken, error) {
+	record, err := s.GetIntent(ctx, id)
+	if err != nil {
+		return store.TimestampToken{}, err
+	}
+	return stamp(ctx, s, c, record.Hash, "")
+}
+
+// StampChainHead requests a token over the current head of chain and saves
+// it.
+func StampChainHead(ctx context.Context, s *store.Store, c *Client, chain string) (store.TimestampToken, error) {
+	head, err := s.ChainHead(ctx, chain)
+	if err != nil {
+		return store.TimestampToken{}, err
+	}
+	return stamp(ctx, s, c, head, chain)
+}
+
+func stamp(ctx context.Context, s *store.Store, c *Client, hash, chain string) (store.TimestampToken, error) {
+	token, genTime, err := c.Stamp(ctx, hash)
+	if err != nil {
+		return store.TimestampToken{}, err
+	}
+	return s.SaveTimestampToken(ctx, store.TimestampToken{
+		Hash:      hash,
+		Chain:     chain,
+		Authority: c.url,
+		Token:     token,
+		GenTime:   genTime.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// hashFor returns the hash a digest algorithm OID names.
+func hashFor(alg pkix.AlgorithmIdentifier) (crypto.Hash, error) {
+	h, ok := digestAlgorithms[alg.Algorithm.String()]
+	if !ok {
+		return 0, fmt.Errorf("unsupported digest algorithm %s", alg.Algorithm)
+	}
+	return h, nil
+}
@@ -0,0 +1,309 @@
+package tsa
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// testAuthority is a minimal RFC 3161 authority signing with an ECDSA key
+// certified by its own root.
+type testAuthority struct {
+	t      *testing.T
+	roots  *x509.CertPool
+	root   *x509.Certificate
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	status int
+	url    string
+}
+
+func newTestAuthority(t *testing.T) *testAuthority {
+	t.Helper()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate root key: %v", err)
+	}
+	now := time.Now()
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("create root: %v", err)
+	}
+	root, _ := x509.ParseCertificate(rootDER)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate tsa key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, root, &key.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("create tsa cert: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	a := &testAuthority{t: t, roots: x509.NewCertPool(), root: root, cert: cert, key: key}
+	a.roots.AddCert(root)
+	srv := httptest.NewServer(a)
+	t.Cleanup(srv.Close)
+	a.url = srv.URL
+	return a
+}
+
+func (a *testAuthority) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "application/timestamp-query" {
+		http.Error(w, "bad content type", http.StatusUnsupportedMediaType)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var resp timeStampResp
+	if a.status != 0 {
+		resp.Status = pkiStatusInfo{Status: a.status, StatusString: []string{"policy not accepted"}}
+	} else {
+		resp.TimeStampToken = asn1.RawValue{FullBytes: a.token(req.MessageImprint, req.Nonce)}
+	}
+	der, err := asn1.Marshal(resp)
+	if err != nil {
+		a.t.Errorf("marshal response: %v", err)
+	}
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(der)
+}
+
+// token builds a TimeStampToken as a real authority would.
+func (a *testAuthority) token(imprint messageImprint, nonce *big.Int) []byte {
+	a.t.Helper()
+	tst, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(time.Now().UnixNano()),
+		GenTime:        time.Now().UTC().Truncate(time.Second),
+		Accuracy:       accuracy{Seconds: 1},
+		Nonce:          nonce,
+	})
+	if err != nil {
+		a.t.Fatalf("marshal TSTInfo: %v", err)
+	}
+
+	digest := crypto.SHA256.New()
+	digest.Write(tst)
+	var attrs []byte
+	for _, attr := range []struct {
+		oid   asn1.ObjectIdentifier
+		value any
+	}{{oidContentType, oidTSTInfo}, {oidMessageDigest, digest.Sum(nil)}} {
+		value, _ := asn1.Marshal(attr.value)
+		der, err := asn1.Marshal(attribute{Type: attr.oid, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: value}})
+		if err != nil {
+			a.t.Fatalf("marshal attribute: %v", err)
+		}
+		attrs = append(attrs, der...)
+	}
+	signed, _ := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: attrs})
+	sum := crypto.SHA256.New()
+	sum.Write(signed)
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, sum.Sum(nil))
+	if err != nil {
+		a.t.Fatalf("sign: %v", err)
+	}
+
+	sha256Alg := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Alg},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: tst},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: append(bytes.Clone(a.cert.Raw), a.root.Raw...)},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: a.issuerAndSerial()},
+			DigestAlgorithm:    sha256Alg,
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		a.t.Fatalf("marshal SignedData: %v", err)
+	}
+	token, err := asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	if err != nil {
+		a.t.Fatalf("marshal ContentInfo: %v", err)
+	}
+	return token
+}
+
+func (a *testAuthority) issuerAndSerial() []byte {
+	der, err := asn1.Marshal(issuerAndSerial{Issuer: asn1.RawValue{FullBytes: a.cert.RawIssuer}, Serial: a.cert.SerialNumber})
+	if err != nil {
+		a.t.Fatalf("marshal signer identifier: %v", err)
+	}
+	return der
+}
+
+func openStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func appendIntent(t *testing.T, s *store.Store, prompt string) model.IntentRecord {
+	t.Helper()
+	record, err := s.AppendIntent(context.Background(), model.IntentRecord{
+		Author:     "alice",
+		SourceType: "cli",
+		Prompt:     prompt,
+		Response:   "response to " + prompt,
+	})
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	return record
+}
+
+func TestStampChainHeadVerifies(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAuthority(t)
+	s := openStore(t)
+	appendIntent(t, s, "first")
+	head := appendIntent(t, s, "second")
+
+	tok, err := StampChainHead(ctx, s, New(a.url), "alice")
+	if err != nil {
+		t.Fatalf("stamp chain head: %v", err)
+	}
+	if tok.Hash != head.Hash || tok.Chain != "alice" || tok.Authority != a.url || tok.GenTime == "" {
+		t.Fatalf("unexpected token %+v", tok)
+	}
+	latest, err := s.LatestChainTimestamp(ctx, "alice")
+	if err != nil || latest.ID != tok.ID || !bytes.Equal(latest.Token, tok.Token) {
+		t.Fatalf("expected saved token, got %+v (%v)", latest, err)
+	}
+
+	info, err := Verify(tok.Token, head.Hash, a.roots)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if info.Certificate.Subject.CommonName != "Test TSA" || info.Accuracy != time.Second {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if genTime, _ := time.Parse(time.RFC3339Nano, tok.GenTime); !genTime.Equal(info.GenTime) {
+		t.Fatalf("stored gen time %s, token asserts %s", tok.GenTime, info.GenTime)
+	}
+}
+
+func TestStampIntentSavesToken(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAuthority(t)
+	s := openStore(t)
+	record := appendIntent(t, s, "first")
+
+	tok, err := StampIntent(ctx, s, New(a.url), record.ID)
+	if err != nil {
+		t.Fatalf("stamp intent: %v", err)
+	}
+	if tok.Chain != "" {
+		t.Fatalf("expected no chain, got %q", tok.Chain)
+	}
+	tokens, err := s.TimestampTokens(ctx, record.Hash)
+	if err != nil || len(tokens) != 1 {
+		t.Fatalf("expected one saved token, got %d (%v)", len(tokens), err)
+	}
+	if _, err := Verify(tokens[0].Token, record.Hash, a.roots); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestVerifyRejectsWrongHash(t *testing.T) {
+	a := newTestAuthority(t)
+	token, _, err := New(a.url).Stamp(context.Background(), "sha256:aaaa")
+	if err != nil {
+		t.Fatalf("stamp: %v", err)
+	}
+	if _, err := Verify(token, "sha256:bbbb", a.roots); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestVerifyRejectsUntrustedAuthority(t *testing.T) {
+	a := newTestAuthority(t)
+	other := newTestAuthority(t)
+	token, _, err := New(a.url).Stamp(context.Background(), "sha256:aaaa")
+	if err != nil {
+		t.Fatalf("stamp: %v", err)
+	}
+	if _, err := Verify(token, "sha256:aaaa", other.roots); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestVerifyRejectsTamperedToken(t *testing.T) {
+	a := newTestAuthority(t)
+	token, _, err := New(a.url).Stamp(context.Background(), "sha256:aaaa")
+	if err != nil {
+		t.Fatalf("stamp: %v", err)
+	}
+	// The signature is the token's last field; flipping its final byte
+	// leaves the token well formed.
+	tampered := bytes.Clone(token)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := Verify(tampered, "sha256:aaaa", a.roots); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestStampReportsRejection(t *testing.T) {
+	a := newTestAuthority(t)
+	a.status = 2
+	if _, _, err := New(a.url).Stamp(context.Background(), "sha256:aaaa"); err == nil {
+		t.Fatal("expected rejection error")
+	}
+}
@@ -0,0 +1,240 @@
+package tsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ErrInvalidToken is wrapped by Verify errors that mean the token does not
+// prove the hash was stamped, as opposed to the token being malformed.
+var ErrInvalidToken = errors.New("invalid timestamp token")
+
+// Info is what a verified token asserts.
+type Info struct {
+	GenTime time.Time
+	// Accuracy is the authority's stated bound on GenTime; zero if unstated.
+	Accuracy     time.Duration
+	SerialNumber *big.Int
+	Policy       asn1.ObjectIdentifier
+	Nonce        *big.Int
+	// Certificate is the authority's signing certificate.
+	Certificate *x509.Certificate
+}
+
+// parsed is a decoded token before its signature is checked.
+type parsed struct {
+	Info
+	tst    tstInfo
+	sd     signedData
+	signer signerInfo
+	certs  []*x509.Certificate
+}
+
+// Verify checks that token is a timestamp over hash, signed by a
+// time-stamping certificate that chains to roots (the system pool if nil) at
+// the asserted time, and returns what it asserts.
+func Verify(token []byte, hash string, roots *x509.CertPool) (Info, error) {
+	p, err := parseToken(token)
+	if err != nil {
+		return Info{}, err
+	}
+	if err := checkImprint(p.tst, hash); err != nil {
+		return Info{}, err
+	}
+	cert, err := p.signerCertificate()
+	if err != nil {
+		return Info{}, err
+	}
+	if err := p.checkSignature(cert); err != nil {
+		return Info{}, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range p.certs {
+		if c != cert {
+			intermediates.AddCert(c)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   p.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return Info{}, fmt.Errorf("%w: authority certificate: %v", ErrInvalidToken, err)
+	}
+	p.Certificate = cert
+	return p.Info, nil
+}
+
+// parseToken decodes a TimeStampToken: a CMS SignedData wrapping a TSTInfo.
+func parseToken(der []byte) (*parsed, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("decode timestamp token: %w", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("decode timestamp token: content type %s is not signed data", ci.ContentType)
+	}
+	p := &parsed{}
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &p.sd); err != nil {
+		return nil, fmt.Errorf("decode timestamp token: %w", err)
+	}
+	if !p.sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("decode timestamp token: content type %s is not TSTInfo", p.sd.EncapContentInfo.EContentType)
+	}
+	if _, err := asn1.Unmarshal(p.sd.EncapContentInfo.EContent, &p.tst); err != nil {
+		return nil, fmt.Errorf("decode TSTInfo: %w", err)
+	}
+	if len(p.sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("decode timestamp token: %d signers, want 1", len(p.sd.SignerInfos))
+	}
+	p.signer = p.sd.SignerInfos[0]
+	if len(p.sd.Certificates.Bytes) > 0 {
+		certs, err := x509.ParseCertificates(p.sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("decode timestamp token certificates: %w", err)
+		}
+		p.certs = certs
+	}
+
+	p.GenTime = p.tst.GenTime
+	p.Accuracy = time.Duration(p.tst.Accuracy.Seconds)*time.Second +
+		time.Duration(p.tst.Accuracy.Millis)*time.Millisecond +
+		time.Duration(p.tst.Accuracy.Micros)*time.Microsecond
+	p.SerialNumber = p.tst.SerialNumber
+	p.Policy = p.tst.Policy
+	p.Nonce = p.tst.Nonce
+	return p, nil
+}
+
+// checkImprint reports whether tst covers hash.
+func checkImprint(tst tstInfo, hash string) error {
+	h, err := hashFor(tst.MessageImprint.HashAlgorithm)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	d := h.New()
+	d.Write([]byte(hash))
+	if !bytes.Equal(d.Sum(nil), tst.MessageImprint.HashedMessage) {
+		return fmt.Errorf("%w: token does not cover hash %s", ErrInvalidToken, hash)
+	}
+	return nil
+}
+
+// signerCertificate finds the certificate the signer identifier names, by
+// issuer and serial number or by subject key identifier.
+func (p *parsed) signerCertificate() (*x509.Certificate, error) {
+	sid := p.signer.SID
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, c := range p.certs {
+			if bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+				return c, nil
+			}
+		}
+	} else {
+		var ias issuerAndSerial
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+			return nil, fmt.Errorf("decode signer identifier: %w", err)
+		}
+		for _, c := range p.certs {
+			if bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) && c.SerialNumber.Cmp(ias.Serial) == 0 {
+				return c, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: signing certificate not included", ErrInvalidToken)
+}
+
+// checkSignature checks the signed attributes against the TSTInfo and the
+// signature over them against cert.
+func (p *parsed) checkSignature(cert *x509.Certificate) error {
+	if len(p.signer.SignedAttrs.Bytes) == 0 {
+		return fmt.Errorf("%w: no signed attributes", ErrInvalidToken)
+	}
+	h, err := hashFor(p.signer.DigestAlgorithm)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var contentType, digest []byte
+	for rest := p.signer.SignedAttrs.Bytes; len(rest) > 0; {
+		var attr attribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return fmt.Errorf("decode signed attributes: %w", err)
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			contentType = attr.Values.Bytes
+		case attr.Type.Equal(oidMessageDigest):
+			digest = attr.Values.Bytes
+		}
+	}
+	var ct asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(contentType, &ct); err != nil || !ct.Equal(oidTSTInfo) {
+		return fmt.Errorf("%w: signed content type is not TSTInfo", ErrInvalidToken)
+	}
+	var md []byte
+	if _, err := asn1.Unmarshal(digest, &md); err != nil {
+		return fmt.Errorf("%w: missing message digest", ErrInvalidToken)
+	}
+	d := h.New()
+	d.Write(p.sd.EncapContentInfo.EContent)
+	if !bytes.Equal(d.Sum(nil), md) {
+		return fmt.Errorf("%w: message digest does not match TSTInfo", ErrInvalidToken)
+	}
+
+	alg, err := signatureAlgorithm(p.signer, h)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	// The signature covers the attributes as a SET, not the [0] IMPLICIT
+	// encoding they are carried in.
+	signed := bytes.Clone(p.signer.SignedAttrs.FullBytes)
+	signed[0] = 0x31
+	if err := cert.CheckSignature(alg, signed, p.signer.Signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return nil
+}
+
+// signatureAlgorithm maps a signer's algorithm to x509's. CMS signers often
+// name only the key type and leave the hash to the digest algorithm.
+func signatureAlgorithm(si signerInfo, h crypto.Hash) (x509.SignatureAlgorithm, error) {
+	byHash := func(sha256, sha384, sha512 x509.SignatureAlgorithm) x509.SignatureAlgorithm {
+		switch h {
+		case crypto.SHA384:
+			return sha384
+		case crypto.SHA512:
+			return sha512
+		}
+		return sha256
+	}
+	switch oid := si.SignatureAlgorithm.Algorithm; {
+	case oid.Equal(oidRSAEncryption):
+		return byHash(x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA), nil
+	case oid.Equal(oidECPublicKey):
+		return byHash(x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512), nil
+	case oid.Equal(oidSHA256WithRSA):
+		return x509.SHA256WithRSA, nil
+	case oid.Equal(oidSHA384WithRSA):
+		return x509.SHA384WithRSA, nil
+	case oid.Equal(oidSHA512WithRSA):
+		return x509.SHA512WithRSA, nil
+	case oid.Equal(oidECDSAWithSHA256):
+		return x509.ECDSAWithSHA256, nil
+	case oid.Equal(oidECDSAWithSHA384):
+		return x509.ECDSAWithSHA384, nil
+	case oid.Equal(oidECDSAWithSHA512):
+		return x509.ECDSAWithSHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported signature algorithm %s", oid)
+	}
+}
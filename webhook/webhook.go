@@ -0,0 +1,347 @@
+// Package webhook POSTs newly created intents to configured URLs.
+//
+// A Dispatcher follows the store's change feed (store.Store.WatchSince) and
+// records one delivery per endpoint per created intent in the
+// webhook_deliveries table, then sends each delivery until the endpoint
+// answers 2xx or the attempts run out, backing off exponentially between
+// tries. Deliveries survive restarts: a new Dispatcher resumes after the last
+// enqueued change and retries whatever is still pending.
+//
+// Each request body is the intent as RFC 8785 canonical JSON. When the
+// endpoint has a secret, the request carries
+//
+//	X-Yanzi-Timestamp: <unix seconds>
+//	X-Yanzi-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// which receivers check with Verify.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/export"
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+// Request headers set on every delivery.
+const (
+	HeaderDelivery  = "X-Yanzi-Delivery"
+	HeaderTimestamp = "X-Yanzi-Timestamp"
+	HeaderSignature = "X-Yanzi-Signature"
+)
+
+// Defaults used unless overridden with options.
+const (
+	DefaultMaxAttempts  = 8
+	DefaultBaseBackoff  = time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultPollInterval = time.Second
+	DefaultTimeout      = 10 * time.Second
+)
+
+// deliveryBatchSize bounds how many due deliveries one pass sends.
+const deliveryBatchSize = 100
+
+// Endpoint is a URL that receives every new intent.
+type Endpoint struct {
+	URL string
+	// Secret signs requests; an empty secret sends them unsigned.
+	Secret []byte
+}
+
+// Option configures a Dispatcher.
+type Option func(*Dispatcher)
+
+// WithHTTPClient sets the client used to send deliveries.
+func WithHTTPClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		if c != nil {
+			d.client = c
+		}
+	}
+}
+
+// WithMaxAttempts sets how many times a delivery is tried before it is marked
+// failed; values <= 0 keep the default.
+func WithMaxAttempts(n int) Option {
+	return func(d *Dispatcher) {
+		if n > 0 {
+			d.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the delay after the first failed attempt, doubled after
+// each further failure up to max. Values <= 0 keep the defaults.
+func WithBackoff(base, max time.Duration) Option {
+	return func(d *Dispatcher) {
+		if base > 0 {
+			d.baseBackoff = base
+		}
+		if max > 0 {
+			d.maxBackoff = max
+		}
+	}
+}
+
+// WithPollInterval sets how often Run looks for due deliveries.
+func WithPollInterval(interval time.Duration) Option {
+	return func(d *Dispatcher) {
+		if interval > 0 {
+			d.pollInterval = interval
+		}
+	}
+}
+
+// WithLogger logs failed and abandoned deliveries to l.
+func WithLogger(l *slog.Logger) Option {
+	return func(d *Dispatcher) {
+		if l != nil {
+			d.logger = l
+		}
+	}
+}
+
+// Dispatcher delivers new intents from one store to a set of endpoints.
+type Dispatcher struct {
+	store        *store.Store
+	endpoints    map[string]Endpoint
+	urls         []string
+	client       *http.Client
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+	now          func() time.Time
+	logger       *slog.Logger
+}
+
+// New returns a Dispatcher for s. Endpoints are keyed by URL; a later endpoint
+// with the same URL replaces an earlier one.
+func New(s *store.Store, endpoints []Endpoint, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		store:        s,
+		endpoints:    make(map[string]Endpoint, len(endpoints)),
+		client:       &http.Client{Timeout: DefaultTimeout},
+		maxAttempts:  DefaultMaxAttempts,
+		baseBackoff:  DefaultBaseBackoff,
+		maxBackoff:   DefaultMaxBackoff,
+		pollInterval: DefaultPollInterval,
+		now:          time.Now,
+		logger:       slog.New(slog.DiscardHandler),
+	}
+	for _, ep := range endpoints {
+		if _, ok := d.endpoints[ep.URL]; !ok {
+			d.urls = append(d.urls, ep.URL)
+		}
+		d.endpoints[ep.URL] = ep
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Run enqueues a delivery to every endpoint for each intent created while it
+// runs and sends due deliveries every poll interval, until ctx is cancelled.
+// On the first run it starts with intents created after the call; later runs
+// resume after the last enqueued intent. Delivery failures are recorded on
+// the delivery rather than returned.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	seq, err := d.store.LastWebhookSeq(ctx)
+	if err != nil {
+		return fmt.Errorf("read webhook position: %w", err)
+	}
+	var events <-chan store.IntentEvent
+	if seq == 0 {
+		events, err = d.store.Watch(ctx)
+	} else {
+		events, err = d.store.WatchSince(ctx, seq)
+	}
+	if err != nil {
+		return fmt.Errorf("watch intents: %w", err)
+	}
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if event.Kind != store.EventCreated {
+				continue
+			}
+			if err := d.Enqueue(ctx, event.IntentID, event.Seq); err != nil && ctx.Err() == nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := d.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
+
+// Enqueue records a pending delivery of intentID to every endpoint in one
+// transaction. seq is the changelog sequence of its creation, from which Run
+// resumes.
+func (d *Dispatcher) Enqueue(ctx context.Context, intentID string, seq int64) error {
+	if err := d.store.EnqueueWebhookDeliveries(ctx, d.urls, intentID, seq); err != nil {
+		return fmt.Errorf("enqueue delivery of %s: %w", intentID, err)
+	}
+	return nil
+}
+
+// DeliverDue makes one attempt at every delivery that is due and records the
+// outcome. It returns an error only if the store fails.
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	due, err := d.store.DueWebhookDeliveries(ctx, d.now(), deliveryBatchSize)
+	if err != nil {
+		return fmt.Errorf("list due deliveries: %w", err)
+	}
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := d.attempt(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt sends delivery once and stores the result.
+func (d *Dispatcher) attempt(ctx context.Context, delivery store.WebhookDelivery) error {
+	delivery.Attempts++
+	status, sendErr := d.send(ctx, delivery)
+	if sendErr != nil && ctx.Err() != nil {
+		// Shutting down is not the endpoint's fault; leave the attempt unspent.
+		return ctx.Err()
+	}
+
+	now := d.now().UTC()
+	delivery.LastStatusCode = status
+	switch {
+	case sendErr == nil:
+		delivery.Status = store.DeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = now.Format(time.RFC3339Nano)
+	case errors.Is(sendErr, errPermanent) || delivery.Attempts >= d.maxAttempts:
+		delivery.Status = store.DeliveryFailed
+		delivery.LastError = sendErr.Error()
+		d.logger.Error("webhook delivery failed", "delivery", delivery.ID, "url", delivery.URL, "attempts", delivery.Attempts, "error", sendErr)
+	default:
+		delay := d.backoff(delivery.Attempts)
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(delay).Format(time.RFC3339Nano)
+		d.logger.Warn("webhook delivery will be retried", "delivery", delivery.ID, "url", delivery.URL, "attempts", delivery.Attempts, "retry_in", delay, "error", sendErr)
+	}
+	if err := d.store.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("record delivery %d: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+// errPermanent marks failures that retrying cannot fix.
+var errPermanent = errors.New("permanent failure")
+
+// send POSTs the delivery's intent and returns the response status.
+func (d *Dispatcher) send(ctx context.Context, delivery store.WebhookDelivery) (int, error) {
+	ep, ok := d.endpoints[delivery.URL]
+	if !ok {
+		return 0, fmt.Errorf("%w: endpoint no longer configured", errPermanent)
+	}
+	record, err := d.store.GetIntent(ctx, delivery.IntentID)
+	if err != nil {
+		return 0, fmt.Errorf("load intent: %w", err)
+	}
+	body, err := Payload(record)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errPermanent, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	if len(ep.Secret) > 0 {
+		timestamp := strconv.FormatInt(d.now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(ep.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.baseBackoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
+
+// Payload returns the request body for record: its RFC 8785 canonical JSON.
+func Payload(record model.IntentRecord) ([]byte, error) {
+	return export.CanonicalJSON(record)
+}
+
+// Sign returns the X-Yanzi-Signature value for body sent at timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a delivery's signature header against body and its
+// timestamp header, rejecting timestamps more than tolerance from now. A zero
+// tolerance skips the age check.
+func Verify(secret []byte, timestamp, signature string, body []byte, tolerance time.Duration) error {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return errors.New("unsupported signature scheme")
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return errors.New("signature mismatch")
+	}
+	if tolerance > 0 {
+		sec, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp: %w", err)
+		}
+		if age := time.Since(time.Unix(sec, 0)); age > tolerance || age < -tolerance {
+			return errors.New("timestamp outside tolerance")
+		}
+	}
+	return nil
+}
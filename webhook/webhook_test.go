@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chuxorg/chux-yanzi-core/model"
+	"github.com/chuxorg/chux-yanzi-core/store"
+)
+
+func openTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "yanzi.db"), store.WithWatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	if err := s.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return s
+}
+
+func appendIntent(t *testing.T, s *store.Store, prompt string) model.IntentRecord {
+	t.Helper()
+	record, err := s.AppendIntent(context.Background(), model.IntentRecord{Author: "alice", SourceType: "cli", Prompt: prompt, Response: "r"})
+	if err != nil {
+		t.Fatalf("append intent: %v", err)
+	}
+	return record
+}
+
+// receiver records verified request bodies, failing the first `failures`
+// requests with 503.
+type receiver struct {
+	t        *testing.T
+	secret   []byte
+	mu       sync.Mutex
+	failures int
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := Verify(rc.secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Hour); err != nil {
+		rc.t.Errorf("verify signature: %v", err)
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	rc.bodies = append(rc.bodies, body)
+}
+
+func (rc *receiver) received() [][]byte {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([][]byte(nil), rc.bodies...)
+}
+
+func TestDeliverDueRetriesWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	rc := &receiver{t: t, secret: []byte("k"), failures: 1}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	record := appendIntent(t, s, "p1")
+	d := New(s, []Endpoint{{URL: srv.URL, Secret: rc.secret}}, WithBackoff(time.Minute, time.Hour))
+	if err := d.Enqueue(ctx, record.ID, 1); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	// The store stamps the delivery due with its own clock; start after it.
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	if err := d.DeliverDue(ctx); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	deliveries, err := s.WebhookDeliveries(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != store.DeliveryPending || deliveries[0].Attempts != 1 || deliveries[0].LastStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected one pending retry, got %+v", deliveries)
+	}
+
+	// Not due until the backoff elapses.
+	if err := d.DeliverDue(ctx); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if got := rc.received(); len(got) != 0 {
+		t.Fatalf("expected no delivery before backoff, got %d", len(got))
+	}
+
+	now = now.Add(time.Minute)
+	if err := d.DeliverDue(ctx); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	deliveries, err = s.WebhookDeliveries(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if deliveries[0].Status != store.DeliveryDelivered || deliveries[0].Attempts != 2 || deliveries[0].DeliveredAt == "" {
+		t.Fatalf("expected delivered after retry, got %+v", deliveries[0])
+	}
+	want, err := Payload(record)
+	if err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if got := rc.received(); len(got) != 1 || string(got[0]) != string(want) {
+		t.Fatalf("expected canonical payload %s, got %q", want, got)
+	}
+}
+
+func TestDeliverDueGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	record := appendIntent(t, s, "p1")
+	d := New(s, []Endpoint{{URL: srv.URL}}, WithMaxAttempts(2), WithBackoff(time.Nanosecond, time.Nanosecond))
+	if err := d.Enqueue(ctx, record.ID, 1); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := d.DeliverDue(ctx); err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	deliveries, err := s.WebhookDeliveries(ctx, record.ID)
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != store.DeliveryFailed || deliveries[0].Attempts != 2 || deliveries[0].LastError == "" {
+		t.Fatalf("expected failed after two attempts, got %+v", deliveries)
+	}
+}
+
+func TestRunDeliversNewIntentsToEveryEndpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := openTestStore(t)
+
+	before := appendIntent(t, s, "before")
+	a := &receiver{t: t, secret: []byte("a")}
+	b := &receiver{t: t, secret: []byte("b")}
+	srvA, srvB := httptest.NewServer(a), httptest.NewServer(b)
+	defer srvA.Close()
+	defer srvB.Close()
+
+	d := New(s, []Endpoint{{URL: srvA.URL, Secret: a.secret}, {URL: srvB.URL, Secret: b.secret}}, WithPollInterval(10*time.Millisecond))
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+	// Let Run take its starting position before writing.
+	time.Sleep(50 * time.Millisecond)
+
+	record := appendIntent(t, s, "after")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(a.received()) < 1 || len(b.received()) < 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out: a=%d b=%d", len(a.received()), len(b.received()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	deliveries, err := s.WebhookDeliveries(context.Background(), record.ID)
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("expected a delivery per endpoint, got %+v", deliveries)
+	}
+	if prior, err := s.WebhookDeliveries(context.Background(), before.ID); err != nil || len(prior) != 0 {
+		t.Fatalf("expected no deliveries for intents before the first run, got %+v (%v)", prior, err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	secret := []byte("k")
+	body := []byte(`{"id":"x"}`)
+	ts := "1700000000"
+	sig := Sign(secret, ts, body)
+	if err := Verify(secret, ts, sig, body, 0); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := Verify(secret, ts, sig, []byte(`{"id":"y"}`), 0); err == nil {
+		t.Fatalf("expected tampered body to fail")
+	}
+	if err := Verify(secret, "1700000001", sig, body, 0); err == nil {
+		t.Fatalf("expected tampered timestamp to fail")
+	}
+	if err := Verify(secret, ts, sig, body, time.Minute); err == nil {
+		t.Fatalf("expected stale timestamp to fail")
+	}
+}